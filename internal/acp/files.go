@@ -0,0 +1,48 @@
+package acp
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// clientFiles are the files of a session in the buffers of the editor,
+// including the changes not saved yet. The operations the client can't do
+// work on disk.
+type clientFiles struct {
+	conn      *jsonrpc2.Conn
+	sessionID string
+	fs        fsCapabilities
+}
+
+func (f *clientFiles) ReadTextFile(ctx context.Context, path string) ([]byte, error) {
+	if !f.fs.ReadTextFile {
+		return os.ReadFile(path)
+	}
+	var result readTextFileResult
+	err := f.conn.Call(ctx, methodReadTextFile, readTextFileParams{
+		SessionID: f.sessionID,
+		Path:      path,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in the editor: %w", path, err)
+	}
+	return []byte(result.Content), nil
+}
+
+func (f *clientFiles) WriteTextFile(ctx context.Context, path string, data []byte) error {
+	if !f.fs.WriteTextFile {
+		return os.WriteFile(path, data, 0o644)
+	}
+	err := f.conn.Call(ctx, methodWriteTextFile, writeTextFileParams{
+		SessionID: f.sessionID,
+		Path:      path,
+		Content:   string(data),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to write %s in the editor: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package acp
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// Options of the permission requests.
+const (
+	optionAllow        = "allow"
+	optionAllowSession = "allow_session"
+	optionReject       = "reject"
+)
+
+var permissionOptions = []permissionOption{
+	{OptionID: optionAllow, Name: "Allow", Kind: "allow_once"},
+	{OptionID: optionAllowSession, Name: "Allow for this session", Kind: "allow_always"},
+	{OptionID: optionReject, Name: "Deny", Kind: "reject_once"},
+}
+
+// requestPermission answers a permission request of the workspace by
+// asking the client of the session it comes from.
+func (s *Server) requestPermission(ctx context.Context, req permission.PermissionRequest) {
+	ss, ok := s.owner(ctx, req.SessionID)
+	if !ok {
+		slog.Warn("Denied permission request of unknown session", "session_id", req.SessionID, "tool", req.ToolName)
+		s.ws.PermissionDeny(req)
+		return
+	}
+
+	var result requestPermissionResult
+	err := ss.conn.Call(ctx, methodRequestPermission, requestPermissionParams{
+		SessionID: ss.id,
+		ToolCall: toolCall{
+			ToolCallID: req.ToolCallID,
+			Title:      permissionTitle(req),
+			Kind:       kindOf(req.ToolName),
+			Status:     statusPending,
+			RawInput:   req.Params,
+		},
+		Options: permissionOptions,
+	}, &result)
+	switch {
+	case err != nil:
+		slog.Error("Failed to ask the ACP client for permission", "tool", req.ToolName, "error", err)
+		s.ws.PermissionDeny(req)
+	case result.Outcome.Outcome != "selected":
+		// The prompt was cancelled.
+		s.ws.PermissionDeny(req)
+	case result.Outcome.OptionID == optionAllowSession:
+		s.ws.PermissionGrantPersistent(req)
+	case result.Outcome.OptionID == optionAllow:
+		s.ws.PermissionGrant(req)
+	default:
+		s.ws.PermissionDeny(req)
+	}
+}
+
+// permissionTitle describes req to the user of the client.
+func permissionTitle(req permission.PermissionRequest) string {
+	if req.Description != "" {
+		return req.Description
+	}
+	title := req.ToolName
+	if req.Action != "" {
+		title += ": " + req.Action
+	}
+	if req.Path != "" {
+		title += " in " + req.Path
+	}
+	return title
+}
@@ -0,0 +1,67 @@
+package acp
+
+import (
+	"cmp"
+	"encoding/base64"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// promptOf returns the prompt and the attachments of the content blocks
+// of an ACP prompt. Links to resources are left to the agent to read.
+func promptOf(blocks []contentBlock) (string, []message.Attachment) {
+	var (
+		sb          strings.Builder
+		attachments []message.Attachment
+	)
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			sb.WriteString(block.Text)
+		case "resource_link":
+			sb.WriteString(pathOf(block.URI))
+		case "image":
+			data, err := base64.StdEncoding.DecodeString(block.Data)
+			if err != nil {
+				slog.Warn("Ignored invalid image of ACP prompt", "error", err)
+				continue
+			}
+			name := "image"
+			if block.URI != "" {
+				name = filepath.Base(pathOf(block.URI))
+			}
+			attachments = append(attachments, message.Attachment{
+				FileName: name,
+				MimeType: block.MimeType,
+				Content:  data,
+			})
+		case "resource":
+			if block.Resource == nil || block.Resource.Text == "" {
+				continue
+			}
+			path := pathOf(block.Resource.URI)
+			attachments = append(attachments, message.Attachment{
+				FilePath: path,
+				FileName: filepath.Base(path),
+				MimeType: cmp.Or(block.Resource.MimeType, "text/plain"),
+				Content:  []byte(block.Resource.Text),
+			})
+		default:
+			slog.Warn("Ignored unsupported content of ACP prompt", "type", block.Type)
+		}
+	}
+	return sb.String(), attachments
+}
+
+// pathOf returns the path of a file URI, or uri itself for other URIs.
+func pathOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
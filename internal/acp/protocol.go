@@ -0,0 +1,276 @@
+package acp
+
+import "encoding/json"
+
+// ProtocolVersion is the version of the Agent Client Protocol the server
+// speaks.
+const ProtocolVersion = 1
+
+// Methods of the agent, called by the client.
+const (
+	methodInitialize    = "initialize"
+	methodAuthenticate  = "authenticate"
+	methodSessionNew    = "session/new"
+	methodSessionLoad   = "session/load"
+	methodSessionPrompt = "session/prompt"
+	methodSessionCancel = "session/cancel"
+)
+
+// Methods of the client, called by the agent.
+const (
+	methodSessionUpdate     = "session/update"
+	methodRequestPermission = "session/request_permission"
+	methodReadTextFile      = "fs/read_text_file"
+	methodWriteTextFile     = "fs/write_text_file"
+)
+
+type implementation struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion    int                `json:"protocolVersion"`
+	ClientCapabilities clientCapabilities `json:"clientCapabilities"`
+	ClientInfo         *implementation    `json:"clientInfo,omitempty"`
+}
+
+type clientCapabilities struct {
+	FS       fsCapabilities `json:"fs"`
+	Terminal bool           `json:"terminal,omitempty"`
+}
+
+type fsCapabilities struct {
+	ReadTextFile  bool `json:"readTextFile"`
+	WriteTextFile bool `json:"writeTextFile"`
+}
+
+type initializeResult struct {
+	ProtocolVersion   int               `json:"protocolVersion"`
+	AgentCapabilities agentCapabilities `json:"agentCapabilities"`
+	AuthMethods       []authMethod      `json:"authMethods"`
+	AgentInfo         implementation    `json:"agentInfo"`
+}
+
+type agentCapabilities struct {
+	LoadSession        bool               `json:"loadSession"`
+	PromptCapabilities promptCapabilities `json:"promptCapabilities"`
+}
+
+type promptCapabilities struct {
+	Image           bool `json:"image"`
+	Audio           bool `json:"audio"`
+	EmbeddedContext bool `json:"embeddedContext"`
+}
+
+type authMethod struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type newSessionParams struct {
+	Cwd        string            `json:"cwd"`
+	MCPServers []json.RawMessage `json:"mcpServers"`
+}
+
+type newSessionResult struct {
+	SessionID string `json:"sessionId"`
+}
+
+type loadSessionParams struct {
+	SessionID  string            `json:"sessionId"`
+	Cwd        string            `json:"cwd"`
+	MCPServers []json.RawMessage `json:"mcpServers"`
+}
+
+type promptParams struct {
+	SessionID string         `json:"sessionId"`
+	Prompt    []contentBlock `json:"prompt"`
+}
+
+// stopReason tells the client why a prompt turn ended.
+type stopReason string
+
+const (
+	stopEndTurn         stopReason = "end_turn"
+	stopMaxTokens       stopReason = "max_tokens"
+	stopMaxTurnRequests stopReason = "max_turn_requests"
+	stopCancelled       stopReason = "cancelled"
+)
+
+type promptResult struct {
+	StopReason stopReason `json:"stopReason"`
+}
+
+type cancelParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+// contentBlock is a piece of a prompt or of a message, as text, an
+// image, a link to a resource, or an embedded resource.
+type contentBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Resource *embeddedResource `json:"resource,omitempty"`
+}
+
+type embeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+func textBlock(text string) contentBlock {
+	return contentBlock{Type: "text", Text: text}
+}
+
+type sessionNotification struct {
+	SessionID string        `json:"sessionId"`
+	Update    sessionUpdate `json:"update"`
+}
+
+// Kinds of session updates.
+const (
+	updateUserMessageChunk  = "user_message_chunk"
+	updateAgentMessageChunk = "agent_message_chunk"
+	updateAgentThoughtChunk = "agent_thought_chunk"
+	updateToolCall          = "tool_call"
+	updateToolCallUpdate    = "tool_call_update"
+)
+
+// sessionUpdate is an update of a session, either a chunk of a message or
+// a tool call and its updates. Both have content, of different types.
+type sessionUpdate struct {
+	SessionUpdate string
+	Chunk         *contentBlock
+	*toolCall
+}
+
+type chunkUpdate struct {
+	SessionUpdate string        `json:"sessionUpdate"`
+	Content       *contentBlock `json:"content"`
+}
+
+type toolCallUpdate struct {
+	SessionUpdate string `json:"sessionUpdate"`
+	*toolCall
+}
+
+func (u sessionUpdate) MarshalJSON() ([]byte, error) {
+	if u.toolCall != nil {
+		return json.Marshal(toolCallUpdate{SessionUpdate: u.SessionUpdate, toolCall: u.toolCall})
+	}
+	return json.Marshal(chunkUpdate{SessionUpdate: u.SessionUpdate, Content: u.Chunk})
+}
+
+func (u *sessionUpdate) UnmarshalJSON(data []byte) error {
+	var kind struct {
+		SessionUpdate string `json:"sessionUpdate"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return err
+	}
+	u.SessionUpdate = kind.SessionUpdate
+	switch kind.SessionUpdate {
+	case updateToolCall, updateToolCallUpdate:
+		u.toolCall = &toolCall{}
+		return json.Unmarshal(data, u.toolCall)
+	default:
+		var chunk chunkUpdate
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+		u.Chunk = chunk.Content
+		return nil
+	}
+}
+
+// toolKind is the kind of a tool call, which clients use to pick icons.
+type toolKind string
+
+const (
+	kindRead    toolKind = "read"
+	kindEdit    toolKind = "edit"
+	kindSearch  toolKind = "search"
+	kindExecute toolKind = "execute"
+	kindThink   toolKind = "think"
+	kindFetch   toolKind = "fetch"
+	kindOther   toolKind = "other"
+)
+
+type toolCallStatus string
+
+const (
+	statusPending    toolCallStatus = "pending"
+	statusInProgress toolCallStatus = "in_progress"
+	statusCompleted  toolCallStatus = "completed"
+	statusFailed     toolCallStatus = "failed"
+)
+
+// toolCall is a tool call, or the fields of one which changed.
+type toolCall struct {
+	ToolCallID string             `json:"toolCallId"`
+	Title      string             `json:"title,omitempty"`
+	Kind       toolKind           `json:"kind,omitempty"`
+	Status     toolCallStatus     `json:"status,omitempty"`
+	Content    []toolCallContent  `json:"content,omitempty"`
+	Locations  []toolCallLocation `json:"locations,omitempty"`
+	RawInput   any                `json:"rawInput,omitempty"`
+	RawOutput  any                `json:"rawOutput,omitempty"`
+}
+
+// toolCallContent is what a tool call produced, as content or as the diff
+// of a file.
+type toolCallContent struct {
+	Type    string        `json:"type"`
+	Content *contentBlock `json:"content,omitempty"`
+	Path    string        `json:"path,omitempty"`
+	OldText *string       `json:"oldText,omitempty"`
+	NewText string        `json:"newText,omitempty"`
+}
+
+type toolCallLocation struct {
+	Path string `json:"path"`
+	Line int    `json:"line,omitempty"`
+}
+
+type requestPermissionParams struct {
+	SessionID string             `json:"sessionId"`
+	ToolCall  toolCall           `json:"toolCall"`
+	Options   []permissionOption `json:"options"`
+}
+
+type permissionOption struct {
+	OptionID string `json:"optionId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+type requestPermissionResult struct {
+	Outcome struct {
+		Outcome  string `json:"outcome"`
+		OptionID string `json:"optionId,omitempty"`
+	} `json:"outcome"`
+}
+
+type readTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+}
+
+type readTextFileResult struct {
+	Content string `json:"content"`
+}
+
+type writeTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+}
@@ -0,0 +1,374 @@
+// Package acp serves the agent of Crush to editors over the Agent Client
+// Protocol, JSON-RPC over stdio.
+//
+// ACP sessions are Crush sessions. Prompts run through the agent of the
+// workspace, and the messages it streams are sent back to the client as
+// session updates: chunks of text and thoughts, and tool calls. The
+// permission requests of the tools are asked to the client. When the
+// client can read and write text files, the tools of its sessions work on
+// the buffers of the editor instead of on disk.
+package acp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/charmbracelet/crush/internal/workspace"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// Server is an ACP server for the agent of a workspace.
+type Server struct {
+	ws workspace.Workspace
+
+	mu       sync.Mutex
+	client   clientCapabilities
+	sessions map[string]*clientSession
+}
+
+// New returns a server for ws.
+func New(ws workspace.Workspace) *Server {
+	return &Server{
+		ws:       ws,
+		sessions: map[string]*clientSession{},
+	}
+}
+
+// Serve serves a single client reading from r and writing to w, such as
+// the standard input and output, until ctx is done or the client
+// disconnects.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe first so no event of the first prompt is missed.
+	events := s.ws.Events(ctx)
+	conn := jsonrpc2.NewConn(
+		ctx,
+		jsonrpc2.NewPlainObjectStream(stdio{r, w}),
+		jsonrpc2.AsyncHandler(jsonrpc2.HandlerWithError(s.handle)),
+	)
+	defer s.close()
+	go s.handleEvents(ctx, events)
+
+	select {
+	case <-ctx.Done():
+		_ = conn.Close()
+		return ctx.Err()
+	case <-conn.DisconnectNotify():
+		return nil
+	}
+}
+
+// close stops the sessions of the client, which is gone.
+func (s *Server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ss := range s.sessions {
+		tools.DeleteSessionFiles(id)
+		if ss.isPrompting() {
+			s.ws.AgentCancel(id)
+		}
+		delete(s.sessions, id)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+	switch req.Method {
+	case methodInitialize:
+		params, err := paramsOf[initializeParams](req)
+		if err != nil {
+			return nil, err
+		}
+		return s.initialize(params), nil
+	case methodAuthenticate:
+		// No authentication method is advertised, as the providers are
+		// set up in the configuration of Crush.
+		return struct{}{}, nil
+	case methodSessionNew:
+		params, err := paramsOf[newSessionParams](req)
+		if err != nil {
+			return nil, err
+		}
+		return s.newSession(ctx, conn, params)
+	case methodSessionLoad:
+		params, err := paramsOf[loadSessionParams](req)
+		if err != nil {
+			return nil, err
+		}
+		return s.loadSession(ctx, conn, params)
+	case methodSessionPrompt:
+		params, err := paramsOf[promptParams](req)
+		if err != nil {
+			return nil, err
+		}
+		return s.prompt(ctx, params)
+	case methodSessionCancel:
+		params, err := paramsOf[cancelParams](req)
+		if err != nil {
+			return nil, err
+		}
+		s.cancel(params.SessionID)
+		return nil, nil
+	default:
+		return nil, &jsonrpc2.Error{
+			Code:    jsonrpc2.CodeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %s", req.Method),
+		}
+	}
+}
+
+func (s *Server) initialize(params initializeParams) initializeResult {
+	s.mu.Lock()
+	s.client = params.ClientCapabilities
+	s.mu.Unlock()
+
+	if params.ClientInfo != nil {
+		slog.Info("ACP client connected", "name", params.ClientInfo.Name, "version", params.ClientInfo.Version)
+	}
+	return initializeResult{
+		ProtocolVersion: ProtocolVersion,
+		AgentCapabilities: agentCapabilities{
+			LoadSession: true,
+			PromptCapabilities: promptCapabilities{
+				Image:           true,
+				EmbeddedContext: true,
+			},
+		},
+		AuthMethods: []authMethod{},
+		AgentInfo: implementation{
+			Name:    "crush",
+			Title:   "Crush",
+			Version: version.Version,
+		},
+	}
+}
+
+func (s *Server) newSession(ctx context.Context, conn *jsonrpc2.Conn, params newSessionParams) (newSessionResult, error) {
+	s.checkSessionParams(params.Cwd, params.MCPServers)
+	sess, err := s.ws.CreateSession(ctx, "New Session")
+	if err != nil {
+		return newSessionResult{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	s.register(conn, sess.ID)
+	slog.Info("Created session for ACP client", "session_id", sess.ID)
+	return newSessionResult{SessionID: sess.ID}, nil
+}
+
+// loadSession replays the messages of a session to the client, to carry
+// on with it.
+func (s *Server) loadSession(ctx context.Context, conn *jsonrpc2.Conn, params loadSessionParams) (any, error) {
+	s.checkSessionParams(params.Cwd, params.MCPServers)
+	sess, err := s.ws.GetSession(ctx, params.SessionID)
+	if err != nil {
+		return nil, invalidParams(fmt.Errorf("session %q not found", params.SessionID))
+	}
+	if sess.ParentSessionID != "" {
+		return nil, invalidParams(fmt.Errorf("cannot load the child session %q", params.SessionID))
+	}
+	if ss, ok := s.session(sess.ID); ok && ss.isPrompting() {
+		return nil, fmt.Errorf("session %q is busy with a prompt", sess.ID)
+	}
+	msgs, err := s.ws.ListMessages(ctx, sess.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	ss := s.register(conn, sess.ID)
+	for _, msg := range msgs {
+		ss.sync(ctx, msg, true)
+	}
+	return struct{}{}, nil
+}
+
+// checkSessionParams warns about the parameters of new sessions Crush
+// doesn't honor.
+func (s *Server) checkSessionParams(cwd string, mcpServers []json.RawMessage) {
+	if cwd != "" && filepath.Clean(cwd) != filepath.Clean(s.ws.WorkingDir()) {
+		slog.Warn("ACP session works in the working directory of Crush instead of the one asked for",
+			"cwd", cwd, "working_dir", s.ws.WorkingDir())
+	}
+	if len(mcpServers) > 0 {
+		slog.Info("Ignored the MCP servers of the ACP client; Crush uses the ones of its configuration",
+			"count", len(mcpServers))
+	}
+}
+
+// register starts the session sessionID of the client on conn, from
+// scratch if it was started already, making the tools work on the files
+// of the editor if it can read and write them. The forms MCP servers ask
+// for are declined, as clients have no way to show them.
+func (s *Server) register(conn *jsonrpc2.Conn, sessionID string) *clientSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss := newClientSession(conn, sessionID)
+	s.sessions[sessionID] = ss
+	mcp.DeclineElicitations(sessionID)
+	if s.client.FS.ReadTextFile || s.client.FS.WriteTextFile {
+		tools.SetSessionFiles(sessionID, &clientFiles{
+			conn:      conn,
+			sessionID: sessionID,
+			fs:        s.client.FS,
+		})
+	}
+	return ss
+}
+
+func (s *Server) session(sessionID string) (*clientSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.sessions[sessionID]
+	return ss, ok
+}
+
+// owner returns the session of the client working in the Crush session
+// sessionID, or in one of its parents, as sub-agents work in sessions of
+// their own.
+func (s *Server) owner(ctx context.Context, sessionID string) (*clientSession, bool) {
+	for sessionID != "" {
+		if ss, ok := s.session(sessionID); ok {
+			return ss, true
+		}
+		sess, err := s.ws.GetSession(ctx, sessionID)
+		if err != nil {
+			return nil, false
+		}
+		sessionID = sess.ParentSessionID
+	}
+	return nil, false
+}
+
+// prompt runs a prompt through the agent and returns once its turn is
+// over, the updates of the turn having been sent to the client. It relies
+// on AgentRun returning at the end of the turn, as it does for workspaces
+// running their app in-process.
+func (s *Server) prompt(ctx context.Context, params promptParams) (promptResult, error) {
+	ss, ok := s.session(params.SessionID)
+	if !ok {
+		return promptResult{}, invalidParams(fmt.Errorf("session %q not found", params.SessionID))
+	}
+	if s.ws.AgentIsSessionBusy(ss.id) || !ss.startPrompt() {
+		return promptResult{}, fmt.Errorf("session %q is busy with another prompt", ss.id)
+	}
+	defer ss.endPrompt()
+
+	prompt, attachments := promptOf(params.Prompt)
+	runErr := s.ws.AgentRun(ctx, ss.id, prompt, attachments...)
+
+	// Catch up with the messages whose events are still on their way, or
+	// were dropped.
+	msgs, err := s.ws.ListMessages(ctx, ss.id)
+	if err != nil {
+		slog.Warn("Failed to list the messages of the ACP session", "session_id", ss.id, "error", err)
+	}
+	for _, msg := range msgs {
+		ss.sync(ctx, msg, false)
+	}
+
+	if ss.wasCancelled() || errors.Is(runErr, context.Canceled) {
+		return promptResult{StopReason: stopCancelled}, nil
+	}
+	if runErr != nil {
+		return promptResult{}, runErr
+	}
+	return stopReasonOf(msgs)
+}
+
+// stopReasonOf returns why the turn ending with msgs ended.
+func stopReasonOf(msgs []message.Message) (promptResult, error) {
+	var finish *message.Finish
+	for i := len(msgs) - 1; i >= 0 && finish == nil; i-- {
+		if msgs[i].Role == message.Assistant {
+			finish = msgs[i].FinishPart()
+		}
+	}
+	if finish == nil {
+		return promptResult{StopReason: stopEndTurn}, nil
+	}
+	switch finish.Reason {
+	case message.FinishReasonCanceled:
+		return promptResult{StopReason: stopCancelled}, nil
+	case message.FinishReasonMaxTokens:
+		return promptResult{StopReason: stopMaxTokens}, nil
+	case message.FinishReasonBudgetExceeded:
+		// The closest ACP has to a spending budget running out.
+		return promptResult{StopReason: stopMaxTurnRequests}, nil
+	case message.FinishReasonError:
+		return promptResult{}, errors.New(cmp.Or(finish.Message, "the agent failed"))
+	default:
+		return promptResult{StopReason: stopEndTurn}, nil
+	}
+}
+
+func (s *Server) cancel(sessionID string) {
+	ss, ok := s.session(sessionID)
+	if !ok {
+		return
+	}
+	ss.cancel()
+	s.ws.AgentCancel(sessionID)
+}
+
+// handleEvents forwards the events of the workspace to the client, until
+// ctx is done.
+func (s *Server) handleEvents(ctx context.Context, events <-chan tea.Msg) {
+	for ev := range events {
+		switch ev := ev.(type) {
+		case pubsub.Event[message.Message]:
+			if ev.Type == pubsub.DeletedEvent {
+				continue
+			}
+			if ss, ok := s.session(ev.Payload.SessionID); ok {
+				ss.sync(ctx, ev.Payload, false)
+			}
+		case pubsub.Event[permission.PermissionRequest]:
+			go s.requestPermission(ctx, ev.Payload)
+		}
+	}
+}
+
+func paramsOf[T any](req *jsonrpc2.Request) (T, error) {
+	var params T
+	if req.Params == nil {
+		return params, invalidParams(errors.New("missing params"))
+	}
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return params, invalidParams(err)
+	}
+	return params, nil
+}
+
+func invalidParams(err error) error {
+	return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+}
+
+// stdio is the stream of a client over a reader and a writer.
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (s stdio) Close() error {
+	var errs []error
+	if c, ok := s.Reader.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	if c, ok := s.Writer.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
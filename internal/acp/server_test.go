@@ -0,0 +1,476 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/workspace"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorkspace runs prompts with a scripted agent, which makes the tool
+// calls of calls with the real tools, then answers "Done".
+type fakeWorkspace struct {
+	workspace.Workspace
+	workingDir  string
+	sessions    session.Service
+	messages    message.Service
+	permissions permission.Service
+	tools       []fantasy.AgentTool
+	calls       []fantasy.ToolCall
+
+	mu          sync.Mutex
+	prompt      string
+	attachments []message.Attachment
+	cancel      context.CancelFunc
+}
+
+func newFakeWorkspace(t *testing.T, calls ...fantasy.ToolCall) *fakeWorkspace {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	q := db.New(conn)
+	workingDir := t.TempDir()
+	permissions := permission.NewPermissionService(workingDir, false, nil, nil, nil)
+	files := filetracker.NewService(q)
+	return &fakeWorkspace{
+		workingDir:  workingDir,
+		sessions:    session.NewService(q, conn),
+		messages:    message.NewService(q, message.WithDebounce(0)),
+		permissions: permissions,
+		tools: []fantasy.AgentTool{
+			tools.NewViewTool(nil, permissions, files, nil, workingDir, false),
+			tools.NewWriteTool(nil, nil, permissions, history.NewService(q, conn), files, workingDir),
+			fantasy.NewAgentTool("wait", "Waits until cancelled", func(ctx context.Context, _ struct{}, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+				<-ctx.Done()
+				return fantasy.ToolResponse{}, ctx.Err()
+			}),
+		},
+		calls: calls,
+	}
+}
+
+func (w *fakeWorkspace) WorkingDir() string { return w.workingDir }
+
+func (w *fakeWorkspace) CreateSession(ctx context.Context, title string) (session.Session, error) {
+	return w.sessions.Create(ctx, title)
+}
+
+func (w *fakeWorkspace) GetSession(ctx context.Context, sessionID string) (session.Session, error) {
+	return w.sessions.Get(ctx, sessionID)
+}
+
+func (w *fakeWorkspace) ListMessages(ctx context.Context, sessionID string) ([]message.Message, error) {
+	return w.messages.List(ctx, sessionID)
+}
+
+func (w *fakeWorkspace) AgentIsSessionBusy(string) bool { return false }
+
+func (w *fakeWorkspace) AgentCancel(string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *fakeWorkspace) PermissionGrant(req permission.PermissionRequest) {
+	w.permissions.Grant(req)
+}
+
+func (w *fakeWorkspace) PermissionGrantPersistent(req permission.PermissionRequest) {
+	w.permissions.GrantPersistent(req)
+}
+
+func (w *fakeWorkspace) PermissionDeny(req permission.PermissionRequest) {
+	w.permissions.Deny(req)
+}
+
+func (w *fakeWorkspace) Events(ctx context.Context) <-chan tea.Msg {
+	ch := make(chan tea.Msg)
+	msgs := w.messages.Subscribe(ctx)
+	perms := w.permissions.Subscribe(ctx)
+	go func() {
+		defer close(ch)
+		for {
+			var ev tea.Msg
+			select {
+			case <-ctx.Done():
+				return
+			case ev = <-msgs:
+			case ev = <-perms:
+			}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (w *fakeWorkspace) AgentRun(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.mu.Lock()
+	w.prompt, w.attachments, w.cancel = prompt, attachments, cancel
+	w.mu.Unlock()
+
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+	_, err := w.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: prompt}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, call := range w.calls {
+		msg, err := w.messages.Create(ctx, sessionID, message.CreateMessageParams{
+			Role:  message.Assistant,
+			Parts: []message.ContentPart{message.ReasoningContent{Thinking: "Let me see"}},
+		})
+		if err != nil {
+			return err
+		}
+		msg.AddToolCall(message.ToolCall{ID: call.ID, Name: call.Name, Input: call.Input, Finished: true})
+		msg.AddFinish(message.FinishReasonToolUse, "", "")
+		if err := w.messages.Update(ctx, msg); err != nil {
+			return err
+		}
+
+		resp, err := w.tool(call.Name).Run(ctx, call)
+		if err != nil {
+			return err
+		}
+		_, err = w.messages.Create(ctx, sessionID, message.CreateMessageParams{
+			Role: message.Tool,
+			Parts: []message.ContentPart{message.ToolResult{
+				ToolCallID: call.ID,
+				Name:       call.Name,
+				Content:    resp.Content,
+				Metadata:   resp.Metadata,
+				IsError:    resp.IsError,
+			}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = w.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: "Done"},
+			message.Finish{Reason: message.FinishReasonEndTurn},
+		},
+	})
+	return err
+}
+
+func (w *fakeWorkspace) tool(name string) fantasy.AgentTool {
+	for _, tool := range w.tools {
+		if tool.Info().Name == name {
+			return tool
+		}
+	}
+	panic("unknown tool " + name)
+}
+
+// testClient is a scripted ACP client with the files of the editor in
+// buffers.
+type testClient struct {
+	conn *jsonrpc2.Conn
+	// option answers the permission requests.
+	option string
+
+	mu          sync.Mutex
+	updates     []sessionUpdate
+	permissions []requestPermissionParams
+	buffers     map[string]string
+}
+
+// connect connects a client to a server for ws over a pair of pipes, as
+// over stdio.
+func connect(t *testing.T, ws workspace.Workspace, option string, buffers map[string]string) *testClient {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	go func() { _ = New(ws).Serve(t.Context(), serverIn, serverOut) }()
+
+	c := &testClient{option: option, buffers: buffers}
+	c.conn = jsonrpc2.NewConn(
+		t.Context(),
+		jsonrpc2.NewPlainObjectStream(stdio{clientIn, clientOut}),
+		jsonrpc2.HandlerWithError(c.handle),
+	)
+	t.Cleanup(func() { _ = c.conn.Close() })
+
+	var result initializeResult
+	c.call(t, methodInitialize, initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientCapabilities: clientCapabilities{
+			FS: fsCapabilities{ReadTextFile: true, WriteTextFile: true},
+		},
+	}, &result)
+	require.Equal(t, ProtocolVersion, result.ProtocolVersion)
+	require.True(t, result.AgentCapabilities.LoadSession)
+	return c
+}
+
+func (c *testClient) handle(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch req.Method {
+	case methodSessionUpdate:
+		var params sessionNotification
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		c.updates = append(c.updates, params.Update)
+		return nil, nil
+	case methodRequestPermission:
+		var params requestPermissionParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		c.permissions = append(c.permissions, params)
+		var result requestPermissionResult
+		result.Outcome.Outcome = "selected"
+		result.Outcome.OptionID = c.option
+		return result, nil
+	case methodReadTextFile:
+		var params readTextFileParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		content, ok := c.buffers[params.Path]
+		if !ok {
+			return nil, errors.New("no such buffer")
+		}
+		return readTextFileResult{Content: content}, nil
+	case methodWriteTextFile:
+		var params writeTextFileParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		c.buffers[params.Path] = params.Content
+		return nil, nil
+	}
+	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound}
+}
+
+func (c *testClient) call(t *testing.T, method string, params, result any) {
+	t.Helper()
+	require.NoError(t, c.conn.Call(t.Context(), method, params, result))
+}
+
+func (c *testClient) newSession(t *testing.T, cwd string) string {
+	t.Helper()
+	var result newSessionResult
+	c.call(t, methodSessionNew, newSessionParams{Cwd: cwd, MCPServers: []json.RawMessage{}}, &result)
+	require.NotEmpty(t, result.SessionID)
+	return result.SessionID
+}
+
+// updatesOf returns the updates of the given kind the client got.
+func (c *testClient) updatesOf(kind string) []sessionUpdate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var updates []sessionUpdate
+	for _, update := range c.updates {
+		if update.SessionUpdate == kind {
+			updates = append(updates, update)
+		}
+	}
+	return updates
+}
+
+func toolInput(t *testing.T, v any) string {
+	t.Helper()
+	input, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(input)
+}
+
+func TestPrompt(t *testing.T) {
+	t.Parallel()
+
+	ws := newFakeWorkspace(t)
+	viewed := filepath.Join(ws.workingDir, "main.go")
+	written := filepath.Join(ws.workingDir, "new.go")
+	require.NoError(t, os.WriteFile(viewed, []byte("on disk\n"), 0o644))
+	ws.calls = []fantasy.ToolCall{
+		{ID: "call-view", Name: tools.ViewToolName, Input: toolInput(t, map[string]any{"file_path": viewed})},
+		{ID: "call-write", Name: tools.WriteToolName, Input: toolInput(t, map[string]any{"file_path": written, "content": "package main\n"})},
+	}
+
+	c := connect(t, ws, optionAllow, map[string]string{viewed: "in the buffer\n"})
+	sessionID := c.newSession(t, ws.workingDir)
+	require.True(t, mcp.DeclinesElicitations(sessionID), "clients can't answer MCP elicitations")
+
+	var result promptResult
+	c.call(t, methodSessionPrompt, promptParams{
+		SessionID: sessionID,
+		Prompt: []contentBlock{
+			textBlock("Look at "),
+			{Type: "resource_link", URI: "file://" + filepath.ToSlash(viewed), Name: "main.go"},
+			{Type: "resource", Resource: &embeddedResource{URI: "file:///notes.md", MimeType: "text/markdown", Text: "# Notes"}},
+		},
+	}, &result)
+	require.Equal(t, stopEndTurn, result.StopReason)
+
+	t.Run("prompt", func(t *testing.T) {
+		require.Equal(t, "Look at "+viewed, ws.prompt)
+		require.Len(t, ws.attachments, 1)
+		require.Equal(t, "notes.md", ws.attachments[0].FileName)
+		require.Equal(t, "text/markdown", ws.attachments[0].MimeType)
+		require.Equal(t, "# Notes", string(ws.attachments[0].Content))
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		thoughts := c.updatesOf(updateAgentThoughtChunk)
+		require.Len(t, thoughts, 2)
+		require.Equal(t, "Let me see", thoughts[0].Chunk.Text)
+		texts := c.updatesOf(updateAgentMessageChunk)
+		require.Len(t, texts, 1)
+		require.Equal(t, "Done", texts[0].Chunk.Text)
+		require.Empty(t, c.updatesOf(updateUserMessageChunk))
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		calls := c.updatesOf(updateToolCall)
+		require.Len(t, calls, 2)
+		require.Equal(t, "call-view", calls[0].ToolCallID)
+		require.Equal(t, kindRead, calls[0].Kind)
+		require.Equal(t, statusInProgress, calls[0].Status)
+		require.Equal(t, "view: "+viewed, calls[0].Title)
+		require.Equal(t, []toolCallLocation{{Path: viewed}}, calls[0].Locations)
+		require.Equal(t, kindEdit, calls[1].Kind)
+
+		results := c.updatesOf(updateToolCallUpdate)
+		require.Len(t, results, 2)
+		require.Equal(t, "call-view", results[0].ToolCallID)
+		require.Equal(t, statusCompleted, results[0].Status)
+		require.Contains(t, results[0].Content[0].Content.Text, "in the buffer")
+		require.NotContains(t, results[0].Content[0].Content.Text, "on disk")
+		require.Equal(t, statusCompleted, results[1].Status)
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		require.Len(t, c.permissions, 1)
+		require.Equal(t, sessionID, c.permissions[0].SessionID)
+		require.Equal(t, "call-write", c.permissions[0].ToolCall.ToolCallID)
+		require.Equal(t, kindEdit, c.permissions[0].ToolCall.Kind)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		require.Equal(t, "package main\n", c.buffers[written])
+		require.NoFileExists(t, written)
+	})
+
+	t.Run("load", func(t *testing.T) {
+		c := connect(t, ws, optionAllow, map[string]string{})
+		c.call(t, methodSessionLoad, loadSessionParams{SessionID: sessionID, Cwd: ws.workingDir}, nil)
+
+		users := c.updatesOf(updateUserMessageChunk)
+		require.Len(t, users, 1)
+		require.Equal(t, "Look at "+viewed, users[0].Chunk.Text)
+		require.Len(t, c.updatesOf(updateToolCall), 2)
+		require.Len(t, c.updatesOf(updateToolCallUpdate), 2)
+		texts := c.updatesOf(updateAgentMessageChunk)
+		require.Len(t, texts, 1)
+		require.Equal(t, "Done", texts[0].Chunk.Text)
+	})
+}
+
+func TestPromptDenied(t *testing.T) {
+	t.Parallel()
+
+	ws := newFakeWorkspace(t)
+	written := filepath.Join(ws.workingDir, "new.go")
+	ws.calls = []fantasy.ToolCall{
+		{ID: "call-write", Name: tools.WriteToolName, Input: toolInput(t, map[string]any{"file_path": written, "content": "package main\n"})},
+	}
+
+	c := connect(t, ws, optionReject, map[string]string{})
+	var result promptResult
+	c.call(t, methodSessionPrompt, promptParams{
+		SessionID: c.newSession(t, ws.workingDir),
+		Prompt:    []contentBlock{textBlock("Write it")},
+	}, &result)
+	require.Equal(t, stopEndTurn, result.StopReason)
+
+	results := c.updatesOf(updateToolCallUpdate)
+	require.Len(t, results, 1)
+	require.Equal(t, statusFailed, results[0].Status)
+	require.Empty(t, c.buffers)
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	ws := newFakeWorkspace(t, fantasy.ToolCall{ID: "call-wait", Name: "wait", Input: "{}"})
+	c := connect(t, ws, optionAllow, map[string]string{})
+	sessionID := c.newSession(t, ws.workingDir)
+
+	done := make(chan promptResult, 1)
+	go func() {
+		var result promptResult
+		_ = c.conn.Call(t.Context(), methodSessionPrompt, promptParams{
+			SessionID: sessionID,
+			Prompt:    []contentBlock{textBlock("Wait")},
+		}, &result)
+		done <- result
+	}()
+	require.Eventually(t, func() bool {
+		return len(c.updatesOf(updateToolCall)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.conn.Notify(t.Context(), methodSessionCancel, cancelParams{SessionID: sessionID}))
+	select {
+	case result := <-done:
+		require.Equal(t, stopCancelled, result.StopReason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the prompt wasn't cancelled")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	c := connect(t, newFakeWorkspace(t), optionAllow, map[string]string{})
+
+	err := c.conn.Call(t.Context(), "session/set_mode", struct{}{}, nil)
+	var rpcErr *jsonrpc2.Error
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, int64(jsonrpc2.CodeMethodNotFound), rpcErr.Code)
+
+	err = c.conn.Call(t.Context(), methodSessionPrompt, promptParams{SessionID: "nope"}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, int64(jsonrpc2.CodeInvalidParams), rpcErr.Code)
+	require.Equal(t, `session "nope" not found`, rpcErr.Message)
+
+	err = c.conn.Call(t.Context(), methodSessionLoad, loadSessionParams{SessionID: "nope"}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, int64(jsonrpc2.CodeInvalidParams), rpcErr.Code)
+}
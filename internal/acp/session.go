@@ -0,0 +1,195 @@
+package acp
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// clientSession is a Crush session of the client. It keeps track of what the
+// client was told of its messages, as the events of the workspace carry
+// whole messages, and may be dropped or come late.
+type clientSession struct {
+	id   string
+	conn *jsonrpc2.Conn
+
+	mu        sync.Mutex
+	prompting bool
+	cancelled bool
+	// texts and thoughts are the lengths of the text and of the thinking
+	// of the messages sent so far.
+	texts    map[string]int
+	thoughts map[string]int
+	calls    map[string]*callState
+}
+
+// callState is what the client was told of a tool call.
+type callState struct {
+	started  bool
+	finished bool
+	done     bool
+	input    string
+}
+
+func newClientSession(conn *jsonrpc2.Conn, id string) *clientSession {
+	return &clientSession{
+		id:       id,
+		conn:     conn,
+		texts:    map[string]int{},
+		thoughts: map[string]int{},
+		calls:    map[string]*callState{},
+	}
+}
+
+// startPrompt marks the session as running a prompt, unless it already
+// is.
+func (ss *clientSession) startPrompt() bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.prompting {
+		return false
+	}
+	ss.prompting = true
+	ss.cancelled = false
+	return true
+}
+
+func (ss *clientSession) endPrompt() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.prompting = false
+}
+
+func (ss *clientSession) isPrompting() bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.prompting
+}
+
+// cancel marks the running prompt as cancelled by the client.
+func (ss *clientSession) cancel() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.prompting {
+		ss.cancelled = true
+	}
+}
+
+func (ss *clientSession) wasCancelled() bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.cancelled
+}
+
+// sync sends the client what it wasn't told yet of msg. The messages of
+// the user are only sent when replaying the session, as the client sent
+// them otherwise.
+func (ss *clientSession) sync(ctx context.Context, msg message.Message, replay bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, update := range ss.updates(msg, replay) {
+		err := ss.conn.Notify(ctx, methodSessionUpdate, sessionNotification{
+			SessionID: ss.id,
+			Update:    update,
+		})
+		if err != nil {
+			slog.Debug("Failed to send session update to the ACP client", "session_id", ss.id, "error", err)
+			return
+		}
+	}
+}
+
+func (ss *clientSession) updates(msg message.Message, replay bool) []sessionUpdate {
+	var updates []sessionUpdate
+	switch msg.Role {
+	case message.User:
+		if delta := appended(ss.texts, msg.ID, msg.Content().Text); replay && delta != "" {
+			updates = append(updates, chunk(updateUserMessageChunk, delta))
+		}
+	case message.Assistant:
+		if delta := appended(ss.thoughts, msg.ID, msg.ReasoningContent().Thinking); delta != "" {
+			updates = append(updates, chunk(updateAgentThoughtChunk, delta))
+		}
+		if delta := appended(ss.texts, msg.ID, msg.Content().Text); delta != "" {
+			updates = append(updates, chunk(updateAgentMessageChunk, delta))
+		}
+		for _, tc := range msg.ToolCalls() {
+			if update, ok := ss.toolCallUpdate(tc); ok {
+				updates = append(updates, update)
+			}
+		}
+	case message.Tool:
+		for _, tr := range msg.ToolResults() {
+			if update, ok := ss.toolResultUpdate(tr); ok {
+				updates = append(updates, update)
+			}
+		}
+	}
+	return updates
+}
+
+// toolCallUpdate returns the update telling the client of tc, if there is
+// anything new.
+func (ss *clientSession) toolCallUpdate(tc message.ToolCall) (sessionUpdate, bool) {
+	state := ss.call(tc.ID)
+	if state.done || (state.started && (state.finished || !tc.Finished)) {
+		return sessionUpdate{}, false
+	}
+	kind := updateToolCall
+	if state.started {
+		kind = updateToolCallUpdate
+	}
+	state.started = true
+	state.finished = tc.Finished
+	state.input = tc.Input
+	return sessionUpdate{SessionUpdate: kind, toolCall: toolCallOf(tc)}, true
+}
+
+// toolResultUpdate returns the update completing the tool call of tr,
+// unless the client knows already.
+func (ss *clientSession) toolResultUpdate(tr message.ToolResult) (sessionUpdate, bool) {
+	state := ss.call(tr.ToolCallID)
+	if state.done {
+		return sessionUpdate{}, false
+	}
+	call := resultOf(tr, state.input)
+	kind := updateToolCallUpdate
+	if !state.started {
+		// The events of the call were missed.
+		kind = updateToolCall
+		call.Title = tr.Name
+		call.Kind = kindOf(tr.Name)
+	}
+	state.started = true
+	state.finished = true
+	state.done = true
+	return sessionUpdate{SessionUpdate: kind, toolCall: call}, true
+}
+
+func (ss *clientSession) call(id string) *callState {
+	state, ok := ss.calls[id]
+	if !ok {
+		state = &callState{}
+		ss.calls[id] = state
+	}
+	return state
+}
+
+// appended returns what text has on top of what was sent of message id,
+// recording it as sent.
+func appended(sent map[string]int, id, text string) string {
+	n := sent[id]
+	if len(text) <= n {
+		return ""
+	}
+	sent[id] = len(text)
+	return text[n:]
+}
+
+func chunk(kind, text string) sessionUpdate {
+	block := textBlock(text)
+	return sessionUpdate{SessionUpdate: kind, Chunk: &block}
+}
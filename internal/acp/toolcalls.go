@@ -0,0 +1,140 @@
+package acp
+
+import (
+	"cmp"
+	"encoding/json"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// toolArgs are the arguments of the tools which tell what a call is about.
+type toolArgs struct {
+	FilePath string `json:"file_path"`
+	Path     string `json:"path"`
+	Command  string `json:"command"`
+	Pattern  string `json:"pattern"`
+	URL      string `json:"url"`
+	Query    string `json:"query"`
+}
+
+func argsOf(input string) toolArgs {
+	var args toolArgs
+	_ = json.Unmarshal([]byte(input), &args)
+	return args
+}
+
+// toolCallOf returns the ACP tool call of tc, running once its input is
+// complete.
+func toolCallOf(tc message.ToolCall) *toolCall {
+	call := &toolCall{
+		ToolCallID: tc.ID,
+		Title:      titleOf(tc.Name, tc.Input),
+		Kind:       kindOf(tc.Name),
+		Status:     statusPending,
+	}
+	if !tc.Finished {
+		return call
+	}
+	call.Status = statusInProgress
+	var input any
+	if json.Unmarshal([]byte(tc.Input), &input) == nil {
+		call.RawInput = input
+	}
+	args := argsOf(tc.Input)
+	if path := cmp.Or(args.FilePath, args.Path); path != "" && call.Kind != kindSearch && call.Kind != kindExecute {
+		call.Locations = []toolCallLocation{{Path: path}}
+	}
+	return call
+}
+
+// resultOf returns the update completing a tool call with input by its
+// result tr.
+func resultOf(tr message.ToolResult, input string) *toolCall {
+	call := &toolCall{
+		ToolCallID: tr.ToolCallID,
+		Status:     statusCompleted,
+	}
+	if tr.IsError {
+		call.Status = statusFailed
+	}
+	if diff, ok := diffOf(tr, input); ok {
+		call.Content = append(call.Content, diff)
+	}
+	if tr.Content != "" {
+		block := textBlock(tr.Content)
+		call.Content = append(call.Content, toolCallContent{Type: "content", Content: &block})
+	}
+	if tr.Data != "" && strings.HasPrefix(tr.MIMEType, "image/") {
+		call.Content = append(call.Content, toolCallContent{
+			Type:    "content",
+			Content: &contentBlock{Type: "image", Data: tr.Data, MimeType: tr.MIMEType},
+		})
+	}
+	return call
+}
+
+// diffOf returns the diff of the file the tool call of tr edited, if any,
+// so the client can show it.
+func diffOf(tr message.ToolResult, input string) (toolCallContent, bool) {
+	if tr.IsError || (tr.Name != tools.EditToolName && tr.Name != tools.MultiEditToolName) {
+		return toolCallContent{}, false
+	}
+	// The edit tools share the fields of their metadata holding the
+	// content of the file.
+	var metadata tools.EditResponseMetadata
+	if err := json.Unmarshal([]byte(tr.Metadata), &metadata); err != nil || metadata.NewContent == "" {
+		return toolCallContent{}, false
+	}
+	return toolCallContent{
+		Type:    "diff",
+		Path:    argsOf(input).FilePath,
+		OldText: &metadata.OldContent,
+		NewText: metadata.NewContent,
+	}, true
+}
+
+// titleOf returns a title for a call of the tool name with input, which
+// may still be streaming.
+func titleOf(name, input string) string {
+	args := argsOf(input)
+	var subject string
+	switch kindOf(name) {
+	case kindRead, kindEdit:
+		subject = cmp.Or(args.FilePath, args.Path)
+	case kindExecute:
+		subject = args.Command
+	case kindSearch:
+		subject = cmp.Or(args.Pattern, args.Query)
+	case kindFetch:
+		subject = cmp.Or(args.URL, args.Query)
+	}
+	if subject == "" {
+		return name
+	}
+	return name + ": " + subject
+}
+
+// kindOf returns the kind of the calls of the tool name.
+func kindOf(name string) toolKind {
+	switch name {
+	case tools.ViewToolName, tools.LSToolName:
+		return kindRead
+	case tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName,
+		tools.HashlineEditToolName, tools.RenameToolName:
+		return kindEdit
+	case tools.GrepToolName, tools.GlobToolName, tools.SourcegraphToolName,
+		tools.ReferencesToolName, tools.WorkspaceSymbolsToolName:
+		return kindSearch
+	case tools.BashToolName, tools.JobOutputToolName, tools.JobKillToolName:
+		return kindExecute
+	case tools.FetchToolName, tools.WebFetchToolName, tools.WebSearchToolName,
+		tools.AgenticFetchToolName, tools.DownloadToolName:
+		return kindFetch
+	case tools.TodosToolName:
+		return kindThink
+	default:
+		return kindOther
+	}
+}
@@ -38,10 +38,12 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	filehistory "github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/stringext"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	"github.com/charmbracelet/crush/internal/version"
 	"github.com/charmbracelet/x/exp/charmtone"
 )
@@ -81,6 +83,19 @@ type SessionAgentCall struct {
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
 	NonInteractive   bool
+	// Context is sent to the model along with the prompt, such as the
+	// output of hooks, and saved with it as a separate part.
+	Context string
+	// Resume carries on with a turn that failed over to another model. The
+	// prompt is already part of the session, so it isn't added again.
+	Resume bool
+	// Fallback names the model the turn moves on to if the provider keeps
+	// failing, which then finishes the assistant message with
+	// [message.FinishReasonFailover] rather than an error.
+	Fallback string
+	// Model, if set, runs the call on another model than the large model
+	// of the agent, such as the fallback a turn failed over to.
+	Model *Model
 }
 
 type SessionAgent interface {
@@ -96,6 +111,7 @@ type SessionAgent interface {
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	Sample(ctx context.Context, sessionID string, small bool, call fantasy.Call) (*fantasy.Response, string, error)
 	Model() Model
 }
 
@@ -119,6 +135,7 @@ type sessionAgent struct {
 	disableAutoSummarize bool
 	isYolo               bool
 	notify               pubsub.Publisher[notify.Notification]
+	budgets              *config.BudgetOptions
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
@@ -136,6 +153,7 @@ type SessionAgentOptions struct {
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
 	Notify               pubsub.Publisher[notify.Notification]
+	Budgets              *config.BudgetOptions
 }
 
 func NewSessionAgent(
@@ -153,6 +171,7 @@ func NewSessionAgent(
 		tools:                csync.NewSliceFrom(opts.Tools),
 		isYolo:               opts.IsYolo,
 		notify:               opts.Notify,
+		budgets:              opts.Budgets,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
@@ -180,6 +199,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
 	agentTools := a.tools.Copy()
 	largeModel := a.largeModel.Get()
+	if call.Model != nil {
+		largeModel = *call.Model
+	}
 	systemPrompt := a.systemPrompt.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
@@ -198,6 +220,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		systemPrompt += "\n\n<mcp-instructions>\n" + s + "\n</mcp-instructions>"
 	}
 
+	if wt, ok := tools.GetWorktreeFromContext(ctx); ok {
+		systemPrompt += "\n\n" + worktreeInstructions(wt)
+	}
+
 	if len(agentTools) > 0 {
 		// Add Anthropic caching to the last tool.
 		agentTools[len(agentTools)-1].SetProviderOptions(a.getCacheControlOptions())
@@ -221,6 +247,33 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, fmt.Errorf("failed to get session messages: %w", err)
 	}
 
+	// Don't call the model at all once a budget has been reached.
+	budget, err := a.checkBudgets(ctx, call.SessionID)
+	if err != nil {
+		slog.Error("Failed to check budgets", "error", err)
+	}
+	if budget.exceeded != "" {
+		if !call.Resume {
+			if _, err := a.createUserMessage(ctx, call); err != nil {
+				return nil, err
+			}
+		}
+		budgetMsg, err := a.messages.Create(ctx, call.SessionID, message.CreateMessageParams{
+			Role:     message.Assistant,
+			Parts:    []message.ContentPart{},
+			Model:    largeModel.ModelCfg.Model,
+			Provider: largeModel.ModelCfg.Provider,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, a.stopForBudget(ctx, &budgetMsg, currentSession, budget.exceeded)
+	}
+	budgetWarned := budget.warning != ""
+	if budgetWarned {
+		a.notifyBudget(currentSession, notify.TypeBudgetWarning, budget.warning)
+	}
+
 	var wg sync.WaitGroup
 	// Generate title if first message.
 	if len(msgs) == 0 {
@@ -232,14 +285,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer wg.Wait()
 
 	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
-	if err != nil {
-		return nil, err
+	if !call.Resume {
+		_, err = a.createUserMessage(ctx, call)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Add the session to the context.
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
 
+	ctx, turn := telemetry.StartTurn(ctx, call.SessionID, largeModel.ModelCfg.Provider, largeModel.ModelCfg.Model)
+
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(call.SessionID, cancel)
 
@@ -255,7 +312,13 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		}
 	}()
 
-	history, files := a.preparePrompt(msgs, largeModel.CatwalkCfg.SupportsImages, call.Attachments...)
+	history, files := a.preparePrompt(dropForeignReasoning(msgs, largeModel), largeModel.CatwalkCfg.SupportsImages, call.Attachments...)
+	prompt := message.PromptWithTextAttachments(call.Prompt, call.Attachments)
+	if call.Resume {
+		prompt = failoverPrompt
+	} else if call.Context != "" {
+		history = append(history, message.ContextMessage(call.Context))
+	}
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
@@ -263,13 +326,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	var currentAssistant *message.Message
 	var stepMessages []fantasy.Message
 	var shouldSummarize bool
+	var budgetExceeded string
 	// Don't send MaxOutputTokens if 0 — some providers (e.g. LM Studio) reject it
 	var maxOutputTokens *int64
 	if call.MaxOutputTokens > 0 {
 		maxOutputTokens = &call.MaxOutputTokens
 	}
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
+		Prompt:           prompt,
 		Files:            files,
 		Messages:         history,
 		ProviderOptions:  call.ProviderOptions,
@@ -335,9 +399,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				return callContext, prepared, err
 			}
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
+			callContext = filehistory.WithMessageID(callContext, assistantMsg.ID)
 			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, largeModel.CatwalkCfg.SupportsImages)
 			callContext = context.WithValue(callContext, tools.ModelNameContextKey, largeModel.CatwalkCfg.Name)
 			currentAssistant = &assistantMsg
+			turn.StartStep(options.StepNumber)
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
@@ -393,6 +459,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		},
 		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
 			slog.Warn("Provider request failed, retrying", providerRetryLogFields(err, delay)...)
+			turn.Retry(err, delay)
 		},
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			toolCall := message.ToolCall{
@@ -407,6 +474,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			// even if the request is canceled mid-stream
 			return a.messages.Update(ctx, *currentAssistant)
 		},
+		OnStreamFinish: func(usage fantasy.Usage, finishReason fantasy.FinishReason, _ fantasy.ProviderMetadata) error {
+			turn.ResponseFinished(usage, string(finishReason))
+			return nil
+		},
 		OnToolResult: func(result fantasy.ToolResultContent) error {
 			toolResult := a.convertToToolResult(result)
 			// Use parent ctx instead of genCtx to ensure the message is created
@@ -450,7 +521,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				return getSessionErr
 			}
 			usage, estimated := fallbackStepUsage(stepMessages, stepResult)
+			costBefore := updatedSession.Cost
 			a.updateSessionUsage(largeModel, &updatedSession, usage, a.openrouterCost(stepResult.ProviderMetadata), estimated)
+			a.recordUsage(ctx, call.SessionID, usage, updatedSession.Cost-costBefore)
 			_, sessionErr := a.sessions.Save(ctx, updatedSession)
 			if sessionErr != nil {
 				return sessionErr
@@ -483,10 +556,27 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			func(steps []fantasy.StepResult) bool {
 				return hasRepeatedToolCalls(steps, loopDetectionWindowSize, loopDetectionMaxRepeats)
 			},
+			func(_ []fantasy.StepResult) bool {
+				status, err := a.checkBudgets(ctx, call.SessionID)
+				if err != nil {
+					slog.Error("Failed to check budgets", "error", err)
+					return false
+				}
+				if status.warning != "" && !budgetWarned {
+					budgetWarned = true
+					a.notifyBudget(currentSession, notify.TypeBudgetWarning, status.warning)
+				}
+				if status.exceeded != "" {
+					budgetExceeded = status.exceeded
+					return true
+				}
+				return false
+			},
 		},
 	})
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
+	turn.End(err)
 
 	if err != nil {
 		isHyper := largeModel.ModelCfg.Provider == hyper.Name
@@ -556,6 +646,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		linkStyle := lipgloss.NewStyle().Foreground(charmtone.Guac).Underline(true)
 		if isCancelErr {
 			currentAssistant.AddFinish(message.FinishReasonCanceled, "User canceled request", "")
+		} else if call.Fallback != "" && isFailoverError(err) && errors.As(err, &providerErr) {
+			title := cmp.Or(stringext.Capitalize(providerErr.Title), defaultTitle)
+			currentAssistant.AddFinish(message.FinishReasonFailover, fmt.Sprintf("%s, switching to %s", title, call.Fallback), providerErr.Message)
 		} else if isHyper && errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusUnauthorized {
 			currentAssistant.AddFinish(message.FinishReasonError, "Unauthorized", `Please re-authenticate with Hyper. You can also run "crush auth" to re-authenticate.`)
 			if a.notify != nil {
@@ -596,6 +689,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, err
 	}
 
+	if budgetExceeded != "" && currentAssistant != nil {
+		return result, a.stopForBudget(ctx, currentAssistant, currentSession, budgetExceeded)
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
 		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
@@ -662,7 +759,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return nil
 	}
 
-	aiMsgs, _ := a.preparePrompt(msgs, largeModel.CatwalkCfg.SupportsImages)
+	aiMsgs, _ := a.preparePrompt(dropForeignReasoning(msgs, largeModel), largeModel.CatwalkCfg.SupportsImages)
 
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(sessionID, cancel)
@@ -755,7 +852,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
+	costBefore := currentSession.Cost
 	a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost, false)
+	a.recordUsage(ctx, sessionID, resp.TotalUsage, currentSession.Cost-costBefore)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
@@ -803,6 +902,9 @@ func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
 
 func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentCall) (message.Message, error) {
 	parts := []message.ContentPart{message.TextContent{Text: call.Prompt}}
+	if call.Context != "" {
+		parts = append(parts, message.ContextContent{Text: call.Context})
+	}
 	var attachmentParts []message.ContentPart
 	for _, attachment := range call.Attachments {
 		attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
@@ -1116,6 +1218,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 
 	promptTokens := resp.TotalUsage.InputTokens + resp.TotalUsage.CacheCreationTokens
 	completionTokens := resp.TotalUsage.OutputTokens
+	a.recordUsage(ctx, sessionID, resp.TotalUsage, cost)
 
 	// Atomically update only title and usage fields to avoid overriding other
 	// concurrent session updates.
@@ -1,15 +1,21 @@
 package agent
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"charm.land/fantasy"
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
+	"golang.org/x/sync/errgroup"
 )
 
 //go:embed templates/agent_tool.md
@@ -17,6 +23,7 @@ var agentToolDescription string
 
 type AgentParams struct {
 	Prompt string `json:"prompt" description:"The task for the agent to perform"`
+	Agent  string `json:"agent,omitempty" description:"The name of a specialized agent to run the task. Leave empty to use the default search agent"`
 }
 
 const (
@@ -24,27 +31,27 @@ const (
 )
 
 func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error) {
-	agentCfg, ok := c.cfg.Config().Agents[config.AgentTask]
-	if !ok {
+	if _, ok := c.cfg.Config().Agents[config.AgentTask]; !ok {
 		return nil, errors.New("task agent not configured")
 	}
-	prompt, err := taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
-	if err != nil {
-		return nil, err
-	}
-
-	agent, err := c.buildAgent(ctx, prompt, agentCfg, true)
-	if err != nil {
-		return nil, err
-	}
+	agents := &subAgents{coordinator: c, agents: make(map[string]SessionAgent)}
 	return fantasy.NewParallelAgentTool(
 		AgentToolName,
-		agentToolDescription,
+		agentToolDescription+subAgentsDescription(c.cfg.Config()),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Prompt == "" {
 				return fantasy.NewTextErrorResponse("prompt is required"), nil
 			}
 
+			agentID := cmp.Or(params.Agent, config.AgentTask)
+			agent, err := agents.get(ctx, agentID)
+			if errors.Is(err, errUnknownSubAgent) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown agent %q", params.Agent)), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to start agent %q: %s", agentID, err)), nil
+			}
+
 			sessionID := tools.GetSessionFromContext(ctx)
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, errors.New("session id missing from context")
@@ -55,14 +62,94 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				return fantasy.ToolResponse{}, errors.New("agent message id missing from context")
 			}
 
+			sessionTitle := "New Agent Session"
+			if agentID != config.AgentTask {
+				sessionTitle = c.cfg.Config().Agents[agentID].Name + " Session"
+			}
+
 			return c.runSubAgent(ctx, subAgentParams{
 				Agent:          agent,
 				SessionID:      sessionID,
 				AgentMessageID: agentMessageID,
 				ToolCallID:     call.ID,
 				Prompt:         params.Prompt,
-				SessionTitle:   "New Agent Session",
+				SessionTitle:   sessionTitle,
 			})
 		},
 	), nil
 }
+
+var errUnknownSubAgent = errors.New("unknown agent")
+
+// subAgents builds the agents the agent tool runs, the task agent and the
+// user-defined agents that can run as subagents, the first time each is
+// used rather than whenever the tools are rebuilt.
+type subAgents struct {
+	coordinator *coordinator
+
+	mu     sync.Mutex
+	agents map[string]SessionAgent
+}
+
+func (s *subAgents) get(ctx context.Context, id string) (SessionAgent, error) {
+	s.mu.Lock()
+	agent, ok := s.agents[id]
+	s.mu.Unlock()
+	if ok {
+		return agent, nil
+	}
+
+	c := s.coordinator
+	agentCfg, ok := c.cfg.Config().Agents[id]
+	if !ok || !agentCfg.CanBeSubagent() {
+		return nil, errUnknownSubAgent
+	}
+	prompt, err := agentPrompt(agentCfg, true, prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	if err != nil {
+		return nil, err
+	}
+	var ready errgroup.Group
+	agent, err = c.buildAgent(ctx, prompt, agentCfg, true, &ready)
+	if err != nil {
+		return nil, err
+	}
+	if err := ready.Wait(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another call may have built the agent in the meantime; everyone
+	// gets the one stored first.
+	if built, ok := s.agents[id]; ok {
+		return built, nil
+	}
+	s.agents[id] = agent
+	return agent, nil
+}
+
+// subAgentsDescription lists the user-defined agents the agent tool can
+// run, for the tool description.
+func subAgentsDescription(cfg *config.Config) string {
+	var ids []string
+	for id, agent := range cfg.Agents {
+		if id != config.AgentTask && agent.CanBeSubagent() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	slices.Sort(ids)
+
+	var sb strings.Builder
+	sb.WriteString("\n\nSet `agent` to hand the task to one of these specialized agents instead:\n")
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "- %s", id)
+		if desc := cfg.Agents[id].Description; desc != "" {
+			fmt.Fprintf(&sb, ": %s", desc)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/notify"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// budgetScope is spending measured against one configured budget.
+type budgetScope struct {
+	name  string
+	limit *config.Budget
+	usage session.Usage
+}
+
+// budgetStatus describes the first budget reached, if any, and the first
+// budget close to being reached.
+type budgetStatus struct {
+	exceeded string
+	warning  string
+}
+
+// checkBudgets measures the spending of a session, of today, and of the
+// whole workspace against the configured budgets.
+func (a *sessionAgent) checkBudgets(ctx context.Context, sessionID string) (budgetStatus, error) {
+	budgets := a.budgets
+	if budgets == nil {
+		return budgetStatus{}, nil
+	}
+
+	var scopes []budgetScope
+	if budgets.Session != nil {
+		usage, err := a.sessions.SessionUsage(ctx, sessionID)
+		if err != nil {
+			return budgetStatus{}, fmt.Errorf("failed to get session usage: %w", err)
+		}
+		scopes = append(scopes, budgetScope{"Session", budgets.Session, usage})
+	}
+	if budgets.Daily != nil {
+		now := time.Now()
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		usage, err := a.sessions.UsageSince(ctx, midnight)
+		if err != nil {
+			return budgetStatus{}, fmt.Errorf("failed to get daily usage: %w", err)
+		}
+		scopes = append(scopes, budgetScope{"Daily", budgets.Daily, usage})
+	}
+	if budgets.Workspace != nil {
+		usage, err := a.sessions.UsageSince(ctx, time.Unix(0, 0))
+		if err != nil {
+			return budgetStatus{}, fmt.Errorf("failed to get workspace usage: %w", err)
+		}
+		scopes = append(scopes, budgetScope{"Workspace", budgets.Workspace, usage})
+	}
+	return evaluateBudgets(scopes, budgets.WarnThreshold()), nil
+}
+
+// evaluateBudgets compares each scope's spending with its USD and token
+// limits. Zero limits are ignored.
+func evaluateBudgets(scopes []budgetScope, warnAt float64) budgetStatus {
+	var status budgetStatus
+	for _, s := range scopes {
+		if s.limit.USD > 0 {
+			ratio := s.usage.Cost / s.limit.USD
+			switch {
+			case ratio >= 1 && status.exceeded == "":
+				status.exceeded = fmt.Sprintf("%s budget of $%.2f reached ($%.2f spent).", s.name, s.limit.USD, s.usage.Cost)
+			case ratio >= warnAt && ratio < 1 && status.warning == "":
+				status.warning = fmt.Sprintf("%s budget %.0f%% used ($%.2f of $%.2f).", s.name, ratio*100, s.usage.Cost, s.limit.USD)
+			}
+		}
+		if s.limit.Tokens > 0 {
+			used := s.usage.Tokens()
+			ratio := float64(used) / float64(s.limit.Tokens)
+			switch {
+			case ratio >= 1 && status.exceeded == "":
+				status.exceeded = fmt.Sprintf("%s budget of %d tokens reached (%d used).", s.name, s.limit.Tokens, used)
+			case ratio >= warnAt && ratio < 1 && status.warning == "":
+				status.warning = fmt.Sprintf("%s budget %.0f%% used (%d of %d tokens).", s.name, ratio*100, used, s.limit.Tokens)
+			}
+		}
+	}
+	return status
+}
+
+// recordUsage logs the usage of a step so it counts towards budgets.
+func (a *sessionAgent) recordUsage(ctx context.Context, sessionID string, usage fantasy.Usage, cost float64) {
+	err := a.sessions.RecordUsage(ctx, sessionID, session.Usage{
+		PromptTokens:     usage.InputTokens + usage.CacheReadTokens + usage.CacheCreationTokens,
+		CompletionTokens: usage.OutputTokens,
+		Cost:             cost,
+	})
+	if err != nil {
+		slog.Error("Failed to record usage", "session_id", sessionID, "error", err)
+	}
+}
+
+// stopForBudget ends the turn in msg because a budget was reached and
+// returns an error wrapping [ErrBudgetExceeded].
+func (a *sessionAgent) stopForBudget(ctx context.Context, msg *message.Message, sess session.Session, details string) error {
+	msg.FinishThinking()
+	msg.AddFinish(message.FinishReasonBudgetExceeded, "Budget exceeded", details)
+	if err := a.messages.Update(ctx, *msg); err != nil {
+		return err
+	}
+	a.notifyBudget(sess, notify.TypeBudgetExceeded, details)
+	return fmt.Errorf("%w: %s", ErrBudgetExceeded, details)
+}
+
+// notifyBudget publishes a budget warning or stop for a session.
+func (a *sessionAgent) notifyBudget(sess session.Session, typ notify.Type, details string) {
+	if typ == notify.TypeBudgetWarning {
+		slog.Warn("Budget warning", "session_id", sess.ID, "details", details)
+	} else {
+		slog.Warn("Budget exceeded", "session_id", sess.ID, "details", details)
+	}
+	if a.notify == nil {
+		return
+	}
+	a.notify.Publish(pubsub.CreatedEvent, notify.Notification{
+		SessionID:    sess.ID,
+		SessionTitle: sess.Title,
+		Type:         typ,
+		Details:      details,
+	})
+}
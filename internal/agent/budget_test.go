@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateBudgets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		scopes   []budgetScope
+		exceeded string
+		warning  string
+	}{
+		{
+			name: "no scopes",
+		},
+		{
+			name: "under budget",
+			scopes: []budgetScope{
+				{"Session", &config.Budget{USD: 5}, session.Usage{Cost: 1}},
+			},
+		},
+		{
+			name: "usd warning",
+			scopes: []budgetScope{
+				{"Session", &config.Budget{USD: 5}, session.Usage{Cost: 4.25}},
+			},
+			warning: "Session budget 85% used ($4.25 of $5.00).",
+		},
+		{
+			name: "usd exceeded",
+			scopes: []budgetScope{
+				{"Daily", &config.Budget{USD: 5}, session.Usage{Cost: 5.12}},
+			},
+			exceeded: "Daily budget of $5.00 reached ($5.12 spent).",
+		},
+		{
+			name: "token warning",
+			scopes: []budgetScope{
+				{"Session", &config.Budget{Tokens: 1000}, session.Usage{PromptTokens: 700, CompletionTokens: 200}},
+			},
+			warning: "Session budget 90% used (900 of 1000 tokens).",
+		},
+		{
+			name: "token exceeded",
+			scopes: []budgetScope{
+				{"Workspace", &config.Budget{Tokens: 1000}, session.Usage{PromptTokens: 900, CompletionTokens: 200}},
+			},
+			exceeded: "Workspace budget of 1000 tokens reached (1100 used).",
+		},
+		{
+			name: "zero limits ignored",
+			scopes: []budgetScope{
+				{"Session", &config.Budget{}, session.Usage{PromptTokens: 900, Cost: 100}},
+			},
+		},
+		{
+			name: "first exceeded wins",
+			scopes: []budgetScope{
+				{"Session", &config.Budget{USD: 10}, session.Usage{Cost: 9}},
+				{"Daily", &config.Budget{USD: 5}, session.Usage{Cost: 6}},
+				{"Workspace", &config.Budget{USD: 5}, session.Usage{Cost: 7}},
+			},
+			exceeded: "Daily budget of $5.00 reached ($6.00 spent).",
+			warning:  "Session budget 90% used ($9.00 of $10.00).",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			status := evaluateBudgets(tt.scopes, 0.8)
+			require.Equal(t, tt.exceeded, status.exceeded)
+			require.Equal(t, tt.warning, status.warning)
+		})
+	}
+}
+
+func TestBudgetWarnThreshold(t *testing.T) {
+	t.Parallel()
+
+	var budgets *config.BudgetOptions
+	require.Equal(t, 0.8, budgets.WarnThreshold())
+	require.Equal(t, 0.8, (&config.BudgetOptions{}).WarnThreshold())
+	require.Equal(t, 0.5, (&config.BudgetOptions{WarnAt: 0.5}).WarnThreshold())
+}
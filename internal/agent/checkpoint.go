@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// checkpointsKept is how many of the latest checkpoints of a session are
+// kept. Older ones are pruned as new turns are checkpointed.
+const checkpointsKept = 100
+
+// newCheckpointStore returns the store of turn checkpoints, or nil unless
+// they are enabled and the working directory is in a git repository.
+func newCheckpointStore(ctx context.Context, cfg *config.ConfigStore) *checkpoint.Store {
+	opts := cfg.Config().Options
+	if opts == nil || opts.Checkpoints == nil || !*opts.Checkpoints {
+		return nil
+	}
+	s, err := checkpoint.New(ctx, cfg.WorkingDir())
+	if errors.Is(err, checkpoint.ErrNotRepository) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("Failed to set up checkpoints", "error", err)
+		return nil
+	}
+	return s
+}
+
+// checkpoint snapshots the working tree of a session at the start of a
+// turn. A failed snapshot is logged rather than keeping the turn from
+// running.
+func (c *coordinator) checkpoint(ctx context.Context, sessionID, prompt string) {
+	if c.checkpoints == nil {
+		return
+	}
+	dir := c.cfg.WorkingDir()
+	if wt, ok := tools.GetWorktreeFromContext(ctx); ok {
+		dir = wt.Path
+	}
+	cp, err := c.checkpoints.Create(ctx, sessionID, dir, prompt)
+	if err != nil {
+		slog.Warn("Failed to checkpoint working tree", "session_id", sessionID, "error", err)
+		return
+	}
+	slog.Debug("Checkpointed working tree", "session_id", sessionID, "checkpoint", cp.Number, "commit", cp.Commit)
+	if err := c.checkpoints.Prune(ctx, sessionID, checkpointsKept); err != nil {
+		slog.Warn("Failed to prune checkpoints", "session_id", sessionID, "error", err)
+	}
+}
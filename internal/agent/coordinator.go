@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
@@ -22,9 +23,12 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/log"
@@ -35,6 +39,7 @@ import (
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/crush/internal/worktree"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -53,6 +58,7 @@ import (
 var (
 	errCoderAgentNotConfigured         = errors.New("coder agent not configured")
 	errModelProviderNotConfigured      = errors.New("model provider not configured")
+	errModelNotFound                   = errors.New("model not found in provider config")
 	errLargeModelNotSelected           = errors.New("large model not selected")
 	errSmallModelNotSelected           = errors.New("small model not selected")
 	errLargeModelProviderNotConfigured = errors.New("large model provider not configured")
@@ -84,6 +90,12 @@ type Coordinator interface {
 	Summarize(context.Context, string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	// EndSessions fires SessionEnd hooks for every session that fired
+	// SessionStart hooks since startup. Called on shutdown.
+	EndSessions(ctx context.Context)
+	// ServerTools returns the built-in tools Crush exposes to other clients
+	// when it runs as an MCP server.
+	ServerTools() []fantasy.AgentTool
 }
 
 type coordinator struct {
@@ -95,15 +107,26 @@ type coordinator struct {
 	filetracker filetracker.Service
 	lspManager  *lsp.Manager
 	notify      pubsub.Publisher[notify.Notification]
+	worktrees   *worktree.Manager
+	checkpoints *checkpoint.Store
 
-	currentAgent SessionAgent
-	agents       map[string]SessionAgent
+	// The primary agent, which UpdateModels switches while other
+	// goroutines use it.
+	agentMu        sync.RWMutex
+	currentAgent   SessionAgent
+	currentAgentID string
+	agents         map[string]SessionAgent
 
 	// Skills discovery results (session-start snapshot).
 	allSkills    []*skills.Skill // Pre-filter: all discovered after dedup.
 	activeSkills []*skills.Skill // Post-filter: active skills only.
 	skillTracker *skills.Tracker
 
+	// Sessions that have fired SessionStart hooks and are owed a
+	// SessionEnd.
+	startsMu      sync.Mutex
+	sessionStarts map[string]*sessionStart
+
 	readyWg errgroup.Group
 }
 
@@ -141,29 +164,37 @@ func NewCoordinator(
 		filetracker:  filetracker,
 		lspManager:   lspManager,
 		notify:       notify,
+		worktrees:    newWorktreeManager(ctx, cfg),
+		checkpoints:  newCheckpointStore(ctx, cfg),
 		agents:       make(map[string]SessionAgent),
 		allSkills:    allSkills,
 		activeSkills: activeSkills,
 		skillTracker: skillTracker,
+
+		sessionStarts: make(map[string]*sessionStart),
 	}
 
-	agentCfg, ok := cfg.Config().Agents[config.AgentCoder]
+	agentCfg, ok := cfg.Config().PrimaryAgent()
 	if !ok {
 		return nil, errCoderAgentNotConfigured
 	}
 
-	// TODO: make this dynamic when we support multiple agents
-	prompt, err := coderPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := agentPrompt(agentCfg, false, prompt.WithWorkingDir(c.cfg.WorkingDir()))
 	if err != nil {
 		return nil, err
 	}
 
-	agent, err := c.buildAgent(ctx, prompt, agentCfg, false)
+	agent, err := c.buildAgent(ctx, prompt, agentCfg, false, &c.readyWg)
 	if err != nil {
 		return nil, err
 	}
 	c.currentAgent = agent
-	c.agents[config.AgentCoder] = agent
+	c.currentAgentID = agentCfg.ID
+	c.agents[agentCfg.ID] = agent
+
+	// MCP servers sample the models of the sessions they run tools for.
+	mcp.SetSampler(c.sample)
+	go c.watchSessions(ctx)
 	return c, nil
 }
 
@@ -178,63 +209,138 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, fmt.Errorf("failed to update models: %w", err)
 	}
 
-	model := c.currentAgent.Model()
-	maxTokens := model.CatwalkCfg.DefaultMaxTokens
-	if model.ModelCfg.MaxTokens != 0 {
-		maxTokens = model.ModelCfg.MaxTokens
-	}
-
-	if !model.CatwalkCfg.SupportsImages && attachments != nil {
-		// filter out image attachments
-		filteredAttachments := make([]message.Attachment, 0, len(attachments))
-		for _, att := range attachments {
-			if att.IsText() {
-				filteredAttachments = append(filteredAttachments, att)
-			}
-		}
-		attachments = filteredAttachments
-	}
-
+	primary := c.primaryAgent()
+	model := primary.Model()
 	providerCfg, ok := c.cfg.Config().Providers.Get(model.ModelCfg.Provider)
 	if !ok {
 		return nil, errModelProviderNotConfigured
 	}
 
-	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
-
 	if err := c.refreshTokenIfExpired(ctx, providerCfg); err != nil {
 		// NOTE(@andreynering): We don't return here because the event handling to ask the user to reauthenticate
 		// depends on the flow below. If refresh fails, proceed with the token we have.
 		slog.Error("Failed to refresh OAuth2 token. Proceeding with existing token.", "error", err)
 	}
 
-	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
-			SessionID:        sessionID,
-			Prompt:           prompt,
-			Attachments:      attachments,
-			MaxOutputTokens:  maxTokens,
-			ProviderOptions:  mergedOptions,
-			Temperature:      temp,
-			TopP:             topP,
-			TopK:             topK,
-			FrequencyPenalty: freqPenalty,
-			PresencePenalty:  presPenalty,
-		})
+	// A prompt sent while the session is busy is queued and runs as part
+	// of the current turn, whose Run call fires the Stop hooks.
+	startsTurn := !primary.IsSessionBusy(sessionID)
+	hookContext, err := c.runPromptHooks(ctx, sessionID, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = c.withWorktree(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if startsTurn {
+		c.checkpoint(ctx, sessionID, prompt)
+	}
+
+	fallbacks := c.fallbackModels(model.ModelCfg)
+	// failedOver is set once the turn runs on a fallback model, which the
+	// next turn leaves for the primary model again.
+	failedOver := false
+	run := func(resume bool) (*fantasy.AgentResult, error) {
+		call := sessionAgentCall(model, providerCfg, sessionID, prompt, attachments)
+		call.Context = hookContext
+		if failedOver {
+			call.Model = &model
+		}
+		if resume {
+			// The attachments were saved along with the prompt.
+			call.Attachments = nil
+			call.Resume = true
+		}
+		if len(fallbacks) > 0 {
+			call.Fallback = c.modelName(fallbacks[0])
+		}
+		return primary.Run(ctx, call)
+	}
+	// failover resumes the turn on the next fallback model for as long as
+	// the provider keeps failing.
+	failover := func(result *fantasy.AgentResult, err error) (*fantasy.AgentResult, error) {
+		for len(fallbacks) > 0 && ctx.Err() == nil && isFailoverError(err) {
+			next := fallbacks[0]
+			fallbacks = fallbacks[1:]
+			fallback, fallbackProviderCfg, switchErr := c.fallbackModel(ctx, next)
+			if switchErr != nil {
+				slog.Error("Failed to switch to fallback model", "provider", next.Provider, "model", next.Model, "error", switchErr)
+				return result, err
+			}
+			slog.Warn("Provider failed, switching to fallback model",
+				"session_id", sessionID,
+				"from_provider", model.ModelCfg.Provider,
+				"from_model", model.ModelCfg.Model,
+				"provider", next.Provider,
+				"model", next.Model,
+				"error", err,
+			)
+			model, providerCfg, failedOver = fallback, fallbackProviderCfg, true
+			result, err = run(true)
+		}
+		return result, err
 	}
 	beforeLoaded := c.skillTracker.LoadedNames()
-	result, originalErr := run()
+	result, originalErr := run(false)
 	logTurnSkillUsage(sessionID, prompt, c.activeSkills, c.skillTracker, beforeLoaded)
 
 	if c.isUnauthorized(originalErr) {
 		if err := c.retryAfterUnauthorized(ctx, providerCfg); err == nil {
-			return run()
+			result, originalErr = run(false)
+		}
+	}
+	result, originalErr = failover(result, originalErr)
+
+	// Stop hooks can send the agent back to work, with the hook's reason
+	// as the next prompt.
+	for continuations := 0; startsTurn && originalErr == nil && ctx.Err() == nil; continuations++ {
+		reason := c.runStopHook(ctx, sessionID, continuations)
+		if reason == "" {
+			break
 		}
+		prompt, hookContext = reason, ""
+		result, originalErr = failover(run(false))
 	}
 
 	return result, originalErr
 }
 
+// sessionAgentCall builds the call running prompt on model, with the token
+// limit and call options of the model and its provider.
+func sessionAgentCall(model Model, providerCfg config.ProviderConfig, sessionID, prompt string, attachments []message.Attachment) SessionAgentCall {
+	maxTokens := model.CatwalkCfg.DefaultMaxTokens
+	if model.ModelCfg.MaxTokens != 0 {
+		maxTokens = model.ModelCfg.MaxTokens
+	}
+
+	if !model.CatwalkCfg.SupportsImages && attachments != nil {
+		// filter out image attachments
+		filteredAttachments := make([]message.Attachment, 0, len(attachments))
+		for _, att := range attachments {
+			if att.IsText() {
+				filteredAttachments = append(filteredAttachments, att)
+			}
+		}
+		attachments = filteredAttachments
+	}
+
+	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	return SessionAgentCall{
+		SessionID:        sessionID,
+		Prompt:           prompt,
+		Attachments:      attachments,
+		MaxOutputTokens:  maxTokens,
+		ProviderOptions:  mergedOptions,
+		Temperature:      temp,
+		TopP:             topP,
+		TopK:             topK,
+		FrequencyPenalty: freqPenalty,
+		PresencePenalty:  presPenalty,
+	}
+}
+
 func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.ProviderOptions {
 	options := fantasy.ProviderOptions{}
 
@@ -431,8 +537,10 @@ func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderO
 	return modelOptions, temp, topP, topK, freqPenalty, presPenalty
 }
 
-func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent, isSubAgent bool) (SessionAgent, error) {
-	large, small, err := c.buildAgentModels(ctx, isSubAgent)
+// buildAgent builds an agent. Its system prompt and tools are built in the
+// background on ready, which must be waited on before the agent runs.
+func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent, isSubAgent bool, ready *errgroup.Group) (SessionAgent, error) {
+	large, small, err := c.buildAgentModels(ctx, agent.Model, isSubAgent)
 	if err != nil {
 		return nil, err
 	}
@@ -450,9 +558,10 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		Messages:             c.messages,
 		Tools:                nil,
 		Notify:               c.notify,
+		Budgets:              c.cfg.Config().Options.Budgets,
 	})
 
-	c.readyWg.Go(func() error {
+	ready.Go(func() error {
 		systemPrompt, err := prompt.Build(ctx, large.Model.Provider(), large.Model.Model(), c.cfg)
 		if err != nil {
 			return err
@@ -461,7 +570,7 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		return nil
 	})
 
-	c.readyWg.Go(func() error {
+	ready.Go(func() error {
 		tools, err := c.buildTools(ctx, agent, isSubAgent)
 		if err != nil {
 			return err
@@ -475,7 +584,8 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 
 func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubAgent bool) ([]fantasy.AgentTool, error) {
 	var allTools []fantasy.AgentTool
-	if slices.Contains(agent.AllowedTools, AgentToolName) {
+	// Subagents can't launch subagents of their own.
+	if !isSubAgent && slices.Contains(agent.AllowedTools, AgentToolName) {
 		agentTool, err := c.agentTool(ctx)
 		if err != nil {
 			return nil, err
@@ -502,11 +612,11 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 	hashlineMode := c.cfg.Config().Options.HashlineEdit != nil && *c.cfg.Config().Options.HashlineEdit
 	logFile := filepath.Join(c.cfg.Config().Options.DataDirectory, "logs", "crush.log")
 
-	// Build hook runner if PreToolUse hooks are configured.
-	var hookRunner *hooks.Runner
-	if preToolHooks := c.cfg.Config().Hooks[hooks.EventPreToolUse]; len(preToolHooks) > 0 {
-		hookRunner = hooks.NewRunner(preToolHooks, c.cfg.WorkingDir(), c.cfg.WorkingDir())
-	}
+	// Build hook runners if tool hooks are configured.
+	preToolRunner := c.hookRunner(hooks.EventPreToolUse)
+	postToolRunner := c.hookRunner(hooks.EventPostToolUse)
+
+	formatter := format.New(c.cfg.Config().Formatters, c.lspManager, c.cfg.WorkingDir())
 
 	allTools = append(
 		allTools,
@@ -520,12 +630,12 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 
 	if hashlineMode {
 		allTools = append(allTools,
-			tools.NewHashlineEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+			tools.NewHashlineEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
 		)
 	} else {
 		allTools = append(allTools,
-			tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-			tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+			tools.NewEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+			tools.NewMultiEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
 		)
 	}
 
@@ -534,17 +644,32 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 		tools.NewGlobTool(c.cfg.WorkingDir(), c.cfg.Config().Tools.Glob),
 		tools.NewGrepTool(c.cfg.WorkingDir(), c.cfg.Config().Tools.Grep),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Tools.Ls),
+		tools.NewRepoMapTool(c.cfg.WorkingDir(), c.cfg.Config().Options.DataDirectory),
 		tools.NewSourcegraphTool(nil),
 		tools.NewWebSearchTool(nil, c.cfg.Config().Tools.WebSearch),
 		tools.NewTodosTool(c.sessions),
 		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.skillTracker, c.cfg.WorkingDir(), hashlineMode, c.cfg.Config().Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewWriteTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
 		tools.NewNumbatTool(),
 	)
 
 	// Add LSP tools if user has configured LSPs or auto_lsp is enabled (nil or true).
 	if len(c.cfg.Config().LSP) > 0 || c.cfg.Config().Options.AutoLSP == nil || *c.cfg.Config().Options.AutoLSP {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspManager), tools.NewReferencesTool(c.lspManager), tools.NewLSPRestartTool(c.lspManager))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspManager),
+			tools.NewReferencesTool(c.lspManager),
+			tools.NewHoverTool(c.lspManager, c.cfg.WorkingDir()),
+			tools.NewLSPRestartTool(c.lspManager),
+		)
+		if lsp.SupportsRequests() {
+			allTools = append(allTools,
+				tools.NewDefinitionTool(c.lspManager, c.cfg.WorkingDir()),
+				tools.NewDocumentSymbolsTool(c.lspManager, c.cfg.WorkingDir()),
+				tools.NewWorkspaceSymbolsTool(c.lspManager, c.cfg.WorkingDir()),
+				tools.NewRenameTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+				tools.NewCodeActionTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+			)
+		}
 	}
 
 	if len(c.cfg.Config().MCP) > 0 {
@@ -555,12 +680,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 		)
 	}
 
-	var filteredTools []fantasy.AgentTool
-	for _, tool := range allTools {
-		if slices.Contains(agent.AllowedTools, tool.Info().Name) {
-			filteredTools = append(filteredTools, tool)
-		}
-	}
+	filteredTools := filterAllowedTools(allTools, agent.AllowedTools)
 
 	for _, tool := range tools.GetMCPTools(c.permissions, c.cfg, c.cfg.WorkingDir()) {
 		if agent.AllowedMCP == nil {
@@ -589,18 +709,35 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
 
+	// The permission policy applies to sub-agents too, and to the input
+	// hooks may have rewritten, so it wraps the tools inside the hooks.
+	filteredTools = wrapToolsWithPolicy(filteredTools, c.permissions, c.cfg.WorkingDir())
+
 	// Wrap tools with hook interception for the top-level agent only.
 	// Sub-agents (the `agent` task tool, `agentic_fetch`, etc.) run
 	// without hook interception to avoid firing the user's hook N times
 	// per delegated turn. The top-level invocation of the sub-agent tool
 	// itself is still wrapped from the coder's side.
-	filteredTools = wrapToolsWithHooks(filteredTools, hookRunner, isSubAgent)
+	filteredTools = wrapToolsWithHooks(filteredTools, preToolRunner, postToolRunner, isSubAgent)
+	filteredTools = wrapToolsWithTracing(filteredTools)
 
 	return filteredTools, nil
 }
 
-// TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
-func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Model, Model, error) {
+// filterAllowedTools returns the tools whose names are in allowed.
+func filterAllowedTools(tools []fantasy.AgentTool, allowed []string) []fantasy.AgentTool {
+	var filtered []fantasy.AgentTool
+	for _, tool := range tools {
+		if slices.Contains(allowed, tool.Info().Name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// buildAgentModels builds the large and small models for an agent. Agents
+// configured to use the small model get it in both slots.
+func (c *coordinator) buildAgentModels(ctx context.Context, modelType config.SelectedModelType, isSubAgent bool) (Model, Model, error) {
 	largeModelCfg, ok := c.cfg.Config().Models[config.SelectedModelTypeLarge]
 	if !ok {
 		return Model{}, Model{}, errLargeModelNotSelected
@@ -672,17 +809,22 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, err
 	}
 
-	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-			FlatRate:   largeProviderCfg.FlatRate,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-			FlatRate:   smallProviderCfg.FlatRate,
-		}, nil
+	large := Model{
+		Model:      largeModel,
+		CatwalkCfg: *largeCatwalkModel,
+		ModelCfg:   largeModelCfg,
+		FlatRate:   largeProviderCfg.FlatRate,
+	}
+	small := Model{
+		Model:      smallModel,
+		CatwalkCfg: *smallCatwalkModel,
+		ModelCfg:   smallModelCfg,
+		FlatRate:   smallProviderCfg.FlatRate,
+	}
+	if modelType == config.SelectedModelTypeSmall {
+		return small, small, nil
+	}
+	return large, small, nil
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, providerID string) (fantasy.Provider, error) {
@@ -946,60 +1088,106 @@ func isExactoSupported(modelID string) bool {
 }
 
 func (c *coordinator) Cancel(sessionID string) {
-	c.currentAgent.Cancel(sessionID)
+	c.primaryAgent().Cancel(sessionID)
 }
 
 func (c *coordinator) CancelAll() {
-	c.currentAgent.CancelAll()
+	c.primaryAgent().CancelAll()
 }
 
 func (c *coordinator) ClearQueue(sessionID string) {
-	c.currentAgent.ClearQueue(sessionID)
+	c.primaryAgent().ClearQueue(sessionID)
 }
 
 func (c *coordinator) IsBusy() bool {
-	return c.currentAgent.IsBusy()
+	return c.primaryAgent().IsBusy()
 }
 
 func (c *coordinator) IsSessionBusy(sessionID string) bool {
-	return c.currentAgent.IsSessionBusy(sessionID)
+	return c.primaryAgent().IsSessionBusy(sessionID)
 }
 
 func (c *coordinator) Model() Model {
-	return c.currentAgent.Model()
+	return c.primaryAgent().Model()
 }
 
 func (c *coordinator) UpdateModels(ctx context.Context) error {
+	agentCfg, ok := c.cfg.Config().PrimaryAgent()
+	if !ok {
+		return errCoderAgentNotConfigured
+	}
+	c.agentMu.RLock()
+	current, currentID := c.currentAgent, c.currentAgentID
+	c.agentMu.RUnlock()
+	if agentCfg.ID != currentID {
+		if !current.IsBusy() {
+			if err := c.switchAgent(ctx, agentCfg); err != nil {
+				return err
+			}
+		} else if currentCfg, ok := c.cfg.Config().Agents[currentID]; ok {
+			// Switch once the running turns are done.
+			slog.Debug("Agent busy, deferring primary agent switch", "from", currentID, "to", agentCfg.ID)
+			agentCfg = currentCfg
+		}
+	}
+	primary := c.primaryAgent()
+
 	// build the models again so we make sure we get the latest config
-	large, small, err := c.buildAgentModels(ctx, false)
+	large, small, err := c.buildAgentModels(ctx, agentCfg.Model, false)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetModels(large, small)
+	primary.SetModels(large, small)
 
-	agentCfg, ok := c.cfg.Config().Agents[config.AgentCoder]
-	if !ok {
-		return errCoderAgentNotConfigured
+	tools, err := c.buildTools(ctx, agentCfg, false)
+	if err != nil {
+		return err
 	}
+	primary.SetTools(tools)
+	return nil
+}
 
-	tools, err := c.buildTools(ctx, agentCfg, false)
+// switchAgent replaces the primary agent with a freshly built one for
+// agentCfg.
+func (c *coordinator) switchAgent(ctx context.Context, agentCfg config.Agent) error {
+	systemPrompt, err := agentPrompt(agentCfg, false, prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	if err != nil {
+		return err
+	}
+	var ready errgroup.Group
+	agent, err := c.buildAgent(ctx, systemPrompt, agentCfg, false, &ready)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetTools(tools)
+	if err := ready.Wait(); err != nil {
+		return err
+	}
+	c.agentMu.Lock()
+	defer c.agentMu.Unlock()
+	slog.Info("Switched primary agent", "from", c.currentAgentID, "to", agentCfg.ID)
+	c.currentAgent = agent
+	c.currentAgentID = agentCfg.ID
+	c.agents[agentCfg.ID] = agent
 	return nil
 }
 
+// primaryAgent returns the agent handling the user's prompts.
+func (c *coordinator) primaryAgent() SessionAgent {
+	c.agentMu.RLock()
+	defer c.agentMu.RUnlock()
+	return c.currentAgent
+}
+
 func (c *coordinator) QueuedPrompts(sessionID string) int {
-	return c.currentAgent.QueuedPrompts(sessionID)
+	return c.primaryAgent().QueuedPrompts(sessionID)
 }
 
 func (c *coordinator) QueuedPromptsList(sessionID string) []string {
-	return c.currentAgent.QueuedPromptsList(sessionID)
+	return c.primaryAgent().QueuedPromptsList(sessionID)
 }
 
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
-	providerCfg, ok := c.cfg.Config().Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
+	providerCfg, ok := c.cfg.Config().Providers.Get(c.primaryAgent().Model().ModelCfg.Provider)
 	if !ok {
 		return errModelProviderNotConfigured
 	}
@@ -1009,7 +1197,7 @@ func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
 	}
 
 	summarize := func() error {
-		return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
+		return c.primaryAgent().Summarize(ctx, sessionID, getProviderOptions(c.primaryAgent().Model(), providerCfg))
 	}
 
 	err := summarize()
@@ -1102,6 +1290,12 @@ func (c *coordinator) runSubAgent(ctx context.Context, params subAgentParams) (f
 		return fantasy.ToolResponse{}, fmt.Errorf("create session: %w", err)
 	}
 
+	// Sub-agents have no user to answer the forms of MCP servers if their
+	// parent has none.
+	if mcp.DeclinesElicitations(params.SessionID) {
+		mcp.DeclineElicitations(session.ID)
+	}
+
 	// Call session setup function if provided
 	if params.SessionSetup != nil {
 		params.SessionSetup(session.ID)
@@ -42,6 +42,10 @@ func (m *mockSessionAgent) Summarize(context.Context, string, fantasy.ProviderOp
 	return nil
 }
 
+func (m *mockSessionAgent) Sample(context.Context, string, bool, fantasy.Call) (*fantasy.Response, string, error) {
+	return nil, "", nil
+}
+
 // newTestCoordinator creates a minimal coordinator for unit testing runSubAgent.
 func newTestCoordinator(t *testing.T, env fakeEnv, providerID string, providerCfg config.ProviderConfig) *coordinator {
 	cfg, err := config.Init(env.workingDir, "", false)
@@ -422,3 +426,67 @@ func TestGetProviderOptionsReasoningEffort(t *testing.T) {
 		})
 	}
 }
+
+func TestSubAgentsDescription(t *testing.T) {
+	t.Run("no custom agents", func(t *testing.T) {
+		cfg := &config.Config{Options: &config.Options{}}
+		cfg.SetupAgents()
+		assert.Empty(t, subAgentsDescription(cfg))
+	})
+
+	t.Run("lists subagents only", func(t *testing.T) {
+		cfg := &config.Config{
+			Options: &config.Options{},
+			CustomAgents: map[string]config.Agent{
+				"reviewer": {Description: "Reviews changes.", Mode: config.AgentModeSubagent},
+				"docs":     {},
+				"planner":  {Mode: config.AgentModePrimary},
+				"retired":  {Disabled: true},
+			},
+		}
+		cfg.SetupAgents()
+		desc := subAgentsDescription(cfg)
+		assert.Contains(t, desc, "- docs\n- reviewer: Reviews changes.\n")
+		assert.NotContains(t, desc, "planner")
+		assert.NotContains(t, desc, "retired")
+		assert.NotContains(t, desc, "- task")
+	})
+}
+
+func TestSubAgentsGet(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Init(t.TempDir(), "", false)
+	require.NoError(t, err)
+	cached := &mockSessionAgent{}
+	agents := &subAgents{
+		coordinator: &coordinator{cfg: cfg},
+		agents:      map[string]SessionAgent{config.AgentTask: cached},
+	}
+
+	agent, err := agents.get(t.Context(), config.AgentTask)
+	require.NoError(t, err)
+	require.Same(t, cached, agent)
+
+	_, err = agents.get(t.Context(), "missing")
+	require.ErrorIs(t, err, errUnknownSubAgent)
+	_, err = agents.get(t.Context(), config.AgentCoder)
+	require.ErrorIs(t, err, errUnknownSubAgent)
+}
+
+func TestServerToolsLeavesOutDisabledTools(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Init(t.TempDir(), "", false)
+	require.NoError(t, err)
+	cfg.Config().Options.DisabledTools = []string{"bash"}
+	cfg.Config().SetupAgents()
+	coord := &coordinator{cfg: cfg}
+
+	var names []string
+	for _, tool := range coord.ServerTools() {
+		names = append(names, tool.Info().Name)
+	}
+	require.Contains(t, names, "view")
+	require.NotContains(t, names, "bash")
+}
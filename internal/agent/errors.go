@@ -7,4 +7,6 @@ var (
 	ErrSessionBusy      = errors.New("session is currently processing another request")
 	ErrEmptyPrompt      = errors.New("prompt is empty")
 	ErrSessionMissing   = errors.New("session id is missing")
+	ErrPromptBlocked    = errors.New("prompt blocked by hook")
+	ErrBudgetExceeded   = errors.New("budget exceeded")
 )
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openrouter"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// failoverPrompt is sent, without being saved, when a turn resumes on a
+// fallback model, so the new model picks up where the failing one stopped.
+const failoverPrompt = `<system_reminder>The previous model stopped because of a provider error and you are taking over. Carry on with the user's request from where it was left, without starting over. DO NOT mention this message to the user.</system_reminder>`
+
+// isFailoverError reports whether err means the provider is rate limited,
+// overloaded or down. Such errors surface once the retries are used up, or
+// right away when the provider says retrying won't help.
+func isFailoverError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	if providerErr.IsRetryable() {
+		return true
+	}
+	text := strings.ToLower(providerErr.Title + " " + providerErr.Message)
+	for _, s := range []string{"overloaded", "rate limit", "quota", "capacity", "unavailable"} {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackModels returns the fallbacks of a model that can be used, in
+// order. Fallbacks whose provider or model is not configured are skipped.
+func (c *coordinator) fallbackModels(model config.SelectedModel) []config.SelectedModel {
+	var fallbacks []config.SelectedModel
+	for _, fallback := range model.Fallbacks {
+		providerCfg, ok := c.cfg.Config().Providers.Get(fallback.Provider)
+		if !ok || providerCfg.Disable {
+			slog.Warn("Skipping fallback model, provider not configured", "provider", fallback.Provider, "model", fallback.Model)
+			continue
+		}
+		if c.cfg.Config().GetModel(fallback.Provider, fallback.Model) == nil {
+			slog.Warn("Skipping fallback model, model not found in provider config", "provider", fallback.Provider, "model", fallback.Model)
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return fallbacks
+}
+
+// modelName returns the display name of a model and its provider.
+func (c *coordinator) modelName(model config.SelectedModel) string {
+	name := model.Model
+	if m := c.cfg.Config().GetModel(model.Provider, model.Model); m != nil && m.Name != "" {
+		name = m.Name
+	}
+	providerName := model.Provider
+	if providerCfg, ok := c.cfg.Config().Providers.Get(model.Provider); ok && providerCfg.Name != "" {
+		providerName = providerCfg.Name
+	}
+	return fmt.Sprintf("%s via %s", name, providerName)
+}
+
+// buildModel builds a single model from its configuration.
+func (c *coordinator) buildModel(ctx context.Context, modelCfg config.SelectedModel, isSubAgent bool) (Model, config.ProviderConfig, error) {
+	providerCfg, ok := c.cfg.Config().Providers.Get(modelCfg.Provider)
+	if !ok {
+		return Model{}, config.ProviderConfig{}, errModelProviderNotConfigured
+	}
+	catwalkModel := c.cfg.Config().GetModel(modelCfg.Provider, modelCfg.Model)
+	if catwalkModel == nil {
+		return Model{}, config.ProviderConfig{}, errModelNotFound
+	}
+	provider, err := c.buildProvider(providerCfg, modelCfg, isSubAgent)
+	if err != nil {
+		return Model{}, config.ProviderConfig{}, err
+	}
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
+	}
+	languageModel, err := provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return Model{}, config.ProviderConfig{}, err
+	}
+	return Model{
+		Model:      languageModel,
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+		FlatRate:   providerCfg.FlatRate,
+	}, providerCfg, nil
+}
+
+// fallbackModel builds the model a turn fails over to. It only runs the
+// rest of that turn, leaving the models of the agent, which other sessions
+// use, alone.
+func (c *coordinator) fallbackModel(ctx context.Context, fallback config.SelectedModel) (Model, config.ProviderConfig, error) {
+	if providerCfg, ok := c.cfg.Config().Providers.Get(fallback.Provider); ok {
+		if err := c.refreshTokenIfExpired(ctx, providerCfg); err != nil {
+			slog.Error("Failed to refresh OAuth2 token of fallback provider. Proceeding with existing token.", "provider", providerCfg.ID, "error", err)
+		}
+	}
+	return c.buildModel(ctx, fallback, false)
+}
+
+// dropForeignReasoning strips the provider signatures from the reasoning
+// of assistant messages generated by another model than model, which
+// would reject them. The reasoning text itself is kept.
+func dropForeignReasoning(msgs []message.Message, model Model) []message.Message {
+	out := make([]message.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = m
+		if m.Role != message.Assistant || m.Model == "" {
+			continue
+		}
+		if m.Provider == model.ModelCfg.Provider && m.Model == model.ModelCfg.Model {
+			continue
+		}
+		for j, part := range m.Parts {
+			reasoning, ok := part.(message.ReasoningContent)
+			if !ok {
+				continue
+			}
+			if reasoning.Signature == "" && reasoning.ThoughtSignature == "" && reasoning.ResponsesData == nil {
+				break
+			}
+			out[i] = m.Clone()
+			out[i].Parts[j] = message.ReasoningContent{
+				Thinking:   reasoning.Thinking,
+				StartedAt:  reasoning.StartedAt,
+				FinishedAt: reasoning.FinishedAt,
+			}
+			break
+		}
+	}
+	return out
+}
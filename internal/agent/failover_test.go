@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel is a language model that fails with err, or answers text.
+type fakeModel struct {
+	model string
+	err   error
+	text  string
+	calls []fantasy.Call
+}
+
+func (m *fakeModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.calls = append(m.calls, call)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: m.text}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *fakeModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls = append(m.calls, call)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		parts := []fantasy.StreamPart{
+			{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+			{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: m.text},
+			{Type: fantasy.StreamPartTypeTextEnd, ID: "0"},
+			{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+		}
+		for _, part := range parts {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (m *fakeModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (m *fakeModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (m *fakeModel) Provider() string { return "fake" }
+func (m *fakeModel) Model() string    { return m.model }
+
+func TestIsFailoverError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not a provider error", errors.New("boom"), false},
+		{"canceled", fmt.Errorf("run: %w", context.Canceled), false},
+		{"rate limited", &fantasy.ProviderError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &fantasy.ProviderError{StatusCode: http.StatusBadGateway}, true},
+		{"retries used up", &fantasy.RetryError{Errors: []error{&fantasy.ProviderError{StatusCode: http.StatusServiceUnavailable}}}, true},
+		{"overloaded", &fantasy.ProviderError{Title: "overloaded_error", Message: "Overloaded"}, true},
+		{"quota", &fantasy.ProviderError{StatusCode: http.StatusForbidden, Message: "You exceeded your current quota"}, true},
+		{"bad request", &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "invalid tool schema"}, false},
+		{"unauthorized", &fantasy.ProviderError{StatusCode: http.StatusUnauthorized}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, isFailoverError(tt.err))
+		})
+	}
+}
+
+func TestDropForeignReasoning(t *testing.T) {
+	t.Parallel()
+
+	signed := message.ReasoningContent{Thinking: "hmm", Signature: "sig", FinishedAt: 2}
+	msgs := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}},
+		{Role: message.Assistant, Model: "claude", Provider: "anthropic", Parts: []message.ContentPart{signed}},
+		{Role: message.Assistant, Model: "gpt", Provider: "openai", Parts: []message.ContentPart{signed}},
+		{Role: message.Assistant, Parts: []message.ContentPart{signed}},
+	}
+	model := Model{ModelCfg: config.SelectedModel{Model: "claude", Provider: "anthropic"}}
+
+	got := dropForeignReasoning(msgs, model)
+	require.Equal(t, signed, got[1].ReasoningContent())
+	require.Equal(t, message.ReasoningContent{Thinking: "hmm", FinishedAt: 2}, got[2].ReasoningContent())
+	require.Equal(t, signed, got[3].ReasoningContent(), "messages without a model are left alone")
+	require.Equal(t, signed, msgs[2].ReasoningContent(), "the original messages are not modified")
+}
+
+func TestFallbackModels(t *testing.T) {
+	env := testEnv(t)
+	coord := newTestCoordinator(t, env, "backup", config.ProviderConfig{
+		ID:     "backup",
+		Name:   "Backup",
+		Models: []catwalk.Model{{ID: "backup-large", Name: "Backup Large"}},
+	})
+	coord.cfg.Config().Providers.Set("off", config.ProviderConfig{
+		ID:      "off",
+		Disable: true,
+		Models:  []catwalk.Model{{ID: "off-large"}},
+	})
+
+	fallbacks := coord.fallbackModels(config.SelectedModel{
+		Model:    "main",
+		Provider: "main",
+		Fallbacks: []config.SelectedModel{
+			{Model: "off-large", Provider: "off"},
+			{Model: "missing", Provider: "backup"},
+			{Model: "backup-large", Provider: "unknown"},
+			{Model: "backup-large", Provider: "backup"},
+		},
+	})
+	require.Equal(t, []config.SelectedModel{{Model: "backup-large", Provider: "backup"}}, fallbacks)
+	require.Equal(t, "Backup Large via Backup", coord.modelName(fallbacks[0]))
+}
+
+func TestSessionAgentFailover(t *testing.T) {
+	env := testEnv(t)
+
+	failing := &fakeModel{
+		model: "main",
+		err:   &fantasy.ProviderError{Title: "overloaded", Message: "The provider is overloaded."},
+	}
+	small := &fakeModel{model: "small", text: "Title"}
+	agent := testSessionAgent(env, failing, small, "system")
+
+	sess, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID: sess.ID,
+		Prompt:    "hello",
+		Fallback:  "Backup via Backup",
+	})
+	require.True(t, isFailoverError(err))
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.FinishReasonFailover, msgs[1].FinishReason())
+	require.Equal(t, "Overloaded, switching to Backup via Backup", msgs[1].FinishPart().Message)
+	require.Equal(t, "The provider is overloaded.", msgs[1].FinishPart().Details)
+
+	primary := agent.Model()
+	backup := &fakeModel{model: "backup", text: "Hi there"}
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID: sess.ID,
+		Prompt:    "hello",
+		Resume:    true,
+		Model: &Model{
+			Model:      backup,
+			CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000},
+			ModelCfg:   config.SelectedModel{Model: "backup", Provider: "backup"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, primary.ModelCfg, agent.Model().ModelCfg, "the fallback only runs the failing turn")
+
+	msgs, err = env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3, "the prompt is not added again")
+	require.Equal(t, message.Assistant, msgs[2].Role)
+	require.Equal(t, "backup", msgs[2].Model)
+	require.Equal(t, "Hi there", msgs[2].Content().Text)
+
+	require.Len(t, backup.calls, 1)
+	prompt := backup.calls[0].Prompt
+	last := prompt[len(prompt)-1]
+	require.Equal(t, fantasy.MessageRoleUser, last.Role)
+	require.Equal(t, fantasy.TextPart{Text: failoverPrompt}, last.Content[0])
+
+	// The next turn is back on the primary model.
+	failing.err = nil
+	failing.text = "Back"
+	failedCalls := len(failing.calls)
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID: sess.ID,
+		Prompt:    "again",
+	})
+	require.NoError(t, err)
+	require.Len(t, backup.calls, 1)
+	require.Len(t, failing.calls, failedCalls+1)
+	msgs, err = env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Back", msgs[len(msgs)-1].Content().Text)
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/agent/tools"
@@ -14,27 +16,30 @@ import (
 )
 
 // hookedTool wraps a fantasy.AgentTool to run PreToolUse hooks before
-// delegating to the inner tool.
+// delegating to the inner tool, and PostToolUse hooks after. Either runner
+// may be nil.
 type hookedTool struct {
-	inner  fantasy.AgentTool
-	runner *hooks.Runner
+	inner fantasy.AgentTool
+	pre   *hooks.Runner
+	post  *hooks.Runner
 }
 
-func newHookedTool(inner fantasy.AgentTool, runner *hooks.Runner) *hookedTool {
-	return &hookedTool{inner: inner, runner: runner}
+func newHookedTool(inner fantasy.AgentTool, pre, post *hooks.Runner) *hookedTool {
+	return &hookedTool{inner: inner, pre: pre, post: post}
 }
 
 // wrapToolsWithHooks returns a tool slice with each entry wrapped in a
-// hookedTool. Returns the original slice unchanged when runner is nil or
-// when isSubAgent is true — sub-agents never fire hooks, the top-level
-// invocation of the sub-agent tool itself is wrapped on the caller's side.
-func wrapToolsWithHooks(tools []fantasy.AgentTool, runner *hooks.Runner, isSubAgent bool) []fantasy.AgentTool {
-	if runner == nil || isSubAgent {
+// hookedTool. Returns the original slice unchanged when both runners are
+// nil or when isSubAgent is true — sub-agents never fire hooks, the
+// top-level invocation of the sub-agent tool itself is wrapped on the
+// caller's side.
+func wrapToolsWithHooks(tools []fantasy.AgentTool, pre, post *hooks.Runner, isSubAgent bool) []fantasy.AgentTool {
+	if (pre == nil && post == nil) || isSubAgent {
 		return tools
 	}
 	out := make([]fantasy.AgentTool, len(tools))
 	for i, tool := range tools {
-		out[i] = newHookedTool(tool, runner)
+		out[i] = newHookedTool(tool, pre, post)
 	}
 	return out
 }
@@ -53,7 +58,7 @@ func (h *hookedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
 
 func (h *hookedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 	sessionID := tools.GetSessionFromContext(ctx)
-	result, err := h.runner.Run(ctx, hooks.EventPreToolUse, sessionID, call.Name, call.Input)
+	result, err := h.pre.Run(ctx, hooks.EventPreToolUse, sessionID, call.Name, call.Input)
 	if err != nil {
 		slog.Warn("Hook execution error, proceeding with tool call",
 			"tool", call.Name, "error", err)
@@ -88,17 +93,71 @@ func (h *hookedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.To
 		return resp, err
 	}
 
-	if result.Context != "" {
-		if resp.Content != "" {
-			resp.Content += "\n"
+	appendToolContent(&resp, result.Context)
+
+	post, err := h.post.RunEvent(ctx, hooks.Input{
+		Event:        hooks.EventPostToolUse,
+		SessionID:    sessionID,
+		ToolName:     call.Name,
+		ToolInput:    call.Input,
+		ToolResponse: resp.Content,
+		ToolError:    resp.IsError,
+	})
+	if err != nil {
+		slog.Warn("PostToolUse hook execution error, keeping tool result",
+			"tool", call.Name, "error", err)
+	}
+
+	// A PostToolUse deny can't undo the tool call; it flags the result as
+	// an error so the model treats it as a failure and sees the reason.
+	if post.Decision == hooks.DecisionDeny || post.Halt {
+		resp.IsError = true
+		reason := fmt.Sprintf("Tool result flagged by hook. Reason: %s", post.Reason)
+		if post.Halt {
+			reason = fmt.Sprintf("Turn halted by hook. Reason: %s", post.Reason)
+			resp.StopTurn = true
 		}
-		resp.Content += result.Context
+		appendToolContent(&resp, reason)
 	}
+	appendToolContent(&resp, post.Context)
 
-	resp.Metadata = mergeHookMetadata(resp.Metadata, result)
+	resp.Metadata = mergeHookMetadata(resp.Metadata, combineHookResults(result, post))
 	return resp, nil
 }
 
+// appendToolContent adds a line of hook output to a tool response.
+func appendToolContent(resp *fantasy.ToolResponse, text string) {
+	if text == "" {
+		return
+	}
+	if resp.Content != "" {
+		resp.Content += "\n"
+	}
+	resp.Content += text
+}
+
+// combineHookResults folds the PostToolUse result into the PreToolUse one
+// so the UI shows a single hook indicator for the tool call.
+func combineHookResults(pre, post hooks.AggregateResult) hooks.AggregateResult {
+	if post.HookCount == 0 {
+		return pre
+	}
+	if pre.HookCount == 0 {
+		return post
+	}
+	combined := pre
+	combined.HookCount += post.HookCount
+	combined.Hooks = append(slices.Clone(pre.Hooks), post.Hooks...)
+	combined.Halt = pre.Halt || post.Halt
+	if post.Decision == hooks.DecisionDeny {
+		combined.Decision = hooks.DecisionDeny
+	}
+	if post.Reason != "" {
+		combined.Reason = strings.TrimPrefix(pre.Reason+"\n"+post.Reason, "\n")
+	}
+	return combined
+}
+
 // buildHookMetadata creates a HookMetadata from an AggregateResult.
 func buildHookMetadata(result hooks.AggregateResult) hooks.HookMetadata {
 	return hooks.HookMetadata{
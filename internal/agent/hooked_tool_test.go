@@ -51,14 +51,14 @@ func TestHookedTool_AllowStampsHookApproval(t *testing.T) {
 
 	inner := &fakeTool{name: "view", resp: fantasy.NewTextResponse("ok")}
 	runner := newRunner(t, `echo '{"decision":"allow"}'`)
-	tool := newHookedTool(inner, runner)
+	tool := newHookedTool(inner, runner, nil)
 
 	_, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Name: "view"})
 	require.NoError(t, err)
 	require.True(t, inner.called, "inner tool should have run")
 
 	// The inner tool's permission service can now treat call-1 as pre-approved.
-	svc := permission.NewPermissionService(t.TempDir(), false, nil, nil)
+	svc := permission.NewPermissionService(t.TempDir(), false, nil, nil, nil)
 	granted, err := svc.Request(inner.gotCtx, permission.CreatePermissionRequest{
 		SessionID:  "s1",
 		ToolCallID: "call-1",
@@ -75,7 +75,7 @@ func TestHookedTool_SilentDoesNotStampApproval(t *testing.T) {
 
 	inner := &fakeTool{name: "view", resp: fantasy.NewTextResponse("ok")}
 	runner := newRunner(t, `exit 0`) // no stdout, no decision
-	tool := newHookedTool(inner, runner)
+	tool := newHookedTool(inner, runner, nil)
 
 	_, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-2", Name: "view"})
 	require.NoError(t, err)
@@ -85,7 +85,7 @@ func TestHookedTool_SilentDoesNotStampApproval(t *testing.T) {
 	// and must fall through to the normal flow. We verify by checking that
 	// the context does not look pre-approved for this call ID: sending a
 	// request that no subscriber resolves will block until cancelled.
-	svc := permission.NewPermissionService(t.TempDir(), false, nil, nil)
+	svc := permission.NewPermissionService(t.TempDir(), false, nil, nil, nil)
 	ctx, cancel := context.WithCancel(inner.gotCtx)
 	cancel()
 	granted, err := svc.Request(ctx, permission.CreatePermissionRequest{
@@ -104,7 +104,7 @@ func TestHookedTool_DenySkipsInnerTool(t *testing.T) {
 
 	inner := &fakeTool{name: "bash"}
 	runner := newRunner(t, `echo "blocked" >&2; exit 2`)
-	tool := newHookedTool(inner, runner)
+	tool := newHookedTool(inner, runner, nil)
 
 	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-3", Name: "bash"})
 	require.NoError(t, err)
@@ -113,6 +113,37 @@ func TestHookedTool_DenySkipsInnerTool(t *testing.T) {
 	require.Contains(t, resp.Content, "blocked")
 }
 
+func TestHookedTool_PostToolUseAddsContext(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeTool{name: "edit", resp: fantasy.NewTextResponse("edited main.go")}
+	post := newRunner(t, `echo '{"hookSpecificOutput":{"additionalContext":"remember to run gofumpt"}}'`)
+	tool := newHookedTool(inner, nil, post)
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-4", Name: "edit"})
+	require.NoError(t, err)
+	require.True(t, inner.called)
+	require.False(t, resp.IsError)
+	require.Equal(t, "edited main.go\nremember to run gofumpt", resp.Content)
+	require.Contains(t, resp.Metadata, `"hook_count":1`)
+}
+
+func TestHookedTool_PostToolUseBlockFlagsError(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeTool{name: "bash", resp: fantasy.NewTextResponse("FAIL: TestLogin")}
+	post := newRunner(t, `echo "tests failed" >&2; exit 2`)
+	tool := newHookedTool(inner, nil, post)
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-5", Name: "bash"})
+	require.NoError(t, err)
+	require.True(t, inner.called, "PostToolUse runs after the tool, it can't skip it")
+	require.True(t, resp.IsError)
+	require.False(t, resp.StopTurn)
+	require.Contains(t, resp.Content, "FAIL: TestLogin")
+	require.Contains(t, resp.Content, "tests failed")
+}
+
 func TestWrapToolsWithHooks(t *testing.T) {
 	t.Parallel()
 
@@ -121,7 +152,7 @@ func TestWrapToolsWithHooks(t *testing.T) {
 
 	t.Run("top-level agent wraps every tool", func(t *testing.T) {
 		t.Parallel()
-		out := wrapToolsWithHooks(inputs, runner, false)
+		out := wrapToolsWithHooks(inputs, runner, nil, false)
 		require.Len(t, out, len(inputs))
 		for i, tool := range out {
 			_, ok := tool.(*hookedTool)
@@ -131,7 +162,7 @@ func TestWrapToolsWithHooks(t *testing.T) {
 
 	t.Run("sub-agent skips the wrap", func(t *testing.T) {
 		t.Parallel()
-		out := wrapToolsWithHooks(inputs, runner, true)
+		out := wrapToolsWithHooks(inputs, runner, nil, true)
 		require.Equal(t, inputs, out, "sub-agent tools should be returned unwrapped")
 		for _, tool := range out {
 			_, isHooked := tool.(*hookedTool)
@@ -139,9 +170,16 @@ func TestWrapToolsWithHooks(t *testing.T) {
 		}
 	})
 
+	t.Run("post runner alone wraps", func(t *testing.T) {
+		t.Parallel()
+		out := wrapToolsWithHooks(inputs, nil, runner, false)
+		_, ok := out[0].(*hookedTool)
+		require.True(t, ok)
+	})
+
 	t.Run("nil runner skips the wrap for both agent kinds", func(t *testing.T) {
 		t.Parallel()
-		require.Equal(t, inputs, wrapToolsWithHooks(inputs, nil, false))
-		require.Equal(t, inputs, wrapToolsWithHooks(inputs, nil, true))
+		require.Equal(t, inputs, wrapToolsWithHooks(inputs, nil, nil, false))
+		require.Equal(t, inputs, wrapToolsWithHooks(inputs, nil, nil, true))
 	})
 }
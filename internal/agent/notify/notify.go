@@ -12,6 +12,12 @@ const (
 	// TypeReAuthenticate indicates the agent encountered an
 	// authentication error and the user needs to re-authenticate.
 	TypeReAuthenticate Type = "re_authenticate"
+	// TypeBudgetWarning indicates spending is close to a configured
+	// budget.
+	TypeBudgetWarning Type = "budget_warning"
+	// TypeBudgetExceeded indicates the agent stopped because a configured
+	// budget was reached.
+	TypeBudgetExceeded Type = "budget_exceeded"
 )
 
 // Notification represents a domain event published by the agent.
@@ -20,4 +26,5 @@ type Notification struct {
 	SessionTitle string
 	Type         Type
 	ProviderID   string
+	Details      string
 }
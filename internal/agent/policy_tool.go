@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// policyTool wraps a fantasy.AgentTool to apply the permission policy to
+// every call, including those of tools that never request permission,
+// such as view and grep inside the working directory.
+type policyTool struct {
+	inner       fantasy.AgentTool
+	permissions permission.Service
+	workingDir  string
+}
+
+// wrapToolsWithPolicy returns a tool slice with each entry wrapped in a
+// policyTool. Relative patterns are resolved against the session's
+// worktree when it has one, or workingDir. Returns the original slice
+// unchanged when there are no policy rules.
+func wrapToolsWithPolicy(tools []fantasy.AgentTool, permissions permission.Service, workingDir string) []fantasy.AgentTool {
+	if permissions == nil || len(permissions.Policy().Rules()) == 0 {
+		return tools
+	}
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &policyTool{inner: tool, permissions: permissions, workingDir: workingDir}
+	}
+	return out
+}
+
+func (p *policyTool) Info() fantasy.ToolInfo {
+	return p.inner.Info()
+}
+
+func (p *policyTool) ProviderOptions() fantasy.ProviderOptions {
+	return p.inner.ProviderOptions()
+}
+
+func (p *policyTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	p.inner.SetProviderOptions(opts)
+}
+
+func (p *policyTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	// Allow rules are left to the tools, which may still prompt for what
+	// isn't allowed; deny and ask rules are decided here, before the tool
+	// runs, and the tool's own request is granted afterwards.
+	workingDir := tools.WorkingDirFromContext(ctx, p.workingDir)
+	ctx = permission.WithPolicyWorkingDir(ctx, workingDir)
+	rule, ok := p.permissions.Policy().WithWorkingDir(workingDir).Evaluate(call.Name, call.Input, workingDir)
+	if !ok || rule.Decision == permission.DecisionAllow {
+		return p.inner.Run(ctx, call)
+	}
+	granted, err := p.permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:   tools.GetSessionFromContext(ctx),
+		ToolCallID:  call.ID,
+		ToolName:    call.Name,
+		Description: fmt.Sprintf("Use the %s tool", call.Name),
+		Action:      "call",
+		Params:      call.Input,
+		Path:        workingDir,
+		ReadOnly:    true,
+	})
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !granted {
+		return tools.NewPermissionDeniedResponse(), nil
+	}
+	return p.inner.Run(permission.WithPolicyApproval(ctx, call.ID), call)
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/worktree"
+	"github.com/stretchr/testify/require"
+)
+
+func newPolicyService(t *testing.T, rules ...permission.PolicyRule) permission.Service {
+	t.Helper()
+	policy, err := permission.NewPolicy("/work", rules)
+	require.NoError(t, err)
+	return permission.NewPermissionService("/work", false, nil, nil, policy)
+}
+
+func TestPolicyTool_DenyBlocksToolsThatNeverAsk(t *testing.T) {
+	t.Parallel()
+
+	svc := newPolicyService(t, permission.PolicyRule{Decision: permission.DecisionDeny, Match: "view(.env)"})
+	inner := &fakeTool{name: "view", resp: fantasy.NewTextResponse("secret")}
+	wrapped := wrapToolsWithPolicy([]fantasy.AgentTool{inner}, svc, "/work")
+
+	resp, err := wrapped[0].Run(t.Context(), fantasy.ToolCall{ID: "call-1", Name: "view", Input: `{"file_path": "/work/.env"}`})
+	require.NoError(t, err)
+	require.False(t, inner.called, "denied call must not reach the tool")
+	require.True(t, resp.IsError)
+
+	_, err = wrapped[0].Run(t.Context(), fantasy.ToolCall{ID: "call-2", Name: "view", Input: `{"file_path": "/work/main.go"}`})
+	require.NoError(t, err)
+	require.True(t, inner.called)
+}
+
+func TestPolicyTool_RelativePatternsMatchInWorktree(t *testing.T) {
+	t.Parallel()
+
+	svc := newPolicyService(t, permission.PolicyRule{Decision: permission.DecisionDeny, Match: "view(secrets/**)"})
+	inner := &fakeTool{name: "view", resp: fantasy.NewTextResponse("secret")}
+	wrapped := wrapToolsWithPolicy([]fantasy.AgentTool{inner}, svc, "/work")
+
+	ctx := context.WithValue(t.Context(), tools.WorktreeContextKey, worktree.Worktree{Path: "/worktrees/feature", Branch: "crush/feature"})
+	resp, err := wrapped[0].Run(ctx, fantasy.ToolCall{ID: "call-1", Name: "view", Input: `{"file_path": "/worktrees/feature/secrets/key"}`})
+	require.NoError(t, err)
+	require.False(t, inner.called, "denied call must not reach the tool")
+	require.True(t, resp.IsError)
+}
+
+func TestPolicyTool_AskPromptsOnce(t *testing.T) {
+	t.Parallel()
+
+	rule := permission.PolicyRule{Decision: permission.DecisionAsk, Match: "edit(*.go)"}
+	svc := newPolicyService(t, rule)
+	svc.AutoApproveSession("s1")
+	inner := &fakeTool{name: "edit", resp: fantasy.NewTextResponse("ok")}
+	wrapped := wrapToolsWithPolicy([]fantasy.AgentTool{inner}, svc, "/work")
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "s1")
+	_, err := wrapped[0].Run(ctx, fantasy.ToolCall{ID: "call-1", Name: "edit", Input: `{"file_path": "main.go"}`})
+	require.NoError(t, err)
+	require.True(t, inner.called)
+
+	// The tool's own request for the call is granted without asking again,
+	// even by a service that would otherwise prompt.
+	other := newPolicyService(t, rule)
+	granted, err := other.Request(inner.gotCtx, permission.CreatePermissionRequest{
+		SessionID:  "s1",
+		ToolCallID: "call-1",
+		ToolName:   "edit",
+		Action:     "write",
+		Params:     map[string]any{"file_path": "/work/main.go"},
+		Path:       "/work",
+	})
+	require.NoError(t, err)
+	require.True(t, granted)
+}
+
+func TestWrapToolsWithPolicy_NoRules(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeTool{name: "view"}
+	svc := permission.NewPermissionService("/work", false, nil, nil, nil)
+	wrapped := wrapToolsWithPolicy([]fantasy.AgentTool{inner}, svc, "/work")
+	require.Same(t, inner, wrapped[0])
+}
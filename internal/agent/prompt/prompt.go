@@ -15,17 +15,20 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/repomap"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/skills"
 )
 
 // Prompt represents a template-based prompt generator.
 type Prompt struct {
-	name       string
-	template   string
-	now        func() time.Time
-	platform   string
-	workingDir string
+	name        string
+	template    string
+	now         func() time.Time
+	platform    string
+	workingDir  string
+	agentPrompt string
+	repoMap     bool
 }
 
 type PromptDat struct {
@@ -40,6 +43,8 @@ type PromptDat struct {
 	ContextFiles  []ContextFile
 	AvailSkillXML string
 	HashlineEdit  bool
+	AgentPrompt   string
+	RepoMap       string
 }
 
 type ContextFile struct {
@@ -67,6 +72,22 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithAgentPrompt sets the system prompt of a user-defined agent, exposed
+// to the template as AgentPrompt.
+func WithAgentPrompt(agentPrompt string) Option {
+	return func(p *Prompt) {
+		p.agentPrompt = agentPrompt
+	}
+}
+
+// WithRepoMap adds the repository map of the working directory, exposed to
+// the template as RepoMap, unless it is disabled in the config.
+func WithRepoMap() Option {
+	return func(p *Prompt) {
+		p.repoMap = true
+	}
+}
+
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 	p := &Prompt{
 		name:     name,
@@ -211,6 +232,7 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, store *
 		Date:          p.now().Format("1/2/2006"),
 		AvailSkillXML: availSkillXML,
 		HashlineEdit:  hashlineEdit,
+		AgentPrompt:   p.agentPrompt,
 	}
 	if isGit {
 		var err error
@@ -220,12 +242,48 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, store *
 		}
 	}
 
+	if p.repoMap && cfg.Options.RepoMap.IsEnabled() {
+		data.RepoMap = buildRepoMap(ctx, workingDir, cfg)
+	}
+
 	for _, contextFiles := range files {
 		data.ContextFiles = append(data.ContextFiles, contextFiles...)
 	}
 	return data, nil
 }
 
+// repoMapTimeout is how long building the system prompt waits for the
+// repository map to update.
+const repoMapTimeout = 2 * time.Second
+
+// buildRepoMap returns the repository map of workingDir cut to the token
+// budget, or nothing if it fails to update or takes longer than
+// repoMapTimeout. A slow update carries on in the background, so the map
+// is ready for a later prompt.
+func buildRepoMap(ctx context.Context, workingDir string, cfg *config.Config) string {
+	m := repomap.For(workingDir, cfg.Options.DataDirectory)
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Update(context.WithoutCancel(ctx))
+	}()
+
+	timer := time.NewTimer(repoMapTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			slog.Warn("Failed to update the repository map", "error", err)
+			return ""
+		}
+	case <-timer.C:
+		slog.Info("Repository map not ready, leaving it out of the system prompt", "working_dir", workingDir)
+		return ""
+	case <-ctx.Done():
+		return ""
+	}
+	return m.Render(repomap.RenderOptions{MaxTokens: cfg.Options.RepoMap.TokenBudget()}).Text
+}
+
 func isGitRepo(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, ".git"))
 	return err == nil
@@ -14,6 +14,9 @@ var coderPromptTmpl []byte
 //go:embed templates/task.md.tpl
 var taskPromptTmpl []byte
 
+//go:embed templates/custom.md.tpl
+var customPromptTmpl []byte
+
 //go:embed templates/initialize.md.tpl
 var initializePromptTmpl []byte
 
@@ -33,6 +36,25 @@ func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
+// agentPrompt returns the system prompt for an agent. User-defined agents
+// with a prompt of their own get it wrapped with the environment details;
+// the rest use the coder prompt, or the task prompt when run as a
+// subagent. Only top-level agents get the repository map.
+func agentPrompt(agent config.Agent, isSubAgent bool, opts ...prompt.Option) (*prompt.Prompt, error) {
+	if !isSubAgent {
+		opts = append(opts, prompt.WithRepoMap())
+	}
+	switch {
+	case agent.Prompt != "":
+		opts = append(opts, prompt.WithAgentPrompt(agent.Prompt))
+		return prompt.NewPrompt(agent.ID, string(customPromptTmpl), opts...)
+	case isSubAgent:
+		return taskPrompt(opts...)
+	default:
+		return coderPrompt(opts...)
+	}
+}
+
 func InitializePrompt(cfg *config.ConfigStore) (string, error) {
 	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl))
 	if err != nil {
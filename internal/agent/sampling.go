@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+
+	"charm.land/fantasy"
+)
+
+// Sample generates a response to call for an MCP server sampling the model
+// on behalf of the session sessionID, with the small or the large model.
+// It returns the response along with the ID of the model.
+//
+// The usage is recorded against the budgets of the session, but isn't added
+// to the session itself, whose turn is still running.
+func (a *sessionAgent) Sample(ctx context.Context, sessionID string, small bool, call fantasy.Call) (*fantasy.Response, string, error) {
+	model := a.largeModel.Get()
+	if small {
+		model = a.smallModel.Get()
+	}
+	if call.MaxOutputTokens == nil {
+		maxTokens := model.CatwalkCfg.DefaultMaxTokens
+		call.MaxOutputTokens = &maxTokens
+	}
+	call.UserAgent = userAgent
+
+	resp, err := model.Model.Generate(ctx, call)
+	if err != nil {
+		return nil, "", err
+	}
+
+	modelConfig := model.CatwalkCfg
+	cost := modelConfig.CostPer1MInCached/1e6*float64(resp.Usage.CacheCreationTokens) +
+		modelConfig.CostPer1MOutCached/1e6*float64(resp.Usage.CacheReadTokens) +
+		modelConfig.CostPer1MIn/1e6*float64(resp.Usage.InputTokens) +
+		modelConfig.CostPer1MOut/1e6*float64(resp.Usage.OutputTokens)
+	if openrouterCost := a.openrouterCost(resp.ProviderMetadata); openrouterCost != nil {
+		cost = *openrouterCost
+	}
+	if model.FlatRate {
+		cost = 0
+	}
+	a.recordUsage(ctx, sessionID, resp.Usage, cost)
+
+	return resp, cmp.Or(model.CatwalkCfg.ID, model.ModelCfg.Model), nil
+}
+
+// sample answers the sampling requests of the MCP servers with the current
+// agent.
+func (c *coordinator) sample(ctx context.Context, sessionID string, small bool, call fantasy.Call) (*fantasy.Response, string, error) {
+	return c.primaryAgent().Sample(ctx, sessionID, small, call)
+}
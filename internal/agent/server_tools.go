@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/format"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+// ServerTools implements Coordinator. They are the tools working on the
+// files and shell of the project; the ones that need a model or an agent
+// turn are left out, as the client has its own. Like the coder agent, they
+// leave out the tools it isn't allowed, such as disabled_tools.
+func (c *coordinator) ServerTools() []fantasy.AgentTool {
+	cfg := c.cfg.Config()
+	workingDir := c.cfg.WorkingDir()
+	hashlineMode := cfg.Options.HashlineEdit != nil && *cfg.Options.HashlineEdit
+	formatter := format.New(cfg.Formatters, c.lspManager, workingDir)
+
+	allTools := []fantasy.AgentTool{
+		tools.NewBashTool(c.permissions, workingDir, cfg.Options.Attribution, "", buildBashSandboxOptions(cfg.Options)),
+		tools.NewJobOutputTool(),
+		tools.NewJobKillTool(),
+		tools.NewGlobTool(workingDir, cfg.Tools.Glob),
+		tools.NewGrepTool(workingDir, cfg.Tools.Grep),
+		tools.NewLsTool(c.permissions, workingDir, cfg.Tools.Ls),
+		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.skillTracker, workingDir, hashlineMode, cfg.Options.SkillsPaths...),
+		tools.NewWriteTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, workingDir),
+	}
+	if hashlineMode {
+		allTools = append(allTools,
+			tools.NewHashlineEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, workingDir),
+		)
+	} else {
+		allTools = append(allTools,
+			tools.NewEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, workingDir),
+			tools.NewMultiEditTool(c.lspManager, formatter, c.permissions, c.history, c.filetracker, workingDir),
+		)
+	}
+	if len(cfg.LSP) > 0 || cfg.Options.AutoLSP == nil || *cfg.Options.AutoLSP {
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspManager),
+			tools.NewReferencesTool(c.lspManager),
+			tools.NewHoverTool(c.lspManager, workingDir),
+		)
+		if lsp.SupportsRequests() {
+			allTools = append(allTools, tools.NewDefinitionTool(c.lspManager, workingDir))
+		}
+	}
+	allTools = filterAllowedTools(allTools, cfg.Agents[config.AgentCoder].AllowedTools)
+	slices.SortFunc(allTools, func(a, b fantasy.AgentTool) int {
+		return strings.Compare(a.Info().Name, b.Info().Name)
+	})
+
+	allTools = wrapToolsWithPolicy(allTools, c.permissions, workingDir)
+	allTools = wrapToolsWithHooks(allTools, c.hookRunner(hooks.EventPreToolUse), c.hookRunner(hooks.EventPostToolUse), false)
+	return wrapToolsWithTracing(allTools)
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// maxStopHookContinuations caps how many times Stop hooks can send the
+// agent back to work in a single turn. Hooks are expected to check
+// stop_hook_active themselves; this is a backstop for ones that don't.
+const maxStopHookContinuations = 8
+
+// hookRunner returns a runner for the hooks currently configured for
+// event, or nil if there are none. Runners are built on demand so config
+// reloads take effect on the next turn.
+func (c *coordinator) hookRunner(event string) *hooks.Runner {
+	eventHooks := c.cfg.Config().Hooks[event]
+	if len(eventHooks) == 0 {
+		return nil
+	}
+	return hooks.NewRunner(eventHooks, c.cfg.WorkingDir(), c.cfg.WorkingDir())
+}
+
+// sessionStart tracks the SessionStart hooks of a session. Their context
+// is sent along with the first prompt of the session.
+type sessionStart struct {
+	done    chan struct{}
+	context string
+}
+
+// watchSessions fires SessionStart hooks for sessions as they are
+// created. Sessions created for sub-agents and titles are left out.
+func (c *coordinator) watchSessions(ctx context.Context) {
+	for event := range c.sessions.Subscribe(ctx) {
+		if event.Type != pubsub.CreatedEvent || event.Payload.ParentSessionID != "" {
+			continue
+		}
+		source := hooks.SourceStartup
+		if event.Payload.MessageCount > 0 {
+			source = hooks.SourceResume
+		}
+		go c.startSession(ctx, event.Payload.ID, source)
+	}
+}
+
+// startSession fires SessionStart hooks for a session unless they have
+// already fired in this process, and returns its start.
+func (c *coordinator) startSession(ctx context.Context, sessionID, source string) *sessionStart {
+	c.startsMu.Lock()
+	start, started := c.sessionStarts[sessionID]
+	if !started {
+		start = &sessionStart{done: make(chan struct{})}
+		c.sessionStarts[sessionID] = start
+	}
+	c.startsMu.Unlock()
+	if started {
+		return start
+	}
+
+	defer close(start.done)
+	result, err := c.hookRunner(hooks.EventSessionStart).RunEvent(ctx, hooks.Input{
+		Event:     hooks.EventSessionStart,
+		SessionID: sessionID,
+		Source:    source,
+	})
+	if err != nil {
+		slog.Warn("SessionStart hook execution error", "session", sessionID, "error", err)
+	}
+	start.context = result.Context
+	return start
+}
+
+// runPromptHooks fires UserPromptSubmit hooks for a prompt, and
+// SessionStart hooks for a session from before this process started. It
+// returns the context the hooks gave to send along with the prompt,
+// including any SessionStart context not sent yet, or an error wrapping
+// [ErrPromptBlocked] if a hook blocked it.
+func (c *coordinator) runPromptHooks(ctx context.Context, sessionID, prompt string) (string, error) {
+	source := hooks.SourceStartup
+	if msgs, err := c.messages.List(ctx, sessionID); err == nil && len(msgs) > 0 {
+		source = hooks.SourceResume
+	}
+	start := c.startSession(ctx, sessionID, source)
+
+	var contexts []string
+	select {
+	case <-start.done:
+		c.startsMu.Lock()
+		if start.context != "" {
+			contexts = append(contexts, start.context)
+			start.context = ""
+		}
+		c.startsMu.Unlock()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	result, err := c.hookRunner(hooks.EventUserPromptSubmit).RunEvent(ctx, hooks.Input{
+		Event:     hooks.EventUserPromptSubmit,
+		SessionID: sessionID,
+		Prompt:    prompt,
+	})
+	if err != nil {
+		slog.Warn("UserPromptSubmit hook execution error, sending prompt", "session", sessionID, "error", err)
+	}
+	if result.Decision == hooks.DecisionDeny || result.Halt {
+		return "", fmt.Errorf("%w: %s", ErrPromptBlocked, result.Reason)
+	}
+	if result.Context != "" {
+		contexts = append(contexts, result.Context)
+	}
+	return strings.Join(contexts, "\n"), nil
+}
+
+// runStopHook fires Stop hooks at the end of a turn. It returns the
+// reason a hook gave for blocking the stop, which becomes the prompt for
+// another turn, or "" if the agent may stop.
+func (c *coordinator) runStopHook(ctx context.Context, sessionID string, continuations int) string {
+	if continuations >= maxStopHookContinuations {
+		slog.Warn("Stop hooks kept the agent going too many times; stopping", "session", sessionID)
+		return ""
+	}
+	result, err := c.hookRunner(hooks.EventStop).RunEvent(ctx, hooks.Input{
+		Event:          hooks.EventStop,
+		SessionID:      sessionID,
+		StopHookActive: continuations > 0,
+	})
+	if err != nil {
+		slog.Warn("Stop hook execution error", "session", sessionID, "error", err)
+	}
+	if result.Decision != hooks.DecisionDeny || result.Halt {
+		return ""
+	}
+	if result.Reason == "" {
+		return "A stop hook asked you to keep going."
+	}
+	return result.Reason
+}
+
+// EndSessions implements Coordinator.
+func (c *coordinator) EndSessions(ctx context.Context) {
+	c.startsMu.Lock()
+	sessionIDs := slices.Collect(maps.Keys(c.sessionStarts))
+	clear(c.sessionStarts)
+	c.startsMu.Unlock()
+
+	runner := c.hookRunner(hooks.EventSessionEnd)
+	for _, sessionID := range sessionIDs {
+		if _, err := runner.RunEvent(ctx, hooks.Input{
+			Event:     hooks.EventSessionEnd,
+			SessionID: sessionID,
+			Reason:    hooks.ReasonExit,
+		}); err != nil {
+			slog.Warn("SessionEnd hook execution error", "session", sessionID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStartHooks(t *testing.T) {
+	env := testEnv(t)
+	cfg, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	cfg.Config().Hooks = map[string][]config.HookConfig{
+		hooks.EventSessionStart: {{
+			Command: `echo '{"context": "started"}'`,
+		}},
+		hooks.EventUserPromptSubmit: {{
+			Command: `echo '{"context": "prompted"}'`,
+		}},
+	}
+	c := &coordinator{
+		cfg:           cfg,
+		sessions:      env.sessions,
+		messages:      env.messages,
+		sessionStarts: make(map[string]*sessionStart),
+	}
+	go c.watchSessions(t.Context())
+	// Let the watcher subscribe before the session is created.
+	time.Sleep(50 * time.Millisecond)
+
+	sess, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		c.startsMu.Lock()
+		defer c.startsMu.Unlock()
+		_, started := c.sessionStarts[sess.ID]
+		return started
+	}, 5*time.Second, 10*time.Millisecond, "SessionStart fires when the session is created")
+
+	hookContext, err := c.runPromptHooks(t.Context(), sess.ID, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "started\nprompted", hookContext)
+
+	hookContext, err = c.runPromptHooks(t.Context(), sess.ID, "hello again")
+	require.NoError(t, err)
+	require.Equal(t, "prompted", hookContext, "SessionStart context is only sent once")
+}
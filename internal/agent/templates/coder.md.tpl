@@ -376,12 +376,21 @@ Git status (snapshot at conversation start - may be outdated):
 {{.GitStatus}}
 {{end}}
 </env>
+{{if .RepoMap}}
+
+<repo_map>
+Outline of the files of the working directory and their top-level symbols, most referred to first. It may leave files and symbols out; use the repo_map tool to see the outline of a directory or file in full.
+{{.RepoMap}}
+</repo_map>
+{{end}}
 
 {{if gt (len .Config.LSP) 0}}
 <lsp>
 Diagnostics (lint/typecheck) included in tool output.
 - Fix issues in files you changed
 - Ignore issues in files you didn't touch (unless user asks)
+- Prefer lsp_definition, lsp_hover, and lsp_document_symbols over repeated grep/view to find where symbols are defined and what they are
+- Use lsp_rename to rename symbols instead of editing each usage; use lsp_code_action for quick fixes, refactorings, and organizing imports
 </lsp>
 {{end}}
 {{- if .AvailSkillXML}}
@@ -0,0 +1,30 @@
+{{.AgentPrompt}}
+
+<env>
+Working directory: {{.WorkingDir}}
+Is directory a git repo: {{if .IsGitRepo}}yes{{else}}no{{end}}
+Platform: {{.Platform}}
+Today's date: {{.Date}}
+{{if .GitStatus}}
+
+Git status (snapshot at conversation start - may be outdated):
+{{.GitStatus}}
+{{end}}
+</env>
+{{if .RepoMap}}
+
+<repo_map>
+Outline of the files of the working directory and their top-level symbols, most referred to first. It may leave files and symbols out; use the repo_map tool to see the outline of a directory or file in full.
+{{.RepoMap}}
+</repo_map>
+{{end}}
+
+{{if .ContextFiles}}
+<memory>
+{{range .ContextFiles}}
+<file path="{{.Path}}">
+{{.Content}}
+</file>
+{{end}}
+</memory>
+{{end}}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -217,7 +218,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			}
 
 			// Determine working directory
-			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			execWorkingDir := cmp.Or(params.WorkingDir, WorkingDirFromContext(ctx, workingDir))
 
 			// Resolve sandbox config for this invocation.
 			var sandboxCfg *shell.SandboxConfig
@@ -229,9 +230,15 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 						return fantasy.NewTextErrorResponse(err.Error()), nil
 					}
 				}
+				writablePaths := params.SandboxWritablePaths
+				// Git commands in a session's worktree write to the
+				// repository's git directory, which is outside of it.
+				if wt, ok := GetWorktreeFromContext(ctx); ok {
+					writablePaths = append(slices.Clip(writablePaths), wt.WritableGitPaths()...)
+				}
 				sandboxCfg = &shell.SandboxConfig{
 					Enabled:       true,
-					WritablePaths: params.SandboxWritablePaths,
+					WritablePaths: writablePaths,
 					Network:       sandboxOpts.NetworkDefault || params.SandboxNetwork,
 					OverlayDir:    sandboxOpts.OverlayDir,
 				}
@@ -255,25 +262,25 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
-			if !isSafeReadOnly {
-				p, err := permissions.Request(
-					ctx,
-					permission.CreatePermissionRequest{
-						SessionID:   sessionID,
-						Path:        execWorkingDir,
-						ToolCallID:  call.ID,
-						ToolName:    BashToolName,
-						Action:      "execute",
-						Description: fmt.Sprintf("Execute command: %s", params.Command),
-						Params:      BashPermissionsParams(params),
-					},
-				)
-				if err != nil {
-					return fantasy.ToolResponse{}, err
-				}
-				if !p {
-					return NewPermissionDeniedResponse(), nil
-				}
+			// Safe commands are still checked, as the policy may deny them.
+			p, err := permissions.Request(
+				ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        execWorkingDir,
+					ToolCallID:  call.ID,
+					ToolName:    BashToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Execute command: %s", params.Command),
+					Params:      BashPermissionsParams(params),
+					ReadOnly:    isSafeReadOnly,
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
 			}
 
 			// If explicitly requested as background, start immediately with detached context
@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/worktree"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,6 +56,8 @@ func (m *mockBashPermissionService) ListSessionPermissions(sessionID string) []p
 }
 func (m *mockBashPermissionService) DeleteSessionPermission(sessionID string, permissionID string) {}
 
+func (m *mockBashPermissionService) Policy() *permission.Policy { return nil }
+
 func TestBashTool_DefaultAutoBackgroundThreshold(t *testing.T) {
 	workingDir := t.TempDir()
 	tool := newBashToolForTest(workingDir)
@@ -95,6 +98,24 @@ func TestBashTool_CustomAutoBackgroundThreshold(t *testing.T) {
 	require.NoError(t, bgManager.Kill(meta.ShellID))
 }
 
+func TestBashTool_RunsInSessionWorktree(t *testing.T) {
+	workingDir := t.TempDir()
+	worktreeDir := t.TempDir()
+	tool := newBashToolForTest(workingDir)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	ctx = context.WithValue(ctx, WorktreeContextKey, worktree.Worktree{Path: worktreeDir, Branch: "crush/test"})
+
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "print working directory",
+		Command:     "pwd",
+	})
+
+	require.False(t, resp.IsError)
+	var meta BashResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, worktreeDir, meta.WorkingDirectory)
+}
+
 type recordingPermissionService struct {
 	*pubsub.Broker[permission.PermissionRequest]
 	requestCount int
@@ -102,6 +123,10 @@ type recordingPermissionService struct {
 }
 
 func (m *recordingPermissionService) Request(ctx context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	if req.ReadOnly {
+		// Granted without asking, as there is no policy.
+		return true, nil
+	}
 	m.requestCount++
 	return m.allow, nil
 }
@@ -132,6 +157,8 @@ func (m *recordingPermissionService) ListSessionPermissions(_ string) []permissi
 
 func (m *recordingPermissionService) DeleteSessionPermission(_, _ string) {}
 
+func (m *recordingPermissionService) Policy() *permission.Policy { return nil }
+
 func (m *recordingPermissionService) ListRules(_ context.Context) ([]db.PermissionRule, error) {
 	return nil, nil
 }
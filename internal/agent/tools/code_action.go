@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionParams struct {
+	FilePath  string `json:"file_path" description:"The path to the file"`
+	StartLine int    `json:"start_line,omitempty" description:"The 1-based first line of the range to get code actions for (default 1)"`
+	EndLine   int    `json:"end_line,omitempty" description:"The 1-based last line of the range (defaults to start_line)"`
+	Kind      string `json:"kind,omitempty" description:"Only return actions of this kind, e.g. quickfix, refactor, refactor.rewrite, source.organizeImports"`
+	Title     string `json:"title,omitempty" description:"The title of the action to apply. Leave empty to list the available actions without applying any."`
+}
+
+const CodeActionToolName = "lsp_code_action"
+
+//go:embed code_action.md
+var codeActionDescription string
+
+func NewCodeActionTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionToolName,
+		codeActionDescription,
+		func(ctx context.Context, params CodeActionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+			}
+
+			client := clientForFile(ctx, lspManager, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP server handles %s", filePath)), nil
+			}
+
+			var kinds []protocol.CodeActionKind
+			if params.Kind != "" {
+				kinds = append(kinds, protocol.CodeActionKind(params.Kind))
+			}
+			rng := lineRange(string(content), params.StartLine, params.EndLine)
+			actions, err := client.CodeActions(ctx, filePath, rng, kinds...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(actions) == 0 {
+				return fantasy.NewTextResponse("No code actions available"), nil
+			}
+			if params.Title == "" {
+				return fantasy.NewTextResponse(formatCodeActions("Available code actions", actions)), nil
+			}
+
+			action, errResp := selectCodeAction(actions, params.Title)
+			if errResp != nil {
+				return *errResp, nil
+			}
+			if action.Disabled != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("code action '%s' can't be applied: %s", action.Title, action.Disabled.Reason)), nil
+			}
+			if action.Edit == nil && action.Data != nil {
+				action, err = client.ResolveCodeAction(ctx, action)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+			}
+			if action.Edit == nil {
+				if action.Command != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("code action '%s' runs the server command %s, which isn't supported", action.Title, action.Command.Command)), nil
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("code action '%s' has no edits", action.Title)), nil
+			}
+
+			return applyWorkspaceEdit(
+				ctx,
+				workspaceEditContext{lspManager, permissions, files, filetracker, workingDir},
+				*action.Edit,
+				client.OffsetEncoding(),
+				CodeActionToolName,
+				fmt.Sprintf("Apply code action: %s", action.Title),
+				fmt.Sprintf("Applied code action: %s", action.Title),
+				call,
+			)
+		},
+	)
+}
+
+// lineRange returns the range covering whole lines start through end
+// (1-based) of content.
+func lineRange(content string, start, end int) protocol.Range {
+	start = max(start, 1)
+	end = max(end, start)
+	lines := strings.Split(content, "\n")
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(start - 1)}, //nolint:gosec
+		End:   protocol.Position{Line: uint32(end)},       //nolint:gosec
+	}
+	if end >= len(lines) {
+		last := len(lines) - 1
+		rng.End = protocol.Position{Line: uint32(last), Character: uint32(len(lines[last]))} //nolint:gosec
+		rng.Start.Line = min(rng.Start.Line, rng.End.Line)
+	}
+	return rng
+}
+
+// selectCodeAction finds the action with the given title. Titles match
+// case-insensitively, and a unique partial match is accepted.
+func selectCodeAction(actions []protocol.CodeAction, title string) (protocol.CodeAction, *fantasy.ToolResponse) {
+	var partial []protocol.CodeAction
+	for _, action := range actions {
+		if strings.EqualFold(action.Title, title) {
+			return action, nil
+		}
+		if strings.Contains(strings.ToLower(action.Title), strings.ToLower(title)) {
+			partial = append(partial, action)
+		}
+	}
+	switch len(partial) {
+	case 1:
+		return partial[0], nil
+	case 0:
+		resp := fantasy.NewTextErrorResponse(formatCodeActions(fmt.Sprintf("No code action matches '%s'. Available code actions", title), actions))
+		return protocol.CodeAction{}, &resp
+	default:
+		resp := fantasy.NewTextErrorResponse(formatCodeActions(fmt.Sprintf("Several code actions match '%s'; use the full title", title), partial))
+		return protocol.CodeAction{}, &resp
+	}
+}
+
+func formatCodeActions(heading string, actions []protocol.CodeAction) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "%s:\n", heading)
+	for _, action := range actions {
+		fmt.Fprintf(&output, "- %s", action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&output, " [%s]", action.Kind)
+		}
+		if action.IsPreferred {
+			output.WriteString(" (preferred)")
+		}
+		if action.Disabled != nil {
+			fmt.Fprintf(&output, " (disabled: %s)", action.Disabled.Reason)
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
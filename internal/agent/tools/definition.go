@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type DefinitionParams struct {
+	Symbol string `json:"symbol" description:"The symbol name to look up (e.g., function name, type name, method name)"`
+	Path   string `json:"path,omitempty" description:"A file or directory where the symbol is used. Defaults to the current working directory."`
+	Line   int    `json:"line,omitempty" description:"The 1-based line where the symbol appears when path is a file, to pick a specific occurrence"`
+}
+
+const DefinitionToolName = "lsp_definition"
+
+//go:embed definition.md
+var definitionDescription string
+
+func NewDefinitionTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DefinitionToolName,
+		definitionDescription,
+		func(ctx context.Context, params DefinitionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			if params.Symbol == "" {
+				return fantasy.NewTextErrorResponse("symbol is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			matches, err := findSymbol(ctx, params.Symbol, filepathext.SmartJoin(workingDir, cmp.Or(params.Path, ".")), params.Line)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search for symbol: %s", err)), nil
+			}
+			if len(matches) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("Symbol '%s' not found", params.Symbol)), nil
+			}
+
+			var allErrs error
+			for _, match := range matches {
+				client := clientForFile(ctx, lspManager, match.path)
+				if client == nil {
+					continue
+				}
+				locations, err := client.FindDefinition(ctx, match.path, match.lineNum, match.charNum+getSymbolOffset(params.Symbol))
+				if err != nil {
+					slog.Debug("Failed to find definition", "error", err, "symbol", params.Symbol, "path", match.path, "line", match.lineNum)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				if len(locations) > 0 {
+					return fantasy.NewTextResponse(formatDefinitions(params.Symbol, cleanupLocations(locations))), nil
+				}
+			}
+
+			if allErrs != nil {
+				return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+			}
+			return fantasy.NewTextResponse(fmt.Sprintf("No definition found for symbol '%s'", params.Symbol)), nil
+		},
+	)
+}
+
+// findSymbol finds where symbol appears under path, giving LSP tools
+// positions to ask about. If line is set only matches on that line are
+// kept.
+func findSymbol(ctx context.Context, symbol, path string, line int) ([]grepMatch, error) {
+	matches, _, _, err := searchFiles(ctx, regexp.QuoteMeta(symbol), path, "", 100)
+	if err != nil {
+		return nil, err
+	}
+	if line <= 0 {
+		return matches, nil
+	}
+	var onLine []grepMatch
+	for _, match := range matches {
+		if match.lineNum == line {
+			onLine = append(onLine, match)
+		}
+	}
+	return onLine, nil
+}
+
+// clientForFile returns the LSP client that handles path, starting one if
+// needed, or nil if no server handles it.
+func clientForFile(ctx context.Context, lspManager *lsp.Manager, path string) *lsp.Client {
+	lspManager.Start(ctx, path)
+	for client := range lspManager.Clients().Seq() {
+		if client.HandlesFile(path) {
+			return client
+		}
+	}
+	slog.Debug("No LSP clients to handle", "path", path)
+	return nil
+}
+
+func formatDefinitions(symbol string, locations []protocol.Location) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "Found %d definition(s) of '%s':\n", len(locations), symbol)
+	for _, loc := range locations {
+		path, err := loc.URI.Path()
+		if err != nil {
+			slog.Error("Failed to convert location URI to path", "uri", loc.URI, "error", err)
+			continue
+		}
+		line := int(loc.Range.Start.Line) + 1
+		fmt.Fprintf(&output, "\n%s:%d:%d\n", path, line, loc.Range.Start.Character+1)
+		if text := sourceLine(path, line); text != "" {
+			fmt.Fprintf(&output, "  %s\n", text)
+		}
+	}
+	return output.String()
+}
+
+// sourceLine returns the trimmed text of a 1-based line in a file, or ""
+// if it can't be read.
+func sourceLine(path string, line int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
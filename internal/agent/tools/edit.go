@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
@@ -56,11 +57,13 @@ type editContext struct {
 	permissions permission.Service
 	files       history.Service
 	filetracker filetracker.Service
+	formatter   *format.Formatter
 	workingDir  string
 }
 
 func NewEditTool(
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -70,6 +73,7 @@ func NewEditTool(
 		EditToolName,
 		editDescription,
 		func(ctx context.Context, params EditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -79,7 +83,7 @@ func NewEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, formatter, workingDir}
 
 			if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
@@ -157,13 +161,16 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return NewPermissionDeniedResponse(), nil
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	err = writeSessionFile(edit.ctx, filePath, []byte(content))
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	content, formatNote := formatAfterWrite(edit.ctx, edit.formatter, filePath, content)
+	_, additions, removals = diff.GenerateDiff("", content, strings.TrimPrefix(filePath, edit.workingDir))
+
 	// File can't be in the history so we create a new file history
-	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
+	_, err = edit.files.CreateMissing(history.WithoutMessageID(edit.ctx), sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -179,7 +186,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("File created: "+filePath),
+		fantasy.NewTextResponse("File created: "+filePath+formatNote),
 		EditResponseMetadata{
 			OldContent: "",
 			NewContent: content,
@@ -222,7 +229,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readSessionFile(edit.ctx, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -283,15 +290,18 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = writeSessionFile(edit.ctx, filePath, []byte(newContent))
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	newContent, formatNote := formatAfterWrite(edit.ctx, edit.formatter, filePath, newContent)
+	_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.Create(history.WithoutMessageID(edit.ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			// Log error but don't fail the operation
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -299,7 +309,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	}
 	if file.Content != oldContent {
 		// User manually changed the content; store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.CreateVersion(history.WithoutMessageID(edit.ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			slog.Error("Error creating file history version", "error", err)
 		}
@@ -313,7 +323,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse("Content deleted from file: "+filePath+formatNote),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -356,7 +366,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readSessionFile(edit.ctx, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -417,15 +427,18 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = writeSessionFile(edit.ctx, filePath, []byte(newContent))
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	newContent, formatNote := formatAfterWrite(edit.ctx, edit.formatter, filePath, newContent)
+	_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.Create(history.WithoutMessageID(edit.ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			// Log error but don't fail the operation
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -433,7 +446,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	}
 	if file.Content != oldContent {
 		// User manually changed the content; store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.CreateVersion(history.WithoutMessageID(edit.ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			slog.Debug("Error creating file history version", "error", err)
 		}
@@ -447,7 +460,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse("Content replaced in file: "+filePath+formatNote),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -59,6 +59,7 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 		FetchToolName,
 		fetchDescription(),
 		func(ctx context.Context, params FetchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.URL == "" {
 				return fantasy.NewTextErrorResponse("URL parameter is required"), nil
 			}
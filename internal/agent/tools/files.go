@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/searchindex"
+)
+
+// Files reads and writes the text files of a session somewhere else than
+// on disk, such as in the buffers of the editor driving the session.
+type Files interface {
+	ReadTextFile(ctx context.Context, path string) ([]byte, error)
+	WriteTextFile(ctx context.Context, path string, data []byte) error
+}
+
+// sessionFiles are the files of the sessions which don't work on disk.
+var sessionFiles = csync.NewMap[string, Files]()
+
+// SetSessionFiles makes the tools read and write the text files of the
+// session sessionID through f, until [DeleteSessionFiles]. Sessions of
+// sub-agents still work on disk.
+func SetSessionFiles(sessionID string, f Files) {
+	sessionFiles.Set(sessionID, f)
+}
+
+// DeleteSessionFiles makes the tools work on disk again for the session
+// sessionID.
+func DeleteSessionFiles(sessionID string) {
+	sessionFiles.Del(sessionID)
+}
+
+// filesFromContext returns the files of the session of ctx, if they aren't
+// on disk.
+func filesFromContext(ctx context.Context) (Files, bool) {
+	return sessionFiles.Get(GetSessionFromContext(ctx))
+}
+
+// readSessionFile reads the text file path of the session of ctx.
+func readSessionFile(ctx context.Context, path string) ([]byte, error) {
+	if f, ok := filesFromContext(ctx); ok {
+		return f.ReadTextFile(ctx, path)
+	}
+	return os.ReadFile(path)
+}
+
+// writeSessionFile writes data to the text file path of the session of ctx.
+// Files written on disk are updated in the search index, so grep and glob
+// find them before the index is next refreshed.
+func writeSessionFile(ctx context.Context, path string, data []byte) error {
+	if f, ok := filesFromContext(ctx); ok {
+		return f.WriteTextFile(ctx, path, data)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	searchindex.Update(path)
+	return nil
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferFiles are files in the buffers of an editor.
+type bufferFiles map[string]string
+
+func (f bufferFiles) ReadTextFile(_ context.Context, path string) ([]byte, error) {
+	content, ok := f[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func (f bufferFiles) WriteTextFile(_ context.Context, path string, data []byte) error {
+	f[path] = string(data)
+	return nil
+}
+
+func TestSessionFiles(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	path := filepath.Join(workingDir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("on disk\n"), 0o644))
+
+	buffers := bufferFiles{path: "package main\n"}
+	SetSessionFiles("buffers-session", buffers)
+	t.Cleanup(func() { DeleteSessionFiles("buffers-session") })
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "buffers-session")
+
+	resp := runViewTool(t, newViewToolForTest(workingDir), ctx, ViewParams{FilePath: path})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "package main")
+	require.NotContains(t, resp.Content, "on disk")
+
+	input, err := json.Marshal(EditParams{FilePath: path, OldString: "main", NewString: "tools"})
+	require.NoError(t, err)
+	edit := NewEditTool(nil, nil, &mockPermissionService{}, &mockHistoryService{}, mockFileTrackerService{}, workingDir)
+	resp, err = edit.Run(ctx, fantasy.ToolCall{ID: "edit-call", Name: EditToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "package tools\n", buffers[path])
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "on disk\n", string(b), "the file on disk is left alone")
+
+	t.Run("other sessions work on disk", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), SessionIDContextKey, "disk-session")
+		resp := runViewTool(t, newViewToolForTest(workingDir), ctx, ViewParams{FilePath: path})
+		require.Contains(t, resp.Content, "on disk")
+	})
+}
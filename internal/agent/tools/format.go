@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/format"
+)
+
+// formatAfterWrite runs the configured formatter on a file a tool just
+// wrote with content. It returns the file's content afterwards and a note
+// for the tool result with the formatter's changes, or any error it hit.
+func formatAfterWrite(ctx context.Context, formatter *format.Formatter, filePath, content string) (string, string) {
+	// Editors holding the files of the session format their buffers
+	// themselves.
+	if _, ok := filesFromContext(ctx); ok {
+		return content, ""
+	}
+	changed, err := formatter.Format(ctx, filePath)
+	var note string
+	if err != nil {
+		slog.Warn("Failed to format file", "path", filePath, "error", err)
+		note = fmt.Sprintf("\n\nFormatting failed: %s", err)
+	}
+	if !changed {
+		return content, note
+	}
+
+	formatted, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return content, note
+	}
+	if note == "" {
+		changes, _, _ := diff.GenerateDiff(content, string(formatted), filePath)
+		note = fmt.Sprintf("\n\nThe file was formatted after the change:\n%s", changes)
+	}
+	return string(formatted), note
+}
@@ -45,8 +45,9 @@ type GlobParams struct {
 }
 
 type GlobResponseMetadata struct {
-	NumberOfFiles int  `json:"number_of_files"`
-	Truncated     bool `json:"truncated"`
+	NumberOfFiles int    `json:"number_of_files"`
+	Truncated     bool   `json:"truncated"`
+	Source        string `json:"source,omitempty"`
 }
 
 func NewGlobTool(workingDir string, cfg config.ToolGlob) fantasy.AgentTool {
@@ -54,6 +55,7 @@ func NewGlobTool(workingDir string, cfg config.ToolGlob) fantasy.AgentTool {
 		GlobToolName,
 		globDescription(),
 		func(ctx context.Context, params GlobParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
@@ -63,10 +65,11 @@ func NewGlobTool(workingDir string, cfg config.ToolGlob) fantasy.AgentTool {
 			searchCtx, cancel := context.WithTimeout(ctx, cfg.GetTimeout())
 			defer cancel()
 
-			files, truncated, err := globFiles(searchCtx, params.Pattern, searchPath, 100)
+			files, truncated, source, err := globFiles(searchCtx, params.Pattern, searchPath, 100)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error finding files: %v", err)), nil
 			}
+			slog.Debug("Globbed files", "pattern", params.Pattern, "path", searchPath, "source", source)
 
 			var output string
 			if len(files) == 0 {
@@ -84,24 +87,33 @@ func NewGlobTool(workingDir string, cfg config.ToolGlob) fantasy.AgentTool {
 				GlobResponseMetadata{
 					NumberOfFiles: len(files),
 					Truncated:     truncated,
+					Source:        source,
 				},
 			), nil
 		},
 	)
 }
 
-func globFiles(ctx context.Context, pattern, searchPath string, limit int) ([]string, bool, error) {
+// globFiles finds the files under searchPath matching pattern with the
+// search index when it is warm, and with ripgrep or by walking the
+// directory otherwise. It returns where the files came from.
+func globFiles(ctx context.Context, pattern, searchPath string, limit int) ([]string, bool, string, error) {
+	if matches, truncated, ok := globWithIndex(pattern, searchPath, limit); ok {
+		return matches, truncated, SearchSourceIndex, nil
+	}
+
 	cmdRg := getRgCmd(ctx, pattern)
 	if cmdRg != nil {
 		cmdRg.Dir = searchPath
 		matches, err := runRipgrep(cmdRg, searchPath, limit)
 		if err == nil {
-			return matches, len(matches) >= limit && limit > 0, nil
+			return matches, len(matches) >= limit && limit > 0, SearchSourceRipgrep, nil
 		}
 		slog.Warn("Ripgrep execution failed, falling back to doublestar", "error", err)
 	}
 
-	return fsext.GlobGitignoreAware(pattern, searchPath, limit)
+	matches, truncated, err := fsext.GlobGitignoreAware(pattern, searchPath, limit)
+	return matches, truncated, SearchSourceWalk, err
 }
 
 func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int) ([]string, error) {
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
@@ -81,8 +82,9 @@ type grepMatch struct {
 }
 
 type GrepResponseMetadata struct {
-	NumberOfMatches int  `json:"number_of_matches"`
-	Truncated       bool `json:"truncated"`
+	NumberOfMatches int    `json:"number_of_matches"`
+	Truncated       bool   `json:"truncated"`
+	Source          string `json:"source,omitempty"`
 }
 
 const (
@@ -125,6 +127,7 @@ func NewGrepTool(workingDir string, config config.ToolGrep) fantasy.AgentTool {
 		GrepToolName,
 		grepDescription(),
 		func(ctx context.Context, params GrepParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
@@ -139,10 +142,11 @@ func NewGrepTool(workingDir string, config config.ToolGrep) fantasy.AgentTool {
 			searchCtx, cancel := context.WithTimeout(ctx, config.GetTimeout())
 			defer cancel()
 
-			matches, truncated, err := searchFiles(searchCtx, searchPattern, searchPath, params.Include, 100)
+			matches, truncated, source, err := searchFiles(searchCtx, searchPattern, searchPath, params.Include, 100)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error searching files: %v", err)), nil
 			}
+			slog.Debug("Searched files", "pattern", searchPattern, "path", searchPath, "source", source)
 
 			var output strings.Builder
 			if len(matches) == 0 {
@@ -184,18 +188,31 @@ func NewGrepTool(workingDir string, config config.ToolGrep) fantasy.AgentTool {
 				GrepResponseMetadata{
 					NumberOfMatches: len(matches),
 					Truncated:       truncated,
+					Source:          source,
 				},
 			), nil
 		},
 	)
 }
 
-func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, bool, error) {
-	matches, err := searchWithRipgrep(ctx, pattern, rootPath, include)
+// searchFiles searches the files under rootPath for pattern with the search
+// index when it is warm, and with ripgrep or by walking the directory
+// otherwise. It returns where the matches came from.
+func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, bool, string, error) {
+	source := SearchSourceIndex
+	matches, ok, err := searchWithIndex(ctx, pattern, rootPath, include)
 	if err != nil {
-		matches, err = searchFilesWithRegex(pattern, rootPath, include)
+		return nil, false, "", err
+	}
+	if !ok {
+		source = SearchSourceRipgrep
+		matches, err = searchWithRipgrep(ctx, pattern, rootPath, include)
 		if err != nil {
-			return nil, false, err
+			source = SearchSourceWalk
+			matches, err = searchFilesWithRegex(pattern, rootPath, include)
+			if err != nil {
+				return nil, false, "", err
+			}
 		}
 	}
 
@@ -208,7 +225,7 @@ func searchFiles(ctx context.Context, pattern, rootPath, include string, limit i
 		matches = matches[:limit]
 	}
 
-	return matches, truncated, nil
+	return matches, truncated, source, nil
 }
 
 func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]grepMatch, error) {
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/crush/internal/searchindex"
 	"github.com/stretchr/testify/require"
 )
 
@@ -419,3 +421,43 @@ func TestColumnMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchWithIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "pkg", "a.go"), []byte("package pkg\n\nfunc Hello() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "pkg", "b.go"), []byte("package pkg\n\nfunc World() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("say hello\n"), 0o644))
+
+	matches, _, source, err := searchFiles(t.Context(), "Hello", tempDir, "", 100)
+	require.NoError(t, err)
+	require.NotEqual(t, SearchSourceIndex, source)
+	require.Len(t, matches, 1)
+
+	stop := searchindex.Start(t.Context(), tempDir, t.TempDir(), time.Hour)
+	t.Cleanup(func() { stop(t.Context()) })
+	require.Eventually(t, func() bool { return searchindex.Lookup(tempDir) != nil }, 5*time.Second, 10*time.Millisecond)
+
+	matches, _, source, err = searchFiles(t.Context(), "Hello", tempDir, "", 100)
+	require.NoError(t, err)
+	require.Equal(t, SearchSourceIndex, source)
+	require.Len(t, matches, 1)
+	require.Equal(t, filepath.Join(tempDir, "pkg", "a.go"), matches[0].path)
+	require.Equal(t, 3, matches[0].lineNum)
+
+	matches, _, _, err = searchFiles(t.Context(), "(?i)hello", tempDir, "*.txt", 100)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, filepath.Join(tempDir, "notes.txt"), matches[0].path)
+
+	files, truncated, source, err := globFiles(t.Context(), "**/*.go", tempDir, 1)
+	require.NoError(t, err)
+	require.Equal(t, SearchSourceIndex, source)
+	require.True(t, truncated)
+	require.Len(t, files, 1)
+
+	// A file path isn't searched with the index.
+	_, _, source, err = searchFiles(t.Context(), "Hello", filepath.Join(tempDir, "pkg", "a.go"), "", 100)
+	require.NoError(t, err)
+	require.NotEqual(t, SearchSourceIndex, source)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/hashline"
 	"github.com/charmbracelet/crush/internal/history"
@@ -50,6 +51,7 @@ type HashlineEditPermissionsParams struct {
 
 func NewHashlineEditTool(
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -59,6 +61,7 @@ func NewHashlineEditTool(
 		HashlineEditToolName,
 		string(hashlineEditDescription),
 		func(ctx context.Context, params HashlineEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.Path == "" {
 				return fantasy.NewTextErrorResponse("path is required"), nil
 			}
@@ -83,10 +86,10 @@ func NewHashlineEditTool(
 			}
 
 			if isNewFile {
-				return hashlineCreateFile(ctx, permissions, files, filetracker, lspManager, filePath, workingDir, params.Edits, call)
+				return hashlineCreateFile(ctx, permissions, files, filetracker, lspManager, formatter, filePath, workingDir, params.Edits, call)
 			}
 
-			return hashlineEditFile(ctx, permissions, files, filetracker, lspManager, filePath, workingDir, params.Edits, call)
+			return hashlineEditFile(ctx, permissions, files, filetracker, lspManager, formatter, filePath, workingDir, params.Edits, call)
 		})
 }
 
@@ -123,7 +126,7 @@ func hashlineDeleteFile(
 		return NewPermissionDeniedResponse(), nil
 	}
 
-	oldContent, err := os.ReadFile(filePath)
+	oldContent, err := readSessionFile(ctx, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -132,8 +135,8 @@ func hashlineDeleteFile(
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to delete file: %w", err)
 	}
 
-	_, _ = files.Create(ctx, sessionID, filePath, string(oldContent))
-	_, _ = files.CreateVersion(ctx, sessionID, filePath, "")
+	_, _ = files.Create(history.WithoutMessageID(ctx), sessionID, filePath, string(oldContent))
+	_, _ = files.CreateMissing(ctx, sessionID, filePath)
 
 	return fantasy.WithResponseMetadata(
 		fantasy.NewTextResponse("File deleted: "+filePath),
@@ -196,6 +199,7 @@ func hashlineCreateFile(
 	files history.Service,
 	filetracker filetracker.Service,
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	filePath, workingDir string,
 	edits []HashlineOp,
 	call fantasy.ToolCall,
@@ -257,17 +261,20 @@ func hashlineCreateFile(
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, []byte(newContent), 0o644); err != nil {
+	if err := writeSessionFile(ctx, filePath, []byte(newContent)); err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	_, _ = files.Create(ctx, sessionID, filePath, "")
+	newContent, formatNote := formatAfterWrite(ctx, formatter, filePath, newContent)
+	_, additions, removals = diff.GenerateDiff("", newContent, strings.TrimPrefix(filePath, workingDir))
+
+	_, _ = files.CreateMissing(history.WithoutMessageID(ctx), sessionID, filePath)
 	_, _ = files.CreateVersion(ctx, sessionID, filePath, newContent)
 	filetracker.RecordRead(ctx, sessionID, filePath)
 
 	notifyLSPs(ctx, lspManager, filePath)
 
-	text := fmt.Sprintf("<result>\nFile created: %s\n</result>\n", filePath)
+	text := fmt.Sprintf("<result>\nFile created: %s%s\n</result>\n", filePath, formatNote)
 	text += getDiagnostics(filePath, lspManager)
 
 	return fantasy.WithResponseMetadata(
@@ -286,6 +293,7 @@ func hashlineEditFile(
 	files history.Service,
 	filetracker filetracker.Service,
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	filePath, workingDir string,
 	edits []HashlineOp,
 	call fantasy.ToolCall,
@@ -313,7 +321,7 @@ func hashlineEditFile(
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readSessionFile(ctx, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -516,19 +524,25 @@ func hashlineEditFile(
 		writeContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	if err := os.WriteFile(filePath, []byte(writeContent), 0o644); err != nil {
+	if err := writeSessionFile(ctx, filePath, []byte(writeContent)); err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	formatted, formatNote := formatAfterWrite(ctx, formatter, filePath, writeContent)
+	if formatted != writeContent {
+		newContent, _ = fsext.ToUnixLineEndings(formatted)
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, workingDir))
+	}
+
 	file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
-		_, err = files.Create(ctx, sessionID, filePath, oldContent)
+		_, err = files.Create(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 		}
 	}
 	if file.Content != oldContent {
-		_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent)
+		_, err = files.CreateVersion(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
 		if err != nil {
 			slog.Error("Error creating file history version", "error", err)
 		}
@@ -542,7 +556,7 @@ func hashlineEditFile(
 
 	notifyLSPs(ctx, lspManager, filePath)
 
-	text := fmt.Sprintf("<result>\nFile edited: %s (%d additions, %d removals)\n</result>\n", filePath, additions, removals)
+	text := fmt.Sprintf("<result>\nFile edited: %s (%d additions, %d removals)%s\n</result>\n", filePath, additions, removals, formatNote)
 	text += getDiagnostics(filePath, lspManager)
 
 	return fantasy.WithResponseMetadata(
@@ -71,7 +71,7 @@ func callEdit(t *testing.T, filePath, workingDir string, ft *mockFiletracker, ed
 	perms := &mockPermissionService{}
 	hist := &mockHistoryService{}
 	call := fantasy.ToolCall{ID: "test-call"}
-	return hashlineEditFile(testCtx(), perms, hist, ft, nil, filePath, workingDir, edits, call)
+	return hashlineEditFile(testCtx(), perms, hist, ft, nil, nil, filePath, workingDir, edits, call)
 }
 
 // callCreate is a helper that invokes hashlineCreateFile.
@@ -80,7 +80,7 @@ func callCreate(t *testing.T, filePath, workingDir string, ft *mockFiletracker,
 	perms := &mockPermissionService{}
 	hist := &mockHistoryService{}
 	call := fantasy.ToolCall{ID: "test-call"}
-	return hashlineCreateFile(testCtx(), perms, hist, ft, nil, filePath, workingDir, edits, call)
+	return hashlineCreateFile(testCtx(), perms, hist, ft, nil, nil, filePath, workingDir, edits, call)
 }
 
 func readFile(t *testing.T, path string) string {
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+type HoverParams struct {
+	Symbol string `json:"symbol" description:"The symbol name to inspect (e.g., function name, variable name, type name)"`
+	Path   string `json:"path,omitempty" description:"A file or directory where the symbol is used. Defaults to the current working directory."`
+	Line   int    `json:"line,omitempty" description:"The 1-based line where the symbol appears when path is a file, to pick a specific occurrence"`
+}
+
+const HoverToolName = "lsp_hover"
+
+//go:embed hover.md
+var hoverDescription string
+
+func NewHoverTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		HoverToolName,
+		hoverDescription,
+		func(ctx context.Context, params HoverParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			if params.Symbol == "" {
+				return fantasy.NewTextErrorResponse("symbol is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			matches, err := findSymbol(ctx, params.Symbol, filepathext.SmartJoin(workingDir, cmp.Or(params.Path, ".")), params.Line)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search for symbol: %s", err)), nil
+			}
+			if len(matches) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("Symbol '%s' not found", params.Symbol)), nil
+			}
+
+			var allErrs error
+			for _, match := range matches {
+				client := clientForFile(ctx, lspManager, match.path)
+				if client == nil {
+					continue
+				}
+				char := match.charNum + getSymbolOffset(params.Symbol)
+				hover, err := client.Hover(ctx, match.path, match.lineNum, char)
+				if err != nil {
+					slog.Debug("Failed to get hover", "error", err, "symbol", params.Symbol, "path", match.path, "line", match.lineNum)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				if hover != "" {
+					return fantasy.NewTextResponse(fmt.Sprintf("%s:%d:%d\n\n%s", match.path, match.lineNum, char, hover)), nil
+				}
+			}
+
+			if allErrs != nil {
+				return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+			}
+			return fantasy.NewTextResponse(fmt.Sprintf("No hover information for symbol '%s'", params.Symbol)), nil
+		},
+	)
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/searchindex"
+)
+
+// Where the results of grep and glob came from, reported in their
+// metadata.
+const (
+	// SearchSourceIndex is the search index of the working directory.
+	SearchSourceIndex = "index"
+	// SearchSourceRipgrep is ripgrep.
+	SearchSourceRipgrep = "ripgrep"
+	// SearchSourceWalk is walking the directory.
+	SearchSourceWalk = "walk"
+)
+
+// indexFor returns the search index of dir, if it is a directory with a
+// warm index.
+func indexFor(dir string) *searchindex.Index {
+	ix := searchindex.Lookup(dir)
+	if ix == nil {
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+	return ix
+}
+
+// searchWithIndex searches the files the search index of rootPath says may
+// match pattern. It reports false if there is no index to use.
+func searchWithIndex(ctx context.Context, pattern, rootPath, include string) ([]grepMatch, bool, error) {
+	ix := indexFor(rootPath)
+	if ix == nil {
+		return nil, false, nil
+	}
+	regex, err := searchRegexCache.get(pattern)
+	if err != nil {
+		// Leave patterns Go doesn't support to ripgrep.
+		return nil, false, nil
+	}
+	var includePattern *regexp.Regexp
+	if include != "" {
+		includePattern, err = globRegexCache.get(globToRegex(include))
+		if err != nil {
+			return nil, false, nil
+		}
+	}
+	candidates, err := ix.Candidates(rootPath, pattern)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	matches := []grepMatch{}
+	for _, f := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, true, err
+		}
+		if skipIndexed(rootPath, f.Path) {
+			continue
+		}
+		if includePattern != nil && !includePattern.MatchString(f.Path) {
+			continue
+		}
+		match, lineNum, charNum, lineText, err := fileContainsPattern(f.Path, regex)
+		if err != nil || !match {
+			continue
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, grepMatch{
+			path:     f.Path,
+			modTime:  info.ModTime(),
+			lineNum:  lineNum,
+			charNum:  charNum,
+			lineText: lineText,
+		})
+		if len(matches) >= 200 {
+			break
+		}
+	}
+	return matches, true, nil
+}
+
+// globWithIndex returns the files of the search index of searchPath
+// matching pattern, most recently modified first. It reports false if
+// there is no index to use.
+func globWithIndex(pattern, searchPath string, limit int) ([]string, bool, bool) {
+	ix := indexFor(searchPath)
+	if ix == nil {
+		return nil, false, false
+	}
+	files, err := ix.Glob(searchPath, pattern)
+	if err != nil {
+		return nil, false, false
+	}
+	files = slices.DeleteFunc(files, func(f searchindex.File) bool {
+		return skipIndexed(searchPath, f.Path)
+	})
+	slices.SortFunc(files, func(a, b searchindex.File) int {
+		return b.ModTime.Compare(a.ModTime)
+	})
+	truncated := limit > 0 && len(files) > limit
+	if truncated {
+		files = files[:limit]
+	}
+	matches := make([]string, len(files))
+	for i, f := range files {
+		matches[i] = f.Path
+	}
+	return matches, truncated, true
+}
+
+// skipIndexed reports whether path is hidden below root. Ripgrep skips
+// such files, so results from the index leave them out too.
+func skipIndexed(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err != nil || fsext.SkipHidden(rel)
+}
@@ -126,7 +126,7 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
 				ToolCallID:  params.ID,
-				Path:        m.workingDir,
+				Path:        WorkingDirFromContext(ctx, m.workingDir),
 				ToolName:    m.Info().Name,
 				Action:      "execute",
 				Description: permissionDescription,
@@ -141,6 +141,9 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 		}
 	}
 
+	// Requests the server makes while running the tool are attributed to
+	// this tool call.
+	ctx = mcp.WithToolCall(ctx, sessionID, params.ID)
 	result, err := mcp.RunTool(ctx, m.cfg, m.mcpName, m.tool.Name, mcpInput)
 	if err != nil {
 		return fantasy.NewTextErrorResponse(err.Error()), nil
@@ -0,0 +1,290 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// The actions answering an elicitation.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+// ErrElicitationNotFound is returned when answering an elicitation which
+// was already answered, or is no longer needed.
+var ErrElicitationNotFound = errors.New("elicitation not found")
+
+// Elicitation is a form an MCP server asks the user to fill in.
+type Elicitation struct {
+	ID         string
+	Name       string
+	SessionID  string
+	ToolCallID string
+	Message    string
+	Fields     []ElicitationField
+}
+
+// ElicitationField is a field of the form of an elicitation.
+type ElicitationField struct {
+	Name        string
+	Title       string
+	Description string
+	// Type is string, number, integer or boolean.
+	Type     string
+	Format   string
+	Required bool
+	// Options are the values the field is limited to, if any, with their
+	// titles in OptionTitles.
+	Options      []string
+	OptionTitles []string
+	// Default is the default value of the field, as text.
+	Default string
+}
+
+// ElicitationResponse is the answer of the user to an elicitation.
+type ElicitationResponse struct {
+	// Action is one of [ElicitationAccept], [ElicitationDecline] and
+	// [ElicitationCancel].
+	Action string
+	// Content holds the values of the fields, when accepted.
+	Content map[string]any
+}
+
+var (
+	elicitations        = pubsub.NewBroker[Elicitation]()
+	pendingElicitations = csync.NewMap[string, chan ElicitationResponse]()
+	// elicitationTurn lets one elicitation at a time through, as the user
+	// answers them one by one.
+	elicitationTurn = make(chan struct{}, 1)
+	// noElicitations holds the sessions no user answers elicitations for.
+	noElicitations = csync.NewMap[string, bool]()
+)
+
+// SubscribeElicitations returns a channel for the elicitations to answer.
+// Elicitations which are no longer needed, because their tool call ended,
+// are published again as deleted.
+func SubscribeElicitations(ctx context.Context) <-chan pubsub.Event[Elicitation] {
+	return elicitations.Subscribe(ctx)
+}
+
+// AnswerElicitation answers the elicitation id.
+func AnswerElicitation(id string, resp ElicitationResponse) error {
+	ch, ok := pendingElicitations.Take(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrElicitationNotFound, id)
+	}
+	ch <- resp
+	return nil
+}
+
+// DeclineElicitations declines the elicitations of the tool calls of the
+// session sessionID, for sessions running without a user. Every entry
+// point without a user to answer them must call it, or the tool calls
+// asking wait until they are canceled.
+func DeclineElicitations(sessionID string) {
+	noElicitations.Set(sessionID, true)
+}
+
+// DeclinesElicitations reports whether the elicitations of the tool calls
+// of the session sessionID are declined.
+func DeclinesElicitations(sessionID string) bool {
+	_, ok := noElicitations.Get(sessionID)
+	return ok
+}
+
+// elicitationHandler returns the handler of the elicitations of the MCP
+// server name, which are published for the user to answer. Those which
+// can't be attributed to a tool call are declined.
+func elicitationHandler(name string) func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	return func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		tc, ok := requestToolCall(name, req.Params)
+		if !ok || DeclinesElicitations(tc.sessionID) || req.Params.Mode == "url" {
+			return &mcp.ElicitResult{Action: ElicitationDecline}, nil
+		}
+		fields, err := elicitationFields(req.Params.RequestedSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := tc.context(ctx)
+		defer cancel()
+		select {
+		case elicitationTurn <- struct{}{}:
+			defer func() { <-elicitationTurn }()
+		case <-ctx.Done():
+			return &mcp.ElicitResult{Action: ElicitationCancel}, nil
+		}
+
+		e := Elicitation{
+			ID:         uuid.NewString(),
+			Name:       name,
+			SessionID:  tc.sessionID,
+			ToolCallID: tc.toolCallID,
+			Message:    req.Params.Message,
+			Fields:     fields,
+		}
+		ch := make(chan ElicitationResponse, 1)
+		pendingElicitations.Set(e.ID, ch)
+		elicitations.Publish(pubsub.CreatedEvent, e)
+
+		select {
+		case resp := <-ch:
+			result := &mcp.ElicitResult{Action: resp.Action}
+			if resp.Action == ElicitationAccept {
+				result.Content = resp.Content
+			}
+			return result, nil
+		case <-ctx.Done():
+			pendingElicitations.Del(e.ID)
+			elicitations.Publish(pubsub.DeletedEvent, e)
+			return &mcp.ElicitResult{Action: ElicitationCancel}, nil
+		}
+	}
+}
+
+// ElicitationContent converts the texts entered in the fields of an
+// elicitation to the content of its response. Empty optional fields are
+// left out.
+func ElicitationContent(fields []ElicitationField, texts []string) (map[string]any, error) {
+	content := make(map[string]any, len(fields))
+	for i, f := range fields {
+		if strings.TrimSpace(texts[i]) == "" {
+			if f.Required {
+				return nil, fmt.Errorf("%s is required", f.label())
+			}
+			continue
+		}
+		v, err := f.Value(texts[i])
+		if err != nil {
+			return nil, err
+		}
+		content[f.Name] = v
+	}
+	return content, nil
+}
+
+// Value converts the text s entered in the field to its value.
+func (f ElicitationField) Value(s string) (any, error) {
+	s = strings.TrimSpace(s)
+	if len(f.Options) > 0 && !slices.Contains(f.Options, s) {
+		return nil, fmt.Errorf("%s must be one of %s", f.label(), strings.Join(f.Options, ", "))
+	}
+	switch f.Type {
+	case "boolean":
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", f.label())
+		}
+		return v, nil
+	case "integer":
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", f.label())
+		}
+		return v, nil
+	case "number":
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.label())
+		}
+		return v, nil
+	default:
+		return s, nil
+	}
+}
+
+func (f ElicitationField) label() string {
+	if f.Title != "" {
+		return f.Title
+	}
+	return f.Name
+}
+
+// elicitationProperty is a property of the schema of an elicitation.
+// Elicitations only have properties of primitive types.
+type elicitationProperty struct {
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Format      string   `json:"format"`
+	Enum        []string `json:"enum"`
+	EnumNames   []string `json:"enumNames"`
+	OneOf       []struct {
+		Const string `json:"const"`
+		Title string `json:"title"`
+	} `json:"oneOf"`
+	Default any `json:"default"`
+}
+
+// elicitationFields returns the fields of the form described by the schema
+// of an elicitation. They are sorted by name, as the order of the
+// properties is lost once the schema is decoded.
+func elicitationFields(schema any) ([]ElicitationField, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var s struct {
+		Properties map[string]elicitationProperty `json:"properties"`
+		Required   []string                       `json:"required"`
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid elicitation schema: %w", err)
+	}
+
+	fields := make([]ElicitationField, 0, len(s.Properties))
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		p := s.Properties[name]
+		f := ElicitationField{
+			Name:        name,
+			Title:       p.Title,
+			Description: p.Description,
+			Type:        p.Type,
+			Format:      p.Format,
+			Required:    slices.Contains(s.Required, name),
+		}
+		switch p.Type {
+		case "string", "number", "integer", "boolean":
+		default:
+			return nil, fmt.Errorf("unsupported type %q of elicitation field %q", p.Type, name)
+		}
+		switch {
+		case len(p.OneOf) > 0:
+			for _, o := range p.OneOf {
+				f.Options = append(f.Options, o.Const)
+				f.OptionTitles = append(f.OptionTitles, cmp.Or(o.Title, o.Const))
+			}
+		case len(p.Enum) > 0:
+			f.Options = p.Enum
+			f.OptionTitles = p.Enum
+			if len(p.EnumNames) == len(p.Enum) {
+				f.OptionTitles = p.EnumNames
+			}
+		case p.Type == "boolean":
+			f.Options = []string{"true", "false"}
+			f.OptionTitles = []string{"Yes", "No"}
+		}
+		if p.Default != nil {
+			f.Default = fmt.Sprint(p.Default)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
@@ -10,8 +10,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +23,7 @@ import (
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	"github.com/charmbracelet/crush/internal/version"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -159,12 +162,15 @@ func Close(ctx context.Context) error {
 	}
 	wg.Wait()
 	broker.Shutdown()
+	elicitations.Shutdown()
+	progress.Shutdown()
 	return nil
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
 func Initialize(ctx context.Context, permissions permission.Service, cfg *config.ConfigStore) {
 	slog.Info("Initializing MCP clients")
+	permissionService = permissions
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.Config().MCP {
@@ -236,7 +242,7 @@ func initClient(ctx context.Context, cfg *config.ConfigStore, name string, m con
 	updateState(name, StateStarting, nil, nil, Counts{})
 
 	// createSession handles its own timeout internally.
-	session, err := createSession(ctx, name, m, resolver)
+	session, err := createSession(ctx, cfg, name, m, resolver)
 	if err != nil {
 		return err
 	}
@@ -311,7 +317,7 @@ func getOrRenewClient(ctx context.Context, cfg *config.ConfigStore, name string)
 	}
 	updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
 
-	sess, err = createSession(ctx, name, m, cfg.Resolver())
+	sess, err = createSession(ctx, cfg, name, m, cfg.Resolver())
 	if err != nil {
 		return nil, err
 	}
@@ -348,7 +354,23 @@ func updateState(name string, state State, err error, client *ClientSession, cou
 	})
 }
 
-func createSession(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*ClientSession, error) {
+// traceRequests returns a middleware tracing the requests sent to the MCP
+// server name. Notifications aren't traced.
+func traceRequests(name string) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if strings.HasPrefix(method, "notifications/") {
+				return next(ctx, method, req)
+			}
+			ctx, end := telemetry.MCPRequest(ctx, name, method)
+			result, err := next(ctx, method, req)
+			end(err)
+			return result, err
+		}
+	}
+}
+
+func createSession(ctx context.Context, cfg *config.ConfigStore, name string, m config.MCPConfig, resolver config.VariableResolver) (*ClientSession, error) {
 	timeout := mcpTimeout(m)
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
@@ -361,7 +383,34 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 		cancelTimer.Stop()
 		return nil, err
 	}
+	if cfg != nil {
+		transport = withOAuth(transport, name, m, cfg)
+	}
+
+	var workingDir string
+	if cfg != nil {
+		workingDir = cfg.WorkingDir()
+	}
+	client := newClient(name, workingDir)
+
+	session, err := client.Connect(mcpCtx, transport, nil)
+	if err != nil {
+		err = maybeStdioErr(err, transport)
+		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
+		slog.Error("MCP client failed to initialize", "error", err, "name", name)
+		cancel()
+		cancelTimer.Stop()
+		return nil, err
+	}
+
+	cancelTimer.Stop()
+	slog.Debug("MCP client initialized", "name", name)
+	return &ClientSession{session, cancel}, nil
+}
 
+// newClient returns the client connecting to the MCP server name, reporting
+// workingDir, if any, as its root.
+func newClient(name, workingDir string) *mcp.Client {
 	client := mcp.NewClient(
 		&mcp.Implementation{
 			Name:    "crush",
@@ -391,22 +440,29 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 				level := parseLevel(req.Params.Level)
 				slog.Log(ctx, level, "MCP log", "name", name, "logger", req.Params.Logger, "data", req.Params.Data)
 			},
+			ProgressNotificationHandler: progressHandler(name),
+			CreateMessageHandler:        createMessageHandler(name),
+			ElicitationHandler:          elicitationHandler(name),
 		},
 	)
-
-	session, err := client.Connect(mcpCtx, transport, nil)
-	if err != nil {
-		err = maybeStdioErr(err, transport)
-		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
-		slog.Error("MCP client failed to initialize", "error", err, "name", name)
-		cancel()
-		cancelTimer.Stop()
-		return nil, err
+	if workingDir != "" {
+		client.AddRoots(&mcp.Root{
+			Name: filepath.Base(workingDir),
+			URI:  fileURI(workingDir),
+		})
 	}
+	client.AddSendingMiddleware(traceRequests(name))
+	return client
+}
 
-	cancelTimer.Stop()
-	slog.Debug("MCP client initialized", "name", name)
-	return &ClientSession{session, cancel}, nil
+// fileURI returns the file URI of the absolute path path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths, like C:/src, go after a slash too.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
 }
 
 // maybeStdioErr if a stdio mcp prints an error in non-json format, it'll fail
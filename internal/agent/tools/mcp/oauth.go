@@ -0,0 +1,592 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+// AuthorizationError is returned for MCP servers requiring an authorization
+// Crush has no valid token for.
+type AuthorizationError struct {
+	Name string
+}
+
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("mcp '%s' requires authorization: run 'crush login mcp %s'", e.Name, e.Name)
+}
+
+// tokenStore saves the OAuth tokens of MCP servers. It's the
+// [config.ConfigStore] outside of tests.
+type tokenStore interface {
+	SetMCPOAuthToken(name string, client *config.MCPOAuthClient, token *oauth.Token) error
+	LoadMCPOAuthToken(name string) (*oauth.Token, error)
+}
+
+// oauthHTTPClient sends the requests to the authorization servers, and the
+// discovery requests to the MCP servers, which don't need their headers.
+var oauthHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// withOAuth makes the HTTP and SSE transports authorize their requests
+// with the OAuth token of the MCP server name, unless its headers
+// authorize them already.
+func withOAuth(transport mcp.Transport, name string, m config.MCPConfig, store tokenStore) mcp.Transport {
+	for k := range m.Headers {
+		if strings.EqualFold(k, "Authorization") {
+			return transport
+		}
+	}
+	switch t := transport.(type) {
+	case *mcp.StreamableClientTransport:
+		t.HTTPClient.Transport = newOAuthTransport(name, t.Endpoint, m, store, t.HTTPClient.Transport)
+	case *mcp.SSEClientTransport:
+		t.HTTPClient.Transport = newOAuthTransport(name, t.Endpoint, m, store, t.HTTPClient.Transport)
+	}
+	return transport
+}
+
+// oauthTransport authorizes the requests to an MCP server with its OAuth
+// token, refreshing the token as it expires or gets rejected.
+type oauthTransport struct {
+	name     string
+	endpoint string
+	client   *config.MCPOAuthClient
+	store    tokenStore
+	base     http.RoundTripper
+
+	mu    sync.Mutex
+	token *oauth.Token
+	// server is discovered on the first refresh.
+	server *authServer
+}
+
+func newOAuthTransport(name, endpoint string, m config.MCPConfig, store tokenStore, base http.RoundTripper) *oauthTransport {
+	return &oauthTransport{
+		name:     name,
+		endpoint: endpoint,
+		client:   m.OAuthClient,
+		store:    store,
+		base:     base,
+		token:    m.OAuthToken,
+	}
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.currentToken(req.Context())
+	resp, err := t.send(req, token)
+	if err != nil || !requiresAuthorization(resp) {
+		return resp, err
+	}
+
+	canRetry := req.Body == nil || req.GetBody != nil
+	if token != nil && token.RefreshToken != "" && canRetry {
+		refreshed, err := t.refresh(req.Context(), token, resp)
+		if err == nil {
+			drain(resp)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+			resp, err = t.send(req, refreshed)
+			if err != nil || !requiresAuthorization(resp) {
+				return resp, err
+			}
+		} else {
+			slog.Warn("Failed to refresh MCP OAuth token", "name", t.name, "error", err)
+		}
+	}
+	drain(resp)
+	return nil, &AuthorizationError{Name: t.name}
+}
+
+func (t *oauthTransport) send(req *http.Request, token *oauth.Token) (*http.Response, error) {
+	if token != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// currentToken returns the token to send, refreshing it first if it
+// expired.
+func (t *oauthTransport) currentToken(ctx context.Context) *oauth.Token {
+	t.mu.Lock()
+	token := t.token
+	t.mu.Unlock()
+	if token == nil || !expired(token) || token.RefreshToken == "" {
+		return token
+	}
+	refreshed, err := t.refresh(ctx, token, nil)
+	if err != nil {
+		slog.Warn("Failed to refresh MCP OAuth token", "name", t.name, "error", err)
+		return token
+	}
+	return refreshed
+}
+
+// refresh refreshes the token old, rejected with resp if not nil, and saves
+// the new one. As [config.ConfigStore.RefreshOAuthToken] does, it uses the
+// token on disk instead if another Crush session refreshed it already.
+func (t *oauthTransport) refresh(ctx context.Context, old *oauth.Token, resp *http.Response) (*oauth.Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != nil && t.token.AccessToken != old.AccessToken {
+		// Refreshed by a concurrent request.
+		return t.token, nil
+	}
+	if token := t.diskToken(old); token != nil {
+		slog.Info("Using MCP OAuth token refreshed by another session", "name", t.name)
+		t.token = token
+		return token, nil
+	}
+	if t.client == nil || t.client.ClientID == "" {
+		return nil, errors.New("no oauth client configured")
+	}
+
+	if t.server == nil {
+		server, err := discover(ctx, t.endpoint, resp, t.client)
+		if err != nil {
+			return nil, err
+		}
+		t.server = server
+	}
+	token, err := requestToken(ctx, t.server.meta.TokenEndpoint, t.client, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {old.RefreshToken},
+		"resource":      {t.server.resource},
+	})
+	if err != nil {
+		if token := t.diskToken(old); token != nil {
+			t.token = token
+			return token, nil
+		}
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = old.RefreshToken
+	}
+
+	slog.Info("Successfully refreshed MCP OAuth token", "name", t.name)
+	t.token = token
+	if err := t.store.SetMCPOAuthToken(t.name, t.client, token); err != nil {
+		slog.Warn("Failed to persist refreshed MCP OAuth token", "name", t.name, "error", err)
+	}
+	return token, nil
+}
+
+// diskToken returns the token on disk if it's valid and newer than old.
+func (t *oauthTransport) diskToken(old *oauth.Token) *oauth.Token {
+	token, err := t.store.LoadMCPOAuthToken(t.name)
+	if err != nil || token == nil || expired(token) || token.AccessToken == old.AccessToken {
+		return nil
+	}
+	return token
+}
+
+// requiresAuthorization reports whether resp asks for a token, or for a
+// token with more scopes.
+func requiresAuthorization(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return true
+	case http.StatusForbidden:
+		return challengeParam(challenges(resp), "error") == "insufficient_scope"
+	default:
+		return false
+	}
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+}
+
+// expired reports whether token expired. Tokens without expiration never
+// do.
+func expired(token *oauth.Token) bool {
+	return token.ExpiresAt != 0 && token.IsExpired()
+}
+
+// authServer is the authorization server of an MCP server.
+type authServer struct {
+	// resource is the canonical URL of the MCP server, which tokens are
+	// requested for.
+	resource string
+	meta     *oauthex.AuthServerMeta
+	scopes   []string
+}
+
+// discover discovers the authorization server of the MCP server at
+// endpoint following the MCP authorization spec: from the protected
+// resource metadata, found through the challenges of resp, if not nil, or
+// at the well-known URLs, falling back to the origin of the MCP server.
+func discover(ctx context.Context, endpoint string, resp *http.Response, client *config.MCPOAuthClient) (*authServer, error) {
+	challenges := challenges(resp)
+	prm, err := protectedResource(ctx, endpoint, challengeParam(challenges, "resource_metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := prm.AuthorizationServers[0]
+	meta, err := auth.GetAuthServerMetadata(ctx, issuer, oauthHTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authorization server metadata: %w", err)
+	}
+	if meta == nil {
+		// Servers predating metadata discovery use the default endpoints.
+		issuer = strings.TrimSuffix(issuer, "/")
+		meta = &oauthex.AuthServerMeta{
+			Issuer:                issuer,
+			AuthorizationEndpoint: issuer + "/authorize",
+			TokenEndpoint:         issuer + "/token",
+			RegistrationEndpoint:  issuer + "/register",
+		}
+	}
+
+	server := &authServer{resource: prm.Resource, meta: meta}
+	switch {
+	case client != nil && len(client.Scopes) > 0:
+		server.scopes = client.Scopes
+	case challengeParam(challenges, "scope") != "":
+		server.scopes = strings.Fields(challengeParam(challenges, "scope"))
+	default:
+		server.scopes = prm.ScopesSupported
+	}
+	return server, nil
+}
+
+// protectedResource returns the protected resource metadata of the MCP
+// server at endpoint.
+func protectedResource(ctx context.Context, endpoint, metadataURL string) (*oauthex.ProtectedResourceMetadata, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mcp url: %w", err)
+	}
+	origin := &url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	type candidate struct{ metadataURL, resource string }
+	var candidates []candidate
+	if metadataURL != "" {
+		candidates = append(candidates, candidate{metadataURL, endpoint})
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		candidates = append(candidates, candidate{origin.JoinPath("/.well-known/oauth-protected-resource", path).String(), endpoint})
+	}
+	candidates = append(candidates, candidate{origin.JoinPath("/.well-known/oauth-protected-resource").String(), origin.String()})
+
+	for _, c := range candidates {
+		prm, err := oauthex.GetProtectedResourceMetadata(ctx, c.metadataURL, c.resource, oauthHTTPClient)
+		if err != nil || prm == nil {
+			continue
+		}
+		if len(prm.AuthorizationServers) == 0 {
+			return nil, fmt.Errorf("protected resource metadata of %s has no authorization servers", endpoint)
+		}
+		return prm, nil
+	}
+	// Servers predating protected resource metadata are their own
+	// authorization server.
+	return &oauthex.ProtectedResourceMetadata{
+		Resource:             endpoint,
+		AuthorizationServers: []string{origin.String()},
+	}, nil
+}
+
+func challenges(resp *http.Response) []oauthex.Challenge {
+	if resp == nil {
+		return nil
+	}
+	challenges, err := oauthex.ParseWWWAuthenticate(resp.Header.Values("WWW-Authenticate"))
+	if err != nil {
+		slog.Debug("Invalid WWW-Authenticate header", "error", err)
+	}
+	return challenges
+}
+
+// challengeParam returns the parameter key of the first bearer challenge
+// having it.
+func challengeParam(challenges []oauthex.Challenge, key string) string {
+	for _, c := range challenges {
+		if c.Scheme == "bearer" && c.Params[key] != "" {
+			return c.Params[key]
+		}
+	}
+	return ""
+}
+
+// tokenResponse is the response of a token endpoint, per RFC 6749.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken requests a token for client from the token endpoint
+// tokenURL, with the grant in values.
+func requestToken(ctx context.Context, tokenURL string, client *config.MCPOAuthClient, values url.Values) (*oauth.Token, error) {
+	values.Set("client_id", client.ClientID)
+	if client.ClientSecret != "" {
+		values.Set("client_secret", client.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s", cmp.Or(body.ErrorDescription, body.Error, resp.Status))
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+
+	token := &oauth.Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		ExpiresIn:    body.ExpiresIn,
+	}
+	if token.ExpiresIn > 0 {
+		token.SetExpiresAt()
+	}
+	return token, nil
+}
+
+// Login authorizes Crush with the HTTP or SSE MCP server name following the
+// MCP authorization spec, and saves the token. openURL is called with the
+// URL to authorize Crush at in the browser, which then redirects to a
+// server listening on localhost until ctx is done.
+func Login(ctx context.Context, cfg *config.ConfigStore, name string, openURL func(string)) error {
+	m, ok := cfg.Config().MCP[name]
+	if !ok {
+		return fmt.Errorf("mcp '%s' not found in configuration", name)
+	}
+	return login(ctx, cfg, name, m, cfg.Resolver(), openURL)
+}
+
+func login(ctx context.Context, store tokenStore, name string, m config.MCPConfig, resolver config.VariableResolver, openURL func(string)) error {
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return fmt.Errorf("mcp '%s' is not an http or sse server", name)
+	}
+	endpoint, err := m.ResolvedURL(resolver)
+	if err != nil {
+		return err
+	}
+	headers, err := m.ResolvedHeaders(resolver)
+	if err != nil {
+		return err
+	}
+
+	server, err := discover(ctx, endpoint, probe(ctx, endpoint, headers), m.OAuthClient)
+	if err != nil {
+		return err
+	}
+
+	listener, err := listenRedirect(m.OAuthClient)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the authorization redirect: %w", err)
+	}
+	defer listener.Close()
+	redirectURL := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	client := m.OAuthClient
+	if client == nil || client.ClientID == "" || (client.RedirectURL != "" && client.RedirectURL != redirectURL) {
+		if client, err = register(ctx, server, m.OAuthClient, redirectURL); err != nil {
+			return err
+		}
+	}
+
+	verifier := rand.Text() + rand.Text()
+	challenge := sha256.Sum256([]byte(verifier))
+	state := rand.Text()
+	authURL, err := url.Parse(server.meta.AuthorizationEndpoint)
+	if err != nil {
+		return fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", client.ClientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("resource", server.resource)
+	if len(server.scopes) > 0 {
+		query.Set("scope", strings.Join(server.scopes, " "))
+	}
+	authURL.RawQuery = query.Encode()
+
+	codes := make(chan redirectResult, 1)
+	srv := &http.Server{
+		Handler:           redirectHandler(state, codes),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Close()
+
+	openURL(authURL.String())
+
+	var result redirectResult
+	select {
+	case result = <-codes:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	token, err := requestToken(ctx, server.meta.TokenEndpoint, client, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {result.code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+		"resource":      {server.resource},
+	})
+	if err != nil {
+		return err
+	}
+	return store.SetMCPOAuthToken(name, client, token)
+}
+
+// probe requests the MCP server at endpoint without a token, returning the
+// response if it requires one, as its challenges may point to the
+// protected resource metadata.
+func probe(ctx context.Context, endpoint string, headers map[string]string) *http.Response {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	drain(resp)
+	if !requiresAuthorization(resp) {
+		return nil
+	}
+	return resp
+}
+
+// listenRedirect listens on the localhost port the browser is redirected
+// to: the one configured, or the one a registered client was registered
+// with if it's free, or a random one.
+func listenRedirect(client *config.MCPOAuthClient) (net.Listener, error) {
+	if client != nil && client.RedirectPort != 0 {
+		return net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", client.RedirectPort))
+	}
+	if client != nil && client.RedirectURL != "" {
+		if u, err := url.Parse(client.RedirectURL); err == nil {
+			if listener, err := net.Listen("tcp", u.Host); err == nil {
+				return listener, nil
+			}
+		}
+	}
+	return net.Listen("tcp", "127.0.0.1:0")
+}
+
+// register registers Crush with the authorization server of server
+// through dynamic client registration, keeping the options of configured.
+func register(ctx context.Context, server *authServer, configured *config.MCPOAuthClient, redirectURL string) (*config.MCPOAuthClient, error) {
+	if server.meta.RegistrationEndpoint == "" {
+		return nil, errors.New("the authorization server doesn't support dynamic client registration: set the client_id of oauth_client to a client registered with it")
+	}
+	resp, err := oauthex.RegisterClient(ctx, server.meta.RegistrationEndpoint, &oauthex.ClientRegistrationMetadata{
+		RedirectURIs:            []string{redirectURL},
+		TokenEndpointAuthMethod: "none",
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		ResponseTypes:           []string{"code"},
+		ClientName:              "Crush",
+		ClientURI:               "https://github.com/charmbracelet/crush",
+		Scope:                   strings.Join(server.scopes, " "),
+		ApplicationType:         "native",
+	}, oauthHTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register client: %w", err)
+	}
+
+	client := &config.MCPOAuthClient{
+		ClientID:     resp.ClientID,
+		ClientSecret: resp.ClientSecret,
+		RedirectURL:  redirectURL,
+	}
+	if configured != nil {
+		client.Scopes = configured.Scopes
+		client.RedirectPort = configured.RedirectPort
+	}
+	return client, nil
+}
+
+type redirectResult struct {
+	code string
+	err  error
+}
+
+// redirectHandler handles the redirect of the browser back to Crush,
+// sending the authorization code, or the error, to results.
+func redirectHandler(state string, results chan<- redirectResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var result redirectResult
+		switch {
+		case query.Get("state") != state:
+			http.Error(w, "Invalid state.", http.StatusBadRequest)
+			return
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s", cmp.Or(query.Get("error_description"), query.Get("error")))
+			_, _ = fmt.Fprintln(w, "Authorization failed. You can close this window.")
+		case query.Get("code") == "":
+			result.err = errors.New("authorization failed: no code in the redirect")
+			_, _ = fmt.Fprintln(w, "Authorization failed. You can close this window.")
+		default:
+			result.code = query.Get("code")
+			_, _ = fmt.Fprintln(w, "Crush is authorized. You can close this window.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
@@ -0,0 +1,277 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// authServerStub is a stand-in authorization server protecting an MCP
+// server at /mcp.
+type authServerStub struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	issued    int
+	tokens    map[string]bool
+	refreshes map[string]bool
+	codes     map[string]string // code -> code challenge
+	resources []string
+}
+
+func newAuthServerStub(t *testing.T) *authServerStub {
+	t.Helper()
+	s := &authServerStub{
+		tokens:    map[string]bool{},
+		refreshes: map[string]bool{},
+		codes:     map[string]string{},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "protected"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "ping"}, func(_ context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "pong"}}}, nil, nil
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, &mcp.StreamableHTTPOptions{Stateless: true})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		s.mu.Unlock()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource/mcp", scope="tools"`, s.URL))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"resource":              s.URL + "/mcp",
+			"authorization_servers": []string{s.URL + "/auth"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/auth", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                           s.URL + "/auth",
+			"authorization_endpoint":           s.URL + "/auth/authorize",
+			"token_endpoint":                   s.URL + "/auth/token",
+			"registration_endpoint":            s.URL + "/auth/register",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/auth/register", func(w http.ResponseWriter, r *http.Request) {
+		var meta map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&meta)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, map[string]any{"client_id": "registered", "redirect_uris": meta["redirect_uris"]})
+	})
+	mux.HandleFunc("/auth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != "registered" || q.Get("code_challenge_method") != "S256" || q.Get("scope") != "tools" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.codes["code"] = q.Get("code_challenge")
+		s.resources = append(s.resources, q.Get("resource"))
+		s.mu.Unlock()
+		redirect, _ := url.Parse(q.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"code"}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.resources = append(s.resources, r.Form.Get("resource"))
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if s.codes[r.Form.Get("code")] != base64.RawURLEncoding.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]any{"error": "invalid_grant"})
+				return
+			}
+			delete(s.codes, r.Form.Get("code"))
+		case "refresh_token":
+			if !s.refreshes[r.Form.Get("refresh_token")] {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]any{"error": "invalid_grant", "error_description": "unknown refresh token"})
+				return
+			}
+			delete(s.refreshes, r.Form.Get("refresh_token"))
+		}
+		s.issued++
+		access, refresh := fmt.Sprintf("access-%d", s.issued), fmt.Sprintf("refresh-%d", s.issued)
+		s.tokens[access] = true
+		s.refreshes[refresh] = true
+		writeJSON(w, map[string]any{"access_token": access, "refresh_token": refresh, "expires_in": 3600, "token_type": "Bearer"})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// revoke revokes all the access tokens, as if they expired.
+func (s *authServerStub) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// memoryTokenStore is a token store in memory, standing for the config
+// file.
+type memoryTokenStore struct {
+	mu     sync.Mutex
+	client *config.MCPOAuthClient
+	token  *oauth.Token
+}
+
+func (s *memoryTokenStore) SetMCPOAuthToken(_ string, client *config.MCPOAuthClient, token *oauth.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client, s.token = client, token
+	return nil
+}
+
+func (s *memoryTokenStore) LoadMCPOAuthToken(string) (*oauth.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// browse follows the authorization URL as the browser would.
+func browse(authURL string) {
+	go func() {
+		resp, err := http.Get(authURL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+}
+
+func connectProtected(t *testing.T, store *memoryTokenStore, m config.MCPConfig) (*mcp.ClientSession, error) {
+	t.Helper()
+	transport, err := createTransport(t.Context(), m, config.IdentityResolver())
+	require.NoError(t, err)
+	transport = withOAuth(transport, "protected", m, store)
+	client := mcp.NewClient(&mcp.Implementation{Name: "crush"}, nil)
+	sess, err := client.Connect(t.Context(), transport, nil)
+	if err == nil {
+		t.Cleanup(func() { _ = sess.Close() })
+	}
+	return sess, err
+}
+
+func TestOAuth(t *testing.T) {
+	t.Parallel()
+
+	s := newAuthServerStub(t)
+	store := &memoryTokenStore{}
+	m := config.MCPConfig{Type: config.MCPHttp, URL: s.URL + "/mcp"}
+
+	_, err := connectProtected(t, store, m)
+	var authErr *AuthorizationError
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, "mcp 'protected' requires authorization: run 'crush login mcp protected'", authErr.Error())
+
+	require.NoError(t, login(t.Context(), store, "protected", m, config.IdentityResolver(), browse))
+	require.Equal(t, "registered", store.client.ClientID)
+	require.Contains(t, store.client.RedirectURL, "http://127.0.0.1:")
+	require.Equal(t, "access-1", store.token.AccessToken)
+	require.Equal(t, "refresh-1", store.token.RefreshToken)
+	require.Equal(t, []string{s.URL + "/mcp", s.URL + "/mcp"}, s.resources)
+
+	m.OAuthClient, m.OAuthToken = store.client, store.token
+	sess, err := connectProtected(t, store, m)
+	require.NoError(t, err)
+
+	t.Run("refreshes rejected tokens", func(t *testing.T) {
+		s.revoke()
+		res, err := sess.CallTool(t.Context(), &mcp.CallToolParams{Name: "ping"})
+		require.NoError(t, err)
+		require.Equal(t, "pong", res.Content[0].(*mcp.TextContent).Text)
+		require.Equal(t, "access-2", store.token.AccessToken)
+		require.Equal(t, "refresh-2", store.token.RefreshToken)
+	})
+
+	t.Run("refreshes expired tokens", func(t *testing.T) {
+		m.OAuthToken = &oauth.Token{
+			AccessToken:  store.token.AccessToken,
+			RefreshToken: store.token.RefreshToken,
+			ExpiresIn:    3600,
+			ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+		}
+		_, err := connectProtected(t, store, m)
+		require.NoError(t, err)
+		require.Equal(t, "access-3", store.token.AccessToken)
+	})
+
+	t.Run("uses tokens refreshed by other sessions", func(t *testing.T) {
+		m.OAuthToken = &oauth.Token{AccessToken: "stale", RefreshToken: "refresh-1"}
+		_, err := connectProtected(t, store, m)
+		require.NoError(t, err)
+		require.Equal(t, "access-3", store.token.AccessToken)
+	})
+
+	t.Run("requires authorization once refresh tokens are rejected", func(t *testing.T) {
+		s.revoke()
+		m.OAuthToken = &oauth.Token{AccessToken: "stale", RefreshToken: "unknown"}
+		_, err := connectProtected(t, &memoryTokenStore{}, m)
+		require.ErrorAs(t, err, &authErr)
+	})
+}
+
+func TestOAuthLoginErrors(t *testing.T) {
+	t.Parallel()
+
+	err := login(t.Context(), &memoryTokenStore{}, "stdio", config.MCPConfig{Type: config.MCPStdio}, config.IdentityResolver(), nil)
+	require.EqualError(t, err, "mcp 'stdio' is not an http or sse server")
+
+	s := newAuthServerStub(t)
+	m := config.MCPConfig{Type: config.MCPHttp, URL: s.URL + "/mcp"}
+	deny := func(authURL string) {
+		u, _ := url.Parse(authURL)
+		redirect, _ := url.Parse(u.Query().Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"error": {"access_denied"}, "state": {u.Query().Get("state")}}.Encode()
+		browse(redirect.String())
+	}
+	err = login(t.Context(), &memoryTokenStore{}, "protected", m, config.IdentityResolver(), deny)
+	require.EqualError(t, err, "authorization failed: access_denied")
+}
+
+func TestWithOAuthSkipsAuthorizationHeaders(t *testing.T) {
+	t.Parallel()
+
+	m := config.MCPConfig{
+		Type:    config.MCPHttp,
+		URL:     "https://mcp.example.com/mcp",
+		Headers: map[string]string{"authorization": "Bearer static"},
+	}
+	transport, err := createTransport(t.Context(), m, config.IdentityResolver())
+	require.NoError(t, err)
+	transport = withOAuth(transport, "static", m, &memoryTokenStore{})
+	require.IsType(t, &headerRoundTripper{}, transport.(*mcp.StreamableClientTransport).HTTPClient.Transport)
+}
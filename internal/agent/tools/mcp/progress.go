@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Progress is the progress an MCP server reports on a running tool call.
+type Progress struct {
+	Name       string
+	SessionID  string
+	ToolCallID string
+	Progress   float64
+	// Total is the total progress, or 0 when unknown.
+	Total   float64
+	Message string
+}
+
+var progress = pubsub.NewBroker[Progress]()
+
+// SubscribeProgress returns a channel for the progress of the tool calls.
+func SubscribeProgress(ctx context.Context) <-chan pubsub.Event[Progress] {
+	return progress.Subscribe(ctx)
+}
+
+// progressHandler returns the handler of the progress notifications of the
+// MCP server name, which refer to the tool calls by their IDs.
+func progressHandler(name string) func(context.Context, *mcp.ProgressNotificationClientRequest) {
+	return func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+		call, ok := findToolCall(name, fmt.Sprint(req.Params.ProgressToken))
+		if !ok {
+			return
+		}
+		progress.Publish(pubsub.UpdatedEvent, Progress{
+			Name:       name,
+			SessionID:  call.sessionID,
+			ToolCallID: call.toolCallID,
+			Progress:   req.Params.Progress,
+			Total:      req.Params.Total,
+			Message:    req.Params.Message,
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// newRequestingServer returns a server whose tools make requests to the
+// client and return what they got. The progress tool reports its progress,
+// then waits for release before returning, as progress reported on ended
+// tool calls is dropped.
+func newRequestingServer(release <-chan struct{}) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "requesting"}, nil)
+	text := func(format string, args ...any) *mcp.CallToolResult {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(format, args...)}}}
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "roots"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		res, err := req.Session.ListRoots(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return text("%s %s", res.Roots[0].Name, res.Roots[0].URI), nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "progress"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		for i := range 2 {
+			err := req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: req.Params.GetProgressToken(),
+				Progress:      float64(i + 1),
+				Total:         2,
+				Message:       fmt.Sprintf("step %d", i+1),
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		<-release
+		return text("done"), nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "sample"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		res, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			SystemPrompt:     "Be brief.",
+			Messages:         []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "hi"}}},
+			MaxTokens:        10,
+			ModelPreferences: &mcp.ModelPreferences{SpeedPriority: 1},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return text("%s from %s (%s)", res.Content.(*mcp.TextContent).Text, res.Model, res.StopReason), nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "ask"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+			Message: "Who are you?",
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string", "title": "Name"},
+					"age":  map[string]any{"type": "integer"},
+				},
+				"required": []string{"name"},
+			},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return text("%s %v", res.Action, res.Content), nil, nil
+	})
+	return server
+}
+
+// connectRequestingServer connects to a requesting server as the MCP
+// server name, working in workingDir.
+func connectRequestingServer(t *testing.T, name, workingDir string, release <-chan struct{}) *config.ConfigStore {
+	t.Helper()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := newRequestingServer(release).Connect(t.Context(), serverTransport, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cs, err := newClient(name, workingDir).Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	sessions.Set(name, &ClientSession{cs, cancel})
+	t.Cleanup(func() {
+		sessions.Del(name)
+		_ = cs.Close()
+		_ = ss.Close()
+	})
+
+	cfg, err := config.Init(workingDir, "", false)
+	require.NoError(t, err)
+	cfg.Config().MCP = map[string]config.MCPConfig{name: {Type: config.MCPStdio}}
+	return cfg
+}
+
+func TestServerRequests(t *testing.T) {
+	workingDir := t.TempDir()
+	release := make(chan struct{})
+	cfg := connectRequestingServer(t, "requesting", workingDir, release)
+	ctx := WithToolCall(t.Context(), "session", "call")
+
+	t.Run("roots", func(t *testing.T) {
+		res, err := RunTool(ctx, cfg, "requesting", "roots", "{}")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Base(workingDir)+" "+fileURI(workingDir), res.Content)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		events := SubscribeProgress(t.Context())
+		done := make(chan ToolResult, 1)
+		go func() {
+			res, err := RunTool(ctx, cfg, "requesting", "progress", "{}")
+			require.NoError(t, err)
+			done <- res
+		}()
+		for i := range 2 {
+			ev := <-events
+			require.Equal(t, Progress{
+				Name:       "requesting",
+				SessionID:  "session",
+				ToolCallID: "call",
+				Progress:   float64(i + 1),
+				Total:      2,
+				Message:    fmt.Sprintf("step %d", i+1),
+			}, ev.Payload)
+		}
+		close(release)
+		require.Equal(t, "done", (<-done).Content)
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		permissions := permission.NewPermissionService(workingDir, false, nil, nil, nil)
+		permissionService = permissions
+		var small bool
+		var call fantasy.Call
+		SetSampler(func(_ context.Context, sessionID string, s bool, c fantasy.Call) (*fantasy.Response, string, error) {
+			require.Equal(t, "session", sessionID)
+			small, call = s, c
+			return &fantasy.Response{
+				Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "hello"}},
+				FinishReason: fantasy.FinishReasonStop,
+			}, "small-model", nil
+		})
+		t.Cleanup(func() {
+			SetSampler(nil)
+			permissionService = nil
+		})
+
+		requests := permissions.Subscribe(t.Context())
+		answer := func(grant bool) {
+			go func() {
+				req := (<-requests).Payload
+				require.Equal(t, "mcp_requesting_sampling", req.ToolName)
+				require.Equal(t, "call", req.ToolCallID)
+				if grant {
+					permissions.Grant(req)
+				} else {
+					permissions.Deny(req)
+				}
+			}()
+		}
+
+		answer(true)
+		res, err := RunTool(ctx, cfg, "requesting", "sample", "{}")
+		require.NoError(t, err)
+		require.Equal(t, "hello from small-model (endTurn)", res.Content)
+		require.True(t, small)
+		require.Equal(t, int64(10), *call.MaxOutputTokens)
+		require.Equal(t, fantasy.Prompt{
+			fantasy.NewSystemMessage("Be brief."),
+			fantasy.NewUserMessage("hi"),
+		}, call.Prompt)
+
+		answer(false)
+		res, err = RunTool(ctx, cfg, "requesting", "sample", "{}")
+		require.NoError(t, err)
+		require.Contains(t, res.Content, "sampling was denied by the user")
+	})
+
+	t.Run("elicitation", func(t *testing.T) {
+		elicitationsCtx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		events := SubscribeElicitations(elicitationsCtx)
+		go func() {
+			e := (<-events).Payload
+			require.Equal(t, "Who are you?", e.Message)
+			require.Equal(t, "call", e.ToolCallID)
+			require.Equal(t, []ElicitationField{
+				{Name: "age", Type: "integer"},
+				{Name: "name", Title: "Name", Type: "string", Required: true},
+			}, e.Fields)
+			content, err := ElicitationContent(e.Fields, []string{"", "Ada"})
+			require.NoError(t, err)
+			require.NoError(t, AnswerElicitation(e.ID, ElicitationResponse{Action: ElicitationAccept, Content: content}))
+		}()
+		res, err := RunTool(ctx, cfg, "requesting", "ask", "{}")
+		require.NoError(t, err)
+		require.Equal(t, "accept map[name:Ada]", res.Content)
+
+		DeclineElicitations("declined")
+		res, err = RunTool(WithToolCall(t.Context(), "declined", "call"), cfg, "requesting", "ask", "{}")
+		require.NoError(t, err)
+		require.Equal(t, "decline map[]", res.Content)
+	})
+
+	t.Run("elicitations end with their tool calls", func(t *testing.T) {
+		elicitationsCtx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		events := SubscribeElicitations(elicitationsCtx)
+
+		callCtx, cancelCall := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			_, err := RunTool(callCtx, cfg, "requesting", "ask", "{}")
+			done <- err
+		}()
+		created := <-events
+		require.Equal(t, pubsub.CreatedEvent, created.Type)
+		cancelCall()
+		deleted := <-events
+		require.Equal(t, pubsub.DeletedEvent, deleted.Type)
+		require.Equal(t, created.Payload.ID, deleted.Payload.ID)
+		require.Error(t, <-done)
+		require.Error(t, AnswerElicitation(created.Payload.ID, ElicitationResponse{Action: ElicitationAccept}))
+	})
+}
+
+func TestRequestToolCall(t *testing.T) {
+	t.Parallel()
+
+	first, endFirst := startToolCall(WithToolCall(t.Context(), "session-1", "call-1"), "busy")
+
+	params := &mcp.CreateMessageParams{}
+	call, ok := requestToolCall("busy", params)
+	require.True(t, ok, "the only running call")
+	require.Same(t, first, call)
+
+	second, endSecond := startToolCall(WithToolCall(t.Context(), "session-2", "call-2"), "busy")
+	_, ok = requestToolCall("busy", params)
+	require.False(t, ok, "requests of concurrent calls can't be told apart")
+
+	params.SetProgressToken("call-2")
+	call, ok = requestToolCall("busy", params)
+	require.True(t, ok, "the call of the progress token")
+	require.Same(t, second, call)
+
+	params.SetProgressToken("call-3")
+	_, ok = requestToolCall("busy", params)
+	require.False(t, ok)
+
+	endSecond()
+	endFirst()
+	_, ok = requestToolCall("busy", &mcp.ElicitParams{})
+	require.False(t, ok, "no call is running")
+
+	_, endAnonymous := startToolCall(t.Context(), "busy")
+	defer endAnonymous()
+	_, ok = requestToolCall("busy", &mcp.ElicitParams{})
+	require.False(t, ok, "calls without a session")
+}
+
+func TestElicitationContent(t *testing.T) {
+	t.Parallel()
+
+	fields, err := elicitationFields(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count":   map[string]any{"type": "integer", "default": 3},
+			"ok":      map[string]any{"type": "boolean"},
+			"ratio":   map[string]any{"type": "number"},
+			"size":    map[string]any{"type": "string", "enum": []string{"s", "m"}, "enumNames": []string{"Small", "Medium"}},
+			"station": map[string]any{"type": "string", "oneOf": []map[string]any{{"const": "a", "title": "Alpha"}}},
+		},
+		"required": []string{"ok"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ElicitationField{
+		{Name: "count", Type: "integer", Default: "3"},
+		{Name: "ok", Type: "boolean", Required: true, Options: []string{"true", "false"}, OptionTitles: []string{"Yes", "No"}},
+		{Name: "ratio", Type: "number"},
+		{Name: "size", Type: "string", Options: []string{"s", "m"}, OptionTitles: []string{"Small", "Medium"}},
+		{Name: "station", Type: "string", Options: []string{"a"}, OptionTitles: []string{"Alpha"}},
+	}, fields)
+
+	content, err := ElicitationContent(fields, []string{"4", "true", "0.5", "m", ""})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"count": int64(4), "ok": true, "ratio": 0.5, "size": "m"}, content)
+
+	_, err = ElicitationContent(fields, []string{"", "", "", "", ""})
+	require.EqualError(t, err, "ok is required")
+	_, err = ElicitationContent(fields, []string{"four", "true", "", "", ""})
+	require.EqualError(t, err, "count must be an integer")
+	_, err = ElicitationContent(fields, []string{"", "true", "", "l", ""})
+	require.EqualError(t, err, "size must be one of s, m")
+
+	_, err = elicitationFields(map[string]any{"properties": map[string]any{"tags": map[string]any{"type": "array"}}})
+	require.EqualError(t, err, `unsupported type "array" of elicitation field "tags"`)
+}
+
+func TestSamplingCall(t *testing.T) {
+	t.Parallel()
+
+	call, err := samplingCall(&mcp.CreateMessageParams{
+		Messages: []*mcp.SamplingMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: "what's this?"}},
+			{Role: "user", Content: &mcp.ImageContent{Data: []byte{0x89, 'P', 'N', 'G'}, MIMEType: "image/png"}},
+			{Role: "assistant", Content: &mcp.TextContent{Text: "a picture"}},
+		},
+		Temperature: 0.2,
+	})
+	require.NoError(t, err)
+	require.Nil(t, call.MaxOutputTokens)
+	require.Equal(t, 0.2, *call.Temperature)
+	require.Equal(t, fantasy.Prompt{
+		{Role: fantasy.MessageRoleUser, Content: []fantasy.MessagePart{fantasy.TextPart{Text: "what's this?"}}},
+		{Role: fantasy.MessageRoleUser, Content: []fantasy.MessagePart{fantasy.FilePart{Data: []byte{0x89, 'P', 'N', 'G'}, MediaType: "image/png"}}},
+		{Role: fantasy.MessageRoleAssistant, Content: []fantasy.MessagePart{fantasy.TextPart{Text: "a picture"}}},
+	}, call.Prompt)
+
+	_, err = samplingCall(&mcp.CreateMessageParams{SystemPrompt: "alone"})
+	require.EqualError(t, err, "sampling requires messages")
+
+	require.False(t, prefersSmallModel(nil))
+	require.False(t, prefersSmallModel(&mcp.ModelPreferences{SpeedPriority: 0.5, IntelligencePriority: 0.8}))
+	require.True(t, prefersSmallModel(&mcp.ModelPreferences{CostPriority: 0.9, IntelligencePriority: 0.1}))
+}
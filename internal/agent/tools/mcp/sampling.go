@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Sampler generates a response to call with the small or the large model
+// of the session sessionID, and returns it along with the ID of the model.
+type Sampler func(ctx context.Context, sessionID string, small bool, call fantasy.Call) (*fantasy.Response, string, error)
+
+var (
+	samplerMu sync.RWMutex
+	sampler   Sampler
+	// permissionService asks the user for permission to sample the models
+	// on behalf of the servers.
+	permissionService permission.Service
+)
+
+// SetSampler sets the sampler answering the sampling requests of the MCP
+// servers. Servers can't sample until it is set.
+func SetSampler(s Sampler) {
+	samplerMu.Lock()
+	defer samplerMu.Unlock()
+	sampler = s
+}
+
+func getSampler() Sampler {
+	samplerMu.RLock()
+	defer samplerMu.RUnlock()
+	return sampler
+}
+
+// samplingPermissionsParams are the parameters shown when asking for
+// permission to sample a model.
+type samplingPermissionsParams struct {
+	Model        string   `json:"model"`
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+	Messages     []string `json:"messages"`
+	MaxTokens    int64    `json:"max_tokens,omitempty"`
+}
+
+// createMessageHandler returns the handler of the sampling requests of the
+// MCP server name. Each request asks for permission, then runs through the
+// model of the session of the tool call it is made for, which is charged
+// for it. Requests which can't be attributed to a tool call are rejected.
+func createMessageHandler(name string) func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		sample := getSampler()
+		if sample == nil || permissionService == nil {
+			return nil, errors.New("sampling is not available")
+		}
+		call, err := samplingCall(req.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		tc, ok := requestToolCall(name, req.Params)
+		if !ok {
+			return nil, errors.New("sampling is only available to a single running tool call")
+		}
+		ctx, cancel := tc.context(ctx)
+		defer cancel()
+
+		small := prefersSmallModel(req.Params.ModelPreferences)
+		granted, err := permissionService.Request(ctx, permission.CreatePermissionRequest{
+			SessionID:   tc.sessionID,
+			ToolCallID:  tc.toolCallID,
+			ToolName:    fmt.Sprintf("mcp_%s_sampling", name),
+			Action:      "sample",
+			Description: fmt.Sprintf("let %s sample the model with the following request:", name),
+			Params:      samplingPermissions(req.Params, small),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, errors.New("sampling was denied by the user")
+		}
+
+		resp, model, err := sample(ctx, tc.sessionID, small, call)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CreateMessageResult{
+			Content:    &mcp.TextContent{Text: resp.Content.Text()},
+			Model:      model,
+			Role:       "assistant",
+			StopReason: stopReason(resp.FinishReason),
+		}, nil
+	}
+}
+
+// samplingCall converts the sampling request params to a model call.
+func samplingCall(params *mcp.CreateMessageParams) (fantasy.Call, error) {
+	var prompt fantasy.Prompt
+	if params.SystemPrompt != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(params.SystemPrompt))
+	}
+	for _, m := range params.Messages {
+		role := fantasy.MessageRoleUser
+		if m.Role == "assistant" {
+			role = fantasy.MessageRoleAssistant
+		}
+		var part fantasy.MessagePart
+		switch c := m.Content.(type) {
+		case *mcp.TextContent:
+			part = fantasy.TextPart{Text: c.Text}
+		case *mcp.ImageContent:
+			part = fantasy.FilePart{Data: ensureRawBytes(c.Data), MediaType: c.MIMEType}
+		case *mcp.AudioContent:
+			part = fantasy.FilePart{Data: ensureRawBytes(c.Data), MediaType: c.MIMEType}
+		default:
+			return fantasy.Call{}, fmt.Errorf("unsupported sampling content: %T", m.Content)
+		}
+		prompt = append(prompt, fantasy.Message{Role: role, Content: []fantasy.MessagePart{part}})
+	}
+	if len(prompt) == 0 || prompt[len(prompt)-1].Role == fantasy.MessageRoleSystem {
+		return fantasy.Call{}, errors.New("sampling requires messages")
+	}
+
+	call := fantasy.Call{Prompt: prompt}
+	if params.MaxTokens > 0 {
+		maxTokens := params.MaxTokens
+		call.MaxOutputTokens = &maxTokens
+	}
+	if params.Temperature != 0 {
+		temperature := params.Temperature
+		call.Temperature = &temperature
+	}
+	return call, nil
+}
+
+// prefersSmallModel reports whether the server values speed or cost more
+// than intelligence.
+func prefersSmallModel(prefs *mcp.ModelPreferences) bool {
+	if prefs == nil {
+		return false
+	}
+	return max(prefs.SpeedPriority, prefs.CostPriority) > prefs.IntelligencePriority
+}
+
+// samplingPermissions returns the parameters shown when asking for
+// permission to sample a model, as JSON.
+func samplingPermissions(params *mcp.CreateMessageParams, small bool) string {
+	p := samplingPermissionsParams{
+		Model:        "large",
+		SystemPrompt: params.SystemPrompt,
+		MaxTokens:    params.MaxTokens,
+	}
+	if small {
+		p.Model = "small"
+	}
+	for _, m := range params.Messages {
+		var text string
+		switch c := m.Content.(type) {
+		case *mcp.TextContent:
+			text = c.Text
+		case *mcp.ImageContent:
+			text = "[image]"
+		case *mcp.AudioContent:
+			text = "[audio]"
+		}
+		p.Messages = append(p.Messages, fmt.Sprintf("%s: %s", m.Role, text))
+	}
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func stopReason(reason fantasy.FinishReason) string {
+	switch reason {
+	case fantasy.FinishReasonStop:
+		return "endTurn"
+	case fantasy.FinishReasonLength:
+		return "maxTokens"
+	case fantasy.FinishReasonToolCalls:
+		return "toolUse"
+	default:
+		return ""
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
@@ -43,10 +44,17 @@ func RunTool(ctx context.Context, cfg *config.ConfigStore, name, toolName string
 	if err != nil {
 		return ToolResult{}, err
 	}
-	result, err := c.CallTool(ctx, &mcp.CallToolParams{
+	params := &mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
-	})
+	}
+	call, end := startToolCall(ctx, name)
+	defer end()
+	if call.toolCallID != "" {
+		// Progress notifications refer to the tool call.
+		params.SetProgressToken(call.toolCallID)
+	}
+	result, err := c.CallTool(ctx, params)
 	if err != nil {
 		return ToolResult{}, err
 	}
@@ -108,6 +116,97 @@ func RunTool(ctx context.Context, cfg *config.ConfigStore, name, toolName string
 	}, nil
 }
 
+// toolCall is a tool call running on an MCP server. The requests of the
+// server, such as sampling and elicitation, are made on its behalf.
+type toolCall struct {
+	ctx        context.Context
+	sessionID  string
+	toolCallID string
+}
+
+type toolCallContextKey struct{}
+
+// WithToolCall returns a context running MCP tools on behalf of the tool
+// call toolCallID of the session sessionID.
+func WithToolCall(ctx context.Context, sessionID, toolCallID string) context.Context {
+	return context.WithValue(ctx, toolCallContextKey{}, toolCall{
+		sessionID:  sessionID,
+		toolCallID: toolCallID,
+	})
+}
+
+var (
+	toolCallsMu sync.Mutex
+	// toolCalls are the tool calls running on each MCP server, in the
+	// order they started.
+	toolCalls = map[string][]*toolCall{}
+)
+
+// startToolCall records the tool call of ctx as running on the MCP server
+// name, until end is called.
+func startToolCall(ctx context.Context, name string) (call *toolCall, end func()) {
+	c, _ := ctx.Value(toolCallContextKey{}).(toolCall)
+	ctx, cancel := context.WithCancel(ctx)
+	call = &toolCall{ctx: ctx, sessionID: c.sessionID, toolCallID: c.toolCallID}
+
+	toolCallsMu.Lock()
+	toolCalls[name] = append(toolCalls[name], call)
+	toolCallsMu.Unlock()
+
+	return call, func() {
+		cancel()
+		toolCallsMu.Lock()
+		defer toolCallsMu.Unlock()
+		toolCalls[name] = slices.DeleteFunc(toolCalls[name], func(c *toolCall) bool { return c == call })
+		if len(toolCalls[name]) == 0 {
+			delete(toolCalls, name)
+		}
+	}
+}
+
+// requestToolCall returns the tool call running on the MCP server name
+// which a request of the server is made for: the one whose progress token
+// the request carries, or else the only one running. Servers can't always
+// tell which call a request is for, and a request which could be for more
+// than one is not attributed at all, as it would be charged to the wrong
+// session.
+func requestToolCall(name string, params interface{ GetProgressToken() any }) (*toolCall, bool) {
+	if token := params.GetProgressToken(); token != nil {
+		return findToolCall(name, fmt.Sprint(token))
+	}
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+	calls := toolCalls[name]
+	if len(calls) != 1 || calls[0].sessionID == "" {
+		return nil, false
+	}
+	return calls[0], true
+}
+
+// findToolCall returns the tool call toolCallID running on the MCP server
+// name.
+func findToolCall(name, toolCallID string) (*toolCall, bool) {
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+	for _, call := range toolCalls[name] {
+		if call.toolCallID != "" && call.toolCallID == toolCallID {
+			return call, true
+		}
+	}
+	return nil, false
+}
+
+// context returns a copy of ctx which is also canceled once the tool call
+// ends.
+func (c *toolCall) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
 // RefreshTools gets the updated list of tools from the MCP and updates the
 // global state.
 func RefreshTools(ctx context.Context, cfg *config.ConfigStore, name string) {
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -59,6 +60,7 @@ var multieditDescription string
 
 func NewMultiEditTool(
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -68,6 +70,7 @@ func NewMultiEditTool(
 		MultiEditToolName,
 		multieditDescription,
 		func(ctx context.Context, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -86,7 +89,7 @@ func NewMultiEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, formatter, workingDir}
 			// Handle file creation case (first edit has empty old_string)
 			if len(params.Edits) > 0 && params.Edits[0].OldString == "" {
 				response, err = processMultiEditWithCreation(editCtx, params, call)
@@ -200,13 +203,16 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	// Write the file
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = writeSessionFile(edit.ctx, params.FilePath, []byte(currentContent))
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	currentContent, formatNote := formatAfterWrite(edit.ctx, edit.formatter, params.FilePath, currentContent)
+	_, additions, removals = diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+
 	// Update file history
-	_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, "")
+	_, err = edit.files.CreateMissing(history.WithoutMessageID(edit.ctx), sessionID, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
@@ -226,7 +232,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+formatNote),
 		MultiEditResponseMetadata{
 			OldContent:   "",
 			NewContent:   currentContent,
@@ -275,7 +281,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Read current file content
-	content, err := os.ReadFile(params.FilePath)
+	content, err := readSessionFile(edit.ctx, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -348,22 +354,25 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Write the updated content
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = writeSessionFile(edit.ctx, params.FilePath, []byte(currentContent))
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	currentContent, formatNote := formatAfterWrite(edit.ctx, edit.formatter, params.FilePath, currentContent)
+	_, additions, removals = diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+
 	// Update file history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, params.FilePath, sessionID)
 	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, oldContent)
+		_, err = edit.files.Create(history.WithoutMessageID(edit.ctx), sessionID, params.FilePath, oldContent)
 		if err != nil {
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 		}
 	}
 	if file.Content != oldContent {
 		// User manually changed the content, store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, oldContent)
+		_, err = edit.files.CreateVersion(history.WithoutMessageID(edit.ctx), sessionID, params.FilePath, oldContent)
 		if err != nil {
 			slog.Error("Error creating file history version", "error", err)
 		}
@@ -385,7 +394,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+formatNote),
 		MultiEditResponseMetadata{
 			OldContent:   oldContent,
 			NewContent:   currentContent,
@@ -54,6 +54,8 @@ func (m *mockPermissionService) ListSessionPermissions(sessionID string) []permi
 }
 func (m *mockPermissionService) DeleteSessionPermission(sessionID string, permissionID string) {}
 
+func (m *mockPermissionService) Policy() *permission.Policy { return nil }
+
 type mockHistoryService struct {
 	*pubsub.Broker[history.File]
 }
@@ -66,6 +68,10 @@ func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path,
 	return history.File{}, nil
 }
 
+func (m *mockHistoryService) CreateMissing(ctx context.Context, sessionID, path string) (history.File, error) {
+	return history.File{Path: path, Missing: true}, nil
+}
+
 func (m *mockHistoryService) GetByPathAndSession(ctx context.Context, path, sessionID string) (history.File, error) {
 	return history.File{Path: path, Content: ""}, nil
 }
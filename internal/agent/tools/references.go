@@ -46,9 +46,9 @@ func NewReferencesTool(lspManager *lsp.Manager) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
 			}
 
-			workingDir := cmp.Or(params.Path, ".")
+			workingDir := cmp.Or(params.Path, WorkingDirFromContext(ctx, "."))
 
-			matches, _, err := searchFiles(ctx, regexp.QuoteMeta(params.Symbol), workingDir, "", 100)
+			matches, _, _, err := searchFiles(ctx, regexp.QuoteMeta(params.Symbol), workingDir, "", 100)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search for symbol: %s", err)), nil
 			}
@@ -101,16 +101,8 @@ func find(ctx context.Context, lspManager *lsp.Manager, symbol string, match gre
 		return nil, fmt.Errorf("failed to get absolute path: %s", err)
 	}
 
-	var client *lsp.Client
-	for c := range lspManager.Clients().Seq() {
-		if c.HandlesFile(absPath) {
-			client = c
-			break
-		}
-	}
-
+	client := clientForFile(ctx, lspManager, absPath)
 	if client == nil {
-		slog.Warn("No LSP clients to handle", "path", match.path)
 		return nil, nil
 	}
 
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path" description:"The path to a file where the symbol appears"`
+	Line     int    `json:"line" description:"The 1-based line where the symbol appears"`
+	Symbol   string `json:"symbol" description:"The current name of the symbol"`
+	NewName  string `json:"new_name" description:"The new name for the symbol"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed rename.md
+var renameDescription string
+
+func NewRenameTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		renameDescription,
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			switch {
+			case params.FilePath == "":
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			case params.Line <= 0:
+				return fantasy.NewTextErrorResponse("line is required"), nil
+			case params.Symbol == "":
+				return fantasy.NewTextErrorResponse("symbol is required"), nil
+			case params.NewName == "":
+				return fantasy.NewTextErrorResponse("new_name is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			if _, err := os.Stat(filePath); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+			}
+
+			matches, err := findSymbol(ctx, params.Symbol, filePath, params.Line)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search for symbol: %s", err)), nil
+			}
+			if len(matches) == 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("symbol '%s' not found on line %d of %s", params.Symbol, params.Line, filePath)), nil
+			}
+
+			client := clientForFile(ctx, lspManager, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP server handles %s", filePath)), nil
+			}
+
+			match := matches[0]
+			edit, err := client.Rename(ctx, filePath, match.lineNum, match.charNum+getSymbolOffset(params.Symbol), params.NewName)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if edit == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("the LSP server can't rename '%s'", params.Symbol)), nil
+			}
+
+			return applyWorkspaceEdit(
+				ctx,
+				workspaceEditContext{lspManager, permissions, files, filetracker, workingDir},
+				*edit,
+				client.OffsetEncoding(),
+				RenameToolName,
+				fmt.Sprintf("Rename %s to %s", params.Symbol, params.NewName),
+				fmt.Sprintf("Renamed %s to %s", params.Symbol, params.NewName),
+				call,
+			)
+		},
+	)
+}
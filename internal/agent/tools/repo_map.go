@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/repomap"
+)
+
+const (
+	RepoMapToolName = "repo_map"
+	// maxRepoMapTokens is the approximate size of the map the tool
+	// returns.
+	maxRepoMapTokens = 4096
+)
+
+//go:embed repo_map.md
+var repoMapDescription string
+
+type RepoMapParams struct {
+	Path string `json:"path,omitempty" description:"The directory or file to outline (defaults to the current working directory)"`
+}
+
+type RepoMapResponseMetadata struct {
+	NumberOfFiles int  `json:"number_of_files"`
+	Truncated     bool `json:"truncated"`
+}
+
+func NewRepoMapTool(workingDir, dataDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RepoMapToolName,
+		repoMapDescription,
+		func(ctx context.Context, params RepoMapParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			absWorkingDir, err := filepath.Abs(workingDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error resolving working directory: %v", err)), nil
+			}
+
+			var rel string
+			if params.Path != "" {
+				absPath, err := filepath.Abs(filepathext.SmartJoin(absWorkingDir, params.Path))
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("error resolving path: %v", err)), nil
+				}
+				rel, err = filepath.Rel(absWorkingDir, absPath)
+				if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("path %s is outside the working directory", params.Path)), nil
+				}
+			}
+
+			m := repomap.For(absWorkingDir, dataDir)
+			if err := m.Update(ctx); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error updating repository map: %w", err)
+			}
+			result := m.Render(repomap.RenderOptions{
+				Path:      rel,
+				MaxTokens: maxRepoMapTokens,
+				Lines:     true,
+			})
+			if result.Files == 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no files to outline in %s", filepath.Join(absWorkingDir, rel))), nil
+			}
+
+			output := result.Text
+			if result.Truncated {
+				output += "\n\n(Some files and symbols were left out. Use a more specific path to see them.)"
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output),
+				RepoMapResponseMetadata{
+					NumberOfFiles: result.Files,
+					Truncated:     result.Truncated,
+				},
+			), nil
+		},
+	)
+}
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type DocumentSymbolsParams struct {
+	FilePath string `json:"file_path" description:"The path to the file to list symbols for"`
+}
+
+type WorkspaceSymbolsParams struct {
+	Query string `json:"query" description:"The symbol name, or part of it, to search for"`
+	Path  string `json:"path,omitempty" description:"Only return symbols in files under this directory"`
+}
+
+const (
+	DocumentSymbolsToolName  = "lsp_document_symbols"
+	WorkspaceSymbolsToolName = "lsp_workspace_symbols"
+
+	maxWorkspaceSymbols = 100
+)
+
+//go:embed document_symbols.md
+var documentSymbolsDescription string
+
+//go:embed workspace_symbols.md
+var workspaceSymbolsDescription string
+
+func NewDocumentSymbolsTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DocumentSymbolsToolName,
+		documentSymbolsDescription,
+		func(ctx context.Context, params DocumentSymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(ctx, lspManager, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", params.FilePath)), nil
+			}
+
+			symbols, err := client.DocumentSymbols(ctx, filePath)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(symbols) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No symbols found in %s", params.FilePath)), nil
+			}
+
+			var output strings.Builder
+			fmt.Fprintf(&output, "Symbols in %s:\n\n", filePath)
+			writeDocumentSymbols(&output, symbols, 0)
+			return fantasy.NewTextResponse(output.String()), nil
+		},
+	)
+}
+
+func NewWorkspaceSymbolsTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		WorkspaceSymbolsToolName,
+		workspaceSymbolsDescription,
+		func(ctx context.Context, params WorkspaceSymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
+			if params.Query == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			if lspManager.Clients().Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			dir := filepathext.SmartJoin(workingDir, cmp.Or(params.Path, "."))
+
+			var symbols []protocol.SymbolInformation
+			var allErrs error
+			for client := range lspManager.Clients().Seq() {
+				if client.GetServerState() != lsp.StateReady {
+					continue
+				}
+				found, err := client.WorkspaceSymbols(ctx, params.Query)
+				if err != nil {
+					slog.Debug("Failed to search workspace symbols", "error", err, "client", client.GetName())
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				for _, symbol := range found {
+					path, err := symbol.Location.URI.Path()
+					if err != nil || !fsext.HasPrefix(path, dir) {
+						continue
+					}
+					symbols = append(symbols, symbol)
+				}
+			}
+
+			if len(symbols) == 0 {
+				if allErrs != nil {
+					return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+				}
+				return fantasy.NewTextResponse(fmt.Sprintf("No symbols found matching '%s'", params.Query)), nil
+			}
+			return fantasy.NewTextResponse(formatWorkspaceSymbols(symbols)), nil
+		},
+	)
+}
+
+func writeDocumentSymbols(output *strings.Builder, symbols []protocol.DocumentSymbol, depth int) {
+	for _, symbol := range symbols {
+		fmt.Fprintf(output, "%s%s %s", strings.Repeat("  ", depth), symbolKind(symbol.Kind), symbol.Name)
+		if symbol.Detail != "" {
+			fmt.Fprintf(output, " %s", symbol.Detail)
+		}
+		fmt.Fprintf(output, " (lines %d-%d)\n", symbol.Range.Start.Line+1, symbol.Range.End.Line+1)
+		writeDocumentSymbols(output, symbol.Children, depth+1)
+	}
+}
+
+func formatWorkspaceSymbols(symbols []protocol.SymbolInformation) string {
+	slices.SortStableFunc(symbols, func(a, b protocol.SymbolInformation) int {
+		return cmp.Or(
+			strings.Compare(string(a.Location.URI), string(b.Location.URI)),
+			cmp.Compare(a.Location.Range.Start.Line, b.Location.Range.Start.Line),
+		)
+	})
+	total := len(symbols)
+	if total > maxWorkspaceSymbols {
+		symbols = symbols[:maxWorkspaceSymbols]
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "Found %d symbol(s):\n\n", total)
+	for _, symbol := range symbols {
+		path, _ := symbol.Location.URI.Path()
+		fmt.Fprintf(&output, "%s %s", symbolKind(symbol.Kind), symbol.Name)
+		if symbol.ContainerName != "" {
+			fmt.Fprintf(&output, " in %s", symbol.ContainerName)
+		}
+		fmt.Fprintf(&output, " - %s:%d\n", path, symbol.Location.Range.Start.Line+1)
+	}
+	if total > maxWorkspaceSymbols {
+		fmt.Fprintf(&output, "\n(showing the first %d; refine the query to narrow the results)\n", maxWorkspaceSymbols)
+	}
+	return output.String()
+}
+
+func symbolKind(kind protocol.SymbolKind) string {
+	if name, ok := protocol.TableKindMap[kind]; ok {
+		return strings.ToLower(name)
+	}
+	return "symbol"
+}
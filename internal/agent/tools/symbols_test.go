@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDocumentSymbols(t *testing.T) {
+	t.Parallel()
+
+	symbols := []protocol.DocumentSymbol{
+		{
+			Name:  "Server",
+			Kind:  protocol.Struct,
+			Range: protocol.Range{Start: protocol.Position{Line: 9}, End: protocol.Position{Line: 14}},
+			Children: []protocol.DocumentSymbol{
+				{
+					Name:   "addr",
+					Detail: "string",
+					Kind:   protocol.Field,
+					Range:  protocol.Range{Start: protocol.Position{Line: 10}, End: protocol.Position{Line: 10}},
+				},
+			},
+		},
+	}
+
+	var output strings.Builder
+	writeDocumentSymbols(&output, symbols, 0)
+	require.Equal(t, "struct Server (lines 10-15)\n  field addr string (lines 11-11)\n", output.String())
+}
+
+func TestFormatWorkspaceSymbols(t *testing.T) {
+	t.Parallel()
+
+	symbol := func(name, path string, line uint32) protocol.SymbolInformation {
+		return protocol.SymbolInformation{
+			Name: name,
+			Kind: protocol.Function,
+			Location: protocol.Location{
+				URI:   protocol.URIFromPath(path),
+				Range: protocol.Range{Start: protocol.Position{Line: line}},
+			},
+		}
+	}
+
+	t.Run("sorted by file and line", func(t *testing.T) {
+		t.Parallel()
+		output := formatWorkspaceSymbols([]protocol.SymbolInformation{
+			symbol("b", "/src/b.go", 3),
+			symbol("a2", "/src/a.go", 20),
+			symbol("a1", "/src/a.go", 1),
+		})
+		require.Equal(t, "Found 3 symbol(s):\n\n"+
+			"function a1 - /src/a.go:2\n"+
+			"function a2 - /src/a.go:21\n"+
+			"function b - /src/b.go:4\n", output)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+		symbols := make([]protocol.SymbolInformation, maxWorkspaceSymbols+5)
+		for i := range symbols {
+			symbols[i] = symbol("f", "/src/a.go", uint32(i))
+		}
+		output := formatWorkspaceSymbols(symbols)
+		require.Contains(t, output, "Found 105 symbol(s)")
+		require.Equal(t, maxWorkspaceSymbols, strings.Count(output, "function f"))
+		require.Contains(t, output, "showing the first 100")
+	})
+}
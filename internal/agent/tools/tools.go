@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/worktree"
 )
 
 type (
@@ -15,6 +16,7 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	worktreeKey         string
 )
 
 const (
@@ -26,6 +28,9 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey is the key for the model name in the context.
 	ModelNameContextKey modelNameKey = "model_name"
+	// WorktreeContextKey is the key for the git worktree the session works
+	// in, when it has one.
+	WorktreeContextKey worktreeKey = "worktree"
 )
 
 // getContextValue is a generic helper that retrieves a typed value from context.
@@ -61,6 +66,22 @@ func GetModelNameFromContext(ctx context.Context) string {
 	return getContextValue(ctx, ModelNameContextKey, "")
 }
 
+// GetWorktreeFromContext retrieves the git worktree the session works in
+// from the context.
+func GetWorktreeFromContext(ctx context.Context) (worktree.Worktree, bool) {
+	wt := getContextValue(ctx, WorktreeContextKey, worktree.Worktree{})
+	return wt, wt.Path != ""
+}
+
+// WorkingDirFromContext returns the directory tools work in for the
+// session in the context: its worktree if it has one, or workingDir.
+func WorkingDirFromContext(ctx context.Context, workingDir string) string {
+	if wt, ok := GetWorktreeFromContext(ctx); ok {
+		return wt.Path
+	}
+	return workingDir
+}
+
 // NewPermissionDeniedResponse returns a tool response indicating the user
 // denied permission, with StopTurn set so the agent loop does not retry.
 func NewPermissionDeniedResponse() fantasy.ToolResponse {
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	_ "embed"
 	"errors"
@@ -101,6 +102,7 @@ func NewViewTool(
 		ViewToolName,
 		viewDescription(),
 		func(ctx context.Context, params ViewParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -234,7 +236,7 @@ func NewViewTool(
 			if isSkillFile {
 				maxContentSize = 0
 			}
-			content, hasMore, err := readTextFile(filePath, params.Offset, params.Limit, maxContentSize)
+			content, hasMore, err := readSessionTextFile(ctx, filePath, params.Offset, params.Limit, maxContentSize)
 			if err != nil {
 				var tooLarge contentTooLargeError
 				if errors.As(err, &tooLarge) {
@@ -312,14 +314,31 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
+// readSessionTextFile reads the lines of the text file filePath of the
+// session of ctx, wherever it is.
+func readSessionTextFile(ctx context.Context, filePath string, offset, limit, maxContentSize int) (string, bool, error) {
+	f, ok := filesFromContext(ctx)
+	if !ok {
+		return readTextFile(filePath, offset, limit, maxContentSize)
+	}
+	data, err := f.ReadTextFile(ctx, filePath)
+	if err != nil {
+		return "", false, err
+	}
+	return readTextLines(bytes.NewReader(data), offset, limit, maxContentSize)
+}
+
 func readTextFile(filePath string, offset, limit, maxContentSize int) (string, bool, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", false, err
 	}
 	defer file.Close()
+	return readTextLines(file, offset, limit, maxContentSize)
+}
 
-	reader := bufio.NewReader(file)
+func readTextLines(r io.Reader, offset, limit, maxContentSize int) (string, bool, error) {
+	reader := bufio.NewReader(r)
 	skipped := 0
 	for skipped < offset {
 		_, err := reader.ReadString('\n')
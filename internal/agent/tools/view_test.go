@@ -243,6 +243,8 @@ func (m *mockViewPermissionService) ListSessionPermissions(_ string) []permissio
 
 func (m *mockViewPermissionService) DeleteSessionPermission(_, _ string) {}
 
+func (m *mockViewPermissionService) Policy() *permission.Policy { return nil }
+
 func (m *mockViewPermissionService) ListRules(_ context.Context) ([]db.PermissionRule, error) {
 	return nil, nil
 }
@@ -39,6 +39,7 @@ func NewWebFetchTool(workingDir string, client *http.Client) fantasy.AgentTool {
 		WebFetchToolName,
 		renderToolDescription(webFetchDescriptionTpl),
 		func(ctx context.Context, params WebFetchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.URL == "" {
 				return fantasy.NewTextErrorResponse("url is required"), nil
 			}
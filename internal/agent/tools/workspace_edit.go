@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/searchindex"
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// WorkspaceEditFile is a file changed by an LSP workspace edit.
+type WorkspaceEditFile struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+// WorkspaceEditPermissionsParams are the permission parameters for tools
+// that apply LSP workspace edits.
+type WorkspaceEditPermissionsParams struct {
+	Files []WorkspaceEditFile `json:"files"`
+}
+
+// WorkspaceEditResponseMetadata is the response metadata for tools that
+// apply LSP workspace edits.
+type WorkspaceEditResponseMetadata struct {
+	Files     []WorkspaceEditFile `json:"files"`
+	Additions int                 `json:"additions"`
+	Removals  int                 `json:"removals"`
+}
+
+type workspaceEditContext struct {
+	lspManager  *lsp.Manager
+	permissions permission.Service
+	files       history.Service
+	filetracker filetracker.Service
+	workingDir  string
+}
+
+// applyWorkspaceEdit previews the files an LSP workspace edit changes,
+// asks for permission to write them, then writes them and records their
+// new versions in the file history.
+func applyWorkspaceEdit(
+	ctx context.Context,
+	w workspaceEditContext,
+	edit protocol.WorkspaceEdit,
+	encoding powernap.OffsetEncoding,
+	toolName, description, summary string,
+	call fantasy.ToolCall,
+) (fantasy.ToolResponse, error) {
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying edits")
+	}
+
+	editsByPath, err := util.TextEditsByPath(edit)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply edit: %s", err)), nil
+	}
+
+	var (
+		changes []WorkspaceEditFile
+		// The files as read and as written, with their original line
+		// endings; changes only has them normalized for the diff.
+		originals = make(map[string]string)
+		contents  = make(map[string]string)
+	)
+	for _, path := range slices.Sorted(maps.Keys(editsByPath)) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read file %s: %s", path, err)), nil
+		}
+		newContent, err := util.ApplyTextEdits(string(content), editsByPath[path], encoding)
+		if err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply edit to %s: %s", path, err)), nil
+		}
+		if newContent == string(content) {
+			continue
+		}
+		originals[path] = string(content)
+		contents[path] = newContent
+
+		oldUnix, _ := fsext.ToUnixLineEndings(string(content))
+		newUnix, _ := fsext.ToUnixLineEndings(newContent)
+		_, additions, removals := diff.GenerateDiff(oldUnix, newUnix, strings.TrimPrefix(path, w.workingDir))
+		changes = append(changes, WorkspaceEditFile{
+			FilePath:   path,
+			OldContent: oldUnix,
+			NewContent: newUnix,
+			Additions:  additions,
+			Removals:   removals,
+		})
+	}
+	if len(changes) == 0 {
+		return fantasy.NewTextResponse("No changes to apply"), nil
+	}
+
+	p, err := w.permissions.Request(
+		ctx,
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        w.workingDir,
+			ToolCallID:  call.ID,
+			ToolName:    toolName,
+			Action:      "write",
+			Description: description,
+			Params:      WorkspaceEditPermissionsParams{Files: changes},
+		},
+	)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return NewPermissionDeniedResponse(), nil
+	}
+
+	meta := WorkspaceEditResponseMetadata{Files: changes}
+	var output strings.Builder
+	fmt.Fprintf(&output, "<result>\n%s\n", summary)
+	for _, change := range changes {
+		if err := os.WriteFile(change.FilePath, []byte(contents[change.FilePath]), 0o644); err != nil {
+			return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+		}
+		searchindex.Update(change.FilePath)
+		if err := recordFileVersions(ctx, w.files, sessionID, change.FilePath, originals[change.FilePath], contents[change.FilePath]); err != nil {
+			return fantasy.ToolResponse{}, err
+		}
+		w.filetracker.RecordRead(ctx, sessionID, change.FilePath)
+
+		meta.Additions += change.Additions
+		meta.Removals += change.Removals
+		fmt.Fprintf(&output, "- %s (%d additions, %d removals)\n", change.FilePath, change.Additions, change.Removals)
+	}
+	output.WriteString("</result>\n")
+
+	notifyLSPsOfFiles(ctx, w.lspManager, contents)
+	output.WriteString(getDiagnostics(changes[0].FilePath, w.lspManager))
+
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output.String()), meta), nil
+}
+
+// recordFileVersions stores newContent as the latest version of a file in
+// the history, first storing oldContent if the file changed outside of
+// Crush since its last recorded version.
+func recordFileVersions(ctx context.Context, files history.Service, sessionID, filePath, oldContent, newContent string) error {
+	file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
+	if err != nil {
+		file, err = files.Create(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
+		if err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if file.Content != oldContent {
+		// User manually changed the content; store an intermediate version
+		_, err = files.CreateVersion(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
+		if err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	_, err = files.CreateVersion(ctx, sessionID, filePath, newContent)
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+	return nil
+}
+
+// notifyLSPsOfFiles tells the LSP servers about changes to several files
+// and waits once for their diagnostics, rather than once per file.
+func notifyLSPsOfFiles(ctx context.Context, manager *lsp.Manager, files map[string]string) {
+	if manager == nil {
+		return
+	}
+	notified := make(map[*lsp.Client]bool)
+	for path := range files {
+		manager.Start(ctx, path)
+		for client := range manager.Clients().Seq() {
+			if !client.HandlesFile(path) {
+				continue
+			}
+			_ = client.OpenFileOnDemand(ctx, path)
+			_ = client.NotifyChange(ctx, path)
+			notified[client] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	for client := range notified {
+		wg.Go(func() {
+			client.WaitForDiagnostics(ctx, 5*time.Second)
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/history"
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWorkspaceEdit(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	a := filepath.Join(workingDir, "a.go")
+	b := filepath.Join(workingDir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("func foo() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("x := foo()\r\n"), 0o644))
+
+	rename := func(line, start, end uint32) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: start},
+				End:   protocol.Position{Line: line, Character: end},
+			},
+			NewText: "bar",
+		}
+	}
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(a): {rename(0, 5, 8)},
+			protocol.URIFromPath(b): {rename(0, 5, 8)},
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	w := workspaceEditContext{
+		permissions: &mockPermissionService{},
+		files:       &mockHistoryService{},
+		filetracker: mockFileTrackerService{},
+		workingDir:  workingDir,
+	}
+	resp, err := applyWorkspaceEdit(ctx, w, edit, powernap.UTF16, RenameToolName, "Rename foo to bar", "Renamed foo to bar", fantasy.ToolCall{ID: "call"})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Renamed foo to bar")
+
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "func bar() {}\n", string(content))
+	content, err = os.ReadFile(b)
+	require.NoError(t, err)
+	require.Equal(t, "x := bar()\r\n", string(content))
+
+	t.Run("resource operations are rejected", func(t *testing.T) {
+		t.Parallel()
+		resp, err := applyWorkspaceEdit(ctx, w, protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{
+				{DeleteFile: &protocol.DeleteFile{URI: protocol.URIFromPath(a)}},
+			},
+		}, powernap.UTF16, RenameToolName, "", "", fantasy.ToolCall{ID: "call"})
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+	})
+}
+
+// recordingHistoryService records the versions created for files that
+// have no history yet.
+type recordingHistoryService struct {
+	mockHistoryService
+	versions []string
+}
+
+func (m *recordingHistoryService) GetByPathAndSession(context.Context, string, string) (history.File, error) {
+	return history.File{}, errors.New("not found")
+}
+
+func (m *recordingHistoryService) Create(_ context.Context, _, path, content string) (history.File, error) {
+	m.versions = append(m.versions, content)
+	return history.File{Path: path, Content: content}, nil
+}
+
+func (m *recordingHistoryService) CreateVersion(_ context.Context, _, path, content string) (history.File, error) {
+	m.versions = append(m.versions, content)
+	return history.File{Path: path, Content: content}, nil
+}
+
+func TestApplyWorkspaceEditRecordsWrittenContent(t *testing.T) {
+	t.Parallel()
+
+	workingDir := t.TempDir()
+	path := filepath.Join(workingDir, "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("x := foo()\r\n"), 0o644))
+
+	files := &recordingHistoryService{}
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	w := workspaceEditContext{
+		permissions: &mockPermissionService{},
+		files:       files,
+		filetracker: mockFileTrackerService{},
+		workingDir:  workingDir,
+	}
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(path): {{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 5},
+					End:   protocol.Position{Line: 0, Character: 8},
+				},
+				NewText: "bar",
+			}},
+		},
+	}
+	resp, err := applyWorkspaceEdit(ctx, w, edit, powernap.UTF16, RenameToolName, "", "", fantasy.ToolCall{ID: "call"})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+
+	// The history matches the disk, line endings included, and the
+	// initial version isn't stored twice.
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"x := foo()\r\n", string(content)}, files.versions)
+}
+
+func TestLineRange(t *testing.T) {
+	t.Parallel()
+
+	content := "package main\n\nfunc main() {}\n"
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 0},
+		End:   protocol.Position{Line: 1},
+	}, lineRange(content, 0, 0))
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 1},
+		End:   protocol.Position{Line: 3},
+	}, lineRange(content, 2, 3))
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 2},
+		End:   protocol.Position{Line: 3, Character: 0},
+	}, lineRange(content, 3, 10))
+}
+
+func TestSelectCodeAction(t *testing.T) {
+	t.Parallel()
+
+	actions := []protocol.CodeAction{
+		{Title: "Organize Imports", Kind: protocol.SourceOrganizeImports},
+		{Title: "Fill Config"},
+		{Title: "Fill Options"},
+	}
+
+	action, resp := selectCodeAction(actions, "organize imports")
+	require.Nil(t, resp)
+	require.Equal(t, "Organize Imports", action.Title)
+
+	action, resp = selectCodeAction(actions, "config")
+	require.Nil(t, resp)
+	require.Equal(t, "Fill Config", action.Title)
+
+	_, resp = selectCodeAction(actions, "fill")
+	require.NotNil(t, resp)
+	require.Contains(t, resp.Content, "Several code actions match")
+
+	_, resp = selectCodeAction(actions, "extract")
+	require.NotNil(t, resp)
+	require.Contains(t, resp.Content, "Organize Imports [source.organizeImports]")
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
@@ -45,6 +46,7 @@ const WriteToolName = "write"
 
 func NewWriteTool(
 	lspManager *lsp.Manager,
+	formatter *format.Formatter,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -54,6 +56,7 @@ func NewWriteTool(
 		WriteToolName,
 		writeDescription,
 		func(ctx context.Context, params WriteParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := WorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -78,7 +81,7 @@ func NewWriteTool(
 						filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
 				}
 
-				oldContent, readErr := os.ReadFile(filePath)
+				oldContent, readErr := readSessionFile(ctx, filePath)
 				if readErr == nil && string(oldContent) == params.Content {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
 				}
@@ -93,13 +96,13 @@ func NewWriteTool(
 
 			oldContent := ""
 			if fileInfo != nil && !fileInfo.IsDir() {
-				oldBytes, readErr := os.ReadFile(filePath)
+				oldBytes, readErr := readSessionFile(ctx, filePath)
 				if readErr == nil {
 					oldContent = string(oldBytes)
 				}
 			}
 
-			diff, additions, removals := diff.GenerateDiff(
+			fileDiff, additions, removals := diff.GenerateDiff(
 				oldContent,
 				params.Content,
 				strings.TrimPrefix(filePath, workingDir),
@@ -128,29 +131,43 @@ func NewWriteTool(
 				return NewPermissionDeniedResponse(), nil
 			}
 
-			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+			err = writeSessionFile(ctx, filePath, []byte(params.Content))
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 			}
 
+			content, formatNote := formatAfterWrite(ctx, formatter, filePath, params.Content)
+			if content != params.Content {
+				fileDiff, additions, removals = diff.GenerateDiff(oldContent, content, strings.TrimPrefix(filePath, workingDir))
+			}
+
 			// Check if file exists in history
+			missing := fileInfo == nil
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
-				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				if missing {
+					file, err = files.CreateMissing(history.WithoutMessageID(ctx), sessionID, filePath)
+				} else {
+					file, err = files.Create(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
+				}
 				if err != nil {
 					// Log error but don't fail the operation
 					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 				}
 			}
-			if file.Content != oldContent {
+			if file.Content != oldContent || file.Missing != missing {
 				// User manually changed the content; store an intermediate version
-				_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent)
+				if missing {
+					_, err = files.CreateMissing(history.WithoutMessageID(ctx), sessionID, filePath)
+				} else {
+					_, err = files.CreateVersion(history.WithoutMessageID(ctx), sessionID, filePath, oldContent)
+				}
 				if err != nil {
 					slog.Error("Error creating file history version", "error", err)
 				}
 			}
 			// Store the new version
-			_, err = files.CreateVersion(ctx, sessionID, filePath, params.Content)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, content)
 			if err != nil {
 				slog.Error("Error creating file history version", "error", err)
 			}
@@ -159,13 +176,13 @@ func NewWriteTool(
 
 			notifyLSPs(ctx, lspManager, params.FilePath)
 
-			result := fmt.Sprintf("File successfully written: %s", filePath)
+			result := fmt.Sprintf("File successfully written: %s%s", filePath, formatNote)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
 			result += getDiagnostics(filePath, lspManager)
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(result),
 				WriteResponseMetadata{
-					Diff:      diff,
+					Diff:      fileDiff,
 					Additions: additions,
 					Removals:  removals,
 				},
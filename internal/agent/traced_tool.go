@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/telemetry"
+)
+
+// tracedTool wraps a fantasy.AgentTool to trace its calls, including the
+// hooks and permission prompts they go through.
+type tracedTool struct {
+	inner fantasy.AgentTool
+}
+
+// wrapToolsWithTracing returns a tool slice with each entry wrapped in a
+// tracedTool. Returns the original slice unchanged when telemetry is off.
+func wrapToolsWithTracing(tools []fantasy.AgentTool) []fantasy.AgentTool {
+	if !telemetry.Enabled() {
+		return tools
+	}
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &tracedTool{inner: tool}
+	}
+	return out
+}
+
+func (t *tracedTool) Info() fantasy.ToolInfo {
+	return t.inner.Info()
+}
+
+func (t *tracedTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.inner.ProviderOptions()
+}
+
+func (t *tracedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.inner.SetProviderOptions(opts)
+}
+
+func (t *tracedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	ctx, end := telemetry.Tool(ctx, call.Name, call.ID)
+	resp, err := t.inner.Run(ctx, call)
+	end(resp.IsError, err)
+	return resp, err
+}
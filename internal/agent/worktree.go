@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+// newWorktreeManager returns the manager of session worktrees, or nil if
+// they are disabled or the working directory is not in a git repository.
+func newWorktreeManager(ctx context.Context, cfg *config.ConfigStore) *worktree.Manager {
+	opts := cfg.Config().Options
+	if opts == nil || opts.Worktrees == nil || !opts.Worktrees.Enabled {
+		return nil
+	}
+	m, err := worktree.New(ctx, cfg.WorkingDir(), opts.Worktrees.Directory)
+	if errors.Is(err, worktree.ErrNotRepository) {
+		slog.Warn("Session worktrees are enabled, but the working directory is not in a git repository", "dir", cfg.WorkingDir())
+		return nil
+	}
+	if err != nil {
+		slog.Error("Failed to set up session worktrees", "error", err)
+		return nil
+	}
+	return m
+}
+
+// withWorktree adds the git worktree of a session to ctx, creating it the
+// first time the session runs, so its tools work there. Sub-agents inherit
+// the worktree of the session that runs them through the context of the
+// tool call.
+func (c *coordinator) withWorktree(ctx context.Context, sessionID string) (context.Context, error) {
+	if c.worktrees == nil {
+		return ctx, nil
+	}
+	if _, ok := tools.GetWorktreeFromContext(ctx); ok {
+		return ctx, nil
+	}
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if sess.ParentSessionID != "" {
+		return ctx, nil
+	}
+	wt, err := c.worktrees.Ensure(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up session worktree: %w", err)
+	}
+	if c.lspManager != nil {
+		c.lspManager.AddRoot(wt.Path)
+	}
+	return context.WithValue(ctx, tools.WorktreeContextKey, wt), nil
+}
+
+// worktreeInstructions tells the model about the session's worktree, since
+// the working directory in the system prompt is the workspace's.
+func worktreeInstructions(wt worktree.Worktree) string {
+	return fmt.Sprintf(`<worktree>
+This session works in its own git worktree at %s, on the branch %s. Use it as the working directory instead of the one above: relative paths and shell commands resolve against it, and every file you read or change must be under it. The user merges or discards the branch when you are done, so don't merge, rebase, or push it yourself.
+</worktree>`, wt.Path, wt.Branch)
+}
@@ -10,7 +10,6 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +20,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
@@ -31,10 +31,13 @@ import (
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/searchindex"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	"github.com/charmbracelet/crush/internal/update"
@@ -83,7 +86,11 @@ type App struct {
 // skills.NewManager + skills.DiscoverFromConfig).
 func New(ctx context.Context, conn *sql.DB, store *config.ConfigStore, skillsMgr *skills.Manager) (*App, error) {
 	q := db.New(conn)
-	sessions := session.NewService(q, conn)
+	var sessionOpts []session.ServiceOption
+	if checkpoints, err := checkpoint.New(ctx, store.WorkingDir()); err == nil {
+		sessionOpts = append(sessionOpts, session.WithCheckpoints(checkpoints))
+	}
+	sessions := session.NewService(q, conn, sessionOpts...)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
 	cfg := store.Config()
@@ -92,12 +99,16 @@ func New(ctx context.Context, conn *sql.DB, store *config.ConfigStore, skillsMgr
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
 		allowedTools = cfg.Permissions.AllowedTools
 	}
+	policy, err := permission.LoadPolicy(store.WorkingDir(), config.PermissionPolicyFiles(store.WorkingDir())...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permission policy: %w", err)
+	}
 
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(store.WorkingDir(), skipPermissionsRequests, allowedTools, q),
+		Permissions: permission.NewPermissionService(store.WorkingDir(), skipPermissionsRequests, allowedTools, q, policy),
 		FileTracker: filetracker.NewService(q),
 		LSPManager:  lsp.NewManager(store),
 		Skills:      skillsMgr,
@@ -114,6 +125,19 @@ func New(ctx context.Context, conn *sql.DB, store *config.ConfigStore, skillsMgr
 
 	app.setupEvents()
 
+	// Start telemetry before anything it traces.
+	shutdownTelemetry, err := telemetry.Init(ctx, cfg.Options.Telemetry, cfg.Options.DataDirectory)
+	if err != nil {
+		slog.Error("Failed to initialize telemetry", "error", err)
+	} else {
+		app.cleanupFuncs = append(app.cleanupFuncs, shutdownTelemetry)
+	}
+
+	if opts := cfg.Options.SearchIndex; opts != nil && opts.Enabled {
+		stop := searchindex.Start(ctx, store.WorkingDir(), cfg.Options.DataDirectory, opts.GetRefreshInterval())
+		app.cleanupFuncs = append(app.cleanupFuncs, stop)
+	}
+
 	// Check for updates in the background.
 	go app.checkForUpdates(ctx)
 
@@ -209,8 +233,8 @@ func (app *App) resolveSession(ctx context.Context, continueSessionID string, us
 }
 
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string, hideSpinner bool, continueSessionID string, useLast bool) error {
+// given prompt, printing to output in the given format.
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, outputFormat OutputFormat, prompt, largeModel, smallModel string, hideSpinner bool, continueSessionID string, useLast bool) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -292,6 +316,8 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	// Automatically approve all permission requests for this non-interactive
 	// session.
 	app.Permissions.AutoApproveSession(sess.ID)
+	// Nobody is there to fill in the forms MCP servers ask for.
+	mcp.DeclineElicitations(sess.ID)
 
 	type response struct {
 		result *fantasy.AgentResult
@@ -299,6 +325,13 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 	done := make(chan response, 1)
 
+	// Subscribe before starting the agent so no event raised before the
+	// first step is missed.
+	messageEvents := app.Messages.Subscribe(ctx)
+	permissionEvents := app.Permissions.Subscribe(ctx)
+	notifications := app.agentNotifications.Subscribe(ctx)
+	out := NewRunOutput(output, outputFormat, sess.ID)
+
 	go func(ctx context.Context, sessionID, prompt string) {
 		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
 		if err != nil {
@@ -312,18 +345,10 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		}
 	}(ctx, sess.ID, prompt)
 
-	messageEvents := app.Messages.Subscribe(ctx)
-	messageReadBytes := make(map[string]int)
-	var printed bool
-
 	defer func() {
 		if progress && stderrTTY {
 			_, _ = fmt.Fprintf(os.Stderr, ansi.ResetProgressBar)
 		}
-
-		// Always print a newline at the end. If output is a TTY this will
-		// prevent the prompt from overwriting the last line of output.
-		_, _ = fmt.Fprintln(output)
 	}()
 
 	for {
@@ -336,44 +361,76 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		select {
 		case result := <-done:
 			stopSpinner()
-			if result.err != nil {
-				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
-					slog.Debug("Non-interactive: agent processing cancelled", "session_id", sess.ID)
-					return nil
+			// Every message event of the run was queued before it
+			// returned; write out the ones not handled yet.
+			for pending := true; pending; {
+				select {
+				case event := <-messageEvents:
+					if err := out.Message(proto.MessageFromMessage(event.Payload)); err != nil {
+						return err
+					}
+				default:
+					pending = false
 				}
-				return fmt.Errorf("agent processing failed: %w", result.err)
 			}
-			return nil
+
+			runErr := result.err
+			if runErr != nil && (errors.Is(runErr, context.Canceled) || errors.Is(runErr, agent.ErrRequestCancelled)) {
+				slog.Debug("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+				runErr = nil
+			}
+			if runErr != nil {
+				runErr = fmt.Errorf("agent processing failed: %w", runErr)
+			}
+			final, err := app.Sessions.Get(context.WithoutCancel(ctx), sess.ID)
+			if err != nil {
+				final = sess
+			}
+			if err := out.Done(proto.Session{
+				ID:               final.ID,
+				PromptTokens:     final.PromptTokens,
+				CompletionTokens: final.CompletionTokens,
+				Cost:             final.Cost,
+			}, runErr); err != nil {
+				return err
+			}
+			return runErr
 
 		case event := <-messageEvents:
 			msg := event.Payload
 			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
+			}
+			if err := out.Message(proto.MessageFromMessage(msg)); err != nil {
+				slog.Error("Non-interactive: failed to write message", "error", err)
+				return err
+			}
 
-				content := msg.Content().String()
-				readBytes := messageReadBytes[msg.ID]
-
-				if len(content) < readBytes {
-					slog.Error("Non-interactive: message content is shorter than read bytes", "message_length", len(content), "read_bytes", readBytes)
-					return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), readBytes)
-				}
+		case event := <-permissionEvents:
+			req := event.Payload
+			if err := out.PermissionRequest(proto.PermissionRequest{
+				ID:          req.ID,
+				SessionID:   req.SessionID,
+				ToolCallID:  req.ToolCallID,
+				ToolName:    req.ToolName,
+				Description: req.Description,
+				Action:      req.Action,
+				Params:      req.Params,
+				Path:        req.Path,
+				Rule:        req.Rule,
+			}); err != nil {
+				return err
+			}
 
-				part := content[readBytes:]
-				// Trim leading whitespace. Sometimes the LLM includes leading
-				// formatting and intentation, which we don't want here.
-				if readBytes == 0 {
-					part = strings.TrimLeft(part, " \t")
-				}
-				// Ignore initial whitespace-only messages.
-				if printed || strings.TrimSpace(part) != "" {
-					printed = true
-					fmt.Fprint(output, part)
-				}
-				messageReadBytes[msg.ID] = len(content)
+		case event := <-notifications:
+			n := event.Payload
+			if n.SessionID == sess.ID && n.Type == notify.TypeBudgetWarning {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: %s\n", n.Details)
 			}
 
 		case <-ctx.Done():
 			stopSpinner()
+			_ = out.Done(proto.Session{ID: sess.ID}, ctx.Err())
 			return ctx.Err()
 		}
 	}
@@ -486,6 +543,8 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "agent-notifications", app.agentNotifications.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp-elicitations", mcp.SubscribeElicitations, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp-progress", mcp.SubscribeProgress, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
 	if app.Skills != nil {
 		setupSubscriber(ctx, app.serviceEventsWG, "skills", app.Skills.SubscribeEvents, app.events)
@@ -597,6 +656,12 @@ func (app *App) Shutdown() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Let SessionEnd hooks run before anything they might inspect is torn
+	// down.
+	if app.AgentCoordinator != nil {
+		app.AgentCoordinator.EndSessions(shutdownCtx)
+	}
+
 	// Drain any debounced message updates before the DB-close cleanup
 	// runs in the parallel block below. message.Service buffers
 	// streaming deltas (see internal/message/message.go) and we must
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/checkpoint"
+)
+
+// ListCheckpoints returns the checkpoints taken at the start of the turns
+// of a session, oldest first. A working directory outside of a git
+// repository has none.
+func (app *App) ListCheckpoints(ctx context.Context, sessionID string) ([]checkpoint.Checkpoint, error) {
+	s, err := checkpoint.New(ctx, app.config.WorkingDir())
+	if errors.Is(err, checkpoint.ErrNotRepository) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.List(ctx, sessionID)
+}
+
+// CheckpointDiff returns the changes made during turn n of a session. See
+// [checkpoint.Store.Diff].
+func (app *App) CheckpointDiff(ctx context.Context, sessionID string, n int, stat bool) (string, error) {
+	s, err := checkpointStore(ctx, app.config.WorkingDir())
+	if err != nil {
+		return "", err
+	}
+	return s.Diff(ctx, sessionID, n, stat)
+}
+
+// RestoreCheckpoint brings the working tree back to how it was at the start
+// of turn n of a session. See [checkpoint.Store.Restore].
+func (app *App) RestoreCheckpoint(ctx context.Context, sessionID string, n int) ([]checkpoint.Change, error) {
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return nil, agent.ErrSessionBusy
+	}
+	s, err := checkpointStore(ctx, app.config.WorkingDir())
+	if err != nil {
+		return nil, err
+	}
+	return s.Restore(ctx, sessionID, n)
+}
+
+// checkpointStore returns the checkpoint store of the repository containing
+// workingDir. Outside of a repository, there is no checkpoint to find.
+func checkpointStore(ctx context.Context, workingDir string) (*checkpoint.Store, error) {
+	s, err := checkpoint.New(ctx, workingDir)
+	if errors.Is(err, checkpoint.ErrNotRepository) {
+		return nil, checkpoint.ErrNotFound
+	}
+	return s, err
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
@@ -72,6 +73,22 @@ func (m *mockSessionService) Delete(context.Context, string) error {
 	return nil
 }
 
+func (m *mockSessionService) Fork(context.Context, string, string) (session.Session, error) {
+	return session.Session{}, nil
+}
+
+func (m *mockSessionService) RecordUsage(context.Context, string, session.Usage) error {
+	return nil
+}
+
+func (m *mockSessionService) SessionUsage(context.Context, string) (session.Usage, error) {
+	return session.Usage{}, nil
+}
+
+func (m *mockSessionService) UsageSince(context.Context, time.Time) (session.Usage, error) {
+	return session.Usage{}, nil
+}
+
 func (m *mockSessionService) CreateAgentToolSessionID(messageID, toolCallID string) string {
 	return fmt.Sprintf("%s$$%s", messageID, toolCallID)
 }
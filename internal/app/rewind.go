@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// ResolveRewindPoint finds the message a session should be rewound to.
+// target can be either a message ID or the ID of a tool call; a tool call
+// resolves to the assistant message that issued it.
+func ResolveRewindPoint(ctx context.Context, messages message.Service, sessionID, target string) (message.Message, error) {
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return message.Message{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	for _, msg := range msgs {
+		if msg.ID == target {
+			return msg, nil
+		}
+		for _, tc := range msg.ToolCalls() {
+			if tc.ID == target {
+				return msg, nil
+			}
+		}
+	}
+	return message.Message{}, fmt.Errorf("message or tool call not found in session: %s", target)
+}
+
+// RewindSession rolls the files the agent changed in a session back to the
+// state they were in before the given message or tool call. See
+// [history.Rewind] for how conflicts are handled.
+func (app *App) RewindSession(ctx context.Context, sessionID, target string, opts history.RewindOptions) (history.RewindResult, error) {
+	if !opts.DryRun && app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return history.RewindResult{}, agent.ErrSessionBusy
+	}
+	if err := app.Messages.FlushAll(ctx); err != nil {
+		return history.RewindResult{}, err
+	}
+	_, result, err := Rewind(ctx, app.Messages, app.History, sessionID, target, opts)
+	return result, err
+}
+
+// Rewind rolls the files the agent changed in a session back to the state
+// they were in before the given message or tool call, which it returns
+// along with the outcome. See [history.Rewind].
+func Rewind(ctx context.Context, messages message.Service, files history.Service, sessionID, target string, opts history.RewindOptions) (message.Message, history.RewindResult, error) {
+	msg, err := ResolveRewindPoint(ctx, messages, sessionID, target)
+	if err != nil {
+		return message.Message{}, history.RewindResult{}, err
+	}
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return msg, history.RewindResult{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	var undone []string
+	if i := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == msg.ID }); i >= 0 {
+		for _, m := range msgs[i:] {
+			undone = append(undone, m.ID)
+		}
+	}
+	result, err := history.Rewind(ctx, files, sessionID, undone, opts)
+	return msg, result, err
+}
+
+// ForkSession branches a new session off a session at the given message or
+// tool call. See [session.Service.Fork].
+func (app *App) ForkSession(ctx context.Context, sessionID, target string) (session.Session, error) {
+	if err := app.Messages.FlushAll(ctx); err != nil {
+		return session.Session{}, err
+	}
+	msg, err := ResolveRewindPoint(ctx, app.Messages, sessionID, target)
+	if err != nil {
+		return session.Session{}, err
+	}
+	return app.Sessions.Fork(ctx, sessionID, msg.ID)
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/proto"
+)
+
+// OutputFormat selects how a non-interactive run writes its output.
+type OutputFormat string
+
+const (
+	// OutputFormatText writes the assistant's text as it streams in.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON writes a single [proto.RunResult] when the run ends.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatStreamJSON writes a [proto.RunEvent] per line as the run
+	// progresses, ending with the result.
+	OutputFormatStreamJSON OutputFormat = "stream-json"
+)
+
+// ParseOutputFormat parses an output format name. An empty name is
+// [OutputFormatText].
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(s); f {
+	case "", OutputFormatText:
+		return OutputFormatText, nil
+	case OutputFormatJSON, OutputFormatStreamJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q: must be one of text, json, stream-json", s)
+	}
+}
+
+// RunOutput writes the output of a non-interactive run of a session in
+// the chosen [OutputFormat]. Feed it every message and permission request
+// event, then call [RunOutput.Done] once the run ends.
+type RunOutput struct {
+	w         io.Writer
+	enc       *json.Encoder
+	format    OutputFormat
+	sessionID string
+
+	readBytes   map[string]int
+	printed     bool
+	result      string
+	finish      proto.FinishReason
+	toolCalls   []proto.ToolCall
+	seenCalls   map[string]bool
+	toolResults map[string]proto.ToolResult
+}
+
+// NewRunOutput creates a RunOutput writing to w for the given session.
+func NewRunOutput(w io.Writer, format OutputFormat, sessionID string) *RunOutput {
+	return &RunOutput{
+		w:           w,
+		enc:         json.NewEncoder(w),
+		format:      format,
+		sessionID:   sessionID,
+		readBytes:   make(map[string]int),
+		seenCalls:   make(map[string]bool),
+		toolResults: make(map[string]proto.ToolResult),
+	}
+}
+
+// Message handles a created or updated message. Messages from other
+// sessions, including sub-agent sessions, are ignored.
+func (o *RunOutput) Message(msg proto.Message) error {
+	if msg.SessionID != o.sessionID {
+		return nil
+	}
+	switch msg.Role {
+	case proto.Assistant:
+		return o.assistantMessage(msg)
+	case proto.Tool:
+		for _, tr := range msg.ToolResults() {
+			if _, ok := o.toolResults[tr.ToolCallID]; ok {
+				continue
+			}
+			o.toolResults[tr.ToolCallID] = tr
+			if err := o.event(proto.RunEvent{
+				Type:       proto.RunEventTypeToolResult,
+				MessageID:  msg.ID,
+				ToolResult: &tr,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o *RunOutput) assistantMessage(msg proto.Message) error {
+	content := msg.Content().String()
+	readBytes := o.readBytes[msg.ID]
+	if len(content) < readBytes {
+		return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), readBytes)
+	}
+	delta := content[readBytes:]
+	o.readBytes[msg.ID] = len(content)
+	if text := strings.TrimSpace(content); text != "" {
+		o.result = text
+	}
+
+	switch o.format {
+	case OutputFormatText:
+		// Trim leading whitespace. Sometimes the LLM includes leading
+		// formatting and intentation, which we don't want here.
+		if readBytes == 0 {
+			delta = strings.TrimLeft(delta, " \t")
+		}
+		// Ignore initial whitespace-only messages.
+		if o.printed || strings.TrimSpace(delta) != "" {
+			o.printed = true
+			if _, err := fmt.Fprint(o.w, delta); err != nil {
+				return err
+			}
+		}
+	case OutputFormatStreamJSON:
+		if delta != "" {
+			if err := o.event(proto.RunEvent{
+				Type:      proto.RunEventTypeMessageDelta,
+				MessageID: msg.ID,
+				Delta:     delta,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tc := range msg.ToolCalls() {
+		if !tc.Finished || o.seenCalls[tc.ID] {
+			continue
+		}
+		o.seenCalls[tc.ID] = true
+		o.toolCalls = append(o.toolCalls, tc)
+		if err := o.event(proto.RunEvent{
+			Type:      proto.RunEventTypeToolCall,
+			MessageID: msg.ID,
+			ToolCall:  &tc,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if msg.IsFinished() {
+		o.finish = msg.FinishReason()
+	}
+	return nil
+}
+
+// PermissionRequest handles a permission request waiting for an answer.
+func (o *RunOutput) PermissionRequest(req proto.PermissionRequest) error {
+	if req.SessionID != o.sessionID {
+		return nil
+	}
+	return o.event(proto.RunEvent{
+		Type:              proto.RunEventTypePermissionRequest,
+		PermissionRequest: &req,
+	})
+}
+
+// Done writes the end of the output: a trailing newline for text, or the
+// result of the run for the JSON formats. sess is the session after the
+// run and runErr the error the run failed with, if any.
+func (o *RunOutput) Done(sess proto.Session, runErr error) error {
+	if o.format == OutputFormatText {
+		// Always print a newline at the end. If output is a TTY this will
+		// prevent the prompt from overwriting the last line of output.
+		_, err := fmt.Fprintln(o.w)
+		return err
+	}
+
+	result := proto.RunResult{
+		SessionID:    o.sessionID,
+		Result:       o.result,
+		FinishReason: o.finish,
+		Usage: proto.RunUsage{
+			PromptTokens:     sess.PromptTokens,
+			CompletionTokens: sess.CompletionTokens,
+		},
+		Cost:      sess.Cost,
+		ToolCalls: make([]proto.RunToolCall, 0, len(o.toolCalls)),
+	}
+	switch {
+	case runErr != nil:
+		result.IsError = true
+		result.Error = runErr.Error()
+	case o.finish == proto.FinishReasonError || o.finish == proto.FinishReasonBudgetExceeded:
+		result.IsError = true
+	}
+	for _, tc := range o.toolCalls {
+		call := proto.RunToolCall{Call: tc}
+		if tr, ok := o.toolResults[tc.ID]; ok {
+			call.Result = &tr
+		}
+		result.ToolCalls = append(result.ToolCalls, call)
+	}
+
+	if o.format == OutputFormatJSON {
+		return o.enc.Encode(result)
+	}
+	return o.event(proto.RunEvent{
+		Type:   proto.RunEventTypeResult,
+		Result: &result,
+	})
+}
+
+// event writes ev when streaming JSON events.
+func (o *RunOutput) event(ev proto.RunEvent) error {
+	if o.format != OutputFormatStreamJSON {
+		return nil
+	}
+	ev.SessionID = o.sessionID
+	return o.enc.Encode(ev)
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]OutputFormat{
+		"":            OutputFormatText,
+		"text":        OutputFormatText,
+		"json":        OutputFormatJSON,
+		"stream-json": OutputFormatStreamJSON,
+	} {
+		got, err := ParseOutputFormat(in)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	_, err := ParseOutputFormat("yaml")
+	require.ErrorContains(t, err, "invalid output format")
+}
+
+// feedRun replays a short run with one tool call into out.
+func feedRun(t *testing.T, out *RunOutput) {
+	t.Helper()
+
+	assistant := proto.Message{ID: "m1", SessionID: "s1", Role: proto.Assistant}
+	assistant.Parts = []proto.ContentPart{proto.TextContent{Text: "  Let me"}}
+	require.NoError(t, out.Message(assistant))
+	assistant.Parts = []proto.ContentPart{
+		proto.TextContent{Text: "  Let me look."},
+		proto.ToolCall{ID: "tc1", Name: "ls", Input: `{}`, Finished: true},
+		proto.Finish{Reason: proto.FinishReasonToolUse},
+	}
+	require.NoError(t, out.Message(assistant))
+
+	// Messages of other sessions are ignored.
+	require.NoError(t, out.Message(proto.Message{
+		ID:        "other",
+		SessionID: "s2",
+		Role:      proto.Assistant,
+		Parts:     []proto.ContentPart{proto.TextContent{Text: "nope"}},
+	}))
+
+	require.NoError(t, out.Message(proto.Message{
+		ID:        "m2",
+		SessionID: "s1",
+		Role:      proto.Tool,
+		Parts:     []proto.ContentPart{proto.ToolResult{ToolCallID: "tc1", Name: "ls", Content: "main.go"}},
+	}))
+
+	require.NoError(t, out.PermissionRequest(proto.PermissionRequest{ID: "p1", SessionID: "s1", ToolName: "bash"}))
+
+	require.NoError(t, out.Message(proto.Message{
+		ID:        "m3",
+		SessionID: "s1",
+		Role:      proto.Assistant,
+		Parts: []proto.ContentPart{
+			proto.TextContent{Text: " Done."},
+			proto.Finish{Reason: proto.FinishReasonEndTurn},
+		},
+	}))
+}
+
+func TestRunOutputText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := NewRunOutput(&buf, OutputFormatText, "s1")
+	feedRun(t, out)
+	require.NoError(t, out.Done(proto.Session{ID: "s1"}, nil))
+	require.Equal(t, "Let me look.Done.\n", buf.String())
+}
+
+func TestRunOutputJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := NewRunOutput(&buf, OutputFormatJSON, "s1")
+	feedRun(t, out)
+	require.NoError(t, out.Done(proto.Session{ID: "s1", PromptTokens: 100, CompletionTokens: 20, Cost: 0.5}, nil))
+
+	var result proto.RunResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Equal(t, "s1", result.SessionID)
+	require.Equal(t, "Done.", result.Result)
+	require.Equal(t, proto.FinishReasonEndTurn, result.FinishReason)
+	require.False(t, result.IsError)
+	require.Equal(t, proto.RunUsage{PromptTokens: 100, CompletionTokens: 20}, result.Usage)
+	require.Equal(t, 0.5, result.Cost)
+	require.Len(t, result.ToolCalls, 1)
+	require.Equal(t, "ls", result.ToolCalls[0].Call.Name)
+	require.NotNil(t, result.ToolCalls[0].Result)
+	require.Equal(t, "main.go", result.ToolCalls[0].Result.Content)
+}
+
+func TestRunOutputJSONError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := NewRunOutput(&buf, OutputFormatJSON, "s1")
+	require.NoError(t, out.Done(proto.Session{ID: "s1"}, errors.New("boom")))
+
+	var result proto.RunResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.True(t, result.IsError)
+	require.Equal(t, "boom", result.Error)
+	require.NotNil(t, result.ToolCalls)
+}
+
+func TestRunOutputStreamJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := NewRunOutput(&buf, OutputFormatStreamJSON, "s1")
+	feedRun(t, out)
+	require.NoError(t, out.Done(proto.Session{ID: "s1"}, nil))
+
+	var events []proto.RunEvent
+	for line := range strings.Lines(buf.String()) {
+		var ev proto.RunEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &ev))
+		require.Equal(t, "s1", ev.SessionID)
+		events = append(events, ev)
+	}
+
+	types := make([]proto.RunEventType, len(events))
+	for i, ev := range events {
+		types[i] = ev.Type
+	}
+	require.Equal(t, []proto.RunEventType{
+		proto.RunEventTypeMessageDelta,
+		proto.RunEventTypeMessageDelta,
+		proto.RunEventTypeToolCall,
+		proto.RunEventTypeToolResult,
+		proto.RunEventTypePermissionRequest,
+		proto.RunEventTypeMessageDelta,
+		proto.RunEventTypeResult,
+	}, types)
+	require.Equal(t, "  Let me", events[0].Delta)
+	require.Equal(t, " look.", events[1].Delta)
+	require.Equal(t, "tc1", events[2].ToolCall.ID)
+	require.Equal(t, "main.go", events[3].ToolResult.Content)
+	require.Equal(t, "bash", events[4].PermissionRequest.ToolName)
+	require.Equal(t, "Done.", events[6].Result.Result)
+}
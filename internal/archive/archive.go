@@ -0,0 +1,409 @@
+// Package archive exports sessions to, and imports them from, a versioned
+// JSON format that can move them between Crush databases.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/google/uuid"
+)
+
+// Version is the version of the archive format written by [Export]. It's
+// bumped whenever the format changes in a way older versions of Crush
+// can't read.
+const Version = 1
+
+// ErrIncompatible is returned when importing an archive this version of
+// Crush can't read.
+var ErrIncompatible = errors.New("incompatible archive")
+
+// Archive is a session along with everything needed to recreate it in
+// another database.
+type Archive struct {
+	Version int `json:"version"`
+	// SchemaVersion is the database schema version the archive was
+	// exported from.
+	SchemaVersion int64  `json:"schema_version"`
+	CrushVersion  string `json:"crush_version"`
+	ExportedAt    int64  `json:"exported_at"`
+
+	Session Session `json:"session"`
+	// Children are the sessions created by the session, such as those of
+	// sub-agents, listed parents first.
+	Children []Session `json:"children,omitempty"`
+	// Messages are the messages of the session and its children.
+	Messages []Message `json:"messages"`
+	// Files are the file history versions of the session and its children.
+	Files []File `json:"files,omitempty"`
+}
+
+// Session is an archived session.
+type Session struct {
+	ID                  string          `json:"id"`
+	ParentSessionID     string          `json:"parent_session_id,omitempty"`
+	Title               string          `json:"title"`
+	MessageCount        int64           `json:"message_count"`
+	PromptTokens        int64           `json:"prompt_tokens"`
+	CompletionTokens    int64           `json:"completion_tokens"`
+	Cost                float64         `json:"cost"`
+	SummaryMessageID    string          `json:"summary_message_id,omitempty"`
+	Todos               json.RawMessage `json:"todos,omitempty"`
+	ForkedFromSessionID string          `json:"forked_from_session_id,omitempty"`
+	ForkedAtMessageID   string          `json:"forked_at_message_id,omitempty"`
+	CreatedAt           int64           `json:"created_at"`
+	UpdatedAt           int64           `json:"updated_at"`
+}
+
+// Message is an archived message. Parts are kept as stored so no detail is
+// lost on the way through.
+type Message struct {
+	ID               string          `json:"id"`
+	SessionID        string          `json:"session_id"`
+	Role             string          `json:"role"`
+	Parts            json.RawMessage `json:"parts"`
+	Model            string          `json:"model,omitempty"`
+	Provider         string          `json:"provider,omitempty"`
+	IsSummaryMessage bool            `json:"is_summary_message,omitempty"`
+	CreatedAt        int64           `json:"created_at"`
+	UpdatedAt        int64           `json:"updated_at"`
+	FinishedAt       int64           `json:"finished_at,omitempty"`
+}
+
+// File is an archived version of a file in the file history.
+type File struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Version   int64  `json:"version"`
+	MessageID string `json:"message_id,omitempty"`
+	Missing   bool   `json:"missing,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// missingFilesSchemaVersion is the schema version from which file versions
+// record whether the file existed. Before it, a missing file was recorded
+// as empty content.
+const missingFilesSchemaVersion = 20261021000000
+
+// Export archives the session with the given ID.
+func Export(ctx context.Context, conn *sql.DB, sessionID string) (*Archive, error) {
+	schema, err := db.SchemaVersion(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema version: %w", err)
+	}
+
+	q := db.New(conn)
+	root, err := q.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		Version:       Version,
+		SchemaVersion: schema,
+		CrushVersion:  version.Version,
+		ExportedAt:    time.Now().Unix(),
+		Session:       fromDBSession(root),
+	}
+
+	// Walk the session tree parents first, so the children can be imported
+	// in order.
+	queue := []db.Session{root}
+	for len(queue) > 0 {
+		sess := queue[0]
+		queue = queue[1:]
+
+		msgs, err := q.ListMessagesBySession(ctx, sess.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing messages of session %s: %w", sess.ID, err)
+		}
+		for _, msg := range msgs {
+			a.Messages = append(a.Messages, fromDBMessage(msg))
+		}
+
+		files, err := q.ListFilesBySession(ctx, sess.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing files of session %s: %w", sess.ID, err)
+		}
+		for _, file := range files {
+			a.Files = append(a.Files, fromDBFile(file))
+		}
+
+		children, err := q.ListChildSessions(ctx, sql.NullString{String: sess.ID, Valid: true})
+		if err != nil {
+			return nil, fmt.Errorf("listing children of session %s: %w", sess.ID, err)
+		}
+		for _, child := range children {
+			a.Children = append(a.Children, fromDBSession(child))
+		}
+		queue = append(queue, children...)
+	}
+	return a, nil
+}
+
+// Import recreates the archived session in the database as a new top-level
+// session and returns it. Sessions, messages, and files get new IDs, so the
+// same archive can be imported more than once.
+func Import(ctx context.Context, conn *sql.DB, a *Archive) (db.Session, error) {
+	if a.Version < 1 || a.Version > Version {
+		return db.Session{}, fmt.Errorf("%w: archive format version %d, this version of Crush reads up to %d", ErrIncompatible, a.Version, Version)
+	}
+	schema, err := db.SchemaVersion(ctx, conn)
+	if err != nil {
+		return db.Session{}, fmt.Errorf("reading schema version: %w", err)
+	}
+	if a.SchemaVersion > schema {
+		return db.Session{}, fmt.Errorf("%w: archive was exported from a newer database (schema %d, this database is at %d); upgrade Crush to import it", ErrIncompatible, a.SchemaVersion, schema)
+	}
+
+	if a.Session.ID == "" {
+		return db.Session{}, fmt.Errorf("%w: archive has no session", ErrIncompatible)
+	}
+	ids := newIDMap(a)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return db.Session{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	qtx := db.New(conn).WithTx(tx)
+
+	// The session this one was forked from is only kept when it's in the
+	// database too, which it is when importing back where it came from.
+	root := a.Session
+	if root.ForkedFromSessionID != "" {
+		if _, err := qtx.GetSessionByID(ctx, root.ForkedFromSessionID); err != nil {
+			root.ForkedFromSessionID = ""
+			root.ForkedAtMessageID = ""
+		}
+	}
+	root.ParentSessionID = ""
+	if err := importSession(ctx, qtx, ids, root); err != nil {
+		return db.Session{}, err
+	}
+	for _, child := range a.Children {
+		if _, ok := ids[child.ParentSessionID]; !ok {
+			return db.Session{}, fmt.Errorf("%w: session %s has no parent in the archive", ErrIncompatible, child.ID)
+		}
+		if err := importSession(ctx, qtx, ids, child); err != nil {
+			return db.Session{}, err
+		}
+	}
+
+	for _, msg := range a.Messages {
+		sessionID, ok := ids[msg.SessionID]
+		if !ok {
+			return db.Session{}, fmt.Errorf("%w: message %s belongs to no session in the archive", ErrIncompatible, msg.ID)
+		}
+		parts, err := message.UnmarshalParts(msg.Parts)
+		if err != nil {
+			return db.Session{}, fmt.Errorf("reading parts of message %s: %w", msg.ID, err)
+		}
+		id := ids[msg.ID]
+		if err := qtx.CopyMessage(ctx, db.CopyMessageParams{
+			ID:               id,
+			SessionID:        sessionID,
+			Role:             msg.Role,
+			Parts:            compact(msg.Parts),
+			Model:            nullString(msg.Model),
+			Provider:         nullString(msg.Provider),
+			IsSummaryMessage: boolToInt(msg.IsSummaryMessage),
+			FinishedAt:       sql.NullInt64{Int64: msg.FinishedAt, Valid: msg.FinishedAt != 0},
+			CreatedAt:        msg.CreatedAt,
+			UpdatedAt:        msg.UpdatedAt,
+		}); err != nil {
+			return db.Session{}, fmt.Errorf("importing message %s: %w", msg.ID, err)
+		}
+		if err := qtx.IndexMessage(ctx, db.IndexMessageParams{
+			ID:      id,
+			Content: message.SearchText(parts),
+		}); err != nil {
+			return db.Session{}, fmt.Errorf("indexing message %s: %w", msg.ID, err)
+		}
+	}
+
+	for _, file := range a.Files {
+		sessionID, ok := ids[file.SessionID]
+		if !ok {
+			return db.Session{}, fmt.Errorf("%w: file %s belongs to no session in the archive", ErrIncompatible, file.Path)
+		}
+		if err := qtx.CopyFile(ctx, db.CopyFileParams{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			Path:      file.Path,
+			Content:   file.Content,
+			Version:   file.Version,
+			MessageID: ids[file.MessageID],
+			Missing:   boolToInt(file.Missing || (a.SchemaVersion < missingFilesSchemaVersion && file.Content == "")),
+			CreatedAt: file.CreatedAt,
+			UpdatedAt: file.UpdatedAt,
+		}); err != nil {
+			return db.Session{}, fmt.Errorf("importing file %s: %w", file.Path, err)
+		}
+	}
+
+	imported, err := qtx.GetSessionByID(ctx, ids[a.Session.ID])
+	if err != nil {
+		return db.Session{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return db.Session{}, fmt.Errorf("committing transaction: %w", err)
+	}
+	return imported, nil
+}
+
+// Read decodes an archive written by [Archive.Write].
+func Read(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("decoding archive: %w", err)
+	}
+	if a.Version == 0 {
+		return nil, fmt.Errorf("%w: not a Crush session archive", ErrIncompatible)
+	}
+	return &a, nil
+}
+
+// Write encodes the archive as indented JSON.
+func (a *Archive) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// newIDMap maps the IDs of the archived sessions and messages to new ones.
+// Sub-agent and title sessions derive their IDs from the message and
+// session that created them, so theirs are derived from the new IDs in the
+// same way.
+func newIDMap(a *Archive) map[string]string {
+	ids := make(map[string]string, 1+len(a.Children)+len(a.Messages))
+	for _, msg := range a.Messages {
+		ids[msg.ID] = uuid.New().String()
+	}
+	ids[a.Session.ID] = uuid.New().String()
+	for _, child := range a.Children {
+		if messageID, toolCallID, ok := session.ParseAgentToolSessionID(child.ID); ok {
+			if id, ok := ids[messageID]; ok {
+				ids[child.ID] = session.AgentToolSessionID(id, toolCallID)
+				continue
+			}
+		}
+		// See [session.Service.CreateTitleSession].
+		if parentID, ok := ids[child.ParentSessionID]; ok && child.ID == "title-"+child.ParentSessionID {
+			ids[child.ID] = "title-" + parentID
+			continue
+		}
+		ids[child.ID] = uuid.New().String()
+	}
+	return ids
+}
+
+func importSession(ctx context.Context, q *db.Queries, ids map[string]string, sess Session) error {
+	var todos sql.NullString
+	if len(sess.Todos) > 0 {
+		todos = sql.NullString{String: compact(sess.Todos), Valid: true}
+	}
+	if err := q.ImportSession(ctx, db.ImportSessionParams{
+		ID:                  ids[sess.ID],
+		ParentSessionID:     nullString(ids[sess.ParentSessionID]),
+		Title:               sess.Title,
+		PromptTokens:        sess.PromptTokens,
+		CompletionTokens:    sess.CompletionTokens,
+		Cost:                sess.Cost,
+		SummaryMessageID:    nullString(ids[sess.SummaryMessageID]),
+		Todos:               todos,
+		ForkedFromSessionID: nullString(sess.ForkedFromSessionID),
+		ForkedAtMessageID:   nullString(sess.ForkedAtMessageID),
+		UpdatedAt:           sess.UpdatedAt,
+		CreatedAt:           sess.CreatedAt,
+	}); err != nil {
+		return fmt.Errorf("importing session %s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+func fromDBSession(s db.Session) Session {
+	sess := Session{
+		ID:                  s.ID,
+		ParentSessionID:     s.ParentSessionID.String,
+		Title:               s.Title,
+		MessageCount:        s.MessageCount,
+		PromptTokens:        s.PromptTokens,
+		CompletionTokens:    s.CompletionTokens,
+		Cost:                s.Cost,
+		SummaryMessageID:    s.SummaryMessageID.String,
+		ForkedFromSessionID: s.ForkedFromSessionID.String,
+		ForkedAtMessageID:   s.ForkedAtMessageID.String,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+	if s.Todos.Valid && json.Valid([]byte(s.Todos.String)) {
+		sess.Todos = json.RawMessage(s.Todos.String)
+	}
+	return sess
+}
+
+func fromDBMessage(m db.Message) Message {
+	return Message{
+		ID:               m.ID,
+		SessionID:        m.SessionID,
+		Role:             m.Role,
+		Parts:            json.RawMessage(m.Parts),
+		Model:            m.Model.String,
+		Provider:         m.Provider.String,
+		IsSummaryMessage: m.IsSummaryMessage != 0,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+		FinishedAt:       m.FinishedAt.Int64,
+	}
+}
+
+func fromDBFile(f db.File) File {
+	return File{
+		ID:        f.ID,
+		SessionID: f.SessionID,
+		Path:      f.Path,
+		Content:   f.Content,
+		Version:   f.Version,
+		MessageID: f.MessageID,
+		Missing:   f.Missing != 0,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// compact undoes the indentation [Archive.Write] adds to raw JSON, so it's
+// stored as it was before the export.
+func compact(raw json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T) *sql.DB {
+	t.Helper()
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestExportImport(t *testing.T) {
+	src := connect(t)
+	q := db.New(src)
+	sessions := session.NewService(q, src)
+	messages := message.NewService(q)
+
+	sess, err := sessions.Create(t.Context(), "Fix the migration")
+	require.NoError(t, err)
+	user, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "Why is the migration failing?"}},
+	})
+	require.NoError(t, err)
+	assistant, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Model: "model",
+		Parts: []message.ContentPart{
+			message.ToolCall{ID: "call-1", Name: "agent", Input: `{"prompt":"look"}`, Finished: true},
+			message.Finish{Reason: message.FinishReasonToolUse},
+		},
+	})
+	require.NoError(t, err)
+
+	task, err := sessions.CreateTaskSession(t.Context(), session.AgentToolSessionID(assistant.ID, "call-1"), sess.ID, "look")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), task.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "look"}},
+	})
+	require.NoError(t, err)
+
+	_, err = q.CreateFile(t.Context(), db.CreateFileParams{ID: "file-1", SessionID: sess.ID, Path: "/work/db.go", Content: "package db"})
+	require.NoError(t, err)
+
+	sess.Todos = []session.Todo{{Content: "Fix it", Status: session.TodoStatusPending, ActiveForm: "Fixing it"}}
+	sess.SummaryMessageID = user.ID
+	_, err = sessions.Save(t.Context(), sess)
+	require.NoError(t, err)
+
+	a, err := Export(t.Context(), src, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, Version, a.Version)
+	require.NotZero(t, a.SchemaVersion)
+	require.Len(t, a.Children, 1)
+	require.Len(t, a.Messages, 3)
+	require.Len(t, a.Files, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, a.Write(&buf))
+	a, err = Read(&buf)
+	require.NoError(t, err)
+
+	dst := connect(t)
+	imported, err := Import(t.Context(), dst, a)
+	require.NoError(t, err)
+	require.NotEqual(t, sess.ID, imported.ID)
+	require.Equal(t, "Fix the migration", imported.Title)
+	require.Equal(t, int64(2), imported.MessageCount)
+	require.False(t, imported.ParentSessionID.Valid)
+	require.JSONEq(t, `[{"content":"Fix it","status":"pending","active_form":"Fixing it"}]`, imported.Todos.String)
+
+	dq := db.New(dst)
+	msgs, err := dq.ListMessagesBySession(t.Context(), imported.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.NotEqual(t, user.ID, msgs[0].ID)
+	require.Equal(t, msgs[0].ID, imported.SummaryMessageID.String)
+	require.Equal(t, "model", msgs[1].Model.String)
+	parts, err := message.UnmarshalParts([]byte(msgs[1].Parts))
+	require.NoError(t, err)
+	require.Equal(t, assistant.Parts, parts)
+
+	// The sub-agent session is found from the new assistant message.
+	child, err := dq.GetSessionByID(t.Context(), session.AgentToolSessionID(msgs[1].ID, "call-1"))
+	require.NoError(t, err)
+	require.Equal(t, imported.ID, child.ParentSessionID.String)
+	require.Equal(t, int64(1), child.MessageCount)
+
+	files, err := dq.ListFilesBySession(t.Context(), imported.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "/work/db.go", files[0].Path)
+	require.Equal(t, "package db", files[0].Content)
+
+	results, err := message.NewService(dq).Search(t.Context(), "migration", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, msgs[0].ID, results[0].MessageID)
+
+	// Importing again makes another copy.
+	again, err := Import(t.Context(), dst, a)
+	require.NoError(t, err)
+	require.NotEqual(t, imported.ID, again.ID)
+}
+
+func TestImportIncompatible(t *testing.T) {
+	conn := connect(t)
+
+	_, err := Import(t.Context(), conn, &Archive{Version: Version + 1, Session: Session{ID: "s"}})
+	require.ErrorIs(t, err, ErrIncompatible)
+
+	schema, err := db.SchemaVersion(t.Context(), conn)
+	require.NoError(t, err)
+	_, err = Import(t.Context(), conn, &Archive{Version: Version, SchemaVersion: schema + 1, Session: Session{ID: "s"}})
+	require.ErrorIs(t, err, ErrIncompatible)
+	require.ErrorContains(t, err, "upgrade Crush")
+
+	_, err = Read(bytes.NewBufferString(`{"title":"not an archive"}`))
+	require.ErrorIs(t, err, ErrIncompatible)
+}
@@ -23,12 +23,13 @@ import (
 
 // Common errors returned by backend operations.
 var (
-	ErrWorkspaceNotFound       = errors.New("workspace not found")
-	ErrLSPClientNotFound       = errors.New("LSP client not found")
-	ErrAgentNotInitialized     = errors.New("agent coordinator not initialized")
-	ErrPathRequired            = errors.New("path is required")
-	ErrInvalidPermissionAction = errors.New("invalid permission action")
-	ErrUnknownCommand          = errors.New("unknown command")
+	ErrWorkspaceNotFound        = errors.New("workspace not found")
+	ErrLSPClientNotFound        = errors.New("LSP client not found")
+	ErrAgentNotInitialized      = errors.New("agent coordinator not initialized")
+	ErrPathRequired             = errors.New("path is required")
+	ErrInvalidPermissionAction  = errors.New("invalid permission action")
+	ErrInvalidElicitationAction = errors.New("invalid elicitation action")
+	ErrUnknownCommand           = errors.New("unknown command")
 )
 
 // ShutdownFunc is called when the backend needs to trigger a server
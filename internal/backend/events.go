@@ -107,3 +107,13 @@ func (b *Backend) MCPRefreshPrompts(ctx context.Context, _ string, name string)
 func (b *Backend) MCPRefreshResources(ctx context.Context, _ string, name string) {
 	mcptools.RefreshResources(ctx, name)
 }
+
+// AnswerMCPElicitation answers the elicitation id of an MCP server.
+func (b *Backend) AnswerMCPElicitation(_ string, id string, resp mcptools.ElicitationResponse) error {
+	switch resp.Action {
+	case mcptools.ElicitationAccept, mcptools.ElicitationDecline, mcptools.ElicitationCancel:
+	default:
+		return ErrInvalidElicitationAction
+	}
+	return mcptools.AnswerElicitation(id, resp)
+}
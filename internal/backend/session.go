@@ -3,6 +3,8 @@ package backend
 import (
 	"context"
 
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/session"
@@ -130,3 +132,70 @@ func (b *Backend) ListAllUserMessages(ctx context.Context, workspaceID string) (
 
 	return ws.Messages.ListAllUserMessages(ctx)
 }
+
+// SearchMessages runs a full-text search over the messages of a
+// workspace.
+func (b *Backend) SearchMessages(ctx context.Context, workspaceID, query string, limit int) ([]message.SearchResult, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws.Messages.Search(ctx, query, limit)
+}
+
+// ForkSession branches a new session off a session at the given message
+// or tool call.
+func (b *Backend) ForkSession(ctx context.Context, workspaceID, sessionID string, req proto.SessionForkRequest) (session.Session, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return session.Session{}, err
+	}
+
+	return ws.ForkSession(ctx, sessionID, req.MessageID)
+}
+
+// ListCheckpoints returns the checkpoints of a session.
+func (b *Backend) ListCheckpoints(ctx context.Context, workspaceID, sessionID string) ([]checkpoint.Checkpoint, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws.ListCheckpoints(ctx, sessionID)
+}
+
+// CheckpointDiff returns the changes made during a turn of a session.
+func (b *Backend) CheckpointDiff(ctx context.Context, workspaceID, sessionID string, n int, stat bool) (string, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	return ws.CheckpointDiff(ctx, sessionID, n, stat)
+}
+
+// RestoreCheckpoint brings the working tree back to how it was at the
+// start of a turn of a session.
+func (b *Backend) RestoreCheckpoint(ctx context.Context, workspaceID, sessionID string, n int) ([]checkpoint.Change, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws.RestoreCheckpoint(ctx, sessionID, n)
+}
+
+// RewindSession rolls back the files changed in a session to the state
+// before the given message or tool call.
+func (b *Backend) RewindSession(ctx context.Context, workspaceID, sessionID string, req proto.SessionRewindRequest) (history.RewindResult, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return history.RewindResult{}, err
+	}
+
+	return ws.RewindSession(ctx, sessionID, req.MessageID, history.RewindOptions{
+		Force:  req.Force,
+		DryRun: req.DryRun,
+	})
+}
@@ -0,0 +1,477 @@
+// Package checkpoint snapshots the working tree at the start of every agent
+// turn, so the changes made during a turn can be reviewed and undone, even
+// those made through the shell.
+//
+// A checkpoint is a commit of the whole working tree, tracked and untracked
+// files alike, stored under a hidden ref of the repository:
+//
+//	refs/crush/checkpoints/<session id>/<turn>
+//
+// The working tree as it was before the latest restore of a session is
+// kept the same way, outside of its turns, so the restore can be undone:
+//
+//	refs/crush/restores/<session id>
+//
+// Taking one touches neither the user's index nor their branch.
+package checkpoint
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefPrefix is the prefix of the refs checkpoints are stored under.
+const RefPrefix = "refs/crush/checkpoints/"
+
+// restoreRefPrefix is the prefix of the refs holding the working tree as
+// it was before the latest restore of each session.
+const restoreRefPrefix = "refs/crush/restores/"
+
+// directoryTrailer records the working tree a checkpoint was taken of in
+// its commit message, so it can be restored there.
+const directoryTrailer = "Crush-Directory: "
+
+var (
+	// ErrNotRepository is returned by [New] when the working directory is
+	// not inside a git working tree.
+	ErrNotRepository = errors.New("not inside a git working tree")
+	// ErrNotFound is returned when a session has no checkpoint with the
+	// given number.
+	ErrNotFound = errors.New("checkpoint not found")
+	// ErrNothingToUndo is returned by [Store.Undo] when a session has no
+	// restore to undo.
+	ErrNothingToUndo = errors.New("no restore to undo")
+)
+
+// Checkpoint is a snapshot of the working tree taken at the start of a
+// turn of a session.
+type Checkpoint struct {
+	// Number is the turn the checkpoint was taken at, starting at 1.
+	Number    int
+	SessionID string
+	// Commit is the ID of the commit holding the snapshot.
+	Commit string
+	// Prompt is the prompt that started the turn.
+	Prompt string
+	// Dir is the working tree the snapshot was taken of.
+	Dir       string
+	CreatedAt int64
+}
+
+// Ref returns the name of the ref the checkpoint is stored under.
+func (c Checkpoint) Ref() string {
+	return ref(c.SessionID, c.Number)
+}
+
+func ref(sessionID string, n int) string {
+	return RefPrefix + sessionID + "/" + strconv.Itoa(n)
+}
+
+// Change is a file changed by a restore.
+type Change struct {
+	Path string
+	// Deleted reports whether the file was deleted because it did not exist
+	// at the checkpoint, rather than restored.
+	Deleted bool
+}
+
+// Store takes, lists, and restores the checkpoints of the sessions of a
+// repository.
+type Store struct {
+	repo string
+}
+
+// New returns a Store for the repository containing workingDir. It returns
+// [ErrNotRepository] if workingDir is not inside a git working tree.
+func New(ctx context.Context, workingDir string) (*Store, error) {
+	top, err := toplevel(ctx, workingDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{repo: top}, nil
+}
+
+// Create snapshots the working tree containing dir as the next checkpoint
+// of a session. dir may be the repository or any of its linked worktrees.
+func (s *Store) Create(ctx context.Context, sessionID, dir, prompt string) (Checkpoint, error) {
+	top, err := toplevel(ctx, dir)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	existing, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	n := 1
+	if len(existing) > 0 {
+		n = existing[len(existing)-1].Number + 1
+	}
+
+	prompt = strings.TrimSpace(prompt)
+	commit, err := commitSnapshot(ctx, top, fmt.Sprintf("Checkpoint %d", n), prompt)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	cp := Checkpoint{
+		Number:    n,
+		SessionID: sessionID,
+		Commit:    commit,
+		Prompt:    prompt,
+		Dir:       top,
+		CreatedAt: time.Now().Unix(),
+	}
+	if _, err := git(ctx, s.repo, nil, "update-ref", cp.Ref(), commit, ""); err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// commitSnapshot commits a snapshot of the working tree at top, with the
+// given subject and body, and returns the commit's ID.
+func commitSnapshot(ctx context.Context, top, subject, body string) (string, error) {
+	tree, err := snapshot(ctx, top)
+	if err != nil {
+		return "", err
+	}
+
+	msg := subject + "\n\n"
+	if body != "" {
+		msg += body + "\n\n"
+	}
+	msg += directoryTrailer + top + "\n"
+
+	args := []string{"commit-tree", tree}
+	if head, err := git(ctx, top, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		args = append(args, "-p", strings.TrimSpace(head))
+	}
+	// Checkpoints are Crush's, not the user's, and must not fail for want
+	// of a configured identity.
+	env := []string{
+		"GIT_AUTHOR_NAME=Crush", "GIT_AUTHOR_EMAIL=crush@charm.land",
+		"GIT_COMMITTER_NAME=Crush", "GIT_COMMITTER_EMAIL=crush@charm.land",
+	}
+	commit, err := gitInput(ctx, top, env, strings.NewReader(msg), args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit checkpoint: %w", err)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// List returns the checkpoints of a session, oldest first.
+func (s *Store) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	out, err := git(ctx, s.repo, nil, "for-each-ref",
+		"--format=%(refname)%00%(objectname)%00%(committerdate:unix)%00%(contents)%00%00",
+		RefPrefix+sessionID+"/",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []Checkpoint
+	for record := range strings.SplitSeq(out, "\x00\x00") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[0], RefPrefix+sessionID+"/"))
+		if err != nil {
+			continue
+		}
+		created, _ := strconv.ParseInt(fields[2], 10, 64)
+		prompt, dir := parseMessage(fields[3])
+		checkpoints = append(checkpoints, Checkpoint{
+			Number:    n,
+			SessionID: sessionID,
+			Commit:    fields[1],
+			Prompt:    prompt,
+			Dir:       dir,
+			CreatedAt: created,
+		})
+	}
+	slices.SortFunc(checkpoints, func(a, b Checkpoint) int {
+		return cmp.Compare(a.Number, b.Number)
+	})
+	return checkpoints, nil
+}
+
+// parseMessage returns the prompt and directory recorded in the commit
+// message of a checkpoint.
+func parseMessage(msg string) (prompt, dir string) {
+	_, body, _ := strings.Cut(msg, "\n")
+	var lines []string
+	for line := range strings.Lines(body) {
+		if d, ok := strings.CutPrefix(line, directoryTrailer); ok {
+			dir = strings.TrimSpace(d)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "")), dir
+}
+
+// Get returns checkpoint n of a session.
+func (s *Store) Get(ctx context.Context, sessionID string, n int) (Checkpoint, error) {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	for _, cp := range checkpoints {
+		if cp.Number == n {
+			return cp, nil
+		}
+	}
+	return Checkpoint{}, ErrNotFound
+}
+
+// Diff returns the changes made during turn n of a session, from its
+// checkpoint to the next one, or to the working tree as it is now for the
+// latest turn. With stat, a diffstat is returned instead of a patch.
+func (s *Store) Diff(ctx context.Context, sessionID string, n int, stat bool) (string, error) {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(checkpoints, func(cp Checkpoint) bool { return cp.Number == n })
+	if i < 0 {
+		return "", ErrNotFound
+	}
+	from := checkpoints[i]
+
+	var to string
+	if i+1 < len(checkpoints) {
+		to = checkpoints[i+1].Commit
+	} else {
+		top, err := toplevel(ctx, cmp.Or(from.Dir, s.repo))
+		if err != nil {
+			return "", err
+		}
+		if to, err = snapshot(ctx, top); err != nil {
+			return "", err
+		}
+	}
+
+	args := []string{"diff", "--no-color"}
+	if stat {
+		args = append(args, "--stat")
+	}
+	return git(ctx, s.repo, nil, append(args, from.Commit, to)...)
+}
+
+// Restore brings the working tree checkpoint n of a session was taken of
+// back to how it was then, restoring changed and deleted files and deleting
+// files that were added since. Ignored files are left alone, as are the
+// index and the current branch.
+//
+// The working tree is snapshotted first, outside of the session's turns,
+// so the latest restore can be undone with [Store.Undo].
+func (s *Store) Restore(ctx context.Context, sessionID string, n int) ([]Change, error) {
+	cp, err := s.Get(ctx, sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	top, err := toplevel(ctx, cmp.Or(cp.Dir, s.repo))
+	if err != nil {
+		return nil, err
+	}
+	current, err := commitSnapshot(ctx, top, fmt.Sprintf("Before restoring checkpoint %d", n), "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, s.repo, nil, "update-ref", restoreRefPrefix+sessionID, current); err != nil {
+		return nil, fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return checkout(ctx, top, cp.Commit, current)
+}
+
+// Undo brings the working tree back to how it was before the latest
+// restore of a session. It returns [ErrNothingToUndo] if there is none.
+func (s *Store) Undo(ctx context.Context, sessionID string) ([]Change, error) {
+	ref := restoreRefPrefix + sessionID
+	out, err := git(ctx, s.repo, nil, "for-each-ref", "--format=%(objectname)%00%(contents)", ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, msg, ok := strings.Cut(strings.TrimSpace(out), "\x00")
+	if !ok {
+		return nil, ErrNothingToUndo
+	}
+	_, dir := parseMessage(msg)
+	top, err := toplevel(ctx, cmp.Or(dir, s.repo))
+	if err != nil {
+		return nil, err
+	}
+	current, err := snapshot(ctx, top)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := checkout(ctx, top, commit, current)
+	if err != nil {
+		return changes, err
+	}
+	if _, err := git(ctx, s.repo, nil, "update-ref", "-d", ref); err != nil {
+		return changes, err
+	}
+	return changes, nil
+}
+
+// checkout brings the working tree at dir, whose snapshot is current,
+// back to the snapshot in commit.
+func checkout(ctx context.Context, dir, commit, current string) ([]Change, error) {
+	out, err := git(ctx, dir, nil, "diff", "--name-status", "--no-renames", "-z", commit, current)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	var restore []string
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+		if status == "A" {
+			if err := os.Remove(filepath.Join(dir, path)); err != nil && !os.IsNotExist(err) {
+				return changes, fmt.Errorf("failed to delete %s: %w", path, err)
+			}
+			changes = append(changes, Change{Path: path, Deleted: true})
+			continue
+		}
+		restore = append(restore, path)
+		changes = append(changes, Change{Path: path})
+	}
+	if len(restore) == 0 {
+		return changes, nil
+	}
+
+	// Check the files out of the checkpoint through a throwaway index.
+	tmp, err := os.MkdirTemp("", "crush-checkpoint-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	if _, err := git(ctx, dir, env, "read-tree", commit); err != nil {
+		return nil, err
+	}
+	paths := strings.Join(restore, "\x00") + "\x00"
+	if _, err := gitInput(ctx, dir, env, strings.NewReader(paths), "checkout-index", "--force", "-z", "--stdin"); err != nil {
+		return nil, fmt.Errorf("failed to restore files: %w", err)
+	}
+	return changes, nil
+}
+
+// Delete removes all the checkpoints of a session, and the working tree
+// kept to undo its latest restore.
+func (s *Store) Delete(ctx context.Context, sessionID string) error {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, cp := range checkpoints {
+		if _, err := git(ctx, s.repo, nil, "update-ref", "-d", cp.Ref()); err != nil {
+			return err
+		}
+	}
+	_, err = git(ctx, s.repo, nil, "update-ref", "-d", restoreRefPrefix+sessionID)
+	return err
+}
+
+// Prune removes the oldest checkpoints of a session, keeping the keep most
+// recent ones. The remaining checkpoints keep their numbers.
+func (s *Store) Prune(ctx context.Context, sessionID string, keep int) error {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) <= keep {
+		return nil
+	}
+	for _, cp := range checkpoints[:len(checkpoints)-max(keep, 0)] {
+		if _, err := git(ctx, s.repo, nil, "update-ref", "-d", cp.Ref()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot writes the working tree at top, tracked and untracked files but
+// not ignored ones, as a tree object and returns its ID.
+func snapshot(ctx context.Context, top string) (string, error) {
+	tmp, err := os.MkdirTemp("", "crush-checkpoint-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+
+	// Start from a copy of the real index so that unchanged files don't
+	// have to be hashed again.
+	if path, err := git(ctx, top, nil, "rev-parse", "--path-format=absolute", "--git-path", "index"); err == nil {
+		_ = copyFile(strings.TrimSpace(path), index)
+	}
+	env := []string{"GIT_INDEX_FILE=" + index}
+	if _, err := git(ctx, top, env, "add", "--all"); err != nil {
+		return "", fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	tree, err := git(ctx, top, env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	return strings.TrimSpace(tree), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func toplevel(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil || strings.TrimSpace(out) == "" {
+		return "", ErrNotRepository
+	}
+	return filepath.Clean(strings.TrimSpace(out)), nil
+}
+
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	return gitInput(ctx, dir, env, nil, args...)
+}
+
+func gitInput(ctx context.Context, dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(cmp.Or(stderr.String(), stdout.String()))
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
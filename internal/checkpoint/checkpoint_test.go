@@ -0,0 +1,188 @@
+package checkpoint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(k+"_NAME", "Crush")
+		t.Setenv(k+"_EMAIL", "crush@charm.land")
+	}
+	repo := t.TempDir()
+	run(t, repo, "init", "--initial-branch=main")
+	write(t, repo, "main.go", "package main\n")
+	write(t, repo, ".gitignore", "*.log\n")
+	run(t, repo, "add", "main.go", ".gitignore")
+	run(t, repo, "commit", "--message", "initial")
+	return repo
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(t.Context(), dir, nil, args...)
+	require.NoError(t, err)
+	return out
+}
+
+func write(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func read(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestCheckpoint(t *testing.T) {
+	repo := newRepo(t)
+	s, err := New(t.Context(), repo)
+	require.NoError(t, err)
+
+	list, err := s.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	write(t, repo, "notes.txt", "untracked\n")
+	first, err := s.Create(t.Context(), "session", repo, "Add a main func\n\nPlease.")
+	require.NoError(t, err)
+	require.Equal(t, 1, first.Number)
+
+	// Changes made during the turn, as if through the shell.
+	write(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	write(t, repo, "new.go", "package main\n")
+	write(t, repo, "debug.log", "ignored\n")
+	require.NoError(t, os.Remove(filepath.Join(repo, "notes.txt")))
+
+	second, err := s.Create(t.Context(), "session", repo, "Undo it")
+	require.NoError(t, err)
+	require.Equal(t, 2, second.Number)
+
+	list, err = s.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "Add a main func\n\nPlease.", list[0].Prompt)
+	require.Equal(t, first.Commit, list[0].Commit)
+	require.Equal(t, repo, list[0].Dir)
+	require.Equal(t, "Undo it", list[1].Prompt)
+
+	diff, err := s.Diff(t.Context(), "session", 1, false)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+func main() {}")
+	require.Contains(t, diff, "new.go")
+	require.Contains(t, diff, "-untracked")
+	require.NotContains(t, diff, "debug.log")
+
+	// The latest turn is diffed against the working tree.
+	write(t, repo, "main.go", "package main\n\nfunc main() { println() }\n")
+	diff, err = s.Diff(t.Context(), "session", 2, true)
+	require.NoError(t, err)
+	require.Contains(t, diff, "main.go")
+
+	_, err = s.Diff(t.Context(), "session", 3, false)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Neither the index nor the branch were touched.
+	require.Empty(t, run(t, repo, "diff", "--cached", "--name-only"))
+	require.Equal(t, "main\n", run(t, repo, "branch", "--show-current"))
+	require.Equal(t, "1\n", run(t, repo, "rev-list", "--count", "HEAD"))
+
+	changes, err := s.Restore(t.Context(), "session", 1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []Change{
+		{Path: "main.go"},
+		{Path: "new.go", Deleted: true},
+		{Path: "notes.txt"},
+	}, changes)
+	require.Equal(t, "package main\n", read(t, repo, "main.go"))
+	require.Equal(t, "untracked\n", read(t, repo, "notes.txt"))
+	require.NoFileExists(t, filepath.Join(repo, "new.go"))
+	require.FileExists(t, filepath.Join(repo, "debug.log"))
+
+	// The restore can be undone, and isn't counted as a turn.
+	list, err = s.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	_, err = s.Undo(t.Context(), "session")
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() { println() }\n", read(t, repo, "main.go"))
+	require.FileExists(t, filepath.Join(repo, "new.go"))
+	_, err = s.Undo(t.Context(), "session")
+	require.ErrorIs(t, err, ErrNothingToUndo)
+
+	next, err := s.Create(t.Context(), "session", repo, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, next.Number)
+
+	require.NoError(t, s.Delete(t.Context(), "session"))
+	list, err = s.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestPrune(t *testing.T) {
+	repo := newRepo(t)
+	s, err := New(t.Context(), repo)
+	require.NoError(t, err)
+
+	for range 4 {
+		_, err := s.Create(t.Context(), "session", repo, "")
+		require.NoError(t, err)
+	}
+	_, err = s.Create(t.Context(), "other", repo, "")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Prune(t.Context(), "session", 2))
+	list, err := s.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, 3, list[0].Number)
+	require.Equal(t, 4, list[1].Number)
+
+	next, err := s.Create(t.Context(), "session", repo, "")
+	require.NoError(t, err)
+	require.Equal(t, 5, next.Number)
+
+	other, err := s.List(t.Context(), "other")
+	require.NoError(t, err)
+	require.Len(t, other, 1)
+}
+
+func TestCheckpointWithoutCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	run(t, repo, "init")
+	write(t, repo, "main.go", "package main\n")
+
+	s, err := New(t.Context(), repo)
+	require.NoError(t, err)
+	_, err = s.Create(t.Context(), "session", repo, "")
+	require.NoError(t, err)
+
+	write(t, repo, "main.go", "package changed\n")
+	_, err = s.Restore(t.Context(), "session", 1)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", read(t, repo, "main.go"))
+}
+
+func TestNewNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := New(t.Context(), t.TempDir())
+	require.ErrorIs(t, err, ErrNotRepository)
+}
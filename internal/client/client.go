@@ -2,12 +2,16 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	stdpath "path"
 	"path/filepath"
 	"time"
@@ -17,6 +21,9 @@ import (
 	"github.com/charmbracelet/crush/internal/server"
 )
 
+// ErrUnauthorized is returned when the server rejects the client's token.
+var ErrUnauthorized = errors.New("unauthorized: missing or invalid server token (set CRUSH_SERVER_TOKEN)")
+
 // DummyHost is used to satisfy the http.Client's requirement for a URL.
 const DummyHost = "api.crush.localhost"
 
@@ -26,6 +33,7 @@ type Client struct {
 	path    string
 	network string
 	addr    string
+	token   string
 }
 
 // DefaultClient creates a new [Client] connected to the default server address.
@@ -47,9 +55,19 @@ func NewClient(path, network, address string) (*Client, error) {
 	p := &http.Protocols{}
 	p.SetHTTP1(true)
 	p.SetUnencryptedHTTP2(true)
+	if c.network == "https" {
+		p.SetHTTP2(true)
+	}
 	tr := http.DefaultTransport.(*http.Transport).Clone()
 	tr.Protocols = p
 	tr.DialContext = c.dialer
+	if c.network == "https" {
+		tlsConfig, err := tlsConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = tlsConfig
+	}
 	if c.network == "npipe" || c.network == "unix" {
 		tr.DisableCompression = true
 	}
@@ -60,6 +78,34 @@ func NewClient(path, network, address string) (*Client, error) {
 	return c, nil
 }
 
+// SetToken sets the bearer token sent with every request, as required by
+// servers listening on TCP.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// tlsConfigFromEnv returns the TLS configuration for https servers,
+// trusting the CA certificates in the file named by CRUSH_SERVER_CA in
+// addition to the system roots.
+func tlsConfigFromEnv() (*tls.Config, error) {
+	caFile := os.Getenv("CRUSH_SERVER_CA")
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
 // Path returns the client's workspace filesystem path.
 func (c *Client) Path() string {
 	return c.path
@@ -176,6 +222,10 @@ func (c *Client) sendReq(ctx context.Context, method, path string, query url.Val
 	if err != nil {
 		return nil, err
 	}
+	if rsp.StatusCode == http.StatusUnauthorized {
+		rsp.Body.Close()
+		return nil, ErrUnauthorized
+	}
 
 	return rsp, nil
 }
@@ -191,7 +241,13 @@ func (c *Client) buildReq(ctx context.Context, method, url string, body io.Reade
 	}
 
 	r.URL.Scheme = "http"
+	if c.network == "https" {
+		r.URL.Scheme = "https"
+	}
 	r.URL.Host = c.addr
+	if c.token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	if c.network == "npipe" || c.network == "unix" {
 		r.Host = DummyHost
 	}
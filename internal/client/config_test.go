@@ -99,3 +99,31 @@ func TestSetProviderAPIKeyNilOAuthFailsLocally(t *testing.T) {
 	err := c.SetProviderAPIKey(context.Background(), "ws1", config.ScopeGlobal, "x", tok)
 	require.Error(t, err)
 }
+
+func TestClientSendsToken(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := captureClient(t, srv)
+	c.SetToken("secret")
+	require.NoError(t, c.Health(context.Background()))
+	require.Equal(t, "Bearer secret", got)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := captureClient(t, srv)
+	require.ErrorIs(t, c.Health(context.Background()), ErrUnauthorized)
+}
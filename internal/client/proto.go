@@ -11,9 +11,12 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
@@ -144,6 +147,14 @@ func (c *Client) SubscribeEvents(ctx context.Context, id string) (<-chan any, er
 				var e pubsub.Event[proto.MCPEvent]
 				_ = json.Unmarshal(p.Payload, &e)
 				sendEvent(ctx, events, e)
+			case pubsub.PayloadTypeMCPElicitation:
+				var e pubsub.Event[proto.MCPElicitation]
+				_ = json.Unmarshal(p.Payload, &e)
+				sendEvent(ctx, events, e)
+			case pubsub.PayloadTypeMCPProgress:
+				var e pubsub.Event[proto.MCPProgress]
+				_ = json.Unmarshal(p.Payload, &e)
+				sendEvent(ctx, events, e)
 			case pubsub.PayloadTypePermissionRequest:
 				var e pubsub.Event[proto.PermissionRequest]
 				_ = json.Unmarshal(p.Payload, &e)
@@ -276,6 +287,21 @@ func (c *Client) MCPRefreshResources(ctx context.Context, id, name string) error
 	return nil
 }
 
+// AnswerMCPElicitation answers the elicitation eid of an MCP server.
+func (c *Client) AnswerMCPElicitation(ctx context.Context, id, eid string, resp proto.MCPElicitationResponse) error {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/mcp/elicitations/%s", id, eid), nil,
+		jsonBody(resp),
+		http.Header{"Content-Type": []string{"application/json"}})
+	if err != nil {
+		return fmt.Errorf("failed to answer MCP elicitation: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to answer MCP elicitation: status code %d", rsp.StatusCode)
+	}
+	return nil
+}
+
 // GetAgentSessionQueuedPrompts retrieves the number of queued prompts for a
 // session.
 func (c *Client) GetAgentSessionQueuedPrompts(ctx context.Context, id string, sessionID string) (int, error) {
@@ -448,6 +474,121 @@ func (c *Client) ListSessionHistoryFiles(ctx context.Context, id string, session
 	return files, nil
 }
 
+// ForkSession branches a new session off a session at a message or tool
+// call and returns the new session.
+func (c *Client) ForkSession(ctx context.Context, id string, sessionID string, req proto.SessionForkRequest) (*proto.Session, error) {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/fork", id, sessionID), nil, jsonBody(req), http.Header{"Content-Type": []string{"application/json"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fork session: status code %d", rsp.StatusCode)
+	}
+	var sess proto.Session
+	if err := json.NewDecoder(rsp.Body).Decode(&sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &sess, nil
+}
+
+// ListCheckpoints returns the checkpoints of a session.
+func (c *Client) ListCheckpoints(ctx context.Context, id string, sessionID string) ([]proto.Checkpoint, error) {
+	rsp, err := c.get(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/checkpoints", id, sessionID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list checkpoints: status code %d", rsp.StatusCode)
+	}
+	var checkpoints []proto.Checkpoint
+	if err := json.NewDecoder(rsp.Body).Decode(&checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// CheckpointDiff returns the changes made during a turn of a session.
+func (c *Client) CheckpointDiff(ctx context.Context, id string, sessionID string, n int, stat bool) (string, error) {
+	var params url.Values
+	if stat {
+		params = url.Values{"stat": []string{"true"}}
+	}
+	rsp, err := c.get(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/checkpoints/%d/diff", id, sessionID, n), params, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff checkpoint: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode == http.StatusNotFound {
+		return "", checkpoint.ErrNotFound
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to diff checkpoint: status code %d", rsp.StatusCode)
+	}
+	var diff proto.CheckpointDiff
+	if err := json.NewDecoder(rsp.Body).Decode(&diff); err != nil {
+		return "", fmt.Errorf("failed to decode checkpoint diff: %w", err)
+	}
+	return diff.Diff, nil
+}
+
+// RestoreCheckpoint brings the working tree back to how it was at the
+// start of a turn of a session.
+func (c *Client) RestoreCheckpoint(ctx context.Context, id string, sessionID string, n int) ([]proto.CheckpointChange, error) {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/checkpoints/%d/restore", id, sessionID, n), nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, checkpoint.ErrNotFound
+	}
+	if rsp.StatusCode != http.StatusOK {
+		// A busy session carries an error message worth showing.
+		var e proto.Error
+		if err := json.NewDecoder(rsp.Body).Decode(&e); err == nil && e.Message != "" {
+			return nil, fmt.Errorf("failed to restore checkpoint: %s", e.Message)
+		}
+		return nil, fmt.Errorf("failed to restore checkpoint: status code %d", rsp.StatusCode)
+	}
+	var changes []proto.CheckpointChange
+	if err := json.NewDecoder(rsp.Body).Decode(&changes); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint restore: %w", err)
+	}
+	return changes, nil
+}
+
+// RewindSession rolls back the files changed in a session. When files were
+// modified outside of Crush and the request is not forced, it returns the
+// computed changes along with [history.ErrRewindConflict].
+func (c *Client) RewindSession(ctx context.Context, id string, sessionID string, req proto.SessionRewindRequest) (*proto.SessionRewindResult, error) {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/rewind", id, sessionID), nil, jsonBody(req), http.Header{"Content-Type": []string{"application/json"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind session: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusConflict {
+		return nil, fmt.Errorf("failed to rewind session: status code %d", rsp.StatusCode)
+	}
+	var body struct {
+		proto.SessionRewindResult
+		proto.Error
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode rewind result: %w", err)
+	}
+	if rsp.StatusCode == http.StatusConflict {
+		// A busy session is also reported as a conflict, but carries an
+		// error message rather than the computed changes.
+		if body.Message != "" {
+			return nil, fmt.Errorf("failed to rewind session: %s", body.Message)
+		}
+		return &body.SessionRewindResult, history.ErrRewindConflict
+	}
+	return &body.SessionRewindResult, nil
+}
+
 // CreateSession creates a new session in a workspace as a proto type.
 func (c *Client) CreateSession(ctx context.Context, id string, title string) (*proto.Session, error) {
 	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/sessions", id), nil, jsonBody(proto.Session{Title: title}), http.Header{"Content-Type": []string{"application/json"}})
@@ -613,6 +754,28 @@ func (c *Client) ListAllUserMessages(ctx context.Context, id string) ([]proto.Me
 	return msgs, nil
 }
 
+// SearchMessages runs a full-text search over the messages of a
+// workspace. A zero limit uses the server's default.
+func (c *Client) SearchMessages(ctx context.Context, id string, query string, limit int) ([]proto.SearchResult, error) {
+	params := url.Values{"q": []string{query}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	rsp, err := c.get(ctx, fmt.Sprintf("/workspaces/%s/search", id), params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search messages: status code %d", rsp.StatusCode)
+	}
+	var results []proto.SearchResult
+	if err := json.NewDecoder(rsp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+	return results, nil
+}
+
 // CancelAgentSession cancels an ongoing agent operation for a session.
 func (c *Client) CancelAgentSession(ctx context.Context, id string, sessionID string) error {
 	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/agent/sessions/%s/cancel", id, sessionID), nil, nil, nil)
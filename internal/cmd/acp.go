@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/crush/internal/acp"
+	"github.com/spf13/cobra"
+)
+
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Serve the agent of Crush to editors over the Agent Client Protocol",
+	Long: `Serve the agent of Crush to editors speaking the Agent Client Protocol
+(ACP), JSON-RPC over stdio. The editor starts it and talks to it over its
+standard input and output.
+
+Each ACP session is a Crush session, which editors can load again later.
+The answers of the agent and its tool calls stream to the editor, which
+is asked for the permissions the tools need. When the editor supports it,
+the tools read and write files through its buffers, unsaved changes
+included, instead of on disk.
+
+It always runs its own app in the working directory, as the tools work on
+the files of the editor.`,
+	Example: `
+# Serve in the project, as configured in the editor
+crush acp --cwd /path/to/project
+  `,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		ws, cleanup, err := setupLocalWorkspace(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if !ws.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		slog.Info("Serving ACP over stdio")
+		err = acp.New(ws).Serve(ctx, os.Stdin, os.Stdout)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("acp server error: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	acpCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+}
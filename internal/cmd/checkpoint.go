@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var checkpointCmd = &cobra.Command{
+	Use:     "checkpoint",
+	Aliases: []string{"checkpoints", "cp"},
+	Short:   "Review and restore per-turn checkpoints",
+	Long: `At the start of every turn of a session, Crush snapshots the working tree,
+tracked and untracked files alike, into a hidden git ref. The changes made
+during a turn, including those made through shell commands, can be reviewed
+and undone from there without touching the index or the current branch. It's
+off by default; set "options.checkpoints" to true to turn it on.`,
+}
+
+var (
+	checkpointListJSON    bool
+	checkpointDiffStat    bool
+	checkpointRestoreJSON bool
+	checkpointUndoJSON    bool
+)
+
+var checkpointListCmd = &cobra.Command{
+	Use:   "list <session-id>",
+	Short: "List the checkpoints of a session",
+	Long: `List the checkpoints of a session, one per turn, oldest first. Use --json for
+machine-readable output. ID can be a UUID, full hash, or hash prefix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckpointList,
+}
+
+var checkpointDiffCmd = &cobra.Command{
+	Use:   "diff <session-id> <n>",
+	Short: "Show the changes made during a turn",
+	Long: `Show the changes made during turn n of a session, from its checkpoint to the
+next one, or to the working tree as it is now for the latest turn. ID can be
+a UUID, full hash, or hash prefix.`,
+	Example: `
+# Review what the second turn changed
+crush checkpoint diff 3f2a 2
+  `,
+	Args: cobra.ExactArgs(2),
+	RunE: runCheckpointDiff,
+}
+
+var checkpointRestoreCmd = &cobra.Command{
+	Use:   "restore <session-id> <n>",
+	Short: "Bring the working tree back to a checkpoint",
+	Long: `Bring the working tree back to how it was at the start of turn n of a
+session: changed and deleted files are restored, and files added since are
+deleted. Ignored files are left alone. The working tree is snapshotted
+first, so the latest restore can be undone with 'crush checkpoint undo'. Use
+--json for machine-readable output. ID can be a UUID, full hash, or hash
+prefix.`,
+	Example: `
+# Undo everything since the start of the third turn
+crush checkpoint restore 3f2a 3
+  `,
+	Args: cobra.ExactArgs(2),
+	RunE: runCheckpointRestore,
+}
+
+var checkpointUndoCmd = &cobra.Command{
+	Use:   "undo <session-id>",
+	Short: "Undo the latest restore of a session",
+	Long: `Bring the working tree back to how it was before the latest restore of a
+session. Use --json for machine-readable output. ID can be a UUID, full hash,
+or hash prefix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckpointUndo,
+}
+
+func init() {
+	checkpointListCmd.Flags().BoolVar(&checkpointListJSON, "json", false, "output in JSON format")
+	checkpointDiffCmd.Flags().BoolVar(&checkpointDiffStat, "stat", false, "show a diffstat instead of a patch")
+	checkpointRestoreCmd.Flags().BoolVar(&checkpointRestoreJSON, "json", false, "output in JSON format")
+	checkpointUndoCmd.Flags().BoolVar(&checkpointUndoJSON, "json", false, "output in JSON format")
+	checkpointCmd.AddCommand(checkpointListCmd)
+	checkpointCmd.AddCommand(checkpointDiffCmd)
+	checkpointCmd.AddCommand(checkpointRestoreCmd)
+	checkpointCmd.AddCommand(checkpointUndoCmd)
+}
+
+// checkpointSetup resolves the session a checkpoint command is about and
+// opens the checkpoint store of the working directory's repository.
+func checkpointSetup(cmd *cobra.Command, id string) (context.Context, session.Session, *checkpoint.Store, func(), error) {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return nil, session.Session{}, nil, nil, err
+	}
+	sess, err := resolveSessionID(ctx, svc.sessions, id)
+	if err != nil {
+		cleanup()
+		return nil, session.Session{}, nil, nil, err
+	}
+	store, err := checkpointStore(ctx, svc.cfg)
+	if err != nil {
+		cleanup()
+		return nil, session.Session{}, nil, nil, err
+	}
+	return ctx, sess, store, cleanup, nil
+}
+
+func checkpointStore(ctx context.Context, cfg *config.ConfigStore) (*checkpoint.Store, error) {
+	store, err := checkpoint.New(ctx, cfg.WorkingDir())
+	if errors.Is(err, checkpoint.ErrNotRepository) {
+		return nil, fmt.Errorf("checkpoints need a git repository: %s is %w", cfg.WorkingDir(), err)
+	}
+	return store, err
+}
+
+func parseCheckpointNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid checkpoint number: %s", s)
+	}
+	return n, nil
+}
+
+type checkpointListItem struct {
+	Number    int    `json:"number"`
+	Commit    string `json:"commit"`
+	Prompt    string `json:"prompt"`
+	Dir       string `json:"dir"`
+	CreatedAt string `json:"created_at"`
+}
+
+type checkpointListResult struct {
+	ID          string               `json:"id"`
+	UUID        string               `json:"uuid"`
+	Checkpoints []checkpointListItem `json:"checkpoints"`
+}
+
+func runCheckpointList(cmd *cobra.Command, args []string) error {
+	ctx, sess, store, cleanup, err := checkpointSetup(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	checkpoints, err := store.List(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if checkpointListJSON {
+		result := checkpointListResult{
+			ID:          session.HashID(sess.ID),
+			UUID:        sess.ID,
+			Checkpoints: make([]checkpointListItem, len(checkpoints)),
+		}
+		for i, cp := range checkpoints {
+			result.Checkpoints[i] = checkpointListItem{
+				Number:    cp.Number,
+				Commit:    cp.Commit,
+				Prompt:    cp.Prompt,
+				Dir:       cp.Dir,
+				CreatedAt: time.Unix(cp.CreatedAt, 0).UTC().Format(time.RFC3339),
+			}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(result)
+	}
+
+	if len(checkpoints) == 0 {
+		fmt.Fprintln(out, "No checkpoints")
+		return nil
+	}
+	for _, cp := range checkpoints {
+		prompt, _, _ := strings.Cut(cp.Prompt, "\n")
+		fmt.Fprintf(out, "%3d  %s  %s  %s\n",
+			cp.Number,
+			cp.Commit[:min(len(cp.Commit), 12)],
+			time.Unix(cp.CreatedAt, 0).Format("2006-01-02 15:04"),
+			prompt,
+		)
+	}
+	return nil
+}
+
+func runCheckpointDiff(cmd *cobra.Command, args []string) error {
+	n, err := parseCheckpointNumber(args[1])
+	if err != nil {
+		return err
+	}
+	ctx, sess, store, cleanup, err := checkpointSetup(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	diff, err := store.Diff(ctx, sess.ID, n, checkpointDiffStat)
+	if err != nil {
+		return fmt.Errorf("failed to diff checkpoint %d: %w", n, err)
+	}
+	_, err = io.WriteString(cmd.OutOrStdout(), diff)
+	return err
+}
+
+type checkpointRestoreChange struct {
+	Path    string `json:"path"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+type checkpointRestoreResult struct {
+	ID         string                    `json:"id"`
+	UUID       string                    `json:"uuid"`
+	Checkpoint int                       `json:"checkpoint,omitempty"`
+	Changes    []checkpointRestoreChange `json:"changes"`
+}
+
+func runCheckpointRestore(cmd *cobra.Command, args []string) error {
+	n, err := parseCheckpointNumber(args[1])
+	if err != nil {
+		return err
+	}
+	ctx, sess, store, cleanup, err := checkpointSetup(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	changes, err := store.Restore(ctx, sess.ID, n)
+	if err != nil {
+		return fmt.Errorf("failed to restore checkpoint %d: %w", n, err)
+	}
+
+	out := cmd.OutOrStdout()
+	if checkpointRestoreJSON {
+		return writeCheckpointRestoreJSON(out, sess, n, changes)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "Nothing to restore")
+		return nil
+	}
+	printCheckpointChanges(out, changes)
+	fmt.Fprintf(out, "Restored checkpoint %d of session %s\n", n, session.HashID(sess.ID)[:12])
+	return nil
+}
+
+func runCheckpointUndo(cmd *cobra.Command, args []string) error {
+	ctx, sess, store, cleanup, err := checkpointSetup(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	changes, err := store.Undo(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to undo restore: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if checkpointUndoJSON {
+		return writeCheckpointRestoreJSON(out, sess, 0, changes)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "Nothing to restore")
+		return nil
+	}
+	printCheckpointChanges(out, changes)
+	fmt.Fprintf(out, "Undid the latest restore of session %s\n", session.HashID(sess.ID)[:12])
+	return nil
+}
+
+func writeCheckpointRestoreJSON(w io.Writer, sess session.Session, n int, changes []checkpoint.Change) error {
+	result := checkpointRestoreResult{
+		ID:         session.HashID(sess.ID),
+		UUID:       sess.ID,
+		Checkpoint: n,
+		Changes:    make([]checkpointRestoreChange, len(changes)),
+	}
+	for i, c := range changes {
+		result.Changes[i] = checkpointRestoreChange{Path: c.Path, Deleted: c.Deleted}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(result)
+}
+
+func printCheckpointChanges(w io.Writer, changes []checkpoint.Change) {
+	for _, c := range changes {
+		action := "restore"
+		if c.Deleted {
+			action = "delete"
+		}
+		fmt.Fprintf(w, "%-7s %s\n", action, c.Path)
+	}
+}
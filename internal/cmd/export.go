@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/crush/internal/archive"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/message"
@@ -15,10 +16,29 @@ import (
 
 var exportCmd = &cobra.Command{
 	Use:   "export <session-id> [output-file]",
-	Short: "Export a conversation to a markdown file",
-	Long:  "Export a conversation to a shareable markdown file. Outputs to stdout by default, or to a file if an output path is given.",
-	Args:  cobra.RangeArgs(1, 2),
-	RunE:  runExport,
+	Short: "Export a conversation to a markdown, JSON, or HTML file",
+	Long: `Export a conversation. Outputs to stdout by default, or to a file if an
+output path is given.
+
+The markdown and HTML formats are for reading and sharing. The JSON format is
+a lossless archive of the session, its sub-agent sessions, file history, and
+todos that can be loaded into another Crush database with "crush import".`,
+	Example: `
+# Share a conversation as a web page
+crush export 5c1e9d7a-0b7e-4a33-9d2f-6b1f0f4a2b1c session.html --format html
+
+# Move a session to another machine
+crush export 5c1e9d7a-0b7e-4a33-9d2f-6b1f0f4a2b1c session.json --format json
+crush import session.json
+  `,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExport,
+}
+
+var exportFormat string
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "markdown", "output format: markdown, json, or html")
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
@@ -43,34 +63,59 @@ func runExport(cmd *cobra.Command, args []string) error {
 
 	queries := db.New(conn)
 
-	sess, err := queries.GetSessionByID(ctx, sessionID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("session %q not found", sessionID)
+	var out string
+	switch exportFormat {
+	case "markdown", "md":
+		sess, err := queries.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("session %q not found", sessionID)
+			}
+			return fmt.Errorf("failed to get session: %w", err)
 		}
-		return fmt.Errorf("failed to get session: %w", err)
-	}
 
-	msgs, err := queries.ListMessagesBySession(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to list messages: %w", err)
-	}
+		msgs, err := queries.ListMessagesBySession(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
 
-	md, err := renderMarkdown(sess, msgs)
-	if err != nil {
-		return fmt.Errorf("failed to render markdown: %w", err)
+		out, err = renderMarkdown(sess, msgs)
+		if err != nil {
+			return fmt.Errorf("failed to render markdown: %w", err)
+		}
+	case "json", "html":
+		a, err := archive.Export(ctx, conn, sessionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("session %q not found", sessionID)
+			}
+			return fmt.Errorf("failed to export session: %w", err)
+		}
+
+		var sb strings.Builder
+		if exportFormat == "json" {
+			err = a.Write(&sb)
+		} else {
+			err = renderHTML(&sb, a)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", exportFormat, err)
+		}
+		out = sb.String()
+	default:
+		return fmt.Errorf("unknown format %q: use markdown, json, or html", exportFormat)
 	}
 
 	if len(args) == 2 {
 		outputPath := args[1]
-		if err := os.WriteFile(outputPath, []byte(md), 0o644); err != nil {
+		if err := os.WriteFile(outputPath, []byte(out), 0o644); err != nil {
 			return fmt.Errorf("failed to write file: %w", err)
 		}
 		fmt.Fprintf(cmd.ErrOrStderr(), "Exported to %s\n", outputPath)
 		return nil
 	}
 
-	_, err = fmt.Fprint(cmd.OutOrStdout(), md)
+	_, err = fmt.Fprint(cmd.OutOrStdout(), out)
 	return err
 }
 
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/archive"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// htmlMarkdown renders message text for HTML exports. Raw HTML in the text
+// is left out rather than passed through, so the page is safe to open.
+var htmlMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+type htmlExport struct {
+	Title    string
+	Date     string
+	Messages []htmlMessage
+}
+
+type htmlMessage struct {
+	Role  string
+	Label string
+	Parts []htmlPart
+}
+
+type htmlPart struct {
+	Text      template.HTML
+	Reasoning template.HTML
+	Tool      *htmlTool
+}
+
+type htmlTool struct {
+	Name    string
+	Input   string
+	Result  string
+	IsError bool
+}
+
+// renderHTML renders the archived session as a self-contained, read-only
+// web page. Sub-agent sessions are left out; their tool calls are shown
+// like any other.
+func renderHTML(w io.Writer, a *archive.Archive) error {
+	title := a.Session.Title
+	if title == "" {
+		title = "Untitled conversation"
+	}
+	page := htmlExport{
+		Title: title,
+		Date:  time.Unix(a.Session.CreatedAt, 0).Format("January 2, 2006"),
+	}
+
+	var msgs []*message.Message
+	for _, m := range a.Messages {
+		if m.SessionID != a.Session.ID {
+			continue
+		}
+		parts, err := message.UnmarshalParts(m.Parts)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal message parts: %w", err)
+		}
+		msgs = append(msgs, &message.Message{ID: m.ID, Role: message.MessageRole(m.Role), Parts: parts})
+	}
+
+	results := make(map[string]message.ToolResult)
+	for _, msg := range msgs {
+		for _, tr := range msg.ToolResults() {
+			results[tr.ToolCallID] = tr
+		}
+	}
+
+	for _, msg := range msgs {
+		var out htmlMessage
+		switch msg.Role {
+		case message.User:
+			out = htmlMessage{Role: "user", Label: "User"}
+		case message.Assistant:
+			out = htmlMessage{Role: "assistant", Label: "Assistant"}
+		default:
+			// Tool results are shown with their calls, and system messages
+			// aren't useful for sharing.
+			continue
+		}
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case message.TextContent:
+				html, err := markdownToHTML(p.Text)
+				if err != nil {
+					return err
+				}
+				if html != "" {
+					out.Parts = append(out.Parts, htmlPart{Text: html})
+				}
+			case message.ReasoningContent:
+				html, err := markdownToHTML(p.Thinking)
+				if err != nil {
+					return err
+				}
+				if html != "" {
+					out.Parts = append(out.Parts, htmlPart{Reasoning: html})
+				}
+			case message.ToolCall:
+				tool := &htmlTool{Name: p.Name, Input: strings.TrimSpace(p.Input)}
+				if tr, ok := results[p.ID]; ok {
+					tool.Result = strings.TrimSpace(tr.Content)
+					tool.IsError = tr.IsError
+				}
+				out.Parts = append(out.Parts, htmlPart{Tool: tool})
+			}
+		}
+		if len(out.Parts) > 0 {
+			page.Messages = append(page.Messages, out)
+		}
+	}
+
+	return htmlExportTemplate.Execute(w, page)
+}
+
+func markdownToHTML(text string) (template.HTML, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+	var b bytes.Buffer
+	if err := htmlMarkdown.Convert([]byte(text), &b); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return template.HTML(b.String()), nil //nolint:gosec
+}
+
+var htmlExportTemplate = template.Must(template.New("export").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="crush">
+<title>{{.Title}}</title>
+<style>
+:root { color-scheme: light dark; --bg: #fffdf7; --fg: #201f26; --muted: #858392; --user: #6b50ff; --assistant: #00a4ff; --code: #f1efef; --error: #eb4268; }
+@media (prefers-color-scheme: dark) { :root { --bg: #201f26; --fg: #f1efef; --muted: #858392; --code: #2d2c35; } }
+body { margin: 0; background: var(--bg); color: var(--fg); font: 16px/1.6 system-ui, sans-serif; }
+main { max-width: 52rem; margin: 0 auto; padding: 2rem 1rem; }
+header { border-bottom: 1px solid var(--muted); margin-bottom: 2rem; }
+h1 { margin: 0 0 .25rem; }
+.meta { color: var(--muted); margin-top: 0; }
+.message { border-left: 3px solid var(--muted); padding: 0 0 0 1rem; margin: 0 0 2rem; }
+.message h2 { font-size: .9rem; text-transform: uppercase; letter-spacing: .05em; margin: 0 0 .5rem; }
+.user { border-color: var(--user); } .user h2 { color: var(--user); }
+.assistant { border-color: var(--assistant); } .assistant h2 { color: var(--assistant); }
+pre, code { font: 14px/1.5 ui-monospace, monospace; background: var(--code); border-radius: 4px; }
+code { padding: .1em .3em; }
+pre { padding: .75rem; overflow-x: auto; white-space: pre-wrap; word-break: break-word; }
+pre code { padding: 0; }
+details { margin: .5rem 0; }
+summary { cursor: pointer; color: var(--muted); }
+.error { color: var(--error); }
+table { border-collapse: collapse; } th, td { border: 1px solid var(--muted); padding: .25rem .5rem; }
+</style>
+</head>
+<body>
+<main>
+<header>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated by <a href="https://github.com/charmbracelet/crush">crush</a> on {{.Date}}</p>
+</header>
+{{- range .Messages}}
+<section class="message {{.Role}}">
+<h2>{{.Label}}</h2>
+{{- range .Parts}}
+{{- if .Text}}
+{{.Text}}
+{{- else if .Reasoning}}
+<details><summary>Reasoning</summary>
+{{.Reasoning}}
+</details>
+{{- else if .Tool}}
+<details><summary>Tool call: <code>{{.Tool.Name}}</code>{{if .Tool.IsError}} <span class="error">failed</span>{{end}}</summary>
+{{- if .Tool.Input}}
+<pre><code>{{.Tool.Input}}</code></pre>
+{{- end}}
+{{- if .Tool.Result}}
+<pre{{if .Tool.IsError}} class="error"{{end}}><code>{{.Tool.Result}}</code></pre>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/archive"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(t *testing.T) {
+	t.Parallel()
+
+	parts := func(v ...any) json.RawMessage {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+	a := &archive.Archive{
+		Session: archive.Session{ID: "s", Title: "Fix <the> bug"},
+		Messages: []archive.Message{
+			{ID: "1", SessionID: "s", Role: "user", Parts: parts(
+				map[string]any{"type": "text", "data": map[string]any{"text": "Is `go vet` happy? <script>alert(1)</script>"}},
+			)},
+			{ID: "2", SessionID: "s", Role: "assistant", Parts: parts(
+				map[string]any{"type": "tool_call", "data": map[string]any{"id": "call-1", "name": "bash", "input": `{"command":"go vet ./..."}`}},
+			)},
+			{ID: "3", SessionID: "s", Role: "tool", Parts: parts(
+				map[string]any{"type": "tool_result", "data": map[string]any{"tool_call_id": "call-1", "name": "bash", "content": "vet: <nil>", "is_error": true}},
+			)},
+			{ID: "4", SessionID: "child", Role: "user", Parts: parts(
+				map[string]any{"type": "text", "data": map[string]any{"text": "sub-agent prompt"}},
+			)},
+		},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, renderHTML(&sb, a))
+	out := sb.String()
+
+	require.Contains(t, out, "<title>Fix &lt;the&gt; bug</title>")
+	require.Contains(t, out, "<code>go vet</code>")
+	require.NotContains(t, out, "<script>")
+	require.Contains(t, out, "Tool call: <code>bash</code>")
+	require.Contains(t, out, `{&#34;command&#34;:&#34;go vet ./...&#34;}`)
+	require.Contains(t, out, `<pre class="error"><code>vet: &lt;nil&gt;</code></pre>`)
+	require.NotContains(t, out, "sub-agent prompt")
+}
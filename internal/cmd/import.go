@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/archive"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a session exported with --format json",
+	Long: `Import a session archive written by "crush export --format json" into the
+database of the data directory, along with its sub-agent sessions, file
+history, and todos. The imported session gets new IDs, so an archive can be
+imported more than once. Use "-" to read the archive from stdin.`,
+	Example: `
+# Import a session exported on another machine
+crush import session.json
+
+# Import into a specific data directory
+crush import session.json --data-dir ./other/.crush
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+var importJSON bool
+
+func init() {
+	importCmd.Flags().BoolVar(&importJSON, "json", false, "output in JSON format")
+}
+
+type importResult struct {
+	ID       string `json:"id"`
+	UUID     string `json:"uuid"`
+	Title    string `json:"title"`
+	Messages int64  `json:"messages"`
+	Sessions int    `json:"sessions"`
+	Files    int    `json:"files"`
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	var r io.Reader = cmd.InOrStdin()
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	a, err := archive.Read(r)
+	if err != nil {
+		return err
+	}
+
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	ctx := cmd.Context()
+
+	if dataDir == "" {
+		cfg, err := config.Init("", "", false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize config: %w", err)
+		}
+		dataDir = cfg.Config().Options.DataDirectory
+	}
+
+	conn, err := db.Connect(ctx, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	sess, err := archive.Import(ctx, conn, a)
+	if err != nil {
+		return fmt.Errorf("failed to import session: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if importJSON {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(importResult{
+			ID:       session.HashID(sess.ID),
+			UUID:     sess.ID,
+			Title:    sess.Title,
+			Messages: sess.MessageCount,
+			Sessions: 1 + len(a.Children),
+			Files:    len(a.Files),
+		})
+	}
+
+	fmt.Fprintf(out, "Imported session %s %q with %d message(s)\n", session.HashID(sess.ID)[:12], sess.Title, sess.MessageCount)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"charm.land/lipgloss/v2"
 	"github.com/atotto/clipboard"
+	mcptools "github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/client"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/oauth"
@@ -25,7 +26,8 @@ var loginCmd = &cobra.Command{
 	Short:   "Login Crush to a platform",
 	Long: `Login Crush to a specified platform.
 The platform should be provided as an argument.
-Available platforms are: hyper, copilot.`,
+Available platforms are: hyper, copilot, and mcp followed by the name of
+an HTTP or SSE MCP server requiring authorization.`,
 	Example: `
 # Authenticate with Charm Hyper
 crush login
@@ -33,6 +35,9 @@ crush login
 # Authenticate with GitHub Copilot
 crush login copilot
 
+# Authorize Crush with the MCP server named linear in the config
+crush login mcp linear
+
 # Force re-authentication even if already logged in
 crush login -f copilot
   `,
@@ -41,9 +46,21 @@ crush login -f copilot
 		"copilot",
 		"github",
 		"github-copilot",
+		"mcp",
 	},
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		if len(args) > 0 && args[0] == "mcp" {
+			if len(args) < 2 {
+				return fmt.Errorf("missing the name of the mcp server: crush login mcp <name>")
+			}
+			return loginMCP(cmd, args[1], force)
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("unexpected argument: %s", args[1])
+		}
+
 		c, ws, cleanup, err := connectToServer(cmd)
 		if err != nil {
 			return err
@@ -60,7 +77,6 @@ crush login -f copilot
 		if len(args) > 0 {
 			provider = args[0]
 		}
-		force, _ := cmd.Flags().GetBool("force")
 		switch provider {
 		case "hyper":
 			return loginHyper(c, ws.ID, force)
@@ -217,6 +233,48 @@ func loginCopilot(c *client.Client, wsID string, force bool) error {
 	return nil
 }
 
+func loginMCP(cmd *cobra.Command, name string, force bool) error {
+	ctx := getLoginContext()
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	m, ok := cfg.Config().MCP[name]
+	if !ok {
+		return fmt.Errorf("mcp '%s' not found in configuration", name)
+	}
+	if !force && m.OAuthToken != nil {
+		fmt.Printf("You are already logged in to the %s MCP server.\n", name)
+		fmt.Println("Use --force to re-authenticate.")
+		return nil
+	}
+
+	err = mcptools.Login(ctx, cfg, name, func(url string) {
+		fmt.Println("Open the following URL to authorize Crush with the MCP server:")
+		fmt.Println()
+		fmt.Println(lipgloss.NewStyle().Hyperlink(url, "id=mcp-"+name).Render(url))
+		fmt.Println()
+		if err := browser.OpenURL(url); err != nil {
+			fmt.Println("Could not open the URL. You'll need to manually open the URL in your browser.")
+		}
+		fmt.Println("Waiting for authorization...")
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("You're now authenticated with the %s MCP server!\n", name)
+	return nil
+}
+
 func getLoginContext() context.Context {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	go func() {
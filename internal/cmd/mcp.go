@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/mcpserver"
+	"github.com/charmbracelet/crush/internal/server"
+	"github.com/charmbracelet/crush/internal/workspace"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Use Crush over the Model Context Protocol",
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the tools and the agent of Crush to MCP clients",
+	Long: `Serve the built-in tools of Crush, such as view, edit, grep, glob, bash with
+its sandbox, references, and diagnostics, and the crush_agent tool, which
+runs a prompt through the agent, to MCP clients.
+
+It serves over stdio by default, or streamable HTTP with --http. HTTP
+clients must send the token printed by 'crush server token' as bearer
+token. Addresses other than loopback ones require --tls-cert and
+--tls-key, so the token isn't sent in the clear. Each client works in a
+Crush session of its own.
+
+Tools asking for permission ask the client through elicitation. Clients
+without elicitation are denied, unless the permission policy or the
+allowed tools allow the call, or --yolo is set.`,
+	Example: `
+# Serve over stdio, as configured in the MCP client
+crush mcp serve --cwd /path/to/project
+
+# Serve over streamable HTTP on localhost
+crush mcp serve --http localhost:7373
+
+# Serve over HTTPS on all interfaces
+crush mcp serve --http :7373 --tls-cert cert.pem --tls-key key.pem
+  `,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var (
+			addr, _    = cmd.Flags().GetString("http")
+			tlsCert, _ = cmd.Flags().GetString("tls-cert")
+			tlsKey, _  = cmd.Flags().GetString("tls-key")
+		)
+		if (tlsCert == "") != (tlsKey == "") {
+			return fmt.Errorf("serving over TLS requires both --tls-cert and --tls-key")
+		}
+		// The bearer token would travel in the clear over plain HTTP,
+		// which only TLS keeps off the network.
+		if addr != "" && tlsCert == "" && !server.IsLoopback(addr) {
+			return fmt.Errorf("http addresses must be loopback addresses since they aren't encrypted; use --tls-cert and --tls-key to listen on %s", addr)
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		// The server always runs its own app, as the tools work on the
+		// local project.
+		ws, cleanup, err := setupLocalWorkspace(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if !ws.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		srv := mcpserver.New(ctx, ws.(*workspace.AppWorkspace).App())
+		if addr == "" {
+			slog.Info("Serving MCP over stdio")
+			err := srv.Run(ctx, &mcp.StdioTransport{})
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return fmt.Errorf("mcp server error: %v", err)
+			}
+			return nil
+		}
+
+		cfg, err := loadServerConfig(cmd)
+		if err != nil {
+			return err
+		}
+		tokens, err := serverTokens(cfg)
+		if err != nil {
+			return err
+		}
+		httpServer := &http.Server{
+			Addr:              addr,
+			Handler:           server.RequireToken(tokens, srv.Handler()),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		slog.Info("Serving MCP over streamable HTTP", "addr", addr, "tls", tlsCert != "")
+
+		errch := make(chan error, 1)
+		go func() {
+			if tlsCert != "" {
+				errch <- httpServer.ListenAndServeTLS(tlsCert, tlsKey)
+				return
+			}
+			errch <- httpServer.ListenAndServe()
+		}()
+		select {
+		case <-ctx.Done():
+		case err := <-errch:
+			return fmt.Errorf("mcp server error: %v", err)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shutdown mcp server: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().String("http", "", "Serve over streamable HTTP on this address instead of stdio, e.g. localhost:7373")
+	mcpServeCmd.Flags().String("tls-cert", "", "TLS certificate file for serving --http over HTTPS")
+	mcpServeCmd.Flags().String("tls-key", "", "TLS key file for serving --http over HTTPS")
+	mcpServeCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+	mcpCmd.AddCommand(mcpServeCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/spf13/cobra"
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Inspect the permission policy",
+	Long: `Inspect the permission policy read from .crush/permissions.json in the
+project and permissions.json next to the global config. The first
+matching rule of each file allows, denies, or asks for a tool call; the
+global file comes first, and a deny from either file wins.`,
+}
+
+var permissionsCheckCmd = &cobra.Command{
+	Use:   "check <tool> [json-input]",
+	Short: "Show how the permission policy decides a tool call",
+	Example: `
+# Check a bash command
+crush permissions check bash '{"command": "git status --short"}'
+
+# Check an edit
+crush permissions check edit '{"file_path": "internal/app/app.go"}'
+  `,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+
+		input := json.RawMessage("{}")
+		if len(args) > 1 {
+			input = json.RawMessage(args[1])
+			if !json.Valid(input) {
+				return fmt.Errorf("invalid JSON input: %s", args[1])
+			}
+		}
+
+		policy, err := permission.LoadPolicy(cwd, config.PermissionPolicyFiles(cwd)...)
+		if err != nil {
+			return fmt.Errorf("failed to load permission policy: %w", err)
+		}
+
+		rule, ok := policy.Evaluate(args[0], input, cwd)
+		if !ok {
+			cmd.Println("No policy rule matches; the allowlist and saved permissions apply, then Crush asks.")
+			return nil
+		}
+		cmd.Println(rule.Decision)
+		cmd.Printf("  rule: %s\n", rule.Match)
+		if rule.Reason != "" {
+			cmd.Printf("  reason: %s\n", rule.Reason)
+		}
+		cmd.Printf("  from: %s\n", rule.Source)
+		return nil
+	},
+}
+
+func init() {
+	permissionsCmd.AddCommand(permissionsCheckCmd)
+}
@@ -54,7 +54,7 @@ func init() {
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "Custom crush data directory")
 	rootCmd.PersistentFlags().StringArray("config", nil, "Config file path (overrides default config chain; can be specified multiple times to merge)")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
-	rootCmd.PersistentFlags().StringVarP(&clientHost, "host", "H", server.DefaultHost(), "Connect to a specific crush server host (for advanced users)")
+	rootCmd.PersistentFlags().StringVarP(&clientHost, "host", "H", server.DefaultHost(), "Connect to a specific crush server host, e.g. unix:///tmp/crush.sock or https://host:port (for advanced users; TCP hosts read their token from $CRUSH_SERVER_TOKEN)")
 	rootCmd.PersistentFlags().StringArrayP("set", "o", nil, "Override a config option (key=value, e.g. --set debug=true)")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
@@ -73,7 +73,12 @@ func init() {
 		loginCmd,
 		statsCmd,
 		exportCmd,
+		importCmd,
 		sessionsCmd,
+		permissionsCmd,
+		checkpointCmd,
+		mcpCmd,
+		acpCmd,
 	)
 }
 
@@ -416,6 +421,7 @@ func connectToServer(cmd *cobra.Command) (*client.Client, *proto.Workspace, func
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	c.SetToken(os.Getenv("CRUSH_SERVER_TOKEN"))
 
 	var setOverrides map[string]string
 	if len(setArgs) > 0 {
@@ -11,6 +11,7 @@ import (
 
 	"charm.land/lipgloss/v2"
 	"charm.land/log/v2"
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/client"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
@@ -58,6 +59,12 @@ crush run --session {session-id} "Follow up on your last response"
 # Continue the most recent session
 crush run --continue "Follow up on your last response"
 
+# Print the result, usage, and tool calls as JSON
+crush run --output-format json "Fix the failing test"
+
+# Stream newline-delimited JSON events as they happen
+crush run --output-format stream-json "Fix the failing test"
+
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var (
@@ -67,15 +74,21 @@ crush run --continue "Follow up on your last response"
 			smallModel, _ = cmd.Flags().GetString("small-model")
 			sessionID, _  = cmd.Flags().GetString("session")
 			useLast, _    = cmd.Flags().GetBool("continue")
+			outputFmt, _  = cmd.Flags().GetString("output-format")
 		)
 
+		outputFormat, err := app.ParseOutputFormat(outputFmt)
+		if err != nil {
+			return err
+		}
+
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 		defer cancel()
 
 		prompt := strings.Join(args, " ")
 
-		prompt, err := MaybePrependStdin(prompt)
+		prompt, err = MaybePrependStdin(prompt)
 		if err != nil {
 			slog.Error("Failed to read from stdin", "error", err)
 			return err
@@ -119,7 +132,7 @@ crush run --continue "Follow up on your last response"
 				slog.SetDefault(slog.New(log.New(os.Stderr)))
 			}
 
-			return runNonInteractive(ctx, c, ws, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+			return runNonInteractive(ctx, c, ws, outputFormat, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
 		}
 
 		ws, cleanup, err := setupLocalWorkspace(cmd)
@@ -139,7 +152,7 @@ crush run --continue "Follow up on your last response"
 		}
 
 		appWs := ws.(*workspace.AppWorkspace)
-		return appWs.App().RunNonInteractive(ctx, os.Stdout, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+		return appWs.App().RunNonInteractive(ctx, os.Stdout, outputFormat, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
 	},
 }
 
@@ -150,6 +163,7 @@ func init() {
 	runCmd.Flags().String("small-model", "", "Small model to use. If not provided, uses the default small model for the provider")
 	runCmd.Flags().StringP("session", "s", "", "Continue a previous session by ID")
 	runCmd.Flags().BoolP("continue", "C", false, "Continue the most recent session")
+	runCmd.Flags().String("output-format", string(app.OutputFormatText), "Output format: text, json, or stream-json")
 	runCmd.MarkFlagsMutuallyExclusive("session", "continue")
 }
 
@@ -159,6 +173,7 @@ func runNonInteractive(
 	ctx context.Context,
 	c *client.Client,
 	ws *proto.Workspace,
+	outputFormat app.OutputFormat,
 	prompt, largeModel, smallModel string,
 	hideSpinner bool,
 	continueSessionID string,
@@ -247,14 +262,22 @@ func runNonInteractive(
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
-	messageReadBytes := make(map[string]int)
-	var printed bool
+	out := app.NewRunOutput(os.Stdout, outputFormat, sess.ID)
+	finish := func(runErr error) error {
+		final := *sess
+		if s, err := c.GetSession(context.WithoutCancel(ctx), ws.ID, sess.ID); err == nil {
+			final = *s
+		}
+		if err := out.Done(final, runErr); err != nil {
+			return err
+		}
+		return runErr
+	}
 
 	defer func() {
 		if progress && stderrTTY {
 			_, _ = fmt.Fprintf(os.Stderr, ansi.ResetProgressBar)
 		}
-		_, _ = fmt.Fprintln(os.Stdout)
 	}()
 
 	for {
@@ -266,54 +289,80 @@ func runNonInteractive(
 		case ev, ok := <-events:
 			if !ok {
 				stopSpinner()
-				return nil
+				return finish(nil)
 			}
 
 			switch e := ev.(type) {
 			case pubsub.Event[proto.Message]:
 				msg := e.Payload
-				if msg.SessionID != sess.ID || msg.Role != proto.Assistant || len(msg.Parts) == 0 {
+				if msg.SessionID != sess.ID || msg.Role == proto.User {
 					continue
 				}
-				stopSpinner()
-
-				content := msg.Content().String()
-				readBytes := messageReadBytes[msg.ID]
-
-				if len(content) < readBytes {
-					slog.Error("Non-interactive: message content shorter than read bytes",
-						"message_length", len(content), "read_bytes", readBytes)
-					return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), readBytes)
+				if msg.Role == proto.Assistant && len(msg.Parts) > 0 {
+					stopSpinner()
 				}
-
-				part := content[readBytes:]
-				if readBytes == 0 {
-					part = strings.TrimLeft(part, " \t")
+				if err := out.Message(msg); err != nil {
+					slog.Error("Non-interactive: failed to write message", "error", err)
+					return err
 				}
-				if printed || strings.TrimSpace(part) != "" {
-					printed = true
-					fmt.Fprint(os.Stdout, part)
+
+				if done, err := runFinished(msg); done {
+					return finish(err)
 				}
-				messageReadBytes[msg.ID] = len(content)
 
-				if msg.IsFinished() {
-					return nil
+			case pubsub.Event[proto.PermissionRequest]:
+				if err := out.PermissionRequest(e.Payload); err != nil {
+					return err
 				}
 
 			case pubsub.Event[proto.AgentEvent]:
 				if e.Payload.Error != nil {
 					stopSpinner()
-					return fmt.Errorf("agent error: %w", e.Payload.Error)
+					return finish(fmt.Errorf("agent error: %w", e.Payload.Error))
+				}
+				if e.Payload.Type == proto.AgentEventTypeBudgetWarning && e.Payload.SessionID == sess.ID {
+					_, _ = fmt.Fprintf(os.Stderr, "Warning: %s\n", e.Payload.Details)
 				}
 			}
 
 		case <-ctx.Done():
 			stopSpinner()
+			_ = out.Done(*sess, ctx.Err())
 			return ctx.Err()
 		}
 	}
 }
 
+// runFinished reports whether a run ends with msg, and the error it ends
+// with. A run stopped by an error or a spent budget ends with an error, so
+// the command exits with a non-zero status.
+func runFinished(msg proto.Message) (bool, error) {
+	if msg.Role != proto.Assistant || !msg.IsFinished() {
+		return false, nil
+	}
+	switch msg.FinishReason() {
+	case proto.FinishReasonToolUse:
+		// The agent carries on once the tools have run.
+		return false, nil
+	case proto.FinishReasonFailover:
+		// The agent carries on with a fallback model.
+		return false, nil
+	case proto.FinishReasonError, proto.FinishReasonBudgetExceeded:
+		return true, finishError(msg.FinishPart())
+	default:
+		return true, nil
+	}
+}
+
+// finishError returns the error a run ended with, as recorded in the
+// finish part of its last message.
+func finishError(fp *proto.Finish) error {
+	if fp.Details == "" {
+		return fmt.Errorf("agent processing failed: %s", fp.Message)
+	}
+	return fmt.Errorf("agent processing failed: %s: %s", fp.Message, fp.Details)
+}
+
 // waitForAgent polls GetAgentInfo until the agent is ready, with a
 // timeout.
 func waitForAgent(ctx context.Context, c *client.Client, wsID string) error {
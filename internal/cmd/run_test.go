@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunFinished(t *testing.T) {
+	t.Parallel()
+
+	finished := func(reason proto.FinishReason, message, details string) proto.Message {
+		msg := proto.Message{Role: proto.Assistant}
+		msg.AddFinish(reason, message, details)
+		return msg
+	}
+
+	done, err := runFinished(proto.Message{Role: proto.Assistant})
+	require.False(t, done)
+	require.NoError(t, err)
+
+	done, err = runFinished(finished(proto.FinishReasonToolUse, "", ""))
+	require.False(t, done)
+	require.NoError(t, err)
+
+	done, err = runFinished(finished(proto.FinishReasonEndTurn, "", ""))
+	require.True(t, done)
+	require.NoError(t, err)
+
+	done, err = runFinished(finished(proto.FinishReasonBudgetExceeded, "Budget exceeded", "session budget of $1.00 reached"))
+	require.True(t, done)
+	require.EqualError(t, err, "agent processing failed: Budget exceeded: session budget of $1.00 reached")
+}
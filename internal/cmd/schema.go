@@ -3,8 +3,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/invopop/jsonschema"
 	"github.com/spf13/cobra"
 )
@@ -16,7 +18,9 @@ var schemaCmd = &cobra.Command{
 	Hidden: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		reflector := new(jsonschema.Reflector)
-		bts, err := json.MarshalIndent(reflector.Reflect(&config.Config{}), "", "  ")
+		schema := reflector.Reflect(&config.Config{})
+		addKeymapActions(schema)
+		bts, err := json.MarshalIndent(schema, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal schema: %w", err)
 		}
@@ -24,3 +28,32 @@ var schemaCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// addKeymapActions lists the actions of the TUI as the properties of the
+// keymap option, which the configuration can't know about by itself.
+func addKeymapActions(schema *jsonschema.Schema) {
+	tui, ok := schema.Definitions["TUIOptions"]
+	if !ok {
+		return
+	}
+	km, ok := tui.Properties.Get("keymap")
+	if !ok {
+		return
+	}
+	km.Properties = jsonschema.NewProperties()
+	for _, action := range keymap.Actions() {
+		description := action.Description
+		if description == "" {
+			description = action.Name
+		}
+		if len(action.Keys) > 0 {
+			description += " (default: " + strings.Join(action.Keys, ", ") + ")"
+		}
+		km.Properties.Set(action.Name, &jsonschema.Schema{
+			Type:        "array",
+			Items:       &jsonschema.Schema{Type: "string"},
+			Description: description,
+		})
+	}
+	km.AdditionalProperties = jsonschema.FalseSchema
+}
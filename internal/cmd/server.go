@@ -17,35 +17,117 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var serverHost string
+var (
+	serverHost       string
+	serverTLSCert    string
+	serverTLSKey     string
+	serverTokenNew   bool
+	serverTokenQuiet bool
+)
 
 func init() {
-	serverCmd.Flags().StringVarP(&serverHost, "host", "H", server.DefaultHost(), "Server host (TCP or Unix socket)")
+	serverCmd.Flags().StringVarP(&serverHost, "host", "H", server.DefaultHost(), "Server host (unix://, npipe://, tcp:// on a loopback address, or https://)")
+	serverCmd.Flags().StringVar(&serverTLSCert, "tls-cert", "", "TLS certificate file for https:// hosts")
+	serverCmd.Flags().StringVar(&serverTLSKey, "tls-key", "", "TLS key file for https:// hosts")
+	serverTokenCmd.Flags().BoolVar(&serverTokenNew, "new", false, "Generate a new token in addition to existing ones")
+	serverTokenCmd.Flags().BoolVarP(&serverTokenQuiet, "quiet", "q", false, "Only print the token")
+	serverCmd.AddCommand(serverTokenCmd)
 	rootCmd.AddCommand(serverCmd)
 }
 
+var serverTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print the token clients use to connect over TCP",
+	Long: `Print the bearer token clients must send to a server listening on a tcp:// or https:// host.
+A token is generated the first time one is needed. Clients read it from $CRUSH_SERVER_TOKEN.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := loadServerConfig(cmd)
+		if err != nil {
+			return err
+		}
+		path := server.TokensFile(cfg.Config().Options.DataDirectory)
+
+		tokens, err := server.LoadTokens(path)
+		if err != nil {
+			return fmt.Errorf("failed to load server tokens: %v", err)
+		}
+		var token string
+		if len(tokens) > 0 && !serverTokenNew {
+			token = tokens[0]
+		} else if token, err = server.CreateToken(path); err != nil {
+			return fmt.Errorf("failed to create server token: %v", err)
+		}
+
+		if serverTokenQuiet {
+			fmt.Fprintln(cmd.OutOrStdout(), token)
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n\nStored in %s. Connect with:\n\n  CRUSH_SERVER_TOKEN=%s crush --host <host>\n", token, path, token)
+		return nil
+	},
+}
+
+func loadServerConfig(cmd *cobra.Command) (*config.ConfigStore, error) {
+	dataDir, err := cmd.Flags().GetString("data-dir")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data directory: %v", err)
+	}
+	debug, err := cmd.Flags().GetBool("debug")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debug flag: %v", err)
+	}
+	cfg, err := config.Load(config.GlobalWorkspaceDir(), dataDir, debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+// serverTokens returns the tokens a TCP server accepts, generating one if
+// none exist yet.
+func serverTokens(cfg *config.ConfigStore) ([]string, error) {
+	path := server.TokensFile(cfg.Config().Options.DataDirectory)
+	tokens, err := server.LoadTokens(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server tokens: %v", err)
+	}
+	if len(tokens) > 0 {
+		return tokens, nil
+	}
+	token, err := server.CreateToken(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server token: %v", err)
+	}
+	slog.Info("Generated server token; print it with `crush server token`", "path", path)
+	return []string{token}, nil
+}
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start the Crush server",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		dataDir, err := cmd.Flags().GetString("data-dir")
-		if err != nil {
-			return fmt.Errorf("failed to get data directory: %v", err)
-		}
 		debug, err := cmd.Flags().GetBool("debug")
 		if err != nil {
 			return fmt.Errorf("failed to get debug flag: %v", err)
 		}
 
-		cfg, err := config.Load(config.GlobalWorkspaceDir(), dataDir, debug)
+		cfg, err := loadServerConfig(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load configuration: %v", err)
+			return err
 		}
 
 		hostURL, err := server.ParseHostURL(serverHost)
 		if err != nil {
 			return fmt.Errorf("invalid server host: %v", err)
 		}
+		if hostURL.Scheme == "https" && (serverTLSCert == "" || serverTLSKey == "") {
+			return fmt.Errorf("https hosts require --tls-cert and --tls-key")
+		}
+		// Clients send the token and their environment with each request,
+		// which only TLS keeps off the network.
+		if hostURL.Scheme == "tcp" && !server.IsLoopback(hostURL.Host) {
+			return fmt.Errorf("tcp:// hosts must be loopback addresses since they aren't encrypted; use https:// with --tls-cert and --tls-key to listen on %s", hostURL.Host)
+		}
 
 		logFile := filepath.Join(config.GlobalCacheDir(), "server-"+safeHostName(hostURL), "crush.log")
 
@@ -57,6 +139,14 @@ var serverCmd = &cobra.Command{
 
 		srv := server.NewServer(cfg, hostURL.Scheme, hostURL.Host)
 		srv.SetLogger(slog.Default())
+		if hostURL.Scheme == "tcp" || hostURL.Scheme == "https" {
+			tokens, err := serverTokens(cfg)
+			if err != nil {
+				return err
+			}
+			srv.SetTokens(tokens)
+			srv.SetTLS(serverTLSCert, serverTLSKey)
+		}
 		slog.Info("Starting Crush server...", "addr", serverHost)
 
 		errch := make(chan error, 1)
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -17,13 +18,17 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/colorprofile"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/chat"
 	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/crush/internal/worktree"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/charmtone"
 	"github.com/charmbracelet/x/term"
@@ -38,11 +43,22 @@ var sessionCmd = &cobra.Command{
 }
 
 var (
-	sessionListJSON   bool
-	sessionShowJSON   bool
-	sessionLastJSON   bool
-	sessionDeleteJSON bool
-	sessionRenameJSON bool
+	sessionListJSON    bool
+	sessionShowJSON    bool
+	sessionLastJSON    bool
+	sessionDeleteJSON  bool
+	sessionRenameJSON  bool
+	sessionRewindJSON  bool
+	sessionRewindTo    string
+	sessionRewindOpts  history.RewindOptions
+	sessionForkJSON    bool
+	sessionForkAt      string
+	sessionSearchJSON  bool
+	sessionSearchLimit int
+	sessionDiffStat    bool
+	sessionMergeJSON   bool
+	sessionMergeMsg    string
+	sessionDiscardJSON bool
 )
 
 var sessionListCmd = &cobra.Command{
@@ -85,22 +101,136 @@ var sessionRenameCmd = &cobra.Command{
 	RunE:  runSessionRename,
 }
 
+var sessionRewindCmd = &cobra.Command{
+	Use:   "rewind <id> --to <message-id>",
+	Short: "Roll back files changed by a session",
+	Long: `Restore or delete the files the agent changed in a session so the working
+tree matches the state before the given message or tool call. Files that were
+changed outside of Crush since the agent wrote them are not touched unless
+--force is given. Use --json for machine-readable output. ID can be a UUID,
+full hash, or hash prefix.`,
+	Example: `
+# Preview what rewinding to a message would change
+crush session rewind 3f2a --to 5c1e9d7a-0b7e-4a33-9d2f-6b1f0f4a2b1c --dry-run
+
+# Rewind, overwriting files that were changed outside of Crush
+crush session rewind 3f2a --to 5c1e9d7a-0b7e-4a33-9d2f-6b1f0f4a2b1c --force
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionRewind,
+}
+
+var sessionForkCmd = &cobra.Command{
+	Use:   "fork <id> --at <message-id>",
+	Short: "Branch a new session off a session",
+	Long: `Create a new session that copies the messages of a session up to and
+including the given message or tool call, along with its todos and the files
+it has read. The original session is left untouched. Use --json for
+machine-readable output. ID can be a UUID, full hash, or hash prefix.`,
+	Example: `
+# Fork a session at a message and continue from there
+crush session fork 3f2a --at 5c1e9d7a-0b7e-4a33-9d2f-6b1f0f4a2b1c
+crush --session <new-session-id>
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionFork,
+}
+
+var sessionSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search messages across sessions",
+	Long: `Search the text, tool call inputs, and tool results of the messages of all
+sessions, best matches first. Every word of the query must match; words match
+by their stem, so "fixing" also finds "fixed". Use --json for machine-readable
+output.`,
+	Example: `
+# Find where a function was discussed
+crush session search resolveSessionID
+
+# Get the best ten matches as JSON
+crush session search "migration failed" --limit 10 --json
+  `,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSessionSearch,
+}
+
+var sessionDiffCmd = &cobra.Command{
+	Use:   "diff <id>",
+	Short: "Show the changes in a session's worktree",
+	Long: `Show the changes a session made in its git worktree since its branch was
+created, including changes that were not committed yet. Sessions get a
+worktree when "options.worktrees.enabled" is set. ID can be a UUID, full hash,
+or hash prefix.`,
+	Example: `
+# Review a session's changes
+crush session diff 3f2a
+
+# Apply them to the current working tree without merging the branch
+crush session diff 3f2a | git apply
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionDiff,
+}
+
+var sessionMergeCmd = &cobra.Command{
+	Use:   "merge <id>",
+	Short: "Merge a session's worktree into the current branch",
+	Long: `Commit the pending changes in a session's git worktree and merge its branch
+into the current branch of the repository, then remove the worktree and the
+branch. If the merge conflicts it is aborted and the worktree is kept. Use
+--json for machine-readable output. ID can be a UUID, full hash, or hash
+prefix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionMerge,
+}
+
+var sessionDiscardCmd = &cobra.Command{
+	Use:   "discard <id>",
+	Short: "Discard a session's worktree",
+	Long: `Remove a session's git worktree and delete its branch, throwing away the
+changes in them. The session itself is kept. Use --json for machine-readable
+output. ID can be a UUID, full hash, or hash prefix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionDiscard,
+}
+
 func init() {
 	sessionListCmd.Flags().BoolVar(&sessionListJSON, "json", false, "output in JSON format")
 	sessionShowCmd.Flags().BoolVar(&sessionShowJSON, "json", false, "output in JSON format")
 	sessionLastCmd.Flags().BoolVar(&sessionLastJSON, "json", false, "output in JSON format")
 	sessionDeleteCmd.Flags().BoolVar(&sessionDeleteJSON, "json", false, "output in JSON format")
 	sessionRenameCmd.Flags().BoolVar(&sessionRenameJSON, "json", false, "output in JSON format")
+	sessionRewindCmd.Flags().BoolVar(&sessionRewindJSON, "json", false, "output in JSON format")
+	sessionRewindCmd.Flags().StringVar(&sessionRewindTo, "to", "", "message or tool call ID to rewind to")
+	sessionRewindCmd.Flags().BoolVar(&sessionRewindOpts.Force, "force", false, "overwrite files changed outside of Crush")
+	sessionRewindCmd.Flags().BoolVar(&sessionRewindOpts.DryRun, "dry-run", false, "show what would change without touching any files")
+	_ = sessionRewindCmd.MarkFlagRequired("to")
+	sessionForkCmd.Flags().BoolVar(&sessionForkJSON, "json", false, "output in JSON format")
+	sessionForkCmd.Flags().StringVar(&sessionForkAt, "at", "", "message or tool call ID to fork at")
+	_ = sessionForkCmd.MarkFlagRequired("at")
+	sessionSearchCmd.Flags().BoolVar(&sessionSearchJSON, "json", false, "output in JSON format")
+	sessionSearchCmd.Flags().IntVar(&sessionSearchLimit, "limit", message.DefaultSearchLimit, "maximum number of results")
+	sessionDiffCmd.Flags().BoolVar(&sessionDiffStat, "stat", false, "show a diffstat instead of a patch")
+	sessionMergeCmd.Flags().BoolVar(&sessionMergeJSON, "json", false, "output in JSON format")
+	sessionMergeCmd.Flags().StringVarP(&sessionMergeMsg, "message", "m", "", "message for the commit of pending changes (default: the session title)")
+	sessionDiscardCmd.Flags().BoolVar(&sessionDiscardJSON, "json", false, "output in JSON format")
 	sessionCmd.AddCommand(sessionListCmd)
 	sessionCmd.AddCommand(sessionShowCmd)
 	sessionCmd.AddCommand(sessionLastCmd)
 	sessionCmd.AddCommand(sessionDeleteCmd)
 	sessionCmd.AddCommand(sessionRenameCmd)
+	sessionCmd.AddCommand(sessionRewindCmd)
+	sessionCmd.AddCommand(sessionForkCmd)
+	sessionCmd.AddCommand(sessionSearchCmd)
+	sessionCmd.AddCommand(sessionDiffCmd)
+	sessionCmd.AddCommand(sessionMergeCmd)
+	sessionCmd.AddCommand(sessionDiscardCmd)
 }
 
 type sessionServices struct {
 	sessions session.Service
 	messages message.Service
+	history  history.Service
 	cfg      *config.ConfigStore
 }
 
@@ -124,10 +254,16 @@ func sessionSetup(cmd *cobra.Command) (context.Context, *sessionServices, func()
 		return nil, nil, nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	var sessionOpts []session.ServiceOption
+	if checkpoints, err := checkpoint.New(ctx, cfg.WorkingDir()); err == nil {
+		sessionOpts = append(sessionOpts, session.WithCheckpoints(checkpoints))
+	}
+
 	queries := db.New(conn)
 	svc := &sessionServices{
-		sessions: session.NewService(queries, conn),
+		sessions: session.NewService(queries, conn, sessionOpts...),
 		messages: message.NewService(queries),
+		history:  history.NewService(queries, conn),
 		cfg:      cfg,
 	}
 	return ctx, svc, func() { conn.Close() }, nil
@@ -160,6 +296,10 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 				Created:  time.Unix(s.CreatedAt, 0).Format(time.RFC3339),
 				Modified: time.Unix(s.UpdatedAt, 0).Format(time.RFC3339),
 			}
+			if s.ForkedFromSessionID != "" {
+				output[i].ForkedFrom = session.HashID(s.ForkedFromSessionID)
+				output[i].ForkedAt = s.ForkedAtMessageID
+			}
 		}
 		enc := json.NewEncoder(out)
 		enc.SetEscapeHTML(false)
@@ -171,6 +311,7 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 
 	hashStyle := lipgloss.NewStyle().Foreground(charmtone.Malibu)
 	dateStyle := lipgloss.NewStyle().Foreground(charmtone.Damson)
+	forkStyle := lipgloss.NewStyle().Foreground(charmtone.Squid)
 
 	width := sessionOutputWidth
 	if tw, _, err := term.GetSize(os.Stdout.Fd()); err == nil && tw > 0 {
@@ -184,8 +325,12 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 		hash := session.HashID(s.ID)[:7]
 		date := time.Unix(s.CreatedAt, 0).Format(time.RFC3339)
 		title := strings.ReplaceAll(s.Title, "\n", " ")
-		title = ansi.Truncate(title, titleWidth, "…")
-		_, writeErr = fmt.Fprintln(w, hashStyle.Render(hash), dateStyle.Render(date), title)
+		var lineage string
+		if s.ForkedFromSessionID != "" {
+			lineage = " (fork of " + session.HashID(s.ForkedFromSessionID)[:7] + ")"
+		}
+		title = ansi.Truncate(title, max(titleWidth-len(lineage), 10), "…")
+		_, writeErr = fmt.Fprintln(w, hashStyle.Render(hash), dateStyle.Render(date), title+forkStyle.Render(lineage))
 		if writeErr != nil {
 			break
 		}
@@ -202,6 +347,9 @@ type sessionJSON struct {
 	Title    string `json:"title"`
 	Created  string `json:"created"`
 	Modified string `json:"modified"`
+
+	ForkedFrom string `json:"forked_from,omitempty"`
+	ForkedAt   string `json:"forked_at,omitempty"`
 }
 
 type sessionMutationResult struct {
@@ -358,6 +506,354 @@ func runSessionRename(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+type sessionRewindResult struct {
+	ID        string                `json:"id"`
+	UUID      string                `json:"uuid"`
+	MessageID string                `json:"message_id"`
+	Applied   bool                  `json:"applied"`
+	Changes   []sessionRewindChange `json:"changes"`
+}
+
+type sessionRewindChange struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Conflict bool   `json:"conflict,omitempty"`
+}
+
+func runSessionRewind(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	event.SessionRewound(sessionRewindJSON)
+
+	sess, err := resolveSessionID(ctx, svc.sessions, args[0])
+	if err != nil {
+		return err
+	}
+
+	msg, result, rewindErr := app.Rewind(ctx, svc.messages, svc.history, sess.ID, sessionRewindTo, sessionRewindOpts)
+	if rewindErr != nil && !errors.Is(rewindErr, history.ErrRewindConflict) {
+		return fmt.Errorf("failed to rewind session: %w", rewindErr)
+	}
+
+	out := cmd.OutOrStdout()
+	if sessionRewindJSON {
+		output := sessionRewindResult{
+			ID:        session.HashID(sess.ID),
+			UUID:      sess.ID,
+			MessageID: msg.ID,
+			Applied:   result.Applied,
+			Changes:   make([]sessionRewindChange, len(result.Changes)),
+		}
+		for i, c := range result.Changes {
+			output.Changes[i] = sessionRewindChange{
+				Path:     c.Path,
+				Action:   string(c.Action),
+				Conflict: c.Conflict,
+			}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+		return rewindErr
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Fprintln(out, "Nothing to rewind")
+		return nil
+	}
+	for _, c := range result.Changes {
+		marker := ""
+		if c.Conflict {
+			marker = " (changed outside of Crush)"
+		}
+		fmt.Fprintf(out, "%-7s %s%s\n", c.Action, c.Path, marker)
+	}
+	switch {
+	case errors.Is(rewindErr, history.ErrRewindConflict):
+		return fmt.Errorf("%w; use --force to overwrite them", rewindErr)
+	case result.Applied:
+		fmt.Fprintf(out, "Rewound %d file(s) in session %s\n", len(result.Changes), session.HashID(sess.ID)[:12])
+	}
+	return nil
+}
+
+type sessionForkResult struct {
+	ID         string `json:"id"`
+	UUID       string `json:"uuid"`
+	Title      string `json:"title"`
+	ForkedFrom string `json:"forked_from"`
+	ForkedAt   string `json:"forked_at"`
+	Messages   int64  `json:"messages"`
+}
+
+func runSessionFork(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sess, err := resolveSessionID(ctx, svc.sessions, args[0])
+	if err != nil {
+		return err
+	}
+
+	msg, err := app.ResolveRewindPoint(ctx, svc.messages, sess.ID, sessionForkAt)
+	if err != nil {
+		return err
+	}
+
+	forked, err := svc.sessions.Fork(ctx, sess.ID, msg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fork session: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if sessionForkJSON {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(sessionForkResult{
+			ID:         session.HashID(forked.ID),
+			UUID:       forked.ID,
+			Title:      forked.Title,
+			ForkedFrom: session.HashID(sess.ID),
+			ForkedAt:   msg.ID,
+			Messages:   forked.MessageCount,
+		})
+	}
+
+	fmt.Fprintf(out, "Forked session %s into %s with %d message(s)\n", session.HashID(sess.ID)[:12], session.HashID(forked.ID)[:12], forked.MessageCount)
+	return nil
+}
+
+type sessionSearchResult struct {
+	ID        string   `json:"id"`
+	UUID      string   `json:"uuid"`
+	Title     string   `json:"title"`
+	MessageID string   `json:"message_id"`
+	Role      string   `json:"role"`
+	Created   string   `json:"created"`
+	Snippet   string   `json:"snippet"`
+	Matches   [][2]int `json:"matches,omitempty"`
+}
+
+func runSessionSearch(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	results, err := svc.messages.Search(ctx, strings.Join(args, " "), sessionSearchLimit)
+	if err != nil {
+		return fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	if sessionSearchJSON {
+		output := make([]sessionSearchResult, len(results))
+		for i, r := range results {
+			output[i] = sessionSearchResult{
+				ID:        session.HashID(r.SessionID),
+				UUID:      r.SessionID,
+				Title:     r.SessionTitle,
+				MessageID: r.MessageID,
+				Role:      string(r.Role),
+				Created:   time.Unix(r.CreatedAt, 0).Format(time.RFC3339),
+				Snippet:   r.Snippet,
+				Matches:   r.Matches,
+			}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetEscapeHTML(false)
+		return enc.Encode(output)
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no messages found")
+	}
+
+	w, cleanup, usingPager := sessionWriter(ctx, len(results)*3)
+	defer cleanup()
+
+	hashStyle := lipgloss.NewStyle().Foreground(charmtone.Malibu)
+	dateStyle := lipgloss.NewStyle().Foreground(charmtone.Damson)
+	roleStyle := lipgloss.NewStyle().Foreground(charmtone.Squid)
+	matchStyle := lipgloss.NewStyle().Foreground(charmtone.Zest).Bold(true)
+
+	var writeErr error
+	for _, r := range results {
+		hash := session.HashID(r.SessionID)[:7]
+		date := time.Unix(r.CreatedAt, 0).Format(time.RFC3339)
+		title := strings.ReplaceAll(r.SessionTitle, "\n", " ")
+		if _, writeErr = fmt.Fprintln(w, hashStyle.Render(hash), dateStyle.Render(date), title, roleStyle.Render(string(r.Role))); writeErr != nil {
+			break
+		}
+		if _, writeErr = fmt.Fprintf(w, "  %s\n\n", highlightMatches(r.Snippet, r.Matches, matchStyle)); writeErr != nil {
+			break
+		}
+	}
+	if writeErr != nil && usingPager && isBrokenPipe(writeErr) {
+		return nil
+	}
+	return writeErr
+}
+
+// highlightMatches renders the matched ranges of a search snippet with
+// style, flattening it onto a single line.
+func highlightMatches(snippet string, matches [][2]int, style lipgloss.Style) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[0] < last || m[1] > len(snippet) {
+			continue
+		}
+		b.WriteString(snippet[last:m[0]])
+		b.WriteString(style.Render(snippet[m[0]:m[1]]))
+		last = m[1]
+	}
+	b.WriteString(snippet[last:])
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// sessionWorktrees returns the manager of the session worktrees of the
+// repository of the working directory. It works even if worktrees have
+// since been disabled, so existing ones can still be cleaned up.
+func sessionWorktrees(ctx context.Context, cfg *config.ConfigStore) (*worktree.Manager, error) {
+	dir := config.GlobalWorktreesDir()
+	if opts := cfg.Config().Options.Worktrees; opts != nil {
+		dir = opts.Directory
+	}
+	return worktree.New(ctx, cfg.WorkingDir(), dir)
+}
+
+func runSessionDiff(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sess, err := resolveSessionID(ctx, svc.sessions, args[0])
+	if err != nil {
+		return err
+	}
+	worktrees, err := sessionWorktrees(ctx, svc.cfg)
+	if err != nil {
+		return err
+	}
+
+	diff, err := worktrees.Diff(ctx, sess.ID, sessionDiffStat)
+	if err != nil {
+		return fmt.Errorf("failed to diff session worktree: %w", err)
+	}
+	_, err = io.WriteString(cmd.OutOrStdout(), diff)
+	return err
+}
+
+type sessionWorktreeResult struct {
+	ID        string `json:"id"`
+	UUID      string `json:"uuid"`
+	Title     string `json:"title"`
+	Branch    string `json:"branch"`
+	Merged    bool   `json:"merged,omitempty"`
+	Discarded bool   `json:"discarded,omitempty"`
+}
+
+func runSessionMerge(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sess, err := resolveSessionID(ctx, svc.sessions, args[0])
+	if err != nil {
+		return err
+	}
+	worktrees, err := sessionWorktrees(ctx, svc.cfg)
+	if err != nil {
+		return err
+	}
+
+	msg := cmp.Or(sessionMergeMsg, sess.Title, "Crush session "+worktree.Name(sess.ID))
+	if err := worktrees.Merge(ctx, sess.ID, msg); err != nil {
+		return fmt.Errorf("failed to merge session worktree: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	branch := worktree.Branch(sess.ID)
+	if sessionMergeJSON {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(sessionWorktreeResult{
+			ID:     session.HashID(sess.ID),
+			UUID:   sess.ID,
+			Title:  sess.Title,
+			Branch: branch,
+			Merged: true,
+		})
+	}
+
+	fmt.Fprintf(out, "Merged %s into %s\n", branch, worktrees.Repository())
+	return nil
+}
+
+func runSessionDiscard(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sess, err := resolveSessionID(ctx, svc.sessions, args[0])
+	if err != nil {
+		return err
+	}
+	worktrees, err := sessionWorktrees(ctx, svc.cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := worktrees.Discard(ctx, sess.ID); err != nil {
+		return fmt.Errorf("failed to discard session worktree: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	branch := worktree.Branch(sess.ID)
+	if sessionDiscardJSON {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(sessionWorktreeResult{
+			ID:        session.HashID(sess.ID),
+			UUID:      sess.ID,
+			Title:     sess.Title,
+			Branch:    branch,
+			Discarded: true,
+		})
+	}
+
+	fmt.Fprintf(out, "Discarded %s\n", branch)
+	return nil
+}
+
 func runSessionLast(cmd *cobra.Command, _ []string) error {
 	event.SetNonInteractive(true)
 
@@ -422,6 +918,10 @@ func outputSessionJSON(w io.Writer, sess session.Session, msgs []*message.Messag
 		},
 		Messages: make([]sessionShowMessage, len(msgs)),
 	}
+	if sess.ForkedFromSessionID != "" {
+		output.Meta.ForkedFrom = session.HashID(sess.ForkedFromSessionID)
+		output.Meta.ForkedAt = sess.ForkedAtMessageID
+	}
 
 	for i, msg := range msgs {
 		output.Messages[i] = sessionShowMessage{
@@ -468,6 +968,9 @@ func outputSessionHuman(ctx context.Context, cfg *config.ConfigStore, sess sessi
 	fmt.Fprintln(&buf, keyStyle.Render("UUID:  ")+valStyle.Render(sess.ID))
 	fmt.Fprintln(&buf, keyStyle.Render("Title: ")+valStyle.Render(sess.Title))
 	fmt.Fprintln(&buf, keyStyle.Render("Date:  ")+valStyle.Render(created))
+	if sess.ForkedFromSessionID != "" {
+		fmt.Fprintln(&buf, keyStyle.Render("Fork:  ")+valStyle.Render(session.HashID(sess.ForkedFromSessionID)[:12]+" at "+sess.ForkedAtMessageID))
+	}
 	if len(skills) > 0 {
 		skillNames := make([]string, len(skills))
 		for i, s := range skills {
@@ -579,6 +1082,8 @@ type sessionShowMeta struct {
 	CompletionTokens int64              `json:"completion_tokens"`
 	TotalTokens      int64              `json:"total_tokens"`
 	Skills           []sessionShowSkill `json:"skills,omitempty"`
+	ForkedFrom       string             `json:"forked_from,omitempty"`
+	ForkedAt         string             `json:"forked_at,omitempty"`
 }
 
 type sessionShowSkill struct {
@@ -672,6 +1177,11 @@ func convertParts(parts []message.ContentPart) []sessionShowPart {
 				Type: "text",
 				Text: p.Text,
 			})
+		case message.ContextContent:
+			result = append(result, sessionShowPart{
+				Type: "context",
+				Text: p.Text,
+			})
 		case message.ReasoningContent:
 			result = append(result, sessionShowPart{
 				Type:       "reasoning",
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/home"
+	"gopkg.in/yaml.v3"
+)
+
+// AgentMode controls where an agent can be used.
+type AgentMode string
+
+const (
+	// AgentModeAll agents can be the primary agent or a subagent.
+	AgentModeAll AgentMode = "all"
+	// AgentModePrimary agents only handle the user's prompts.
+	AgentModePrimary AgentMode = "primary"
+	// AgentModeSubagent agents are only reachable through the agent tool.
+	AgentModeSubagent AgentMode = "subagent"
+)
+
+// CanBePrimary reports whether the agent can handle the user's prompts.
+func (a Agent) CanBePrimary() bool {
+	return !a.Disabled && a.Mode != AgentModeSubagent
+}
+
+// CanBeSubagent reports whether the agent can be run through the agent
+// tool.
+func (a Agent) CanBeSubagent() bool {
+	return !a.Disabled && a.Mode != AgentModePrimary
+}
+
+// PrimaryAgentID returns the ID of the agent that handles the user's
+// prompts. It falls back to the coder agent when options.primary_agent is
+// unset or names an agent that can't be used as the primary agent.
+func (c *Config) PrimaryAgentID() string {
+	if c.Options == nil || c.Options.PrimaryAgent == "" {
+		return AgentCoder
+	}
+	agent, ok := c.Agents[c.Options.PrimaryAgent]
+	if !ok || !agent.CanBePrimary() {
+		return AgentCoder
+	}
+	return agent.ID
+}
+
+// PrimaryAgent returns the agent that handles the user's prompts.
+func (c *Config) PrimaryAgent() (Agent, bool) {
+	agent, ok := c.Agents[c.PrimaryAgentID()]
+	return agent, ok
+}
+
+// resolveCustomAgent fills in the defaults for a user-defined agent.
+// Tools the agent asks for are limited to the ones enabled globally.
+func (c *Config) resolveCustomAgent(id string, agent Agent, allowedTools []string) Agent {
+	agent.ID = id
+	agent.Name = cmp.Or(agent.Name, id)
+	if agent.Model == "" {
+		agent.Model = SelectedModelTypeLarge
+	}
+	if agent.Mode == "" {
+		agent.Mode = AgentModeAll
+	}
+	if agent.ContextPaths == nil {
+		agent.ContextPaths = c.Options.ContextPaths
+	}
+	if agent.AllowedTools == nil {
+		agent.AllowedTools = allowedTools
+	} else {
+		agent.AllowedTools = filterSlice(allowedTools, agent.AllowedTools, true)
+	}
+	return agent
+}
+
+// AgentDirs returns the directories agent definition files are loaded
+// from, in increasing order of precedence.
+func AgentDirs(dataDir string) []string {
+	return []string{
+		filepath.Join(home.Config(), appName, "agents"),
+		filepath.Join(dataDir, "agents"),
+	}
+}
+
+// agentFrontmatter is the YAML frontmatter of an agent definition file.
+// The markdown body becomes the agent's system prompt.
+type agentFrontmatter struct {
+	Name         string              `yaml:"name"`
+	Description  string              `yaml:"description"`
+	Disabled     bool                `yaml:"disabled"`
+	Model        SelectedModelType   `yaml:"model"`
+	Mode         AgentMode           `yaml:"mode"`
+	AllowedTools []string            `yaml:"allowed_tools"`
+	AllowedMCP   map[string][]string `yaml:"allowed_mcp"`
+}
+
+// LoadAgentFiles reads agent definitions from the *.md files in dirs. The
+// file name without its extension is the agent ID; files in later
+// directories override earlier ones with the same ID. Files that fail to
+// parse are logged and skipped.
+func LoadAgentFiles(dirs ...string) map[string]Agent {
+	agents := make(map[string]Agent)
+	for _, dir := range dirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			continue
+		}
+		for _, path := range matches {
+			agent, err := ParseAgentFile(path)
+			if err != nil {
+				slog.Warn("Failed to load agent file", "path", path, "error", err)
+				continue
+			}
+			agents[agent.ID] = agent
+		}
+	}
+	return agents
+}
+
+// ParseAgentFile parses a markdown agent definition with YAML frontmatter.
+func ParseAgentFile(path string) (Agent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Agent{}, err
+	}
+	frontmatter, body, err := splitAgentFrontmatter(string(content))
+	if err != nil {
+		return Agent{}, err
+	}
+
+	var fm agentFrontmatter
+	if err := yaml.Unmarshal([]byte(frontmatter), &fm); err != nil {
+		return Agent{}, fmt.Errorf("parsing frontmatter: %w", err)
+	}
+	switch fm.Model {
+	case "", SelectedModelTypeLarge, SelectedModelTypeSmall:
+	default:
+		return Agent{}, fmt.Errorf("invalid model %q: must be large or small", fm.Model)
+	}
+	switch fm.Mode {
+	case "", AgentModeAll, AgentModePrimary, AgentModeSubagent:
+	default:
+		return Agent{}, fmt.Errorf("invalid mode %q: must be all, primary, or subagent", fm.Mode)
+	}
+
+	return Agent{
+		ID:           strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Name:         fm.Name,
+		Description:  fm.Description,
+		Disabled:     fm.Disabled,
+		Model:        fm.Model,
+		Mode:         fm.Mode,
+		AllowedTools: fm.AllowedTools,
+		AllowedMCP:   fm.AllowedMCP,
+		Prompt:       strings.TrimSpace(body),
+	}, nil
+}
+
+// splitAgentFrontmatter extracts the YAML frontmatter and body from a
+// markdown file.
+func splitAgentFrontmatter(content string) (frontmatter, body string, err error) {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	start := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) != ""
+	})
+	if start == -1 || strings.TrimSpace(lines[start]) != "---" {
+		return "", "", errors.New("no YAML frontmatter found")
+	}
+	end := slices.IndexFunc(lines[start+1:], func(line string) bool {
+		return strings.TrimSpace(line) == "---"
+	})
+	if end == -1 {
+		return "", "", errors.New("unclosed frontmatter")
+	}
+	end += start + 1
+	return strings.Join(lines[start+1:end], "\n"), strings.Join(lines[end+1:], "\n"), nil
+}
+
+// mergeAgentFiles adds the agents defined in markdown files to
+// [Config.CustomAgents]. Agents defined in the config file win over files
+// with the same ID.
+func (c *Config) mergeAgentFiles() {
+	files := LoadAgentFiles(AgentDirs(c.Options.DataDirectory)...)
+	if len(files) == 0 {
+		return
+	}
+	maps.Copy(files, c.CustomAgents)
+	c.CustomAgents = files
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("frontmatter and body", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "reviewer.md")
+		require.NoError(t, os.WriteFile(path, []byte(`---
+name: Reviewer
+description: Reviews changes without editing files.
+model: small
+mode: subagent
+allowed_tools: [view, grep]
+allowed_mcp:
+  github: [get_pull_request]
+---
+
+You review code. Never edit files.
+`), 0o644))
+
+		agent, err := ParseAgentFile(path)
+		require.NoError(t, err)
+		require.Equal(t, Agent{
+			ID:           "reviewer",
+			Name:         "Reviewer",
+			Description:  "Reviews changes without editing files.",
+			Model:        SelectedModelTypeSmall,
+			Mode:         AgentModeSubagent,
+			AllowedTools: []string{"view", "grep"},
+			AllowedMCP:   map[string][]string{"github": {"get_pull_request"}},
+			Prompt:       "You review code. Never edit files.",
+		}, agent)
+	})
+
+	t.Run("invalid model", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "bad.md")
+		require.NoError(t, os.WriteFile(path, []byte("---\nmodel: huge\n---\nprompt\n"), 0o644))
+
+		_, err := ParseAgentFile(path)
+		require.ErrorContains(t, err, "invalid model")
+	})
+
+	t.Run("missing frontmatter", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "plain.md")
+		require.NoError(t, os.WriteFile(path, []byte("just a prompt\n"), 0o644))
+
+		_, err := ParseAgentFile(path)
+		require.Error(t, err)
+	})
+}
+
+func TestLoadAgentFiles(t *testing.T) {
+	t.Parallel()
+
+	global := t.TempDir()
+	project := t.TempDir()
+	write := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write(global, "reviewer.md", "---\nname: Global Reviewer\n---\nglobal\n")
+	write(global, "docs.md", "---\nname: Docs\n---\ndocs\n")
+	write(project, "reviewer.md", "---\nname: Project Reviewer\n---\nproject\n")
+	write(project, "broken.md", "no frontmatter\n")
+	write(project, "notes.txt", "---\nname: Notes\n---\n")
+
+	agents := LoadAgentFiles(global, project, filepath.Join(project, "missing"))
+	require.Len(t, agents, 2)
+	require.Equal(t, "Project Reviewer", agents["reviewer"].Name)
+	require.Equal(t, "Docs", agents["docs"].Name)
+}
+
+func TestSetupAgentsCustom(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Options: &Options{
+			ContextPaths: []string{"AGENTS.md"},
+			PrimaryAgent: "reviewer",
+		},
+		CustomAgents: map[string]Agent{
+			"reviewer": {
+				AllowedTools: []string{"view", "grep", "not_a_tool"},
+				Prompt:       "Review things.",
+			},
+			"helper": {
+				Mode:  AgentModeSubagent,
+				Model: SelectedModelTypeSmall,
+			},
+			AgentCoder: {Name: "Impostor"},
+		},
+	}
+	cfg.SetupAgents()
+
+	reviewer, ok := cfg.Agents["reviewer"]
+	require.True(t, ok)
+	require.Equal(t, "reviewer", reviewer.ID)
+	require.Equal(t, "reviewer", reviewer.Name)
+	require.Equal(t, SelectedModelTypeLarge, reviewer.Model)
+	require.Equal(t, AgentModeAll, reviewer.Mode)
+	require.Equal(t, []string{"grep", "view"}, reviewer.AllowedTools)
+	require.Equal(t, []string{"AGENTS.md"}, reviewer.ContextPaths)
+
+	helper := cfg.Agents["helper"]
+	require.Contains(t, helper.AllowedTools, "bash")
+	require.False(t, helper.CanBePrimary())
+	require.True(t, helper.CanBeSubagent())
+
+	require.Equal(t, "Coder", cfg.Agents[AgentCoder].Name)
+
+	t.Run("primary agent", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "reviewer", cfg.PrimaryAgentID())
+	})
+
+	t.Run("subagent-only primary falls back to coder", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Options:      &Options{PrimaryAgent: "helper"},
+			CustomAgents: map[string]Agent{"helper": {Mode: AgentModeSubagent}},
+		}
+		cfg.SetupAgents()
+		require.Equal(t, AgentCoder, cfg.PrimaryAgentID())
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"maps"
 	"net/http"
 	"net/url"
@@ -85,6 +86,10 @@ type SelectedModel struct {
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// Models to switch to, in order, when the provider keeps failing with
+	// rate limits or outages.
+	Fallbacks []SelectedModel `json:"fallbacks,omitempty" jsonschema:"description=Models to switch to in order when the provider is rate limited or unavailable"`
 }
 
 type ProviderConfig struct {
@@ -197,6 +202,26 @@ type MCPConfig struct {
 	// omitted from the outgoing request rather than sent as
 	// "Header:".
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	// OAuthClient is the OAuth client Crush authorizes with HTTP/SSE MCP
+	// servers following the MCP authorization spec. When it has no client
+	// ID, 'crush login mcp' registers one dynamically and saves it here.
+	OAuthClient *MCPOAuthClient `json:"oauth_client,omitempty" jsonschema:"description=OAuth client for HTTP/SSE MCP servers requiring authorization"`
+	// OAuthToken is the token saved by 'crush login mcp', refreshed as it
+	// expires.
+	OAuthToken *oauth.Token `json:"oauth,omitempty" jsonschema:"description=OAuth2 token for authentication with the MCP server"`
+}
+
+// MCPOAuthClient is the OAuth client of an MCP server.
+type MCPOAuthClient struct {
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=Client ID registered with the authorization server; registered dynamically when empty"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=Client secret of confidential clients"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=Scopes to request instead of the ones advertised by the MCP server,example=read,example=write"`
+	RedirectPort int      `json:"redirect_port,omitempty" jsonschema:"description=Port of the localhost redirect URL; random when zero,example=8765"`
+
+	// RedirectURL is the redirect URL a dynamically registered client was
+	// registered with. Empty for clients configured by the user.
+	RedirectURL string `json:"redirect_url,omitempty" jsonschema:"description=Redirect URL of a dynamically registered client; set by Crush"`
 }
 
 type LSPConfig struct {
@@ -219,6 +244,12 @@ type TUIOptions struct {
 
 	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
 	Transparent *bool       `json:"transparent,omitempty" jsonschema:"description=Enable transparent background for the TUI interface,default=false"`
+	Theme       string      `json:"theme,omitempty" jsonschema:"description=Color theme of the TUI: a built-in theme or one loaded from the themes directories,example=isobit,example=isobit-light,example=charmtone,example=charmtone-light,example=hypercrush"`
+
+	// Keymap maps TUI actions, such as "quit" or "editor.send_message", to
+	// the keys they are bound to, replacing the default ones. The TUI
+	// validates the action names, as they are only known to it.
+	Keymap map[string][]string `json:"keymap,omitempty" jsonschema:"description=Key bindings of the TUI by action; an empty list unbinds the action"`
 }
 
 // Completions defines options for the completions UI.
@@ -269,19 +300,26 @@ type Options struct {
 	// the SQLite database and workspace overrides. Relative paths are
 	// resolved against the working directory; absolute paths are used
 	// verbatim. After defaulting the stored value is always absolute.
-	DataDirectory             string          `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data. Relative paths are resolved against the working directory; absolute paths are used as-is.,default=.crush,example=.crush"`
-	DisabledTools             []string        `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
-	DisableProviderAutoUpdate bool            `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
-	DisableDefaultProviders   bool            `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled\\, providers must be fully specified in the config file with base_url\\, models\\, and api_key - no merging with defaults occurs,default=false"`
-	Attribution               *Attribution    `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
-	DisableMetrics            bool            `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
-	InitializeAs              string          `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
-	AutoLSP                   *bool           `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
-	Progress                  *bool           `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
-	HashlineEdit              *bool           `json:"hashline_edit,omitempty" jsonschema:"description=Enable hashline-addressed editing mode. When enabled the view tool emits LINE#HASH| prefixed output and hashline_edit replaces edit/multiedit,default=false"`
-	DisableNotifications      bool            `json:"disable_notifications,omitempty" jsonschema:"description=Disable desktop notifications,default=false"`
-	DisabledSkills            []string        `json:"disabled_skills,omitempty" jsonschema:"description=List of skill names to disable and hide from the agent,example=crush-config"`
-	Sandbox                   *SandboxOptions `json:"sandbox,omitempty" jsonschema:"description=Sandbox options for bash command isolation via bubblewrap"`
+	DataDirectory             string              `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data. Relative paths are resolved against the working directory; absolute paths are used as-is.,default=.crush,example=.crush"`
+	DisabledTools             []string            `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
+	DisableProviderAutoUpdate bool                `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
+	DisableDefaultProviders   bool                `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled\\, providers must be fully specified in the config file with base_url\\, models\\, and api_key - no merging with defaults occurs,default=false"`
+	Attribution               *Attribution        `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
+	DisableMetrics            bool                `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
+	InitializeAs              string              `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	AutoLSP                   *bool               `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
+	Progress                  *bool               `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
+	HashlineEdit              *bool               `json:"hashline_edit,omitempty" jsonschema:"description=Enable hashline-addressed editing mode. When enabled the view tool emits LINE#HASH| prefixed output and hashline_edit replaces edit/multiedit,default=false"`
+	DisableNotifications      bool                `json:"disable_notifications,omitempty" jsonschema:"description=Disable desktop notifications,default=false"`
+	DisabledSkills            []string            `json:"disabled_skills,omitempty" jsonschema:"description=List of skill names to disable and hide from the agent,example=crush-config"`
+	Sandbox                   *SandboxOptions     `json:"sandbox,omitempty" jsonschema:"description=Sandbox options for bash command isolation via bubblewrap"`
+	Budgets                   *BudgetOptions      `json:"budgets,omitempty" jsonschema:"description=Spending limits that stop the agent once reached"`
+	Worktrees                 *WorktreeOptions    `json:"worktrees,omitempty" jsonschema:"description=Per-session git worktrees that keep sessions running at the same time from editing the same files"`
+	Checkpoints               *bool               `json:"checkpoints,omitempty" jsonschema:"description=Snapshot the working tree into a hidden git ref at the start of every turn so the changes of a turn can be reviewed and restored,default=false"`
+	Telemetry                 *TelemetryOptions   `json:"telemetry,omitempty" jsonschema:"description=OpenTelemetry traces and metrics of agent turns\\, provider requests\\, and tool calls"`
+	RepoMap                   *RepoMapOptions     `json:"repo_map,omitempty" jsonschema:"description=Outline of the files and top-level symbols of the workspace added to the system prompt"`
+	SearchIndex               *SearchIndexOptions `json:"search_index,omitempty" jsonschema:"description=On-disk trigram index of the working directory that speeds up grep\\, glob\\, and file completions in large repositories"`
+	PrimaryAgent              string              `json:"primary_agent,omitempty" jsonschema:"description=ID of the agent that handles prompts,default=coder,example=coder"`
 }
 
 // SandboxOptions configures OS-level isolation for bash commands.
@@ -291,6 +329,92 @@ type SandboxOptions struct {
 	Network *bool   `json:"network,omitempty" jsonschema:"description=Allow network access inside the sandbox by default. The model can still request network per-command,default=false"`
 }
 
+// WorktreeOptions configures per-session git worktrees. When enabled, each
+// session works in a git worktree of its own on a dedicated branch, which
+// can be diffed, merged back, or discarded with "crush session".
+type WorktreeOptions struct {
+	Enabled bool `json:"enabled,omitempty" jsonschema:"description=Give each session its own git worktree and branch,default=false"`
+	// Directory is where worktrees are created. After defaulting it is
+	// always absolute.
+	Directory string `json:"directory,omitempty" jsonschema:"description=Directory to create session worktrees in. Relative paths are resolved against the working directory,default=~/.local/share/crush/worktrees"`
+}
+
+// TelemetryOptions configures the export of OpenTelemetry traces and
+// metrics. It is unrelated to DisableMetrics, which controls the product
+// usage metrics sent to Charm.
+type TelemetryOptions struct {
+	Enabled  bool              `json:"enabled,omitempty" jsonschema:"description=Export OpenTelemetry traces and metrics,default=false"`
+	Exporter string            `json:"exporter,omitempty" jsonschema:"description=Where to export to,enum=otlp-http,enum=otlp-grpc,enum=file,default=otlp-http"`
+	Endpoint string            `json:"endpoint,omitempty" jsonschema:"description=URL of the OTLP collector. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or the local collector,example=http://localhost:4318"`
+	Headers  map[string]string `json:"headers,omitempty" jsonschema:"description=Headers sent to the OTLP collector"`
+	File     string            `json:"file,omitempty" jsonschema:"description=File to append JSON lines to with the file exporter. Relative paths are resolved against the data directory,default=telemetry.jsonl"`
+}
+
+// RepoMapOptions configures the repository map: an outline of the files of
+// the workspace and their most referred to symbols, added to the system
+// prompt of the agent.
+type RepoMapOptions struct {
+	Enabled   *bool `json:"enabled,omitempty" jsonschema:"description=Add the repository map to the system prompt,default=false"`
+	MaxTokens int   `json:"max_tokens,omitempty" jsonschema:"description=Approximate size of the repository map in tokens,default=1024,minimum=0"`
+}
+
+// IsEnabled reports whether the repository map is added to the system
+// prompt, which it isn't by default.
+func (r *RepoMapOptions) IsEnabled() bool {
+	return r != nil && r.Enabled != nil && *r.Enabled
+}
+
+// TokenBudget returns the approximate size of the repository map,
+// defaulting to 1024 tokens.
+func (r *RepoMapOptions) TokenBudget() int {
+	if r == nil || r.MaxTokens <= 0 {
+		return 1024
+	}
+	return r.MaxTokens
+}
+
+// SearchIndexOptions configures the trigram index of the working directory.
+// It is built in the background and used by grep, glob, and file
+// completions once built; until then they walk the tree as usual.
+type SearchIndexOptions struct {
+	Enabled         bool `json:"enabled,omitempty" jsonschema:"description=Keep a trigram index of the working directory in the data directory,default=false"`
+	RefreshInterval int  `json:"refresh_interval,omitempty" jsonschema:"description=Seconds between checks for changed files to update the index,default=30,minimum=0"`
+}
+
+// GetRefreshInterval returns the user-defined refresh interval or the
+// default of 30 seconds.
+func (s *SearchIndexOptions) GetRefreshInterval() time.Duration {
+	if s == nil || s.RefreshInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(s.RefreshInterval) * time.Second
+}
+
+// BudgetOptions configures spending limits. The agent warns when spending
+// reaches WarnAt of a limit and stops once a limit is reached.
+type BudgetOptions struct {
+	Session   *Budget `json:"session,omitempty" jsonschema:"description=Limit for a single session including its sub-agents"`
+	Daily     *Budget `json:"daily,omitempty" jsonschema:"description=Limit for all sessions in this workspace per calendar day in local time"`
+	Workspace *Budget `json:"workspace,omitempty" jsonschema:"description=Limit for all sessions in this workspace"`
+	WarnAt    float64 `json:"warn_at,omitempty" jsonschema:"description=Fraction of a limit at which to warn,default=0.8,minimum=0,maximum=1"`
+}
+
+// WarnThreshold returns the fraction of a limit at which to warn,
+// defaulting to 0.8.
+func (b *BudgetOptions) WarnThreshold() float64 {
+	if b == nil || b.WarnAt <= 0 || b.WarnAt > 1 {
+		return 0.8
+	}
+	return b.WarnAt
+}
+
+// Budget is a spending limit in US dollars, tokens, or both. Zero means
+// no limit.
+type Budget struct {
+	USD    float64 `json:"usd,omitempty" jsonschema:"description=Spending limit in US dollars,minimum=0,example=5"`
+	Tokens int64   `json:"tokens,omitempty" jsonschema:"description=Limit on prompt plus completion tokens,minimum=0,example=2000000"`
+}
+
 type MCPs map[string]MCPConfig
 
 type MCP struct {
@@ -502,26 +626,34 @@ func (l LSPConfig) ResolvedEnv(r VariableResolver) (map[string]string, error) {
 }
 
 type Agent struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	ID          string `json:"id,omitempty" jsonschema:"-"`
+	Name        string `json:"name,omitempty" jsonschema:"description=Display name of the agent,example=Reviewer"`
+	Description string `json:"description,omitempty" jsonschema:"description=What the agent is for. Shown to the primary agent when it picks a subagent,example=Reviews changes without editing files"`
 	// This is the id of the system prompt used by the agent
-	Disabled bool `json:"disabled,omitempty"`
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Disable this agent,default=false"`
+
+	Model SelectedModelType `json:"model,omitempty" jsonschema:"description=The model type to use for this agent,enum=large,enum=small,default=large"`
+
+	// Where the agent can be used: as the primary agent, as a subagent
+	// of the agent tool, or both.
+	Mode AgentMode `json:"mode,omitempty" jsonschema:"description=Whether the agent can be used as the primary agent\\, as a subagent through the agent tool\\, or both,enum=all,enum=primary,enum=subagent,default=all"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	// The system prompt of a user-defined agent. Built-in agents leave
+	// this empty and use their own templates.
+	Prompt string `json:"prompt,omitempty" jsonschema:"description=System prompt for the agent. Environment details and context files are appended to it"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
-	AllowedTools []string `json:"allowed_tools,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=Tools the agent can use. Defaults to every enabled tool,example=view,example=grep"`
 
 	// this tells us which MCPs are available for this agent
 	//  if this is empty all mcps are available
 	//  the string array is the list of tools from the AllowedMCP the agent has available
 	//  if the string array is nil, all tools from the AllowedMCP are available
-	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty"`
+	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty" jsonschema:"description=MCP servers the agent can use mapped to the allowed tool names. An empty list allows all tools of that server. Defaults to every MCP server"`
 
 	// Overrides the context paths for this agent
-	ContextPaths []string `json:"context_paths,omitempty"`
+	ContextPaths []string `json:"context_paths,omitempty" jsonschema:"description=Overrides the context paths for this agent"`
 }
 
 type Tools struct {
@@ -570,8 +702,10 @@ type ToolWebSearch struct {
 // is owned by hooks.Runner so a JSON round-trip, merge, or reload can't
 // silently drop compiled state.
 type HookConfig struct {
-	// Regex pattern tested against the tool name. Empty means match all.
-	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regex pattern tested against the tool name. Empty means match all tools."`
+	// Regex pattern tested against the tool name, or the source/reason for
+	// SessionStart/SessionEnd. Ignored by UserPromptSubmit and Stop. Empty
+	// means match all.
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regex pattern tested against the tool name (or the source/reason for SessionStart/SessionEnd). Empty means match all."`
 	// Shell command to execute.
 	Command string `json:"command" jsonschema:"required,description=Shell command to execute when the hook fires"`
 	// Timeout in seconds. Default 30.
@@ -587,6 +721,31 @@ func (h *HookConfig) TimeoutDuration() time.Duration {
 	return time.Duration(h.Timeout) * time.Second
 }
 
+// FormatterConfig configures how files of one type are formatted after the
+// agent writes them.
+type FormatterConfig struct {
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Whether this formatter is disabled,default=false"`
+	// Shell command that formats the file in place. The file's path is in
+	// $CRUSH_FILE_PATH.
+	Command string `json:"command,omitempty" jsonschema:"description=Shell command that formats the file in place. The file's path is in $CRUSH_FILE_PATH.,example=gofmt -w \"$CRUSH_FILE_PATH\""`
+	// Format with the LSP server that handles the file when no command is
+	// set.
+	LSP bool `json:"lsp,omitempty" jsonschema:"description=Format with the LSP server's textDocument/formatting when no command is set,default=false"`
+	// LSP code action kinds applied after formatting.
+	CodeActions []string `json:"code_actions,omitempty" jsonschema:"description=LSP code action kinds to apply after formatting,example=source.organizeImports,example=source.fixAll"`
+	// Timeout in seconds. Default 10.
+	Timeout int `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for formatting a file,default=10"`
+}
+
+// TimeoutDuration returns the formatter timeout as a time.Duration,
+// defaulting to 10s.
+func (f FormatterConfig) TimeoutDuration() time.Duration {
+	if f.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(f.Timeout) * time.Second
+}
+
 // Config holds the configuration for crush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -610,7 +769,15 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitzero" jsonschema:"description=Tool configurations"`
 
-	Hooks map[string][]HookConfig `json:"hooks,omitempty" jsonschema:"description=User-defined shell commands that fire on hook events (e.g. PreToolUse)"`
+	// Formatters run after the agent writes a file, keyed by file type
+	// (the file extension without the dot).
+	Formatters map[string]FormatterConfig `json:"formatters,omitempty" jsonschema:"description=Formatters run after the agent writes a file\\, keyed by file extension without the dot"`
+
+	Hooks map[string][]HookConfig `json:"hooks,omitempty" jsonschema:"description=User-defined shell commands that fire on hook events (PreToolUse\\, PostToolUse\\, UserPromptSubmit\\, Stop\\, SessionStart\\, SessionEnd)"`
+
+	// User-defined agents, keyed by ID. Agents loaded from markdown files
+	// are merged in by [ConfigStore.SetupAgents].
+	CustomAgents map[string]Agent `json:"agents,omitempty" jsonschema:"description=User-defined agents keyed by ID"`
 
 	Agents map[string]Agent `json:"-"`
 }
@@ -692,12 +859,19 @@ func allToolNames() []string {
 		"hashline_edit",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_definition",
+		"lsp_hover",
+		"lsp_document_symbols",
+		"lsp_workspace_symbols",
+		"lsp_rename",
+		"lsp_code_action",
 		"lsp_restart",
 		"fetch",
 		"agentic_fetch",
 		"glob",
 		"grep",
 		"ls",
+		"repo_map",
 		"sourcegraph",
 		"todos",
 		"view",
@@ -718,7 +892,7 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{"glob", "grep", "ls", "repo_map", "sourcegraph", "view"}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -735,6 +909,8 @@ func filterSlice(data []string, mask []string, include bool) []string {
 	return filtered
 }
 
+// SetupAgents configures the built-in coder and task agents along with
+// any user-defined agents in [Config.CustomAgents].
 func (c *Config) SetupAgents() {
 	allowedTools := resolveAllowedTools(allToolNames(), c.Options.DisabledTools)
 
@@ -754,6 +930,7 @@ func (c *Config) SetupAgents() {
 			Name:         "Coder",
 			Description:  "An agent that helps with executing coding tasks.",
 			Model:        SelectedModelTypeLarge,
+			Mode:         AgentModePrimary,
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: allowedTools,
 		},
@@ -763,12 +940,20 @@ func (c *Config) SetupAgents() {
 			Name:         "Task",
 			Description:  "An agent that helps with searching for context and finding implementation details.",
 			Model:        SelectedModelTypeLarge,
+			Mode:         AgentModeSubagent,
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: resolveReadOnlyTools(allowedTools),
 			// NO MCPs or LSPs by default
 			AllowedMCP: map[string][]string{},
 		},
 	}
+	for id, agent := range c.CustomAgents {
+		if _, builtin := agents[id]; builtin {
+			slog.Warn("Ignoring user-defined agent that shadows a built-in agent", "agent", id)
+			continue
+		}
+		agents[id] = c.resolveCustomAgent(id, agent, allowedTools)
+	}
 	c.Agents = agents
 }
 
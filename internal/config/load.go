@@ -459,6 +459,10 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 		}
 	}
 	c.Options.DataDirectory = filepath.Clean(filepathext.SmartJoin(workingDir, c.Options.DataDirectory))
+	if c.Options.Worktrees != nil {
+		dir := cmp.Or(home.Long(c.Options.Worktrees.Directory), GlobalWorktreesDir())
+		c.Options.Worktrees.Directory = filepath.Clean(filepathext.SmartJoin(workingDir, dir))
+	}
 	if c.Providers == nil {
 		c.Providers = csync.NewMap[string, ProviderConfig]()
 	}
@@ -876,6 +880,26 @@ func ProjectConfigs(cwd string) []string {
 	return lookupConfigs(cwd)
 }
 
+// PermissionPolicyFiles returns the permission policy files for a project
+// in evaluation order: the user's, then the project's own, so that a
+// policy checked into a repository can't override the user's.
+func PermissionPolicyFiles(cwd string) []string {
+	return []string{
+		filepath.Join(filepath.Dir(GlobalConfig()), "permissions.json"),
+		filepath.Join(cwd, defaultDataDirectory, "permissions.json"),
+	}
+}
+
+// ThemeDirs returns the directories of the theme files of a project in
+// loading order: the user's, then the project's own, whose themes replace
+// the user's ones of the same name.
+func ThemeDirs(cwd string) []string {
+	return []string{
+		filepath.Join(filepath.Dir(GlobalConfig()), "themes"),
+		filepath.Join(cwd, defaultDataDirectory, "themes"),
+	}
+}
+
 // GlobalConfigData returns the path to the main data directory for the application.
 // this config is used when the app overrides configurations instead of updating the global config.
 func GlobalConfigData() string {
@@ -909,6 +933,13 @@ func GlobalWorkspaceDir() string {
 	return filepath.Dir(GlobalConfigData())
 }
 
+// GlobalWorktreesDir returns the default directory session worktrees are
+// created in. Keeping them out of the project means tools and LSP servers
+// working on the project don't see the files of every session.
+func GlobalWorktreesDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfigData()), "worktrees")
+}
+
 func assignIfNil[T any](ptr **T, val T) {
 	if *ptr == nil {
 		*ptr = &val
@@ -1019,6 +1050,16 @@ func normalizeHookEvent(name string) string {
 	switch strings.ToLower(strings.ReplaceAll(name, "_", "")) {
 	case "pretooluse":
 		return "PreToolUse"
+	case "posttooluse":
+		return "PostToolUse"
+	case "userpromptsubmit":
+		return "UserPromptSubmit"
+	case "stop":
+		return "Stop"
+	case "sessionstart":
+		return "SessionStart"
+	case "sessionend":
+		return "SessionEnd"
 	default:
 		return name
 	}
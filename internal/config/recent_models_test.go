@@ -212,6 +212,28 @@ func TestUpdatePreferredModel_UpdatesRecents(t *testing.T) {
 	require.Len(t, small, 1)
 }
 
+func TestUpdatePreferredModel_KeepsFallbacks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	store := testStoreWithPath(cfg, dir)
+
+	fallbacks := []SelectedModel{
+		{Provider: "openai", Model: "gpt-4o"},
+		{Provider: "openrouter", Model: "qwen/qwen3-coder"},
+	}
+	cfg.Models[SelectedModelTypeLarge] = SelectedModel{Provider: "anthropic", Model: "claude-sonnet-4", Fallbacks: fallbacks}
+
+	require.NoError(t, store.UpdatePreferredModel(ScopeGlobal, SelectedModelTypeLarge, SelectedModel{Provider: "anthropic", Model: "claude-opus-4"}))
+	require.Equal(t, fallbacks, cfg.Models[SelectedModelTypeLarge].Fallbacks)
+
+	// A model in the chain is not its own fallback.
+	require.NoError(t, store.UpdatePreferredModel(ScopeGlobal, SelectedModelTypeLarge, SelectedModel{Provider: "openai", Model: "gpt-4o"}))
+	require.Equal(t, fallbacks[1:], cfg.Models[SelectedModelTypeLarge].Fallbacks)
+}
+
 func TestRecordRecentModel_TypeIsolation(t *testing.T) {
 	t.Parallel()
 
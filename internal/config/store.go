@@ -81,8 +81,10 @@ func (s *ConfigStore) KnownProviders() []catwalk.Provider {
 	return s.knownProviders
 }
 
-// SetupAgents configures the coder and task agents on the config.
+// SetupAgents configures the built-in agents and the user-defined agents
+// from the config file and agent definition files.
 func (s *ConfigStore) SetupAgents() {
+	s.config.mergeAgentFiles()
 	s.config.SetupAgents()
 }
 
@@ -209,6 +211,12 @@ func (s *ConfigStore) RemoveConfigField(scope Scope, key string) error {
 // UpdatePreferredModel updates the preferred model for the given type and
 // persists it to the config file at the given scope.
 func (s *ConfigStore) UpdatePreferredModel(scope Scope, modelType SelectedModelType, model SelectedModel) error {
+	if model.Fallbacks == nil {
+		// Keep the fallback chain of the model being replaced.
+		model.Fallbacks = slices.DeleteFunc(slices.Clone(s.config.Models[modelType].Fallbacks), func(fallback SelectedModel) bool {
+			return fallback.Provider == model.Provider && fallback.Model == model.Model
+		})
+	}
 	s.config.Models[modelType] = model
 	if err := s.SetConfigField(scope, fmt.Sprintf("models.%s", modelType), model); err != nil {
 		return fmt.Errorf("failed to update preferred model: %w", err)
@@ -387,6 +395,12 @@ func (s *ConfigStore) applyToken(providerConfig ProviderConfig, token *oauth.Tok
 // config file on disk. Returns nil if the token is not found or matches the
 // current in-memory token.
 func (s *ConfigStore) loadTokenFromDisk(scope Scope, providerID string) (*oauth.Token, error) {
+	return s.readTokenFromDisk(scope, fmt.Sprintf("providers.%s.oauth", providerID))
+}
+
+// readTokenFromDisk reads the OAuth token at key from the config file of
+// scope. Returns nil if the token is not found.
+func (s *ConfigStore) readTokenFromDisk(scope Scope, key string) (*oauth.Token, error) {
 	path, err := s.configPath(scope)
 	if err != nil {
 		return nil, err
@@ -400,8 +414,7 @@ func (s *ConfigStore) loadTokenFromDisk(scope Scope, providerID string) (*oauth.
 		return nil, err
 	}
 
-	oauthKey := fmt.Sprintf("providers.%s.oauth", providerID)
-	oauthResult := gjson.Get(string(data), oauthKey)
+	oauthResult := gjson.Get(string(data), key)
 	if !oauthResult.Exists() {
 		return nil, nil
 	}
@@ -418,6 +431,40 @@ func (s *ConfigStore) loadTokenFromDisk(scope Scope, providerID string) (*oauth.
 	return &token, nil
 }
 
+// SetMCPOAuthToken saves the OAuth token of the MCP server name, along with
+// the client it was issued to, and persists them.
+func (s *ConfigStore) SetMCPOAuthToken(name string, client *MCPOAuthClient, token *oauth.Token) error {
+	if err := s.SetConfigFields(s.mcpScope(name), map[string]any{
+		fmt.Sprintf("mcp.%s.oauth_client", name): client,
+		fmt.Sprintf("mcp.%s.oauth", name):        token,
+	}); err != nil {
+		return fmt.Errorf("failed to save oauth token of mcp %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadMCPOAuthToken reads the OAuth token of the MCP server name from the
+// config file on disk, where another Crush session may have refreshed it.
+// Returns nil if the token is not found.
+func (s *ConfigStore) LoadMCPOAuthToken(name string) (*oauth.Token, error) {
+	return s.readTokenFromDisk(s.mcpScope(name), fmt.Sprintf("mcp.%s.oauth", name))
+}
+
+// mcpScope returns the scope the OAuth state of the MCP server name is saved
+// in: the global one for servers of the global config, so every project
+// shares it, and the workspace one otherwise, which keeps tokens out of the
+// project config.
+func (s *ConfigStore) mcpScope(name string) Scope {
+	key := "mcp." + name
+	if s.workspacePath == "" || s.HasConfigField(ScopeGlobal, key) {
+		return ScopeGlobal
+	}
+	if data, err := os.ReadFile(GlobalConfig()); err == nil && gjson.GetBytes(data, key).Exists() {
+		return ScopeGlobal
+	}
+	return ScopeWorkspace
+}
+
 // recordRecentModel records a model in the recent models list.
 func (s *ConfigStore) recordRecentModel(scope Scope, modelType SelectedModelType, model SelectedModel) error {
 	if model.Provider == "" || model.Model == "" {
@@ -729,3 +729,32 @@ func TestRefreshOAuthToken_UsesDiskTokenWhenDifferent(t *testing.T) {
 	require.Equal(t, "newer-access-token", updatedConfig.OAuthToken.AccessToken)
 	require.Equal(t, "refresh-abc", updatedConfig.OAuthToken.RefreshToken)
 }
+
+func TestSetMCPOAuthToken_SavesWhereTheServerIsDefined(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "global.json")
+	workspacePath := filepath.Join(dir, "workspace.json")
+	require.NoError(t, os.WriteFile(globalPath, []byte(`{"mcp": {"global": {"type": "http"}}}`), 0o600))
+
+	store := &ConfigStore{
+		config:         &Config{},
+		globalDataPath: globalPath,
+		workspacePath:  workspacePath,
+	}
+	client := &MCPOAuthClient{ClientID: "client"}
+
+	for name, path := range map[string]string{"global": globalPath, "project": workspacePath} {
+		token := &oauth.Token{AccessToken: name + "-token", RefreshToken: "refresh"}
+		require.NoError(t, store.SetMCPOAuthToken(name, client, token))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(data), `"client_id":"client"`)
+
+		loaded, err := store.LoadMCPOAuthToken(name)
+		require.NoError(t, err)
+		require.Equal(t, token, loaded)
+	}
+}
@@ -148,6 +148,15 @@ func ResetPool() {
 	}
 }
 
+// SchemaVersion returns the version of the last migration applied to the
+// database.
+func SchemaVersion(ctx context.Context, conn *sql.DB) (int64, error) {
+	if err := initGoose(); err != nil {
+		return 0, fmt.Errorf("failed to initialize goose: %w", err)
+	}
+	return goose.GetDBVersionContext(ctx, conn)
+}
+
 func initGoose() error {
 	gooseInitOnce.Do(func() {
 		goose.SetBaseFS(FS)
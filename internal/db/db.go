@@ -24,9 +24,24 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.copyFileStmt, err = db.PrepareContext(ctx, copyFile); err != nil {
+		return nil, fmt.Errorf("error preparing query CopyFile: %w", err)
+	}
+	if q.copyMessageStmt, err = db.PrepareContext(ctx, copyMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query CopyMessage: %w", err)
+	}
+	if q.copyMessageIndexStmt, err = db.PrepareContext(ctx, copyMessageIndex); err != nil {
+		return nil, fmt.Errorf("error preparing query CopyMessageIndex: %w", err)
+	}
+	if q.copySessionReadFilesStmt, err = db.PrepareContext(ctx, copySessionReadFiles); err != nil {
+		return nil, fmt.Errorf("error preparing query CopySessionReadFiles: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateFile: %w", err)
 	}
+	if q.createForkedSessionStmt, err = db.PrepareContext(ctx, createForkedSession); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateForkedSession: %w", err)
+	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
@@ -36,6 +51,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createUsageLogStmt, err = db.PrepareContext(ctx, createUsageLog); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUsageLog: %w", err)
+	}
 	if q.deleteFileStmt, err = db.PrepareContext(ctx, deleteFile); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteFile: %w", err)
 	}
@@ -81,6 +99,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.getSessionUsageTotalStmt, err = db.PrepareContext(ctx, getSessionUsageTotal); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionUsageTotal: %w", err)
+	}
 	if q.getToolUsageStmt, err = db.PrepareContext(ctx, getToolUsage); err != nil {
 		return nil, fmt.Errorf("error preparing query GetToolUsage: %w", err)
 	}
@@ -99,9 +120,21 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getUsageByModelStmt, err = db.PrepareContext(ctx, getUsageByModel); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUsageByModel: %w", err)
 	}
+	if q.getUsageTotalSinceStmt, err = db.PrepareContext(ctx, getUsageTotalSince); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUsageTotalSince: %w", err)
+	}
+	if q.importSessionStmt, err = db.PrepareContext(ctx, importSession); err != nil {
+		return nil, fmt.Errorf("error preparing query ImportSession: %w", err)
+	}
+	if q.indexMessageStmt, err = db.PrepareContext(ctx, indexMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query IndexMessage: %w", err)
+	}
 	if q.listAllUserMessagesStmt, err = db.PrepareContext(ctx, listAllUserMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAllUserMessages: %w", err)
 	}
+	if q.listChildSessionsStmt, err = db.PrepareContext(ctx, listChildSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChildSessions: %w", err)
+	}
 	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
 		return nil, fmt.Errorf("error preparing query ListFilesByPath: %w", err)
 	}
@@ -138,6 +171,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.renameSessionStmt, err = db.PrepareContext(ctx, renameSession); err != nil {
 		return nil, fmt.Errorf("error preparing query RenameSession: %w", err)
 	}
+	if q.searchMessagesStmt, err = db.PrepareContext(ctx, searchMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchMessages: %w", err)
+	}
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
 	}
@@ -152,11 +188,36 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.copyFileStmt != nil {
+		if cerr := q.copyFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing copyFileStmt: %w", cerr)
+		}
+	}
+	if q.copyMessageStmt != nil {
+		if cerr := q.copyMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing copyMessageStmt: %w", cerr)
+		}
+	}
+	if q.copyMessageIndexStmt != nil {
+		if cerr := q.copyMessageIndexStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing copyMessageIndexStmt: %w", cerr)
+		}
+	}
+	if q.copySessionReadFilesStmt != nil {
+		if cerr := q.copySessionReadFilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing copySessionReadFilesStmt: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createFileStmt: %w", cerr)
 		}
 	}
+	if q.createForkedSessionStmt != nil {
+		if cerr := q.createForkedSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createForkedSessionStmt: %w", cerr)
+		}
+	}
 	if q.createMessageStmt != nil {
 		if cerr := q.createMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
@@ -172,6 +233,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createUsageLogStmt != nil {
+		if cerr := q.createUsageLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUsageLogStmt: %w", cerr)
+		}
+	}
 	if q.deleteFileStmt != nil {
 		if cerr := q.deleteFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteFileStmt: %w", cerr)
@@ -247,6 +313,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.getSessionUsageTotalStmt != nil {
+		if cerr := q.getSessionUsageTotalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionUsageTotalStmt: %w", cerr)
+		}
+	}
 	if q.getToolUsageStmt != nil {
 		if cerr := q.getToolUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getToolUsageStmt: %w", cerr)
@@ -277,11 +348,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUsageByModelStmt: %w", cerr)
 		}
 	}
+	if q.getUsageTotalSinceStmt != nil {
+		if cerr := q.getUsageTotalSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUsageTotalSinceStmt: %w", cerr)
+		}
+	}
+	if q.importSessionStmt != nil {
+		if cerr := q.importSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing importSessionStmt: %w", cerr)
+		}
+	}
+	if q.indexMessageStmt != nil {
+		if cerr := q.indexMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing indexMessageStmt: %w", cerr)
+		}
+	}
 	if q.listAllUserMessagesStmt != nil {
 		if cerr := q.listAllUserMessagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAllUserMessagesStmt: %w", cerr)
 		}
 	}
+	if q.listChildSessionsStmt != nil {
+		if cerr := q.listChildSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChildSessionsStmt: %w", cerr)
+		}
+	}
 	if q.listFilesByPathStmt != nil {
 		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listFilesByPathStmt: %w", cerr)
@@ -342,6 +433,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing renameSessionStmt: %w", cerr)
 		}
 	}
+	if q.searchMessagesStmt != nil {
+		if cerr := q.searchMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchMessagesStmt: %w", cerr)
+		}
+	}
 	if q.updateMessageStmt != nil {
 		if cerr := q.updateMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
@@ -396,10 +492,16 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                             DBTX
 	tx                             *sql.Tx
+	copyFileStmt                   *sql.Stmt
+	copyMessageStmt                *sql.Stmt
+	copyMessageIndexStmt           *sql.Stmt
+	copySessionReadFilesStmt       *sql.Stmt
 	createFileStmt                 *sql.Stmt
+	createForkedSessionStmt        *sql.Stmt
 	createMessageStmt              *sql.Stmt
 	createPermissionRuleStmt       *sql.Stmt
 	createSessionStmt              *sql.Stmt
+	createUsageLogStmt             *sql.Stmt
 	deleteFileStmt                 *sql.Stmt
 	deleteMessageStmt              *sql.Stmt
 	deletePermissionRuleStmt       *sql.Stmt
@@ -415,13 +517,18 @@ type Queries struct {
 	getMessageStmt                 *sql.Stmt
 	getRecentActivityStmt          *sql.Stmt
 	getSessionByIDStmt             *sql.Stmt
+	getSessionUsageTotalStmt       *sql.Stmt
 	getToolUsageStmt               *sql.Stmt
 	getTotalStatsStmt              *sql.Stmt
 	getUsageByDayStmt              *sql.Stmt
 	getUsageByDayOfWeekStmt        *sql.Stmt
 	getUsageByHourStmt             *sql.Stmt
 	getUsageByModelStmt            *sql.Stmt
+	getUsageTotalSinceStmt         *sql.Stmt
+	importSessionStmt              *sql.Stmt
+	indexMessageStmt               *sql.Stmt
 	listAllUserMessagesStmt        *sql.Stmt
+	listChildSessionsStmt          *sql.Stmt
 	listFilesByPathStmt            *sql.Stmt
 	listFilesBySessionStmt         *sql.Stmt
 	listLatestSessionFilesStmt     *sql.Stmt
@@ -434,6 +541,7 @@ type Queries struct {
 	matchPermissionRuleStmt        *sql.Stmt
 	recordFileReadStmt             *sql.Stmt
 	renameSessionStmt              *sql.Stmt
+	searchMessagesStmt             *sql.Stmt
 	updateMessageStmt              *sql.Stmt
 	updateSessionStmt              *sql.Stmt
 	updateSessionTitleAndUsageStmt *sql.Stmt
@@ -443,10 +551,16 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
 		db:                             tx,
 		tx:                             tx,
+		copyFileStmt:                   q.copyFileStmt,
+		copyMessageStmt:                q.copyMessageStmt,
+		copyMessageIndexStmt:           q.copyMessageIndexStmt,
+		copySessionReadFilesStmt:       q.copySessionReadFilesStmt,
 		createFileStmt:                 q.createFileStmt,
+		createForkedSessionStmt:        q.createForkedSessionStmt,
 		createMessageStmt:              q.createMessageStmt,
 		createPermissionRuleStmt:       q.createPermissionRuleStmt,
 		createSessionStmt:              q.createSessionStmt,
+		createUsageLogStmt:             q.createUsageLogStmt,
 		deleteFileStmt:                 q.deleteFileStmt,
 		deleteMessageStmt:              q.deleteMessageStmt,
 		deletePermissionRuleStmt:       q.deletePermissionRuleStmt,
@@ -462,13 +576,18 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		getMessageStmt:                 q.getMessageStmt,
 		getRecentActivityStmt:          q.getRecentActivityStmt,
 		getSessionByIDStmt:             q.getSessionByIDStmt,
+		getSessionUsageTotalStmt:       q.getSessionUsageTotalStmt,
 		getToolUsageStmt:               q.getToolUsageStmt,
 		getTotalStatsStmt:              q.getTotalStatsStmt,
 		getUsageByDayStmt:              q.getUsageByDayStmt,
 		getUsageByDayOfWeekStmt:        q.getUsageByDayOfWeekStmt,
 		getUsageByHourStmt:             q.getUsageByHourStmt,
 		getUsageByModelStmt:            q.getUsageByModelStmt,
+		getUsageTotalSinceStmt:         q.getUsageTotalSinceStmt,
+		importSessionStmt:              q.importSessionStmt,
+		indexMessageStmt:               q.indexMessageStmt,
 		listAllUserMessagesStmt:        q.listAllUserMessagesStmt,
+		listChildSessionsStmt:          q.listChildSessionsStmt,
 		listFilesByPathStmt:            q.listFilesByPathStmt,
 		listFilesBySessionStmt:         q.listFilesBySessionStmt,
 		listLatestSessionFilesStmt:     q.listLatestSessionFilesStmt,
@@ -481,6 +600,7 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		matchPermissionRuleStmt:        q.matchPermissionRuleStmt,
 		recordFileReadStmt:             q.recordFileReadStmt,
 		renameSessionStmt:              q.renameSessionStmt,
+		searchMessagesStmt:             q.searchMessagesStmt,
 		updateMessageStmt:              q.updateMessageStmt,
 		updateSessionStmt:              q.updateSessionStmt,
 		updateSessionTitleAndUsageStmt: q.updateSessionTitleAndUsageStmt,
@@ -10,6 +10,70 @@ import (
 	"database/sql"
 )
 
+const copyMessage = `-- name: CopyMessage :exec
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    finished_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CopyMessageParams struct {
+	ID               string         `json:"id"`
+	SessionID        string         `json:"session_id"`
+	Role             string         `json:"role"`
+	Parts            string         `json:"parts"`
+	Model            sql.NullString `json:"model"`
+	Provider         sql.NullString `json:"provider"`
+	IsSummaryMessage int64          `json:"is_summary_message"`
+	FinishedAt       sql.NullInt64  `json:"finished_at"`
+	CreatedAt        int64          `json:"created_at"`
+	UpdatedAt        int64          `json:"updated_at"`
+}
+
+func (q *Queries) CopyMessage(ctx context.Context, arg CopyMessageParams) error {
+	_, err := q.exec(ctx, q.copyMessageStmt, copyMessage,
+		arg.ID,
+		arg.SessionID,
+		arg.Role,
+		arg.Parts,
+		arg.Model,
+		arg.Provider,
+		arg.IsSummaryMessage,
+		arg.FinishedAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const copyMessageIndex = `-- name: CopyMessageIndex :exec
+INSERT OR REPLACE INTO messages_fts (rowid, message_id, session_id, content)
+SELECT m.rowid, m.id, m.session_id, f.content
+FROM messages AS m, messages AS src
+JOIN messages_fts AS f ON f.rowid = src.rowid
+WHERE m.id = ? AND src.id = ?
+`
+
+type CopyMessageIndexParams struct {
+	ID       string `json:"id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) CopyMessageIndex(ctx context.Context, arg CopyMessageIndexParams) error {
+	_, err := q.exec(ctx, q.copyMessageIndexStmt, copyMessageIndex, arg.ID, arg.SourceID)
+	return err
+}
+
 const createMessage = `-- name: CreateMessage :one
 INSERT INTO messages (
     id,
@@ -107,6 +171,23 @@ func (q *Queries) GetMessage(ctx context.Context, id string) (Message, error) {
 	return i, err
 }
 
+const indexMessage = `-- name: IndexMessage :exec
+INSERT OR REPLACE INTO messages_fts (rowid, message_id, session_id, content)
+SELECT rowid, id, session_id, CAST(? AS TEXT)
+FROM messages
+WHERE id = ?
+`
+
+type IndexMessageParams struct {
+	Content string `json:"content"`
+	ID      string `json:"id"`
+}
+
+func (q *Queries) IndexMessage(ctx context.Context, arg IndexMessageParams) error {
+	_, err := q.exec(ctx, q.indexMessageStmt, indexMessage, arg.Content, arg.ID)
+	return err
+}
+
 const listAllUserMessages = `-- name: ListAllUserMessages :many
 SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
 FROM messages
@@ -230,6 +311,74 @@ func (q *Queries) ListUserMessagesBySession(ctx context.Context, sessionID strin
 	return items, nil
 }
 
+const searchMessages = `-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    s.title AS session_title,
+    m.role,
+    m.created_at,
+    CAST(snippet(messages_fts, 2, ?, ?, '…', 24) AS TEXT) AS snippet
+FROM messages_fts AS f
+JOIN messages AS m ON m.rowid = f.rowid AND m.id = f.message_id
+JOIN sessions AS s ON s.id = m.session_id
+WHERE messages_fts MATCH ?
+    AND s.parent_session_id IS NULL
+ORDER BY f.rank
+LIMIT ?
+`
+
+type SearchMessagesParams struct {
+	MatchStart interface{} `json:"match_start"`
+	MatchEnd   interface{} `json:"match_end"`
+	Query      interface{} `json:"query"`
+	Limit      int64       `json:"limit"`
+}
+
+type SearchMessagesRow struct {
+	ID           string `json:"id"`
+	SessionID    string `json:"session_id"`
+	SessionTitle string `json:"session_title"`
+	Role         string `json:"role"`
+	CreatedAt    int64  `json:"created_at"`
+	Snippet      string `json:"snippet"`
+}
+
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.query(ctx, q.searchMessagesStmt, searchMessages,
+		arg.MatchStart,
+		arg.MatchEnd,
+		arg.Query,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.SessionTitle,
+			&i.Role,
+			&i.CreatedAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateMessage = `-- name: UpdateMessage :exec
 UPDATE messages
 SET
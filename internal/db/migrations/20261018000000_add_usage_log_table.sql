@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS usage_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL CHECK (session_id != ''),
+    root_session_id TEXT NOT NULL CHECK (root_session_id != ''),
+    prompt_tokens INTEGER NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    completion_tokens INTEGER NOT NULL DEFAULT 0 CHECK (completion_tokens >= 0),
+    cost REAL NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_log_root_session_id ON usage_log (root_session_id);
+CREATE INDEX IF NOT EXISTS idx_usage_log_created_at ON usage_log (created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Carry over what sessions spent before the log existed, so budgets count
+-- it. The cost of a root session includes that of its sub-sessions, and its
+-- token counters only hold the last turn, so only its cost is logged.
+INSERT INTO usage_log (session_id, root_session_id, cost, created_at)
+SELECT id, id, cost, updated_at
+FROM sessions
+WHERE (parent_session_id IS NULL OR parent_session_id = '') AND cost > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_usage_log_created_at;
+DROP INDEX IF EXISTS idx_usage_log_root_session_id;
+DROP TABLE IF EXISTS usage_log;
+-- +goose StatementEnd
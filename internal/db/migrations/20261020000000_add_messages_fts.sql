@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Full-text index over the searchable text of messages: text content, tool
+-- call inputs and tool results. Rows share the rowid of their message and are
+-- written by the message service.
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5 (
+    message_id UNINDEXED,
+    session_id UNINDEXED,
+    content,
+    tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS delete_messages_fts
+AFTER DELETE ON messages
+BEGIN
+DELETE FROM messages_fts WHERE rowid = old.rowid;
+END;
+
+INSERT INTO messages_fts (rowid, message_id, session_id, content)
+SELECT m.rowid, m.id, m.session_id, (
+    SELECT group_concat(text, char(10))
+    FROM (
+        SELECT CASE json_extract(p.value, '$.type')
+            WHEN 'text' THEN json_extract(p.value, '$.data.text')
+            WHEN 'tool_call' THEN json_extract(p.value, '$.data.name') || ' ' || json_extract(p.value, '$.data.input')
+            WHEN 'tool_result' THEN json_extract(p.value, '$.data.content')
+        END AS text
+        FROM json_each(m.parts) AS p
+    )
+    WHERE text IS NOT NULL AND text != ''
+)
+FROM messages AS m
+WHERE json_valid(m.parts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS delete_messages_fts;
+DROP TABLE IF EXISTS messages_fts;
+-- +goose StatementEnd
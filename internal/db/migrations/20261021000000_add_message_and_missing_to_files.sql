@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE files ADD COLUMN message_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN missing INTEGER NOT NULL DEFAULT 0;
+-- Versions recorded before this can't tell a missing file from an empty
+-- one, so they're all kept as existing; rewinding over them restores an
+-- empty file rather than deleting one that may be needed.
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN missing;
+ALTER TABLE files DROP COLUMN message_id;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageLogMigrationBackfillsSessionCost(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, Release(dataDir))
+		ResetPool()
+	})
+
+	conn, err := Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	require.NoError(t, goose.DownToContext(t.Context(), conn, "migrations", 20260226000000))
+
+	_, err = conn.ExecContext(t.Context(), `INSERT INTO sessions (id, parent_session_id, title, cost, updated_at, created_at) VALUES
+		('root', NULL, 'root', 1.5, 100, 100),
+		('child', 'root', 'child', 0.5, 100, 100),
+		('free', NULL, 'free', 0, 100, 100)`)
+	require.NoError(t, err)
+	require.NoError(t, goose.UpContext(t.Context(), conn, "migrations"))
+
+	q := New(conn)
+	total, err := q.GetSessionUsageTotal(t.Context(), "root")
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, total.Cost, 1e-9)
+
+	since, err := q.GetUsageTotalSince(t.Context(), 0)
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, since.Cost, 1e-9)
+}
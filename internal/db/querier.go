@@ -6,13 +6,20 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 type Querier interface {
+	CopyFile(ctx context.Context, arg CopyFileParams) error
+	CopyMessage(ctx context.Context, arg CopyMessageParams) error
+	CopyMessageIndex(ctx context.Context, arg CopyMessageIndexParams) error
+	CopySessionReadFiles(ctx context.Context, arg CopySessionReadFilesParams) error
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
+	CreateForkedSession(ctx context.Context, arg CreateForkedSessionParams) (Session, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
 	CreatePermissionRule(ctx context.Context, arg CreatePermissionRuleParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateUsageLog(ctx context.Context, arg CreateUsageLogParams) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
 	DeletePermissionRule(ctx context.Context, id int64) error
@@ -28,13 +35,18 @@ type Querier interface {
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetRecentActivity(ctx context.Context) ([]GetRecentActivityRow, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	GetSessionUsageTotal(ctx context.Context, rootSessionID string) (GetSessionUsageTotalRow, error)
 	GetToolUsage(ctx context.Context) ([]GetToolUsageRow, error)
 	GetTotalStats(ctx context.Context) (GetTotalStatsRow, error)
 	GetUsageByDay(ctx context.Context) ([]GetUsageByDayRow, error)
 	GetUsageByDayOfWeek(ctx context.Context) ([]GetUsageByDayOfWeekRow, error)
 	GetUsageByHour(ctx context.Context) ([]GetUsageByHourRow, error)
 	GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, error)
+	GetUsageTotalSince(ctx context.Context, createdAt int64) (GetUsageTotalSinceRow, error)
+	ImportSession(ctx context.Context, arg ImportSessionParams) error
+	IndexMessage(ctx context.Context, arg IndexMessageParams) error
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
+	ListChildSessions(ctx context.Context, parentSessionID sql.NullString) ([]Session, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
@@ -47,6 +59,7 @@ type Querier interface {
 	MatchPermissionRule(ctx context.Context, arg MatchPermissionRuleParams) (int64, error)
 	RecordFileRead(ctx context.Context, arg RecordFileReadParams) error
 	RenameSession(ctx context.Context, arg RenameSessionParams) error
+	SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
 	UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSessionTitleAndUsageParams) error
@@ -9,6 +9,24 @@ import (
 	"context"
 )
 
+const copySessionReadFiles = `-- name: CopySessionReadFiles :exec
+INSERT INTO read_files (session_id, path, read_at)
+SELECT CAST(? AS TEXT), path, read_at
+FROM read_files
+WHERE session_id = ? AND read_at <= ?
+`
+
+type CopySessionReadFilesParams struct {
+	NewSessionID string `json:"new_session_id"`
+	SessionID    string `json:"session_id"`
+	Before       int64  `json:"before"`
+}
+
+func (q *Queries) CopySessionReadFiles(ctx context.Context, arg CopySessionReadFilesParams) error {
+	_, err := q.exec(ctx, q.copySessionReadFilesStmt, copySessionReadFiles, arg.NewSessionID, arg.SessionID, arg.Before)
+	return err
+}
+
 const getFileRead = `-- name: GetFileRead :one
 SELECT session_id, path, read_at FROM read_files
 WHERE session_id = ? AND path = ? LIMIT 1
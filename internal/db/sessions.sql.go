@@ -10,6 +10,61 @@ import (
 	"database/sql"
 )
 
+const createForkedSession = `-- name: CreateForkedSession :one
+INSERT INTO sessions (
+    id,
+    title,
+    todos,
+    forked_from_session_id,
+    forked_at_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now'),
+    strftime('%s', 'now')
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
+`
+
+type CreateForkedSessionParams struct {
+	ID                  string         `json:"id"`
+	Title               string         `json:"title"`
+	Todos               sql.NullString `json:"todos"`
+	ForkedFromSessionID sql.NullString `json:"forked_from_session_id"`
+	ForkedAtMessageID   sql.NullString `json:"forked_at_message_id"`
+}
+
+func (q *Queries) CreateForkedSession(ctx context.Context, arg CreateForkedSessionParams) (Session, error) {
+	row := q.queryRow(ctx, q.createForkedSessionStmt, createForkedSession,
+		arg.ID,
+		arg.Title,
+		arg.Todos,
+		arg.ForkedFromSessionID,
+		arg.ForkedAtMessageID,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.ForkedFromSessionID,
+		&i.ForkedAtMessageID,
+	)
+	return i, err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -33,7 +88,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
 `
 
 type CreateSessionParams struct {
@@ -69,6 +124,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ForkedFromSessionID,
+		&i.ForkedAtMessageID,
 	)
 	return i, err
 }
@@ -84,7 +141,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getLastSession = `-- name: GetLastSession :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
 FROM sessions
 ORDER BY updated_at DESC
 LIMIT 1
@@ -105,12 +162,14 @@ func (q *Queries) GetLastSession(ctx context.Context) (Session, error) {
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ForkedFromSessionID,
+		&i.ForkedAtMessageID,
 	)
 	return i, err
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -130,12 +189,110 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ForkedFromSessionID,
+		&i.ForkedAtMessageID,
 	)
 	return i, err
 }
 
+const importSession = `-- name: ImportSession :exec
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    title,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    summary_message_id,
+    todos,
+    forked_from_session_id,
+    forked_at_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type ImportSessionParams struct {
+	ID                  string         `json:"id"`
+	ParentSessionID     sql.NullString `json:"parent_session_id"`
+	Title               string         `json:"title"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	Cost                float64        `json:"cost"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	Todos               sql.NullString `json:"todos"`
+	ForkedFromSessionID sql.NullString `json:"forked_from_session_id"`
+	ForkedAtMessageID   sql.NullString `json:"forked_at_message_id"`
+	UpdatedAt           int64          `json:"updated_at"`
+	CreatedAt           int64          `json:"created_at"`
+}
+
+func (q *Queries) ImportSession(ctx context.Context, arg ImportSessionParams) error {
+	_, err := q.exec(ctx, q.importSessionStmt, importSession,
+		arg.ID,
+		arg.ParentSessionID,
+		arg.Title,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.Cost,
+		arg.SummaryMessageID,
+		arg.Todos,
+		arg.ForkedFromSessionID,
+		arg.ForkedAtMessageID,
+		arg.UpdatedAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const listChildSessions = `-- name: ListChildSessions :many
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListChildSessions(ctx context.Context, parentSessionID sql.NullString) ([]Session, error) {
+	rows, err := q.query(ctx, q.listChildSessionsStmt, listChildSessions, parentSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentSessionID,
+			&i.Title,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.SummaryMessageID,
+			&i.Todos,
+			&i.ForkedFromSessionID,
+			&i.ForkedAtMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY updated_at DESC
@@ -162,6 +319,8 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.ForkedFromSessionID,
+			&i.ForkedAtMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -203,7 +362,7 @@ SET
     cost = ?,
     todos = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, forked_from_session_id, forked_at_message_id
 `
 
 type UpdateSessionParams struct {
@@ -239,6 +398,8 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ForkedFromSessionID,
+		&i.ForkedAtMessageID,
 	)
 	return i, err
 }
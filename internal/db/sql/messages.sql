@@ -25,6 +25,22 @@ INSERT INTO messages (
 )
 RETURNING *;
 
+-- name: CopyMessage :exec
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    finished_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: UpdateMessage :exec
 UPDATE messages
 SET
@@ -53,3 +69,32 @@ SELECT *
 FROM messages
 WHERE role = 'user'
 ORDER BY created_at DESC;
+
+-- name: IndexMessage :exec
+INSERT OR REPLACE INTO messages_fts (rowid, message_id, session_id, content)
+SELECT rowid, id, session_id, CAST(sqlc.arg(content) AS TEXT)
+FROM messages
+WHERE id = sqlc.arg(id);
+
+-- name: CopyMessageIndex :exec
+INSERT OR REPLACE INTO messages_fts (rowid, message_id, session_id, content)
+SELECT m.rowid, m.id, m.session_id, f.content
+FROM messages AS m, messages AS src
+JOIN messages_fts AS f ON f.rowid = src.rowid
+WHERE m.id = sqlc.arg(id) AND src.id = sqlc.arg(source_id);
+
+-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    s.title AS session_title,
+    m.role,
+    m.created_at,
+    CAST(snippet(messages_fts, 2, sqlc.arg(match_start), sqlc.arg(match_end), '…', 24) AS TEXT) AS snippet
+FROM messages_fts AS f
+JOIN messages AS m ON m.rowid = f.rowid AND m.id = f.message_id
+JOIN sessions AS s ON s.id = m.session_id
+WHERE messages_fts MATCH sqlc.arg(query)
+    AND s.parent_session_id IS NULL
+ORDER BY f.rank
+LIMIT sqlc.arg(limit);
@@ -18,3 +18,9 @@ WHERE session_id = ? AND path = ? LIMIT 1;
 SELECT * FROM read_files
 WHERE session_id = ?
 ORDER BY read_at DESC;
+
+-- name: CopySessionReadFiles :exec
+INSERT INTO read_files (session_id, path, read_at)
+SELECT CAST(sqlc.arg(new_session_id) AS TEXT), path, read_at
+FROM read_files
+WHERE session_id = sqlc.arg(session_id) AND read_at <= sqlc.arg(before);
@@ -23,6 +23,43 @@ INSERT INTO sessions (
     strftime('%s', 'now')
 ) RETURNING *;
 
+-- name: CreateForkedSession :one
+INSERT INTO sessions (
+    id,
+    title,
+    todos,
+    forked_from_session_id,
+    forked_at_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now'),
+    strftime('%s', 'now')
+) RETURNING *;
+
+-- name: ImportSession :exec
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    title,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    summary_message_id,
+    todos,
+    forked_from_session_id,
+    forked_at_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: GetSessionByID :one
 SELECT *
 FROM sessions
@@ -40,6 +77,12 @@ FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY updated_at DESC;
 
+-- name: ListChildSessions :many
+SELECT *
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC;
+
 -- name: UpdateSession :one
 UPDATE sessions
 SET
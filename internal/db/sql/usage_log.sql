@@ -0,0 +1,32 @@
+-- name: CreateUsageLog :exec
+INSERT INTO usage_log (
+    session_id,
+    root_session_id,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+);
+
+-- name: GetSessionUsageTotal :one
+SELECT
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) as prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) as completion_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) as cost
+FROM usage_log
+WHERE root_session_id = ?;
+
+-- name: GetUsageTotalSince :one
+SELECT
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) as prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) as completion_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) as cost
+FROM usage_log
+WHERE created_at >= ?;
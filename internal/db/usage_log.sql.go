@@ -0,0 +1,91 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: usage_log.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createUsageLog = `-- name: CreateUsageLog :exec
+INSERT INTO usage_log (
+    session_id,
+    root_session_id,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+)
+`
+
+type CreateUsageLogParams struct {
+	SessionID        string  `json:"session_id"`
+	RootSessionID    string  `json:"root_session_id"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (q *Queries) CreateUsageLog(ctx context.Context, arg CreateUsageLogParams) error {
+	_, err := q.exec(ctx, q.createUsageLogStmt, createUsageLog,
+		arg.SessionID,
+		arg.RootSessionID,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.Cost,
+	)
+	return err
+}
+
+const getSessionUsageTotal = `-- name: GetSessionUsageTotal :one
+SELECT
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) as prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) as completion_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) as cost
+FROM usage_log
+WHERE root_session_id = ?
+`
+
+type GetSessionUsageTotalRow struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (q *Queries) GetSessionUsageTotal(ctx context.Context, rootSessionID string) (GetSessionUsageTotalRow, error) {
+	row := q.queryRow(ctx, q.getSessionUsageTotalStmt, getSessionUsageTotal, rootSessionID)
+	var i GetSessionUsageTotalRow
+	err := row.Scan(&i.PromptTokens, &i.CompletionTokens, &i.Cost)
+	return i, err
+}
+
+const getUsageTotalSince = `-- name: GetUsageTotalSince :one
+SELECT
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) as prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) as completion_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) as cost
+FROM usage_log
+WHERE created_at >= ?
+`
+
+type GetUsageTotalSinceRow struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (q *Queries) GetUsageTotalSince(ctx context.Context, createdAt int64) (GetUsageTotalSinceRow, error) {
+	row := q.queryRow(ctx, q.getUsageTotalSinceStmt, getUsageTotalSince, createdAt)
+	var i GetUsageTotalSinceRow
+	err := row.Scan(&i.PromptTokens, &i.CompletionTokens, &i.Cost)
+	return i, err
+}
@@ -29,6 +29,10 @@ func SessionDeleted() {
 	send("session deleted")
 }
 
+func SessionForked() {
+	send("session forked")
+}
+
 func SessionSwitched() {
 	send("session switched")
 }
@@ -81,3 +85,7 @@ func SessionDeletedCommand(json bool) {
 func SessionRenamed(json bool) {
 	send("session renamed", "json", json)
 }
+
+func SessionRewound(json bool) {
+	send("session rewound", "json", json)
+}
@@ -0,0 +1,232 @@
+// Package format formats files after the agent writes them, using the
+// commands or LSP servers configured per file type.
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// Formatter formats files with the formatters configured for their file
+// type. A nil Formatter formats nothing.
+type Formatter struct {
+	formatters map[string]config.FormatterConfig
+	lspManager *lsp.Manager
+	workingDir string
+}
+
+// New creates a Formatter. It returns nil if no formatters are
+// configured.
+func New(formatters map[string]config.FormatterConfig, lspManager *lsp.Manager, workingDir string) *Formatter {
+	if len(formatters) == 0 {
+		return nil
+	}
+	return &Formatter{
+		formatters: formatters,
+		lspManager: lspManager,
+		workingDir: workingDir,
+	}
+}
+
+// Format formats the file at path in place and reports whether its
+// content changed. Files without a formatter are left alone.
+func (f *Formatter) Format(ctx context.Context, path string) (bool, error) {
+	cfg, ok := f.formatterFor(path)
+	if !ok {
+		return false, nil
+	}
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.TimeoutDuration())
+	defer cancel()
+
+	switch {
+	case cfg.Command != "":
+		err = f.runCommand(ctx, cfg.Command, path)
+	case cfg.LSP:
+		err = f.formatWithLSP(ctx, path)
+	}
+	if err == nil && len(cfg.CodeActions) > 0 {
+		err = f.applyCodeActions(ctx, path, cfg.CodeActions)
+	}
+
+	after, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return false, errors.Join(err, readErr)
+	}
+	return !bytes.Equal(before, after), err
+}
+
+func (f *Formatter) formatterFor(path string) (config.FormatterConfig, bool) {
+	if f == nil {
+		return config.FormatterConfig{}, false
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	cfg, ok := f.formatters[ext]
+	if !ok || cfg.Disabled {
+		return config.FormatterConfig{}, false
+	}
+	return cfg, true
+}
+
+// runCommand runs a formatter command through Crush's embedded shell, the
+// same way hooks are run.
+func (f *Formatter) runCommand(ctx context.Context, command, path string) error {
+	env := append(os.Environ(), shell.CrushEnvMarkers()...)
+	env = append(env,
+		fmt.Sprintf("CRUSH_FILE_PATH=%s", path),
+		fmt.Sprintf("CRUSH_CWD=%s", f.workingDir),
+	)
+
+	var stderr bytes.Buffer
+	err := shell.Run(ctx, shell.RunOptions{
+		Command: command,
+		Cwd:     f.workingDir,
+		Env:     env,
+		Stderr:  &stderr,
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
+
+func (f *Formatter) formatWithLSP(ctx context.Context, path string) error {
+	client, err := f.client(ctx, path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	edits, err := client.Format(ctx, path, formattingOptions(string(content)))
+	if err != nil {
+		return err
+	}
+	return applyEdits(ctx, client, path, string(content), edits)
+}
+
+// applyCodeActions applies the first code action of each kind the server
+// offers for the whole file, such as source.organizeImports.
+func (f *Formatter) applyCodeActions(ctx context.Context, path string, kinds []string) error {
+	client, err := f.client(ctx, path)
+	if err != nil {
+		return err
+	}
+	for _, kind := range kinds {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		actions, err := client.CodeActions(ctx, path, documentRange(string(content)), protocol.CodeActionKind(kind))
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			if action.Disabled != nil {
+				continue
+			}
+			if action.Edit == nil && action.Data != nil {
+				if action, err = client.ResolveCodeAction(ctx, action); err != nil {
+					return err
+				}
+			}
+			if action.Edit == nil {
+				continue
+			}
+			editsByPath, err := util.TextEditsByPath(*action.Edit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", kind, err)
+			}
+			if err := applyEdits(ctx, client, path, string(content), editsByPath[path]); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// client returns the LSP client for path with the file's current content
+// synced to it.
+func (f *Formatter) client(ctx context.Context, path string) (*lsp.Client, error) {
+	if f.lspManager == nil {
+		return nil, errors.New("no LSP clients available")
+	}
+	f.lspManager.Start(ctx, path)
+	for client := range f.lspManager.Clients().Seq() {
+		if !client.HandlesFile(path) {
+			continue
+		}
+		if err := client.OpenFileOnDemand(ctx, path); err != nil {
+			return nil, err
+		}
+		_ = client.NotifyChange(ctx, path)
+		return client, nil
+	}
+	return nil, fmt.Errorf("no LSP server handles %s", path)
+}
+
+func applyEdits(ctx context.Context, client *lsp.Client, path, content string, edits []protocol.TextEdit) error {
+	if len(edits) == 0 {
+		return nil
+	}
+	newContent, err := util.ApplyTextEdits(content, edits, client.OffsetEncoding())
+	if err != nil {
+		return err
+	}
+	if newContent == content {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return err
+	}
+	return client.NotifyChange(ctx, path)
+}
+
+// formattingOptions guesses the file's indentation from its first indented
+// line, since servers such as prettier's need it.
+func formattingOptions(content string) protocol.FormattingOptions {
+	opts := protocol.FormattingOptions{TabSize: 4, InsertSpaces: true}
+	for line := range strings.SplitSeq(content, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			opts.InsertSpaces = false
+			break
+		}
+		if indent := len(line) - len(strings.TrimLeft(line, " ")); indent > 0 && indent < len(line) {
+			if indent == 2 {
+				opts.TabSize = 2
+			}
+			break
+		}
+	}
+	return opts
+}
+
+// documentRange returns the range covering all of content.
+func documentRange(content string) protocol.Range {
+	lines := strings.Split(content, "\n")
+	last := len(lines) - 1
+	return protocol.Range{
+		End: protocol.Position{Line: uint32(last), Character: uint32(len(lines[last]))}, //nolint:gosec
+	}
+}
@@ -0,0 +1,146 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(nil, nil, t.TempDir()))
+	require.NotNil(t, New(map[string]config.FormatterConfig{"go": {LSP: true}}, nil, t.TempDir()))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("command", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.txt")
+		require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{
+			"txt": {Command: `printf 'HELLO\n' > "$CRUSH_FILE_PATH"`},
+		}, nil, dir)
+		changed, err := f.Format(t.Context(), path)
+		require.NoError(t, err)
+		require.True(t, changed)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "HELLO\n", string(content))
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.txt")
+		require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{
+			"txt": {Command: "true"},
+		}, nil, dir)
+		changed, err := f.Format(t.Context(), path)
+		require.NoError(t, err)
+		require.False(t, changed)
+	})
+
+	t.Run("command failure", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.txt")
+		require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{
+			"txt": {Command: "echo bad syntax >&2; exit 1"},
+		}, nil, dir)
+		_, err := f.Format(t.Context(), path)
+		require.ErrorContains(t, err, "bad syntax")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.txt")
+		require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{
+			"txt": {Command: "exit 1", Disabled: true},
+		}, nil, dir)
+		changed, err := f.Format(t.Context(), path)
+		require.NoError(t, err)
+		require.False(t, changed)
+	})
+
+	t.Run("other file type", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.go")
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{
+			"txt": {Command: "exit 1"},
+		}, nil, dir)
+		changed, err := f.Format(t.Context(), path)
+		require.NoError(t, err)
+		require.False(t, changed)
+	})
+
+	t.Run("nil formatter", func(t *testing.T) {
+		t.Parallel()
+		var f *Formatter
+		changed, err := f.Format(t.Context(), filepath.Join(t.TempDir(), "main.go"))
+		require.NoError(t, err)
+		require.False(t, changed)
+	})
+
+	t.Run("lsp without clients", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "main.go")
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+		f := New(map[string]config.FormatterConfig{"go": {LSP: true}}, nil, dir)
+		_, err := f.Format(t.Context(), path)
+		require.ErrorContains(t, err, "no LSP clients available")
+	})
+}
+
+func TestFormattingOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		content      string
+		tabSize      uint32
+		insertSpaces bool
+	}{
+		{"tabs", "func main() {\n\tfoo()\n}\n", 4, false},
+		{"two spaces", "a:\n  b: 1\n", 2, true},
+		{"four spaces", "def f():\n    pass\n", 4, true},
+		{"unindented", "hello\nworld\n", 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := formattingOptions(tt.content)
+			require.Equal(t, tt.tabSize, opts.TabSize)
+			require.Equal(t, tt.insertSpaces, opts.InsertSpaces)
+		})
+	}
+}
+
+func TestDocumentRange(t *testing.T) {
+	t.Parallel()
+
+	rng := documentRange("a\nbc\ndef")
+	require.Equal(t, uint32(0), rng.Start.Line)
+	require.Equal(t, uint32(2), rng.End.Line)
+	require.Equal(t, uint32(3), rng.End.Character)
+}
@@ -21,10 +21,34 @@ type File struct {
 	Path      string
 	Content   string
 	Version   int64
+	// MessageID is the message whose tool call wrote the version, if any.
+	MessageID string
+	// Missing reports whether the file did not exist at this version.
+	Missing   bool
 	CreatedAt int64
 	UpdatedAt int64
 }
 
+type messageIDContextKey struct{}
+
+// WithMessageID returns a copy of ctx attributing the versions created with
+// it to the message with the given ID.
+func WithMessageID(ctx context.Context, messageID string) context.Context {
+	return context.WithValue(ctx, messageIDContextKey{}, messageID)
+}
+
+// WithoutMessageID returns a copy of ctx attributing the versions created
+// with it to no message. It is used for content the agent found on disk
+// rather than wrote, so rewinding a message never skips past it.
+func WithoutMessageID(ctx context.Context) context.Context {
+	return WithMessageID(ctx, "")
+}
+
+func messageIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(messageIDContextKey{}).(string)
+	return id
+}
+
 // Service manages file versions and history for sessions.
 type Service interface {
 	pubsub.Subscriber[File]
@@ -32,6 +56,9 @@ type Service interface {
 
 	// CreateVersion creates a new version of a file.
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
+	// CreateMissing creates a new version of a file recording that it does
+	// not exist.
+	CreateMissing(ctx context.Context, sessionID, path string) (File, error)
 
 	Get(ctx context.Context, id string) (File, error)
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
@@ -56,13 +83,23 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
 }
 
 // CreateVersion creates a new version of a file with auto-incremented version
 // number. If no previous versions exist for the path, it creates the initial
 // version. The provided content is stored as the new version.
 func (s *service) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
+	return s.createNextVersion(ctx, sessionID, path, content, false)
+}
+
+// CreateMissing creates a new version of a file, as CreateVersion does,
+// recording that the file does not exist.
+func (s *service) CreateMissing(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createNextVersion(ctx, sessionID, path, "", true)
+}
+
+func (s *service) createNextVersion(ctx context.Context, sessionID, path, content string, missing bool) (File, error) {
 	// Get the latest version for this path
 	files, err := s.q.ListFilesByPath(ctx, path)
 	if err != nil {
@@ -71,17 +108,17 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 
 	if len(files) == 0 {
 		// No previous versions, create initial
-		return s.Create(ctx, sessionID, path, content)
+		return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, missing)
 	}
 
 	// Get the latest version
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, missing)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64, missing bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -105,6 +142,8 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			MessageID: messageIDFromContext(ctx),
+			Missing:   boolToInt(missing),
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -211,7 +250,16 @@ func (s *service) fromDBItem(item db.File) File {
 		Path:      item.Path,
 		Content:   item.Content,
 		Version:   item.Version,
+		MessageID: item.MessageID,
+		Missing:   item.Missing != 0,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
 	}
 }
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
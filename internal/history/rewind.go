@@ -0,0 +1,191 @@
+package history
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ErrRewindConflict is returned by [Rewind] when one or more files were
+// modified outside of Crush since the agent last wrote them and the rewind
+// was not forced.
+var ErrRewindConflict = errors.New("files were modified outside of crush")
+
+// RewindAction describes what a rewind does to a single file.
+type RewindAction string
+
+const (
+	// RewindRestore writes a previous version back to disk.
+	RewindRestore RewindAction = "restore"
+	// RewindDelete removes a file that did not exist at the rewind point.
+	RewindDelete RewindAction = "delete"
+)
+
+// RewindChange is a single file touched by a rewind.
+type RewindChange struct {
+	Path    string
+	Action  RewindAction
+	Content string
+	// Conflict reports whether the file on disk differs from the last
+	// version the agent wrote, meaning someone else changed it since.
+	Conflict bool
+}
+
+// RewindOptions controls how [Rewind] behaves.
+type RewindOptions struct {
+	// Force overwrites files even if they were changed outside of Crush.
+	Force bool
+	// DryRun computes the changes without touching the disk.
+	DryRun bool
+}
+
+// RewindResult is the outcome of a [Rewind].
+type RewindResult struct {
+	Changes []RewindChange
+	Applied bool
+}
+
+// Conflicts returns the paths of files that were changed outside of Crush.
+func (r RewindResult) Conflicts() []string {
+	var paths []string
+	for _, c := range r.Changes {
+		if c.Conflict {
+			paths = append(paths, c.Path)
+		}
+	}
+	return paths
+}
+
+// Rewind rolls the files touched by a session back to the state they were
+// in before the given messages, which are the message rewound to and those
+// after it. Versions are taken in the order they were recorded: from the
+// first one written by any of the messages, every version of a file is
+// undone, and the file is restored to the version recorded just before, or
+// to the content the agent first saw if the messages wrote the first
+// version too. Files that did not exist at that point are deleted.
+//
+// Unless opts.Force is set, Rewind refuses to touch anything if a file on
+// disk no longer matches the last version the agent wrote, returning
+// [ErrRewindConflict] along with the computed changes. Every restored file
+// gets a new version so later edits and rewinds start from the right
+// baseline.
+func Rewind(ctx context.Context, svc Service, sessionID string, messageIDs []string, opts RewindOptions) (RewindResult, error) {
+	files, err := svc.ListBySession(ctx, sessionID)
+	if err != nil {
+		return RewindResult{}, fmt.Errorf("failed to list session files: %w", err)
+	}
+
+	undone := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		undone[id] = true
+	}
+
+	byPath := make(map[string][]File)
+	var paths []string
+	for _, f := range files {
+		if _, ok := byPath[f.Path]; !ok {
+			paths = append(paths, f.Path)
+		}
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+	slices.Sort(paths)
+
+	var result RewindResult
+	for _, path := range paths {
+		versions := byPath[path]
+		slices.SortFunc(versions, func(a, b File) int {
+			return cmp.Compare(a.Version, b.Version)
+		})
+
+		first := slices.IndexFunc(versions, func(v File) bool {
+			return v.MessageID != "" && undone[v.MessageID]
+		})
+		if first < 0 {
+			// Nothing was written to this file after the rewind point.
+			continue
+		}
+		target := versions[max(first-1, 0)]
+		latest := versions[len(versions)-1]
+
+		current, exists, err := readCurrent(path)
+		if err != nil {
+			return RewindResult{}, err
+		}
+		if exists == !target.Missing && current == target.Content {
+			continue
+		}
+
+		change := RewindChange{
+			Path:     path,
+			Action:   RewindRestore,
+			Content:  target.Content,
+			Conflict: exists == latest.Missing || current != latest.Content,
+		}
+		if target.Missing {
+			change.Action = RewindDelete
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if !opts.Force && len(result.Conflicts()) > 0 {
+		return result, ErrRewindConflict
+	}
+
+	for _, change := range result.Changes {
+		if err := applyRewindChange(change); err != nil {
+			return result, err
+		}
+		if err := recordRewindChange(ctx, svc, sessionID, change); err != nil {
+			return result, fmt.Errorf("failed to record rewound version of %s: %w", change.Path, err)
+		}
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// readCurrent returns the content of the file at path, and whether it
+// exists. A missing file reads as empty content.
+func readCurrent(path string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), true, nil
+}
+
+func recordRewindChange(ctx context.Context, svc Service, sessionID string, change RewindChange) error {
+	var err error
+	if change.Action == RewindDelete {
+		_, err = svc.CreateMissing(ctx, sessionID, change.Path)
+	} else {
+		_, err = svc.CreateVersion(ctx, sessionID, change.Path, change.Content)
+	}
+	return err
+}
+
+func applyRewindChange(change RewindChange) error {
+	switch change.Action {
+	case RewindDelete:
+		if err := os.Remove(change.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", change.Path, err)
+		}
+	default:
+		if err := os.MkdirAll(filepath.Dir(change.Path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+		}
+		if err := os.WriteFile(change.Path, []byte(change.Content), 0o644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
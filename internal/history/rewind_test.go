@@ -0,0 +1,194 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRewind(t *testing.T) (Service, string) {
+	t.Helper()
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+
+	q := db.New(conn)
+	sessionID := "rewind-session"
+	_, err = q.CreateSession(t.Context(), db.CreateSessionParams{ID: sessionID, Title: "rewind"})
+	require.NoError(t, err)
+
+	return NewService(q, conn), sessionID
+}
+
+// write mimics what the edit tools do during a turn of messageID: record
+// the previous content, attributed to no message, the first time a file
+// is touched or when it changed outside of Crush, then the new content.
+func write(t *testing.T, svc Service, sessionID, messageID, path, content string) {
+	t.Helper()
+	ctx := WithMessageID(t.Context(), messageID)
+	old, err := os.ReadFile(path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if !missing {
+		require.NoError(t, err)
+	}
+	file, err := svc.GetByPathAndSession(ctx, path, sessionID)
+	if err != nil || file.Content != string(old) || file.Missing != missing {
+		if missing {
+			_, err = svc.CreateMissing(WithoutMessageID(ctx), sessionID, path)
+		} else {
+			_, err = svc.CreateVersion(WithoutMessageID(ctx), sessionID, path, string(old))
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	_, err = svc.CreateVersion(ctx, sessionID, path, content)
+	require.NoError(t, err)
+}
+
+func TestRewind(t *testing.T) {
+	t.Run("restores modified and deletes created files", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		dir := t.TempDir()
+		existing := filepath.Join(dir, "main.go")
+		created := filepath.Join(dir, "new.go")
+		untouched := filepath.Join(dir, "early.go")
+		require.NoError(t, os.WriteFile(existing, []byte("original"), 0o644))
+
+		write(t, svc, sessionID, "first", untouched, "early")
+		write(t, svc, sessionID, "second", existing, "edited")
+		write(t, svc, sessionID, "second", created, "brand new")
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"second"}, RewindOptions{})
+		require.NoError(t, err)
+		require.True(t, result.Applied)
+		require.Equal(t, []RewindChange{
+			{Path: existing, Action: RewindRestore, Content: "original"},
+			{Path: created, Action: RewindDelete, Content: ""},
+		}, result.Changes)
+
+		data, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		require.Equal(t, "original", string(data))
+		require.NoFileExists(t, created)
+		require.FileExists(t, untouched)
+
+		latest, err := svc.GetByPathAndSession(t.Context(), existing, sessionID)
+		require.NoError(t, err)
+		require.Equal(t, "original", latest.Content)
+	})
+
+	t.Run("restores intermediate version", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		write(t, svc, sessionID, "first", path, "first")
+		write(t, svc, sessionID, "second", path, "second")
+		write(t, svc, sessionID, "third", path, "third")
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"second", "third"}, RewindOptions{})
+		require.NoError(t, err)
+		require.Len(t, result.Changes, 1)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "first", string(data))
+	})
+
+	t.Run("keeps changes made outside of crush before the message", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		write(t, svc, sessionID, "first", path, "A")
+		require.NoError(t, os.WriteFile(path, []byte("USER"), 0o644))
+		write(t, svc, sessionID, "second", path, "B")
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"second"}, RewindOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []RewindChange{
+			{Path: path, Action: RewindRestore, Content: "USER"},
+		}, result.Changes)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "USER", string(data))
+	})
+
+	t.Run("refuses to overwrite external changes", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(path, []byte("original"), 0o644))
+
+		write(t, svc, sessionID, "agent", path, "agent")
+		require.NoError(t, os.WriteFile(path, []byte("user"), 0o644))
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"agent"}, RewindOptions{})
+		require.ErrorIs(t, err, ErrRewindConflict)
+		require.False(t, result.Applied)
+		require.Equal(t, []string{path}, result.Conflicts())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "user", string(data))
+
+		result, err = Rewind(t.Context(), svc, sessionID, []string{"agent"}, RewindOptions{Force: true})
+		require.NoError(t, err)
+		require.True(t, result.Applied)
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "original", string(data))
+	})
+
+	t.Run("dry run leaves files alone", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		write(t, svc, sessionID, "agent", path, "agent")
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"agent"}, RewindOptions{DryRun: true})
+		require.NoError(t, err)
+		require.False(t, result.Applied)
+		require.Len(t, result.Changes, 1)
+		require.FileExists(t, path)
+	})
+
+	t.Run("restores empty files instead of deleting them", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "__init__.py")
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+		write(t, svc, sessionID, "agent", path, "import os\n")
+
+		result, err := Rewind(t.Context(), svc, sessionID, []string{"agent"}, RewindOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []RewindChange{{Path: path, Action: RewindRestore}}, result.Changes)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Empty(t, data)
+	})
+
+	t.Run("rewinds again after a rewind", func(t *testing.T) {
+		svc, sessionID := setupRewind(t)
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		write(t, svc, sessionID, "first", path, "first")
+		write(t, svc, sessionID, "second", path, "second")
+		_, err := Rewind(t.Context(), svc, sessionID, []string{"second"}, RewindOptions{})
+		require.NoError(t, err)
+		write(t, svc, sessionID, "third", path, "third")
+
+		_, err = Rewind(t.Context(), svc, sessionID, []string{"first", "third"}, RewindOptions{})
+		require.NoError(t, err)
+		require.NoFileExists(t, path)
+	})
+}
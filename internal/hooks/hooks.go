@@ -1,5 +1,5 @@
 // Package hooks runs user-defined shell commands that fire on hook events
-// (e.g. PreToolUse), returning decisions that control agent behavior.
+// (e.g. PreToolUse, Stop), returning decisions that control agent behavior.
 package hooks
 
 import (
@@ -12,7 +12,20 @@ import (
 
 // Hook event name constants.
 const (
-	EventPreToolUse = "PreToolUse"
+	EventPreToolUse       = "PreToolUse"
+	EventPostToolUse      = "PostToolUse"
+	EventUserPromptSubmit = "UserPromptSubmit"
+	EventStop             = "Stop"
+	EventSessionStart     = "SessionStart"
+	EventSessionEnd       = "SessionEnd"
+)
+
+// SessionStart sources and SessionEnd reasons, passed to hooks as "source"
+// and "reason" and matched against the hook's matcher.
+const (
+	SourceStartup = "startup" // A new session was created.
+	SourceResume  = "resume"  // An existing session got its first prompt since startup.
+	ReasonExit    = "exit"    // Crush is shutting down.
 )
 
 // HaltExitCode is the exit code that halts the whole turn. 2 blocks the
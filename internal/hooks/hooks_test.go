@@ -723,4 +723,140 @@ func TestParseStdoutClaudeCodeFormat(t *testing.T) {
 		require.Equal(t, DecisionAllow, r.Decision)
 		require.Equal(t, "hello", r.Context)
 	})
+
+	t.Run("additionalContext", func(t *testing.T) {
+		t.Parallel()
+		r := parseStdout(`{"hookSpecificOutput":{"hookEventName":"PostToolUse","additionalContext":"run the tests"}}`)
+		require.Equal(t, DecisionNone, r.Decision)
+		require.Equal(t, "run the tests", r.Context)
+	})
+
+	t.Run("top-level block next to hookSpecificOutput", func(t *testing.T) {
+		t.Parallel()
+		r := parseStdout(`{"decision":"block","reason":"lint failed","hookSpecificOutput":{"hookEventName":"PostToolUse"}}`)
+		require.Equal(t, DecisionDeny, r.Decision)
+		require.Equal(t, "lint failed", r.Reason)
+	})
+
+	t.Run("top-level block", func(t *testing.T) {
+		t.Parallel()
+		r := parseStdout(`{"decision":"block","reason":"tests are failing"}`)
+		require.Equal(t, DecisionDeny, r.Decision)
+		require.Equal(t, "tests are failing", r.Reason)
+	})
+
+	t.Run("continue false halts", func(t *testing.T) {
+		t.Parallel()
+		r := parseStdout(`{"continue":false,"stopReason":"build broken"}`)
+		require.True(t, r.Halt)
+		require.Equal(t, "build broken", r.Reason)
+	})
+
+	t.Run("continue true is a no-op", func(t *testing.T) {
+		t.Parallel()
+		r := parseStdout(`{"continue":true}`)
+		require.False(t, r.Halt)
+		require.Equal(t, DecisionNone, r.Decision)
+	})
+}
+
+func TestBuildPayloadEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("PostToolUse carries the response", func(t *testing.T) {
+		t.Parallel()
+		payload := buildPayload(Input{
+			Event:        EventPostToolUse,
+			SessionID:    "sess-1",
+			ToolName:     "bash",
+			ToolInput:    `{"command":"ls"}`,
+			ToolResponse: "main.go",
+			ToolError:    true,
+		}, "/work")
+		require.JSONEq(t, `{
+			"event": "PostToolUse",
+			"session_id": "sess-1",
+			"cwd": "/work",
+			"tool_name": "bash",
+			"tool_input": {"command": "ls"},
+			"tool_response": "main.go",
+			"tool_error": true
+		}`, string(payload))
+	})
+
+	t.Run("UserPromptSubmit omits tool fields", func(t *testing.T) {
+		t.Parallel()
+		payload := buildPayload(Input{
+			Event:     EventUserPromptSubmit,
+			SessionID: "sess-1",
+			Prompt:    "fix the login flow",
+		}, "/work")
+		require.JSONEq(t, `{
+			"event": "UserPromptSubmit",
+			"session_id": "sess-1",
+			"cwd": "/work",
+			"prompt": "fix the login flow"
+		}`, string(payload))
+	})
+
+	t.Run("Stop and session events", func(t *testing.T) {
+		t.Parallel()
+		stop := buildPayload(Input{Event: EventStop, SessionID: "s", StopHookActive: true}, "/work")
+		require.Contains(t, string(stop), `"stop_hook_active":true`)
+
+		start := buildPayload(Input{Event: EventSessionStart, SessionID: "s", Source: SourceResume}, "/work")
+		require.Contains(t, string(start), `"source":"resume"`)
+
+		end := buildPayload(Input{Event: EventSessionEnd, SessionID: "s", Reason: ReasonExit}, "/work")
+		require.Contains(t, string(end), `"reason":"exit"`)
+	})
+}
+
+func TestRunEventMatching(t *testing.T) {
+	t.Parallel()
+
+	t.Run("events without a target ignore matchers", func(t *testing.T) {
+		t.Parallel()
+		r := NewRunner([]config.HookConfig{
+			{Command: `echo '{"context":"from hook"}'`, Matcher: "^bash$"},
+		}, t.TempDir(), t.TempDir())
+		result, err := r.RunEvent(context.Background(), Input{Event: EventUserPromptSubmit, SessionID: "sess", Prompt: "hi"})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.HookCount)
+		require.Equal(t, "from hook", result.Context)
+	})
+
+	t.Run("SessionStart matches the source", func(t *testing.T) {
+		t.Parallel()
+		r := NewRunner([]config.HookConfig{
+			{Command: `echo '{"context":"welcome back"}'`, Matcher: "^resume$"},
+		}, t.TempDir(), t.TempDir())
+		startup, err := r.RunEvent(context.Background(), Input{Event: EventSessionStart, SessionID: "sess", Source: SourceStartup})
+		require.NoError(t, err)
+		require.Zero(t, startup.HookCount)
+
+		resume, err := r.RunEvent(context.Background(), Input{Event: EventSessionStart, SessionID: "sess", Source: SourceResume})
+		require.NoError(t, err)
+		require.Equal(t, "welcome back", resume.Context)
+	})
+
+	t.Run("nil runner runs nothing", func(t *testing.T) {
+		t.Parallel()
+		var r *Runner
+		result, err := r.RunEvent(context.Background(), Input{Event: EventStop, SessionID: "sess"})
+		require.NoError(t, err)
+		require.Equal(t, DecisionNone, result.Decision)
+		require.Zero(t, result.HookCount)
+	})
+
+	t.Run("hook sees the prompt on stdin", func(t *testing.T) {
+		t.Parallel()
+		r := NewRunner([]config.HookConfig{
+			{Command: `if grep -q '"prompt":"deploy to prod"'; then echo '{"decision":"block","reason":"no deploys"}'; fi`},
+		}, t.TempDir(), t.TempDir())
+		result, err := r.RunEvent(context.Background(), Input{Event: EventUserPromptSubmit, SessionID: "sess", Prompt: "deploy to prod"})
+		require.NoError(t, err)
+		require.Equal(t, DecisionDeny, result.Decision)
+		require.Equal(t, "no deploys", result.Reason)
+	})
 }
@@ -16,30 +16,87 @@ import (
 // an older version. Unknown higher versions are still parsed but logged.
 const SupportedOutputVersion = 1
 
+// Input describes a single hook invocation. Which fields are meaningful
+// depends on the event: tool events carry the tool name and input (plus
+// the response for PostToolUse), UserPromptSubmit carries the prompt,
+// Stop carries StopHookActive, and SessionStart/SessionEnd carry Source
+// and Reason respectively.
+type Input struct {
+	Event          string
+	SessionID      string
+	ToolName       string
+	ToolInput      string // Raw tool input JSON.
+	ToolResponse   string
+	ToolError      bool
+	Prompt         string
+	StopHookActive bool
+	Source         string
+	Reason         string
+}
+
+// matchTarget returns the value hook matchers are tested against for the
+// event, and false for events that have nothing to match, in which case
+// every hook for the event runs.
+func (in Input) matchTarget() (string, bool) {
+	switch in.Event {
+	case EventUserPromptSubmit, EventStop:
+		return "", false
+	case EventSessionStart:
+		return in.Source, true
+	case EventSessionEnd:
+		return in.Reason, true
+	default:
+		return in.ToolName, true
+	}
+}
+
 // Payload is the JSON structure piped to hook commands via stdin.
 // ToolInput is emitted as a parsed JSON object for compatibility with
 // Claude Code hooks (which expect tool_input to be an object, not a
-// string).
+// string). Per-event fields are omitted when they don't apply.
 type Payload struct {
-	Event     string          `json:"event"`
-	SessionID string          `json:"session_id"`
-	CWD       string          `json:"cwd"`
-	ToolName  string          `json:"tool_name"`
-	ToolInput json.RawMessage `json:"tool_input"`
+	Event          string          `json:"event"`
+	SessionID      string          `json:"session_id"`
+	CWD            string          `json:"cwd"`
+	ToolName       string          `json:"tool_name,omitempty"`
+	ToolInput      json.RawMessage `json:"tool_input,omitempty"`
+	ToolResponse   string          `json:"tool_response,omitempty"`
+	ToolError      bool            `json:"tool_error,omitempty"`
+	Prompt         string          `json:"prompt,omitempty"`
+	StopHookActive bool            `json:"stop_hook_active,omitempty"`
+	Source         string          `json:"source,omitempty"`
+	Reason         string          `json:"reason,omitempty"`
 }
 
-// BuildPayload constructs the JSON stdin payload for a hook command.
+// BuildPayload constructs the JSON stdin payload for a tool hook command.
 func BuildPayload(eventName, sessionID, cwd, toolName, toolInputJSON string) []byte {
-	toolInput := json.RawMessage(toolInputJSON)
-	if !json.Valid(toolInput) {
-		toolInput = json.RawMessage("{}")
-	}
-	p := Payload{
+	return buildPayload(Input{
 		Event:     eventName,
 		SessionID: sessionID,
-		CWD:       cwd,
 		ToolName:  toolName,
-		ToolInput: toolInput,
+		ToolInput: toolInputJSON,
+	}, cwd)
+}
+
+// buildPayload constructs the JSON stdin payload for any hook event.
+func buildPayload(in Input, cwd string) []byte {
+	p := Payload{
+		Event:          in.Event,
+		SessionID:      in.SessionID,
+		CWD:            cwd,
+		ToolName:       in.ToolName,
+		ToolResponse:   in.ToolResponse,
+		ToolError:      in.ToolError,
+		Prompt:         in.Prompt,
+		StopHookActive: in.StopHookActive,
+		Source:         in.Source,
+		Reason:         in.Reason,
+	}
+	if in.ToolName != "" {
+		p.ToolInput = json.RawMessage(in.ToolInput)
+		if !json.Valid(p.ToolInput) {
+			p.ToolInput = json.RawMessage("{}")
+		}
 	}
 	data, err := json.Marshal(p)
 	if err != nil {
@@ -88,11 +145,6 @@ func parseStdout(stdout string) HookResult {
 		return HookResult{Decision: DecisionNone}
 	}
 
-	// Claude Code compat: if hookSpecificOutput is present, parse that.
-	if hso, ok := raw["hookSpecificOutput"]; ok {
-		return parseClaudeCodeOutput(hso)
-	}
-
 	var parsed struct {
 		Version      int             `json:"version"`
 		Decision     string          `json:"decision"`
@@ -100,26 +152,55 @@ func parseStdout(stdout string) HookResult {
 		Reason       string          `json:"reason"`
 		Context      json.RawMessage `json:"context"`
 		UpdatedInput json.RawMessage `json:"updated_input"`
+
+		// Claude Code top-level fields.
+		Continue   *bool  `json:"continue"`
+		StopReason string `json:"stopReason"`
 	}
-	if err := json.Unmarshal([]byte(stdout), &parsed); err != nil {
-		return HookResult{Decision: DecisionNone}
-	}
+	parseErr := json.Unmarshal([]byte(stdout), &parsed)
+
+	var result HookResult
+	if hso, ok := raw["hookSpecificOutput"]; ok {
+		// Claude Code compat: if hookSpecificOutput is present, parse that.
+		// PostToolUse, UserPromptSubmit, and Stop hooks put their
+		// decision and reason at the top level next to it.
+		result = parseClaudeCodeOutput(hso)
+		if result.Decision == DecisionNone {
+			result.Decision = parseDecision(parsed.Decision)
+		}
+		if result.Reason == "" {
+			result.Reason = parsed.Reason
+		}
+	} else {
+		if parseErr != nil {
+			return HookResult{Decision: DecisionNone}
+		}
+
+		if parsed.Version > SupportedOutputVersion {
+			slog.Debug(
+				"Hook output declared a newer envelope version than this build supports",
+				"version", parsed.Version,
+				"supported", SupportedOutputVersion,
+			)
+		}
 
-	if parsed.Version > SupportedOutputVersion {
-		slog.Debug(
-			"Hook output declared a newer envelope version than this build supports",
-			"version", parsed.Version,
-			"supported", SupportedOutputVersion,
-		)
+		result = HookResult{
+			Halt:    parsed.Halt,
+			Reason:  parsed.Reason,
+			Context: parseContext(parsed.Context),
+		}
+		result.Decision = parseDecision(parsed.Decision)
+		result.UpdatedInput = rawToString(parsed.UpdatedInput)
 	}
 
-	result := HookResult{
-		Halt:    parsed.Halt,
-		Reason:  parsed.Reason,
-		Context: parseContext(parsed.Context),
+	// Claude Code's "continue": false stops the agent outright, which is
+	// what halt means here.
+	if parsed.Continue != nil && !*parsed.Continue {
+		result.Halt = true
+		if parsed.StopReason != "" {
+			result.Reason = parsed.StopReason
+		}
 	}
-	result.Decision = parseDecision(parsed.Decision)
-	result.UpdatedInput = rawToString(parsed.UpdatedInput)
 	return result
 }
 
@@ -161,6 +242,7 @@ func parseClaudeCodeOutput(data json.RawMessage) HookResult {
 		PermissionDecision       string          `json:"permissionDecision"`
 		PermissionDecisionReason string          `json:"permissionDecisionReason"`
 		UpdatedInput             json.RawMessage `json:"updatedInput"`
+		AdditionalContext        string          `json:"additionalContext"`
 	}
 	if err := json.Unmarshal(data, &hso); err != nil {
 		return HookResult{Decision: DecisionNone}
@@ -169,6 +251,7 @@ func parseClaudeCodeOutput(data json.RawMessage) HookResult {
 	result := HookResult{
 		Decision: parseDecision(hso.PermissionDecision),
 		Reason:   hso.PermissionDecisionReason,
+		Context:  hso.AdditionalContext,
 	}
 
 	// Marshal updatedInput back to a string for our opaque format.
@@ -199,9 +282,9 @@ func rawToString(raw json.RawMessage) string {
 
 func parseDecision(s string) Decision {
 	switch strings.ToLower(s) {
-	case "allow":
+	case "allow", "approve":
 		return DecisionAllow
-	case "deny":
+	case "deny", "block":
 		return DecisionDeny
 	default:
 		return DecisionNone
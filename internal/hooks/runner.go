@@ -11,6 +11,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/telemetry"
 )
 
 // abandonGrace is how long runOne waits after ctx cancellation for the
@@ -85,14 +86,31 @@ func (r *Runner) Hooks() []config.HookConfig {
 	return out
 }
 
-// Run executes all matching hooks for the given event and tool, returning
-// an aggregated result.
+// Run executes all matching hooks for the given tool event and tool,
+// returning an aggregated result.
 func (r *Runner) Run(ctx context.Context, eventName, sessionID, toolName, toolInputJSON string) (AggregateResult, error) {
-	matching := r.matchingHooks(toolName)
+	return r.RunEvent(ctx, Input{
+		Event:     eventName,
+		SessionID: sessionID,
+		ToolName:  toolName,
+		ToolInput: toolInputJSON,
+	})
+}
+
+// RunEvent executes all hooks matching in, returning an aggregated result.
+// A nil Runner runs nothing, so callers can hold one per event without
+// checking whether any hooks are configured for it.
+func (r *Runner) RunEvent(ctx context.Context, in Input) (AggregateResult, error) {
+	if r == nil {
+		return AggregateResult{Decision: DecisionNone}, nil
+	}
+	matching := r.matchingHooks(in)
 	if len(matching) == 0 {
 		return AggregateResult{Decision: DecisionNone}, nil
 	}
 
+	ctx, end := telemetry.Hook(ctx, in.Event, in.ToolName)
+
 	// Deduplicate by command string.
 	seen := make(map[string]bool, len(matching))
 	var deduped []config.HookConfig
@@ -104,8 +122,8 @@ func (r *Runner) Run(ctx context.Context, eventName, sessionID, toolName, toolIn
 		deduped = append(deduped, h)
 	}
 
-	envVars := BuildEnv(eventName, toolName, sessionID, r.cwd, r.projectDir, toolInputJSON)
-	payload := BuildPayload(eventName, sessionID, r.cwd, toolName, toolInputJSON)
+	envVars := BuildEnv(in.Event, in.ToolName, in.SessionID, r.cwd, r.projectDir, in.ToolInput)
+	payload := buildPayload(in, r.cwd)
 
 	results := make([]HookResult, len(deduped))
 	var wg sync.WaitGroup
@@ -119,7 +137,7 @@ func (r *Runner) Run(ctx context.Context, eventName, sessionID, toolName, toolIn
 	}
 	wg.Wait()
 
-	agg := aggregate(results, toolInputJSON)
+	agg := aggregate(results, in.ToolInput)
 	agg.Hooks = make([]HookInfo, len(deduped))
 	for i, h := range deduped {
 		agg.Hooks[i] = HookInfo{
@@ -133,20 +151,23 @@ func (r *Runner) Run(ctx context.Context, eventName, sessionID, toolName, toolIn
 	}
 	slog.Info(
 		"Hook completed",
-		"event", eventName,
-		"tool", toolName,
+		"event", in.Event,
+		"tool", in.ToolName,
 		"hooks", len(deduped),
 		"decision", agg.Decision.String(),
 	)
+	end(agg.Decision.String())
 	return agg, nil
 }
 
-// matchingHooks returns hooks whose matcher matches the tool name (or has
-// no matcher, which matches everything).
-func (r *Runner) matchingHooks(toolName string) []config.HookConfig {
+// matchingHooks returns hooks whose matcher matches the event's match
+// target, e.g. the tool name (or has no matcher, which matches
+// everything). Events without a match target run every hook.
+func (r *Runner) matchingHooks(in Input) []config.HookConfig {
+	target, ok := in.matchTarget()
 	var matched []config.HookConfig
 	for _, h := range r.hooks {
-		if h.matcher == nil || h.matcher.MatchString(toolName) {
+		if !ok || h.matcher == nil || h.matcher.MatchString(target) {
 			matched = append(matched, h.cfg)
 		}
 	}
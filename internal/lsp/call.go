@@ -0,0 +1,40 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/telemetry"
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+)
+
+// ErrRequestUnsupported is returned for requests the powernap client can't
+// send.
+var ErrRequestUnsupported = errors.New("request not supported by the lsp client")
+
+// requester is a powernap client that can send any request, not only the
+// handful (hover, references, completion) it wraps.
+type requester interface {
+	Call(ctx context.Context, method string, params, result any) error
+}
+
+// SupportsRequests reports whether the powernap client can send the
+// requests behind go-to-definition, symbols, rename, code actions, and
+// formatting. The tools using them are only offered when it can.
+func SupportsRequests() bool {
+	_, ok := any((*powernap.Client)(nil)).(requester)
+	return ok
+}
+
+// call sends a request the powernap client has no method for.
+func (c *Client) call(ctx context.Context, method string, params, result any) (err error) {
+	ctx, end := telemetry.LSPRequest(ctx, c.name, method)
+	defer func() { end(err) }()
+
+	r, ok := any(c.client).(requester)
+	if !ok || c.client == nil {
+		return fmt.Errorf("%w: %s", ErrRequestUnsupported, method)
+	}
+	return r.Call(ctx, method, params, result)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/charmbracelet/x/powernap/pkg/transport"
@@ -665,7 +667,253 @@ func (c *Client) FindReferences(ctx context.Context, filepath string, line, char
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	ctx, end := telemetry.LSPRequest(ctx, c.name, "textDocument/references")
 	// NOTE: line and character should be 0-based.
 	// See: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#position
-	return c.client.FindReferences(ctx, filepath, line-1, character-1, includeDeclaration)
+	locations, err := c.client.FindReferences(ctx, filepath, line-1, character-1, includeDeclaration)
+	end(err)
+	return locations, err
+}
+
+// FindDefinition finds where the symbol at the given position is defined.
+// Location links are flattened to the location of the target's name.
+func (c *Client) FindDefinition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	params := protocol.DefinitionParams{
+		TextDocumentPositionParams: textDocumentPosition(filepath, line, character),
+	}
+	var result protocol.Or_Result_textDocument_definition
+	if err := c.call(ctx, "textDocument/definition", params, &result); err != nil {
+		return nil, fmt.Errorf("definition request failed: %w", err)
+	}
+
+	switch v := result.Value.(type) {
+	case protocol.Definition:
+		switch loc := v.Value.(type) {
+		case protocol.Location:
+			return []protocol.Location{loc}, nil
+		case []protocol.Location:
+			return loc, nil
+		}
+	case []protocol.DefinitionLink:
+		locations := make([]protocol.Location, 0, len(v))
+		for _, link := range v {
+			locations = append(locations, protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		}
+		return locations, nil
+	}
+	return nil, nil
+}
+
+// Hover returns the hover documentation for the symbol at the given
+// position, usually its signature or type and doc comment.
+func (c *Client) Hover(ctx context.Context, filepath string, line, character int) (string, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pos := textDocumentPosition(filepath, line, character)
+	ctx, end := telemetry.LSPRequest(ctx, c.name, "textDocument/hover")
+	result, err := c.client.RequestHover(ctx, string(pos.TextDocument.URI), pos.Position)
+	end(err)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Contents.Value), nil
+}
+
+// DocumentSymbols returns the symbols defined in a file. Servers that only
+// return flat symbol information get each symbol as a top-level entry.
+func (c *Client) DocumentSymbols(ctx context.Context, filepath string) ([]protocol.DocumentSymbol, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	params := protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+	}
+	var result protocol.Or_Result_textDocument_documentSymbol
+	if err := c.call(ctx, "textDocument/documentSymbol", params, &result); err != nil {
+		return nil, fmt.Errorf("document symbol request failed: %w", err)
+	}
+
+	switch v := result.Value.(type) {
+	case []protocol.DocumentSymbol:
+		return v, nil
+	case []protocol.SymbolInformation:
+		symbols := make([]protocol.DocumentSymbol, 0, len(v))
+		for _, info := range v {
+			symbols = append(symbols, protocol.DocumentSymbol{
+				Name:           info.Name,
+				Detail:         info.ContainerName,
+				Kind:           info.Kind,
+				Range:          info.Location.Range,
+				SelectionRange: info.Location.Range,
+			})
+		}
+		return symbols, nil
+	}
+	return nil, nil
+}
+
+// WorkspaceSymbols searches the whole workspace for symbols matching
+// query. Matching is up to the server, and is usually fuzzy.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	params := protocol.WorkspaceSymbolParams{Query: query}
+	var result protocol.Or_Result_workspace_symbol
+	if err := c.call(ctx, "workspace/symbol", params, &result); err != nil {
+		return nil, fmt.Errorf("workspace symbol request failed: %w", err)
+	}
+
+	results, err := result.Results()
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]protocol.SymbolInformation, 0, len(results))
+	for _, r := range results {
+		switch v := r.(type) {
+		case *protocol.SymbolInformation:
+			symbols = append(symbols, *v)
+		case *protocol.WorkspaceSymbol:
+			symbols = append(symbols, protocol.SymbolInformation{
+				Name:          v.Name,
+				Kind:          v.Kind,
+				ContainerName: v.ContainerName,
+				Location:      v.GetLocation(),
+			})
+		}
+	}
+	return symbols, nil
+}
+
+// Rename asks the server for the edits that rename the symbol at the
+// given position to newName across the workspace. The edits are returned,
+// not applied.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	pos := textDocumentPosition(filepath, line, character)
+	params := protocol.RenameParams{
+		TextDocument: pos.TextDocument,
+		Position:     pos.Position,
+		NewName:      newName,
+	}
+	var result *protocol.WorkspaceEdit
+	if err := c.call(ctx, "textDocument/rename", params, &result); err != nil {
+		return nil, fmt.Errorf("rename request failed: %w", err)
+	}
+	return result, nil
+}
+
+// CodeActions returns the code actions available for a range in a file,
+// limited to the given kinds if any. Diagnostics overlapping the range are
+// sent along so the server can offer quick fixes for them. Actions that
+// are plain commands are skipped, since their edits can't be previewed.
+func (c *Client) CodeActions(ctx context.Context, filepath string, rng protocol.Range, only ...protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	uri := protocol.URIFromPath(filepath)
+	diagnostics := []protocol.Diagnostic{}
+	for _, diag := range c.GetFileDiagnostics(uri) {
+		if diag.Range.Start.Line <= rng.End.Line && diag.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	}
+	var result []protocol.Or_Result_textDocument_codeAction_Item0_Elem
+	if err := c.call(ctx, "textDocument/codeAction", params, &result); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+
+	actions := make([]protocol.CodeAction, 0, len(result))
+	for _, item := range result {
+		if action, ok := item.Value.(protocol.CodeAction); ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// ResolveCodeAction fills in the edit of a code action the server computes
+// lazily.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var result protocol.CodeAction
+	if err := c.call(ctx, "codeAction/resolve", action, &result); err != nil {
+		return protocol.CodeAction{}, fmt.Errorf("code action resolve failed: %w", err)
+	}
+	return result, nil
+}
+
+// Format asks the server for the edits that format a whole file. The
+// edits are returned, not applied.
+func (c *Client) Format(ctx context.Context, filepath string, options protocol.FormattingOptions) ([]protocol.TextEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Options:      options,
+	}
+	var result []protocol.TextEdit
+	if err := c.call(ctx, "textDocument/formatting", params, &result); err != nil {
+		return nil, fmt.Errorf("formatting request failed: %w", err)
+	}
+	return result, nil
+}
+
+// OffsetEncoding returns the position encoding negotiated with the server.
+func (c *Client) OffsetEncoding() powernap.OffsetEncoding {
+	return c.client.GetOffsetEncoding()
+}
+
+// textDocumentPosition builds request params for a 1-based position in a
+// file.
+func textDocumentPosition(filepath string, line, character int) protocol.TextDocumentPositionParams {
+	// NOTE: LSP positions are 0-based.
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Position: protocol.Position{
+			Line:      uint32(max(line-1, 0)),      //nolint:gosec
+			Character: uint32(max(character-1, 0)), //nolint:gosec
+		},
+	}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/stretchr/testify/require"
 )
@@ -202,3 +203,14 @@ func TestWaitForDiagnostics_NilClient(t *testing.T) {
 	// Should not panic.
 	c.WaitForDiagnostics(context.Background(), time.Second)
 }
+
+func TestCallUnsupported(t *testing.T) {
+	t.Parallel()
+
+	if SupportsRequests() {
+		t.Skip("powernap client sends requests")
+	}
+	c := &Client{client: &powernap.Client{}}
+	err := c.call(t.Context(), "textDocument/definition", nil, nil)
+	require.ErrorIs(t, err, ErrRequestUnsupported)
+}
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +28,7 @@ const unavailableRetryDelay = 30 * time.Second
 type Manager struct {
 	clients     *csync.Map[string, *Client]
 	unavailable *csync.Map[string, time.Time]
+	roots       *csync.Slice[string]
 	cfg         *config.ConfigStore
 	manager     *powernapconfig.Manager
 	callback    func(name string, client *Client)
@@ -63,6 +65,7 @@ func NewManager(cfg *config.ConfigStore) *Manager {
 	return &Manager{
 		clients:     csync.NewMap[string, *Client](),
 		unavailable: csync.NewMap[string, time.Time](),
+		roots:       csync.NewSlice[string](),
 		cfg:         cfg,
 		manager:     manager,
 		callback:    func(string, *Client) {}, // default no-op callback
@@ -96,17 +99,50 @@ func (s *Manager) TrackConfigured() {
 	wg.Wait()
 }
 
+// AddRoot adds a workspace root besides the working directory, such as the
+// git worktree of a session. Files under it are handled by clients of their
+// own, rooted there and named after the server and the root's base name.
+func (s *Manager) AddRoot(dir string) {
+	if !slices.Contains(s.roots.Copy(), dir) {
+		s.roots.Append(dir)
+	}
+}
+
+// rootFor returns the workspace root containing path, or the empty string
+// if it is outside of all of them.
+func (s *Manager) rootFor(path string) string {
+	for root := range s.roots.Seq() {
+		if fsext.HasPrefix(path, root) {
+			return root
+		}
+	}
+	if fsext.HasPrefix(path, s.cfg.WorkingDir()) {
+		return s.cfg.WorkingDir()
+	}
+	return ""
+}
+
+// clientName returns the name of the client of a server for a workspace
+// root.
+func (s *Manager) clientName(name, root string) string {
+	if root == s.cfg.WorkingDir() {
+		return name
+	}
+	return name + "@" + filepath.Base(root)
+}
+
 // Start starts an LSP server that can handle the given file path.
 // If an appropriate LSP is already running, this is a no-op.
 func (s *Manager) Start(ctx context.Context, path string) {
-	if !fsext.HasPrefix(path, s.cfg.WorkingDir()) {
+	root := s.rootFor(path)
+	if root == "" {
 		return
 	}
 
 	var wg sync.WaitGroup
 	for name, server := range s.manager.GetServers() {
 		wg.Go(func() {
-			s.startServer(ctx, name, path, server)
+			s.startServer(ctx, name, path, root, server)
 		})
 	}
 	wg.Wait()
@@ -144,7 +180,7 @@ var skipAutoStartCommands = map[string]bool{
 	"tflint":  true,
 }
 
-func (s *Manager) startServer(ctx context.Context, name, filepath string, server *powernapconfig.ServerConfig) {
+func (s *Manager) startServer(ctx context.Context, name, filepath, root string, server *powernapconfig.ServerConfig) {
 	var (
 		isUserConfigured = s.isUserConfigured(name)
 		autoLSP          = s.cfg.Config().Options.AutoLSP
@@ -159,10 +195,12 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 		return
 	}
 
-	if client, ok := s.clients.Get(name); ok {
+	key := s.clientName(name, root)
+
+	if client, ok := s.clients.Get(key); ok {
 		switch client.GetServerState() {
 		case StateReady, StateStarting, StateDisabled:
-			s.callback(name, client)
+			s.callback(key, client)
 			// already done, return
 			return
 		}
@@ -185,16 +223,16 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 	}
 
 	// this is the slowest bit, so we do it last.
-	if !handles(server, filepath, s.cfg.WorkingDir()) {
+	if !handles(server, filepath, root) {
 		// nothing to do
 		return
 	}
 
 	// Check again in case another goroutine started it in the meantime.
-	if client, ok := s.clients.Get(name); ok {
+	if client, ok := s.clients.Get(key); ok {
 		switch client.GetServerState() {
 		case StateReady, StateStarting, StateDisabled:
-			s.callback(name, client)
+			s.callback(key, client)
 			return
 		}
 	}
@@ -204,7 +242,7 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 		name,
 		cfg,
 		s.cfg.Resolver(),
-		s.cfg.WorkingDir(),
+		root,
 		s.cfg.Config().Options.DebugLSP,
 	)
 	if err != nil {
@@ -213,17 +251,17 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 	}
 	// Only store non-nil clients. If another goroutine raced us,
 	// prefer the already-stored client.
-	if existing, ok := s.clients.Get(name); ok {
+	if existing, ok := s.clients.Get(key); ok {
 		switch existing.GetServerState() {
 		case StateReady, StateStarting, StateDisabled:
 			_ = client.Close(ctx)
-			s.callback(name, existing)
+			s.callback(key, existing)
 			return
 		}
 	}
-	s.clients.Set(name, client)
+	s.clients.Set(key, client)
 	defer func() {
-		s.callback(name, client)
+		s.callback(key, client)
 	}()
 
 	switch client.GetServerState() {
@@ -237,10 +275,10 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 	initCtx, cancel := context.WithTimeout(ctx, time.Duration(cmp.Or(cfg.Timeout, 30))*time.Second)
 	defer cancel()
 
-	if _, err := client.Initialize(initCtx, s.cfg.WorkingDir()); err != nil {
+	if _, err := client.Initialize(initCtx, root); err != nil {
 		slog.Error("LSP client initialization failed", "name", name, "error", err)
 		_ = client.Close(ctx)
-		s.clients.Del(name)
+		s.clients.Del(key)
 		return
 	}
 
@@ -251,7 +289,7 @@ func (s *Manager) startServer(ctx context.Context, name, filepath string, server
 		client.SetServerState(StateReady)
 	}
 
-	slog.Debug("LSP client started", "name", name)
+	slog.Debug("LSP client started", "name", key)
 }
 
 func (s *Manager) isUserConfigured(name string) bool {
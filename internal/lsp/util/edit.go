@@ -1,7 +1,7 @@
 package util
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -23,25 +23,40 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit, encodin
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := ApplyTextEdits(string(content), edits, encoding)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEdits applies edits to content and returns the result, keeping
+// the content's line endings.
+func ApplyTextEdits(content string, edits []protocol.TextEdit, encoding powernap.OffsetEncoding) (string, error) {
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -60,7 +75,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit, encodin
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit, encoding)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -79,11 +94,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit, encodin
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit, encoding powernap.OffsetEncoding) ([]string, error) {
@@ -284,6 +295,42 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit, encoding powernap.OffsetEnc
 	return nil
 }
 
+// TextEditsByPath groups the text edits in a WorkspaceEdit by file path.
+// As the LSP specification requires, DocumentChanges is used when present
+// and Changes only otherwise, since servers may send the same edits in
+// both. It fails if the edit creates, renames, or deletes files, since
+// those can't be previewed as a change to a file's content.
+func TextEditsByPath(edit protocol.WorkspaceEdit) (map[string][]protocol.TextEdit, error) {
+	edits := make(map[string][]protocol.TextEdit)
+	if len(edit.DocumentChanges) == 0 {
+		for uri, textEdits := range edit.Changes {
+			path, err := uri.Path()
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI: %w", err)
+			}
+			edits[path] = append(edits[path], textEdits...)
+		}
+		return edits, nil
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			return nil, errors.New("creating, renaming, or deleting files is not supported")
+		}
+		path, err := change.TextDocumentEdit.TextDocument.URI.Path()
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI: %w", err)
+		}
+		for _, e := range change.TextDocumentEdit.Edits {
+			textEdit, err := e.AsTextEdit()
+			if err != nil {
+				return nil, fmt.Errorf("invalid edit type: %w", err)
+			}
+			edits[path] = append(edits[path], textEdit)
+		}
+	}
+	return edits, nil
+}
+
 // rangesOverlap checks if two LSP ranges overlap.
 // Per the LSP specification, ranges are half-open intervals [start, end),
 // so adjacent ranges where one's end equals another's start do NOT overlap.
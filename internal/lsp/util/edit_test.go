@@ -374,3 +374,96 @@ func TestRangesOverlap(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyTextEdits(t *testing.T) {
+	t.Parallel()
+
+	rename := func(line, start, end uint32, text string) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: start},
+				End:   protocol.Position{Line: line, Character: end},
+			},
+			NewText: text,
+		}
+	}
+
+	t.Run("multiple edits", func(t *testing.T) {
+		t.Parallel()
+		content := "func foo() {}\n\nfunc bar() { foo() }\n"
+		got, err := ApplyTextEdits(content, []protocol.TextEdit{
+			rename(0, 5, 8, "baz"),
+			rename(2, 13, 16, "baz"),
+		}, powernap.UTF16)
+		require.NoError(t, err)
+		require.Equal(t, "func baz() {}\n\nfunc bar() { baz() }\n", got)
+	})
+
+	t.Run("keeps CRLF line endings", func(t *testing.T) {
+		t.Parallel()
+		got, err := ApplyTextEdits("a := 1\r\nb := a\r\n", []protocol.TextEdit{
+			rename(0, 0, 1, "x"),
+			rename(1, 5, 6, "x"),
+		}, powernap.UTF16)
+		require.NoError(t, err)
+		require.Equal(t, "x := 1\r\nb := x\r\n", got)
+	})
+
+	t.Run("overlapping edits", func(t *testing.T) {
+		t.Parallel()
+		_, err := ApplyTextEdits("hello world\n", []protocol.TextEdit{
+			rename(0, 0, 5, "a"),
+			rename(0, 3, 8, "b"),
+		}, powernap.UTF16)
+		require.ErrorContains(t, err, "overlapping edits")
+	})
+}
+
+func TestTextEditsByPath(t *testing.T) {
+	t.Parallel()
+
+	edit := protocol.TextEdit{NewText: "x"}
+
+	documentEdit := func(path string) protocol.DocumentChange {
+		return protocol.DocumentChange{TextDocumentEdit: &protocol.TextDocumentEdit{
+			TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+			},
+			Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: edit}},
+		}}
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		t.Parallel()
+		got, err := TextEditsByPath(protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath("/tmp/a.go"): {edit},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]protocol.TextEdit{"/tmp/a.go": {edit}}, got)
+	})
+
+	t.Run("document changes take priority over changes", func(t *testing.T) {
+		t.Parallel()
+		got, err := TextEditsByPath(protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath("/tmp/a.go"): {edit},
+				protocol.URIFromPath("/tmp/b.go"): {edit},
+			},
+			DocumentChanges: []protocol.DocumentChange{documentEdit("/tmp/a.go")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]protocol.TextEdit{"/tmp/a.go": {edit}}, got)
+	})
+
+	t.Run("resource operations", func(t *testing.T) {
+		t.Parallel()
+		_, err := TextEditsByPath(protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{
+				{CreateFile: &protocol.CreateFile{URI: protocol.URIFromPath("/tmp/c.go")}},
+			},
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// permissionSchema is the form the client fills to answer a permission
+// request, on top of accepting or declining it.
+var permissionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"remember": map[string]any{
+			"type":        "boolean",
+			"title":       "Allow for this session",
+			"description": "Allow this tool to do this again in the same directory without asking",
+			"default":     false,
+		},
+	},
+}
+
+// answerPermissions answers the permission requests of the app by asking
+// the clients they come from, until ctx is done.
+func (s *Server) answerPermissions(ctx context.Context) {
+	for event := range s.app.Permissions.Subscribe(ctx) {
+		go s.answerPermission(ctx, event.Payload)
+	}
+}
+
+func (s *Server) answerPermission(ctx context.Context, req permission.PermissionRequest) {
+	ss, ok := s.owner(ctx, req.SessionID)
+	if !ok {
+		slog.Warn("Denied permission request of unknown session", "session_id", req.SessionID, "tool", req.ToolName)
+		s.app.Permissions.Deny(req)
+		return
+	}
+	if !supportsElicitation(ss) {
+		slog.Warn("Denied permission request: the MCP client doesn't support elicitation; allow the tool in the permission policy or run with --yolo",
+			"tool", req.ToolName, "action", req.Action)
+		s.app.Permissions.Deny(req)
+		return
+	}
+
+	result, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		Message:         permissionMessage(req),
+		RequestedSchema: permissionSchema,
+	})
+	switch {
+	case err != nil:
+		slog.Error("Failed to ask the MCP client for permission", "tool", req.ToolName, "error", err)
+		s.app.Permissions.Deny(req)
+	case result.Action != "accept":
+		s.app.Permissions.Deny(req)
+	case result.Content["remember"] == true:
+		s.app.Permissions.GrantPersistent(req)
+	default:
+		s.app.Permissions.Grant(req)
+	}
+}
+
+func supportsElicitation(ss *mcp.ServerSession) bool {
+	p := ss.InitializeParams()
+	return p != nil && p.Capabilities != nil && p.Capabilities.Elicitation != nil
+}
+
+// permissionMessage describes req to the user of the client.
+func permissionMessage(req permission.PermissionRequest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Crush wants to run the %s tool", req.ToolName)
+	if req.Action != "" {
+		fmt.Fprintf(&sb, " to %s", req.Action)
+	}
+	if req.Path != "" {
+		fmt.Fprintf(&sb, " in %s", req.Path)
+	}
+	sb.WriteString(".")
+	if req.Description != "" {
+		fmt.Fprintf(&sb, "\n\n%s", req.Description)
+	}
+	if req.Rule != "" {
+		fmt.Fprintf(&sb, "\n\nAsked by the permission rule %s.", req.Rule)
+	}
+	return sb.String()
+}
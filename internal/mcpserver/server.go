@@ -0,0 +1,256 @@
+// Package mcpserver serves the built-in tools and the agent of Crush over
+// the Model Context Protocol, so other agents and editors can use them.
+//
+// Each MCP session works in a Crush session of its own, created on its
+// first tool call. Permission requests of the tools are asked to the
+// client through elicitation; the permission policy, the allowed tools,
+// and --yolo apply first, as they do in the TUI.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	mcptools "github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// AgentToolName is the name of the tool running a prompt through the
+// agent of Crush.
+const AgentToolName = "crush_agent"
+
+// Server is an MCP server exposing the tools and the agent of an app.
+type Server struct {
+	app    *app.App
+	server *mcp.Server
+
+	mu sync.Mutex
+	// sessions are the Crush sessions of the MCP sessions, and owners the
+	// other way around.
+	sessions map[*mcp.ServerSession]string
+	owners   map[string]*mcp.ServerSession
+}
+
+// New returns a server for app. It answers the permission requests of the
+// app until ctx is done.
+func New(ctx context.Context, app *app.App) *Server {
+	s := &Server{
+		app: app,
+		server: mcp.NewServer(&mcp.Implementation{
+			Name:    "crush",
+			Version: version.Version,
+			Title:   "Crush",
+		}, nil),
+		sessions: map[*mcp.ServerSession]string{},
+		owners:   map[string]*mcp.ServerSession{},
+	}
+	for _, tool := range app.AgentCoordinator.ServerTools() {
+		s.server.AddTool(toolOf(tool.Info()), s.toolHandler(tool))
+	}
+	s.server.AddTool(&mcp.Tool{
+		Name:        AgentToolName,
+		Description: agentToolDescription,
+		InputSchema: agentToolSchema,
+	}, s.runAgent)
+
+	go s.answerPermissions(ctx)
+	return s
+}
+
+// Run serves a single client over t, such as [mcp.StdioTransport], until
+// ctx is done or the client disconnects.
+func (s *Server) Run(ctx context.Context, t mcp.Transport) error {
+	return s.server.Run(ctx, t)
+}
+
+// Handler returns the handler serving clients over streamable HTTP.
+func (s *Server) Handler() http.Handler {
+	return mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return s.server
+	}, nil)
+}
+
+// session returns the Crush session of ss, creating it on first use.
+func (s *Server) session(ctx context.Context, ss *mcp.ServerSession) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.sessions[ss]; ok {
+		return id, nil
+	}
+
+	title := "MCP client"
+	if p := ss.InitializeParams(); p != nil && p.ClientInfo != nil && p.ClientInfo.Name != "" {
+		title = "MCP: " + p.ClientInfo.Name
+	}
+	sess, err := s.app.Sessions.Create(ctx, title)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	s.sessions[ss] = sess.ID
+	s.owners[sess.ID] = ss
+	// Clients can't answer the forms of MCP servers the agent uses.
+	mcptools.DeclineElicitations(sess.ID)
+	slog.Info("Created session for MCP client", "session_id", sess.ID, "title", title)
+
+	go func() {
+		_ = ss.Wait()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.sessions, ss)
+		delete(s.owners, sess.ID)
+	}()
+	return sess.ID, nil
+}
+
+// owner returns the MCP session working in the Crush session sessionID, or
+// in one of its parents, as sub-agents work in sessions of their own.
+func (s *Server) owner(ctx context.Context, sessionID string) (*mcp.ServerSession, bool) {
+	for sessionID != "" {
+		s.mu.Lock()
+		ss, ok := s.owners[sessionID]
+		s.mu.Unlock()
+		if ok {
+			return ss, true
+		}
+		sess, err := s.app.Sessions.Get(ctx, sessionID)
+		if err != nil {
+			return nil, false
+		}
+		sessionID = sess.ParentSessionID
+	}
+	return nil, false
+}
+
+func (s *Server) toolHandler(tool fantasy.AgentTool) mcp.ToolHandler {
+	name := tool.Info().Name
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID, err := s.session(ctx, req.Session)
+		if err != nil {
+			return nil, err
+		}
+		input := string(req.Params.Arguments)
+		if input == "" {
+			input = "{}"
+		}
+
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{
+			ID:    uuid.NewString(),
+			Name:  name,
+			Input: input,
+		})
+		if err != nil {
+			return errorResult(err), nil
+		}
+		return resultOf(resp), nil
+	}
+}
+
+const agentToolDescription = `Runs a task through the Crush coding agent, which works in the project with its own tools, models, and configuration, and returns its final answer.
+
+Calls of the same client continue the same Crush session unless session_id names another one.`
+
+var agentToolSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"prompt": map[string]any{
+			"type":        "string",
+			"description": "The task for the agent",
+		},
+		"session_id": map[string]any{
+			"type":        "string",
+			"description": "The ID of the Crush session to continue, instead of the one of this client",
+		},
+	},
+	"required": []string{"prompt"},
+}
+
+type agentParams struct {
+	Prompt    string `json:"prompt"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+func (s *Server) runAgent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params agentParams
+	if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+		return errorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+	if params.Prompt == "" {
+		return errorResult(errors.New("prompt is required")), nil
+	}
+
+	sessionID := params.SessionID
+	if sessionID == "" {
+		var err error
+		if sessionID, err = s.session(ctx, req.Session); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.app.Sessions.Get(ctx, sessionID); err != nil {
+		return errorResult(fmt.Errorf("session %q not found", sessionID)), nil
+	}
+
+	result, err := s.app.AgentCoordinator.Run(ctx, sessionID, params.Prompt)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	if result == nil {
+		// The session was busy, so the prompt joined its current turn.
+		return textResult("The prompt was queued, as the session is busy with another one."), nil
+	}
+	return textResult(result.Response.Content.Text()), nil
+}
+
+// toolOf returns the MCP tool of a Crush tool.
+func toolOf(info fantasy.ToolInfo) *mcp.Tool {
+	properties := info.Parameters
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(info.Required) > 0 {
+		schema["required"] = info.Required
+	}
+	return &mcp.Tool{
+		Name:        info.Name,
+		Description: info.Description,
+		InputSchema: schema,
+	}
+}
+
+// resultOf returns the MCP result of the response of a Crush tool.
+func resultOf(resp fantasy.ToolResponse) *mcp.CallToolResult {
+	result := &mcp.CallToolResult{IsError: resp.IsError}
+	if resp.Content != "" {
+		result.Content = append(result.Content, &mcp.TextContent{Text: resp.Content})
+	}
+	if resp.Type == "image" {
+		result.Content = append(result.Content, &mcp.ImageContent{Data: resp.Data, MIMEType: resp.MediaType})
+	}
+	if len(result.Content) == 0 {
+		result.Content = []mcp.Content{&mcp.TextContent{}}
+	}
+	return result
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}
+}
+
+func errorResult(err error) *mcp.CallToolResult {
+	result := textResult(err.Error())
+	result.IsError = true
+	return result
+}
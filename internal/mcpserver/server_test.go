@@ -0,0 +1,201 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCoordinator serves a probe tool asking for permission, and answers
+// prompts with their session ID.
+type fakeCoordinator struct {
+	agent.Coordinator
+	permissions permission.Service
+}
+
+type probeParams struct {
+	Path string `json:"path" description:"The path to probe"`
+}
+
+func (c *fakeCoordinator) ServerTools() []fantasy.AgentTool {
+	return []fantasy.AgentTool{
+		fantasy.NewAgentTool("probe", "Probes a path", func(ctx context.Context, params probeParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			granted, err := c.permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:  tools.GetSessionFromContext(ctx),
+				ToolCallID: call.ID,
+				ToolName:   "probe",
+				Action:     "probe",
+				Path:       params.Path,
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !granted {
+				return fantasy.NewTextErrorResponse("denied"), nil
+			}
+			return fantasy.NewTextResponse("granted"), nil
+		}),
+	}
+}
+
+func (c *fakeCoordinator) Run(_ context.Context, sessionID, prompt string, _ ...message.Attachment) (*fantasy.AgentResult, error) {
+	return &fantasy.AgentResult{
+		Response: fantasy.Response{
+			Content: fantasy.ResponseContent{fantasy.TextContent{Text: sessionID + ": " + prompt}},
+		},
+	}, nil
+}
+
+func newTestApp(t *testing.T) *app.App {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	permissions := permission.NewPermissionService(t.TempDir(), false, nil, nil, nil)
+	return &app.App{
+		Sessions:         session.NewService(db.New(conn), conn),
+		Permissions:      permissions,
+		AgentCoordinator: &fakeCoordinator{permissions: permissions},
+	}
+}
+
+// connect connects a client to a server for a, answering elicitations
+// with elicit, if any.
+func connect(t *testing.T, a *app.App, elicit func(*mcp.ElicitRequest) *mcp.ElicitResult) *mcp.ClientSession {
+	t.Helper()
+	srv := New(t.Context(), a)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go func() { _ = srv.Run(t.Context(), serverTransport) }()
+
+	var opts mcp.ClientOptions
+	if elicit != nil {
+		opts.ElicitationHandler = func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			return elicit(req), nil
+		}
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, &opts)
+	cs, err := client.Connect(t.Context(), clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cs.Close() })
+	return cs
+}
+
+func callText(t *testing.T, cs *mcp.ClientSession, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	res, err := cs.CallTool(t.Context(), &mcp.CallToolParams{Name: name, Arguments: args})
+	require.NoError(t, err)
+	require.Len(t, res.Content, 1)
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestListTools(t *testing.T) {
+	t.Parallel()
+
+	cs := connect(t, newTestApp(t), nil)
+	res, err := cs.ListTools(t.Context(), nil)
+	require.NoError(t, err)
+	require.Len(t, res.Tools, 2)
+	require.Equal(t, AgentToolName, res.Tools[0].Name)
+	require.Equal(t, "probe", res.Tools[1].Name)
+
+	schema := res.Tools[1].InputSchema.(map[string]any)
+	require.Equal(t, "object", schema["type"])
+	require.Contains(t, schema["properties"], "path")
+}
+
+func TestPermissions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("granted through elicitation", func(t *testing.T) {
+		t.Parallel()
+		var message string
+		cs := connect(t, newTestApp(t), func(req *mcp.ElicitRequest) *mcp.ElicitResult {
+			message = req.Params.Message
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"remember": false}}
+		})
+		text, isError := callText(t, cs, "probe", map[string]any{"path": "."})
+		require.False(t, isError)
+		require.Equal(t, "granted", text)
+		require.Contains(t, message, "Crush wants to run the probe tool")
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		t.Parallel()
+		cs := connect(t, newTestApp(t), func(*mcp.ElicitRequest) *mcp.ElicitResult {
+			return &mcp.ElicitResult{Action: "decline"}
+		})
+		text, isError := callText(t, cs, "probe", map[string]any{"path": "."})
+		require.True(t, isError)
+		require.Equal(t, "denied", text)
+	})
+
+	t.Run("remembered for the session", func(t *testing.T) {
+		t.Parallel()
+		asked := 0
+		cs := connect(t, newTestApp(t), func(*mcp.ElicitRequest) *mcp.ElicitResult {
+			asked++
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"remember": true}}
+		})
+		for range 2 {
+			text, _ := callText(t, cs, "probe", map[string]any{"path": "."})
+			require.Equal(t, "granted", text)
+		}
+		require.Equal(t, 1, asked)
+	})
+
+	t.Run("denied without elicitation", func(t *testing.T) {
+		t.Parallel()
+		cs := connect(t, newTestApp(t), nil)
+		text, isError := callText(t, cs, "probe", map[string]any{"path": "."})
+		require.True(t, isError)
+		require.Equal(t, "denied", text)
+	})
+}
+
+func TestAgentTool(t *testing.T) {
+	t.Parallel()
+
+	a := newTestApp(t)
+	cs := connect(t, a, nil)
+
+	text, isError := callText(t, cs, AgentToolName, map[string]any{"prompt": "hello"})
+	require.False(t, isError)
+	sessions, err := a.Sessions.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "MCP: test", sessions[0].Title)
+	require.Equal(t, sessions[0].ID+": hello", text)
+
+	text, isError = callText(t, cs, AgentToolName, map[string]any{"prompt": "hello", "session_id": "nope"})
+	require.True(t, isError)
+	require.Equal(t, `session "nope" not found`, text)
+
+	text, isError = callText(t, cs, AgentToolName, map[string]any{"prompt": ""})
+	require.True(t, isError)
+	require.Equal(t, "prompt is required", text)
+}
+
+func TestResultOf(t *testing.T) {
+	t.Parallel()
+
+	res := resultOf(fantasy.NewImageResponse([]byte("png"), "image/png"))
+	require.Len(t, res.Content, 1)
+	require.Equal(t, "image/png", res.Content[0].(*mcp.ImageContent).MIMEType)
+
+	res = resultOf(fantasy.ToolResponse{Type: "text"})
+	require.Equal(t, []mcp.Content{&mcp.TextContent{}}, res.Content)
+
+	res = resultOf(fantasy.NewTextErrorResponse("boom"))
+	require.True(t, res.IsError)
+}
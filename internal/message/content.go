@@ -37,6 +37,12 @@ const (
 	FinishReasonToolUse   FinishReason = "tool_use"
 	FinishReasonCanceled  FinishReason = "canceled"
 	FinishReasonError     FinishReason = "error"
+	// FinishReasonBudgetExceeded means the agent stopped because a
+	// configured spending budget was reached.
+	FinishReasonBudgetExceeded FinishReason = "budget_exceeded"
+	// FinishReasonFailover means the provider kept failing and the turn
+	// carries on with the next fallback model.
+	FinishReasonFailover FinishReason = "failover"
 
 	// Should never happen
 	FinishReasonUnknown FinishReason = "unknown"
@@ -71,6 +77,15 @@ func (tc TextContent) String() string {
 
 func (TextContent) isPart() {}
 
+// ContextContent is context sent to the model along with a user prompt,
+// such as the output of hooks. It is kept apart from the prompt so it
+// isn't shown as something the user wrote.
+type ContextContent struct {
+	Text string `json:"text"`
+}
+
+func (ContextContent) isPart() {}
+
 type ImageURLContent struct {
 	URL    string `json:"url"`
 	Detail string `json:"detail,omitempty"`
@@ -150,6 +165,17 @@ func (m *Message) Content() TextContent {
 	return TextContent{}
 }
 
+// ContextContent returns the context sent along with the message.
+func (m *Message) ContextContent() []ContextContent {
+	var contexts []ContextContent
+	for _, part := range m.Parts {
+		if c, ok := part.(ContextContent); ok {
+			contexts = append(contexts, c)
+		}
+	}
+	return contexts
+}
+
 func (m *Message) ReasoningContent() ReasoningContent {
 	for _, part := range m.Parts {
 		if c, ok := part.(ReasoningContent); ok {
@@ -440,6 +466,12 @@ func (m *Message) AddBinary(mimeType string, data []byte) {
 	m.Parts = append(m.Parts, BinaryContent{MIMEType: mimeType, Data: data})
 }
 
+// ContextMessage returns the message that sends context to the model
+// ahead of the prompt it came with.
+func ContextMessage(text string) fantasy.Message {
+	return fantasy.NewUserMessage("<system_reminder>" + text + "</system_reminder>")
+}
+
 func PromptWithTextAttachments(prompt string, attachments []Attachment) string {
 	var sb strings.Builder
 	sb.WriteString(prompt)
@@ -468,6 +500,9 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 	var messages []fantasy.Message
 	switch m.Role {
 	case User:
+		for _, context := range m.ContextContent() {
+			messages = append(messages, ContextMessage(context.Text))
+		}
 		var parts []fantasy.MessagePart
 		text := strings.TrimSpace(m.Content().Text)
 		var textAttachments []Attachment
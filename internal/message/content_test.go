@@ -140,3 +140,28 @@ func BenchmarkPromptWithTextAttachments(b *testing.B) {
 		})
 	}
 }
+
+func TestToAIMessage_Context(t *testing.T) {
+	t.Parallel()
+
+	msg := &Message{
+		Role: User,
+		Parts: []ContentPart{
+			TextContent{Text: "fix the tests"},
+			ContextContent{Text: "Tests run with make test."},
+		},
+	}
+
+	data, err := marshalParts(msg.Parts)
+	require.NoError(t, err)
+	parts, err := unmarshalParts(data)
+	require.NoError(t, err)
+	require.Equal(t, msg.Parts, parts)
+
+	require.Equal(t, "fix the tests", msg.Content().Text, "context isn't part of the prompt")
+
+	messages := msg.ToAIMessage()
+	require.Len(t, messages, 2)
+	require.Equal(t, ContextMessage("Tests run with make test."), messages[0])
+	require.Equal(t, []fantasy.MessagePart{fantasy.TextPart{Text: "fix the tests"}}, messages[1].Content)
+}
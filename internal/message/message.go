@@ -53,6 +53,7 @@ type Service interface {
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
 
 	// Flush synchronously drains any pending debounced state for the
 	// given message ID, performs the SQL write, and publishes the
@@ -189,6 +190,7 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 	if err != nil {
 		return Message{}, err
 	}
+	s.index(ctx, message)
 	// Clone the message before publishing to avoid race conditions with
 	// concurrent modifications to the Parts slice.
 	s.Publish(pubsub.CreatedEvent, message.Clone())
@@ -407,6 +409,7 @@ func (s *service) write(ctx context.Context, msg Message) error {
 	}); err != nil {
 		return err
 	}
+	s.index(ctx, msg)
 	return nil
 }
 
@@ -521,6 +524,7 @@ type partType string
 const (
 	reasoningType  partType = "reasoning"
 	textType       partType = "text"
+	contextType    partType = "context"
 	imageURLType   partType = "image_url"
 	binaryType     partType = "binary"
 	toolCallType   partType = "tool_call"
@@ -544,6 +548,8 @@ func marshalParts(parts []ContentPart) ([]byte, error) {
 			typ = reasoningType
 		case TextContent:
 			typ = textType
+		case ContextContent:
+			typ = contextType
 		case ImageURLContent:
 			typ = imageURLType
 		case BinaryContent:
@@ -603,6 +609,12 @@ func UnmarshalParts(data []byte) ([]ContentPart, error) {
 				return nil, err
 			}
 			parts = append(parts, part)
+		case contextType:
+			part := ContextContent{}
+			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
 		case imageURLType:
 			part := ImageURLContent{}
 			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
@@ -0,0 +1,139 @@
+package message
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// DefaultSearchLimit is the number of results [Service.Search] returns
+// when no limit is given.
+const DefaultSearchLimit = 50
+
+// Markers delimiting matches in the snippets returned by the full-text
+// index. They are stripped from [SearchResult.Snippet] and turned into
+// [SearchResult.Matches].
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+// SearchResult is a message matching a full-text search.
+type SearchResult struct {
+	MessageID    string
+	SessionID    string
+	SessionTitle string
+	Role         MessageRole
+	CreatedAt    int64
+	// Snippet is the part of the message text around the matches.
+	Snippet string
+	// Matches are the byte ranges of the matched terms in Snippet.
+	Matches [][2]int
+}
+
+// Search finds messages of top-level sessions whose text, tool call
+// inputs, or tool results match query, best matches first. Each word of
+// query must match; words are matched by their stem, so "fixing" finds
+// "fixed".
+func (s *service) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := searchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	rows, err := s.q.SearchMessages(ctx, db.SearchMessagesParams{
+		MatchStart: matchStart,
+		MatchEnd:   matchEnd,
+		Query:      match,
+		Limit:      int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		snippet, matches := parseSnippet(row.Snippet)
+		results[i] = SearchResult{
+			MessageID:    row.ID,
+			SessionID:    row.SessionID,
+			SessionTitle: row.SessionTitle,
+			Role:         MessageRole(row.Role),
+			CreatedAt:    row.CreatedAt,
+			Snippet:      snippet,
+			Matches:      matches,
+		}
+	}
+	return results, nil
+}
+
+// index updates the full-text index entry of msg. Failing to index a
+// message doesn't fail the write; it only makes the message harder to
+// find.
+func (s *service) index(ctx context.Context, msg Message) {
+	if err := s.q.IndexMessage(ctx, db.IndexMessageParams{
+		ID:      msg.ID,
+		Content: SearchText(msg.Parts),
+	}); err != nil {
+		slog.Warn("Failed to index message for search", "message_id", msg.ID, "error", err)
+	}
+}
+
+// SearchText returns the text the search index holds for a message: its
+// text content, tool call names and inputs, and tool results.
+func SearchText(parts []ContentPart) string {
+	var texts []string
+	for _, part := range parts {
+		var text string
+		switch p := part.(type) {
+		case TextContent:
+			text = p.Text
+		case ToolCall:
+			text = p.Name + " " + p.Input
+		case ToolResult:
+			text = p.Content
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// searchQuery turns free text into an FTS5 query matching all of its
+// words. Each word is quoted so punctuation, such as in file names or
+// identifiers, is matched rather than parsed as query syntax.
+func searchQuery(query string) string {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// parseSnippet strips the match markers from a snippet and returns the
+// byte ranges they delimited.
+func parseSnippet(s string) (string, [][2]int) {
+	var (
+		b       strings.Builder
+		matches [][2]int
+		start   = -1
+	)
+	for _, r := range s {
+		switch string(r) {
+		case matchStart:
+			start = b.Len()
+		case matchEnd:
+			if start >= 0 {
+				matches = append(matches, [2]int{start, b.Len()})
+				start = -1
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), matches
+}
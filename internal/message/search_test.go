@@ -0,0 +1,96 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	svc, sessionID := newTestService(t)
+
+	user, err := svc.Create(t.Context(), sessionID, CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: "Why is the migration failing?"}},
+	})
+	require.NoError(t, err)
+
+	assistant, err := svc.Create(t.Context(), sessionID, CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	assistant.AddToolCall(ToolCall{ID: "call-1", Name: "view", Input: `{"file_path":"internal/db/connect.go"}`, Finished: true})
+	require.NoError(t, svc.Update(t.Context(), assistant))
+	require.NoError(t, svc.Flush(t.Context(), assistant.ID))
+
+	tool, err := svc.Create(t.Context(), sessionID, CreateMessageParams{
+		Role:  Tool,
+		Parts: []ContentPart{ToolResult{ToolCallID: "call-1", Name: "view", Content: "func Connect(ctx context.Context, dataDir string)"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("matches text by stem", func(t *testing.T) {
+		results, err := svc.Search(t.Context(), "migrations failed", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, user.ID, results[0].MessageID)
+		require.Equal(t, sessionID, results[0].SessionID)
+		require.Equal(t, "test", results[0].SessionTitle)
+		require.Equal(t, User, results[0].Role)
+		require.Equal(t, "Why is the migration failing?", results[0].Snippet)
+		require.Equal(t, [][2]int{{11, 20}, {21, 28}}, results[0].Matches)
+	})
+
+	t.Run("matches tool call input after update", func(t *testing.T) {
+		results, err := svc.Search(t.Context(), "connect.go", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, assistant.ID, results[0].MessageID)
+	})
+
+	t.Run("matches tool results", func(t *testing.T) {
+		results, err := svc.Search(t.Context(), "dataDir", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, tool.ID, results[0].MessageID)
+	})
+
+	t.Run("requires every word", func(t *testing.T) {
+		results, err := svc.Search(t.Context(), "migration dataDir", 0)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("ignores empty queries", func(t *testing.T) {
+		results, err := svc.Search(t.Context(), "  ", 0)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("forgets deleted messages", func(t *testing.T) {
+		require.NoError(t, svc.Delete(t.Context(), user.ID))
+		results, err := svc.Search(t.Context(), "migration", 0)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+}
+
+func TestSearchQuery(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", searchQuery("   "))
+	require.Equal(t, `"fix" "bug"`, searchQuery("fix  bug"))
+	require.Equal(t, `"say" """hi""" "OR" "a*"`, searchQuery(`say "hi" OR a*`))
+}
+
+func TestParseSnippet(t *testing.T) {
+	t.Parallel()
+
+	snippet, matches := parseSnippet("…the \x02migration\x03 is \x02failing\x03")
+	require.Equal(t, "…the migration is failing", snippet)
+	require.Equal(t, [][2]int{{7, 16}, {20, 27}}, matches)
+
+	snippet, matches = parseSnippet("no matches")
+	require.Equal(t, "no matches", snippet)
+	require.Empty(t, matches)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/telemetry"
 	"github.com/google/uuid"
 )
 
@@ -41,6 +42,46 @@ func hookApproved(ctx context.Context, toolCallID string) bool {
 	return v == toolCallID
 }
 
+// policyApprovalKey is the unexported context key used to mark a tool call
+// whose policy rule was already applied before the tool ran.
+type policyApprovalKey struct{}
+
+// WithPolicyApproval returns a context that marks the given tool call ID as
+// granted by a policy check made before the tool ran, so a policy rule
+// asking about the call doesn't prompt a second time.
+func WithPolicyApproval(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, policyApprovalKey{}, toolCallID)
+}
+
+// policyApproved reports whether the context carries a policy approval for
+// the given tool call ID.
+func policyApproved(ctx context.Context, toolCallID string) bool {
+	if toolCallID == "" {
+		return false
+	}
+	v, _ := ctx.Value(policyApprovalKey{}).(string)
+	return v == toolCallID
+}
+
+// policyWorkingDirKey is the unexported context key for the directory
+// relative policy patterns are matched in.
+type policyWorkingDirKey struct{}
+
+// WithPolicyWorkingDir returns a context whose permission requests match
+// relative policy patterns against paths relative to workingDir, such as
+// the worktree of a session, instead of the workspace's working directory.
+func WithPolicyWorkingDir(ctx context.Context, workingDir string) context.Context {
+	return context.WithValue(ctx, policyWorkingDirKey{}, workingDir)
+}
+
+// policyFor returns the policy to apply to the requests of ctx.
+func (s *permissionService) policyFor(ctx context.Context) *Policy {
+	if dir, _ := ctx.Value(policyWorkingDirKey{}).(string); dir != "" {
+		return s.policy.WithWorkingDir(dir)
+	}
+	return s.policy
+}
+
 type CreatePermissionRequest struct {
 	SessionID   string `json:"session_id"`
 	ToolCallID  string `json:"tool_call_id"`
@@ -49,6 +90,9 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// ReadOnly marks a call that can't change anything, which is granted
+	// without asking unless a policy rule denies it or asks.
+	ReadOnly bool `json:"read_only,omitempty"`
 }
 
 type PermissionNotification struct {
@@ -68,6 +112,8 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// Rule is the policy rule that required asking, if any.
+	Rule string `json:"rule,omitempty"`
 }
 
 type Service interface {
@@ -85,6 +131,8 @@ type Service interface {
 	DeleteRule(ctx context.Context, id int64) error
 	ListSessionPermissions(sessionID string) []PermissionRequest
 	DeleteSessionPermission(sessionID string, permissionID string)
+	// Policy returns the permission policy, which may be nil.
+	Policy() *Policy
 }
 
 // PermissionKey is a composite key for session permission lookups.
@@ -109,6 +157,7 @@ type permissionService struct {
 	autoApproveSessionsMu sync.RWMutex
 	skip                  atomic.Bool
 	allowedTools          []string
+	policy                *Policy
 
 	// used to make sure we only process one request at a time
 	requestMu       sync.Mutex
@@ -214,14 +263,48 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 	s.activeRequestMu.Unlock()
 }
 
-func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) (bool, error) {
+func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) (granted bool, err error) {
+	ctx, end := telemetry.Permission(ctx, opts.ToolName, opts.Action)
+	defer func() { end(granted, err) }()
+
+	// Policy denials hold even when permission requests are skipped.
+	rule, matched := s.policyFor(ctx).Evaluate(opts.ToolName, opts.Params, opts.Path)
+	if matched && rule.Decision == DecisionDeny {
+		slog.Info("Permission denied by policy", "tool", opts.ToolName, "rule", rule.String())
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID:  opts.ToolCallID,
+			Denied:      true,
+			Description: rule.String(),
+		})
+		return false, nil
+	}
+
 	if s.skip.Load() {
 		return true, nil
 	}
 
+	// The rule was applied before the tool ran, asking if it had to.
+	if matched && policyApproved(ctx, opts.ToolCallID) {
+		return true, nil
+	}
+
+	if matched && rule.Decision == DecisionAllow {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID:   opts.ToolCallID,
+			Granted:      true,
+			AutoApproved: true,
+			Description:  opts.Description,
+		})
+		return true, nil
+	}
+	ask := matched && rule.Decision == DecisionAsk
+	if !ask && opts.ReadOnly {
+		return true, nil
+	}
+
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if !ask && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
 		return true, nil
 	}
 
@@ -280,8 +363,11 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		Action:      opts.Action,
 		Params:      opts.Params,
 	}
+	if ask {
+		permission.Rule = rule.String()
+	}
 
-	if s.queries != nil {
+	if s.queries != nil && !ask {
 		_, err := s.queries.MatchPermissionRule(ctx, db.MatchPermissionRuleParams{
 			ToolName: permission.ToolName,
 			Action:   permission.Action,
@@ -305,7 +391,7 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		ToolName:  permission.ToolName,
 		Action:    permission.Action,
 		Path:      permission.Path,
-	}); ok {
+	}); ok && !ask {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -399,7 +485,13 @@ func (s *permissionService) DeleteRule(ctx context.Context, id int64) error {
 	return s.queries.DeletePermissionRule(ctx, id)
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string, queries *db.Queries) Service {
+func (s *permissionService) Policy() *Policy {
+	return s.policy
+}
+
+// NewPermissionService creates a permission service. policy, which may be
+// nil, is evaluated before any other source of approval.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, queries *db.Queries, policy *Policy) Service {
 	svc := &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
@@ -408,6 +500,7 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string, q
 		sessionPermissions:  csync.NewMap[PermissionKey, bool](),
 		autoApproveSessions: make(map[string]bool),
 		allowedTools:        allowedTools,
+		policy:              policy,
 		pendingRequests:     csync.NewMap[string, chan bool](),
 	}
 	svc.skip.Store(skip)
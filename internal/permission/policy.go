@@ -0,0 +1,412 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Decision is what a policy rule decides for the tool calls it matches.
+type Decision string
+
+const (
+	// DecisionAllow grants the call without prompting.
+	DecisionAllow Decision = "allow"
+	// DecisionDeny rejects the call without prompting, even when
+	// permission requests are skipped.
+	DecisionDeny Decision = "deny"
+	// DecisionAsk always prompts, ignoring the allowlist, persisted rules,
+	// and grants earlier in the session.
+	DecisionAsk Decision = "ask"
+)
+
+// PolicyRule is a rule of a permission policy. Match is a tool name,
+// which may contain * wildcards, optionally followed by a pattern in
+// parentheses:
+//
+//   - bash(git status*) matches the command, where * matches anything.
+//     Commands chained with ;, &&, ||, |, or newlines, or run in
+//     subshells, are matched one by one.
+//   - edit(internal/**/*.go) matches the file path with a glob, relative
+//     to the working directory unless the pattern is absolute.
+//   - fetch(https://example.com/*) matches the URL, like commands.
+//   - Any pattern wrapped in slashes, such as bash(/^rm\s+-rf/), is a
+//     regular expression matched against all of the above.
+type PolicyRule struct {
+	Decision Decision `json:"decision"`
+	Match    string   `json:"match"`
+	Reason   string   `json:"reason,omitempty"`
+
+	// Source is the policy file the rule was loaded from.
+	Source string `json:"-"`
+
+	tool    string
+	pattern string
+	re      *regexp.Regexp
+}
+
+// String returns the rule as shown to users, e.g. "deny bash(rm -rf*)".
+func (r PolicyRule) String() string {
+	s := string(r.Decision) + " " + r.Match
+	if r.Reason != "" {
+		s += ": " + r.Reason
+	}
+	return s
+}
+
+// compile parses the rule's Match.
+func (r *PolicyRule) compile() error {
+	switch r.Decision {
+	case DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		return fmt.Errorf("invalid decision %q: must be allow, deny, or ask", r.Decision)
+	}
+
+	match := strings.TrimSpace(r.Match)
+	r.tool, r.pattern = match, ""
+	if i := strings.IndexByte(match, '('); i >= 0 {
+		if !strings.HasSuffix(match, ")") {
+			return fmt.Errorf("invalid match %q: missing closing parenthesis", r.Match)
+		}
+		r.tool, r.pattern = match[:i], match[i+1:len(match)-1]
+	}
+	if r.tool == "" {
+		return fmt.Errorf("invalid match %q: missing tool name", r.Match)
+	}
+	if _, err := path.Match(r.tool, ""); err != nil {
+		return fmt.Errorf("invalid match %q: %w", r.Match, err)
+	}
+
+	if expr, ok := regexPattern(r.pattern); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid match %q: %w", r.Match, err)
+		}
+		r.re = re
+	} else if r.pattern != "" && !doublestar.ValidatePattern(r.pattern) {
+		return fmt.Errorf("invalid match %q: bad glob pattern", r.Match)
+	}
+	return nil
+}
+
+// regexPattern reports whether pattern is a regular expression wrapped in
+// slashes and returns the expression.
+func regexPattern(pattern string) (string, bool) {
+	if len(pattern) < 2 || !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(pattern, "/") {
+		return "", false
+	}
+	return pattern[1 : len(pattern)-1], true
+}
+
+// Policy is an ordered list of permission rules. The first rule of each
+// policy file that matches a tool call is that file's decision, and the
+// first file's decision stands unless another file denies the call, so
+// no file can allow what another denies. A nil Policy matches nothing.
+type Policy struct {
+	workingDir string
+	rules      []PolicyRule
+}
+
+// policyFile is the format of a permission policy file.
+type policyFile struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// NewPolicy creates a policy from rules. Relative path patterns are
+// matched against paths relative to workingDir.
+func NewPolicy(workingDir string, rules []PolicyRule) (*Policy, error) {
+	p := &Policy{workingDir: workingDir}
+	for i, r := range rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// LoadPolicy reads the policy files at paths, in order, and combines their
+// rules into one policy. Missing files are skipped.
+func LoadPolicy(workingDir string, paths ...string) (*Policy, error) {
+	var rules []PolicyRule
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var file policyFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		for i := range file.Rules {
+			file.Rules[i].Source = p
+			if err := file.Rules[i].compile(); err != nil {
+				return nil, fmt.Errorf("%s: rule %d: %w", p, i+1, err)
+			}
+		}
+		rules = append(rules, file.Rules...)
+	}
+	return &Policy{workingDir: workingDir, rules: rules}, nil
+}
+
+// Rules returns the rules of the policy in evaluation order.
+func (p *Policy) Rules() []PolicyRule {
+	if p == nil {
+		return nil
+	}
+	return p.rules
+}
+
+// WithWorkingDir returns a copy of the policy matching relative path
+// patterns against paths relative to workingDir instead.
+func (p *Policy) WithWorkingDir(workingDir string) *Policy {
+	if p == nil || p.workingDir == workingDir {
+		return p
+	}
+	return &Policy{workingDir: workingDir, rules: p.rules}
+}
+
+// Evaluate returns the rule deciding a call of the named tool with the
+// given input, which is either the tool's permission params or its raw
+// JSON input. path is the path the call operates on when the input names
+// none.
+func (p *Policy) Evaluate(toolName string, input any, path string) (PolicyRule, bool) {
+	if p == nil || len(p.rules) == 0 {
+		return PolicyRule{}, false
+	}
+	subject := newPolicySubject(input, path)
+	if subject.command == "" {
+		return p.evaluate(toolName, subject, false)
+	}
+	rule, found := p.evaluate(toolName, subject, true)
+	if found && rule.Decision == DecisionDeny {
+		return rule, true
+	}
+
+	// A command chaining others is denied when any of them is, asks when
+	// any asks, and is allowed only when all of them are. Deny and ask
+	// rules are also matched against the whole command, so patterns may
+	// span several commands.
+	var (
+		allowed, asked       PolicyRule
+		allowFound, askFound bool
+		undecided            bool
+	)
+	if found && rule.Decision == DecisionAsk {
+		asked, askFound = rule, true
+	}
+	for _, command := range splitCommand(subject.command) {
+		s := subject
+		s.command = command
+		r, ok := p.evaluate(toolName, s, false)
+		switch {
+		case !ok:
+			undecided = true
+		case r.Decision == DecisionDeny:
+			return r, true
+		case r.Decision == DecisionAsk:
+			if !askFound {
+				asked, askFound = r, true
+			}
+		case !allowFound:
+			allowed, allowFound = r, true
+		}
+	}
+	switch {
+	case askFound:
+		return asked, true
+	case undecided || !allowFound:
+		return PolicyRule{}, false
+	}
+	return allowed, true
+}
+
+// evaluate returns the rule deciding a call on subject, without looking
+// into the commands a command chains. restrictOnly skips allow rules.
+func (p *Policy) evaluate(toolName string, subject policySubject, restrictOnly bool) (PolicyRule, bool) {
+	var (
+		decided PolicyRule
+		found   bool
+		matched = map[string]bool{}
+	)
+	for _, r := range p.rules {
+		if matched[r.Source] || (restrictOnly && r.Decision == DecisionAllow) || !p.matches(r, toolName, subject) {
+			continue
+		}
+		matched[r.Source] = true
+		if r.Decision == DecisionDeny {
+			return r, true
+		}
+		if !found {
+			decided, found = r, true
+		}
+	}
+	return decided, found
+}
+
+func (p *Policy) matches(r PolicyRule, toolName string, s policySubject) bool {
+	if ok, _ := path.Match(r.tool, toolName); !ok {
+		return false
+	}
+	if r.pattern == "" {
+		return true
+	}
+	if r.re != nil {
+		for _, v := range s.all() {
+			if r.re.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range []string{s.command, s.url} {
+		if v != "" && matchWildcard(r.pattern, v) {
+			return true
+		}
+	}
+	for _, v := range s.paths {
+		if p.matchPath(r.pattern, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches a glob against a path. Relative globs match paths
+// inside the working directory.
+func (p *Policy) matchPath(pattern, name string) bool {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(p.workingDir, name)
+	}
+	name = filepath.Clean(name)
+	if filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "/") {
+		ok, _ := doublestar.Match(pattern, filepath.ToSlash(name))
+		return ok
+	}
+	rel, err := filepath.Rel(p.workingDir, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	ok, _ := doublestar.Match(pattern, filepath.ToSlash(rel))
+	return ok
+}
+
+// matchWildcard reports whether s matches pattern in full, where *
+// matches any run of characters.
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return s == pattern
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
+
+// splitCommand splits a shell command into the simple commands it runs,
+// including those of pipelines, lists, and subshells and command
+// substitutions, with surrounding whitespace trimmed. A command that
+// can't be parsed is returned whole.
+func splitCommand(command string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return []string{strings.TrimSpace(command)}
+	}
+	var (
+		commands []string
+		printer  = syntax.NewPrinter()
+	)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		stmt, ok := node.(*syntax.Stmt)
+		if !ok {
+			return true
+		}
+		switch stmt.Cmd.(type) {
+		case *syntax.BinaryCmd, *syntax.Block, *syntax.Subshell:
+			return true
+		}
+		single := *stmt
+		single.Comments = nil
+		single.Negated, single.Background, single.Coprocess = false, false, false
+		var sb strings.Builder
+		if err := printer.Print(&sb, &single); err == nil {
+			commands = append(commands, strings.TrimSpace(sb.String()))
+		}
+		return true
+	})
+	return commands
+}
+
+// policySubject is what the patterns of policy rules are matched against.
+type policySubject struct {
+	command string
+	url     string
+	paths   []string
+}
+
+func newPolicySubject(input any, fallbackPath string) policySubject {
+	var raw []byte
+	switch v := input.(type) {
+	case nil:
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		raw, _ = json.Marshal(v)
+	}
+	var fields struct {
+		Command  string `json:"command"`
+		URL      string `json:"url"`
+		FilePath string `json:"file_path"`
+		Path     string `json:"path"`
+	}
+	_ = json.Unmarshal(raw, &fields)
+
+	s := policySubject{command: fields.Command, url: fields.URL}
+	for _, p := range []string{fields.FilePath, fields.Path} {
+		if p != "" {
+			s.paths = append(s.paths, p)
+		}
+	}
+	// Commands and URLs are what bash and fetch calls are about; the
+	// directory they run in isn't matched.
+	if len(s.paths) == 0 && s.command == "" && s.url == "" && fallbackPath != "" {
+		s.paths = append(s.paths, fallbackPath)
+	}
+	return s
+}
+
+func (s policySubject) all() []string {
+	var out []string
+	for _, v := range []string{s.command, s.url} {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return append(out, s.paths...)
+}
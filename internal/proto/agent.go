@@ -12,6 +12,9 @@ const (
 	AgentEventTypeError     AgentEventType = "error"
 	AgentEventTypeResponse  AgentEventType = "response"
 	AgentEventTypeSummarize AgentEventType = "summarize"
+
+	AgentEventTypeBudgetWarning  AgentEventType = "budget_warning"
+	AgentEventTypeBudgetExceeded AgentEventType = "budget_exceeded"
 )
 
 // MarshalText implements the [encoding.TextMarshaler] interface.
@@ -36,6 +39,9 @@ type AgentEvent struct {
 	SessionTitle string `json:"session_title,omitempty"`
 	Progress     string `json:"progress,omitempty"`
 	Done         bool   `json:"done,omitempty"`
+
+	// When warning about or stopping at a spending budget.
+	Details string `json:"details,omitempty"`
 }
 
 // MarshalJSON implements the [json.Marshaler] interface.
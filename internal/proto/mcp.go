@@ -170,3 +170,42 @@ func (i *MCPClientInfo) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// MCPElicitation is a form an MCP server asks the user to fill in.
+type MCPElicitation struct {
+	ID         string                `json:"id"`
+	Name       string                `json:"name"`
+	SessionID  string                `json:"session_id"`
+	ToolCallID string                `json:"tool_call_id"`
+	Message    string                `json:"message"`
+	Fields     []MCPElicitationField `json:"fields,omitempty"`
+}
+
+// MCPElicitationField is a field of the form of an MCP elicitation.
+type MCPElicitationField struct {
+	Name         string   `json:"name"`
+	Title        string   `json:"title,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Type         string   `json:"type"`
+	Format       string   `json:"format,omitempty"`
+	Required     bool     `json:"required,omitempty"`
+	Options      []string `json:"options,omitempty"`
+	OptionTitles []string `json:"option_titles,omitempty"`
+	Default      string   `json:"default,omitempty"`
+}
+
+// MCPElicitationResponse is the answer of the user to an MCP elicitation.
+type MCPElicitationResponse struct {
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// MCPProgress is the progress an MCP server reports on a running tool call.
+type MCPProgress struct {
+	Name       string  `json:"name"`
+	SessionID  string  `json:"session_id"`
+	ToolCallID string  `json:"tool_call_id"`
+	Progress   float64 `json:"progress"`
+	Total      float64 `json:"total,omitempty"`
+	Message    string  `json:"message,omitempty"`
+}
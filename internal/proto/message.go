@@ -31,6 +31,18 @@ type Message struct {
 	UpdatedAt int64         `json:"updated_at"`
 }
 
+// SearchResult represents a message matching a full-text search.
+type SearchResult struct {
+	MessageID    string      `json:"message_id"`
+	SessionID    string      `json:"session_id"`
+	SessionTitle string      `json:"session_title"`
+	Role         MessageRole `json:"role"`
+	CreatedAt    int64       `json:"created_at"`
+	Snippet      string      `json:"snippet"`
+	// Matches are the byte ranges of the matched terms in Snippet.
+	Matches [][2]int `json:"matches,omitempty"`
+}
+
 // MessageRole represents the role of a message sender.
 type MessageRole string
 
@@ -56,12 +68,14 @@ func (r *MessageRole) UnmarshalText(data []byte) error {
 type FinishReason string
 
 const (
-	FinishReasonEndTurn   FinishReason = "end_turn"
-	FinishReasonMaxTokens FinishReason = "max_tokens"
-	FinishReasonToolUse   FinishReason = "tool_use"
-	FinishReasonCanceled  FinishReason = "canceled"
-	FinishReasonError     FinishReason = "error"
-	FinishReasonUnknown   FinishReason = "unknown"
+	FinishReasonEndTurn        FinishReason = "end_turn"
+	FinishReasonMaxTokens      FinishReason = "max_tokens"
+	FinishReasonToolUse        FinishReason = "tool_use"
+	FinishReasonCanceled       FinishReason = "canceled"
+	FinishReasonError          FinishReason = "error"
+	FinishReasonBudgetExceeded FinishReason = "budget_exceeded"
+	FinishReasonFailover       FinishReason = "failover"
+	FinishReasonUnknown        FinishReason = "unknown"
 )
 
 // MarshalText implements the [encoding.TextMarshaler] interface.
@@ -614,6 +628,63 @@ func UnmarshalParts(data []byte) ([]ContentPart, error) {
 	return parts, nil
 }
 
+// MessageFromMessage converts a [message.Message] to a proto Message.
+func MessageFromMessage(m message.Message) Message {
+	msg := Message{
+		ID:        m.ID,
+		SessionID: m.SessionID,
+		Role:      MessageRole(m.Role),
+		Model:     m.Model,
+		Provider:  m.Provider,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+
+	for _, p := range m.Parts {
+		switch v := p.(type) {
+		case message.TextContent:
+			msg.Parts = append(msg.Parts, TextContent{Text: v.Text})
+		case message.ReasoningContent:
+			msg.Parts = append(msg.Parts, ReasoningContent{
+				Thinking:   v.Thinking,
+				Signature:  v.Signature,
+				StartedAt:  v.StartedAt,
+				FinishedAt: v.FinishedAt,
+			})
+		case message.ToolCall:
+			msg.Parts = append(msg.Parts, ToolCall{
+				ID:       v.ID,
+				Name:     v.Name,
+				Input:    v.Input,
+				Finished: v.Finished,
+			})
+		case message.ToolResult:
+			msg.Parts = append(msg.Parts, ToolResult{
+				ToolCallID: v.ToolCallID,
+				Name:       v.Name,
+				Content:    v.Content,
+				Data:       v.Data,
+				MIMEType:   v.MIMEType,
+				Metadata:   v.Metadata,
+				IsError:    v.IsError,
+			})
+		case message.Finish:
+			msg.Parts = append(msg.Parts, Finish{
+				Reason:  FinishReason(v.Reason),
+				Time:    v.Time,
+				Message: v.Message,
+				Details: v.Details,
+			})
+		case message.ImageURLContent:
+			msg.Parts = append(msg.Parts, ImageURLContent{URL: v.URL, Detail: v.Detail})
+		case message.BinaryContent:
+			msg.Parts = append(msg.Parts, BinaryContent{Path: v.Path, MIMEType: v.MIMEType, Data: v.Data})
+		}
+	}
+
+	return msg
+}
+
 // Attachment represents a file attachment.
 type Attachment struct {
 	FilePath string `json:"file_path"`
@@ -32,6 +32,7 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	Rule        string `json:"rule,omitempty"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface. This is needed
@@ -124,6 +125,12 @@ func unmarshalToolParams(toolName string, raw json.RawMessage) (any, error) {
 			return nil, err
 		}
 		return params, nil
+	case RenameToolName, CodeActionToolName:
+		var params WorkspaceEditPermissionsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
 	case ViewToolName:
 		var params ViewPermissionsParams
 		if err := json.Unmarshal(raw, &params); err != nil {
@@ -157,6 +157,23 @@ func TestPermissionRequestParamsTypeAssertable(t *testing.T) {
 				require.Equal(t, "summarize this page", v.Prompt)
 			},
 		},
+		{
+			name:     "lsp_rename",
+			toolName: tools.RenameToolName,
+			params: tools.WorkspaceEditPermissionsParams{
+				Files: []tools.WorkspaceEditFile{
+					{FilePath: "/tmp/a.go", OldContent: "foo", NewContent: "bar", Additions: 1, Removals: 1},
+					{FilePath: "/tmp/b.go", OldContent: "foo()", NewContent: "bar()", Additions: 1, Removals: 1},
+				},
+			},
+			assert: func(t *testing.T, got any) {
+				v, ok := got.(tools.WorkspaceEditPermissionsParams)
+				require.True(t, ok, "params must decode as tools.WorkspaceEditPermissionsParams, got %T", got)
+				require.Len(t, v.Files, 2)
+				require.Equal(t, "/tmp/b.go", v.Files[1].FilePath)
+				require.Equal(t, "bar()", v.Files[1].NewContent)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,55 @@
+package proto
+
+// RunEventType represents the type of a [RunEvent].
+type RunEventType string
+
+const (
+	RunEventTypeMessageDelta      RunEventType = "message_delta"
+	RunEventTypeToolCall          RunEventType = "tool_call"
+	RunEventTypeToolResult        RunEventType = "tool_result"
+	RunEventTypePermissionRequest RunEventType = "permission_request"
+	RunEventTypeResult            RunEventType = "result"
+)
+
+// RunEvent is a single line of `crush run --output-format stream-json`
+// output. Only the field matching the event type is set.
+type RunEvent struct {
+	Type      RunEventType `json:"type"`
+	SessionID string       `json:"session_id"`
+	MessageID string       `json:"message_id,omitempty"`
+
+	// Text appended to an assistant message.
+	Delta string `json:"delta,omitempty"`
+
+	ToolCall          *ToolCall          `json:"tool_call,omitempty"`
+	ToolResult        *ToolResult        `json:"tool_result,omitempty"`
+	PermissionRequest *PermissionRequest `json:"permission_request,omitempty"`
+	Result            *RunResult         `json:"result,omitempty"`
+}
+
+// RunResult is the outcome of a non-interactive run. It is the whole
+// output of `crush run --output-format json` and the last event of
+// `--output-format stream-json`.
+type RunResult struct {
+	SessionID    string        `json:"session_id"`
+	Result       string        `json:"result"`
+	FinishReason FinishReason  `json:"finish_reason,omitempty"`
+	IsError      bool          `json:"is_error"`
+	Error        string        `json:"error,omitempty"`
+	Usage        RunUsage      `json:"usage"`
+	Cost         float64       `json:"cost"`
+	ToolCalls    []RunToolCall `json:"tool_calls"`
+}
+
+// RunUsage holds the token counts of the session after a run.
+type RunUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// RunToolCall is a tool call made during a run along with its result, if
+// one was received.
+type RunToolCall struct {
+	Call   ToolCall    `json:"call"`
+	Result *ToolResult `json:"result,omitempty"`
+}
@@ -13,6 +13,9 @@ type Session struct {
 	Todos            []Todo  `json:"todos,omitempty"`
 	CreatedAt        int64   `json:"created_at"`
 	UpdatedAt        int64   `json:"updated_at"`
+
+	ForkedFromSessionID string `json:"forked_from_session_id,omitempty"`
+	ForkedAtMessageID   string `json:"forked_at_message_id,omitempty"`
 }
 
 // Todo represents a single todo entry on a session in the proto layer.
@@ -21,3 +24,53 @@ type Todo struct {
 	Status     string `json:"status"`
 	ActiveForm string `json:"active_form"`
 }
+
+// SessionForkRequest represents a request to branch a new session off a
+// session at a message or tool call.
+type SessionForkRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+// SessionRewindRequest represents a request to roll back the files a
+// session changed to the state before a message or tool call.
+type SessionRewindRequest struct {
+	MessageID string `json:"message_id"`
+	Force     bool   `json:"force,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+}
+
+// RewindChange represents a single file touched by a session rewind.
+type RewindChange struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Conflict bool   `json:"conflict,omitempty"`
+}
+
+// SessionRewindResult represents the outcome of a session rewind.
+type SessionRewindResult struct {
+	Changes []RewindChange `json:"changes"`
+	Applied bool           `json:"applied"`
+}
+
+// Checkpoint represents a snapshot of the working tree taken at the start
+// of a turn of a session.
+type Checkpoint struct {
+	Number    int    `json:"number"`
+	SessionID string `json:"session_id"`
+	Commit    string `json:"commit"`
+	Prompt    string `json:"prompt"`
+	Dir       string `json:"dir"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// CheckpointDiff represents the changes made during a turn of a session.
+type CheckpointDiff struct {
+	Diff string `json:"diff"`
+}
+
+// CheckpointChange represents a single file changed by a checkpoint
+// restore.
+type CheckpointChange struct {
+	Path    string `json:"path"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
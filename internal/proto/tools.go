@@ -99,6 +99,16 @@ const AgenticFetchToolName = tools.AgenticFetchToolName
 // agentic_fetch tool.
 type AgenticFetchPermissionsParams = tools.AgenticFetchPermissionsParams
 
+// RenameToolName is the name of the lsp_rename tool.
+const RenameToolName = tools.RenameToolName
+
+// CodeActionToolName is the name of the lsp_code_action tool.
+const CodeActionToolName = tools.CodeActionToolName
+
+// WorkspaceEditPermissionsParams represents the permission parameters for the
+// lsp_rename and lsp_code_action tools.
+type WorkspaceEditPermissionsParams = tools.WorkspaceEditPermissionsParams
+
 const GlobToolName = "glob"
 
 // GlobParams represents the parameters for the glob tool.
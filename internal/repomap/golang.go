@@ -0,0 +1,173 @@
+package repomap
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterParser(goParser{})
+}
+
+// maxTypeLen is the longest type declaration shown. Longer ones, such as
+// function types with many parameters, are cut short.
+const maxTypeLen = 100
+
+// goParser outlines Go files with go/ast. Symbols are named after their
+// package, like "repomap.New", and methods after their name only, like
+// ".Render", which is what selector expressions refer to. Test files only
+// contribute their references, so tests don't crowd out the code they
+// test.
+type goParser struct{}
+
+func (goParser) Extensions() []string {
+	return []string{".go"}
+}
+
+func (goParser) Parse(path string, src []byte) (Outline, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if f == nil {
+		return Outline{}, err
+	}
+	pkg := f.Name.Name
+
+	var outline Outline
+	if !strings.HasSuffix(path, "_test.go") {
+		outline.Symbols = goSymbols(fset, f, pkg)
+	}
+
+	imports := map[string]bool{}
+	for _, spec := range f.Imports {
+		imports[goImportName(spec)] = true
+	}
+	refs := map[string]bool{}
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := n.X.(*ast.Ident); ok && imports[id.Name] {
+				refs[id.Name+"."+n.Sel.Name] = true
+				return false
+			}
+			refs["."+n.Sel.Name] = true
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Ident:
+			if n.Name != "_" {
+				refs[pkg+"."+n.Name] = true
+			}
+		}
+		return true
+	}
+	for _, decl := range f.Decls {
+		ast.Inspect(decl, visit)
+	}
+	outline.Refs = slices.Sorted(maps.Keys(refs))
+	return outline, err
+}
+
+// goImportName returns the name an import is referred to by, guessing the
+// package name from the import path when there is no explicit name.
+func goImportName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	// gopkg.in/yaml.v3 is yaml, and github.com/go-git/go-git is git.
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndexByte(name, '-'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func goSymbols(fset *token.FileSet, f *ast.File, pkg string) []Symbol {
+	var symbols []Symbol
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			kind, name := KindFunc, pkg+"."+decl.Name.Name
+			if decl.Recv != nil {
+				kind, name = KindMethod, "."+decl.Name.Name
+			}
+			symbols = append(symbols, Symbol{
+				Name:      name,
+				Kind:      kind,
+				Line:      fset.Position(decl.Pos()).Line,
+				Signature: goNode(fset, &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}),
+			})
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, Symbol{
+						Name:      pkg + "." + spec.Name.Name,
+						Kind:      KindType,
+						Line:      fset.Position(spec.Pos()).Line,
+						Signature: goTypeSignature(fset, spec),
+					})
+				case *ast.ValueSpec:
+					kind := KindVar
+					if decl.Tok == token.CONST {
+						kind = KindConst
+					}
+					for _, name := range spec.Names {
+						if name.Name == "_" {
+							continue
+						}
+						symbols = append(symbols, Symbol{
+							Name:      pkg + "." + name.Name,
+							Kind:      kind,
+							Line:      fset.Position(name.Pos()).Line,
+							Signature: string(kind) + " " + name.Name,
+						})
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+func goTypeSignature(fset *token.FileSet, spec *ast.TypeSpec) string {
+	short := *spec
+	short.Doc, short.Comment = nil, nil
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		short.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		short.Type = ast.NewIdent("interface")
+	}
+	s := goNode(fset, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&short}})
+	if len(s) > maxTypeLen {
+		s = s[:maxTypeLen] + "…"
+	}
+	return s
+}
+
+// goNode prints node on a single line.
+func goNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ""
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	s = strings.ReplaceAll(s, ", )", ")")
+	s = strings.ReplaceAll(s, "( ", "(")
+	return s
+}
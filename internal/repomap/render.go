@@ -0,0 +1,163 @@
+package repomap
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RenderOptions configures how a map is rendered.
+type RenderOptions struct {
+	// Path limits the map to a file or directory, relative to the root of
+	// the map. Empty means the whole map.
+	Path string
+	// MaxTokens is the approximate size of the map. The least relevant
+	// files and symbols are left out to stay under it. Zero means no
+	// limit.
+	MaxTokens int
+	// Lines prefixes symbols with their line number.
+	Lines bool
+}
+
+// Result is a rendered map.
+type Result struct {
+	Text string
+	// Files is the number of files in the map.
+	Files int
+	// Truncated reports whether files or symbols were left out to stay
+	// under the token budget.
+	Truncated bool
+}
+
+type rankedSymbol struct {
+	Symbol
+	file  string
+	score float64
+}
+
+// Render renders the map. The symbols most referred to by other files
+// are kept first, and files are listed from the one whose symbols are the
+// most referred to.
+func (m *Map) Render(opts RenderOptions) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	focus := strings.Trim(path.Clean(filepath.ToSlash(opts.Path)), "/")
+	if focus == "." {
+		focus = ""
+	}
+
+	// The number of files referring to and defining each name.
+	refs := map[string]int{}
+	defs := map[string]int{}
+	for _, f := range m.files {
+		for _, ref := range f.Refs {
+			refs[ref]++
+		}
+		for _, sym := range f.Symbols {
+			defs[sym.Name]++
+		}
+	}
+
+	var paths []string
+	var symbols []rankedSymbol
+	for rel, f := range m.files {
+		if focus != "" && rel != focus && !strings.HasPrefix(rel, focus+"/") {
+			continue
+		}
+		paths = append(paths, rel)
+		for _, sym := range f.Symbols {
+			n := refs[sym.Name]
+			if _, ok := slices.BinarySearch(f.Refs, sym.Name); ok {
+				n--
+			}
+			// Names defined in many files, like String, are shared
+			// between their references.
+			symbols = append(symbols, rankedSymbol{
+				Symbol: sym,
+				file:   rel,
+				score:  float64(n) / float64(defs[sym.Name]),
+			})
+		}
+	}
+	slices.SortStableFunc(symbols, func(a, b rankedSymbol) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			strings.Compare(a.file, b.file),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+
+	// Keep the most relevant symbols, and the files they are in, while
+	// they fit.
+	result := Result{Files: len(paths)}
+	tokens := 0
+	kept := map[string][]rankedSymbol{}
+	fileScores := map[string]float64{}
+	for _, sym := range symbols {
+		n := estimateTokens(symbolLine(sym.Symbol, opts.Lines))
+		if _, ok := kept[sym.file]; !ok {
+			n += estimateTokens(sym.file + "\n")
+		}
+		if opts.MaxTokens > 0 && tokens+n > opts.MaxTokens {
+			result.Truncated = true
+			break
+		}
+		tokens += n
+		kept[sym.file] = append(kept[sym.file], sym)
+		fileScores[sym.file] += sym.score
+	}
+	// Files without symbols, or whose symbols didn't fit, are listed
+	// after while there's room.
+	for _, rel := range paths {
+		if _, ok := kept[rel]; ok {
+			continue
+		}
+		n := estimateTokens(rel + "\n")
+		if opts.MaxTokens > 0 && tokens+n > opts.MaxTokens {
+			result.Truncated = true
+			continue
+		}
+		tokens += n
+		kept[rel] = nil
+	}
+
+	files := slices.Collect(maps.Keys(kept))
+	slices.SortFunc(files, func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(fileScores[b], fileScores[a]),
+			strings.Compare(a, b),
+		)
+	})
+	var sb strings.Builder
+	for _, rel := range files {
+		sb.WriteString(rel)
+		sb.WriteString("\n")
+		syms := kept[rel]
+		slices.SortFunc(syms, func(a, b rankedSymbol) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+		for _, sym := range syms {
+			sb.WriteString(symbolLine(sym.Symbol, opts.Lines))
+		}
+	}
+	result.Text = strings.TrimSuffix(sb.String(), "\n")
+	return result
+}
+
+func symbolLine(sym Symbol, lines bool) string {
+	if lines {
+		return fmt.Sprintf("  %d| %s\n", sym.Line, sym.Signature)
+	}
+	return "  " + sym.Signature + "\n"
+}
+
+// estimateTokens approximates the number of tokens of s at four bytes per
+// token.
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
@@ -0,0 +1,275 @@
+// Package repomap builds a repository map: a compact outline of the files
+// of a directory and their top-level symbols, ranked by how much the rest
+// of the directory refers to them. It gives the agent a sense of where
+// things are without having to list and grep its way around first.
+//
+// Files are parsed by the [Parser] registered for their extension. The
+// outlines are cached on disk and only files whose size or modification
+// time changed are parsed again.
+package repomap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/zeebo/xxh3"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxFiles is the most files walked in a directory.
+	maxFiles = 20000
+	// maxFileSize is the size above which files aren't parsed, as they
+	// are most likely generated.
+	maxFileSize = 1 << 20
+	// cacheVersion is bumped when the cache format or what the parsers
+	// extract changes, to drop outdated caches.
+	cacheVersion = 1
+)
+
+// Kind is the kind of a symbol.
+type Kind string
+
+const (
+	KindFunc   Kind = "func"
+	KindMethod Kind = "method"
+	KindType   Kind = "type"
+	KindConst  Kind = "const"
+	KindVar    Kind = "var"
+)
+
+// Symbol is a top-level symbol of a file.
+type Symbol struct {
+	// Name is what references to the symbol match. Parsers may qualify
+	// it, with its package for example, to tell apart symbols with the
+	// same name.
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	Line int    `json:"line"`
+	// Signature is the declaration of the symbol as shown in the map,
+	// such as "func New(root, dataDir string) *Map".
+	Signature string `json:"signature"`
+}
+
+// Outline is what a parser extracts from a file.
+type Outline struct {
+	Symbols []Symbol `json:"symbols,omitempty"`
+	// Refs are the distinct identifiers the file refers to, sorted. They
+	// rank the symbols of the other files.
+	Refs []string `json:"refs,omitempty"`
+}
+
+// Parser extracts the outline of the files of a language.
+type Parser interface {
+	// Extensions returns the file extensions the parser handles, such as
+	// ".go".
+	Extensions() []string
+	// Parse returns the outline of the file at path with content src.
+	Parse(path string, src []byte) (Outline, error)
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]Parser{}
+)
+
+// RegisterParser registers p for its extensions, replacing any parser
+// registered for them before.
+func RegisterParser(p Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	for _, ext := range p.Extensions() {
+		parsers[ext] = p
+	}
+}
+
+func parserFor(path string) Parser {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	return parsers[filepath.Ext(path)]
+}
+
+// file is the cached outline of a file.
+type file struct {
+	ModTime int64 `json:"mod_time"`
+	Size    int64 `json:"size"`
+	Outline
+}
+
+type cache struct {
+	Version int              `json:"version"`
+	Files   map[string]*file `json:"files"`
+}
+
+// Map is the repository map of a directory.
+type Map struct {
+	root      string
+	cachePath string
+
+	mu     sync.Mutex
+	files  map[string]*file
+	loaded bool
+}
+
+var shared = csync.NewMap[string, *Map]()
+
+// For returns the map of root, shared by everyone asking for it, caching
+// it under dataDir.
+func For(root, dataDir string) *Map {
+	return shared.GetOrSet(root, func() *Map {
+		return New(root, dataDir)
+	})
+}
+
+// New returns the map of root, caching it under dataDir. The map is empty
+// until it is updated.
+func New(root, dataDir string) *Map {
+	name := fmt.Sprintf("%016x.json", xxh3.HashString(root))
+	return &Map{
+		root:      root,
+		cachePath: filepath.Join(dataDir, "repomap", name),
+		files:     map[string]*file{},
+	}
+}
+
+// Root returns the directory of the map.
+func (m *Map) Root() string {
+	return m.root
+}
+
+// Update walks the directory, skipping ignored files, and parses the files
+// that changed since the last update.
+func (m *Map) Update(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loaded {
+		m.load()
+		m.loaded = true
+	}
+
+	paths, _, err := fsext.ListDirectory(m.root, nil, 0, maxFiles)
+	if err != nil {
+		return fmt.Errorf("listing files: %w", err)
+	}
+
+	type change struct {
+		rel  string
+		path string
+		info fs.FileInfo
+		file *file
+	}
+	var changes []*change
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if strings.HasSuffix(path, string(filepath.Separator)) || parserFor(path) == nil {
+			continue
+		}
+		rel, err := filepath.Rel(m.root, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := os.Stat(path)
+		if err != nil || info.Size() > maxFileSize {
+			continue
+		}
+		seen[rel] = true
+		if f, ok := m.files[rel]; ok && f.ModTime == info.ModTime().UnixNano() && f.Size == info.Size() {
+			continue
+		}
+		changes = append(changes, &change{rel: rel, path: path, info: info})
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, c := range changes {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			src, err := os.ReadFile(c.path)
+			if err != nil {
+				return nil
+			}
+			outline, err := parserFor(c.path).Parse(c.path, src)
+			if err != nil {
+				slog.Debug("Failed to parse file for the repository map", "path", c.rel, "error", err)
+			}
+			c.file = &file{
+				ModTime: c.info.ModTime().UnixNano(),
+				Size:    c.info.Size(),
+				Outline: outline,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	changed := false
+	for _, c := range changes {
+		if c.file != nil {
+			m.files[c.rel] = c.file
+			changed = true
+		}
+	}
+	for rel := range m.files {
+		if !seen[rel] {
+			delete(m.files, rel)
+			changed = true
+		}
+	}
+	if changed {
+		if err := m.save(); err != nil {
+			slog.Warn("Failed to save the repository map", "path", m.cachePath, "error", err)
+		}
+	}
+	return nil
+}
+
+// load reads the cached outlines, if any. m.mu must be held.
+func (m *Map) load() {
+	data, err := os.ReadFile(m.cachePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Failed to read the repository map", "path", m.cachePath, "error", err)
+		}
+		return
+	}
+	var c cache
+	if err := json.Unmarshal(data, &c); err != nil || c.Version != cacheVersion {
+		return
+	}
+	if c.Files != nil {
+		m.files = c.Files
+	}
+}
+
+// save writes the outlines to the cache. m.mu must be held.
+func (m *Map) save() error {
+	data, err := json.Marshal(cache{Version: cacheVersion, Files: m.files})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.cachePath), 0o700); err != nil {
+		return err
+	}
+	tmp := m.cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.cachePath)
+}
@@ -0,0 +1,213 @@
+package repomap
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestGoParser(t *testing.T) {
+	src := `package store
+
+import (
+	"context"
+
+	cfg "example.com/config"
+)
+
+const Version = 2
+
+var (
+	ErrNotFound = errors.New("not found")
+	_           = 1
+)
+
+// Store stores things.
+type Store struct {
+	items map[string]Item
+}
+
+type Getter interface {
+	Get(ctx context.Context, key string) (Item, error)
+}
+
+type Item[T any] = []T
+
+func New(opts cfg.Options) *Store {
+	return &Store{items: map[string]Item{}}
+}
+
+func (s *Store) Get(ctx context.Context, key string) (Item, error) {
+	item, ok := s.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return item, nil
+}
+`
+	outline, err := goParser{}.Parse("store.go", []byte(src))
+	require.NoError(t, err)
+
+	var signatures []string
+	for _, sym := range outline.Symbols {
+		signatures = append(signatures, sym.Signature)
+	}
+	require.Equal(t, []string{
+		"const Version",
+		"var ErrNotFound",
+		"type Store struct",
+		"type Getter interface",
+		"type Item[T any] = []T",
+		"func New(opts cfg.Options) *Store",
+		"func (s *Store) Get(ctx context.Context, key string) (Item, error)",
+	}, signatures)
+	require.Equal(t, "store.New", outline.Symbols[5].Name)
+	require.Equal(t, KindFunc, outline.Symbols[5].Kind)
+	require.Equal(t, ".Get", outline.Symbols[6].Name)
+	require.Equal(t, KindMethod, outline.Symbols[6].Kind)
+	require.Equal(t, 31, outline.Symbols[6].Line)
+
+	require.Contains(t, outline.Refs, "cfg.Options")
+	require.Contains(t, outline.Refs, "context.Context")
+	require.Contains(t, outline.Refs, "store.ErrNotFound")
+	require.Contains(t, outline.Refs, ".items")
+	require.NotContains(t, outline.Refs, "store.Options", "selectors of imports are qualified with the import")
+	require.NotContains(t, outline.Refs, "store._")
+}
+
+func TestGoParserTestFile(t *testing.T) {
+	outline, err := goParser{}.Parse("store_test.go", []byte("package store\n\nfunc TestNew(t *testing.T) { New(nil) }\n"))
+	require.NoError(t, err)
+	require.Empty(t, outline.Symbols)
+	require.Contains(t, outline.Refs, "store.New")
+}
+
+func TestGoImportName(t *testing.T) {
+	for path, name := range map[string]string{
+		`"fmt"`:                         "fmt",
+		`"path/filepath"`:               "filepath",
+		`"github.com/go-git/go-git/v5"`: "git",
+		`"gopkg.in/yaml.v3"`:            "yaml",
+		`"charm.land/lipgloss/v2"`:      "lipgloss",
+	} {
+		spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: path}}
+		require.Equal(t, name, goImportName(spec), path)
+	}
+	spec := &ast.ImportSpec{Name: ast.NewIdent("lg"), Path: &ast.BasicLit{Kind: token.STRING, Value: `"charm.land/lipgloss/v2"`}}
+	require.Equal(t, "lg", goImportName(spec))
+}
+
+// countingParser outlines .count files as one symbol per line, counting
+// how many files it parses.
+type countingParser struct {
+	parsed *atomic.Int32
+}
+
+func (countingParser) Extensions() []string {
+	return []string{".count"}
+}
+
+func (p countingParser) Parse(path string, src []byte) (Outline, error) {
+	p.parsed.Add(1)
+	var outline Outline
+	for i, line := range strings.Split(strings.TrimSpace(string(src)), "\n") {
+		outline.Symbols = append(outline.Symbols, Symbol{Name: line, Kind: KindFunc, Line: i + 1, Signature: "func " + line})
+	}
+	return outline, nil
+}
+
+func TestUpdateIncremental(t *testing.T) {
+	var parsed atomic.Int32
+	RegisterParser(countingParser{&parsed})
+
+	root, dataDir := t.TempDir(), t.TempDir()
+	writeFile(t, root, "a.count", "alpha\n")
+	writeFile(t, root, "b.count", "beta\n")
+	writeFile(t, root, "ignored/c.count", "gamma\n")
+	writeFile(t, root, ".gitignore", "ignored/\n")
+	writeFile(t, root, "notes.txt", "no parser\n")
+
+	m := New(root, dataDir)
+	require.NoError(t, m.Update(t.Context()))
+	require.Equal(t, int32(2), parsed.Load())
+	require.Equal(t, "a.count\n  func alpha\nb.count\n  func beta", m.Render(RenderOptions{}).Text)
+
+	require.NoError(t, m.Update(t.Context()))
+	require.Equal(t, int32(2), parsed.Load(), "unchanged files aren't parsed again")
+
+	writeFile(t, root, "a.count", "alpha\nalpha2\n")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "a.count"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(root, "b.count")))
+	require.NoError(t, m.Update(t.Context()))
+	require.Equal(t, int32(3), parsed.Load())
+	require.Equal(t, "a.count\n  func alpha\n  func alpha2", m.Render(RenderOptions{}).Text)
+
+	// A new map of the same directory starts from the cache.
+	cached := New(root, dataDir)
+	require.NoError(t, cached.Update(t.Context()))
+	require.Equal(t, int32(3), parsed.Load())
+	require.Equal(t, m.Render(RenderOptions{}).Text, cached.Render(RenderOptions{}).Text)
+}
+
+func TestRender(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/app\n")
+	writeFile(t, root, "store/store.go", `package store
+
+type Store struct{}
+
+func New() *Store { return &Store{} }
+
+func (s *Store) Get(key string) string { return key }
+
+func unused() {}
+`)
+	writeFile(t, root, "main.go", `package main
+
+import "example.com/app/store"
+
+func main() {
+	s := store.New()
+	println(s.Get("key"))
+}
+`)
+	writeFile(t, root, "cmd/tool/main.go", `package main
+
+import "example.com/app/store"
+
+func run() { store.New() }
+`)
+
+	m := New(root, t.TempDir())
+	require.NoError(t, m.Update(t.Context()))
+
+	full := m.Render(RenderOptions{})
+	require.Equal(t, 3, full.Files)
+	require.False(t, full.Truncated)
+	require.True(t, strings.HasPrefix(full.Text, "store/store.go\n"), "the most referred to file comes first:\n%s", full.Text)
+	require.Contains(t, full.Text, "  func New() *Store\n")
+
+	small := m.Render(RenderOptions{MaxTokens: 10})
+	require.True(t, small.Truncated)
+	require.LessOrEqual(t, estimateTokens(small.Text), 10)
+	require.Equal(t, "store/store.go\n  func New() *Store", small.Text, "the most referred to symbol is kept first")
+
+	focused := m.Render(RenderOptions{Path: "cmd/", Lines: true})
+	require.Equal(t, 1, focused.Files)
+	require.Equal(t, "cmd/tool/main.go\n  5| func run()", focused.Text)
+}
@@ -0,0 +1,517 @@
+// Package searchindex keeps an on-disk trigram index of the files of a
+// directory, so that grep, glob, and file completions don't have to walk
+// the whole tree on every call, which is slow in large repositories.
+//
+// The index records every file the walker doesn't ignore and, for text
+// files, the trigrams of their content. A regular expression is turned
+// into the trigrams any match must contain, which narrow the files to
+// search down to a few candidates that are then searched as usual.
+//
+// The index is built in the background, saved under the data directory,
+// and refreshed by periodically comparing the size and modification time
+// of the files with the indexed ones. Changed files are kept in an overlay
+// until there are enough of them to rebuild the index.
+package searchindex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charlievieth/fastwalk"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/zeebo/xxh3"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxFileSize is the size above which the content of files isn't
+	// indexed. Such files are searched on every query.
+	maxFileSize = 1 << 20
+	// sniffLen is how much of a file is checked for NUL bytes to tell
+	// binary files apart.
+	sniffLen = 8 << 10
+	// chunkSize is how many files are read at once while building.
+	chunkSize = 1024
+	// minRebuild is the fewest changed files that trigger a rebuild. Past
+	// it, a rebuild is triggered once a tenth of the files changed.
+	minRebuild = 1000
+	// indexVersion is bumped when the format of the saved index changes.
+	indexVersion = 1
+)
+
+// content is what is known of the content of a file.
+type content uint8
+
+const (
+	// contentIndexed files have their trigrams indexed.
+	contentIndexed content = iota
+	// contentBinary files never match.
+	contentBinary
+	// contentLarge files are too large to index, or couldn't be read, and
+	// always are candidates.
+	contentLarge
+)
+
+type fileEntry struct {
+	// Path is relative to the root, with forward slashes.
+	Path    string
+	ModTime int64
+	Size    int64
+	Content content
+}
+
+// snapshot is an immutable index of the files of the root, as saved on
+// disk.
+type snapshot struct {
+	Version int
+	Root    string
+	Files   []fileEntry
+	// Postings are the sorted ids, which are indexes into Files, of the
+	// files containing each trigram.
+	Postings map[uint32][]uint32
+}
+
+// overlayEntry is a file that was added or changed since the snapshot was
+// built.
+type overlayEntry struct {
+	fileEntry
+	trigrams []uint32
+}
+
+// Index is the trigram index of the files of a directory.
+type Index struct {
+	root string
+	path string
+
+	// refreshMu serializes refreshes.
+	refreshMu sync.Mutex
+	loaded    bool
+
+	mu      sync.RWMutex
+	base    *snapshot
+	byPath  map[string]uint32
+	overlay map[string]*overlayEntry
+	// deleted are the ids of the files of base that were changed, and are
+	// in the overlay, or removed.
+	deleted map[uint32]bool
+
+	warm atomic.Bool
+}
+
+var indexes = csync.NewMap[string, *Index]()
+
+// New returns the index of root, saved under dataDir. The index is empty
+// until it is refreshed.
+func New(root, dataDir string) *Index {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	name := fmt.Sprintf("%016x.gob", xxh3.HashString(root))
+	return &Index{
+		root:    root,
+		path:    filepath.Join(dataDir, "searchindex", name),
+		overlay: map[string]*overlayEntry{},
+		deleted: map[uint32]bool{},
+	}
+}
+
+// Start loads or builds the index of root in the background, saving it
+// under dataDir, and refreshes it every interval until ctx is done or the
+// returned function is called. The index is found by [Lookup] once warm.
+func Start(ctx context.Context, root, dataDir string, interval time.Duration) func(context.Context) error {
+	ix := New(root, dataDir)
+	indexes.Set(ix.root, ix)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ix.run(ctx, interval)
+	}()
+	return func(context.Context) error {
+		cancel()
+		<-done
+		indexes.Del(ix.root)
+		return nil
+	}
+}
+
+// Lookup returns the started index whose root contains path, or nil if
+// there is none or it hasn't finished building.
+func Lookup(path string) *Index {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for root, ix := range indexes.Seq2() {
+		if ix.Warm() && (abs == root || strings.HasPrefix(abs, root+string(filepath.Separator))) {
+			return ix
+		}
+	}
+	return nil
+}
+
+// Root returns the directory of the index.
+func (ix *Index) Root() string {
+	return ix.root
+}
+
+// Warm reports whether the index was built, or loaded and refreshed, and
+// can answer queries.
+func (ix *Index) Warm() bool {
+	return ix.warm.Load()
+}
+
+func (ix *Index) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := ix.Refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Failed to refresh the search index", "root", ix.root, "error", err)
+		} else {
+			slog.Debug("Refreshed the search index", "root", ix.root, "took", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh brings the index up to date with the files of the directory,
+// loading it from disk first if it wasn't, and rebuilding it when it is
+// missing or too many files changed.
+func (ix *Index) Refresh(ctx context.Context) error {
+	ix.refreshMu.Lock()
+	defer ix.refreshMu.Unlock()
+
+	if !ix.loaded {
+		ix.load()
+		ix.loaded = true
+	}
+
+	files, err := ix.walk(ctx)
+	if err != nil {
+		return fmt.Errorf("walking files: %w", err)
+	}
+
+	ix.mu.RLock()
+	base := ix.base
+	var changes []fileEntry
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f.Path] = true
+		if cur := ix.lookupLocked(f.Path); cur == nil || cur.ModTime != f.ModTime || cur.Size != f.Size {
+			changes = append(changes, f)
+		}
+	}
+	var removed []string
+	if base != nil {
+		for id, f := range base.Files {
+			if !seen[f.Path] && !ix.deleted[uint32(id)] {
+				removed = append(removed, f.Path)
+			}
+		}
+	}
+	for p := range ix.overlay {
+		if !seen[p] {
+			removed = append(removed, p)
+		}
+	}
+	pending := len(ix.overlay) + len(ix.deleted)
+	ix.mu.RUnlock()
+
+	if base == nil || pending+len(changes)+len(removed) > max(minRebuild, len(files)/10) {
+		return ix.build(ctx, files)
+	}
+	if len(changes) == 0 && len(removed) == 0 {
+		ix.warm.Store(true)
+		return nil
+	}
+
+	trigrams, err := ix.read(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for i, f := range changes {
+		if id, ok := ix.byPath[f.Path]; ok {
+			ix.deleted[id] = true
+		}
+		ix.overlay[f.Path] = &overlayEntry{fileEntry: f, trigrams: trigrams[i]}
+	}
+	for _, p := range removed {
+		if id, ok := ix.byPath[p]; ok {
+			ix.deleted[id] = true
+		}
+		delete(ix.overlay, p)
+	}
+	ix.warm.Store(true)
+	return nil
+}
+
+// Update brings the file at path up to date in the started index
+// containing it, if any, so that files written between refreshes show up
+// in the results right away.
+func Update(path string) {
+	if ix := Lookup(path); ix != nil {
+		ix.Update(path)
+	}
+}
+
+// Update brings the file at path up to date in the index, or removes it if
+// it no longer exists. New files the walker ignores are left out.
+func (ix *Index) Update(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(ix.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	info, err := os.Stat(abs)
+	if err != nil || !info.Mode().IsRegular() {
+		ix.mu.Lock()
+		defer ix.mu.Unlock()
+		if id, ok := ix.byPath[rel]; ok {
+			ix.deleted[id] = true
+		}
+		delete(ix.overlay, rel)
+		return
+	}
+
+	ix.mu.RLock()
+	known := ix.lookupLocked(rel) != nil
+	ix.mu.RUnlock()
+	if !known && fsext.NewFastGlobWalker(ix.root).ShouldSkip(abs) {
+		return
+	}
+
+	f := fileEntry{Path: rel, ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+	var trigrams []uint32
+	f.Content, trigrams = readTrigrams(abs, f.Size)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if id, ok := ix.byPath[rel]; ok {
+		ix.deleted[id] = true
+	}
+	ix.overlay[rel] = &overlayEntry{fileEntry: f, trigrams: trigrams}
+}
+
+// lookupLocked returns the indexed entry of the file at path, if any.
+// ix.mu must be held.
+func (ix *Index) lookupLocked(path string) *fileEntry {
+	if e, ok := ix.overlay[path]; ok {
+		return &e.fileEntry
+	}
+	if id, ok := ix.byPath[path]; ok && !ix.deleted[id] {
+		return &ix.base.Files[id]
+	}
+	return nil
+}
+
+// walk lists the files of the directory that aren't ignored.
+func (ix *Index) walk(ctx context.Context) ([]fileEntry, error) {
+	walker := fsext.NewFastGlobWalker(ix.root)
+	var mu sync.Mutex
+	var files []fileEntry
+	conf := fastwalk.Config{Follow: true}
+	err := fastwalk.Walk(&conf, ix.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != ix.root && walker.ShouldSkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if walker.ShouldSkip(path) {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(ix.root, path)
+		if err != nil {
+			return nil
+		}
+		mu.Lock()
+		files = append(files, fileEntry{
+			Path:    filepath.ToSlash(rel),
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+		})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(files, func(a, b fileEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return files, nil
+}
+
+// read sets what is known of the content of files and returns their
+// trigrams.
+func (ix *Index) read(ctx context.Context, files []fileEntry) ([][]uint32, error) {
+	trigrams := make([][]uint32, len(files))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i := range files {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			files[i].Content, trigrams[i] = readTrigrams(filepath.Join(ix.root, files[i].Path), files[i].Size)
+			return nil
+		})
+	}
+	return trigrams, g.Wait()
+}
+
+// build indexes files from scratch, replacing the index, and saves it.
+func (ix *Index) build(ctx context.Context, files []fileEntry) error {
+	snap := &snapshot{
+		Version:  indexVersion,
+		Root:     ix.root,
+		Files:    files,
+		Postings: map[uint32][]uint32{},
+	}
+	// Files are read a chunk at a time to bound the memory held by their
+	// trigrams.
+	for start := 0; start < len(files); start += chunkSize {
+		chunk := files[start:min(start+chunkSize, len(files))]
+		trigrams, err := ix.read(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		for i, tris := range trigrams {
+			id := uint32(start + i)
+			for _, t := range tris {
+				snap.Postings[t] = append(snap.Postings[t], id)
+			}
+		}
+	}
+
+	if err := ix.save(snap); err != nil {
+		slog.Warn("Failed to save the search index", "path", ix.path, "error", err)
+	}
+
+	ix.mu.Lock()
+	ix.setBase(snap)
+	ix.mu.Unlock()
+	ix.warm.Store(true)
+	return nil
+}
+
+// setBase replaces the index with snap. ix.mu must be held.
+func (ix *Index) setBase(snap *snapshot) {
+	ix.base = snap
+	ix.byPath = make(map[string]uint32, len(snap.Files))
+	for id, f := range snap.Files {
+		ix.byPath[f.Path] = uint32(id)
+	}
+	ix.overlay = map[string]*overlayEntry{}
+	ix.deleted = map[uint32]bool{}
+}
+
+// load reads the saved index, if any.
+func (ix *Index) load() {
+	f, err := os.Open(ix.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Failed to read the search index", "path", ix.path, "error", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&snap); err != nil {
+		slog.Warn("Failed to decode the search index", "path", ix.path, "error", err)
+		return
+	}
+	if snap.Version != indexVersion || snap.Root != ix.root {
+		return
+	}
+	if snap.Postings == nil {
+		snap.Postings = map[uint32][]uint32{}
+	}
+	ix.mu.Lock()
+	ix.setBase(&snap)
+	ix.mu.Unlock()
+}
+
+// save writes snap to disk.
+func (ix *Index) save(snap *snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o700); err != nil {
+		return err
+	}
+	tmp := ix.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(snap); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ix.path)
+}
+
+// readTrigrams returns what is known of the content of the file at path,
+// and its trigrams if they are indexed.
+func readTrigrams(path string, size int64) (content, []uint32) {
+	if size > maxFileSize {
+		return contentLarge, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return contentLarge, nil
+	}
+	if bytes.IndexByte(data[:min(len(data), sniffLen)], 0) >= 0 {
+		return contentBinary, nil
+	}
+	return contentIndexed, trigramsOf(data)
+}
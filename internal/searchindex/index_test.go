@@ -0,0 +1,195 @@
+package searchindex
+
+import (
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// touch moves the modification time of a file forward, so a refresh sees
+// it changed even when written within the same clock tick.
+func touch(t *testing.T, dir, name string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, name), later, later))
+}
+
+func relPaths(t *testing.T, root string, files []File) []string {
+	t.Helper()
+	var paths []string
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.Path)
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func newTestIndex(t *testing.T) (*Index, string) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, root, "main.go", "package main\n\nfunc main() { NewServer().Run() }\n")
+	writeFile(t, root, "server/server.go", "package server\n\ntype Server struct{}\n\nfunc NewServer() *Server { return nil }\n")
+	writeFile(t, root, "server/client.go", "package server\n\ntype Client struct{}\n")
+	writeFile(t, root, "README.md", "# Example\n\nRun the SERVER.\n")
+	writeFile(t, root, "logo.png", "\x89PNG\x00\x00server")
+	writeFile(t, root, "ignored/skip.go", "package ignored // NewServer\n")
+	writeFile(t, root, ".gitignore", "ignored/\n")
+
+	ix := New(root, t.TempDir())
+	require.False(t, ix.Warm())
+	require.NoError(t, ix.Refresh(t.Context()))
+	require.True(t, ix.Warm())
+	return ix, ix.Root()
+}
+
+func TestCandidates(t *testing.T) {
+	ix, root := newTestIndex(t)
+
+	for pattern, want := range map[string][]string{
+		`NewServer`:              {"main.go", "server/server.go"},
+		`Server struct`:          {"server/server.go"},
+		`type (Server|Client) `:  {"server/client.go", "server/server.go"},
+		`(?i)server\.`:           {"README.md"},
+		`Ser`:                    {"README.md", "main.go", "server/client.go", "server/server.go"},
+		`zzz`:                    nil,
+		`.*`:                     {".gitignore", "README.md", "main.go", "server/client.go", "server/server.go"},
+		`Client|nothing-matches`: {"server/client.go"},
+	} {
+		files, err := ix.Candidates(root, pattern)
+		require.NoError(t, err, pattern)
+		require.Equal(t, want, relPaths(t, root, files), pattern)
+	}
+
+	files, err := ix.Candidates(filepath.Join(root, "server"), `Ser`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/client.go", "server/server.go"}, relPaths(t, root, files))
+
+	_, err = ix.Candidates(root, `(`)
+	require.Error(t, err)
+	_, err = ix.Candidates(t.TempDir(), `Server`)
+	require.Error(t, err)
+}
+
+func TestRefresh(t *testing.T) {
+	ix, root := newTestIndex(t)
+
+	writeFile(t, root, "server/client.go", "package server\n\ntype Client struct{ s *Server }\n")
+	touch(t, root, "server/client.go")
+	writeFile(t, root, "server/extra.go", "package server\n\nvar DefaultServer = NewServer()\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "main.go")))
+	require.NoError(t, ix.Refresh(t.Context()))
+
+	files, err := ix.Candidates(root, `NewServer`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/extra.go", "server/server.go"}, relPaths(t, root, files))
+	files, err = ix.Candidates(root, `\*Server`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/client.go", "server/server.go"}, relPaths(t, root, files))
+
+	// A new index of the same directory starts from the saved one, which
+	// is then refreshed.
+	reloaded := New(root, filepath.Dir(filepath.Dir(ix.path)))
+	require.NoError(t, reloaded.Refresh(t.Context()))
+	require.NotNil(t, reloaded.base)
+	files, err = reloaded.Candidates(root, `NewServer`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/extra.go", "server/server.go"}, relPaths(t, root, files))
+}
+
+func TestUpdate(t *testing.T) {
+	ix, root := newTestIndex(t)
+
+	// Written within the same clock tick, so only an update can tell.
+	writeFile(t, root, "server/client.go", "package server\n\nfunc NewServerClient() {}\n")
+	ix.Update(filepath.Join(root, "server/client.go"))
+	writeFile(t, root, "server/extra.go", "package server\n\nvar DefaultServer = NewServer()\n")
+	ix.Update(filepath.Join(root, "server/extra.go"))
+	writeFile(t, root, "ignored/new.go", "package ignored // NewServer\n")
+	ix.Update(filepath.Join(root, "ignored/new.go"))
+	require.NoError(t, os.Remove(filepath.Join(root, "main.go")))
+	ix.Update(filepath.Join(root, "main.go"))
+	ix.Update(t.TempDir())
+
+	files, err := ix.Candidates(root, `NewServer`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/client.go", "server/extra.go", "server/server.go"}, relPaths(t, root, files))
+	files, err = ix.Glob(root, "**/*.go")
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/client.go", "server/extra.go", "server/server.go"}, relPaths(t, root, files))
+}
+
+func TestGlob(t *testing.T) {
+	ix, root := newTestIndex(t)
+
+	files, err := ix.Glob(root, "**/*.go")
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.go", "server/client.go", "server/server.go"}, relPaths(t, root, files))
+
+	files, err = ix.Glob(filepath.Join(root, "server"), "s*.go")
+	require.NoError(t, err)
+	require.Equal(t, []string{"server/server.go"}, relPaths(t, root, files))
+
+	_, err = ix.Glob(root, "[")
+	require.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	ix, root := newTestIndex(t)
+	sep := string(filepath.Separator)
+
+	paths, truncated := ix.List(root, 1, 0)
+	require.False(t, truncated)
+	require.Equal(t, []string{
+		filepath.Join(root, ".gitignore"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "logo.png"),
+		filepath.Join(root, "main.go"),
+		filepath.Join(root, "server") + sep,
+	}, paths)
+
+	paths, truncated = ix.List(root, 0, 2)
+	require.True(t, truncated)
+	require.Len(t, paths, 2)
+}
+
+func TestLookup(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a/b.go", "package a\n")
+	stop := Start(t.Context(), root, t.TempDir(), time.Hour)
+	require.Eventually(t, func() bool { return Lookup(root) != nil }, 5*time.Second, 10*time.Millisecond)
+	require.NotNil(t, Lookup(filepath.Join(root, "a")))
+	require.Nil(t, Lookup(t.TempDir()))
+	require.NoError(t, stop(t.Context()))
+	require.Nil(t, Lookup(root))
+}
+
+func TestQueryOf(t *testing.T) {
+	for pattern, want := range map[string]string{
+		`abc`:        "and(abc)",
+		`ab`:         "all",
+		`abc.*def`:   "and(and(abc) and(def))",
+		`(abc|xyz)+`: "or(and(abc) and(xyz))",
+		`abc|x`:      "all",
+		`a?bcd`:      "and(bcd)",
+		`(?i)ÉTÉ`:    "all",
+		`[ab]cdef+`:  "and(cde)",
+	} {
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		require.NoError(t, err)
+		require.Equal(t, want, queryOf(re.Simplify()).String(), pattern)
+	}
+}
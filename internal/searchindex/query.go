@@ -0,0 +1,401 @@
+package searchindex
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// File is a file of the index.
+type File struct {
+	// Path is absolute.
+	Path    string
+	ModTime time.Time
+}
+
+// Glob returns the files under dir whose path relative to dir matches the
+// doublestar pattern.
+func (ix *Index) Glob(dir, pattern string) ([]File, error) {
+	pattern = filepath.ToSlash(pattern)
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	prefix, ok := ix.prefix(dir)
+	if !ok {
+		return nil, fmt.Errorf("%s is outside of %s", dir, ix.root)
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	var files []File
+	ix.each(prefix, func(f *fileEntry) {
+		if ok, _ := doublestar.Match(pattern, f.Path[len(prefix):]); ok {
+			files = append(files, ix.file(f))
+		}
+	})
+	return files, nil
+}
+
+// Candidates returns the files under dir whose content may match the
+// regular expression pattern: all the files that do, and maybe a few that
+// don't, which the caller has to search.
+func (ix *Index) Candidates(dir, pattern string) ([]File, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ok := ix.prefix(dir)
+	if !ok {
+		return nil, fmt.Errorf("%s is outside of %s", dir, ix.root)
+	}
+	q := queryOf(re.Simplify())
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	var files []File
+	if ix.base != nil {
+		ids, all := q.eval(ix.base.Postings)
+		if all {
+			ids = make([]uint32, len(ix.base.Files))
+			for id := range ids {
+				ids[id] = uint32(id)
+			}
+		} else {
+			// Files too large to index are in no posting list and
+			// always are candidates.
+			for id, f := range ix.base.Files {
+				if f.Content == contentLarge {
+					ids = append(ids, uint32(id))
+				}
+			}
+			slices.Sort(ids)
+			ids = slices.Compact(ids)
+		}
+		for _, id := range ids {
+			f := &ix.base.Files[id]
+			if ix.deleted[id] || f.Content == contentBinary || !strings.HasPrefix(f.Path, prefix) {
+				continue
+			}
+			files = append(files, ix.file(f))
+		}
+	}
+	for _, e := range ix.overlay {
+		if !strings.HasPrefix(e.Path, prefix) {
+			continue
+		}
+		if e.Content == contentLarge || (e.Content == contentIndexed && q.matches(e.trigrams)) {
+			files = append(files, ix.file(&e.fileEntry))
+		}
+	}
+	return files, nil
+}
+
+// List returns the files under dir, and the directories they are in, as
+// paths joined to dir with directories ending with a separator, like
+// [fsext.ListDirectory]. Depth limits how deep it goes, and limit how many
+// paths are returned, unless zero.
+func (ix *Index) List(dir string, depth, limit int) ([]string, bool) {
+	prefix, ok := ix.prefix(dir)
+	if !ok {
+		return nil, false
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	seen := map[string]bool{}
+	var paths []string
+	ix.each(prefix, func(f *fileEntry) {
+		parts := strings.Split(f.Path[len(prefix):], "/")
+		if depth > 0 && len(parts) > depth {
+			parts = parts[:depth]
+		}
+		for i := range parts {
+			rel := strings.Join(parts[:i+1], "/")
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			if rel != f.Path[len(prefix):] {
+				path += string(filepath.Separator)
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	})
+	slices.Sort(paths)
+	if limit > 0 && len(paths) > limit {
+		return paths[:limit], true
+	}
+	return paths, false
+}
+
+// prefix returns the path of dir relative to the root, with forward
+// slashes and a trailing one, or nothing for the root itself.
+func (ix *Index) prefix(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(ix.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel) + "/", true
+}
+
+// each calls fn with the files under prefix. ix.mu must be held.
+func (ix *Index) each(prefix string, fn func(*fileEntry)) {
+	if ix.base != nil {
+		for id := range ix.base.Files {
+			f := &ix.base.Files[id]
+			if !ix.deleted[uint32(id)] && strings.HasPrefix(f.Path, prefix) {
+				fn(f)
+			}
+		}
+	}
+	for _, e := range ix.overlay {
+		if strings.HasPrefix(e.Path, prefix) {
+			fn(&e.fileEntry)
+		}
+	}
+}
+
+func (ix *Index) file(f *fileEntry) File {
+	return File{
+		Path:    filepath.Join(ix.root, filepath.FromSlash(f.Path)),
+		ModTime: time.Unix(0, f.ModTime),
+	}
+}
+
+// trigramsOf returns the sorted, distinct trigrams of data, with ASCII
+// letters lowercased so that case-insensitive patterns can use them too.
+func trigramsOf(data []byte) []uint32 {
+	if len(data) < 3 {
+		return nil
+	}
+	tris := make([]uint32, 0, len(data)-2)
+	for i := 0; i+2 < len(data); i++ {
+		tris = append(tris, trigram(data[i], data[i+1], data[i+2]))
+	}
+	slices.Sort(tris)
+	return slices.Compact(tris)
+}
+
+func trigram(a, b, c byte) uint32 {
+	return uint32(lower(a))<<16 | uint32(lower(b))<<8 | uint32(lower(c))
+}
+
+func lower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+type queryOp uint8
+
+const (
+	// opAll matches every file.
+	opAll queryOp = iota
+	// opAnd matches files with all the trigrams that match all the
+	// subqueries.
+	opAnd
+	// opOr matches files that match any of the subqueries.
+	opOr
+)
+
+// query is a condition on the trigrams of the files that may match a
+// regular expression.
+type query struct {
+	op       queryOp
+	trigrams []uint32
+	subs     []*query
+}
+
+var allQuery = &query{op: opAll}
+
+// queryOf returns the trigrams the matches of re must contain.
+func queryOf(re *syntax.Regexp) *query {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if !literalUsable(re) {
+			return allQuery
+		}
+		return literalQuery(string(re.Rune))
+	case syntax.OpConcat:
+		// Adjacent literals are joined so their trigrams span them.
+		var subs []*query
+		var lit []rune
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && literalUsable(sub) {
+				lit = append(lit, sub.Rune...)
+				continue
+			}
+			subs = append(subs, literalQuery(string(lit)), queryOf(sub))
+			lit = nil
+		}
+		subs = append(subs, literalQuery(string(lit)))
+		return andQuery(subs...)
+	case syntax.OpCapture, syntax.OpPlus:
+		return queryOf(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return queryOf(re.Sub[0])
+		}
+		return allQuery
+	case syntax.OpAlternate:
+		subs := make([]*query, 0, len(re.Sub))
+		for _, sub := range re.Sub {
+			subs = append(subs, queryOf(sub))
+		}
+		return orQuery(subs...)
+	default:
+		return allQuery
+	}
+}
+
+// literalUsable reports whether the trigrams of the literal re can be
+// looked up, which they can't when it is case-insensitive and not ASCII,
+// as only ASCII letters are lowercased.
+func literalUsable(re *syntax.Regexp) bool {
+	if re.Flags&syntax.FoldCase == 0 {
+		return true
+	}
+	for _, r := range re.Rune {
+		if r >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func literalQuery(s string) *query {
+	tris := trigramsOf([]byte(s))
+	if len(tris) == 0 {
+		return allQuery
+	}
+	return &query{op: opAnd, trigrams: tris}
+}
+
+func andQuery(subs ...*query) *query {
+	subs = slices.DeleteFunc(subs, func(q *query) bool { return q.op == opAll })
+	switch len(subs) {
+	case 0:
+		return allQuery
+	case 1:
+		return subs[0]
+	}
+	return &query{op: opAnd, subs: subs}
+}
+
+func orQuery(subs ...*query) *query {
+	if len(subs) == 0 || slices.ContainsFunc(subs, func(q *query) bool { return q.op == opAll }) {
+		return allQuery
+	}
+	if len(subs) == 1 {
+		return subs[0]
+	}
+	return &query{op: opOr, subs: subs}
+}
+
+// eval returns the ids of the files matching q, or all if q matches every
+// file.
+func (q *query) eval(postings map[uint32][]uint32) (ids []uint32, all bool) {
+	switch q.op {
+	case opAnd:
+		all = true
+		intersect := func(other []uint32) {
+			if all {
+				ids, all = slices.Clone(other), false
+				return
+			}
+			ids = intersectSorted(ids, other)
+		}
+		for _, t := range q.trigrams {
+			intersect(postings[t])
+		}
+		for _, sub := range q.subs {
+			if subIDs, subAll := sub.eval(postings); !subAll {
+				intersect(subIDs)
+			}
+		}
+		return ids, all
+	case opOr:
+		for _, sub := range q.subs {
+			subIDs, subAll := sub.eval(postings)
+			if subAll {
+				return nil, true
+			}
+			ids = append(ids, subIDs...)
+		}
+		slices.Sort(ids)
+		return slices.Compact(ids), false
+	default:
+		return nil, true
+	}
+}
+
+// matches reports whether a file with the sorted trigrams matches q.
+func (q *query) matches(trigrams []uint32) bool {
+	switch q.op {
+	case opAnd:
+		for _, t := range q.trigrams {
+			if _, ok := slices.BinarySearch(trigrams, t); !ok {
+				return false
+			}
+		}
+		for _, sub := range q.subs {
+			if !sub.matches(trigrams) {
+				return false
+			}
+		}
+		return true
+	case opOr:
+		return slices.ContainsFunc(q.subs, func(sub *query) bool { return sub.matches(trigrams) })
+	default:
+		return true
+	}
+}
+
+func intersectSorted(a, b []uint32) []uint32 {
+	var out []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func (q *query) String() string {
+	var parts []string
+	for _, t := range q.trigrams {
+		parts = append(parts, string([]byte{byte(t >> 16), byte(t >> 8), byte(t)}))
+	}
+	for _, sub := range q.subs {
+		parts = append(parts, sub.String())
+	}
+	switch q.op {
+	case opAnd:
+		return "and(" + strings.Join(parts, " ") + ")"
+	case opOr:
+		return "or(" + strings.Join(parts, " ") + ")"
+	default:
+		return "all"
+	}
+}
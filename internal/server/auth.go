@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TokensFile returns the path of the file holding the server's access
+// tokens under dataDir.
+func TokensFile(dataDir string) string {
+	return filepath.Join(dataDir, "server", "tokens")
+}
+
+// LoadTokens reads the access tokens stored in path, one per line. A
+// missing file holds no tokens.
+func LoadTokens(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	return tokens, scanner.Err()
+}
+
+// CreateToken generates a new random access token and appends it to the
+// tokens file at path, creating the file if needed.
+func CreateToken(path string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create tokens directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tokens file: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, token); err != nil {
+		return "", fmt.Errorf("failed to write tokens file: %w", err)
+	}
+	return token, nil
+}
+
+// SetTokens sets the bearer tokens accepted by the server. When no
+// tokens are set, requests are not authenticated, which is only
+// appropriate for Unix sockets and named pipes.
+func (s *Server) SetTokens(tokens []string) {
+	s.tokens = tokens
+}
+
+// authHandler wraps the next handler in a middleware that rejects
+// requests without a valid bearer token with a 401.
+func (s *Server) authHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.tokens) == 0 || authorized(r, s.tokens) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.logDebug(r, "Rejected unauthorized request")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="crush"`)
+		jsonError(w, http.StatusUnauthorized, "unauthorized")
+	})
+}
+
+// RequireToken wraps next in a middleware that rejects requests without
+// one of tokens as bearer token with a 401, like the server does.
+func RequireToken(tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorized(r, tokens) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="crush"`)
+		jsonError(w, http.StatusUnauthorized, "unauthorized")
+	})
+}
+
+func authorized(r *http.Request, tokens []string) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	token = strings.TrimSpace(token)
+	authorized := false
+	for _, t := range tokens {
+		// Compare against every token so the time taken doesn't reveal
+		// which one matched.
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
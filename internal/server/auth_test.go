@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	path := TokensFile(t.TempDir())
+
+	tokens, err := LoadTokens(path)
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+
+	first, err := CreateToken(path)
+	require.NoError(t, err)
+	require.Len(t, first, 64)
+	second, err := CreateToken(path)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	tokens, err = LoadTokens(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{first, second}, tokens)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestLoadTokensSkipsCommentsAndBlankLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(path, []byte("# laptop\nabc\n\n  def  \n"), 0o600))
+
+	tokens, err := LoadTokens(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"abc", "def"}, tokens)
+}
+
+func TestAuthHandler(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	s.SetTokens([]string{"secret", "other"})
+	h := s.authHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "Basic secret", http.StatusUnauthorized},
+		{"valid", "Bearer secret", http.StatusTeapot},
+		{"second token", "bearer other", http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			h.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusUnauthorized {
+				require.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestAuthHandlerWithoutTokens(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	h := s.authHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health", nil))
+	require.Equal(t, http.StatusTeapot, rec.Code)
+}
+
+func TestRequireToken(t *testing.T) {
+	t.Parallel()
+
+	h := RequireToken([]string{"secret"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusTeapot, rec.Code)
+
+	// Unlike the server, no tokens means no request gets through.
+	h = RequireToken(nil, h)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestParseHostURL(t *testing.T) {
+	t.Parallel()
+
+	u, err := ParseHostURL("https://example.com:8443")
+	require.NoError(t, err)
+	require.Equal(t, "https", u.Scheme)
+	require.Equal(t, "example.com:8443", u.Host)
+
+	u, err = ParseHostURL("tcp://127.0.0.1:9000")
+	require.NoError(t, err)
+	require.Equal(t, "tcp", u.Scheme)
+	require.Equal(t, "127.0.0.1:9000", u.Host)
+
+	u, err = ParseHostURL("unix:///tmp/crush.sock")
+	require.NoError(t, err)
+	require.Equal(t, "unix", u.Scheme)
+	require.Equal(t, "/tmp/crush.sock", u.Host)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/proto"
 )
 
@@ -353,6 +354,38 @@ func (c *controllerV1) handlePostWorkspaceMCPDisableDocker(w http.ResponseWriter
 	w.WriteHeader(http.StatusOK)
 }
 
+// handlePostWorkspaceMCPElicitation answers an elicitation of an MCP server.
+//
+//	@Summary		Answer MCP elicitation
+//	@Tags			mcp
+//	@Accept			json
+//	@Param			id		path	string							true	"Workspace ID"
+//	@Param			eid		path	string							true	"Elicitation ID"
+//	@Param			request	body	proto.MCPElicitationResponse	true	"Elicitation response"
+//	@Success		200
+//	@Failure		400	{object}	proto.Error
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/mcp/elicitations/{eid} [post]
+func (c *controllerV1) handlePostWorkspaceMCPElicitation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	eid := r.PathValue("eid")
+
+	var req proto.MCPElicitationResponse
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.server.logError(r, "Failed to decode request", "error", err)
+		jsonError(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	resp := mcp.ElicitationResponse{Action: req.Action, Content: req.Content}
+	if err := c.backend.AnswerMCPElicitation(id, eid, resp); err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // handlePostWorkspaceMCPRefreshTools refreshes tools for a named MCP server.
 //
 //	@Summary		Refresh MCP tools
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
@@ -45,6 +46,23 @@ func wrapEvent(ev any) *pubsub.Payload {
 				ToolCount: e.Payload.Counts.Tools,
 			},
 		})
+	case pubsub.Event[mcp.Elicitation]:
+		return envelope(pubsub.PayloadTypeMCPElicitation, pubsub.Event[proto.MCPElicitation]{
+			Type:    e.Type,
+			Payload: mcpElicitationToProto(e.Payload),
+		})
+	case pubsub.Event[mcp.Progress]:
+		return envelope(pubsub.PayloadTypeMCPProgress, pubsub.Event[proto.MCPProgress]{
+			Type: e.Type,
+			Payload: proto.MCPProgress{
+				Name:       e.Payload.Name,
+				SessionID:  e.Payload.SessionID,
+				ToolCallID: e.Payload.ToolCallID,
+				Progress:   e.Payload.Progress,
+				Total:      e.Payload.Total,
+				Message:    e.Payload.Message,
+			},
+		})
 	case pubsub.Event[permission.PermissionRequest]:
 		return envelope(pubsub.PayloadTypePermissionRequest, pubsub.Event[proto.PermissionRequest]{
 			Type: e.Type,
@@ -57,6 +75,7 @@ func wrapEvent(ev any) *pubsub.Payload {
 				Action:      e.Payload.Action,
 				Path:        e.Payload.Path,
 				Params:      e.Payload.Params,
+				Rule:        e.Payload.Rule,
 			},
 		})
 	case pubsub.Event[permission.PermissionNotification]:
@@ -71,7 +90,7 @@ func wrapEvent(ev any) *pubsub.Payload {
 	case pubsub.Event[message.Message]:
 		return envelope(pubsub.PayloadTypeMessage, pubsub.Event[proto.Message]{
 			Type:    e.Type,
-			Payload: messageToProto(e.Payload),
+			Payload: proto.MessageFromMessage(e.Payload),
 		})
 	case pubsub.Event[session.Session]:
 		return envelope(pubsub.PayloadTypeSession, pubsub.Event[proto.Session]{
@@ -90,6 +109,7 @@ func wrapEvent(ev any) *pubsub.Payload {
 				SessionID:    e.Payload.SessionID,
 				SessionTitle: e.Payload.SessionTitle,
 				Type:         proto.AgentEventType(e.Payload.Type),
+				Details:      e.Payload.Details,
 			},
 		})
 	case pubsub.Event[skills.Event]:
@@ -131,6 +151,31 @@ func mcpEventTypeToProto(t mcp.EventType) proto.MCPEventType {
 	}
 }
 
+func mcpElicitationToProto(e mcp.Elicitation) proto.MCPElicitation {
+	fields := make([]proto.MCPElicitationField, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = proto.MCPElicitationField{
+			Name:         f.Name,
+			Title:        f.Title,
+			Description:  f.Description,
+			Type:         f.Type,
+			Format:       f.Format,
+			Required:     f.Required,
+			Options:      f.Options,
+			OptionTitles: f.OptionTitles,
+			Default:      f.Default,
+		}
+	}
+	return proto.MCPElicitation{
+		ID:         e.ID,
+		Name:       e.Name,
+		SessionID:  e.SessionID,
+		ToolCallID: e.ToolCallID,
+		Message:    e.Message,
+		Fields:     fields,
+	}
+}
+
 func sessionToProto(s session.Session) proto.Session {
 	return proto.Session{
 		ID:               s.ID,
@@ -144,6 +189,9 @@ func sessionToProto(s session.Session) proto.Session {
 		Todos:            todosToProto(s.Todos),
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
+
+		ForkedFromSessionID: s.ForkedFromSessionID,
+		ForkedAtMessageID:   s.ForkedAtMessageID,
 	}
 }
 
@@ -174,60 +222,42 @@ func fileToProto(f history.File) proto.File {
 	}
 }
 
-func messageToProto(m message.Message) proto.Message {
-	msg := proto.Message{
-		ID:        m.ID,
-		SessionID: m.SessionID,
-		Role:      proto.MessageRole(m.Role),
-		Model:     m.Model,
-		Provider:  m.Provider,
-		CreatedAt: m.CreatedAt,
-		UpdatedAt: m.UpdatedAt,
+func rewindResultToProto(r history.RewindResult) proto.SessionRewindResult {
+	out := proto.SessionRewindResult{
+		Changes: make([]proto.RewindChange, len(r.Changes)),
+		Applied: r.Applied,
 	}
+	for i, c := range r.Changes {
+		out.Changes[i] = proto.RewindChange{
+			Path:     c.Path,
+			Action:   string(c.Action),
+			Conflict: c.Conflict,
+		}
+	}
+	return out
+}
 
-	for _, p := range m.Parts {
-		switch v := p.(type) {
-		case message.TextContent:
-			msg.Parts = append(msg.Parts, proto.TextContent{Text: v.Text})
-		case message.ReasoningContent:
-			msg.Parts = append(msg.Parts, proto.ReasoningContent{
-				Thinking:   v.Thinking,
-				Signature:  v.Signature,
-				StartedAt:  v.StartedAt,
-				FinishedAt: v.FinishedAt,
-			})
-		case message.ToolCall:
-			msg.Parts = append(msg.Parts, proto.ToolCall{
-				ID:       v.ID,
-				Name:     v.Name,
-				Input:    v.Input,
-				Finished: v.Finished,
-			})
-		case message.ToolResult:
-			msg.Parts = append(msg.Parts, proto.ToolResult{
-				ToolCallID: v.ToolCallID,
-				Name:       v.Name,
-				Content:    v.Content,
-				Data:       v.Data,
-				MIMEType:   v.MIMEType,
-				Metadata:   v.Metadata,
-				IsError:    v.IsError,
-			})
-		case message.Finish:
-			msg.Parts = append(msg.Parts, proto.Finish{
-				Reason:  proto.FinishReason(v.Reason),
-				Time:    v.Time,
-				Message: v.Message,
-				Details: v.Details,
-			})
-		case message.ImageURLContent:
-			msg.Parts = append(msg.Parts, proto.ImageURLContent{URL: v.URL, Detail: v.Detail})
-		case message.BinaryContent:
-			msg.Parts = append(msg.Parts, proto.BinaryContent{Path: v.Path, MIMEType: v.MIMEType, Data: v.Data})
+func checkpointsToProto(checkpoints []checkpoint.Checkpoint) []proto.Checkpoint {
+	out := make([]proto.Checkpoint, len(checkpoints))
+	for i, cp := range checkpoints {
+		out[i] = proto.Checkpoint{
+			Number:    cp.Number,
+			SessionID: cp.SessionID,
+			Commit:    cp.Commit,
+			Prompt:    cp.Prompt,
+			Dir:       cp.Dir,
+			CreatedAt: cp.CreatedAt,
 		}
 	}
+	return out
+}
 
-	return msg
+func checkpointChangesToProto(changes []checkpoint.Change) []proto.CheckpointChange {
+	out := make([]proto.CheckpointChange, len(changes))
+	for i, c := range changes {
+		out[i] = proto.CheckpointChange{Path: c.Path, Deleted: c.Deleted}
+	}
+	return out
 }
 
 // skillsEventToProto converts a skills.Event into its wire form. Errors
@@ -254,7 +284,23 @@ func skillsEventToProto(e skills.Event) proto.SkillsEvent {
 func messagesToProto(msgs []message.Message) []proto.Message {
 	out := make([]proto.Message, len(msgs))
 	for i, m := range msgs {
-		out[i] = messageToProto(m)
+		out[i] = proto.MessageFromMessage(m)
+	}
+	return out
+}
+
+func searchResultsToProto(results []message.SearchResult) []proto.SearchResult {
+	out := make([]proto.SearchResult, len(results))
+	for i, r := range results {
+		out[i] = proto.SearchResult{
+			MessageID:    r.MessageID,
+			SessionID:    r.SessionID,
+			SessionTitle: r.SessionTitle,
+			Role:         proto.MessageRole(r.Role),
+			CreatedAt:    r.CreatedAt,
+			Snippet:      r.Snippet,
+			Matches:      r.Matches,
+		}
 	}
 	return out
 }
@@ -5,50 +5,13 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/skills"
 	"github.com/stretchr/testify/require"
 )
 
-// TestMessageToProtoToolResult ensures that ToolResult metadata,
-// data, and MIME type survive the conversion to proto. Without these
-// fields the TUI cannot render rich tool output (e.g. syntax-
-// highlighted code from view, diffs from edit, images, etc.) and
-// falls back to the raw LLM-facing string.
-func TestMessageToProtoToolResult(t *testing.T) {
-	t.Parallel()
-
-	src := message.Message{
-		ID:   "m1",
-		Role: message.Tool,
-		Parts: []message.ContentPart{
-			message.ToolResult{
-				ToolCallID: "call-1",
-				Name:       "view",
-				Content:    "<file>\n  1| hi\n</file>",
-				Data:       "base64data",
-				MIMEType:   "image/png",
-				Metadata:   `{"file_path":"/tmp/x","content":"hi"}`,
-				IsError:    false,
-			},
-		},
-	}
-
-	got := messageToProto(src)
-	require.Len(t, got.Parts, 1)
-	tr, ok := got.Parts[0].(proto.ToolResult)
-	require.True(t, ok, "expected proto.ToolResult, got %T", got.Parts[0])
-	require.Equal(t, "call-1", tr.ToolCallID)
-	require.Equal(t, "view", tr.Name)
-	require.Equal(t, "<file>\n  1| hi\n</file>", tr.Content)
-	require.Equal(t, "base64data", tr.Data)
-	require.Equal(t, "image/png", tr.MIMEType)
-	require.Equal(t, `{"file_path":"/tmp/x","content":"hi"}`, tr.Metadata)
-	require.False(t, tr.IsError)
-}
-
 // TestSkillsEventToProto_RoundTrip verifies that a pubsub.Event[skills.Event]
 // can be wrapped, marshaled, and unmarshaled back through the SSE
 // envelope without losing state values or error messages.
@@ -83,3 +46,44 @@ func TestSkillsEventToProto_RoundTrip(t *testing.T) {
 	require.Equal(t, proto.SkillStateError, decoded.Payload.States[1].State)
 	require.Equal(t, "bad frontmatter", decoded.Payload.States[1].Error)
 }
+
+// TestMCPElicitationToProto_RoundTrip verifies that the fields of an MCP
+// elicitation survive the SSE envelope, as the TUI builds its form from
+// them.
+func TestMCPElicitationToProto_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := pubsub.Event[mcp.Elicitation]{
+		Type: pubsub.CreatedEvent,
+		Payload: mcp.Elicitation{
+			ID:         "e1",
+			Name:       "github",
+			SessionID:  "s1",
+			ToolCallID: "call-1",
+			Message:    "Which repository?",
+			Fields: []mcp.ElicitationField{
+				{Name: "private", Type: "boolean", Required: true, Options: []string{"true", "false"}, OptionTitles: []string{"Yes", "No"}},
+				{Name: "repo", Title: "Repository", Type: "string", Default: "crush"},
+			},
+		},
+	}
+
+	env := wrapEvent(src)
+	require.NotNil(t, env)
+	require.Equal(t, pubsub.PayloadTypeMCPElicitation, env.Type)
+
+	var decoded pubsub.Event[proto.MCPElicitation]
+	require.NoError(t, json.Unmarshal(env.Payload, &decoded))
+	require.Equal(t, pubsub.CreatedEvent, decoded.Type)
+	require.Equal(t, proto.MCPElicitation{
+		ID:         "e1",
+		Name:       "github",
+		SessionID:  "s1",
+		ToolCallID: "call-1",
+		Message:    "Which repository?",
+		Fields: []proto.MCPElicitationField{
+			{Name: "private", Type: "boolean", Required: true, Options: []string{"true", "false"}, OptionTitles: []string{"Yes", "No"}},
+			{Name: "repo", Title: "Repository", Type: "string", Default: "crush"},
+		},
+	}, decoded.Payload)
+}
@@ -5,8 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/backend"
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/session"
 )
@@ -459,6 +465,180 @@ func (c *controllerV1) handleDeleteWorkspaceSession(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
+// handlePostWorkspaceSessionFork branches a new session off a session.
+//
+//	@Summary		Fork session
+//	@Description	Creates a new session with copies of the messages up to and including the given message or tool call, and the todos and record of read files the session had at that point. The original session is left untouched.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Workspace ID"
+//	@Param			sid		path		string					true	"Session ID"
+//	@Param			request	body		proto.SessionForkRequest	true	"Fork point"
+//	@Success		200		{object}	proto.Session
+//	@Failure		400		{object}	proto.Error
+//	@Failure		404		{object}	proto.Error
+//	@Failure		500		{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/fork [post]
+func (c *controllerV1) handlePostWorkspaceSessionFork(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+
+	var req proto.SessionForkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.server.logError(r, "Failed to decode request", "error", err)
+		jsonError(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+	if req.MessageID == "" {
+		jsonError(w, http.StatusBadRequest, "message_id is required")
+		return
+	}
+
+	sess, err := c.backend.ForkSession(r.Context(), id, sid, req)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, sessionToProto(sess))
+}
+
+// handlePostWorkspaceSessionRewind rolls back the files a session changed.
+//
+//	@Summary		Rewind session files
+//	@Description	Restores or deletes the files the agent touched so the working tree matches the state before the given message or tool call. Responds with 409 and the computed changes if files were modified outside of Crush and force is not set.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Workspace ID"
+//	@Param			sid		path		string						true	"Session ID"
+//	@Param			request	body		proto.SessionRewindRequest	true	"Rewind target and options"
+//	@Success		200		{object}	proto.SessionRewindResult
+//	@Failure		400		{object}	proto.Error
+//	@Failure		404		{object}	proto.Error
+//	@Failure		409		{object}	proto.SessionRewindResult
+//	@Failure		500		{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/rewind [post]
+func (c *controllerV1) handlePostWorkspaceSessionRewind(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+
+	var req proto.SessionRewindRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.server.logError(r, "Failed to decode request", "error", err)
+		jsonError(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+	if req.MessageID == "" {
+		jsonError(w, http.StatusBadRequest, "message_id is required")
+		return
+	}
+
+	result, err := c.backend.RewindSession(r.Context(), id, sid, req)
+	if errors.Is(err, history.ErrRewindConflict) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(rewindResultToProto(result))
+		return
+	}
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, rewindResultToProto(result))
+}
+
+// handleGetWorkspaceSessionCheckpoints lists the checkpoints of a session.
+//
+//	@Summary		List session checkpoints
+//	@Description	Lists the snapshots of the working tree taken at the start of every turn of a session, oldest first.
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			id	path		string	true	"Workspace ID"
+//	@Param			sid	path		string	true	"Session ID"
+//	@Success		200	{array}		proto.Checkpoint
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/checkpoints [get]
+func (c *controllerV1) handleGetWorkspaceSessionCheckpoints(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+
+	checkpoints, err := c.backend.ListCheckpoints(r.Context(), id, sid)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, checkpointsToProto(checkpoints))
+}
+
+// handleGetWorkspaceSessionCheckpointDiff returns the changes made during
+// a turn of a session.
+//
+//	@Summary		Diff session checkpoint
+//	@Description	Returns the changes made during a turn, from its checkpoint to the next one, or to the working tree for the latest turn.
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			id		path		string	true	"Workspace ID"
+//	@Param			sid		path		string	true	"Session ID"
+//	@Param			n		path		int		true	"Checkpoint number"
+//	@Param			stat	query		bool	false	"Return a diffstat instead of a patch"
+//	@Success		200		{object}	proto.CheckpointDiff
+//	@Failure		400		{object}	proto.Error
+//	@Failure		404		{object}	proto.Error
+//	@Failure		500		{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/checkpoints/{n}/diff [get]
+func (c *controllerV1) handleGetWorkspaceSessionCheckpointDiff(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid checkpoint number")
+		return
+	}
+	stat := r.URL.Query().Get("stat") == "true"
+
+	diff, err := c.backend.CheckpointDiff(r.Context(), id, sid, n, stat)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, proto.CheckpointDiff{Diff: diff})
+}
+
+// handlePostWorkspaceSessionCheckpointRestore restores a checkpoint of a
+// session.
+//
+//	@Summary		Restore session checkpoint
+//	@Description	Brings the working tree back to how it was at the start of a turn, after checkpointing it so the restore can be undone.
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			id	path		string	true	"Workspace ID"
+//	@Param			sid	path		string	true	"Session ID"
+//	@Param			n	path		int		true	"Checkpoint number"
+//	@Success		200	{array}		proto.CheckpointChange
+//	@Failure		400	{object}	proto.Error
+//	@Failure		404	{object}	proto.Error
+//	@Failure		409	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/checkpoints/{n}/restore [post]
+func (c *controllerV1) handlePostWorkspaceSessionCheckpointRestore(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid checkpoint number")
+		return
+	}
+
+	changes, err := c.backend.RestoreCheckpoint(r.Context(), id, sid, n)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, checkpointChangesToProto(changes))
+}
+
 // handleGetWorkspaceSessionUserMessages returns user messages for a session.
 //
 //	@Summary		Get user messages for session
@@ -501,6 +681,45 @@ func (c *controllerV1) handleGetWorkspaceAllUserMessages(w http.ResponseWriter,
 	jsonEncode(w, messagesToProto(messages))
 }
 
+// handleGetWorkspaceSearch runs a full-text search over messages.
+//
+//	@Summary		Search messages
+//	@Description	Searches the text, tool call inputs, and tool results of the messages of all top-level sessions, best matches first.
+//	@Tags			workspaces
+//	@Produce		json
+//	@Param			id		path		string	true	"Workspace ID"
+//	@Param			q		query		string	true	"Search query"
+//	@Param			limit	query		int		false	"Maximum number of results"
+//	@Success		200		{array}		proto.SearchResult
+//	@Failure		400		{object}	proto.Error
+//	@Failure		404		{object}	proto.Error
+//	@Failure		500		{object}	proto.Error
+//	@Router			/workspaces/{id}/search [get]
+func (c *controllerV1) handleGetWorkspaceSearch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		jsonError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			jsonError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	results, err := c.backend.SearchMessages(r.Context(), id, query, limit)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, searchResultsToProto(results))
+}
+
 // handleGetWorkspaceSessionFileTrackerFiles lists files read in a session.
 //
 //	@Summary		List tracked files for session
@@ -949,8 +1168,16 @@ func (c *controllerV1) handleError(w http.ResponseWriter, r *http.Request, err e
 		status = http.StatusBadRequest
 	case errors.Is(err, backend.ErrInvalidPermissionAction):
 		status = http.StatusBadRequest
+	case errors.Is(err, backend.ErrInvalidElicitationAction):
+		status = http.StatusBadRequest
+	case errors.Is(err, mcp.ErrElicitationNotFound):
+		status = http.StatusNotFound
 	case errors.Is(err, backend.ErrUnknownCommand):
 		status = http.StatusBadRequest
+	case errors.Is(err, agent.ErrSessionBusy):
+		status = http.StatusConflict
+	case errors.Is(err, checkpoint.ErrNotFound):
+		status = http.StatusNotFound
 	}
 	c.server.logError(r, err.Error())
 	jsonError(w, status, err.Error())
@@ -28,10 +28,10 @@ func ParseHostURL(host string) (*url.URL, error) {
 	}
 
 	var basePath string
-	if proto == "tcp" {
-		parsed, err := url.Parse("tcp://" + addr)
+	if isTCP(proto) {
+		parsed, err := url.Parse(proto + "://" + addr)
 		if err != nil {
-			return nil, fmt.Errorf("invalid tcp address: %v", err)
+			return nil, fmt.Errorf("invalid %s address: %v", proto, err)
 		}
 		addr = parsed.Host
 		basePath = parsed.Path
@@ -43,6 +43,26 @@ func ParseHostURL(host string) (*url.URL, error) {
 	}, nil
 }
 
+// isTCP reports whether network is served over TCP rather than a Unix
+// socket or named pipe. "https" is TCP with TLS.
+func isTCP(network string) bool {
+	return network == "tcp" || network == "https"
+}
+
+// IsLoopback reports whether the host:port address only accepts
+// connections from this machine.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
 // DefaultHost returns the default server host.
 func DefaultHost() string {
 	sock := "crush.sock"
@@ -65,6 +85,10 @@ type Server struct {
 	h  *http.Server
 	ln net.Listener
 
+	tokens      []string
+	tlsCertFile string
+	tlsKeyFile  string
+
 	backend *backend.Backend
 	logger  *slog.Logger
 }
@@ -104,6 +128,9 @@ func NewServer(cfg *config.ConfigStore, network, address string) *Server {
 	var p http.Protocols
 	p.SetHTTP1(true)
 	p.SetUnencryptedHTTP2(true)
+	if network == "https" {
+		p.SetHTTP2(true)
+	}
 	c := &controllerV1{backend: s.backend, server: s}
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /v1/health", c.handleGetHealth)
@@ -123,9 +150,15 @@ func NewServer(cfg *config.ConfigStore, network, address string) *Server {
 	mux.HandleFunc("PUT /v1/workspaces/{id}/sessions/{sid}", c.handlePutWorkspaceSession)
 	mux.HandleFunc("DELETE /v1/workspaces/{id}/sessions/{sid}", c.handleDeleteWorkspaceSession)
 	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/history", c.handleGetWorkspaceSessionHistory)
+	mux.HandleFunc("POST /v1/workspaces/{id}/sessions/{sid}/fork", c.handlePostWorkspaceSessionFork)
+	mux.HandleFunc("POST /v1/workspaces/{id}/sessions/{sid}/rewind", c.handlePostWorkspaceSessionRewind)
+	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/checkpoints", c.handleGetWorkspaceSessionCheckpoints)
+	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/checkpoints/{n}/diff", c.handleGetWorkspaceSessionCheckpointDiff)
+	mux.HandleFunc("POST /v1/workspaces/{id}/sessions/{sid}/checkpoints/{n}/restore", c.handlePostWorkspaceSessionCheckpointRestore)
 	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/messages", c.handleGetWorkspaceSessionMessages)
 	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/messages/user", c.handleGetWorkspaceSessionUserMessages)
 	mux.HandleFunc("GET /v1/workspaces/{id}/messages/user", c.handleGetWorkspaceAllUserMessages)
+	mux.HandleFunc("GET /v1/workspaces/{id}/search", c.handleGetWorkspaceSearch)
 	mux.HandleFunc("GET /v1/workspaces/{id}/sessions/{sid}/filetracker/files", c.handleGetWorkspaceSessionFileTrackerFiles)
 	mux.HandleFunc("POST /v1/workspaces/{id}/filetracker/read", c.handlePostWorkspaceFileTrackerRead)
 	mux.HandleFunc("GET /v1/workspaces/{id}/filetracker/lastread", c.handleGetWorkspaceFileTrackerLastRead)
@@ -167,19 +200,34 @@ func NewServer(cfg *config.ConfigStore, network, address string) *Server {
 	mux.HandleFunc("POST /v1/workspaces/{id}/mcp/refresh-resources", c.handlePostWorkspaceMCPRefreshResources)
 	mux.HandleFunc("POST /v1/workspaces/{id}/mcp/docker/enable", c.handlePostWorkspaceMCPEnableDocker)
 	mux.HandleFunc("POST /v1/workspaces/{id}/mcp/docker/disable", c.handlePostWorkspaceMCPDisableDocker)
+	mux.HandleFunc("POST /v1/workspaces/{id}/mcp/elicitations/{eid}", c.handlePostWorkspaceMCPElicitation)
 	mux.Handle("/v1/docs/", httpswagger.WrapHandler)
 	s.h = &http.Server{
 		Protocols: &p,
-		Handler:   s.recoverHandler(s.loggingHandler(mux)),
+		Handler:   s.recoverHandler(s.loggingHandler(s.authHandler(mux))),
 	}
-	if network == "tcp" {
+	if isTCP(network) {
 		s.h.Addr = address
 	}
 	return s
 }
 
-// Serve accepts incoming connections on the listener.
+// SetTLS sets the certificate and key files the server uses to serve
+// TLS. It is required for the "https" network.
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.tlsCertFile = certFile
+	s.tlsKeyFile = keyFile
+}
+
+// Serve accepts incoming connections on the listener, over TLS for the
+// "https" network.
 func (s *Server) Serve(ln net.Listener) error {
+	if s.network == "https" {
+		if s.tlsCertFile == "" || s.tlsKeyFile == "" {
+			return fmt.Errorf("https requires a TLS certificate and key")
+		}
+		return s.h.ServeTLS(ln, s.tlsCertFile, s.tlsKeyFile)
+	}
 	return s.h.Serve(ln)
 }
 
@@ -188,7 +236,11 @@ func (s *Server) ListenAndServe() error {
 	if s.ln != nil {
 		return fmt.Errorf("server already started")
 	}
-	ln, err := listen(s.network, s.Addr)
+	network := s.network
+	if isTCP(network) {
+		network = "tcp"
+	}
+	ln, err := listen(network, s.Addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", s.Addr, err)
 	}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLoopback(t *testing.T) {
+	t.Parallel()
+
+	for addr, want := range map[string]bool{
+		"localhost:4000":     true,
+		"LOCALHOST:4000":     true,
+		"127.0.0.1:4000":     true,
+		"127.1.2.3:4000":     true,
+		"[::1]:4000":         true,
+		"::1":                true,
+		"0.0.0.0:4000":       false,
+		":4000":              false,
+		"[::]:4000":          false,
+		"192.168.1.10:4000":  false,
+		"example.com:4000":   false,
+		"localhost.evil:400": false,
+	} {
+		require.Equal(t, want, IsLoopback(addr), addr)
+	}
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+// Fork creates a new session branching off sessionID at messageID. The fork
+// gets copies of the messages up to and including messageID, and the todos
+// and record of read files the session had at that message, so the
+// conversation can continue from that point while the original session
+// stays untouched.
+func (s *service) Fork(ctx context.Context, sessionID, messageID string) (Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	qtx := s.q.WithTx(tx)
+
+	src, err := qtx.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	msgs, err := qtx.ListMessagesBySession(ctx, sessionID)
+	if err != nil {
+		return Session{}, fmt.Errorf("listing session messages: %w", err)
+	}
+	end := slices.IndexFunc(msgs, func(m db.Message) bool { return m.ID == messageID })
+	if end < 0 {
+		return Session{}, fmt.Errorf("message %s not found in session %s", messageID, sessionID)
+	}
+
+	dbSession, err := qtx.CreateForkedSession(ctx, db.CreateForkedSessionParams{
+		ID:                  uuid.New().String(),
+		Title:               src.Title + " (fork)",
+		Todos:               todosAt(msgs[:end+1]),
+		ForkedFromSessionID: sql.NullString{String: sessionID, Valid: true},
+		ForkedAtMessageID:   sql.NullString{String: messageID, Valid: true},
+	})
+	if err != nil {
+		return Session{}, fmt.Errorf("creating fork: %w", err)
+	}
+
+	// Messages keep their timestamps so the fork lists them in the same
+	// order, and a summary the fork includes stays its starting point.
+	var summaryMessageID string
+	for _, msg := range msgs[:end+1] {
+		id := uuid.New().String()
+		if src.SummaryMessageID.Valid && msg.ID == src.SummaryMessageID.String {
+			summaryMessageID = id
+		}
+		if err = qtx.CopyMessage(ctx, db.CopyMessageParams{
+			ID:               id,
+			SessionID:        dbSession.ID,
+			Role:             msg.Role,
+			Parts:            msg.Parts,
+			Model:            msg.Model,
+			Provider:         msg.Provider,
+			IsSummaryMessage: msg.IsSummaryMessage,
+			FinishedAt:       msg.FinishedAt,
+			CreatedAt:        msg.CreatedAt,
+			UpdatedAt:        msg.UpdatedAt,
+		}); err != nil {
+			return Session{}, fmt.Errorf("copying message %s: %w", msg.ID, err)
+		}
+		if err = qtx.CopyMessageIndex(ctx, db.CopyMessageIndexParams{
+			ID:       id,
+			SourceID: msg.ID,
+		}); err != nil {
+			return Session{}, fmt.Errorf("copying search index of message %s: %w", msg.ID, err)
+		}
+	}
+	if err = qtx.CopySessionReadFiles(ctx, db.CopySessionReadFilesParams{
+		NewSessionID: dbSession.ID,
+		SessionID:    sessionID,
+		Before:       msgs[end].CreatedAt,
+	}); err != nil {
+		return Session{}, fmt.Errorf("copying read files: %w", err)
+	}
+	if summaryMessageID != "" {
+		if _, err = qtx.UpdateSession(ctx, db.UpdateSessionParams{
+			ID:               dbSession.ID,
+			Title:            dbSession.Title,
+			SummaryMessageID: sql.NullString{String: summaryMessageID, Valid: true},
+			Todos:            dbSession.Todos,
+		}); err != nil {
+			return Session{}, fmt.Errorf("setting summary message: %w", err)
+		}
+	}
+	// Re-read the session for the message count kept up by triggers.
+	if dbSession, err = qtx.GetSessionByID(ctx, dbSession.ID); err != nil {
+		return Session{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return Session{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.CreatedEvent, session)
+	event.SessionForked()
+	return session, nil
+}
+
+// todosToolName is the name of the tool the agent updates todos with.
+const todosToolName = "todos"
+
+// storedPart is a part of a message as stored in the database. Only the
+// fields of tool results are decoded; the message package can't be used
+// here, as it depends on this one in its tests.
+type storedPart struct {
+	Type string `json:"type"`
+	Data struct {
+		Name     string `json:"name"`
+		Metadata string `json:"metadata"`
+		IsError  bool   `json:"is_error"`
+	} `json:"data"`
+}
+
+// todosAt returns the todos as of the last of msgs, which are those the
+// todos tool last set, as they aren't kept per message.
+func todosAt(msgs []db.Message) sql.NullString {
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role != "tool" {
+			continue
+		}
+		var parts []storedPart
+		if err := json.Unmarshal([]byte(msg.Parts), &parts); err != nil {
+			continue
+		}
+		for _, part := range slices.Backward(parts) {
+			result := part.Data
+			if part.Type != "tool_result" || result.Name != todosToolName || result.IsError {
+				continue
+			}
+			var metadata struct {
+				Todos []Todo `json:"todos"`
+			}
+			if err := json.Unmarshal([]byte(result.Metadata), &metadata); err != nil {
+				continue
+			}
+			todos, err := marshalTodos(metadata.Todos)
+			if err != nil {
+				continue
+			}
+			return sql.NullString{String: todos, Valid: todos != ""}
+		}
+	}
+	return sql.NullString{}
+}
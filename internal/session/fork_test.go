@@ -0,0 +1,112 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFork(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+
+	q := db.New(conn)
+	sessions := NewService(q, conn)
+
+	src, err := sessions.Create(t.Context(), "original")
+	require.NoError(t, err)
+
+	// The todos tool sets the todos once before the fork point and once
+	// after it.
+	todos := []Todo{{Content: "Fix it", Status: TodoStatusInProgress, ActiveForm: "Fixing it"}}
+	later := []Todo{{Content: "Fix it", Status: TodoStatusCompleted, ActiveForm: "Fixing it"}}
+	var ids []string
+	for i, role := range []string{"user", "tool", "assistant", "tool"} {
+		parts := `[]`
+		switch i {
+		case 1:
+			parts = todosResult(t, todos)
+		case 3:
+			parts = todosResult(t, later)
+		}
+		msg, err := q.CreateMessage(t.Context(), db.CreateMessageParams{
+			ID:        role + "-" + string(rune('a'+len(ids))),
+			SessionID: src.ID,
+			Role:      role,
+			Parts:     parts,
+		})
+		require.NoError(t, err)
+		_, err = conn.ExecContext(t.Context(), `UPDATE messages SET created_at = ? WHERE id = ?`, 1000+i*10, msg.ID)
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+	for path, readAt := range map[string]int{"/work/main.go": 1010, "/work/later.go": 1011} {
+		require.NoError(t, q.RecordFileRead(t.Context(), db.RecordFileReadParams{SessionID: src.ID, Path: path}))
+		_, err = conn.ExecContext(t.Context(), `UPDATE read_files SET read_at = ? WHERE path = ?`, readAt, path)
+		require.NoError(t, err)
+	}
+
+	src.Todos = later
+	src.SummaryMessageID = ids[1]
+	src, err = sessions.Save(t.Context(), src)
+	require.NoError(t, err)
+
+	fork, err := sessions.Fork(t.Context(), src.ID, ids[1])
+	require.NoError(t, err)
+	require.NotEqual(t, src.ID, fork.ID)
+	require.Equal(t, "original (fork)", fork.Title)
+	require.Equal(t, src.ID, fork.ForkedFromSessionID)
+	require.Equal(t, ids[1], fork.ForkedAtMessageID)
+	require.Empty(t, fork.ParentSessionID)
+	require.Equal(t, int64(2), fork.MessageCount)
+	require.Equal(t, todos, fork.Todos, "the fork gets the todos at the fork point")
+
+	msgs, err := q.ListMessagesBySession(t.Context(), fork.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, "user", msgs[0].Role)
+	require.Equal(t, "tool", msgs[1].Role)
+	for _, msg := range msgs {
+		require.NotContains(t, ids, msg.ID)
+	}
+	require.Equal(t, msgs[1].ID, fork.SummaryMessageID)
+
+	reads, err := q.ListSessionReadFiles(t.Context(), fork.ID)
+	require.NoError(t, err)
+	require.Len(t, reads, 1, "files read after the fork point aren't copied")
+	require.Equal(t, "/work/main.go", reads[0].Path)
+
+	// The original session is untouched.
+	orig, err := sessions.Get(t.Context(), src.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), orig.MessageCount)
+	require.Equal(t, ids[1], orig.SummaryMessageID)
+	require.Equal(t, later, orig.Todos)
+
+	list, err := sessions.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	_, err = sessions.Fork(t.Context(), src.ID, "missing")
+	require.ErrorContains(t, err, "not found")
+}
+
+func todosResult(t *testing.T, todos []Todo) string {
+	t.Helper()
+	metadata, err := json.Marshal(map[string]any{"todos": todos})
+	require.NoError(t, err)
+	parts, err := json.Marshal([]map[string]any{{
+		"type": "tool_result",
+		"data": map[string]any{"tool_call_id": "call", "name": "todos", "metadata": string(metadata)},
+	}})
+	require.NoError(t, err)
+	return string(parts)
+}
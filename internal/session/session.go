@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/pubsub"
@@ -60,6 +62,11 @@ type Session struct {
 	Todos            []Todo
 	CreatedAt        int64
 	UpdatedAt        int64
+
+	// ForkedFromSessionID and ForkedAtMessageID are set on sessions created
+	// by [Service.Fork] and name the session and message it branched off.
+	ForkedFromSessionID string
+	ForkedAtMessageID   string
 }
 
 type Service interface {
@@ -74,6 +81,12 @@ type Service interface {
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
 	Rename(ctx context.Context, id string, title string) error
 	Delete(ctx context.Context, id string) error
+	Fork(ctx context.Context, sessionID, messageID string) (Session, error)
+
+	// Spending
+	RecordUsage(ctx context.Context, sessionID string, usage Usage) error
+	SessionUsage(ctx context.Context, sessionID string) (Usage, error)
+	UsageSince(ctx context.Context, t time.Time) (Usage, error)
 
 	// Agent tool session management
 	CreateAgentToolSessionID(messageID, toolCallID string) string
@@ -85,6 +98,9 @@ type service struct {
 	*pubsub.Broker[Session]
 	db *sql.DB
 	q  *db.Queries
+	// checkpoints, if set, holds the checkpoints of the sessions, which
+	// are deleted along with them.
+	checkpoints *checkpoint.Store
 
 	// Estimated usage stays in memory so fetch-modify-save paths (e.g.,
 	// updating todos or parent-session cost) do not rebuild a session from
@@ -161,6 +177,12 @@ func (s *service) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
 
+	if s.checkpoints != nil {
+		if err := s.checkpoints.Delete(ctx, dbSession.ID); err != nil {
+			slog.Warn("Failed to delete session checkpoints", "session_id", dbSession.ID, "error", err)
+		}
+	}
+
 	session := s.fromDBItem(dbSession)
 	s.clearEstimatedUsageState(dbSession.ID)
 	s.Publish(pubsub.DeletedEvent, session)
@@ -293,6 +315,9 @@ func (s *service) fromDBItem(item db.Session) Session {
 		Todos:            todos,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
+
+		ForkedFromSessionID: item.ForkedFromSessionID.String,
+		ForkedAtMessageID:   item.ForkedAtMessageID.String,
 	}
 }
 
@@ -318,23 +343,48 @@ func unmarshalTodos(data string) ([]Todo, error) {
 	return todos, nil
 }
 
-func NewService(q *db.Queries, conn *sql.DB) Service {
+// ServiceOption configures a [Service] at construction.
+type ServiceOption func(*service)
+
+// WithCheckpoints makes deleting a session delete its checkpoints from
+// store too.
+func WithCheckpoints(store *checkpoint.Store) ServiceOption {
+	return func(s *service) {
+		s.checkpoints = store
+	}
+}
+
+func NewService(q *db.Queries, conn *sql.DB, opts ...ServiceOption) Service {
 	broker := pubsub.NewBroker[Session]()
-	return &service{
+	s := &service{
 		Broker:         broker,
 		db:             conn,
 		q:              q,
 		estimatedUsage: make(map[string]bool),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateAgentToolSessionID creates a session ID for agent tool sessions using the format "messageID$$toolCallID"
 func (s *service) CreateAgentToolSessionID(messageID, toolCallID string) string {
-	return fmt.Sprintf("%s$$%s", messageID, toolCallID)
+	return AgentToolSessionID(messageID, toolCallID)
 }
 
 // ParseAgentToolSessionID parses an agent tool session ID into its components
 func (s *service) ParseAgentToolSessionID(sessionID string) (messageID string, toolCallID string, ok bool) {
+	return ParseAgentToolSessionID(sessionID)
+}
+
+// AgentToolSessionID creates a session ID for agent tool sessions using the format "messageID$$toolCallID"
+func AgentToolSessionID(messageID, toolCallID string) string {
+	return fmt.Sprintf("%s$$%s", messageID, toolCallID)
+}
+
+// ParseAgentToolSessionID parses an agent tool session ID into its components
+func ParseAgentToolSessionID(sessionID string) (messageID string, toolCallID string, ok bool) {
 	parts := strings.Split(sessionID, "$$")
 	if len(parts) != 2 {
 		return "", "", false
@@ -1,8 +1,10 @@
 package session
 
 import (
+	"os/exec"
 	"testing"
 
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/stretchr/testify/require"
 )
@@ -79,3 +81,36 @@ func TestEstimatedUsageStateCanBeClearedByExplicitSave(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, refetched.EstimatedUsage)
 }
+
+func TestDeleteRemovesCheckpoints(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(k+"_NAME", "Crush")
+		t.Setenv(k+"_EMAIL", "crush@charm.land")
+	}
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "-C", repo, "init").Run())
+	checkpoints, err := checkpoint.New(t.Context(), repo)
+	require.NoError(t, err)
+
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	sessions := NewService(db.New(conn), conn, WithCheckpoints(checkpoints))
+
+	created, err := sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	_, err = checkpoints.Create(t.Context(), created.ID, repo, "prompt")
+	require.NoError(t, err)
+
+	require.NoError(t, sessions.Delete(t.Context(), created.ID))
+	list, err := checkpoints.List(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
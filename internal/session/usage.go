@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// maxSessionDepth bounds the walk from a sub-session up to its root
+// session.
+const maxSessionDepth = 16
+
+// Usage is the tokens and cost spent by one or more sessions.
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+}
+
+// Tokens returns the total number of prompt and completion tokens.
+func (u Usage) Tokens() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// RecordUsage logs tokens and cost spent by a session, attributing them to
+// its root session so that sub-agent spending counts towards its parent.
+func (s *service) RecordUsage(ctx context.Context, sessionID string, usage Usage) error {
+	rootID, err := s.rootSessionID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return s.q.CreateUsageLog(ctx, db.CreateUsageLogParams{
+		SessionID:        sessionID,
+		RootSessionID:    rootID,
+		PromptTokens:     max(usage.PromptTokens, 0),
+		CompletionTokens: max(usage.CompletionTokens, 0),
+		Cost:             max(usage.Cost, 0),
+	})
+}
+
+// SessionUsage returns the total spent by the root session of sessionID
+// and all of its sub-sessions.
+func (s *service) SessionUsage(ctx context.Context, sessionID string) (Usage, error) {
+	rootID, err := s.rootSessionID(ctx, sessionID)
+	if err != nil {
+		return Usage{}, err
+	}
+	row, err := s.q.GetSessionUsageTotal(ctx, rootID)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{
+		PromptTokens:     row.PromptTokens,
+		CompletionTokens: row.CompletionTokens,
+		Cost:             row.Cost,
+	}, nil
+}
+
+// UsageSince returns the total spent by all sessions since t.
+func (s *service) UsageSince(ctx context.Context, t time.Time) (Usage, error) {
+	row, err := s.q.GetUsageTotalSince(ctx, t.Unix())
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{
+		PromptTokens:     row.PromptTokens,
+		CompletionTokens: row.CompletionTokens,
+		Cost:             row.Cost,
+	}, nil
+}
+
+func (s *service) rootSessionID(ctx context.Context, sessionID string) (string, error) {
+	id := sessionID
+	for range maxSessionDepth {
+		dbSession, err := s.q.GetSessionByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return id, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !dbSession.ParentSessionID.Valid || dbSession.ParentSessionID.String == "" {
+			return id, nil
+		}
+		id = dbSession.ParentSessionID.String
+	}
+	return id, nil
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+
+	sessions := NewService(db.New(conn), conn)
+
+	parent, err := sessions.Create(t.Context(), "parent")
+	require.NoError(t, err)
+	child, err := sessions.CreateTaskSession(t.Context(), "tool-call", parent.ID, "child")
+	require.NoError(t, err)
+	other, err := sessions.Create(t.Context(), "other")
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Second)
+	require.NoError(t, sessions.RecordUsage(t.Context(), parent.ID, Usage{PromptTokens: 100, CompletionTokens: 10, Cost: 0.5}))
+	require.NoError(t, sessions.RecordUsage(t.Context(), child.ID, Usage{PromptTokens: 50, CompletionTokens: 5, Cost: 0.25}))
+	require.NoError(t, sessions.RecordUsage(t.Context(), other.ID, Usage{PromptTokens: 1, CompletionTokens: 1, Cost: 1}))
+
+	t.Run("session includes sub-sessions", func(t *testing.T) {
+		for _, id := range []string{parent.ID, child.ID} {
+			usage, err := sessions.SessionUsage(t.Context(), id)
+			require.NoError(t, err)
+			require.Equal(t, int64(165), usage.Tokens())
+			require.InDelta(t, 0.75, usage.Cost, 1e-9)
+		}
+	})
+
+	t.Run("since", func(t *testing.T) {
+		usage, err := sessions.UsageSince(t.Context(), start)
+		require.NoError(t, err)
+		require.Equal(t, int64(167), usage.Tokens())
+		require.InDelta(t, 1.75, usage.Cost, 1e-9)
+
+		usage, err = sessions.UsageSince(t.Context(), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, usage.Tokens())
+		require.Zero(t, usage.Cost)
+	})
+
+	t.Run("survives session deletion", func(t *testing.T) {
+		require.NoError(t, sessions.Delete(t.Context(), other.ID))
+		usage, err := sessions.UsageSince(t.Context(), start)
+		require.NoError(t, err)
+		require.InDelta(t, 1.75, usage.Cost, 1e-9)
+	})
+}
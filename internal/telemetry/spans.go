@@ -0,0 +1,307 @@
+package telemetry
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes Crush adds to the OpenTelemetry semantic conventions.
+const (
+	stepKey              = attribute.Key("crush.agent.step")
+	retryStatusCodeKey   = attribute.Key("crush.provider.retry.status_code")
+	retryDelayKey        = attribute.Key("crush.provider.retry.delay")
+	toolErrorKey         = attribute.Key("crush.tool.error")
+	permissionToolKey    = attribute.Key("crush.permission.tool")
+	permissionActionKey  = attribute.Key("crush.permission.action")
+	permissionGrantedKey = attribute.Key("crush.permission.granted")
+	hookEventKey         = attribute.Key("crush.hook.event")
+	hookToolKey          = attribute.Key("crush.hook.tool")
+	hookDecisionKey      = attribute.Key("crush.hook.decision")
+	mcpServerKey         = attribute.Key("crush.mcp.server")
+	lspServerKey         = attribute.Key("crush.lsp.server")
+	lspMethodKey         = attribute.Key("crush.lsp.method")
+)
+
+// Turn traces an agent turn: a span for the turn, and a child span for
+// each request to the provider. A nil Turn, returned while telemetry is
+// off, does nothing.
+type Turn struct {
+	s     *state
+	ctx   context.Context
+	span  trace.Span
+	start time.Time
+	model string
+	attrs []attribute.KeyValue
+
+	mu        sync.Mutex
+	chat      trace.Span
+	chatStart time.Time
+	usage     fantasy.Usage
+}
+
+// StartTurn starts tracing a turn of the agent of sessionID with the
+// given provider and model. Spans started from the returned context,
+// such as those of tool calls, are children of the turn.
+func StartTurn(ctx context.Context, sessionID, provider, model string) (context.Context, *Turn) {
+	s := active.Load()
+	if s == nil {
+		return ctx, nil
+	}
+	t := &Turn{
+		s:     s,
+		start: time.Now(),
+		model: model,
+		attrs: []attribute.KeyValue{
+			semconv.GenAIProviderNameKey.String(provider),
+			semconv.GenAIRequestModel(model),
+		},
+	}
+	ctx, t.span = s.tracer.Start(ctx, "invoke_agent crush",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(t.attrs...),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameInvokeAgent,
+			semconv.GenAIAgentName("crush"),
+			semconv.GenAIConversationID(sessionID),
+		),
+	)
+	t.ctx = ctx
+	return ctx, t
+}
+
+// StartStep starts tracing the request to the provider of a step of the
+// turn. Retries of the request are part of it.
+func (t *Turn) StartStep(step int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endChat(nil)
+	_, t.chat = t.s.tracer.Start(t.ctx, "chat "+t.model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attrs...),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameChat,
+			stepKey.Int(step),
+		),
+	)
+	t.chatStart = time.Now()
+}
+
+// Retry records that the request of the current step failed with err and
+// is retried after delay.
+func (t *Turn) Retry(err *fantasy.ProviderError, delay time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.chat != nil {
+		attrs := []attribute.KeyValue{retryDelayKey.String(delay.String())}
+		if err != nil {
+			attrs = append(attrs, retryStatusCodeKey.Int(err.StatusCode))
+		}
+		t.chat.AddEvent("retry", trace.WithAttributes(attrs...))
+	}
+	t.s.retries.Add(t.ctx, 1, metric.WithAttributes(t.attrs...))
+}
+
+// ResponseFinished records the token usage and finish reason of the
+// response to the current step, and ends its span.
+func (t *Turn) ResponseFinished(usage fantasy.Usage, finishReason string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.chat == nil {
+		return
+	}
+	t.usage.InputTokens += usage.InputTokens
+	t.usage.OutputTokens += usage.OutputTokens
+	t.chat.SetAttributes(
+		semconv.GenAIResponseFinishReasons(finishReason),
+		semconv.GenAIUsageInputTokens(int(usage.InputTokens)),
+		semconv.GenAIUsageOutputTokens(int(usage.OutputTokens)),
+		semconv.GenAIUsageCacheReadInputTokens(int(usage.CacheReadTokens)),
+		semconv.GenAIUsageCacheCreationInputTokens(int(usage.CacheCreationTokens)),
+	)
+	t.s.tokenUsage.Record(t.ctx, usage.InputTokens, metric.WithAttributes(append(t.metricAttrs(), semconv.GenAITokenTypeInput)...))
+	t.s.tokenUsage.Record(t.ctx, usage.OutputTokens, metric.WithAttributes(append(t.metricAttrs(), semconv.GenAITokenTypeOutput)...))
+	t.endChat(nil)
+}
+
+// End ends the turn, and the request of the current step if it failed
+// with err.
+func (t *Turn) End(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endChat(err)
+	t.span.SetAttributes(
+		semconv.GenAIUsageInputTokens(int(t.usage.InputTokens)),
+		semconv.GenAIUsageOutputTokens(int(t.usage.OutputTokens)),
+	)
+	attrs := slices.Clone(t.attrs)
+	if err != nil {
+		recordError(t.span, err)
+		attrs = append(attrs, semconv.ErrorType(err))
+	}
+	t.span.End()
+	t.s.turnDuration.Record(t.ctx, since(t.start), metric.WithAttributes(attrs...))
+}
+
+// endChat ends the span of the current request, if any. t.mu must be held.
+func (t *Turn) endChat(err error) {
+	if t.chat == nil {
+		return
+	}
+	attrs := t.metricAttrs()
+	if err != nil {
+		recordError(t.chat, err)
+		attrs = append(attrs, semconv.ErrorType(err))
+	}
+	t.chat.End()
+	t.chat = nil
+	t.s.operationDuration.Record(t.ctx, since(t.chatStart), metric.WithAttributes(attrs...))
+}
+
+func (t *Turn) metricAttrs() []attribute.KeyValue {
+	return append([]attribute.KeyValue{semconv.GenAIOperationNameChat}, t.attrs...)
+}
+
+// Tool starts tracing a call to a tool. The returned function ends it;
+// isError reports whether the tool responded with an error.
+func Tool(ctx context.Context, name, callID string) (context.Context, func(isError bool, err error)) {
+	s := active.Load()
+	if s == nil {
+		return ctx, func(bool, error) {}
+	}
+	start := time.Now()
+	ctx, span := s.tracer.Start(ctx, "execute_tool "+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameExecuteTool,
+			semconv.GenAIToolName(name),
+			semconv.GenAIToolCallID(callID),
+		),
+	)
+	return ctx, func(isError bool, err error) {
+		attrs := []attribute.KeyValue{semconv.GenAIToolName(name)}
+		switch {
+		case err != nil:
+			recordError(span, err)
+			attrs = append(attrs, semconv.ErrorType(err))
+		case isError:
+			span.SetAttributes(toolErrorKey.Bool(true))
+			span.SetStatus(codes.Error, "tool responded with an error")
+			attrs = append(attrs, toolErrorKey.Bool(true))
+		}
+		span.End()
+		s.toolDuration.Record(ctx, since(start), metric.WithAttributes(attrs...))
+	}
+}
+
+// Permission starts tracing a permission request for an action of a
+// tool, which may wait for the user. The returned function ends it.
+func Permission(ctx context.Context, tool, action string) (context.Context, func(granted bool, err error)) {
+	s := active.Load()
+	if s == nil {
+		return ctx, func(bool, error) {}
+	}
+	start := time.Now()
+	attrs := []attribute.KeyValue{permissionToolKey.String(tool), permissionActionKey.String(action)}
+	ctx, span := s.tracer.Start(ctx, "permission "+tool,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, func(granted bool, err error) {
+		attrs := append(attrs, permissionGrantedKey.Bool(granted))
+		span.SetAttributes(permissionGrantedKey.Bool(granted))
+		if err != nil {
+			recordError(span, err)
+			attrs = append(attrs, semconv.ErrorType(err))
+		}
+		span.End()
+		s.permissionWait.Record(ctx, since(start), metric.WithAttributes(attrs...))
+	}
+}
+
+// Hook starts tracing the hooks of an event, for a tool if the event is
+// about one. The returned function ends it with the aggregated decision.
+func Hook(ctx context.Context, event, tool string) (context.Context, func(decision string)) {
+	s := active.Load()
+	if s == nil {
+		return ctx, func(string) {}
+	}
+	start := time.Now()
+	attrs := []attribute.KeyValue{hookEventKey.String(event)}
+	if tool != "" {
+		attrs = append(attrs, hookToolKey.String(tool))
+	}
+	ctx, span := s.tracer.Start(ctx, "hook "+event,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, func(decision string) {
+		span.SetAttributes(hookDecisionKey.String(decision))
+		span.End()
+		s.hookDuration.Record(ctx, since(start), metric.WithAttributes(append(attrs, hookDecisionKey.String(decision))...))
+	}
+}
+
+// MCPRequest starts tracing a request to an MCP server. The returned
+// function ends it.
+func MCPRequest(ctx context.Context, server, method string) (context.Context, func(err error)) {
+	return request(ctx, method+" "+server, func(s *state) metric.Float64Histogram { return s.mcpDuration },
+		mcpServerKey.String(server),
+		semconv.McpMethodNameKey.String(method),
+	)
+}
+
+// LSPRequest starts tracing a request to an LSP server. The returned
+// function ends it.
+func LSPRequest(ctx context.Context, server, method string) (context.Context, func(err error)) {
+	return request(ctx, method+" "+server, func(s *state) metric.Float64Histogram { return s.lspDuration },
+		lspServerKey.String(server),
+		lspMethodKey.String(method),
+	)
+}
+
+func request(ctx context.Context, name string, histogram func(*state) metric.Float64Histogram, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	s := active.Load()
+	if s == nil {
+		return ctx, func(error) {}
+	}
+	start := time.Now()
+	ctx, span := s.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, func(err error) {
+		if err != nil {
+			recordError(span, err)
+			attrs = append(attrs, semconv.ErrorType(err))
+		}
+		span.End()
+		histogram(s).Record(ctx, since(start), metric.WithAttributes(attrs...))
+	}
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, strings.TrimSpace(err.Error()))
+}
@@ -0,0 +1,330 @@
+// Package telemetry exports OpenTelemetry traces and metrics of agent
+// turns, provider requests, tool calls, and MCP and LSP requests.
+//
+// Telemetry is off unless enabled in the config. It is unrelated to the
+// event package, which sends product usage metrics and is controlled by
+// disable_metrics. While telemetry is off, every helper in this package is
+// a no-op.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/version"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// name is the instrumentation scope of the tracer and meter.
+const name = "github.com/charmbracelet/crush"
+
+// Exporters.
+const (
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterFile     = "file"
+)
+
+// DefaultFile is where the file exporter writes, relative to the data
+// directory.
+const DefaultFile = "telemetry.jsonl"
+
+// state is the running telemetry pipeline. It is shared by every app in
+// the process, such as the workspaces of a server, and shut down when the
+// last of them releases it.
+type state struct {
+	tracer   trace.Tracer
+	shutdown func(context.Context) error
+	refs     int
+
+	operationDuration metric.Float64Histogram
+	tokenUsage        metric.Int64Histogram
+	retries           metric.Int64Counter
+	turnDuration      metric.Float64Histogram
+	toolDuration      metric.Float64Histogram
+	permissionWait    metric.Float64Histogram
+	hookDuration      metric.Float64Histogram
+	mcpDuration       metric.Float64Histogram
+	lspDuration       metric.Float64Histogram
+}
+
+var (
+	mu     sync.Mutex
+	active atomic.Pointer[state]
+)
+
+// Enabled reports whether telemetry is being exported.
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Init starts exporting telemetry if opts enables it, and returns a
+// function that stops it and flushes what is left. Telemetry already
+// started by another caller is shared.
+func Init(ctx context.Context, opts *config.TelemetryOptions, dataDir string) (func(context.Context) error, error) {
+	if opts == nil || !opts.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	s := active.Load()
+	if s == nil {
+		var err error
+		s, err = start(ctx, opts, dataDir)
+		if err != nil {
+			return nil, err
+		}
+		active.Store(s)
+	}
+	s.refs++
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		var err error
+		once.Do(func() {
+			mu.Lock()
+			s.refs--
+			last := s.refs == 0
+			if last {
+				active.Store(nil)
+			}
+			mu.Unlock()
+			if last {
+				err = s.shutdown(ctx)
+			}
+		})
+		return err
+	}, nil
+}
+
+func start(ctx context.Context, opts *config.TelemetryOptions, dataDir string) (*state, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("crush"),
+		semconv.ServiceVersion(version.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("telemetry resource: %w", err)
+	}
+
+	var (
+		spanExporter   sdktrace.SpanExporter
+		metricExporter sdkmetric.Exporter
+		closer         io.Closer
+	)
+	switch opts.Exporter {
+	case "", ExporterOTLPHTTP:
+		spanExporter, metricExporter, err = otlpHTTPExporters(ctx, opts)
+	case ExporterOTLPGRPC:
+		spanExporter, metricExporter, err = otlpGRPCExporters(ctx, opts)
+	case ExporterFile:
+		spanExporter, metricExporter, closer, err = fileExporters(opts, dataDir)
+	default:
+		err = fmt.Errorf("unknown exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("telemetry exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	s := &state{
+		tracer: tracerProvider.Tracer(name, trace.WithInstrumentationVersion(version.Version)),
+		shutdown: func(ctx context.Context) error {
+			err := errors.Join(
+				tracerProvider.Shutdown(ctx),
+				meterProvider.Shutdown(ctx),
+			)
+			if closer != nil {
+				err = errors.Join(err, closer.Close())
+			}
+			return err
+		},
+	}
+	if err := s.instruments(meterProvider.Meter(name, metric.WithInstrumentationVersion(version.Version))); err != nil {
+		return nil, errors.Join(fmt.Errorf("telemetry instruments: %w", err), s.shutdown(ctx))
+	}
+	return s, nil
+}
+
+// durationBuckets are the histogram buckets, in seconds, recommended for
+// gen_ai.client.operation.duration. They suit the other durations too.
+var durationBuckets = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+
+func (s *state) instruments(meter metric.Meter) error {
+	var err error
+	s.tokenUsage, err = meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithUnit("{token}"),
+		metric.WithDescription("Number of input and output tokens used."),
+		metric.WithExplicitBucketBoundaries(1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+	)
+	if err != nil {
+		return err
+	}
+	s.retries, err = meter.Int64Counter("crush.provider.retries",
+		metric.WithUnit("{retry}"),
+		metric.WithDescription("Number of provider requests retried."),
+	)
+	if err != nil {
+		return err
+	}
+
+	durations := []struct {
+		histogram   *metric.Float64Histogram
+		name        string
+		description string
+	}{
+		{&s.operationDuration, "gen_ai.client.operation.duration", "Duration of provider requests."},
+		{&s.turnDuration, "crush.agent.turn.duration", "Duration of agent turns."},
+		{&s.toolDuration, "crush.tool.duration", "Duration of tool calls, including hooks and permission prompts."},
+		{&s.permissionWait, "crush.permission.wait.duration", "Time spent waiting for permission to run a tool."},
+		{&s.hookDuration, "crush.hook.duration", "Duration of hooks."},
+		{&s.mcpDuration, "crush.mcp.request.duration", "Duration of requests to MCP servers."},
+		{&s.lspDuration, "crush.lsp.request.duration", "Duration of requests to LSP servers."},
+	}
+	for _, d := range durations {
+		*d.histogram, err = meter.Float64Histogram(d.name,
+			metric.WithUnit("s"),
+			metric.WithDescription(d.description),
+			metric.WithExplicitBucketBoundaries(durationBuckets...),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// otlpEndpoint returns the collector URL to use, or "" to leave it to the
+// OTEL_EXPORTER_OTLP_* environment variables the exporters read.
+func otlpEndpoint(opts *config.TelemetryOptions, fallback string) string {
+	if opts.Endpoint != "" {
+		return opts.Endpoint
+	}
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"} {
+		if os.Getenv(env) != "" {
+			return ""
+		}
+	}
+	return fallback
+}
+
+func otlpHTTPExporters(ctx context.Context, opts *config.TelemetryOptions) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	traceOpts := []otlptracehttp.Option{otlptracehttp.WithHeaders(opts.Headers)}
+	metricOpts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(opts.Headers)}
+	if endpoint := otlpEndpoint(opts, "http://localhost:4318"); endpoint != "" {
+		// Like OTEL_EXPORTER_OTLP_ENDPOINT, the endpoint is the base URL
+		// of the collector and each signal has a path of its own.
+		tracesURL, err := url.JoinPath(endpoint, "v1/traces")
+		if err != nil {
+			return nil, nil, err
+		}
+		metricsURL, err := url.JoinPath(endpoint, "v1/metrics")
+		if err != nil {
+			return nil, nil, err
+		}
+		traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(tracesURL))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(metricsURL))
+	}
+	spanExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, nil, errors.Join(err, spanExporter.Shutdown(ctx))
+	}
+	return spanExporter, metricExporter, nil
+}
+
+func otlpGRPCExporters(ctx context.Context, opts *config.TelemetryOptions) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	traceOpts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(opts.Headers)}
+	metricOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(opts.Headers)}
+	if endpoint := otlpEndpoint(opts, "http://localhost:4317"); endpoint != "" {
+		traceOpts = append(traceOpts, otlptracegrpc.WithEndpointURL(endpoint))
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpointURL(endpoint))
+	}
+	spanExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, nil, errors.Join(err, spanExporter.Shutdown(ctx))
+	}
+	return spanExporter, metricExporter, nil
+}
+
+func fileExporters(opts *config.TelemetryOptions, dataDir string) (sdktrace.SpanExporter, sdkmetric.Exporter, io.Closer, error) {
+	path := opts.File
+	if path == "" {
+		path = DefaultFile
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dataDir, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, nil, nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Spans and metrics are exported from different goroutines.
+	w := &lockedWriter{w: f}
+	spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, nil, nil, errors.Join(err, f.Close())
+	}
+	metricExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(w))
+	if err != nil {
+		return nil, nil, nil, errors.Join(err, f.Close())
+	}
+	return spanExporter, metricExporter, f, nil
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// since returns the seconds elapsed since t, the unit of the duration
+// histograms.
+func since(t time.Time) float64 {
+	return time.Since(t).Seconds()
+}
@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitDisabled(t *testing.T) {
+	for _, opts := range []*config.TelemetryOptions{nil, {Exporter: ExporterFile}} {
+		shutdown, err := Init(t.Context(), opts, t.TempDir())
+		require.NoError(t, err)
+		require.False(t, Enabled())
+		require.NoError(t, shutdown(t.Context()))
+	}
+
+	ctx, turn := StartTurn(t.Context(), "session", "anthropic", "claude")
+	require.Nil(t, turn)
+	turn.StartStep(0)
+	turn.ResponseFinished(fantasy.Usage{InputTokens: 10}, "stop")
+	turn.End(nil)
+	_, end := Tool(ctx, "view", "call")
+	end(false, nil)
+}
+
+func TestInitUnknownExporter(t *testing.T) {
+	_, err := Init(t.Context(), &config.TelemetryOptions{Enabled: true, Exporter: "carrier-pigeon"}, t.TempDir())
+	require.ErrorContains(t, err, `unknown exporter "carrier-pigeon"`)
+	require.False(t, Enabled())
+}
+
+func TestFileExporter(t *testing.T) {
+	dataDir := t.TempDir()
+	opts := &config.TelemetryOptions{Enabled: true, Exporter: ExporterFile}
+
+	shutdown, err := Init(t.Context(), opts, dataDir)
+	require.NoError(t, err)
+	shutdownOther, err := Init(t.Context(), opts, dataDir)
+	require.NoError(t, err)
+	require.True(t, Enabled())
+
+	ctx, turn := StartTurn(t.Context(), "session-1", "anthropic", "claude")
+	require.NotNil(t, turn)
+	turn.StartStep(0)
+	turn.Retry(&fantasy.ProviderError{StatusCode: 529}, 0)
+	turn.ResponseFinished(fantasy.Usage{InputTokens: 120, OutputTokens: 30}, "tool-calls")
+
+	toolCtx, endTool := Tool(ctx, "bash", "call-1")
+	permCtx, endPermission := Permission(toolCtx, "bash", "execute")
+	endPermission(true, nil)
+	_, endHook := Hook(permCtx, "PreToolUse", "bash")
+	endHook("allow")
+	endTool(false, nil)
+
+	_, endMCP := MCPRequest(ctx, "docs", "tools/call")
+	endMCP(errors.New("boom"))
+	_, endLSP := LSPRequest(ctx, "gopls", "textDocument/hover")
+	endLSP(nil)
+
+	turn.StartStep(1)
+	turn.End(context.Canceled)
+
+	require.NoError(t, shutdownOther(t.Context()))
+	require.True(t, Enabled(), "telemetry stays on until the last caller shuts it down")
+	require.NoError(t, shutdown(t.Context()))
+	require.NoError(t, shutdown(t.Context()), "shutting down twice is harmless")
+	require.False(t, Enabled())
+
+	data, err := os.ReadFile(filepath.Join(dataDir, DefaultFile))
+	require.NoError(t, err)
+	out := string(data)
+	for _, s := range []string{
+		`"Name":"invoke_agent crush"`,
+		`"Name":"chat claude"`,
+		`"Name":"execute_tool bash"`,
+		`"Name":"permission bash"`,
+		`"Name":"hook PreToolUse"`,
+		`"Name":"tools/call docs"`,
+		`"Name":"textDocument/hover gopls"`,
+		`"gen_ai.conversation.id"`,
+		`"session-1"`,
+		`"gen_ai.client.token.usage"`,
+		`"gen_ai.client.operation.duration"`,
+		`"crush.provider.retries"`,
+		`"crush.agent.turn.duration"`,
+		`"crush.tool.duration"`,
+		`"crush.permission.wait.duration"`,
+		`"crush.hook.duration"`,
+		`"crush.mcp.request.duration"`,
+		`"crush.lsp.request.duration"`,
+	} {
+		require.Contains(t, out, s)
+	}
+}
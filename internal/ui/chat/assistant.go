@@ -340,7 +340,7 @@ func (a *AssistantMessageItem) renderMessageContent(width int) (string, int) {
 		switch a.message.FinishReason() {
 		case message.FinishReasonCanceled:
 			messageParts = append(messageParts, a.sty.Messages.AssistantCanceled.Render("Canceled"))
-		case message.FinishReasonError:
+		case message.FinishReasonError, message.FinishReasonBudgetExceeded, message.FinishReasonFailover:
 			messageParts = append(messageParts, a.cachedError(width))
 		}
 	}
@@ -388,7 +388,11 @@ func (a *AssistantMessageItem) contentKey() (uint64, uint64) {
 // error section. Returns (0, 0) when no error is present so the cache
 // stays a no-op for non-error messages.
 func (a *AssistantMessageItem) errorKey() (uint64, uint64) {
-	if !a.message.IsFinished() || a.message.FinishReason() != message.FinishReasonError {
+	if !a.message.IsFinished() {
+		return 0, 0
+	}
+	reason := a.message.FinishReason()
+	if reason != message.FinishReasonError && reason != message.FinishReasonBudgetExceeded && reason != message.FinishReasonFailover {
 		return 0, 0
 	}
 	finishPart := a.message.FinishPart()
@@ -398,7 +402,7 @@ func (a *AssistantMessageItem) errorKey() (uint64, uint64) {
 	// Length-prefixed framing prevents Message+Details collisions
 	// between distinct (Message, Details) tuples that would
 	// otherwise concatenate to the same byte sequence.
-	return fnvFields([]byte(finishPart.Message), []byte(finishPart.Details)), 0
+	return fnvFields([]byte(reason), []byte(finishPart.Message), []byte(finishPart.Details)), 0
 }
 
 // cachedThinking returns the rendered thinking section, computing and
@@ -522,10 +526,18 @@ func (a *AssistantMessageItem) renderSpinning() string {
 	return a.anim.Render()
 }
 
-// renderError renders an error message.
+// renderError renders an error message, the reason a budget stopped the
+// turn, or the failure that moved it on to a fallback model.
 func (a *AssistantMessageItem) renderError(width int) string {
 	finishPart := a.message.FinishPart()
-	errTag := a.sty.Messages.ErrorTag.Render("ERROR")
+	tag := "ERROR"
+	switch finishPart.Reason {
+	case message.FinishReasonBudgetExceeded:
+		tag = "BUDGET"
+	case message.FinishReasonFailover:
+		tag = "FAILOVER"
+	}
+	errTag := a.sty.Messages.ErrorTag.Render(tag)
 	truncated := ansi.Truncate(finishPart.Message, width-2-lipgloss.Width(errTag), "...")
 	title := fmt.Sprintf("%s %s", errTag, a.sty.Messages.ErrorTitle.Render(truncated))
 	details := a.sty.Messages.ErrorDetails.Width(width - 2).Render(finishPart.Details)
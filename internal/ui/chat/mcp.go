@@ -12,6 +12,16 @@ import (
 // MCPToolMessageItem is a message item that represents a bash tool call.
 type MCPToolMessageItem struct {
 	*baseToolMessageItem
+
+	// progress is the last progress the server reported on the tool call,
+	// if any.
+	progress *mcpToolProgress
+}
+
+// mcpToolProgress is the progress an MCP server reports on a tool call.
+type mcpToolProgress struct {
+	progress, total float64
+	message         string
 }
 
 var _ ToolMessageItem = (*MCPToolMessageItem)(nil)
@@ -23,11 +33,23 @@ func NewMCPToolMessageItem(
 	result *message.ToolResult,
 	canceled bool,
 ) ToolMessageItem {
-	return newBaseToolMessageItem(sty, toolCall, result, &MCPToolRenderContext{}, canceled)
+	t := &MCPToolMessageItem{}
+	t.baseToolMessageItem = newBaseToolMessageItem(sty, toolCall, result, &MCPToolRenderContext{mcp: t}, canceled)
+	return t
+}
+
+// SetProgress sets the progress the server reports on the tool call, out
+// of total when known.
+func (t *MCPToolMessageItem) SetProgress(progress, total float64, message string) {
+	t.progress = &mcpToolProgress{progress: progress, total: total, message: message}
+	t.clearCache()
+	t.Bump()
 }
 
 // MCPToolRenderContext renders bash tool messages.
-type MCPToolRenderContext struct{}
+type MCPToolRenderContext struct {
+	mcp *MCPToolMessageItem
+}
 
 // RenderTool implements the [ToolRenderer] interface.
 func (b *MCPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
@@ -64,6 +86,10 @@ func (b *MCPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *T
 		return header
 	}
 
+	if p := b.mcp.progress; p != nil && opts.Status == ToolStatusRunning && !opts.HasResult() {
+		return joinToolParts(header, sty.Tool.StateWaiting.Render(p.String()))
+	}
+
 	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
 		return joinToolParts(header, earlyState)
 	}
@@ -76,3 +102,17 @@ func (b *MCPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *T
 	body := renderToolResultTextContent(sty, opts.Result.Content, toolResultContentWidths{Body: bodyWidth, Diff: cappedWidth}, opts.ExpandedContent)
 	return joinToolParts(header, body)
 }
+
+// String returns the progress as in "3/10 (30%) Indexing".
+func (p *mcpToolProgress) String() string {
+	var s string
+	if p.total > 0 {
+		s = fmt.Sprintf("%g/%g (%.0f%%)", p.progress, p.total, 100*p.progress/p.total)
+	} else {
+		s = fmt.Sprintf("%g", p.progress)
+	}
+	if p.message != "" {
+		s += " " + p.message
+	}
+	return s
+}
@@ -405,7 +405,9 @@ func ExtractMessageItems(sty *styles.Styles, msg *message.Message, toolResults m
 func ShouldRenderAssistantMessage(msg *message.Message) bool {
 	content := strings.TrimSpace(msg.Content().Text)
 	thinking := strings.TrimSpace(msg.ReasoningContent().Thinking)
-	isError := msg.FinishReason() == message.FinishReasonError
+	isError := msg.FinishReason() == message.FinishReasonError ||
+		msg.FinishReason() == message.FinishReasonBudgetExceeded ||
+		msg.FinishReason() == message.FinishReasonFailover
 	isCancelled := msg.FinishReason() == message.FinishReasonCanceled
 	hasToolCalls := len(msg.ToolCalls()) > 0
 	return !hasToolCalls || content != "" || thinking != "" || msg.IsThinking() || isError || isCancelled
@@ -193,6 +193,67 @@ func (l *LSToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *To
 	return joinToolParts(header, body)
 }
 
+// -----------------------------------------------------------------------------
+// Repo Map Tool
+// -----------------------------------------------------------------------------
+
+// RepoMapToolMessageItem is a message item that represents a repo_map tool
+// call.
+type RepoMapToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RepoMapToolMessageItem)(nil)
+
+// NewRepoMapToolMessageItem creates a new [RepoMapToolMessageItem].
+func NewRepoMapToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RepoMapToolRenderContext{}, canceled)
+}
+
+// RepoMapToolRenderContext renders repo_map tool messages.
+type RepoMapToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *RepoMapToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Repo Map", opts.Anim, opts.Compact)
+	}
+
+	var params tools.RepoMapParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	path := params.Path
+	if path == "" {
+		path = "."
+	}
+	path = fsext.PrettyPath(path)
+
+	header := toolHeader(sty, opts.Status, "Repo Map", cappedWidth, opts.Compact, path)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
 // -----------------------------------------------------------------------------
 // Sourcegraph Tool
 // -----------------------------------------------------------------------------
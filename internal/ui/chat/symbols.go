@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"encoding/json"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// DocumentSymbolsToolMessageItem is a message item that represents a
+// document symbols tool call.
+type DocumentSymbolsToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*DocumentSymbolsToolMessageItem)(nil)
+
+// NewDocumentSymbolsToolMessageItem creates a new [DocumentSymbolsToolMessageItem].
+func NewDocumentSymbolsToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &DocumentSymbolsToolRenderContext{}, canceled)
+}
+
+// DocumentSymbolsToolRenderContext renders document symbols tool messages.
+type DocumentSymbolsToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *DocumentSymbolsToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Symbols", opts.Anim, opts.Compact)
+	}
+
+	var params tools.DocumentSymbolsParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	header := toolHeader(sty, opts.Status, "Symbols", cappedWidth, opts.Compact, fsext.PrettyPath(params.FilePath))
+	return renderSymbolsBody(sty, header, cappedWidth, opts)
+}
+
+// WorkspaceSymbolsToolMessageItem is a message item that represents a
+// workspace symbols tool call.
+type WorkspaceSymbolsToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*WorkspaceSymbolsToolMessageItem)(nil)
+
+// NewWorkspaceSymbolsToolMessageItem creates a new [WorkspaceSymbolsToolMessageItem].
+func NewWorkspaceSymbolsToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &WorkspaceSymbolsToolRenderContext{}, canceled)
+}
+
+// WorkspaceSymbolsToolRenderContext renders workspace symbols tool messages.
+type WorkspaceSymbolsToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *WorkspaceSymbolsToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Workspace Symbols", opts.Anim, opts.Compact)
+	}
+
+	var params tools.WorkspaceSymbolsParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	toolParams := []string{params.Query}
+	if params.Path != "" {
+		toolParams = append(toolParams, "path", fsext.PrettyPath(params.Path))
+	}
+
+	header := toolHeader(sty, opts.Status, "Workspace Symbols", cappedWidth, opts.Compact, toolParams...)
+	return renderSymbolsBody(sty, header, cappedWidth, opts)
+}
+
+// renderSymbolsBody renders the plain text output shared by the symbol
+// tools below their header.
+func renderSymbolsBody(sty *styles.Styles, header string, cappedWidth int, opts *ToolRenderOpts) string {
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
@@ -174,7 +174,8 @@ func newBaseToolMessageItem(
 	canceled bool,
 ) *baseToolMessageItem {
 	// we only do full width for diffs (as far as I know)
-	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName && toolCall.Name != tools.HashlineEditToolName
+	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName && toolCall.Name != tools.HashlineEditToolName &&
+		toolCall.Name != tools.RenameToolName && toolCall.Name != tools.CodeActionToolName
 
 	status := ToolStatusRunning
 	if canceled {
@@ -243,6 +244,8 @@ func NewToolMessageItem(
 		item = NewGrepToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSToolName:
 		item = NewLSToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RepoMapToolName:
+		item = NewRepoMapToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DownloadToolName:
 		item = NewDownloadToolMessageItem(sty, toolCall, result, canceled)
 	case tools.FetchToolName:
@@ -263,6 +266,18 @@ func NewToolMessageItem(
 		item = NewTodosToolMessageItem(sty, toolCall, result, canceled)
 	case tools.ReferencesToolName:
 		item = NewReferencesToolMessageItem(sty, toolCall, result, canceled)
+	case tools.DefinitionToolName:
+		item = NewDefinitionToolMessageItem(sty, toolCall, result, canceled)
+	case tools.HoverToolName:
+		item = NewHoverToolMessageItem(sty, toolCall, result, canceled)
+	case tools.DocumentSymbolsToolName:
+		item = NewDocumentSymbolsToolMessageItem(sty, toolCall, result, canceled)
+	case tools.WorkspaceSymbolsToolName:
+		item = NewWorkspaceSymbolsToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RenameToolName:
+		item = NewRenameToolMessageItem(sty, toolCall, result, canceled)
+	case tools.CodeActionToolName:
+		item = NewCodeActionToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSPRestartToolName:
 		item = NewLSPRestartToolMessageItem(sty, toolCall, result, canceled)
 	case tools.NumbatToolName:
@@ -1234,6 +1249,15 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return fmt.Sprintf("**Path:** %s", fsext.PrettyPath(path))
 		}
+	case tools.RepoMapToolName:
+		var params tools.RepoMapParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			path := params.Path
+			if path == "" {
+				path = "."
+			}
+			return fmt.Sprintf("**Path:** %s", fsext.PrettyPath(path))
+		}
 	case tools.DownloadToolName:
 		var params tools.DownloadParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -1315,7 +1339,7 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return t.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.RepoMapToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
 		return fmt.Sprintf("```\n%s\n```", t.result.Content)
 	default:
 		return t.result.Content
@@ -1659,6 +1683,8 @@ func prettifyToolName(name string) string {
 		return "Grep"
 	case tools.LSToolName:
 		return "List"
+	case tools.RepoMapToolName:
+		return "Repo Map"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
 	case tools.TodosToolName:
@@ -0,0 +1,183 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// -----------------------------------------------------------------------------
+// Rename Tool
+// -----------------------------------------------------------------------------
+
+// RenameToolMessageItem is a message item that represents a rename tool call.
+type RenameToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RenameToolMessageItem)(nil)
+
+// NewRenameToolMessageItem creates a new [RenameToolMessageItem].
+func NewRenameToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RenameToolRenderContext{}, canceled)
+}
+
+// RenameToolRenderContext renders rename tool messages.
+type RenameToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *RenameToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	// Rename tool uses full width for diffs.
+	if opts.IsPending() {
+		return pendingTool(sty, "Rename", opts.Anim, opts.Compact)
+	}
+
+	var params tools.RenameParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, width)
+	}
+
+	toolParams := []string{params.Symbol, "to", params.NewName}
+	if params.FilePath != "" {
+		toolParams = append(toolParams, "file", fsext.PrettyPath(params.FilePath))
+	}
+	if params.Line > 0 {
+		toolParams = append(toolParams, "line", strconv.Itoa(params.Line))
+	}
+
+	header := toolHeader(sty, opts.Status, "Rename", width, opts.Compact, toolParams...)
+	return renderWorkspaceEditTool(sty, header, width, opts)
+}
+
+// -----------------------------------------------------------------------------
+// Code Action Tool
+// -----------------------------------------------------------------------------
+
+// CodeActionToolMessageItem is a message item that represents a code action
+// tool call.
+type CodeActionToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*CodeActionToolMessageItem)(nil)
+
+// NewCodeActionToolMessageItem creates a new [CodeActionToolMessageItem].
+func NewCodeActionToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &CodeActionToolRenderContext{}, canceled)
+}
+
+// CodeActionToolRenderContext renders code action tool messages.
+type CodeActionToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (c *CodeActionToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	// Code action tool uses full width for diffs.
+	if opts.IsPending() {
+		return pendingTool(sty, "Code Action", opts.Anim, opts.Compact)
+	}
+
+	var params tools.CodeActionParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, width)
+	}
+
+	toolParams := []string{fsext.PrettyPath(params.FilePath)}
+	if params.Title != "" {
+		toolParams = append(toolParams, "title", params.Title)
+	}
+	if params.Kind != "" {
+		toolParams = append(toolParams, "kind", params.Kind)
+	}
+	if params.StartLine > 0 {
+		toolParams = append(toolParams, "lines", formatLineRange(params.StartLine, params.EndLine))
+	}
+
+	header := toolHeader(sty, opts.Status, "Code Action", width, opts.Compact, toolParams...)
+	return renderWorkspaceEditTool(sty, header, width, opts)
+}
+
+func formatLineRange(start, end int) string {
+	if end <= start {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// renderWorkspaceEditTool renders the body of a tool that applies an LSP
+// workspace edit: a diff of each changed file, or the plain result when
+// nothing was changed.
+func renderWorkspaceEditTool(sty *styles.Styles, header string, width int, opts *ToolRenderOpts) string {
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, width); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	var meta tools.WorkspaceEditResponseMetadata
+	if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err != nil || len(meta.Files) == 0 {
+		bodyWidth := cappedMessageWidth(width) - toolBodyLeftPaddingTotal
+		body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+		return joinToolParts(header, body)
+	}
+
+	body := toolOutputWorkspaceEditContent(sty, meta, width, opts.ExpandedContent)
+	return joinToolParts(header, body)
+}
+
+// toolOutputWorkspaceEditContent renders the diffs of all files changed by
+// a workspace edit, truncated as a whole unless expanded.
+func toolOutputWorkspaceEditContent(sty *styles.Styles, meta tools.WorkspaceEditResponseMetadata, width int, expanded bool) string {
+	bodyWidth := width - toolBodyLeftPaddingTotal
+
+	diffs := make([]string, 0, len(meta.Files))
+	for _, file := range meta.Files {
+		name := fsext.PrettyPath(file.FilePath)
+		formatter := common.DiffFormatter(sty).
+			Before(name, file.OldContent).
+			After(name, file.NewContent).
+			Width(bodyWidth)
+
+		// Use split view for wide terminals.
+		if width > maxTextWidth {
+			formatter = formatter.Split()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+
+	formatted := strings.Join(diffs, "\n")
+	lines := strings.Split(formatted, "\n")
+
+	// Truncate if needed.
+	maxLines := responseContextHeight
+	if len(lines) > maxLines && !expanded {
+		truncMsg := sty.Tool.DiffTruncation.
+			Width(bodyWidth).
+			Render(fmt.Sprintf(assistantMessageTruncateFormat, len(lines)-maxLines))
+		formatted = strings.Join(lines[:maxLines], "\n") + "\n" + truncMsg
+	}
+
+	return sty.Tool.Body.Render(formatted)
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"image"
+	"log/slog"
 	"os"
 
 	tea "charm.land/bubbletea/v2"
@@ -34,15 +35,47 @@ func (c *Common) Config() *config.Config {
 	return c.Workspace.Config()
 }
 
-// DefaultCommon returns the default common UI configurations. When the
-// workspace has a large model selected, the theme is chosen based on its
-// provider; otherwise the default theme is used.
+// Keymap returns the key bindings configured by the user, by action.
+func (c *Common) Keymap() map[string][]string {
+	cfg := c.Config()
+	if cfg == nil || cfg.Options == nil || cfg.Options.TUI == nil {
+		return nil
+	}
+	return cfg.Options.TUI.Keymap
+}
+
+// Theme returns the name of the theme configured by the user, if any.
+func (c *Common) Theme() string {
+	cfg := c.Config()
+	if cfg == nil || cfg.Options == nil || cfg.Options.TUI == nil {
+		return ""
+	}
+	return cfg.Options.TUI.Theme
+}
+
+// ThemeStyles returns the styles of the configured theme. When no theme is
+// configured, or the configured one doesn't exist, the theme is chosen based
+// on the provider of the large model.
+func (c *Common) ThemeStyles() styles.Styles {
+	if s, ok := styles.ThemeStyles(c.Theme()); ok {
+		return s
+	}
+	return styles.ThemeForProvider(largeModelProviderID(c.Workspace))
+}
+
+// DefaultCommon returns the default common UI configurations. It loads the
+// themes of the user and of the workspace, and uses the configured one,
+// falling back to the one of the provider of the large model.
 func DefaultCommon(ws workspace.Workspace) *Common {
-	s := styles.IsobitStyles()
-	return &Common{
-		Workspace: ws,
-		Styles:    &s,
+	c := &Common{Workspace: ws}
+	if ws != nil {
+		if err := styles.LoadThemes(config.ThemeDirs(ws.WorkingDir())...); err != nil {
+			slog.Warn("Failed to load some themes", "error", err)
+		}
 	}
+	s := c.ThemeStyles()
+	c.Styles = &s
+	return c
 }
 
 // largeModelProviderID returns the provider ID of the currently selected
@@ -2,6 +2,7 @@ package completions
 
 import (
 	"cmp"
+	"log/slog"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/searchindex"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/ordered"
@@ -140,6 +142,11 @@ func (c *Completions) KeyMap() KeyMap {
 	return c.keyMap
 }
 
+// SetKeyMap sets the key bindings.
+func (c *Completions) SetKeyMap(km KeyMap) {
+	c.keyMap = km
+}
+
 // Open opens the completions with file items from the filesystem.
 func (c *Completions) Open(depth, limit int) tea.Cmd {
 	return func() tea.Msg {
@@ -404,8 +411,17 @@ func (c *Completions) Render() string {
 	return c.list.List.Render()
 }
 
+// loadFiles lists the files of the working directory from the search index
+// when it is warm, and by walking the directory otherwise.
 func loadFiles(depth, limit int) []FileCompletionValue {
-	files, _, _ := fsext.ListDirectory(".", nil, depth, limit)
+	var files []string
+	if ix := searchindex.Lookup("."); ix != nil {
+		files, _ = ix.List(".", depth, limit)
+		slog.Debug("Loaded file completions", "source", "index")
+	} else {
+		files, _, _ = fsext.ListDirectory(".", nil, depth, limit)
+		slog.Debug("Loaded file completions", "source", "walk")
+	}
 	slices.Sort(files)
 	result := make([]FileCompletionValue, 0, len(files))
 	for _, file := range files {
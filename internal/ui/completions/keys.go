@@ -2,8 +2,17 @@ package completions
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 )
 
+// KeyMapScope is the scope of the completions key map, prefixing the names
+// of its actions in the configuration.
+const KeyMapScope = "completions"
+
+func init() {
+	keymap.Register(KeyMapScope, DefaultKeyMap)
+}
+
 // KeyMap defines the key bindings for the completions component.
 type KeyMap struct {
 	Down,
@@ -8,6 +8,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/message"
@@ -33,6 +34,8 @@ type ActionOpenDialog struct {
 // ActionSelectSession is a message indicating a session has been selected.
 type ActionSelectSession struct {
 	Session session.Session
+	// MessageID, if set, is the message to jump to in the session.
+	MessageID string
 }
 
 // ActionSelectModel is a message indicating a model has been selected.
@@ -63,10 +66,25 @@ type (
 	ActionSelectReasoningEffort struct {
 		Effort string
 	}
+	// ActionSelectTheme is a message indicating a theme has been selected.
+	ActionSelectTheme struct {
+		Name string
+	}
+	// ActionSelectAgent is a message indicating an agent has been selected
+	// as the primary agent.
+	ActionSelectAgent struct {
+		ID string
+	}
 	ActionPermissionResponse struct {
 		Permission permission.PermissionRequest
 		Action     PermissionAction
 	}
+	// ActionElicitationResponse is a message answering the elicitation of
+	// an MCP server.
+	ActionElicitationResponse struct {
+		ID       string
+		Response mcp.ElicitationResponse
+	}
 	// ActionRunCustomCommand is a message to run a custom command.
 	ActionRunCustomCommand struct {
 		Content   string
@@ -89,6 +107,18 @@ type (
 		Arguments   []commands.Argument
 		Args        map[string]string // Actual argument values
 	}
+	// ActionRestoreCheckpoint is a message to restore the working tree to
+	// a checkpoint of a session.
+	ActionRestoreCheckpoint struct {
+		SessionID string
+		Number    int
+	}
+	// ActionRewind is a message to roll the files of the current session
+	// back to the state before a message.
+	ActionRewind struct {
+		MessageID string
+		Force     bool
+	}
 	// ActionEnableDockerMCP is a message to enable Docker MCP.
 	ActionEnableDockerMCP struct{}
 	// ActionDisableDockerMCP is a message to disable Docker MCP.
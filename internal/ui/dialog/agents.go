@@ -0,0 +1,332 @@
+package dialog
+
+import (
+	"errors"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	// AgentsID is the identifier for the agent selection dialog.
+	AgentsID              = "agents"
+	agentsDialogMaxWidth  = 60
+	agentsDialogMaxHeight = 12
+)
+
+// Agents represents a dialog for selecting the primary agent.
+type Agents struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.FilterableList
+	input textinput.Model
+
+	keyMap agentsKeyMap
+}
+
+// agentsKeyMap defines the key bindings of the dialog.
+type agentsKeyMap struct {
+	Select   key.Binding
+	Next     key.Binding
+	Previous key.Binding
+	UpDown   key.Binding
+	Close    key.Binding
+}
+
+func defaultAgentsKeyMap() agentsKeyMap {
+	return agentsKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose"),
+		),
+		Close: CloseKey,
+	}
+}
+
+// AgentItem represents an agent list item.
+type AgentItem struct {
+	*list.Versioned
+	id        string
+	title     string
+	isCurrent bool
+	t         *styles.Styles
+	m         fuzzy.Match
+	cache     map[int]string
+	focused   bool
+}
+
+// Finished implements list.Item. Agent items are render-stable outside of
+// explicit SetFocused / SetMatch.
+func (r *AgentItem) Finished() bool {
+	return true
+}
+
+var (
+	_ Dialog   = (*Agents)(nil)
+	_ ListItem = (*AgentItem)(nil)
+)
+
+// NewAgents creates a new agent selection dialog.
+func NewAgents(com *common.Common) (*Agents, error) {
+	r := &Agents{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	r.help = help
+
+	r.list = list.NewFilterableList()
+	r.list.Focus()
+
+	r.input = textinput.New()
+	r.input.SetVirtualCursor(false)
+	r.input.Placeholder = "Type to filter"
+	r.input.SetStyles(com.Styles.TextInput)
+	r.input.Focus()
+
+	r.keyMap = keymap.Apply(agentsKeyMapScope, defaultAgentsKeyMap(), com.Keymap())
+
+	if err := r.setAgentItems(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ID implements Dialog.
+func (r *Agents) ID() string {
+	return AgentsID
+}
+
+// HandleMsg implements [Dialog].
+func (r *Agents) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, r.keyMap.Previous):
+			r.list.Focus()
+			if r.list.IsSelectedFirst() {
+				r.list.SelectLast()
+				r.list.ScrollToBottom()
+				break
+			}
+			r.list.SelectPrev()
+			r.list.ScrollToSelected()
+		case key.Matches(msg, r.keyMap.Next):
+			r.list.Focus()
+			if r.list.IsSelectedLast() {
+				r.list.SelectFirst()
+				r.list.ScrollToTop()
+				break
+			}
+			r.list.SelectNext()
+			r.list.ScrollToSelected()
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.list.SelectedItem()
+			if selectedItem == nil {
+				break
+			}
+			agentItem, ok := selectedItem.(*AgentItem)
+			if !ok {
+				break
+			}
+			return ActionSelectAgent{ID: agentItem.id}
+		default:
+			var cmd tea.Cmd
+			r.input, cmd = r.input.Update(msg)
+			value := r.input.Value()
+			r.list.SetFilter(value)
+			r.list.ScrollToTop()
+			r.list.SetSelected(0)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (r *Agents) Cursor() *tea.Cursor {
+	return InputCursor(r.com.Styles, r.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (r *Agents) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(agentsDialogMaxWidth, area.Dx()))
+	height := max(0, min(agentsDialogMaxHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	r.input.SetWidth(innerWidth - t.Dialog.InputPrompt.GetHorizontalFrameSize() - 1)
+	r.list.SetSize(innerWidth, height-heightOffset)
+	r.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Switch Agent"
+	inputView := t.Dialog.InputPrompt.Render(r.input.View())
+	rc.AddPart(inputView)
+
+	visibleCount := len(r.list.FilteredItems())
+	if r.list.Height() >= visibleCount {
+		r.list.ScrollToTop()
+	} else {
+		r.list.ScrollToSelected()
+	}
+
+	listView := t.Dialog.List.Height(r.list.Height()).Render(r.list.Render())
+	rc.AddPart(listView)
+	rc.Help = r.help.View(r)
+
+	view := rc.Render()
+
+	cur := r.Cursor()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (r *Agents) ShortHelp() []key.Binding {
+	return []key.Binding{
+		r.keyMap.UpDown,
+		r.keyMap.Select,
+		r.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (r *Agents) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := []key.Binding{
+		r.keyMap.Select,
+		r.keyMap.Next,
+		r.keyMap.Previous,
+		r.keyMap.Close,
+	}
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+func (r *Agents) setAgentItems() error {
+	cfg := r.com.Config()
+	currentID := cfg.PrimaryAgentID()
+
+	ids := make([]string, 0, len(cfg.Agents))
+	for id, agent := range cfg.Agents {
+		if agent.CanBePrimary() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return errors.New("no agents available")
+	}
+	slices.Sort(ids)
+
+	items := make([]list.FilterableItem, 0, len(ids))
+	selectedIndex := 0
+	for i, id := range ids {
+		items = append(items, &AgentItem{
+			Versioned: list.NewVersioned(),
+			id:        id,
+			title:     cfg.Agents[id].Name,
+			isCurrent: id == currentID,
+			t:         r.com.Styles,
+		})
+		if id == currentID {
+			selectedIndex = i
+		}
+	}
+
+	r.list.SetItems(items...)
+	r.list.SetSelected(selectedIndex)
+	r.list.ScrollToSelected()
+	return nil
+}
+
+// Filter returns the filter value for the agent item.
+func (r *AgentItem) Filter() string {
+	return r.title
+}
+
+// ID returns the unique identifier for the agent.
+func (r *AgentItem) ID() string {
+	return r.id
+}
+
+// SetFocused sets the focus state of the agent item.
+func (r *AgentItem) SetFocused(focused bool) {
+	if r.focused == focused {
+		return
+	}
+	r.cache = nil
+	r.focused = focused
+	if r.Versioned != nil {
+		r.Bump()
+	}
+}
+
+// SetMatch sets the fuzzy match for the agent item.
+func (r *AgentItem) SetMatch(m fuzzy.Match) {
+	if sameFuzzyMatch(r.m, m) {
+		return
+	}
+	r.cache = nil
+	r.m = m
+	if r.Versioned != nil {
+		r.Bump()
+	}
+}
+
+// Render returns the string representation of the agent item.
+func (r *AgentItem) Render(width int) string {
+	info := ""
+	if r.isCurrent {
+		info = "current"
+	}
+	styles := ListItemStyles{
+		ItemBlurred:     r.t.Dialog.NormalItem,
+		ItemFocused:     r.t.Dialog.SelectedItem,
+		InfoTextBlurred: r.t.Dialog.ListItem.InfoBlurred,
+		InfoTextFocused: r.t.Dialog.ListItem.InfoFocused,
+	}
+	return renderItem(styles, r.title, info, r.focused, width, r.cache, &r.m)
+}
+
+// primaryAgents returns how many agents can be used as the primary agent.
+func primaryAgents(cfg *config.Config) int {
+	var n int
+	for _, agent := range cfg.Agents {
+		if agent.CanBePrimary() {
+			n++
+		}
+	}
+	return n
+}
@@ -13,6 +13,7 @@ import (
 	"charm.land/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -43,15 +44,28 @@ type APIKeyInput struct {
 	width int
 	state APIKeyInputState
 
-	keyMap struct {
-		Submit key.Binding
-		Close  key.Binding
-	}
+	keyMap  apiKeyInputKeyMap
 	input   textinput.Model
 	spinner spinner.Model
 	help    help.Model
 }
 
+// apiKeyInputKeyMap defines the key bindings of the dialog.
+type apiKeyInputKeyMap struct {
+	Submit key.Binding
+	Close  key.Binding
+}
+
+func defaultAPIKeyInputKeyMap() apiKeyInputKeyMap {
+	return apiKeyInputKeyMap{
+		Submit: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "submit"),
+		),
+		Close: CloseKey,
+	}
+}
+
 var _ Dialog = (*APIKeyInput)(nil)
 
 // NewAPIKeyInput creates a new Models dialog.
@@ -89,11 +103,7 @@ func NewAPIKeyInput(
 	m.help = help.New()
 	m.help.Styles = t.DialogHelpStyles()
 
-	m.keyMap.Submit = key.NewBinding(
-		key.WithKeys("enter", "ctrl+y"),
-		key.WithHelp("enter", "submit"),
-	)
-	m.keyMap.Close = CloseKey
+	m.keyMap = keymap.Apply(apiKeyInputKeyMapScope, defaultAPIKeyInputKeyMap(), com.Keymap())
 
 	return &m, nil
 }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
 )
@@ -45,18 +46,39 @@ type Arguments struct {
 	resultAction Action
 
 	help   help.Model
-	keyMap struct {
-		Confirm,
-		Next,
-		Previous,
-		ScrollUp,
-		ScrollDown,
-		Close key.Binding
-	}
+	keyMap argumentsKeyMap
 
 	viewport viewport.Model
 }
 
+// argumentsKeyMap defines the key bindings of the dialog.
+type argumentsKeyMap struct {
+	Confirm,
+	Next,
+	Previous,
+	ScrollUp,
+	ScrollDown,
+	Close key.Binding
+}
+
+func defaultArgumentsKeyMap() argumentsKeyMap {
+	return argumentsKeyMap{
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "tab"),
+			key.WithHelp("↓/tab", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "shift+tab"),
+			key.WithHelp("↑/shift+tab", "previous"),
+		),
+		Close: CloseKey,
+	}
+}
+
 var _ Dialog = (*Arguments)(nil)
 
 // NewArguments creates a new arguments dialog.
@@ -72,19 +94,7 @@ func NewArguments(com *common.Common, title, description string, arguments []com
 	a.help = help.New()
 	a.help.Styles = com.Styles.DialogHelpStyles()
 
-	a.keyMap.Confirm = key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "confirm"),
-	)
-	a.keyMap.Next = key.NewBinding(
-		key.WithKeys("down", "tab"),
-		key.WithHelp("↓/tab", "next"),
-	)
-	a.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "shift+tab"),
-		key.WithHelp("↑/shift+tab", "previous"),
-	)
-	a.keyMap.Close = CloseKey
+	a.keyMap = keymap.Apply(argumentsKeyMapScope, defaultArgumentsKeyMap(), com.Keymap())
 
 	// Create input fields for each argument.
 	a.inputs = make([]textinput.Model, len(arguments))
@@ -0,0 +1,382 @@
+package dialog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// CheckpointsID is the identifier for the checkpoints dialog.
+const CheckpointsID = "checkpoints"
+
+const (
+	// checkpointsDialogMaxWidth is wider than other dialogs to fit diffs.
+	checkpointsDialogMaxWidth  = 110
+	checkpointsDialogMaxHeight = 40
+	// checkpointsListMaxHeight is the most turns listed above the diff.
+	checkpointsListMaxHeight = 8
+)
+
+type checkpointsMode uint8
+
+const (
+	checkpointsModeNormal checkpointsMode = iota
+	checkpointsModeRestoring
+)
+
+// Checkpoints is a dialog that lists the checkpoints taken at the start of
+// every turn of a session, shows the changes made during the selected turn,
+// and restores the working tree to a checkpoint.
+type Checkpoints struct {
+	com         *common.Common
+	help        help.Model
+	list        *list.FilterableList
+	input       textinput.Model
+	viewport    viewport.Model
+	sessionID   string
+	checkpoints []checkpoint.Checkpoint
+	mode        checkpointsMode
+
+	// diffs caches the rendered diff of each checkpoint by number.
+	diffs     map[int]string
+	diffWidth int
+	selected  int
+
+	keyMap checkpointsKeyMap
+}
+
+// checkpointsKeyMap defines the key bindings of the dialog.
+type checkpointsKeyMap struct {
+	Next           key.Binding
+	Previous       key.Binding
+	UpDown         key.Binding
+	ScrollDown     key.Binding
+	ScrollUp       key.Binding
+	Scroll         key.Binding
+	Restore        key.Binding
+	ConfirmRestore key.Binding
+	CancelRestore  key.Binding
+	Close          key.Binding
+}
+
+func defaultCheckpointsKeyMap() checkpointsKeyMap {
+	return checkpointsKeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑↓", "choose turn"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("shift+down", "pgdown"),
+			key.WithHelp("shift+↓", "scroll down"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("shift+up", "pgup"),
+			key.WithHelp("shift+↑", "scroll up"),
+		),
+		Scroll: key.NewBinding(
+			key.WithKeys("shift+up", "shift+down"),
+			key.WithHelp("shift+↑↓", "scroll diff"),
+		),
+		Restore: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "restore"),
+		),
+		ConfirmRestore: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "restore"),
+		),
+		CancelRestore: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n", "cancel"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*Checkpoints)(nil)
+
+// NewCheckpoints creates a new checkpoints dialog for a session.
+func NewCheckpoints(com *common.Common, sessionID string) (*Checkpoints, error) {
+	c := new(Checkpoints)
+	c.com = com
+	c.sessionID = sessionID
+	c.diffs = make(map[int]string)
+	c.selected = -1
+
+	checkpoints, err := com.Workspace.ListCheckpoints(context.TODO(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	c.checkpoints = checkpoints
+
+	h := help.New()
+	h.Styles = com.Styles.DialogHelpStyles()
+	c.help = h
+
+	c.list = list.NewFilterableList(checkpointItems(com.Styles, checkpointsModeNormal, checkpoints...)...)
+	c.list.Focus()
+	c.list.SetSelected(0)
+
+	c.input = textinput.New()
+	c.input.SetVirtualCursor(false)
+	c.input.Placeholder = "Type to filter"
+	c.input.SetStyles(com.Styles.TextInput)
+	c.input.Focus()
+
+	c.keyMap = keymap.Apply(checkpointsKeyMapScope, defaultCheckpointsKeyMap(), com.Keymap())
+
+	c.viewport = viewport.New()
+	c.viewport.KeyMap = viewport.KeyMap{
+		Up:   key.NewBinding(key.WithKeys("shift+up")),
+		Down: key.NewBinding(key.WithKeys("shift+down")),
+		// Disable other viewport keys to avoid conflicts with the filter.
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+		PageUp:       key.NewBinding(key.WithKeys("pgup")),
+		PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+	}
+
+	return c, nil
+}
+
+// ID implements Dialog.
+func (c *Checkpoints) ID() string {
+	return CheckpointsID
+}
+
+// HandleMsg implements Dialog.
+func (c *Checkpoints) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch c.mode {
+		case checkpointsModeRestoring:
+			switch {
+			case key.Matches(msg, c.keyMap.ConfirmRestore):
+				c.mode = checkpointsModeNormal
+				if item := c.selectedItem(); item != nil {
+					return ActionRestoreCheckpoint{SessionID: c.sessionID, Number: item.Number}
+				}
+			case key.Matches(msg, c.keyMap.CancelRestore):
+				c.setMode(checkpointsModeNormal)
+			}
+		default:
+			switch {
+			case key.Matches(msg, c.keyMap.Close):
+				return ActionClose{}
+			case key.Matches(msg, c.keyMap.Restore):
+				if c.selectedItem() == nil {
+					return nil
+				}
+				c.setMode(checkpointsModeRestoring)
+			case key.Matches(msg, c.keyMap.ScrollUp), key.Matches(msg, c.keyMap.ScrollDown):
+				c.viewport, _ = c.viewport.Update(msg)
+			case key.Matches(msg, c.keyMap.Previous):
+				c.list.Focus()
+				if c.list.IsSelectedFirst() {
+					c.list.SelectLast()
+				} else {
+					c.list.SelectPrev()
+				}
+				c.list.ScrollToSelected()
+			case key.Matches(msg, c.keyMap.Next):
+				c.list.Focus()
+				if c.list.IsSelectedLast() {
+					c.list.SelectFirst()
+				} else {
+					c.list.SelectNext()
+				}
+				c.list.ScrollToSelected()
+			default:
+				var cmd tea.Cmd
+				c.input, cmd = c.input.Update(msg)
+				c.list.SetFilter(c.input.Value())
+				c.list.ScrollToTop()
+				c.list.SetSelected(0)
+				return ActionCmd{cmd}
+			}
+		}
+	case tea.MouseWheelMsg:
+		c.viewport, _ = c.viewport.Update(msg)
+	}
+	return nil
+}
+
+func (c *Checkpoints) setMode(mode checkpointsMode) {
+	c.mode = mode
+	selected := c.list.Selected()
+	c.list.SetItems(checkpointItems(c.com.Styles, mode, c.checkpoints...)...)
+	c.list.SetFilter(c.input.Value())
+	c.list.SetSelected(selected)
+	c.list.ScrollToSelected()
+}
+
+func (c *Checkpoints) selectedItem() *CheckpointItem {
+	item, _ := c.list.SelectedItem().(*CheckpointItem)
+	return item
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (c *Checkpoints) Cursor() *tea.Cursor {
+	return InputCursor(c.com.Styles, c.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (c *Checkpoints) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(checkpointsDialogMaxWidth, area.Dx()-t.Dialog.View.GetHorizontalBorderSize()))
+	height := max(0, min(checkpointsDialogMaxHeight, area.Dy()-t.Dialog.View.GetVerticalBorderSize()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	listHeight := min(checkpointsListMaxHeight, max(1, len(c.list.FilteredItems())))
+	// Leave a blank line between the list and the diff.
+	diffHeight := max(0, height-heightOffset-listHeight-1)
+
+	c.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1))
+	c.list.SetSize(innerWidth, listHeight)
+	c.help.SetWidth(innerWidth)
+
+	var cur *tea.Cursor
+	rc := NewRenderContext(t, width)
+	rc.Title = "Checkpoints"
+
+	switch c.mode {
+	case checkpointsModeRestoring:
+		rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
+		rc.TitleGradientFromColor = t.Dialog.Sessions.DeletingTitleGradientFromColor
+		rc.TitleGradientToColor = t.Dialog.Sessions.DeletingTitleGradientToColor
+		rc.ViewStyle = t.Dialog.Sessions.DeletingView
+		rc.AddPart(t.Dialog.Sessions.DeletingMessage.Render("Restore the working tree to the start of this turn?"))
+	default:
+		inputView := t.Dialog.InputPrompt.Render(c.input.View())
+		cur = c.Cursor()
+		rc.AddPart(inputView)
+	}
+
+	if len(c.checkpoints) == 0 {
+		rc.AddPart(t.Dialog.List.Render(t.Files.EmptyMessage.Render("No checkpoints yet. One is taken at the start of every turn.")))
+	} else {
+		listView := t.Dialog.List.Height(c.list.Height()).Render(c.list.Render())
+		rc.AddPart(listView)
+		rc.AddPart("\n" + c.diffView(innerWidth, diffHeight))
+	}
+	rc.Help = c.help.View(c)
+
+	view := rc.Render()
+
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// diffView renders the changes made during the selected turn in a
+// scrollable viewport.
+func (c *Checkpoints) diffView(width, height int) string {
+	t := c.com.Styles
+	// Reserve a column for the scrollbar.
+	c.viewport.SetWidth(max(0, width-1))
+	c.viewport.SetHeight(height)
+
+	item := c.selectedItem()
+	number := -1
+	if item != nil {
+		number = item.Number
+	}
+	if number != c.selected || width != c.diffWidth {
+		c.selected = number
+		c.diffWidth = width
+		c.viewport.SetContent(c.renderDiff(number))
+		c.viewport.GotoTop()
+	}
+
+	content := c.viewport.View()
+	if bar := common.Scrollbar(t, height, c.viewport.TotalLineCount(), height, c.viewport.YOffset()); bar != "" {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, content, bar)
+	}
+	return content
+}
+
+func (c *Checkpoints) renderDiff(number int) string {
+	t := c.com.Styles
+	if number < 0 {
+		return ""
+	}
+	if diff, ok := c.diffs[number]; ok {
+		return diff
+	}
+	diff, err := c.com.Workspace.CheckpointDiff(context.TODO(), c.sessionID, number, false)
+	switch {
+	case err != nil:
+		diff = t.Tool.ErrorMessage.Render("Failed to diff checkpoint " + strconv.Itoa(number) + ": " + err.Error())
+	case strings.TrimSpace(diff) == "":
+		diff = t.Files.EmptyMessage.Render("No changes during this turn.")
+	default:
+		diff, _ = common.SyntaxHighlight(t, strings.TrimRight(diff, "\n"), "checkpoint.diff", t.Tool.ContentCodeBg)
+	}
+	c.diffs[number] = diff
+	return diff
+}
+
+// ShortHelp implements [help.KeyMap].
+func (c *Checkpoints) ShortHelp() []key.Binding {
+	switch c.mode {
+	case checkpointsModeRestoring:
+		return []key.Binding{
+			c.keyMap.ConfirmRestore,
+			c.keyMap.CancelRestore,
+		}
+	default:
+		return []key.Binding{
+			c.keyMap.UpDown,
+			c.keyMap.Scroll,
+			c.keyMap.Restore,
+			c.keyMap.Close,
+		}
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (c *Checkpoints) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := []key.Binding{
+		c.keyMap.UpDown,
+		c.keyMap.Scroll,
+		c.keyMap.Restore,
+		c.keyMap.Close,
+	}
+	switch c.mode {
+	case checkpointsModeRestoring:
+		slice = []key.Binding{
+			c.keyMap.ConfirmRestore,
+			c.keyMap.CancelRestore,
+		}
+	}
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
@@ -0,0 +1,94 @@
+package dialog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// CheckpointItem wraps a [checkpoint.Checkpoint] to implement the
+// [ListItem] interface.
+type CheckpointItem struct {
+	*list.Versioned
+	checkpoint.Checkpoint
+	t       *styles.Styles
+	mode    checkpointsMode
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var _ ListItem = &CheckpointItem{}
+
+// Filter returns the filterable value of the checkpoint.
+func (c *CheckpointItem) Filter() string {
+	return c.title()
+}
+
+func (c *CheckpointItem) Finished() bool { return true }
+
+// ID returns the number of the checkpoint.
+func (c *CheckpointItem) ID() string {
+	return strconv.Itoa(c.Number)
+}
+
+// SetMatch sets the fuzzy match for the checkpoint item.
+func (c *CheckpointItem) SetMatch(m fuzzy.Match) {
+	c.cache = nil
+	c.m = m
+}
+
+// SetFocused sets the focus state of the checkpoint item.
+func (c *CheckpointItem) SetFocused(focused bool) {
+	if c.focused == focused {
+		return
+	}
+	c.cache = nil
+	c.focused = focused
+	if c.Versioned != nil {
+		c.Bump()
+	}
+}
+
+// title is the turn number followed by the first line of the prompt that
+// started the turn.
+func (c *CheckpointItem) title() string {
+	prompt, _, _ := strings.Cut(c.Prompt, "\n")
+	if prompt == "" {
+		return fmt.Sprintf("#%d", c.Number)
+	}
+	return fmt.Sprintf("#%d %s", c.Number, prompt)
+}
+
+// Render returns the string representation of the checkpoint item.
+func (c *CheckpointItem) Render(width int) string {
+	sty := ListItemStyles{
+		ItemBlurred:     c.t.Dialog.NormalItem,
+		ItemFocused:     c.t.Dialog.SelectedItem,
+		InfoTextBlurred: c.t.Dialog.Sessions.InfoBlurred,
+		InfoTextFocused: c.t.Dialog.Sessions.InfoFocused,
+	}
+	if c.mode == checkpointsModeRestoring {
+		sty.ItemBlurred = c.t.Dialog.Sessions.DeletingItemBlurred
+		sty.ItemFocused = c.t.Dialog.Sessions.DeletingItemFocused
+	}
+	info := humanize.Time(time.Unix(c.CreatedAt, 0))
+	return renderItem(sty, c.title(), info, c.focused, width, c.cache, &c.m)
+}
+
+// checkpointItems converts a slice of [checkpoint.Checkpoint]s to a slice
+// of [list.FilterableItem]s, latest first.
+func checkpointItems(t *styles.Styles, mode checkpointsMode, checkpoints ...checkpoint.Checkpoint) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(checkpoints))
+	for i, cp := range checkpoints {
+		items[len(checkpoints)-1-i] = &CheckpointItem{Versioned: list.NewVersioned(), Checkpoint: cp, t: t, mode: mode}
+	}
+	return items
+}
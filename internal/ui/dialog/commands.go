@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -43,15 +44,7 @@ type dockerMCPAvailabilityCheckedMsg struct {
 
 type Commands struct {
 	com    *common.Common
-	keyMap struct {
-		Select,
-		UpDown,
-		Next,
-		Previous,
-		Tab,
-		ShiftTab,
-		Close key.Binding
-	}
+	keyMap commandsKeyMap
 
 	sessionID  string
 	hasSession bool
@@ -75,6 +68,49 @@ type Commands struct {
 	dockerMCPCheckInFlight bool
 }
 
+// commandsKeyMap defines the key bindings of the dialog.
+type commandsKeyMap struct {
+	Select,
+	UpDown,
+	Next,
+	Previous,
+	Tab,
+	ShiftTab,
+	Close key.Binding
+}
+
+func defaultCommandsKeyMap() commandsKeyMap {
+	closeKey := CloseKey
+	closeKey.SetHelp("esc", "cancel")
+	return commandsKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		Tab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch selection"),
+		),
+		ShiftTab: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "switch selection prev"),
+		),
+		Close: closeKey,
+	}
+}
+
 var _ Dialog = (*Commands)(nil)
 
 // NewCommands creates a new commands dialog.
@@ -105,33 +141,7 @@ func NewCommands(com *common.Common, sessionID string, hasSession, hasTodos, has
 	c.input.SetStyles(com.Styles.TextInput)
 	c.input.Focus()
 
-	c.keyMap.Select = key.NewBinding(
-		key.WithKeys("enter", "ctrl+y"),
-		key.WithHelp("enter", "confirm"),
-	)
-	c.keyMap.UpDown = key.NewBinding(
-		key.WithKeys("up", "down"),
-		key.WithHelp("↑/↓", "choose"),
-	)
-	c.keyMap.Next = key.NewBinding(
-		key.WithKeys("down"),
-		key.WithHelp("↓", "next item"),
-	)
-	c.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "ctrl+p"),
-		key.WithHelp("↑", "previous item"),
-	)
-	c.keyMap.Tab = key.NewBinding(
-		key.WithKeys("tab"),
-		key.WithHelp("tab", "switch selection"),
-	)
-	c.keyMap.ShiftTab = key.NewBinding(
-		key.WithKeys("shift+tab"),
-		key.WithHelp("shift+tab", "switch selection prev"),
-	)
-	closeKey := CloseKey
-	closeKey.SetHelp("esc", "cancel")
-	c.keyMap.Close = closeKey
+	c.keyMap = keymap.Apply(commandsKeyMapScope, defaultCommandsKeyMap(), com.Keymap())
 
 	if available, known := config.DockerMCPAvailabilityCached(); known {
 		c.dockerMCPAvailable = &available
@@ -437,11 +447,12 @@ func (c *Commands) defaultCommands() []*CommandItem {
 	// Only show compact command if there's an active session
 	if c.hasSession {
 		commands = append(commands, NewCommandItem(c.com.Styles, "summarize", "Summarize Session", "", ActionSummarize{SessionID: c.sessionID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "checkpoints", "Checkpoints", "", ActionOpenDialog{CheckpointsID}))
 	}
 
 	// Add reasoning toggle for models that support it
 	cfg := c.com.Config()
-	if agentCfg, ok := cfg.Agents[config.AgentCoder]; ok {
+	if agentCfg, ok := cfg.PrimaryAgent(); ok {
 		providerCfg := cfg.GetProviderForModel(agentCfg.Model)
 		model := cfg.GetModelByType(agentCfg.Model)
 		if providerCfg != nil && model != nil && model.CanReason {
@@ -464,13 +475,19 @@ func (c *Commands) defaultCommands() []*CommandItem {
 			}
 		}
 	}
+	// Only offer switching agents when there's more than one to pick from.
+	if primaryAgents(cfg) > 1 {
+		commands = append(commands, NewCommandItem(c.com.Styles, "switch_agent", "Switch Agent", "", ActionOpenDialog{
+			DialogID: AgentsID,
+		}))
+	}
 	// Only show toggle compact mode command if window width is larger than compact breakpoint (120)
 	if c.windowWidth >= sidebarCompactModeBreakpoint && c.hasSession {
 		commands = append(commands, NewCommandItem(c.com.Styles, "toggle_sidebar", "Toggle Sidebar", "", ActionToggleCompactMode{}))
 	}
 	if c.hasSession {
 		cfgPrime := c.com.Config()
-		agentCfg := cfgPrime.Agents[config.AgentCoder]
+		agentCfg, _ := cfgPrime.PrimaryAgent()
 		model := cfgPrime.GetModelByType(agentCfg.Model)
 		if model != nil && model.SupportsImages {
 			commands = append(commands, NewCommandItem(c.com.Styles, "file_picker", "Open File Picker", "ctrl+f", ActionOpenDialog{
@@ -534,6 +551,7 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		transparentLabel = "Enable Background Color"
 	}
 	commands = append(commands, NewCommandItem(c.com.Styles, "toggle_transparent", transparentLabel, "", ActionToggleTransparentBackground{}))
+	commands = append(commands, NewCommandItem(c.com.Styles, "switch_theme", "Switch Theme", "", ActionOpenDialog{ThemesID}).WithAliases("theme"))
 
 	commands = append(
 		commands,
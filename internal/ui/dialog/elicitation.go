@@ -0,0 +1,383 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// ElicitationID is the identifier for the MCP elicitation dialog.
+const ElicitationID = "elicitation"
+
+// Elicitation is a dialog for filling in the form an MCP server asks for.
+// Fields limited to options are chosen with left and right, the others are
+// typed in.
+type Elicitation struct {
+	com         *common.Common
+	elicitation mcp.Elicitation
+	inputs      []textinput.Model
+	// choices holds the index of the chosen option of each field with
+	// options, or -1 when none is chosen.
+	choices []int
+	focused int
+
+	help   help.Model
+	keyMap elicitationKeyMap
+
+	viewport viewport.Model
+}
+
+// elicitationKeyMap defines the key bindings of the dialog.
+type elicitationKeyMap struct {
+	Confirm,
+	Next,
+	Previous,
+	NextOption,
+	PreviousOption,
+	Decline,
+	Close key.Binding
+}
+
+func defaultElicitationKeyMap() elicitationKeyMap {
+	return elicitationKeyMap{
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "tab"),
+			key.WithHelp("↓/tab", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "shift+tab"),
+			key.WithHelp("↑/shift+tab", "previous"),
+		),
+		NextOption: key.NewBinding(
+			key.WithKeys("right"),
+			key.WithHelp("→", "next option"),
+		),
+		PreviousOption: key.NewBinding(
+			key.WithKeys("left"),
+			key.WithHelp("←", "previous option"),
+		),
+		Decline: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "decline"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*Elicitation)(nil)
+
+// NewElicitation creates a new dialog for the elicitation e.
+func NewElicitation(com *common.Common, e mcp.Elicitation) *Elicitation {
+	d := &Elicitation{
+		com:         com,
+		elicitation: e,
+	}
+
+	d.help = help.New()
+	d.help.Styles = com.Styles.DialogHelpStyles()
+
+	d.keyMap = keymap.Apply(elicitationKeyMapScope, defaultElicitationKeyMap(), com.Keymap())
+
+	d.inputs = make([]textinput.Model, len(e.Fields))
+	d.choices = make([]int, len(e.Fields))
+	for i, f := range e.Fields {
+		input := textinput.New()
+		input.SetVirtualCursor(false)
+		input.SetStyles(com.Styles.TextInput)
+		input.Prompt = "> "
+		input.Placeholder = f.Description
+		d.choices[i] = -1
+		if len(f.Options) > 0 {
+			for j, o := range f.Options {
+				if o == f.Default {
+					d.choices[i] = j
+				}
+			}
+			if d.choices[i] < 0 && f.Required {
+				d.choices[i] = 0
+			}
+		} else {
+			input.SetValue(f.Default)
+		}
+		if i == 0 {
+			input.Focus()
+		}
+		d.inputs[i] = input
+	}
+
+	return d
+}
+
+// ID implements Dialog.
+func (d *Elicitation) ID() string {
+	return ElicitationID
+}
+
+// ElicitationID returns the ID of the elicitation the dialog is for.
+func (d *Elicitation) ElicitationID() string {
+	return d.elicitation.ID
+}
+
+// focusInput changes focus to a new input by index with wrap-around.
+func (d *Elicitation) focusInput(newIndex int) {
+	if len(d.inputs) == 0 {
+		return
+	}
+	d.inputs[d.focused].Blur()
+
+	n := len(d.inputs)
+	d.focused = ((newIndex % n) + n) % n
+
+	d.inputs[d.focused].Focus()
+	d.ensureFieldVisible(d.focused)
+}
+
+// ensureFieldVisible scrolls the viewport to make the field visible.
+func (d *Elicitation) ensureFieldVisible(fieldIndex int) {
+	fieldStart := fieldIndex * argumentsFieldHeight
+	fieldEnd := fieldStart + argumentsFieldHeight - 1
+	viewportTop := d.viewport.YOffset()
+	viewportHeight := d.viewport.Height()
+
+	if fieldStart < viewportTop {
+		d.viewport.SetYOffset(fieldStart)
+		return
+	}
+	if fieldEnd > viewportTop+viewportHeight-1 {
+		d.viewport.SetYOffset(fieldEnd - viewportHeight + 1)
+	}
+}
+
+// hasOptions reports whether the field i is limited to options.
+func (d *Elicitation) hasOptions(i int) bool {
+	return len(d.elicitation.Fields[i].Options) > 0
+}
+
+// choose moves the choice of the field i by delta, through no choice for
+// optional fields.
+func (d *Elicitation) choose(i, delta int) {
+	f := d.elicitation.Fields[i]
+	first := -1
+	if f.Required {
+		first = 0
+	}
+	n := len(f.Options) - first
+	d.choices[i] = ((d.choices[i]-first+delta)%n+n)%n + first
+}
+
+// respond returns the action answering the elicitation.
+func (d *Elicitation) respond(action string, content map[string]any) Action {
+	return ActionElicitationResponse{
+		ID: d.elicitation.ID,
+		Response: mcp.ElicitationResponse{
+			Action:  action,
+			Content: content,
+		},
+	}
+}
+
+// accept returns the action accepting the elicitation with the values of
+// the fields, or a warning when they aren't valid.
+func (d *Elicitation) accept() Action {
+	texts := make([]string, len(d.inputs))
+	for i, f := range d.elicitation.Fields {
+		switch {
+		case !d.hasOptions(i):
+			texts[i] = d.inputs[i].Value()
+		case d.choices[i] >= 0:
+			texts[i] = f.Options[d.choices[i]]
+		}
+	}
+	content, err := mcp.ElicitationContent(d.elicitation.Fields, texts)
+	if err != nil {
+		return ActionCmd{Cmd: util.ReportWarn(err.Error())}
+	}
+	return d.respond(mcp.ElicitationAccept, content)
+}
+
+// HandleMsg implements Dialog.
+func (d *Elicitation) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return d.respond(mcp.ElicitationCancel, nil)
+		case key.Matches(msg, d.keyMap.Decline):
+			return d.respond(mcp.ElicitationDecline, nil)
+		case key.Matches(msg, d.keyMap.Confirm):
+			if d.focused >= len(d.inputs)-1 {
+				return d.accept()
+			}
+			d.focusInput(d.focused + 1)
+		case key.Matches(msg, d.keyMap.Next):
+			d.focusInput(d.focused + 1)
+		case key.Matches(msg, d.keyMap.Previous):
+			d.focusInput(d.focused - 1)
+		case len(d.inputs) == 0:
+		case d.hasOptions(d.focused):
+			switch {
+			case key.Matches(msg, d.keyMap.NextOption):
+				d.choose(d.focused, 1)
+			case key.Matches(msg, d.keyMap.PreviousOption):
+				d.choose(d.focused, -1)
+			}
+		default:
+			var cmd tea.Cmd
+			d.inputs[d.focused], cmd = d.inputs[d.focused].Update(msg)
+			return ActionCmd{Cmd: cmd}
+		}
+	case tea.MouseWheelMsg:
+		d.viewport, _ = d.viewport.Update(msg)
+	case tea.PasteMsg:
+		if len(d.inputs) == 0 || d.hasOptions(d.focused) {
+			return nil
+		}
+		var cmd tea.Cmd
+		d.inputs[d.focused], cmd = d.inputs[d.focused].Update(msg)
+		return ActionCmd{Cmd: cmd}
+	}
+	return nil
+}
+
+// Cursor returns the cursor position relative to the dialog, offset by the
+// height of the message.
+func (d *Elicitation) Cursor(messageHeight int) *tea.Cursor {
+	if len(d.inputs) == 0 || d.hasOptions(d.focused) {
+		return nil
+	}
+	cursor := InputCursor(d.com.Styles, d.inputs[d.focused].Cursor())
+	if cursor == nil {
+		return nil
+	}
+	cursor.Y += messageHeight + d.focused*argumentsFieldHeight - d.viewport.YOffset() + 1
+	return cursor
+}
+
+// Draw implements Dialog.
+func (d *Elicitation) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	s := d.com.Styles
+
+	contentStyle := s.Dialog.Arguments.Content
+	possibleWidth := area.Dx() - s.Dialog.View.GetHorizontalFrameSize() - contentStyle.GetHorizontalFrameSize()
+	inputWidth := min(possibleWidth, maxInputWidth)
+
+	fields := make([]string, 0, len(d.inputs))
+	for i, f := range d.elicitation.Fields {
+		labelStyle := s.Dialog.Arguments.InputLabelBlurred
+		markRequiredStyle := s.Dialog.Arguments.InputRequiredMarkBlurred
+		if i == d.focused {
+			labelStyle = s.Dialog.Arguments.InputLabelFocused
+			markRequiredStyle = s.Dialog.Arguments.InputRequiredMarkFocused
+		}
+		labelText := f.Name
+		if f.Title != "" {
+			labelText = f.Title
+		}
+		if f.Required {
+			labelText += markRequiredStyle.String()
+		}
+
+		var inputLine string
+		if d.hasOptions(i) {
+			choice := "none"
+			if d.choices[i] >= 0 {
+				choice = f.OptionTitles[d.choices[i]]
+			}
+			inputLine = fmt.Sprintf("> ‹ %s ›", choice)
+			if f.Description != "" {
+				inputLine += "  " + s.Dialog.Arguments.InputLabelBlurred.Render(f.Description)
+			}
+			inputLine = lipgloss.NewStyle().MaxWidth(inputWidth).Render(inputLine)
+		} else {
+			d.inputs[i].SetWidth(max(minInputWidth, min(inputWidth, lipgloss.Width(d.inputs[i].Placeholder))))
+			inputLine = d.inputs[i].View()
+		}
+
+		fields = append(fields, lipgloss.JoinVertical(lipgloss.Left, labelStyle.Render(labelText), inputLine, ""))
+	}
+
+	renderedFields := lipgloss.JoinVertical(lipgloss.Left, fields...)
+	width := max(lipgloss.Width(renderedFields), minInputWidth)
+	height := lipgloss.Height(renderedFields)
+
+	title := fmt.Sprintf("%s asks", d.elicitation.Name)
+	header := common.DialogTitle(s, title, width, s.Dialog.TitleGradFromColor, s.Dialog.TitleGradToColor)
+
+	var message string
+	if m := strings.TrimSpace(d.elicitation.Message); m != "" {
+		message = s.Dialog.Arguments.Description.Width(width).Render(m)
+	}
+
+	helpView := s.Dialog.HelpView.Width(width).Render(d.help.View(d))
+
+	availableHeight := area.Dy() - s.Dialog.View.GetVerticalFrameSize() - contentStyle.GetVerticalFrameSize() - lipgloss.Height(header) - lipgloss.Height(message) - lipgloss.Height(helpView) - 2
+	viewportHeight := min(height, maxViewportHeight, availableHeight)
+
+	d.viewport.SetWidth(width)
+	d.viewport.SetHeight(viewportHeight)
+	d.viewport.SetContent(renderedFields)
+
+	var contentParts []string
+	if message != "" {
+		contentParts = append(contentParts, message)
+	}
+	if len(fields) > 0 {
+		content := d.viewport.View()
+		scrollbar := common.Scrollbar(s, viewportHeight, d.viewport.TotalLineCount(), viewportHeight, d.viewport.YOffset())
+		if scrollbar != "" {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, content, scrollbar)
+		}
+		contentParts = append(contentParts, content)
+	}
+
+	view := lipgloss.JoinVertical(
+		lipgloss.Left,
+		s.Dialog.Title.Render(header),
+		contentStyle.Render(lipgloss.JoinVertical(lipgloss.Left, contentParts...)),
+		helpView,
+	)
+
+	messageHeight := 0
+	if message != "" {
+		messageHeight = lipgloss.Height(message)
+	}
+	cur := d.Cursor(messageHeight)
+
+	DrawCenterCursor(scr, area, s.Dialog.View.Render(view), cur)
+	return cur
+}
+
+// ShortHelp implements help.KeyMap.
+func (d *Elicitation) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.keyMap.Confirm,
+		d.keyMap.Next,
+		d.keyMap.Decline,
+		d.keyMap.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (d *Elicitation) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{d.keyMap.Confirm, d.keyMap.Next, d.keyMap.Previous},
+		{d.keyMap.NextOption, d.keyMap.PreviousOption},
+		{d.keyMap.Decline, d.keyMap.Close},
+	}
+}
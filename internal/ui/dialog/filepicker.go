@@ -17,6 +17,7 @@ import (
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	fimage "github.com/charmbracelet/crush/internal/ui/image"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	uv "github.com/charmbracelet/ultraviolet"
 )
 
@@ -36,14 +37,50 @@ type FilePicker struct {
 	previewingImage bool // indicates if an image is being previewed
 	isTmux          bool
 
-	km struct {
-		Select,
-		Down,
-		Up,
-		Forward,
-		Backward,
-		Navigate,
-		Close key.Binding
+	km filePickerKeyMap
+}
+
+// filePickerKeyMap defines the key bindings of the dialog.
+type filePickerKeyMap struct {
+	Select,
+	Down,
+	Up,
+	Forward,
+	Backward,
+	Navigate,
+	Close key.Binding
+}
+
+func defaultFilePickerKeyMap() filePickerKeyMap {
+	return filePickerKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "accept"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("down/j", "move down"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("up/k", "move up"),
+		),
+		Forward: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("right/l", "move forward"),
+		),
+		Backward: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("left/h", "move backward"),
+		),
+		Navigate: key.NewBinding(
+			key.WithKeys("right", "l", "left", "h", "up", "k", "down", "j"),
+			key.WithHelp("↑↓←→", "navigate"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "close/exit"),
+		),
 	}
 }
 
@@ -67,34 +104,7 @@ func NewFilePicker(com *common.Common) (*FilePicker, tea.Cmd) {
 
 	f.help = help
 
-	f.km.Select = key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "accept"),
-	)
-	f.km.Down = key.NewBinding(
-		key.WithKeys("down", "j"),
-		key.WithHelp("down/j", "move down"),
-	)
-	f.km.Up = key.NewBinding(
-		key.WithKeys("up", "k"),
-		key.WithHelp("up/k", "move up"),
-	)
-	f.km.Forward = key.NewBinding(
-		key.WithKeys("right", "l"),
-		key.WithHelp("right/l", "move forward"),
-	)
-	f.km.Backward = key.NewBinding(
-		key.WithKeys("left", "h"),
-		key.WithHelp("left/h", "move backward"),
-	)
-	f.km.Navigate = key.NewBinding(
-		key.WithKeys("right", "l", "left", "h", "up", "k", "down", "j"),
-		key.WithHelp("↑↓←→", "navigate"),
-	)
-	f.km.Close = key.NewBinding(
-		key.WithKeys("esc", "alt+esc"),
-		key.WithHelp("esc", "close/exit"),
-	)
+	f.km = keymap.Apply(filePickerKeyMapScope, defaultFilePickerKeyMap(), com.Keymap())
 
 	fp := filepicker.New()
 	fp.AllowedTypes = common.AllowedImageTypes
@@ -0,0 +1,43 @@
+package dialog
+
+import "github.com/charmbracelet/crush/internal/ui/keymap"
+
+// The scopes of the key maps of the dialogs, prefixing the names of their
+// actions in the configuration, as in "dialog.sessions.delete".
+const (
+	agentsKeyMapScope          = "dialog.agents"
+	apiKeyInputKeyMapScope     = "dialog.api_key_input"
+	argumentsKeyMapScope       = "dialog.arguments"
+	checkpointsKeyMapScope     = "dialog.checkpoints"
+	commandsKeyMapScope        = "dialog.commands"
+	elicitationKeyMapScope     = "dialog.elicitation"
+	filePickerKeyMapScope      = "dialog.file_picker"
+	modelsKeyMapScope          = "dialog.models"
+	oauthKeyMapScope           = "dialog.oauth"
+	permissionRulesKeyMapScope = "dialog.permission_rules"
+	permissionsKeyMapScope     = "dialog.permissions"
+	quitKeyMapScope            = "dialog.quit"
+	reasoningKeyMapScope       = "dialog.reasoning"
+	rewindKeyMapScope          = "dialog.rewind"
+	sessionsKeyMapScope        = "dialog.sessions"
+	themesKeyMapScope          = "dialog.themes"
+)
+
+func init() {
+	keymap.Register(agentsKeyMapScope, defaultAgentsKeyMap)
+	keymap.Register(apiKeyInputKeyMapScope, defaultAPIKeyInputKeyMap)
+	keymap.Register(argumentsKeyMapScope, defaultArgumentsKeyMap)
+	keymap.Register(checkpointsKeyMapScope, defaultCheckpointsKeyMap)
+	keymap.Register(commandsKeyMapScope, defaultCommandsKeyMap)
+	keymap.Register(elicitationKeyMapScope, defaultElicitationKeyMap)
+	keymap.Register(filePickerKeyMapScope, defaultFilePickerKeyMap)
+	keymap.Register(modelsKeyMapScope, defaultModelsKeyMap)
+	keymap.Register(oauthKeyMapScope, defaultOAuthKeyMap)
+	keymap.Register(permissionRulesKeyMapScope, defaultPermissionRulesKeyMap)
+	keymap.Register(permissionsKeyMapScope, defaultPermissionsKeyMap)
+	keymap.Register(quitKeyMapScope, defaultQuitKeyMap)
+	keymap.Register(reasoningKeyMapScope, defaultReasoningKeyMap)
+	keymap.Register(rewindKeyMapScope, defaultRewindKeyMap)
+	keymap.Register(sessionsKeyMapScope, defaultSessionsKeyMap)
+	keymap.Register(themesKeyMapScope, defaultThemesKeyMap)
+}
@@ -13,6 +13,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
 )
@@ -80,18 +81,51 @@ type Models struct {
 	modelType ModelType
 	providers []catwalk.Provider
 
-	keyMap struct {
-		Tab      key.Binding
-		UpDown   key.Binding
-		Select   key.Binding
-		Edit     key.Binding
-		Next     key.Binding
-		Previous key.Binding
-		Close    key.Binding
+	keyMap modelsKeyMap
+	list   *ModelsList
+	input  textinput.Model
+	help   help.Model
+}
+
+// modelsKeyMap defines the key bindings of the dialog.
+type modelsKeyMap struct {
+	Tab      key.Binding
+	UpDown   key.Binding
+	Select   key.Binding
+	Edit     key.Binding
+	Next     key.Binding
+	Previous key.Binding
+	Close    key.Binding
+}
+
+func defaultModelsKeyMap() modelsKeyMap {
+	return modelsKeyMap{
+		Tab: key.NewBinding(
+			key.WithKeys("tab", "shift+tab"),
+			key.WithHelp("tab", "toggle type"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+e", "edit"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		Close: CloseKey,
 	}
-	list  *ModelsList
-	input textinput.Model
-	help  help.Model
 }
 
 var _ Dialog = (*Models)(nil)
@@ -117,31 +151,7 @@ func NewModels(com *common.Common, isOnboarding bool) (*Models, error) {
 	m.input.SetStyles(com.Styles.TextInput)
 	m.input.Focus()
 
-	m.keyMap.Tab = key.NewBinding(
-		key.WithKeys("tab", "shift+tab"),
-		key.WithHelp("tab", "toggle type"),
-	)
-	m.keyMap.Select = key.NewBinding(
-		key.WithKeys("enter", "ctrl+y"),
-		key.WithHelp("enter", "confirm"),
-	)
-	m.keyMap.Edit = key.NewBinding(
-		key.WithKeys("ctrl+e"),
-		key.WithHelp("ctrl+e", "edit"),
-	)
-	m.keyMap.UpDown = key.NewBinding(
-		key.WithKeys("up", "down"),
-		key.WithHelp("↑/↓", "choose"),
-	)
-	m.keyMap.Next = key.NewBinding(
-		key.WithKeys("down", "ctrl+n"),
-		key.WithHelp("↓", "next item"),
-	)
-	m.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "ctrl+p"),
-		key.WithHelp("↑", "previous item"),
-	)
-	m.keyMap.Close = CloseKey
+	m.keyMap = keymap.Apply(modelsKeyMapScope, defaultModelsKeyMap(), com.Keymap())
 
 	var err error
 	m.providers, err = config.Providers(m.com.Config())
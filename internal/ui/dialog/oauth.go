@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
 	"github.com/pkg/browser"
@@ -53,11 +54,7 @@ type OAuth struct {
 
 	spinner spinner.Model
 	help    help.Model
-	keyMap  struct {
-		Copy   key.Binding
-		Submit key.Binding
-		Close  key.Binding
-	}
+	keyMap  oauthKeyMap
 
 	width           int
 	deviceCode      string
@@ -69,6 +66,27 @@ type OAuth struct {
 	cancelFunc      context.CancelFunc
 }
 
+// oauthKeyMap defines the key bindings of the dialog.
+type oauthKeyMap struct {
+	Copy   key.Binding
+	Submit key.Binding
+	Close  key.Binding
+}
+
+func defaultOAuthKeyMap() oauthKeyMap {
+	return oauthKeyMap{
+		Copy: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy code"),
+		),
+		Submit: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "copy & open"),
+		),
+		Close: CloseKey,
+	}
+}
+
 var _ Dialog = (*OAuth)(nil)
 
 // newOAuth creates a new device flow component.
@@ -100,15 +118,7 @@ func newOAuth(
 	m.help = help.New()
 	m.help.Styles = t.DialogHelpStyles()
 
-	m.keyMap.Copy = key.NewBinding(
-		key.WithKeys("c"),
-		key.WithHelp("c", "copy code"),
-	)
-	m.keyMap.Submit = key.NewBinding(
-		key.WithKeys("enter", "ctrl+y"),
-		key.WithHelp("enter", "copy & open"),
-	)
-	m.keyMap.Close = CloseKey
+	m.keyMap = keymap.Apply(oauthKeyMapScope, defaultOAuthKeyMap(), com.Keymap())
 
 	return &m, tea.Batch(m.spinner.Tick, m.oAuthProvider.initiateAuth)
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -36,15 +37,52 @@ type PermissionRules struct {
 	sessionPerms []permission.PermissionRequest
 	mode         permissionRulesMode
 
-	keyMap struct {
-		Select        key.Binding
-		Next          key.Binding
-		Previous      key.Binding
-		UpDown        key.Binding
-		Delete        key.Binding
-		ConfirmDelete key.Binding
-		CancelDelete  key.Binding
-		Close         key.Binding
+	keyMap permissionRulesKeyMap
+}
+
+// permissionRulesKeyMap defines the key bindings of the dialog.
+type permissionRulesKeyMap struct {
+	Select        key.Binding
+	Next          key.Binding
+	Previous      key.Binding
+	UpDown        key.Binding
+	Delete        key.Binding
+	ConfirmDelete key.Binding
+	CancelDelete  key.Binding
+	Close         key.Binding
+}
+
+func defaultPermissionRulesKeyMap() permissionRulesKeyMap {
+	return permissionRulesKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑↓", "navigate"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "delete"),
+		),
+		ConfirmDelete: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "delete"),
+		),
+		CancelDelete: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n", "cancel"),
+		),
+		Close: CloseKey,
 	}
 }
 
@@ -78,35 +116,7 @@ func NewPermissionRules(com *common.Common, sessionPerms []permission.Permission
 	p.input.SetStyles(com.Styles.TextInput)
 	p.input.Focus()
 
-	p.keyMap.Select = key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "select"),
-	)
-	p.keyMap.Next = key.NewBinding(
-		key.WithKeys("down", "ctrl+n"),
-		key.WithHelp("↓", "next item"),
-	)
-	p.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "ctrl+p"),
-		key.WithHelp("↑", "previous item"),
-	)
-	p.keyMap.UpDown = key.NewBinding(
-		key.WithKeys("up", "down"),
-		key.WithHelp("↑↓", "navigate"),
-	)
-	p.keyMap.Delete = key.NewBinding(
-		key.WithKeys("ctrl+x"),
-		key.WithHelp("ctrl+x", "delete"),
-	)
-	p.keyMap.ConfirmDelete = key.NewBinding(
-		key.WithKeys("y"),
-		key.WithHelp("y", "delete"),
-	)
-	p.keyMap.CancelDelete = key.NewBinding(
-		key.WithKeys("n", "esc"),
-		key.WithHelp("n", "cancel"),
-	)
-	p.keyMap.Close = CloseKey
+	p.keyMap = keymap.Apply(permissionRulesKeyMapScope, defaultPermissionRulesKeyMap(), com.Keymap())
 
 	return p, nil
 }
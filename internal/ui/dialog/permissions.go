@@ -15,6 +15,7 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	uv "github.com/charmbracelet/ultraviolet"
 )
@@ -181,7 +182,7 @@ func NewPermissions(com *common.Common, perm permission.PermissionRequest, opts
 	h := help.New()
 	h.Styles = com.Styles.DialogHelpStyles()
 
-	km := defaultPermissionsKeyMap()
+	km := keymap.Apply(permissionsKeyMapScope, defaultPermissionsKeyMap(), com.Keymap())
 
 	// Configure viewport with matching keybindings.
 	vp := viewport.New()
@@ -319,7 +320,8 @@ func (p *Permissions) respond(action PermissionAction) tea.Msg {
 
 func (p *Permissions) hasDiffView() bool {
 	switch p.permission.ToolName {
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.HashlineEditToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.HashlineEditToolName,
+		tools.RenameToolName, tools.CodeActionToolName:
 		return true
 	}
 	return false
@@ -493,6 +495,15 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 		if params, ok := p.permission.Params.(tools.LSPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("Directory", fsext.PrettyPath(params.Path), contentWidth))
 		}
+	case tools.RenameToolName, tools.CodeActionToolName:
+		lines = append(lines, p.renderKeyValue("Desc", p.permission.Description, contentWidth))
+		if params, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Files", fmt.Sprintf("%d", len(params.Files)), contentWidth))
+		}
+	}
+
+	if p.permission.Rule != "" {
+		lines = append(lines, p.renderKeyValue("Rule", p.permission.Rule, contentWidth))
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, lines...)
@@ -533,6 +544,11 @@ func prettyName(name string) string {
 }
 
 func (p *Permissions) renderContent(width int) string {
+	// Calls a policy rule asks about before the tool runs carry the raw
+	// tool input rather than the tool's permission params.
+	if _, ok := p.permission.Params.(string); ok {
+		return p.renderDefaultContent(width)
+	}
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		return p.renderBashContent(width)
@@ -544,6 +560,8 @@ func (p *Permissions) renderContent(width int) string {
 		return p.renderMultiEditContent(width)
 	case tools.HashlineEditToolName:
 		return p.renderHashlineEditContent(width)
+	case tools.RenameToolName, tools.CodeActionToolName:
+		return p.renderWorkspaceEditContent(width)
 	case tools.DownloadToolName:
 		return p.renderDownloadContent(width)
 	case tools.FetchToolName:
@@ -600,6 +618,43 @@ func (p *Permissions) renderMultiEditContent(contentWidth int) string {
 	return p.renderDiff(params.FilePath, params.OldContent, params.NewContent, contentWidth)
 }
 
+func (p *Permissions) renderWorkspaceEditContent(contentWidth int) string {
+	params, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams)
+	if !ok {
+		return ""
+	}
+	if !p.viewportDirty {
+		if p.isSplitMode() {
+			return p.splitDiffContent
+		}
+		return p.unifiedDiffContent
+	}
+
+	isSplitMode := p.isSplitMode()
+	diffs := make([]string, 0, len(params.Files))
+	for _, file := range params.Files {
+		formatter := common.DiffFormatter(p.com.Styles).
+			Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+			After(fsext.PrettyPath(file.FilePath), file.NewContent).
+			XOffset(p.diffXOffset).
+			Width(contentWidth)
+		if isSplitMode {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+
+	result := strings.Join(diffs, "\n\n")
+	if isSplitMode {
+		p.splitDiffContent = result
+	} else {
+		p.unifiedDiffContent = result
+	}
+	return result
+}
+
 func (p *Permissions) renderDiff(filePath, oldContent, newContent string, contentWidth int) string {
 	if !p.viewportDirty {
 		if p.isSplitMode() {
@@ -5,6 +5,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	uv "github.com/charmbracelet/ultraviolet"
 )
 
@@ -15,14 +16,47 @@ const QuitID = "quit"
 type Quit struct {
 	com        *common.Common
 	selectedNo bool // true if "No" button is selected
-	keyMap     struct {
-		LeftRight,
-		EnterSpace,
-		Yes,
-		No,
-		Tab,
-		Close,
-		Quit key.Binding
+	keyMap     quitKeyMap
+}
+
+// quitKeyMap defines the key bindings of the dialog.
+type quitKeyMap struct {
+	LeftRight,
+	EnterSpace,
+	Yes,
+	No,
+	Tab,
+	Close,
+	Quit key.Binding
+}
+
+func defaultQuitKeyMap() quitKeyMap {
+	return quitKeyMap{
+		LeftRight: key.NewBinding(
+			key.WithKeys("left", "right"),
+			key.WithHelp("←/→", "switch options"),
+		),
+		EnterSpace: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "confirm"),
+		),
+		Yes: key.NewBinding(
+			key.WithKeys("y", "Y", "ctrl+c"),
+			key.WithHelp("y/Y/ctrl+c", "yes"),
+		),
+		No: key.NewBinding(
+			key.WithKeys("n", "N"),
+			key.WithHelp("n/N", "no"),
+		),
+		Tab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch options"),
+		),
+		Close: CloseKey,
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
 	}
 }
 
@@ -34,31 +68,7 @@ func NewQuit(com *common.Common) *Quit {
 		com:        com,
 		selectedNo: true,
 	}
-	q.keyMap.LeftRight = key.NewBinding(
-		key.WithKeys("left", "right"),
-		key.WithHelp("←/→", "switch options"),
-	)
-	q.keyMap.EnterSpace = key.NewBinding(
-		key.WithKeys("enter", " "),
-		key.WithHelp("enter/space", "confirm"),
-	)
-	q.keyMap.Yes = key.NewBinding(
-		key.WithKeys("y", "Y", "ctrl+c"),
-		key.WithHelp("y/Y/ctrl+c", "yes"),
-	)
-	q.keyMap.No = key.NewBinding(
-		key.WithKeys("n", "N"),
-		key.WithHelp("n/N", "no"),
-	)
-	q.keyMap.Tab = key.NewBinding(
-		key.WithKeys("tab"),
-		key.WithHelp("tab", "switch options"),
-	)
-	q.keyMap.Close = CloseKey
-	q.keyMap.Quit = key.NewBinding(
-		key.WithKeys("ctrl+c"),
-		key.WithHelp("ctrl+c", "quit"),
-	)
+	q.keyMap = keymap.Apply(quitKeyMapScope, defaultQuitKeyMap(), com.Keymap())
 	return q
 }
 
@@ -7,8 +7,8 @@ import (
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
-	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -29,12 +29,37 @@ type Reasoning struct {
 	list  *list.FilterableList
 	input textinput.Model
 
-	keyMap struct {
-		Select   key.Binding
-		Next     key.Binding
-		Previous key.Binding
-		UpDown   key.Binding
-		Close    key.Binding
+	keyMap reasoningKeyMap
+}
+
+// reasoningKeyMap defines the key bindings of the dialog.
+type reasoningKeyMap struct {
+	Select   key.Binding
+	Next     key.Binding
+	Previous key.Binding
+	UpDown   key.Binding
+	Close    key.Binding
+}
+
+func defaultReasoningKeyMap() reasoningKeyMap {
+	return reasoningKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose"),
+		),
+		Close: CloseKey,
 	}
 }
 
@@ -78,23 +103,7 @@ func NewReasoning(com *common.Common) (*Reasoning, error) {
 	r.input.SetStyles(com.Styles.TextInput)
 	r.input.Focus()
 
-	r.keyMap.Select = key.NewBinding(
-		key.WithKeys("enter", "ctrl+y"),
-		key.WithHelp("enter", "confirm"),
-	)
-	r.keyMap.Next = key.NewBinding(
-		key.WithKeys("down", "ctrl+n"),
-		key.WithHelp("↓", "next item"),
-	)
-	r.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "ctrl+p"),
-		key.WithHelp("↑", "previous item"),
-	)
-	r.keyMap.UpDown = key.NewBinding(
-		key.WithKeys("up", "down"),
-		key.WithHelp("↑/↓", "choose"),
-	)
-	r.keyMap.Close = CloseKey
+	r.keyMap = keymap.Apply(reasoningKeyMapScope, defaultReasoningKeyMap(), com.Keymap())
 
 	if err := r.setReasoningItems(); err != nil {
 		return nil, err
@@ -226,7 +235,7 @@ func (r *Reasoning) FullHelp() [][]key.Binding {
 
 func (r *Reasoning) setReasoningItems() error {
 	cfg := r.com.Config()
-	agentCfg, ok := cfg.Agents[config.AgentCoder]
+	agentCfg, ok := cfg.PrimaryAgent()
 	if !ok {
 		return errors.New("agent configuration not found")
 	}
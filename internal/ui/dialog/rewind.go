@@ -0,0 +1,172 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// RewindID is the identifier for the rewind dialog.
+const RewindID = "rewind"
+
+// rewindMaxFiles is how many of the files a rewind changes are listed.
+const rewindMaxFiles = 10
+
+// Rewind is a confirmation dialog for rolling the files of a session back
+// to a message, listing the files it changes.
+type Rewind struct {
+	com        *common.Common
+	messageID  string
+	force      bool
+	changes    []history.RewindChange
+	selectedNo bool
+	keyMap     rewindKeyMap
+}
+
+// rewindKeyMap defines the key bindings of the dialog.
+type rewindKeyMap struct {
+	LeftRight,
+	EnterSpace,
+	Yes,
+	No,
+	Tab,
+	Close key.Binding
+}
+
+func defaultRewindKeyMap() rewindKeyMap {
+	return rewindKeyMap{
+		LeftRight: key.NewBinding(
+			key.WithKeys("left", "right"),
+			key.WithHelp("←/→", "switch options"),
+		),
+		EnterSpace: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "confirm"),
+		),
+		Yes: key.NewBinding(
+			key.WithKeys("y", "Y"),
+			key.WithHelp("y/Y", "yes"),
+		),
+		No: key.NewBinding(
+			key.WithKeys("n", "N"),
+			key.WithHelp("n/N", "no"),
+		),
+		Tab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch options"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*Rewind)(nil)
+
+// NewRewind creates a dialog confirming a rewind to the message with the
+// given ID, which would make changes. force tells whether files changed
+// outside of Crush are overwritten.
+func NewRewind(com *common.Common, messageID string, force bool, changes []history.RewindChange) *Rewind {
+	r := &Rewind{
+		com:        com,
+		messageID:  messageID,
+		force:      force,
+		changes:    changes,
+		selectedNo: true,
+	}
+	r.keyMap = keymap.Apply(rewindKeyMapScope, defaultRewindKeyMap(), com.Keymap())
+	return r
+}
+
+// ID implements [Model].
+func (*Rewind) ID() string {
+	return RewindID
+}
+
+// HandleMsg implements [Model].
+func (r *Rewind) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.LeftRight, r.keyMap.Tab):
+			r.selectedNo = !r.selectedNo
+		case key.Matches(msg, r.keyMap.EnterSpace):
+			if !r.selectedNo {
+				return r.confirm()
+			}
+			return ActionClose{}
+		case key.Matches(msg, r.keyMap.Yes):
+			return r.confirm()
+		case key.Matches(msg, r.keyMap.No, r.keyMap.Close):
+			return ActionClose{}
+		}
+	}
+
+	return nil
+}
+
+func (r *Rewind) confirm() Action {
+	return ActionRewind{MessageID: r.messageID, Force: r.force}
+}
+
+// Draw implements [Dialog].
+func (r *Rewind) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	question := fmt.Sprintf("Rewind %d file(s) to before this message?", len(r.changes))
+
+	var files strings.Builder
+	for i, change := range r.changes {
+		if i == rewindMaxFiles {
+			fmt.Fprintf(&files, "\n…and %d more", len(r.changes)-i)
+			break
+		}
+		if i > 0 {
+			files.WriteString("\n")
+		}
+		line := string(change.Action) + " " + change.Path
+		if change.Conflict {
+			line += " (changed outside of Crush)"
+		}
+		files.WriteString(line)
+	}
+
+	buttons := common.ButtonGroup(t, []common.ButtonOpts{
+		{Text: "Rewind", Selected: !r.selectedNo, Padding: 3},
+		{Text: "Cancel", Selected: r.selectedNo, Padding: 3},
+	}, " ")
+	content := t.Dialog.Rewind.Content.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			question,
+			"",
+			t.Dialog.Rewind.Files.Render(files.String()),
+			"",
+			buttons,
+		),
+	)
+
+	view := t.Dialog.Rewind.Frame.Render(content)
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (r *Rewind) ShortHelp() []key.Binding {
+	return []key.Binding{
+		r.keyMap.LeftRight,
+		r.keyMap.EnterSpace,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (r *Rewind) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{r.keyMap.LeftRight, r.keyMap.EnterSpace, r.keyMap.Yes, r.keyMap.No},
+		{r.keyMap.Tab, r.keyMap.Close},
+	}
+}
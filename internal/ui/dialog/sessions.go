@@ -9,8 +9,10 @@ import (
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -26,8 +28,12 @@ const (
 	sessionsModeNormal sessionsMode = iota
 	sessionsModeDeleting
 	sessionsModeUpdating
+	sessionsModeSearching
 )
 
+// sessionsSearchLimit is the number of messages a search lists.
+const sessionsSearchLimit = 100
+
 // Session is a session selector dialog.
 type Session struct {
 	com                *common.Common
@@ -36,21 +42,81 @@ type Session struct {
 	input              textinput.Model
 	selectedSessionInx int
 	sessions           []session.Session
+	results            []message.SearchResult
 
 	sessionsMode sessionsMode
 
-	keyMap struct {
-		Select        key.Binding
-		Next          key.Binding
-		Previous      key.Binding
-		UpDown        key.Binding
-		Delete        key.Binding
-		Rename        key.Binding
-		ConfirmRename key.Binding
-		CancelRename  key.Binding
-		ConfirmDelete key.Binding
-		CancelDelete  key.Binding
-		Close         key.Binding
+	keyMap sessionsKeyMap
+}
+
+// sessionsKeyMap defines the key bindings of the dialog.
+type sessionsKeyMap struct {
+	Select        key.Binding
+	Next          key.Binding
+	Previous      key.Binding
+	UpDown        key.Binding
+	Delete        key.Binding
+	Rename        key.Binding
+	Search        key.Binding
+	CancelSearch  key.Binding
+	ConfirmRename key.Binding
+	CancelRename  key.Binding
+	ConfirmDelete key.Binding
+	CancelDelete  key.Binding
+	Close         key.Binding
+}
+
+func defaultSessionsKeyMap() sessionsKeyMap {
+	return sessionsKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "tab", "ctrl+y"),
+			key.WithHelp("enter", "choose"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑↓", "choose"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "delete"),
+		),
+		Rename: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "rename"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "search messages"),
+		),
+		CancelSearch: key.NewBinding(
+			key.WithKeys("esc", "ctrl+f"),
+			key.WithHelp("esc", "back to sessions"),
+		),
+		ConfirmRename: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "confirm"),
+		),
+		CancelRename: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		ConfirmDelete: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "delete"),
+		),
+		CancelDelete: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n", "cancel"),
+		),
+		Close: CloseKey,
 	}
 }
 
@@ -88,47 +154,7 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 	s.input.SetStyles(com.Styles.TextInput)
 	s.input.Focus()
 
-	s.keyMap.Select = key.NewBinding(
-		key.WithKeys("enter", "tab", "ctrl+y"),
-		key.WithHelp("enter", "choose"),
-	)
-	s.keyMap.Next = key.NewBinding(
-		key.WithKeys("down", "ctrl+n"),
-		key.WithHelp("↓", "next item"),
-	)
-	s.keyMap.Previous = key.NewBinding(
-		key.WithKeys("up", "ctrl+p"),
-		key.WithHelp("↑", "previous item"),
-	)
-	s.keyMap.UpDown = key.NewBinding(
-		key.WithKeys("up", "down"),
-		key.WithHelp("↑↓", "choose"),
-	)
-	s.keyMap.Delete = key.NewBinding(
-		key.WithKeys("ctrl+x"),
-		key.WithHelp("ctrl+x", "delete"),
-	)
-	s.keyMap.Rename = key.NewBinding(
-		key.WithKeys("ctrl+r"),
-		key.WithHelp("ctrl+r", "rename"),
-	)
-	s.keyMap.ConfirmRename = key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "confirm"),
-	)
-	s.keyMap.CancelRename = key.NewBinding(
-		key.WithKeys("esc"),
-		key.WithHelp("esc", "cancel"),
-	)
-	s.keyMap.ConfirmDelete = key.NewBinding(
-		key.WithKeys("y"),
-		key.WithHelp("y", "delete"),
-	)
-	s.keyMap.CancelDelete = key.NewBinding(
-		key.WithKeys("n", "esc"),
-		key.WithHelp("n", "cancel"),
-	)
-	s.keyMap.Close = CloseKey
+	s.keyMap = keymap.Apply(sessionsKeyMapScope, defaultSessionsKeyMap(), com.Keymap())
 
 	return s, nil
 }
@@ -173,10 +199,32 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 					return sessionItem.HandleInput(msg)
 				}
 			}
+		case sessionsModeSearching:
+			switch {
+			case key.Matches(msg, s.keyMap.CancelSearch):
+				s.stopSearch()
+			case key.Matches(msg, s.keyMap.Previous):
+				s.selectPrev()
+			case key.Matches(msg, s.keyMap.Next):
+				s.selectNext()
+			case key.Matches(msg, s.keyMap.Select):
+				if item, ok := s.list.SelectedItem().(*SearchResultItem); ok {
+					return ActionSelectSession{
+						Session:   s.resultSession(item.SearchResult),
+						MessageID: item.MessageID,
+					}
+				}
+			default:
+				var cmd tea.Cmd
+				s.input, cmd = s.input.Update(msg)
+				return ActionCmd{tea.Batch(cmd, s.search())}
+			}
 		default:
 			switch {
 			case key.Matches(msg, s.keyMap.Close):
 				return ActionClose{}
+			case key.Matches(msg, s.keyMap.Search):
+				return ActionCmd{s.startSearch()}
 			case key.Matches(msg, s.keyMap.Rename):
 				s.sessionsMode = sessionsModeUpdating
 				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeUpdating, s.sessions...)...)
@@ -187,25 +235,12 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 				s.sessionsMode = sessionsModeDeleting
 				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeDeleting, s.sessions...)...)
 			case key.Matches(msg, s.keyMap.Previous):
-				s.list.Focus()
-				if s.list.IsSelectedFirst() {
-					s.list.SelectLast()
-				} else {
-					s.list.SelectPrev()
-				}
-				s.list.ScrollToSelected()
+				s.selectPrev()
 			case key.Matches(msg, s.keyMap.Next):
-				s.list.Focus()
-				if s.list.IsSelectedLast() {
-					s.list.SelectFirst()
-				} else {
-					s.list.SelectNext()
-				}
-				s.list.ScrollToSelected()
+				s.selectNext()
 			case key.Matches(msg, s.keyMap.Select):
-				if item := s.list.SelectedItem(); item != nil {
-					sessionItem := item.(*SessionItem)
-					return ActionSelectSession{sessionItem.Session}
+				if sessionItem := s.selectedSessionItem(); sessionItem != nil {
+					return ActionSelectSession{Session: sessionItem.Session}
 				}
 			default:
 				var cmd tea.Cmd
@@ -255,6 +290,11 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 	rc := NewRenderContext(t, width)
 	rc.Title = "Sessions"
 	switch s.sessionsMode {
+	case sessionsModeSearching:
+		rc.Title = "Search Messages"
+		inputView := t.Dialog.InputPrompt.Render(s.input.View())
+		cur = s.Cursor()
+		rc.AddPart(inputView)
 	case sessionsModeDeleting:
 		rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
 		rc.TitleGradientFromColor = t.Dialog.Sessions.DeletingTitleGradientFromColor
@@ -326,12 +366,77 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 }
 
 func (s *Session) selectedSessionItem() *SessionItem {
-	if item := s.list.SelectedItem(); item != nil {
-		return item.(*SessionItem)
+	if item, ok := s.list.SelectedItem().(*SessionItem); ok {
+		return item
+	}
+	return nil
+}
+
+func (s *Session) selectPrev() {
+	s.list.Focus()
+	if s.list.IsSelectedFirst() {
+		s.list.SelectLast()
+	} else {
+		s.list.SelectPrev()
+	}
+	s.list.ScrollToSelected()
+}
+
+func (s *Session) selectNext() {
+	s.list.Focus()
+	if s.list.IsSelectedLast() {
+		s.list.SelectFirst()
+	} else {
+		s.list.SelectNext()
+	}
+	s.list.ScrollToSelected()
+}
+
+// startSearch switches the dialog to searching the messages of all
+// sessions, using what was typed so far as the query.
+func (s *Session) startSearch() tea.Cmd {
+	s.sessionsMode = sessionsModeSearching
+	s.input.Placeholder = "Search messages"
+	s.list.SetFilter("")
+	return s.search()
+}
+
+// stopSearch switches the dialog back to listing sessions.
+func (s *Session) stopSearch() {
+	s.sessionsMode = sessionsModeNormal
+	s.results = nil
+	s.input.Placeholder = "Enter session name"
+	s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+	s.list.SetFilter(s.input.Value())
+	s.list.SetSelected(0)
+}
+
+// search lists the messages matching the current input.
+func (s *Session) search() tea.Cmd {
+	var err error
+	s.results = nil
+	if query := strings.TrimSpace(s.input.Value()); query != "" {
+		s.results, err = s.com.Workspace.SearchMessages(context.TODO(), query, sessionsSearchLimit)
+	}
+	s.list.SetItems(searchResultItems(s.com.Styles, s.results...)...)
+	s.list.ScrollToTop()
+	s.list.SetSelected(0)
+	if err != nil {
+		return util.ReportError(err)
 	}
 	return nil
 }
 
+// resultSession returns the session a search result belongs to.
+func (s *Session) resultSession(result message.SearchResult) session.Session {
+	for _, sess := range s.sessions {
+		if sess.ID == result.SessionID {
+			return sess
+		}
+	}
+	return session.Session{ID: result.SessionID, Title: result.SessionTitle}
+}
+
 func (s *Session) confirmDeleteSession() Action {
 	sessionItem := s.selectedSessionItem()
 	s.sessionsMode = sessionsModeNormal
@@ -426,9 +531,16 @@ func (s *Session) ShortHelp() []key.Binding {
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
 		}
+	case sessionsModeSearching:
+		return []key.Binding{
+			s.keyMap.UpDown,
+			s.keyMap.Select,
+			s.keyMap.CancelSearch,
+		}
 	default:
 		return []key.Binding{
 			s.keyMap.UpDown,
+			s.keyMap.Search,
 			s.keyMap.Rename,
 			s.keyMap.Delete,
 			s.keyMap.Select,
@@ -442,6 +554,7 @@ func (s *Session) FullHelp() [][]key.Binding {
 	m := [][]key.Binding{}
 	slice := []key.Binding{
 		s.keyMap.UpDown,
+		s.keyMap.Search,
 		s.keyMap.Rename,
 		s.keyMap.Delete,
 		s.keyMap.Select,
@@ -459,6 +572,12 @@ func (s *Session) FullHelp() [][]key.Binding {
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
 		}
+	case sessionsModeSearching:
+		slice = []key.Binding{
+			s.keyMap.UpDown,
+			s.keyMap.Select,
+			s.keyMap.CancelSearch,
+		}
 	}
 	for i := 0; i < len(slice); i += 4 {
 		end := min(i+4, len(slice))
@@ -9,6 +9,7 @@ import (
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -51,6 +52,10 @@ type SessionItem struct {
 	cache            map[int]string
 	updateTitleInput textinput.Model
 	focused          bool
+
+	// forkedFrom is the title of the session this one was forked from, if
+	// it still exists.
+	forkedFrom string
 }
 
 // Finished implements list.Item. Session items are render-stable
@@ -104,6 +109,13 @@ func (s *SessionItem) Cursor() *tea.Cursor {
 // Render returns the string representation of the session item.
 func (s *SessionItem) Render(width int) string {
 	info := humanize.Time(time.Unix(s.UpdatedAt, 0))
+	if s.ForkedFromSessionID != "" {
+		origin := "deleted session"
+		if s.forkedFrom != "" {
+			origin = ansi.Truncate(s.forkedFrom, 24, "…")
+		}
+		info = "fork of " + origin + " · " + info
+	}
 	styles := ListItemStyles{
 		ItemBlurred:     s.t.Dialog.NormalItem,
 		ItemFocused:     s.t.Dialog.SelectedItem,
@@ -219,9 +231,14 @@ func (s *SessionItem) SetFocused(focused bool) {
 // sessionItems takes a slice of [session.Session]s and convert them to a slice
 // of [ListItem]s.
 func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Session) []list.FilterableItem {
+	titles := make(map[string]string, len(sessions))
+	for _, s := range sessions {
+		titles[s.ID] = s.Title
+	}
 	items := make([]list.FilterableItem, len(sessions))
 	for i, s := range sessions {
 		item := &SessionItem{Versioned: list.NewVersioned(), Session: s, t: t, sessionsMode: mode}
+		item.forkedFrom = titles[s.ForkedFromSessionID]
 		if mode == sessionsModeUpdating {
 			item.updateTitleInput = textinput.New()
 			item.updateTitleInput.SetVirtualCursor(false)
@@ -236,6 +253,86 @@ func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Sessi
 	return items
 }
 
+// SearchResultItem wraps a [message.SearchResult] to implement the
+// [ListItem] interface.
+type SearchResultItem struct {
+	*list.Versioned
+	message.SearchResult
+	t       *styles.Styles
+	snippet string
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var _ ListItem = &SearchResultItem{}
+
+// Finished implements list.Item.
+func (r *SearchResultItem) Finished() bool {
+	return true
+}
+
+// Filter returns the filterable value of the search result.
+func (r *SearchResultItem) Filter() string {
+	return r.snippet
+}
+
+// ID returns the ID of the matching message.
+func (r *SearchResultItem) ID() string {
+	return r.MessageID
+}
+
+// SetMatch implements list.MatchSettable. Search results keep the matches
+// found by the search rather than those of the dialog's fuzzy filter.
+func (r *SearchResultItem) SetMatch(fuzzy.Match) {}
+
+// SetFocused sets the focus state of the search result item.
+func (r *SearchResultItem) SetFocused(focused bool) {
+	if r.focused == focused {
+		return
+	}
+	r.cache = nil
+	r.focused = focused
+	if r.Versioned != nil {
+		r.Bump()
+	}
+}
+
+// Render returns the string representation of the search result item.
+func (r *SearchResultItem) Render(width int) string {
+	info := ansi.Truncate(r.SessionTitle, 24, "…") + " · " + humanize.Time(time.Unix(r.CreatedAt, 0))
+	styles := ListItemStyles{
+		ItemBlurred:     r.t.Dialog.NormalItem,
+		ItemFocused:     r.t.Dialog.SelectedItem,
+		InfoTextBlurred: r.t.Dialog.Sessions.InfoBlurred,
+		InfoTextFocused: r.t.Dialog.Sessions.InfoFocused,
+	}
+	return renderItem(styles, r.snippet, info, r.focused, width, r.cache, &r.m)
+}
+
+// searchResultItems takes a slice of [message.SearchResult]s and converts
+// them to a slice of [ListItem]s.
+func searchResultItems(t *styles.Styles, results ...message.SearchResult) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(results))
+	for i, res := range results {
+		item := &SearchResultItem{Versioned: list.NewVersioned(), SearchResult: res, t: t}
+		// Flatten the snippet onto one line without moving the matches.
+		item.snippet = strings.Map(func(r rune) rune {
+			if r == '\n' || r == '\r' || r == '\t' {
+				return ' '
+			}
+			return r
+		}, res.Snippet)
+		for _, match := range res.Matches {
+			for i := match[0]; i < match[1]; i++ {
+				item.m.MatchedIndexes = append(item.m.MatchedIndexes, i)
+			}
+		}
+		items[i] = item
+	}
+	return items
+}
+
 func matchedRanges(in []int) [][2]int {
 	if len(in) == 0 {
 		return [][2]int{}
@@ -0,0 +1,305 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	// ThemesID is the identifier for the themes dialog.
+	ThemesID              = "themes"
+	themesDialogMaxWidth  = 50
+	themesDialogMaxHeight = 16
+)
+
+// Themes represents a dialog for switching the color theme.
+type Themes struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.FilterableList
+	input textinput.Model
+
+	keyMap themesKeyMap
+}
+
+// themesKeyMap defines the key bindings of the dialog.
+type themesKeyMap struct {
+	Select   key.Binding
+	Next     key.Binding
+	Previous key.Binding
+	UpDown   key.Binding
+	Close    key.Binding
+}
+
+func defaultThemesKeyMap() themesKeyMap {
+	return themesKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "ctrl+y"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose"),
+		),
+		Close: CloseKey,
+	}
+}
+
+// ThemeItem represents a theme list item.
+type ThemeItem struct {
+	*list.Versioned
+	name      string
+	isCurrent bool
+	t         *styles.Styles
+	m         fuzzy.Match
+	cache     map[int]string
+	focused   bool
+}
+
+// Finished implements list.Item. Theme items are render-stable
+// outside of explicit SetFocused / SetMatch.
+func (i *ThemeItem) Finished() bool {
+	return true
+}
+
+var (
+	_ Dialog   = (*Themes)(nil)
+	_ ListItem = (*ThemeItem)(nil)
+)
+
+// NewThemes creates a new themes dialog.
+func NewThemes(com *common.Common) *Themes {
+	d := &Themes{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	d.help = help
+
+	d.list = list.NewFilterableList()
+	d.list.Focus()
+
+	d.input = textinput.New()
+	d.input.SetVirtualCursor(false)
+	d.input.Placeholder = "Type to filter"
+	d.input.SetStyles(com.Styles.TextInput)
+	d.input.Focus()
+
+	d.keyMap = keymap.Apply(themesKeyMapScope, defaultThemesKeyMap(), com.Keymap())
+
+	d.setThemeItems()
+
+	return d
+}
+
+// ID implements Dialog.
+func (d *Themes) ID() string {
+	return ThemesID
+}
+
+// HandleMsg implements [Dialog].
+func (d *Themes) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, d.keyMap.Previous):
+			d.list.Focus()
+			if d.list.IsSelectedFirst() {
+				d.list.SelectLast()
+				d.list.ScrollToBottom()
+				break
+			}
+			d.list.SelectPrev()
+			d.list.ScrollToSelected()
+		case key.Matches(msg, d.keyMap.Next):
+			d.list.Focus()
+			if d.list.IsSelectedLast() {
+				d.list.SelectFirst()
+				d.list.ScrollToTop()
+				break
+			}
+			d.list.SelectNext()
+			d.list.ScrollToSelected()
+		case key.Matches(msg, d.keyMap.Select):
+			selectedItem := d.list.SelectedItem()
+			if selectedItem == nil {
+				break
+			}
+			themeItem, ok := selectedItem.(*ThemeItem)
+			if !ok {
+				break
+			}
+			return ActionSelectTheme{Name: themeItem.name}
+		default:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(msg)
+			value := d.input.Value()
+			d.list.SetFilter(value)
+			d.list.ScrollToTop()
+			d.list.SetSelected(0)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (d *Themes) Cursor() *tea.Cursor {
+	return InputCursor(d.com.Styles, d.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (d *Themes) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := d.com.Styles
+	width := max(0, min(themesDialogMaxWidth, area.Dx()))
+	height := max(0, min(themesDialogMaxHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+
+	d.input.SetWidth(innerWidth - t.Dialog.InputPrompt.GetHorizontalFrameSize() - 1)
+	d.list.SetSize(innerWidth, height-heightOffset)
+	d.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Switch Theme"
+	inputView := t.Dialog.InputPrompt.Render(d.input.View())
+	rc.AddPart(inputView)
+
+	visibleCount := len(d.list.FilteredItems())
+	if d.list.Height() >= visibleCount {
+		d.list.ScrollToTop()
+	} else {
+		d.list.ScrollToSelected()
+	}
+
+	listView := t.Dialog.List.Height(d.list.Height()).Render(d.list.Render())
+	rc.AddPart(listView)
+	rc.Help = d.help.View(d)
+
+	view := rc.Render()
+
+	cur := d.Cursor()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (d *Themes) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.keyMap.UpDown,
+		d.keyMap.Select,
+		d.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (d *Themes) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := []key.Binding{
+		d.keyMap.Select,
+		d.keyMap.Next,
+		d.keyMap.Previous,
+		d.keyMap.Close,
+	}
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+func (d *Themes) setThemeItems() {
+	current := d.com.Theme()
+	if current == "" {
+		current = styles.DefaultTheme
+	}
+
+	names := styles.ThemeNames()
+	items := make([]list.FilterableItem, 0, len(names))
+	selectedIndex := 0
+	for i, name := range names {
+		item := &ThemeItem{
+			Versioned: list.NewVersioned(),
+			name:      name,
+			isCurrent: name == current,
+			t:         d.com.Styles,
+		}
+		items = append(items, item)
+		if name == current {
+			selectedIndex = i
+		}
+	}
+
+	d.list.SetItems(items...)
+	d.list.SetSelected(selectedIndex)
+	d.list.ScrollToSelected()
+}
+
+// Filter returns the filter value for the theme item.
+func (i *ThemeItem) Filter() string {
+	return i.name
+}
+
+// ID returns the unique identifier for the theme.
+func (i *ThemeItem) ID() string {
+	return i.name
+}
+
+// SetFocused sets the focus state of the theme item.
+func (i *ThemeItem) SetFocused(focused bool) {
+	if i.focused == focused {
+		return
+	}
+	i.cache = nil
+	i.focused = focused
+	if i.Versioned != nil {
+		i.Bump()
+	}
+}
+
+// SetMatch sets the fuzzy match for the theme item.
+func (i *ThemeItem) SetMatch(m fuzzy.Match) {
+	if sameFuzzyMatch(i.m, m) {
+		return
+	}
+	i.cache = nil
+	i.m = m
+	if i.Versioned != nil {
+		i.Bump()
+	}
+}
+
+// Render returns the string representation of the theme item.
+func (i *ThemeItem) Render(width int) string {
+	info := ""
+	if i.isCurrent {
+		info = "current"
+	}
+	styles := ListItemStyles{
+		ItemBlurred:     i.t.Dialog.NormalItem,
+		ItemFocused:     i.t.Dialog.SelectedItem,
+		InfoTextBlurred: i.t.Dialog.ListItem.InfoBlurred,
+		InfoTextFocused: i.t.Dialog.ListItem.InfoFocused,
+	}
+	return renderItem(styles, i.name, info, i.focused, width, i.cache, &i.m)
+}
@@ -0,0 +1,247 @@
+// Package keymap lets users rebind the keys of the TUI.
+//
+// Key maps are structs of [key.Binding] fields, possibly nested in other
+// structs. Each binding is an action, named after the path to its field in
+// snake case under the scope its key map is registered with: the NewSession
+// binding of the Chat struct of a key map registered as "" is the action
+// "chat.new_session", and the Delete binding of one registered as
+// "dialog.sessions" is "dialog.sessions.delete".
+//
+// The configuration maps actions to the keys they are bound to. An empty
+// list of keys unbinds the action.
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"charm.land/bubbles/v2/key"
+)
+
+// Action is an action that can be bound to keys.
+type Action struct {
+	// Name is the name of the action in the configuration.
+	Name string
+	// Keys are the keys the action is bound to by default.
+	Keys []string
+	// Description is what the action does, as shown in the help.
+	Description string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() any{}
+)
+
+// Register registers the key map of scope, as returned by defaults, so its
+// actions are known to [Validate] and listed by [Actions].
+func Register[T any](scope string, defaults func() T) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[scope] = func() any {
+		km := defaults()
+		return &km
+	}
+}
+
+// Actions returns the actions of the registered key maps, sorted by name.
+func Actions() []Action {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var actions []Action
+	for scope, defaults := range registry {
+		for _, e := range entriesOf(defaults(), scope) {
+			actions = append(actions, Action{
+				Name:        e.action,
+				Keys:        e.binding.Keys(),
+				Description: e.binding.Help().Desc,
+			})
+		}
+	}
+	slices.SortFunc(actions, func(a, b Action) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return actions
+}
+
+// Validate reports the problems with overrides: actions no registered key
+// map has, and keys bound to actions which would then conflict. Actions
+// conflict when they are in the same struct, or one is in a struct nested
+// in the other's, unless their default bindings already share the key.
+func Validate(overrides map[string][]string) error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	known := map[string]bool{}
+	var errs []error
+	for scope, defaults := range registry {
+		entries := entriesOf(defaults(), scope)
+		for _, e := range entries {
+			known[e.action] = true
+		}
+		errs = append(errs, apply(entries, overrides)...)
+	}
+	var unknown []string
+	for action := range overrides {
+		if !known[action] {
+			unknown = append(unknown, action)
+		}
+	}
+	slices.Sort(unknown)
+	for _, action := range unknown {
+		errs = append(errs, fmt.Errorf("unknown action %q", action))
+	}
+	return errors.Join(errs...)
+}
+
+// Apply returns km, the key map of scope, with the keys of its actions set
+// to the ones overrides binds them to. The help shows the first key of an
+// overridden action. Overrides that conflict are left out, see
+// [Validate].
+func Apply[T any](scope string, km T, overrides map[string][]string) T {
+	if len(overrides) > 0 {
+		apply(entriesOf(&km, scope), overrides)
+	}
+	return km
+}
+
+// Overridden reports whether overrides rebinds action.
+func Overridden(overrides map[string][]string, action string) bool {
+	_, ok := overrides[action]
+	return ok
+}
+
+// entry is a binding of a key map.
+type entry struct {
+	action string
+	// group is the name of the struct the binding is in, which is a
+	// prefix of the action.
+	group   string
+	binding *key.Binding
+}
+
+var bindingType = reflect.TypeFor[key.Binding]()
+
+// entriesOf returns the bindings of km, a pointer to a key map.
+func entriesOf(km any, scope string) []*entry {
+	v := reflect.ValueOf(km)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("keymap: %T is not a pointer to a struct", km))
+	}
+	var entries []*entry
+	var walk func(v reflect.Value, group string)
+	walk = func(v reflect.Value, group string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := join(group, snakeCase(f.Name))
+			switch {
+			case f.Type == bindingType:
+				entries = append(entries, &entry{
+					action:  name,
+					group:   group,
+					binding: v.Field(i).Addr().Interface().(*key.Binding),
+				})
+			case f.Type.Kind() == reflect.Struct:
+				walk(v.Field(i), name)
+			}
+		}
+	}
+	walk(v.Elem(), scope)
+	return entries
+}
+
+// apply sets the keys of entries to overrides, restoring the defaults of
+// the ones that conflict, and returns the conflicts.
+func apply(entries []*entry, overrides map[string][]string) []error {
+	defaults := make([]key.Binding, len(entries))
+	overridden := make([]bool, len(entries))
+	for i, e := range entries {
+		defaults[i] = *e.binding
+		keys, ok := overrides[e.action]
+		if !ok {
+			continue
+		}
+		overridden[i] = true
+		if len(keys) == 0 {
+			e.binding.SetEnabled(false)
+			continue
+		}
+		e.binding.SetKeys(keys...)
+		e.binding.SetHelp(keys[0], e.binding.Help().Desc)
+		e.binding.SetEnabled(true)
+	}
+
+	var errs []error
+	conflicting := make([]bool, len(entries))
+	for i, a := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			b := entries[j]
+			if !overridden[i] && !overridden[j] || !related(a.group, b.group) {
+				continue
+			}
+			for _, k := range boundKeys(a.binding) {
+				if !slices.Contains(boundKeys(b.binding), k) ||
+					slices.Contains(boundKeys(&defaults[i]), k) && slices.Contains(boundKeys(&defaults[j]), k) {
+					continue
+				}
+				errs = append(errs, fmt.Errorf("key %q is bound to both %q and %q", k, a.action, b.action))
+				conflicting[i] = conflicting[i] || overridden[i]
+				conflicting[j] = conflicting[j] || overridden[j]
+			}
+		}
+	}
+	for i, e := range entries {
+		if conflicting[i] {
+			*e.binding = defaults[i]
+		}
+	}
+	return errs
+}
+
+func boundKeys(b *key.Binding) []string {
+	if !b.Enabled() {
+		return nil
+	}
+	return b.Keys()
+}
+
+// related reports whether the actions of the groups can conflict, which
+// they can when one group is the other or nested in it.
+func related(a, b string) bool {
+	return a == b || a == "" || b == "" ||
+		strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// snakeCase converts a field name such as "UpDownOneItem" to
+// "up_down_one_item".
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
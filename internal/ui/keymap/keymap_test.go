@@ -0,0 +1,133 @@
+package keymap
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeyMap struct {
+	Editor struct {
+		SendMessage key.Binding
+		Newline     key.Binding
+	}
+	Chat struct {
+		Up     key.Binding
+		UpDown key.Binding
+	}
+	Quit key.Binding
+
+	unexported key.Binding
+}
+
+func defaultTestKeyMap() testKeyMap {
+	var km testKeyMap
+	km.Editor.SendMessage = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "send"))
+	km.Editor.Newline = key.NewBinding(key.WithKeys("shift+enter", "ctrl+j"), key.WithHelp("ctrl+j", "newline"))
+	km.Chat.Up = key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑", "up"))
+	km.Chat.UpDown = key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑↓", "scroll"))
+	km.Quit = key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit"))
+	return km
+}
+
+func init() {
+	Register("test", defaultTestKeyMap)
+}
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"Quit":          "quit",
+		"SendMessage":   "send_message",
+		"UpDownOneItem": "up_down_one_item",
+		"APIKeyInput":   "api_key_input",
+	} {
+		require.Equal(t, want, snakeCase(name), name)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no overrides", func(t *testing.T) {
+		t.Parallel()
+		km := Apply("test", defaultTestKeyMap(), nil)
+		require.Equal(t, []string{"enter"}, km.Editor.SendMessage.Keys())
+	})
+
+	t.Run("overrides keys and help", func(t *testing.T) {
+		t.Parallel()
+		km := Apply("test", defaultTestKeyMap(), map[string][]string{
+			"test.editor.send_message": {"ctrl+s", "ctrl+enter"},
+			"test.chat.up":             {"ctrl+u"},
+		})
+		require.Equal(t, []string{"ctrl+s", "ctrl+enter"}, km.Editor.SendMessage.Keys())
+		require.Equal(t, key.Help{Key: "ctrl+s", Desc: "send"}, km.Editor.SendMessage.Help())
+		require.Equal(t, []string{"ctrl+u"}, km.Chat.Up.Keys())
+		require.Equal(t, []string{"shift+enter", "ctrl+j"}, km.Editor.Newline.Keys())
+	})
+
+	t.Run("empty list unbinds", func(t *testing.T) {
+		t.Parallel()
+		km := Apply("test", defaultTestKeyMap(), map[string][]string{
+			"test.quit": {},
+		})
+		require.False(t, km.Quit.Enabled())
+	})
+
+	t.Run("conflicting overrides are left out", func(t *testing.T) {
+		t.Parallel()
+		km := Apply("test", defaultTestKeyMap(), map[string][]string{
+			// Conflicts with the global quit.
+			"test.editor.newline": {"ctrl+c"},
+			// Keys shared by default stay allowed.
+			"test.chat.up": {"up"},
+			// Editor and chat are separate.
+			"test.editor.send_message": {"k"},
+		})
+		require.Equal(t, []string{"shift+enter", "ctrl+j"}, km.Editor.Newline.Keys())
+		require.Equal(t, []string{"up"}, km.Chat.Up.Keys())
+		require.Equal(t, []string{"k"}, km.Editor.SendMessage.Keys())
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Validate(nil))
+	require.NoError(t, Validate(map[string][]string{
+		"test.quit":         {"ctrl+q"},
+		"test.chat.up_down": {},
+	}))
+
+	err := Validate(map[string][]string{
+		"test.nope":           {"x"},
+		"test.unexported":     {"x"},
+		"test.editor.newline": {"ctrl+c"},
+	})
+	require.ErrorContains(t, err, `unknown action "test.nope"`)
+	require.ErrorContains(t, err, `unknown action "test.unexported"`)
+	require.ErrorContains(t, err, `key "ctrl+c" is bound to both "test.editor.newline" and "test.quit"`)
+}
+
+func TestActions(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+	for _, a := range Actions() {
+		names = append(names, a.Name)
+		if a.Name == "test.editor.newline" {
+			require.Equal(t, []string{"shift+enter", "ctrl+j"}, a.Keys)
+			require.Equal(t, "newline", a.Description)
+		}
+	}
+	require.Equal(t, []string{
+		"test.chat.up",
+		"test.chat.up_down",
+		"test.editor.newline",
+		"test.editor.send_message",
+		"test.quit",
+	}, names)
+}
@@ -602,6 +602,21 @@ func (m *Chat) MessageItem(id string) chat.MessageItem {
 	return item
 }
 
+// SelectItem selects and scrolls to the first item matching one of the
+// given message or tool call IDs. It reports whether any was found.
+func (m *Chat) SelectItem(ids ...string) bool {
+	for _, id := range ids {
+		idx, ok := m.idInxMap[id]
+		if !ok {
+			continue
+		}
+		m.SetSelected(idx)
+		m.ScrollToSelected()
+		return true
+	}
+	return false
+}
+
 // SelectedMessageID returns the message ID of the currently selected item.
 // For tool message items, it returns the parent message ID. Returns an empty
 // string if no item is selected or the item is not a message.
@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"charm.land/lipgloss/v2"
-	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/common"
@@ -30,12 +29,16 @@ type header struct {
 	com     *common.Common
 	width   int
 	compact bool
+
+	// detailsKey is the key toggling the session details.
+	detailsKey string
 }
 
 // newHeader creates a new header model.
-func newHeader(com *common.Common) *header {
+func newHeader(com *common.Common, detailsKey string) *header {
 	h := &header{
-		com: com,
+		com:        com,
+		detailsKey: detailsKey,
 	}
 	h.refresh()
 	return h
@@ -101,6 +104,7 @@ func (h *header) drawHeader(
 		session,
 		lspErrorCount,
 		detailsOpen,
+		h.detailsKey,
 		availDetailWidth,
 		hyperCredits,
 	)
@@ -133,6 +137,7 @@ func renderHeaderDetails(
 	session *session.Session,
 	lspErrorCount int,
 	detailsOpen bool,
+	detailsKey string,
 	availWidth int,
 	hyperCredits *int,
 ) string {
@@ -144,7 +149,7 @@ func renderHeaderDetails(
 		parts = append(parts, t.LSP.ErrorDiagnostic.Render(fmt.Sprintf("%s%d", styles.LSPErrorIcon, lspErrorCount)))
 	}
 
-	agentCfg := com.Config().Agents[config.AgentCoder]
+	agentCfg, _ := com.Config().PrimaryAgent()
 	model := com.Config().GetModelByType(agentCfg.Model)
 	if model != nil && model.ContextWindow > 0 {
 		percentage := (float64(session.CompletionTokens+session.PromptTokens) / float64(model.ContextWindow)) * 100
@@ -161,11 +166,10 @@ func renderHeaderDetails(
 		parts = append(parts, hc)
 	}
 
-	const keystroke = "ctrl+d"
 	if detailsOpen {
-		parts = append(parts, t.Header.Keystroke.Render(keystroke)+t.Header.KeystrokeTip.Render(" close"))
+		parts = append(parts, t.Header.Keystroke.Render(detailsKey)+t.Header.KeystrokeTip.Render(" close"))
 	} else {
-		parts = append(parts, t.Header.Keystroke.Render(keystroke)+t.Header.KeystrokeTip.Render(" open "))
+		parts = append(parts, t.Header.Keystroke.Render(detailsKey)+t.Header.KeystrokeTip.Render(" open "))
 	}
 
 	dot := t.Header.Separator.Render(" • ")
@@ -1,6 +1,22 @@
 package model
 
-import "charm.land/bubbles/v2/key"
+import (
+	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+)
+
+// The scopes of the key maps of the model, prefixing the names of their
+// actions in the configuration. The actions of the main key map have no
+// prefix, as in "quit" or "editor.send_message".
+const (
+	keyMapScope   = ""
+	viKeyMapScope = "vi"
+)
+
+func init() {
+	keymap.Register(keyMapScope, DefaultKeyMap)
+	keymap.Register(viKeyMapScope, defaultViKeyMap)
+}
 
 type KeyMap struct {
 	Editor struct {
@@ -48,6 +64,9 @@ type KeyMap struct {
 		ClearHighlight key.Binding
 		Expand         key.Binding
 		DeleteMessage  key.Binding
+		Rewind         key.Binding
+		RewindForce    key.Binding
+		Fork           key.Binding
 	}
 
 	Initialize struct {
@@ -255,6 +274,18 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete message"),
 	)
+	km.Chat.Rewind = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rewind files to message"),
+	)
+	km.Chat.RewindForce = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "rewind files, overwriting changes"),
+	)
+	km.Chat.Fork = key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "fork session at message"),
+	)
 	km.Initialize.Yes = key.NewBinding(
 		key.WithKeys("y", "Y"),
 		key.WithHelp("y", "yes"),
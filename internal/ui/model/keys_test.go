@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/ui/keymap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyMapOverrides(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, keymap.Validate(map[string][]string{
+		"quit":                   {"ctrl+q"},
+		"editor.newline":         {"alt+enter"},
+		"chat.new_session":       {},
+		"vi.word_forward":        {"W"},
+		"completions.select":     {"enter"},
+		"dialog.sessions.delete": {"ctrl+x"},
+	}))
+
+	err := keymap.Validate(map[string][]string{
+		"editor.send_message": {"ctrl+s"},
+		"vi.delete":           {"x"},
+		"chat.nope":           {"x"},
+	})
+	require.ErrorContains(t, err, `key "ctrl+s" is bound to both "editor.send_message" and "sessions"`)
+	require.ErrorContains(t, err, `key "x" is bound to both "vi.delete_char" and "vi.delete"`)
+	require.ErrorContains(t, err, `unknown action "chat.nope"`)
+
+	km := keymap.Apply(viKeyMapScope, defaultViKeyMap(), map[string][]string{
+		"vi.word_forward": {"W"},
+	})
+	require.Equal(t, []string{"W"}, km.WordForward.Keys())
+}
@@ -26,6 +26,9 @@ type loadSessionMsg struct {
 	session   *session.Session
 	files     []SessionFile
 	readFiles []string
+	// messageID, if set, is the message to select once the session is
+	// shown.
+	messageID string
 }
 
 // lspFilePaths returns deduplicated file paths from both modified and read
@@ -89,6 +92,20 @@ func (m *UI) loadSession(sessionID string) tea.Cmd {
 	}
 }
 
+// loadSessionAt loads the session like [UI.loadSession] and then selects
+// the message with the given ID.
+func (m *UI) loadSessionAt(sessionID, messageID string) tea.Cmd {
+	load := m.loadSession(sessionID)
+	return func() tea.Msg {
+		msg := load()
+		if loaded, ok := msg.(loadSessionMsg); ok {
+			loaded.messageID = messageID
+			return loaded
+		}
+		return msg
+	}
+}
+
 func (m *UI) loadSessionFiles(sessionID string) ([]SessionFile, error) {
 	files, err := m.com.Workspace.ListSessionHistory(context.Background(), sessionID)
 	if err != nil {
@@ -47,6 +47,7 @@ import (
 	"github.com/charmbracelet/crush/internal/ui/completions"
 	"github.com/charmbracelet/crush/internal/ui/dialog"
 	fimage "github.com/charmbracelet/crush/internal/ui/image"
+	"github.com/charmbracelet/crush/internal/ui/keymap"
 	"github.com/charmbracelet/crush/internal/ui/logo"
 	"github.com/charmbracelet/crush/internal/ui/notification"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -153,6 +154,32 @@ type (
 		messageID string
 	}
 
+	// rewindSelectedMessageMsg is sent to roll the session's files back to
+	// the state before the currently selected chat message.
+	rewindSelectedMessageMsg struct {
+		messageID string
+		force     bool
+	}
+
+	// rewindPreviewedMsg is sent with the changes a rewind would make, to
+	// be confirmed.
+	rewindPreviewedMsg struct {
+		messageID string
+		force     bool
+		changes   []history.RewindChange
+	}
+
+	// forkSelectedMessageMsg is sent to branch a new session off the
+	// current one at the currently selected chat message.
+	forkSelectedMessageMsg struct {
+		messageID string
+	}
+
+	// sessionForkedMsg is sent once a fork has been created.
+	sessionForkedMsg struct {
+		session session.Session
+	}
+
 	// sessionFilesUpdatesMsg is sent when the files for this session have been updated
 	sessionFilesUpdatesMsg struct {
 		sessionFiles []SessionFile
@@ -308,7 +335,8 @@ func New(com *common.Common, initialSessionID string, continueLast bool) *UI {
 
 	ch := NewChat(com)
 
-	keyMap := DefaultKeyMap()
+	overrides := com.Keymap()
+	keyMap := keymap.Apply(keyMapScope, DefaultKeyMap(), overrides)
 
 	// Completions component
 	comp := completions.New(
@@ -316,6 +344,7 @@ func New(com *common.Common, initialSessionID string, continueLast bool) *UI {
 		com.Styles.Completions.Focused,
 		com.Styles.Completions.Match,
 	)
+	comp.SetKeyMap(keymap.Apply(completions.KeyMapScope, completions.DefaultKeyMap(), overrides))
 
 	todoSpinner := spinner.New(
 		spinner.WithSpinner(spinner.MiniDot),
@@ -338,7 +367,7 @@ func New(com *common.Common, initialSessionID string, continueLast bool) *UI {
 		},
 	)
 
-	header := newHeader(com)
+	header := newHeader(com, keyMap.Chat.Details.Help().Key)
 
 	ui := &UI{
 		com:                 com,
@@ -359,6 +388,8 @@ func New(com *common.Common, initialSessionID string, continueLast bool) *UI {
 		skillStates:         skills.GetLatestStates(),
 	}
 
+	ui.vi.keyMap = keymap.Apply(viKeyMapScope, defaultViKeyMap(), overrides)
+
 	status := NewStatus(com, ui)
 
 	ui.setEditorPrompt(com.Workspace.PermissionSkipRequests())
@@ -412,6 +443,14 @@ func (m *UI) Init() tea.Cmd {
 	if m.com.IsHyper() {
 		cmds = append(cmds, m.fetchHyperCredits())
 	}
+	if err := keymap.Validate(m.com.Keymap()); err != nil {
+		slog.Warn("Invalid key bindings in the configuration", "error", err)
+		cmds = append(cmds, util.ReportWarn("Some key bindings in the configuration are invalid and were ignored, see the logs"))
+	}
+	if theme := m.com.Theme(); theme != "" && !slices.Contains(styles.ThemeNames(), theme) {
+		slog.Warn("Unknown theme in the configuration", "theme", theme)
+		cmds = append(cmds, util.ReportWarn(fmt.Sprintf("Unknown theme %q, using the default one", theme)))
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -564,6 +603,11 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.historyReset()
 		cmds = append(cmds, m.loadPromptHistory())
 		m.updateLayoutAndSize()
+		if msg.messageID != "" {
+			if cmd := m.selectMessage(msgs, msg.messageID); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
 
 	case sessionFilesUpdatesMsg:
 		m.sessionFiles = msg.sessionFiles
@@ -680,6 +724,21 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case mcp.EventResourcesListChanged:
 			return m, handleMCPResourcesEvent(m.com.Workspace, msg.Payload.Name)
 		}
+	case pubsub.Event[mcp.Elicitation]:
+		if msg.Type == pubsub.DeletedEvent {
+			m.closeElicitationDialog(msg.Payload.ID)
+			break
+		}
+		m.dialog.CloseDialog(dialog.ElicitationID)
+		m.dialog.OpenDialog(dialog.NewElicitation(m.com, msg.Payload))
+		if cmd := m.sendNotification(notification.Notification{
+			Title:   "Crush is waiting...",
+			Message: fmt.Sprintf("%s is asking for input", msg.Payload.Name),
+		}); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case pubsub.Event[mcp.Progress]:
+		m.handleMCPProgress(msg.Payload)
 	case pubsub.Event[permission.PermissionRequest]:
 		if cmd := m.openPermissionsDialog(msg.Payload); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -712,13 +771,26 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyboardEnhancementsMsg:
 		m.keyenh = msg
 		if msg.SupportsKeyDisambiguation() {
-			m.keyMap.Models.SetHelp("ctrl+m", "models")
-			m.keyMap.Editor.Newline.SetHelp("shift+enter", "newline")
+			overrides := m.com.Keymap()
+			if !keymap.Overridden(overrides, "models") {
+				m.keyMap.Models.SetHelp("ctrl+m", "models")
+			}
+			if !keymap.Overridden(overrides, "editor.newline") {
+				m.keyMap.Editor.Newline.SetHelp("shift+enter", "newline")
+			}
 		}
 	case copyChatHighlightMsg:
 		cmds = append(cmds, m.copyChatHighlight())
 	case deleteSelectedMessageMsg:
 		cmds = append(cmds, m.deleteMessage(msg.messageID))
+	case rewindSelectedMessageMsg:
+		cmds = append(cmds, m.previewRewind(msg.messageID, msg.force))
+	case rewindPreviewedMsg:
+		m.dialog.OpenDialog(dialog.NewRewind(m.com, msg.messageID, msg.force, msg.changes))
+	case forkSelectedMessageMsg:
+		cmds = append(cmds, m.forkAtMessage(msg.messageID))
+	case sessionForkedMsg:
+		cmds = append(cmds, m.loadSession(msg.session.ID), util.ReportInfo("Forked session: "+msg.session.Title))
 	case DelayedClickMsg:
 		// Handle delayed single-click action (e.g., expansion).
 		m.chat.HandleDelayedClick(msg)
@@ -1007,6 +1079,32 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// selectMessage focuses the chat on the message with the given ID. Tool
+// results aren't shown as items of their own, so those, like tool-only
+// assistant messages, are found through their tool calls.
+func (m *UI) selectMessage(msgs []message.Message, messageID string) tea.Cmd {
+	ids := []string{messageID}
+	for _, msg := range msgs {
+		if msg.ID != messageID {
+			continue
+		}
+		for _, tc := range msg.ToolCalls() {
+			ids = append(ids, tc.ID)
+		}
+		for _, tr := range msg.ToolResults() {
+			ids = append(ids, tr.ToolCallID)
+		}
+		break
+	}
+	if !m.chat.SelectItem(ids...) {
+		return util.ReportWarn("Message not found in session")
+	}
+	m.focus = uiFocusMain
+	m.textarea.Blur()
+	m.chat.Focus()
+	return m.chat.RestartPausedVisibleAnimations()
+}
+
 // loadNestedToolCalls recursively loads nested tool calls for agent/agentic_fetch tools.
 func (m *UI) loadNestedToolCalls(items []chat.MessageItem) {
 	for _, item := range items {
@@ -1356,6 +1454,10 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	// Session dialog messages.
 	case dialog.ActionSelectSession:
 		m.dialog.CloseDialog(dialog.SessionsID)
+		if msg.MessageID != "" {
+			cmds = append(cmds, m.loadSessionAt(msg.Session.ID, msg.MessageID))
+			break
+		}
 		cmds = append(cmds, m.loadSession(msg.Session.ID))
 
 	// Open dialog message.
@@ -1396,6 +1498,12 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionRestoreCheckpoint:
+		m.dialog.CloseDialog(dialog.CheckpointsID)
+		cmds = append(cmds, m.restoreCheckpoint(msg.SessionID, msg.Number))
+	case dialog.ActionRewind:
+		m.dialog.CloseDialog(dialog.RewindID)
+		cmds = append(cmds, m.rewindToMessage(msg.MessageID, msg.Force))
 	case dialog.ActionSummarize:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("Agent is busy, please wait before summarizing session..."))
@@ -1434,7 +1542,7 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 				return util.ReportError(errors.New("configuration not found"))()
 			}
 
-			agentCfg, ok := cfg.Agents[config.AgentCoder]
+			agentCfg, ok := cfg.PrimaryAgent()
 			if !ok {
 				return util.ReportError(errors.New("agent configuration not found"))()
 			}
@@ -1505,7 +1613,7 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			break
 		}
 
-		agentCfg, ok := cfg.Agents[config.AgentCoder]
+		agentCfg, ok := cfg.PrimaryAgent()
 		if !ok {
 			cmds = append(cmds, util.ReportError(errors.New("agent configuration not found")))
 			break
@@ -1523,6 +1631,49 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			return util.NewInfoMsg("Reasoning effort set to " + msg.Effort)
 		})
 		m.dialog.CloseDialog(dialog.ReasoningID)
+	case dialog.ActionSelectTheme:
+		s, ok := styles.ThemeStyles(msg.Name)
+		if !ok {
+			cmds = append(cmds, util.ReportError(fmt.Errorf("unknown theme %q", msg.Name)))
+			break
+		}
+		m.applyTheme(s)
+		if err := m.com.Workspace.SetConfigField(config.ScopeGlobal, "options.tui.theme", msg.Name); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+		} else {
+			cmds = append(cmds, util.ReportInfo("Theme set to "+msg.Name))
+		}
+		m.dialog.CloseDialog(dialog.ThemesID)
+	case dialog.ActionSelectAgent:
+		if m.isAgentBusy() {
+			cmds = append(cmds, util.ReportWarn("Agent is busy, please wait before switching agents..."))
+			break
+		}
+
+		cfg := m.com.Config()
+		if cfg == nil || cfg.Options == nil {
+			cmds = append(cmds, util.ReportError(errors.New("configuration not found")))
+			break
+		}
+		agentCfg, ok := cfg.Agents[msg.ID]
+		if !ok {
+			cmds = append(cmds, util.ReportError(fmt.Errorf("agent %q not found", msg.ID)))
+			break
+		}
+
+		cfg.Options.PrimaryAgent = msg.ID
+		if err := m.com.Workspace.SetConfigField(config.ScopeWorkspace, "options.primary_agent", msg.ID); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+			break
+		}
+
+		cmds = append(cmds, func() tea.Msg {
+			if err := m.com.Workspace.UpdateAgentModel(context.TODO()); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.NewInfoMsg("Switched to " + agentCfg.Name + " agent")
+		})
+		m.dialog.CloseDialog(dialog.AgentsID)
 	case dialog.ActionPermissionResponse:
 		m.dialog.CloseDialog(dialog.PermissionsID)
 		switch msg.Action {
@@ -1534,6 +1685,12 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.Workspace.PermissionDeny(msg.Permission)
 		}
 
+	case dialog.ActionElicitationResponse:
+		m.dialog.CloseDialog(dialog.ElicitationID)
+		if err := m.com.Workspace.MCPAnswerElicitation(msg.ID, msg.Response); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+		}
+
 	case dialog.ActionFilePickerSelected:
 		cmds = append(cmds, tea.Sequence(
 			msg.Cmd(),
@@ -1695,9 +1852,9 @@ func (m *UI) handleSelectModel(msg dialog.ActionSelectModel) tea.Cmd {
 	if err := m.com.Workspace.UpdatePreferredModel(config.ScopeGlobal, msg.ModelType, msg.Model); err != nil {
 		cmds = append(cmds, util.ReportError(err))
 	} else {
-		if msg.ModelType == config.SelectedModelTypeLarge {
+		if msg.ModelType == config.SelectedModelTypeLarge && m.com.Theme() == "" {
 			// Swap the theme live based on the newly selected large
-			// model's provider.
+			// model's provider, unless the user picked a theme.
 			m.applyTheme(styles.ThemeForProvider(providerID))
 		}
 		if _, ok := cfg.Models[config.SelectedModelTypeSmall]; !ok {
@@ -2131,6 +2288,19 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 						return deleteSelectedMessageMsg{messageID: id}
 					})
 				}
+			case key.Matches(msg, m.keyMap.Chat.Rewind), key.Matches(msg, m.keyMap.Chat.RewindForce):
+				if id := m.chat.SelectedMessageID(); id != "" {
+					force := key.Matches(msg, m.keyMap.Chat.RewindForce)
+					cmds = append(cmds, func() tea.Msg {
+						return rewindSelectedMessageMsg{messageID: id, force: force}
+					})
+				}
+			case key.Matches(msg, m.keyMap.Chat.Fork):
+				if id := m.chat.SelectedMessageID(); id != "" {
+					cmds = append(cmds, func() tea.Msg {
+						return forkSelectedMessageMsg{messageID: id}
+					})
+				}
 			default:
 				if ok, cmd := m.chat.HandleKeyMsg(msg); ok {
 					cmds = append(cmds, cmd)
@@ -2332,8 +2502,8 @@ func (m *UI) ShortHelp() []key.Binding {
 	k := &m.keyMap
 	tab := k.Tab
 	commands := k.Commands
-	if m.focus == uiFocusEditor && m.textarea.Value() == "" {
-		commands.SetHelp("/ or ctrl+p", "commands")
+	if m.focus == uiFocusEditor && m.textarea.Value() == "" && k.Editor.Commands.Enabled() {
+		commands.SetHelp(k.Editor.Commands.Help().Key+" or "+commands.Help().Key, "commands")
 	}
 
 	switch m.state {
@@ -2344,17 +2514,17 @@ func (m *UI) ShortHelp() []key.Binding {
 		if m.isAgentBusy() {
 			cancelBinding := k.Chat.Cancel
 			if m.isCanceling {
-				cancelBinding.SetHelp("esc", "press again to cancel")
+				cancelBinding.SetHelp(cancelBinding.Help().Key, "press again to cancel")
 			} else if m.com.Workspace.AgentQueuedPrompts(m.session.ID) > 0 {
-				cancelBinding.SetHelp("esc", "clear queue")
+				cancelBinding.SetHelp(cancelBinding.Help().Key, "clear queue")
 			}
 			binds = append(binds, cancelBinding)
 		}
 
 		if m.focus == uiFocusEditor {
-			tab.SetHelp("tab", "focus chat")
+			tab.SetHelp(tab.Help().Key, "focus chat")
 		} else {
-			tab.SetHelp("tab", "focus editor")
+			tab.SetHelp(tab.Help().Key, "focus editor")
 		}
 
 		binds = append(
@@ -2409,12 +2579,12 @@ func (m *UI) FullHelp() [][]key.Binding {
 	var binds [][]key.Binding
 	k := &m.keyMap
 	help := k.Help
-	help.SetHelp("ctrl+g", "less")
+	help.SetHelp(help.Help().Key, "less")
 	hasAttachments := len(m.attachments.List()) > 0
 	hasSession := m.hasSession()
 	commands := k.Commands
-	if m.focus == uiFocusEditor && m.textarea.Value() == "" {
-		commands.SetHelp("/ or ctrl+p", "commands")
+	if m.focus == uiFocusEditor && m.textarea.Value() == "" && k.Editor.Commands.Enabled() {
+		commands.SetHelp(k.Editor.Commands.Help().Key+" or "+commands.Help().Key, "commands")
 	}
 
 	switch m.state {
@@ -2428,9 +2598,9 @@ func (m *UI) FullHelp() [][]key.Binding {
 		if m.isAgentBusy() {
 			cancelBinding := k.Chat.Cancel
 			if m.isCanceling {
-				cancelBinding.SetHelp("esc", "press again to cancel")
+				cancelBinding.SetHelp(cancelBinding.Help().Key, "press again to cancel")
 			} else if m.com.Workspace.AgentQueuedPrompts(m.session.ID) > 0 {
-				cancelBinding.SetHelp("esc", "clear queue")
+				cancelBinding.SetHelp(cancelBinding.Help().Key, "clear queue")
 			}
 			binds = append(binds, []key.Binding{cancelBinding})
 		}
@@ -2438,9 +2608,9 @@ func (m *UI) FullHelp() [][]key.Binding {
 		mainBinds := []key.Binding{}
 		tab := k.Tab
 		if m.focus == uiFocusEditor {
-			tab.SetHelp("tab", "focus chat")
+			tab.SetHelp(tab.Help().Key, "focus chat")
 		} else {
-			tab.SetHelp("tab", "focus editor")
+			tab.SetHelp(tab.Help().Key, "focus editor")
 		}
 
 		mainBinds = append(
@@ -2496,6 +2666,8 @@ func (m *UI) FullHelp() [][]key.Binding {
 				[]key.Binding{
 					k.Chat.Copy,
 					k.Chat.ClearHighlight,
+					k.Chat.Rewind,
+					k.Chat.Fork,
 				},
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
@@ -2552,7 +2724,7 @@ func (m *UI) currentModelSupportsImages() bool {
 	if cfg == nil {
 		return false
 	}
-	agentCfg, ok := cfg.Agents[config.AgentCoder]
+	agentCfg, ok := cfg.PrimaryAgent()
 	if !ok {
 		return false
 	}
@@ -3334,6 +3506,14 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openReasoningDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.ThemesID:
+		if cmd := m.openThemesDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.AgentsID:
+		if cmd := m.openAgentsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case dialog.FilePickerID:
 		if cmd := m.openFilesDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -3346,6 +3526,10 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openPermissionRulesDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.CheckpointsID:
+		if cmd := m.openCheckpointsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	default:
 		// Unknown dialog
 		break
@@ -3427,6 +3611,33 @@ func (m *UI) openReasoningDialog() tea.Cmd {
 	return nil
 }
 
+// openThemesDialog opens the themes dialog.
+func (m *UI) openThemesDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.ThemesID) {
+		m.dialog.BringToFront(dialog.ThemesID)
+		return nil
+	}
+
+	m.dialog.OpenDialog(dialog.NewThemes(m.com))
+	return nil
+}
+
+// openAgentsDialog opens the agent selection dialog.
+func (m *UI) openAgentsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.AgentsID) {
+		m.dialog.BringToFront(dialog.AgentsID)
+		return nil
+	}
+
+	agentsDialog, err := dialog.NewAgents(m.com)
+	if err != nil {
+		return util.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(agentsDialog)
+	return nil
+}
+
 // openSessionsDialog opens the sessions dialog. If the dialog is already open,
 // it brings it to the front. Otherwise, it will list all the sessions and open
 // the dialog.
@@ -3482,6 +3693,26 @@ func (m *UI) openPermissionRulesDialog() tea.Cmd {
 	m.dialog.OpenDialog(rulesDialog)
 	return nil
 }
+
+// openCheckpointsDialog opens the checkpoints dialog of the current session.
+func (m *UI) openCheckpointsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.CheckpointsID) {
+		m.dialog.BringToFront(dialog.CheckpointsID)
+		return nil
+	}
+	if !m.hasSession() {
+		return nil
+	}
+
+	checkpointsDialog, err := dialog.NewCheckpoints(m.com, m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(checkpointsDialog)
+	return nil
+}
+
 // openPermissionsDialog opens the permissions dialog for a permission request.
 func (m *UI) openPermissionsDialog(perm permission.PermissionRequest) tea.Cmd {
 	// Close any existing permissions dialog first.
@@ -3514,6 +3745,22 @@ func (m *UI) handlePermissionNotification(notification permission.PermissionNoti
 	}
 }
 
+// closeElicitationDialog closes the dialog of the elicitation id, which is
+// no longer needed.
+func (m *UI) closeElicitationDialog(id string) {
+	if d, ok := m.dialog.Dialog(dialog.ElicitationID).(*dialog.Elicitation); ok && d.ElicitationID() == id {
+		m.dialog.CloseDialog(dialog.ElicitationID)
+	}
+}
+
+// handleMCPProgress shows the progress an MCP server reports on its tool
+// item.
+func (m *UI) handleMCPProgress(p mcp.Progress) {
+	if item, ok := m.chat.MessageItem(p.ToolCallID).(*chat.MCPToolMessageItem); ok {
+		item.SetProgress(p.Progress, p.Total, p.Message)
+	}
+}
+
 // handleAgentNotification translates domain agent events into desktop
 // notifications using the UI notification backend.
 func (m *UI) handleAgentNotification(n notify.Notification) tea.Cmd {
@@ -3530,6 +3777,13 @@ func (m *UI) handleAgentNotification(n notify.Notification) tea.Cmd {
 		return tea.Batch(cmds...)
 	case notify.TypeReAuthenticate:
 		return m.handleReAuthenticate(n.ProviderID)
+	case notify.TypeBudgetWarning:
+		return util.ReportWarn(n.Details)
+	case notify.TypeBudgetExceeded:
+		return m.sendNotification(notification.Notification{
+			Title:   "Crush stopped",
+			Message: fmt.Sprintf("Budget reached in \"%s\"", n.SessionTitle),
+		})
 	default:
 		return nil
 	}
@@ -3544,7 +3798,7 @@ func (m *UI) handleReAuthenticate(providerID string) tea.Cmd {
 	if !ok {
 		return nil
 	}
-	agentCfg, ok := cfg.Agents[config.AgentCoder]
+	agentCfg, ok := cfg.PrimaryAgent()
 	if !ok {
 		return nil
 	}
@@ -3924,6 +4178,118 @@ func (m *UI) deleteMessage(messageID string) tea.Cmd {
 		return nil
 	}
 }
+
+// previewRewind works out what rolling the current session's files back
+// to the state before the given message would change, for the user to
+// confirm. Unless force is set, files changed outside of Crush keep the
+// rewind from happening, and the user is told how to overwrite them.
+func (m *UI) previewRewind(messageID string, force bool) tea.Cmd {
+	if messageID == "" || !m.hasSession() {
+		return nil
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("Agent is busy, please wait before rewinding...")
+	}
+	sessionID := m.session.ID
+	return func() tea.Msg {
+		result, err := m.com.Workspace.RewindSession(context.Background(), sessionID, messageID, history.RewindOptions{
+			DryRun: true,
+		})
+		switch {
+		case err != nil:
+			return util.NewErrorMsg(err)
+		case len(result.Changes) == 0:
+			return util.NewInfoMsg("Nothing to rewind")
+		case !force && len(result.Conflicts()) > 0:
+			return rewindConflictMsg(m.keyMap.Chat.RewindForce, result.Conflicts())
+		}
+		return rewindPreviewedMsg{messageID: messageID, force: force, changes: result.Changes}
+	}
+}
+
+// rewindToMessage rolls the current session's files back to the state
+// before the given message. Files changed outside of Crush are overwritten
+// if force is set; otherwise they keep the rewind from happening, and the
+// user is told how to overwrite them.
+func (m *UI) rewindToMessage(messageID string, force bool) tea.Cmd {
+	if messageID == "" || !m.hasSession() {
+		return nil
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("Agent is busy, please wait before rewinding...")
+	}
+	sessionID := m.session.ID
+	return func() tea.Msg {
+		result, err := m.com.Workspace.RewindSession(context.Background(), sessionID, messageID, history.RewindOptions{
+			Force: force,
+		})
+		switch {
+		case errors.Is(err, history.ErrRewindConflict):
+			return rewindConflictMsg(m.keyMap.Chat.RewindForce, result.Conflicts())
+		case err != nil:
+			return util.NewErrorMsg(err)
+		case len(result.Changes) == 0:
+			return util.NewInfoMsg("Nothing to rewind")
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeSuccess,
+			Msg:  fmt.Sprintf("Rewound %d file(s)", len(result.Changes)),
+		}
+	}
+}
+
+// rewindConflictMsg warns that files were changed outside of Crush, and
+// how to overwrite them.
+func rewindConflictMsg(forceKey key.Binding, conflicts []string) util.InfoMsg {
+	return util.InfoMsg{
+		Type: util.InfoTypeWarn,
+		Msg: fmt.Sprintf(
+			"%d file(s) changed outside of Crush: %s. Press %s to overwrite them.",
+			len(conflicts), strings.Join(conflicts, ", "), forceKey.Help().Key,
+		),
+	}
+}
+
+// restoreCheckpoint brings the working tree back to how it was at the start
+// of a turn of a session.
+func (m *UI) restoreCheckpoint(sessionID string, number int) tea.Cmd {
+	if m.isAgentBusy() {
+		return util.ReportWarn("Agent is busy, please wait before restoring a checkpoint...")
+	}
+	return func() tea.Msg {
+		changes, err := m.com.Workspace.RestoreCheckpoint(context.Background(), sessionID, number)
+		switch {
+		case err != nil:
+			return util.NewErrorMsg(err)
+		case len(changes) == 0:
+			return util.NewInfoMsg("Nothing to restore")
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeSuccess,
+			Msg:  fmt.Sprintf("Restored %d file(s) to checkpoint %d", len(changes), number),
+		}
+	}
+}
+
+// forkAtMessage branches a new session off the current one at the given
+// message and switches to it. The current session is left as it is.
+func (m *UI) forkAtMessage(messageID string) tea.Cmd {
+	if messageID == "" || !m.hasSession() {
+		return nil
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("Agent is busy, please wait before forking...")
+	}
+	sessionID := m.session.ID
+	return func() tea.Msg {
+		forked, err := m.com.Workspace.ForkSession(context.Background(), sessionID, messageID)
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return sessionForkedMsg{session: forked}
+	}
+}
+
 func (m *UI) enableDockerMCP() tea.Msg {
 	ctx := context.Background()
 	if err := m.com.Workspace.EnableDockerMCP(ctx); err != nil {
@@ -19,89 +19,165 @@ const (
 type viState struct {
 	enabled bool
 	mode    viMode
+	keyMap  viKeyMap
 
-	// pending stores a partial normal-mode command (e.g., "d" waiting for
-	// a motion, or "g" waiting for another "g").
-	pending string
+	// pending stores the key of a partial normal-mode command (e.g., "d"
+	// waiting for a motion, or "g" waiting for another "g"), and pendingOp
+	// the command.
+	pending   string
+	pendingOp viOp
 
 	// baseCursorShape is the cursor shape configured by the theme, used in
 	// insert mode. Normal mode always uses CursorBlock.
 	baseCursorShape tea.CursorShape
 }
 
+// viOp is a normal-mode command waiting for a second key.
+type viOp uint8
+
+const (
+	viOpNone viOp = iota
+	viOpDelete
+	viOpGoto
+)
+
+// viKeyMap defines the key bindings of vi normal mode.
+type viKeyMap struct {
+	// Mode switching.
+	Insert          key.Binding
+	InsertLineStart key.Binding
+	Append          key.Binding
+	AppendLineEnd   key.Binding
+	OpenBelow       key.Binding
+	OpenAbove       key.Binding
+
+	// Movement. The motions also complete the delete command.
+	Left         key.Binding
+	Right        key.Binding
+	Down         key.Binding
+	Up           key.Binding
+	WordForward  key.Binding
+	WordBackward key.Binding
+	WordEnd      key.Binding
+	LineStart    key.Binding
+	LineEnd      key.Binding
+
+	// Document movement. Goto followed by itself moves to the beginning.
+	DocumentEnd key.Binding
+	Goto        key.Binding
+
+	// Editing. Delete followed by itself deletes the line.
+	DeleteChar     key.Binding
+	Delete         key.Binding
+	ChangeToEnd    key.Binding
+	DeleteToEnd    key.Binding
+	Substitute     key.Binding
+	SubstituteLine key.Binding
+}
+
+func defaultViKeyMap() viKeyMap {
+	return viKeyMap{
+		Insert:          key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "insert")),
+		InsertLineStart: key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "insert at line start")),
+		Append:          key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "append")),
+		AppendLineEnd:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "append at line end")),
+		OpenBelow:       key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open line below")),
+		OpenAbove:       key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "open line above")),
+
+		Left:         key.NewBinding(key.WithKeys("h", "left"), key.WithHelp("h", "left")),
+		Right:        key.NewBinding(key.WithKeys("l", "right"), key.WithHelp("l", "right")),
+		Down:         key.NewBinding(key.WithKeys("j", "down"), key.WithHelp("j", "down")),
+		Up:           key.NewBinding(key.WithKeys("k", "up"), key.WithHelp("k", "up")),
+		WordForward:  key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "next word")),
+		WordBackward: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "previous word")),
+		WordEnd:      key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "end of word")),
+		LineStart:    key.NewBinding(key.WithKeys("0", "home"), key.WithHelp("0", "line start")),
+		LineEnd:      key.NewBinding(key.WithKeys("$", "end"), key.WithHelp("$", "line end")),
+
+		DocumentEnd: key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "end of text")),
+		Goto:        key.NewBinding(key.WithKeys("g"), key.WithHelp("gg", "start of text")),
+
+		DeleteChar:     key.NewBinding(key.WithKeys("x", "delete"), key.WithHelp("x", "delete character")),
+		Delete:         key.NewBinding(key.WithKeys("d"), key.WithHelp("d{motion}", "delete")),
+		ChangeToEnd:    key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "change to line end")),
+		DeleteToEnd:    key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "delete to line end")),
+		Substitute:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "substitute character")),
+		SubstituteLine: key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "substitute line")),
+	}
+}
+
 // viHandleNormalKey processes a keypress in vi normal mode. Returns true if
 // the key was consumed.
 func (m *UI) viHandleNormalKey(msg tea.KeyPressMsg) (consumed bool, cmd tea.Cmd) {
-	k := msg.String()
-
 	// Handle pending commands first.
 	if m.vi.pending != "" {
-		return m.viHandlePending(k)
+		return m.viHandlePending(msg)
 	}
 
+	k := &m.vi.keyMap
 	switch {
 	// Mode switching.
-	case k == "i":
+	case key.Matches(msg, k.Insert):
 		m.viEnterInsert()
-	case k == "I":
+	case key.Matches(msg, k.InsertLineStart):
 		m.textarea.CursorStart()
 		m.viEnterInsert()
-	case k == "a":
+	case key.Matches(msg, k.Append):
 		m.viCursorRight()
 		m.viEnterInsert()
-	case k == "A":
+	case key.Matches(msg, k.AppendLineEnd):
 		m.textarea.CursorEnd()
 		m.viEnterInsert()
-	case k == "o":
+	case key.Matches(msg, k.OpenBelow):
 		m.textarea.CursorEnd()
 		m.textarea.InsertRune('\n')
 		m.viEnterInsert()
-	case k == "O":
+	case key.Matches(msg, k.OpenAbove):
 		m.textarea.CursorStart()
 		m.textarea.InsertRune('\n')
 		m.textarea.CursorUp()
 		m.viEnterInsert()
 
 	// Movement.
-	case k == "h" || k == "left":
+	case key.Matches(msg, k.Left):
 		m.viCursorLeft()
-	case k == "l" || k == "right":
+	case key.Matches(msg, k.Right):
 		m.viCursorRight()
-	case k == "j" || k == "down":
+	case key.Matches(msg, k.Down):
 		m.textarea.CursorDown()
-	case k == "k" || k == "up":
+	case key.Matches(msg, k.Up):
 		m.textarea.CursorUp()
-	case k == "w":
+	case key.Matches(msg, k.WordForward):
 		m.viWordForward()
-	case k == "b":
+	case key.Matches(msg, k.WordBackward):
 		m.viWordBackward()
-	case k == "e":
+	case key.Matches(msg, k.WordEnd):
 		m.viWordEnd()
-	case k == "0" || k == "home":
+	case key.Matches(msg, k.LineStart):
 		m.textarea.CursorStart()
-	case k == "$" || k == "end":
+	case key.Matches(msg, k.LineEnd):
 		m.textarea.CursorEnd()
 
 	// Document movement.
-	case k == "G":
+	case key.Matches(msg, k.DocumentEnd):
 		m.textarea.MoveToEnd()
-	case k == "g":
-		m.vi.pending = "g"
+	case key.Matches(msg, k.Goto):
+		m.vi.pending, m.vi.pendingOp = msg.String(), viOpGoto
 
 	// Editing.
-	case k == "x" || k == "delete":
+	case key.Matches(msg, k.DeleteChar):
 		m.viDeleteCharForward()
-	case k == "d":
-		m.vi.pending = "d"
-	case k == "C":
+	case key.Matches(msg, k.Delete):
+		m.vi.pending, m.vi.pendingOp = msg.String(), viOpDelete
+	case key.Matches(msg, k.ChangeToEnd):
 		m.viDeleteToEnd()
 		m.viEnterInsert()
-	case k == "D":
+	case key.Matches(msg, k.DeleteToEnd):
 		m.viDeleteToEnd()
-	case k == "s":
+	case key.Matches(msg, k.Substitute):
 		m.viDeleteCharForward()
 		m.viEnterInsert()
-	case k == "S":
+	case key.Matches(msg, k.SubstituteLine):
 		m.viDeleteLine()
 		m.viEnterInsert()
 
@@ -113,30 +189,26 @@ func (m *UI) viHandleNormalKey(msg tea.KeyPressMsg) (consumed bool, cmd tea.Cmd)
 }
 
 // viHandlePending handles the second key of a two-key command.
-func (m *UI) viHandlePending(k string) (bool, tea.Cmd) {
-	pending := m.vi.pending
-	m.vi.pending = ""
-
-	switch pending {
-	case "d":
-		switch k {
-		case "d":
+func (m *UI) viHandlePending(msg tea.KeyPressMsg) (bool, tea.Cmd) {
+	op := m.vi.pendingOp
+	m.vi.pending, m.vi.pendingOp = "", viOpNone
+
+	k := &m.vi.keyMap
+	switch op {
+	case viOpDelete:
+		switch {
+		case key.Matches(msg, k.Delete):
 			m.viDeleteLine()
-		case "w":
+		case key.Matches(msg, k.WordForward):
 			m.viDeleteWord()
-		case "$":
+		case key.Matches(msg, k.LineEnd):
 			m.viDeleteToEnd()
-		case "0":
+		case key.Matches(msg, k.LineStart):
 			m.viDeleteToStart()
-		default:
-			return true, nil
 		}
-	case "g":
-		switch k {
-		case "g":
+	case viOpGoto:
+		if key.Matches(msg, k.Goto) {
 			m.textarea.MoveToBegin()
-		default:
-			return true, nil
 		}
 	}
 
@@ -152,7 +224,7 @@ func (m *UI) viEnterInsert() {
 // viEnterNormal switches to normal mode and updates the cursor shape.
 func (m *UI) viEnterNormal() {
 	m.vi.mode = viNormal
-	m.vi.pending = ""
+	m.vi.pending, m.vi.pendingOp = "", viOpNone
 	m.viUpdateCursor()
 }
 
@@ -9,11 +9,14 @@ import (
 // IsobitStyles returns the isobit theme styles, built on top of the quickStyle
 // system with darker backgrounds and blue accents.
 func IsobitStyles() Styles {
-	blue := lipgloss.Color("#2475f4")
+	s, _ := ThemeStyles(ThemeIsobit)
+	return s
+}
 
-	s := quickStyle(quickStyleOpts{
+func isobitPalette() quickStyleOpts {
+	return quickStyleOpts{
 		primary:   charmtone.Charple,
-		secondary: blue,
+		secondary: lipgloss.Color("#2475f4"),
 		accent:    charmtone.Bok,
 		keyword:   charmtone.Blush,
 
@@ -42,11 +45,58 @@ func IsobitStyles() Styles {
 		success:           charmtone.Julep,
 		successMoreSubtle: charmtone.Bok,
 		successMostSubtle: charmtone.Guac,
-	})
+	}
+}
+
+// isobitLightPalette is the isobit palette for light terminals.
+func isobitLightPalette() quickStyleOpts {
+	return quickStyleOpts{
+		primary:   charmtone.Charple,
+		secondary: lipgloss.Color("#2475f4"),
+		accent:    charmtone.Pickle,
+		keyword:   charmtone.Urchin,
+
+		fgBase:       charmtone.Pepper,
+		fgSubtle:     charmtone.Charcoal,
+		fgMoreSubtle: charmtone.Oyster,
+		fgMostSubtle: charmtone.Squid,
+
+		onPrimary: charmtone.Butter,
 
-	s.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(blue)
+		bgBase:         lipgloss.Color("#ffffff"),
+		bgLeastVisible: lipgloss.Color("#f2f2f2"),
+		bgLessVisible:  lipgloss.Color("#e6e6e6"),
+		bgMostVisible:  lipgloss.Color("#d9d9d9"),
+
+		separator: charmtone.Smoke,
+
+		destructive:       charmtone.Sriracha,
+		error:             charmtone.Pom,
+		warningSubtle:     charmtone.Cumin,
+		warning:           lipgloss.Color("#c77c02"),
+		busy:              charmtone.Tang,
+		info:              charmtone.Damson,
+		infoMoreSubtle:    charmtone.Oceania,
+		infoMostSubtle:    charmtone.Anchovy,
+		success:           charmtone.Pickle,
+		successMoreSubtle: charmtone.NeueGuac,
+		successMostSubtle: charmtone.NeueZinc,
+
+		link:  charmtone.Oceania,
+		image: charmtone.Macaron,
+
+		diffInsert:             lipgloss.Color("#2e7d32"),
+		diffInsertBg:           lipgloss.Color("#e6f4e6"),
+		diffInsertLineNumberBg: lipgloss.Color("#d7ecd7"),
+		diffDelete:             lipgloss.Color("#b3261e"),
+		diffDeleteBg:           lipgloss.Color("#fbe9e9"),
+		diffDeleteLineNumberBg: lipgloss.Color("#f5d7d7"),
+	}
+}
+
+// finishIsobit sets the selection and cursors of the isobit themes.
+func finishIsobit(s *Styles, o quickStyleOpts) {
+	s.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(o.secondary)
 	s.TextInput.Cursor.Shape = tea.CursorBar
 	s.Editor.Textarea.Cursor.Shape = tea.CursorBar
-
-	return s
 }
@@ -54,6 +54,42 @@ type quickStyleOpts struct {
 	success           color.Color
 	successMoreSubtle color.Color
 	successMostSubtle color.Color
+
+	// Markdown links and images. They default to the Charmtone ones.
+	link  color.Color
+	image color.Color
+
+	// Diff lines. They default to muted greens and reds on dark
+	// backgrounds.
+	diffInsert             color.Color
+	diffInsertBg           color.Color
+	diffInsertLineNumberBg color.Color
+	diffDelete             color.Color
+	diffDeleteBg           color.Color
+	diffDeleteLineNumberBg color.Color
+}
+
+// withDefaults returns o with the colors that have defaults set to them,
+// unless they are set already.
+func (o quickStyleOpts) withDefaults() quickStyleOpts {
+	for _, c := range []struct {
+		color *color.Color
+		def   color.Color
+	}{
+		{&o.link, charmtone.Zinc},
+		{&o.image, charmtone.Cheeky},
+		{&o.diffInsert, lipgloss.Color("#629657")},
+		{&o.diffInsertBg, lipgloss.Color("#323931")},
+		{&o.diffInsertLineNumberBg, lipgloss.Color("#2b322a")},
+		{&o.diffDelete, lipgloss.Color("#a45c59")},
+		{&o.diffDeleteBg, lipgloss.Color("#383030")},
+		{&o.diffDeleteLineNumberBg, lipgloss.Color("#312929")},
+	} {
+		if *c.color == nil {
+			*c.color = c.def
+		}
+	}
+	return o
 }
 
 // quickStyle builds the default Styles (that is, the default theme, Charmtone
@@ -62,6 +98,7 @@ type quickStyleOpts struct {
 // The idea here is that you can do most of the work on a theme with quickStyle,
 // then add overrides as needed.
 func quickStyle(o quickStyleOpts) Styles {
+	o = o.withDefaults()
 	var (
 		base   = lipgloss.NewStyle().Foreground(o.fgBase)
 		muted  = lipgloss.NewStyle().Foreground(o.fgMoreSubtle)
@@ -207,7 +244,7 @@ func quickStyle(o quickStyleOpts) Styles {
 			Unticked:       "[ ] ",
 		},
 		Link: ansi.StylePrimitive{
-			Color:     hex(charmtone.Zinc),
+			Color:     hex(o.link),
 			Underline: new(true),
 		},
 		LinkText: ansi.StylePrimitive{
@@ -215,7 +252,7 @@ func quickStyle(o quickStyleOpts) Styles {
 			Bold:  new(true),
 		},
 		Image: ansi.StylePrimitive{
-			Color:     hex(charmtone.Cheeky),
+			Color:     hex(o.image),
 			Underline: new(true),
 		},
 		ImageText: ansi.StylePrimitive{
@@ -529,23 +566,23 @@ func quickStyle(o quickStyleOpts) Styles {
 		},
 		InsertLine: diffview.LineStyle{
 			LineNumber: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#629657")).
-				Background(lipgloss.Color("#2b322a")),
+				Foreground(o.diffInsert).
+				Background(o.diffInsertLineNumberBg),
 			Symbol: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#629657")).
-				Background(lipgloss.Color("#323931")),
+				Foreground(o.diffInsert).
+				Background(o.diffInsertBg),
 			Code: lipgloss.NewStyle().
-				Background(lipgloss.Color("#323931")),
+				Background(o.diffInsertBg),
 		},
 		DeleteLine: diffview.LineStyle{
 			LineNumber: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#a45c59")).
-				Background(lipgloss.Color("#312929")),
+				Foreground(o.diffDelete).
+				Background(o.diffDeleteLineNumberBg),
 			Symbol: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#a45c59")).
-				Background(lipgloss.Color("#383030")),
+				Foreground(o.diffDelete).
+				Background(o.diffDeleteBg),
 			Code: lipgloss.NewStyle().
-				Background(lipgloss.Color("#383030")),
+				Background(o.diffDeleteBg),
 		},
 		Filename: diffview.LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -839,6 +876,11 @@ func quickStyle(o quickStyleOpts) Styles {
 	// Dialog.Quit
 	s.Dialog.Quit.Content = lipgloss.NewStyle().Foreground(o.fgBase)
 	s.Dialog.Quit.Frame = lipgloss.NewStyle().BorderForeground(o.primary).Border(lipgloss.RoundedBorder()).Padding(1, 2)
+
+	// Dialog.Rewind
+	s.Dialog.Rewind.Content = lipgloss.NewStyle().Foreground(o.fgBase)
+	s.Dialog.Rewind.Files = lipgloss.NewStyle().Foreground(o.fgMoreSubtle).AlignHorizontal(lipgloss.Left)
+	s.Dialog.Rewind.Frame = lipgloss.NewStyle().BorderForeground(o.primary).Border(lipgloss.RoundedBorder()).Padding(1, 2)
 	s.Dialog.View = base.Border(lipgloss.RoundedBorder()).BorderForeground(o.primary)
 	s.Dialog.PrimaryText = base.Padding(0, 1).Foreground(o.primary)
 	s.Dialog.SecondaryText = base.Padding(0, 1).Foreground(o.fgMostSubtle)
@@ -431,6 +431,12 @@ type Styles struct {
 			Frame   lipgloss.Style // Outer rounded border framing the quit dialog
 		}
 
+		Rewind struct {
+			Content lipgloss.Style // Wrapper for the rewind dialog's inner content
+			Files   lipgloss.Style // List of the files the rewind changes
+			Frame   lipgloss.Style // Outer rounded border framing the rewind dialog
+		}
+
 		APIKey struct {
 			Spinner lipgloss.Style // Loading spinner while validating the key
 		}
@@ -1,57 +1,370 @@
 package styles
 
-import "github.com/charmbracelet/x/exp/charmtone"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/glamour/v2/ansi"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/exp/charmtone"
+)
+
+// Names of the built-in themes.
+const (
+	ThemeIsobit         = "isobit"
+	ThemeIsobitLight    = "isobit-light"
+	ThemeCharmtone      = "charmtone"
+	ThemeCharmtoneLight = "charmtone-light"
+	ThemeHypercrush     = "hypercrush"
+)
+
+// DefaultTheme is the theme used when none is configured.
+const DefaultTheme = ThemeIsobit
+
+// ThemeFile is the format of theme files, which are JSON files named after
+// their theme.
+type ThemeFile struct {
+	// Name is the name of the theme. It defaults to the name of the file
+	// without its extension.
+	Name string `json:"name,omitempty"`
+	// Extends is the theme this one starts from. It defaults to
+	// [DefaultTheme].
+	Extends string `json:"extends,omitempty"`
+	// Colors replaces colors of the palette by role, such as "primary" or
+	// "bg_base", as "#rrggbb".
+	Colors map[string]string `json:"colors,omitempty"`
+	// Syntax replaces how the syntax highlighting styles tokens, by chroma
+	// token type, such as "keyword" or "literal_string". Styles are
+	// space-separated "#rrggbb" foregrounds, "bg:#rrggbb" backgrounds,
+	// "bold", "italic", and "underline".
+	Syntax map[string]string `json:"syntax,omitempty"`
+}
+
+// theme is what the styles of a theme are built from.
+type theme struct {
+	palette quickStyleOpts
+	// syntax replaces the styles of tokens, as in [ThemeFile].
+	syntax map[string]string
+	// finish makes last changes to the styles built from the palette.
+	finish func(*Styles, quickStyleOpts)
+}
+
+func (t *theme) styles() Styles {
+	s := quickStyle(t.palette)
+	for name, style := range t.syntax {
+		// Both were checked when the theme was loaded.
+		prim, _ := parseSyntaxStyle(style)
+		*syntaxTokens[name](s.Markdown.CodeBlock.Chroma) = prim
+	}
+	if t.finish != nil {
+		t.finish(&s, t.palette.withDefaults())
+	}
+	return s
+}
+
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]*theme{
+		ThemeIsobit:         {palette: isobitPalette(), finish: finishIsobit},
+		ThemeIsobitLight:    {palette: isobitLightPalette(), syntax: lightSyntax, finish: finishIsobit},
+		ThemeCharmtone:      {palette: charmtonePalette()},
+		ThemeCharmtoneLight: {palette: charmtoneLightPalette(), syntax: lightSyntax},
+		ThemeHypercrush:     {palette: hypercrushPalette()},
+	}
+)
+
+// ThemeNames returns the names of the available themes, sorted.
+func ThemeNames() []string {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ThemeStyles returns the styles of the named theme, and whether there is
+// such a theme.
+func ThemeStyles(name string) (Styles, bool) {
+	themesMu.RLock()
+	t, ok := themes[name]
+	themesMu.RUnlock()
+	if !ok {
+		return Styles{}, false
+	}
+	return t.styles(), true
+}
 
 // ThemeForProvider returns the Styles associated with the given provider
-// ID. Unknown or empty provider IDs yield the default Charmtone Pantera
-// theme.
+// ID. Unknown or empty provider IDs yield the default theme.
 func ThemeForProvider(_ string) Styles {
-	return IsobitStyles()
+	s, _ := ThemeStyles(DefaultTheme)
+	return s
 }
 
-// CharmtonePantera returns the Charmtone dark theme. It's the default style
-// for the UI.
-func CharmtonePantera() Styles {
-	return quickStyle(quickStyleOpts{
-		primary:   charmtone.Charple,
-		secondary: charmtone.Dolly,
-		accent:    charmtone.Bok,
-		keyword:   charmtone.Blush,
+// LoadThemes loads the themes of the JSON files of dirs, skipping the ones
+// that don't exist. Themes of later directories replace the ones of the
+// same name of earlier directories, and any theme can replace a built-in
+// one. The errors of the files that couldn't be loaded are joined; the
+// other themes are loaded regardless.
+func LoadThemes(dirs ...string) error {
+	var errs []error
+	files := map[string]*ThemeFile{}
+	paths := map[string]string{}
+	for _, dir := range dirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, path := range matches {
+			f, err := readThemeFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("theme %s: %w", path, err))
+				continue
+			}
+			files[f.Name] = f
+			paths[f.Name] = path
+		}
+	}
 
-		fgBase:       charmtone.Ash,
-		fgMoreSubtle: charmtone.Squid,
-		fgSubtle:     charmtone.Smoke,
-		fgMostSubtle: charmtone.Oyster,
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	resolved := map[string]*theme{}
+	resolving := map[string]bool{}
+	var resolve func(name string) (*theme, error)
+	resolve = func(name string) (*theme, error) {
+		if t, ok := resolved[name]; ok {
+			return t, nil
+		}
+		f, ok := files[name]
+		if !ok || resolving[name] {
+			// A theme extending one of the same name starts from the one
+			// it replaces.
+			if t, ok := themes[name]; ok {
+				return t, nil
+			}
+			return nil, fmt.Errorf("unknown theme %q", name)
+		}
+		resolving[name] = true
+		defer delete(resolving, name)
+		base, err := resolve(f.Extends)
+		if err != nil {
+			return nil, err
+		}
+		t, err := f.theme(base)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = t
+		return t, nil
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if _, err := resolve(name); err != nil {
+			errs = append(errs, fmt.Errorf("theme %s: %w", paths[name], err))
+		}
+	}
+	for name, t := range resolved {
+		themes[name] = t
+	}
+	return errors.Join(errs...)
+}
 
-		onPrimary: charmtone.Butter,
+func readThemeFile(path string) (*ThemeFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f ThemeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if f.Extends == "" {
+		f.Extends = DefaultTheme
+	}
+	return &f, nil
+}
 
-		bgBase:         charmtone.Pepper,
-		bgLeastVisible: charmtone.BBQ,
-		bgLessVisible:  charmtone.Charcoal,
-		bgMostVisible:  charmtone.Iron,
+// theme returns the theme of f, starting from base.
+func (f *ThemeFile) theme(base *theme) (*theme, error) {
+	t := &theme{
+		palette: base.palette,
+		syntax:  map[string]string{},
+		finish:  base.finish,
+	}
+	for role, value := range f.Colors {
+		field, ok := paletteRoles[role]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", role)
+		}
+		c, err := parseColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("color %q: %w", role, err)
+		}
+		*field(&t.palette) = c
+	}
+	for name, style := range base.syntax {
+		t.syntax[name] = style
+	}
+	for name, style := range f.Syntax {
+		if _, ok := syntaxTokens[name]; !ok {
+			return nil, fmt.Errorf("unknown syntax token %q", name)
+		}
+		if _, err := parseSyntaxStyle(style); err != nil {
+			return nil, fmt.Errorf("syntax token %q: %w", name, err)
+		}
+		t.syntax[name] = style
+	}
+	return t, nil
+}
 
-		separator: charmtone.Charcoal,
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
 
-		destructive:       charmtone.Coral,
-		error:             charmtone.Sriracha,
-		warningSubtle:     charmtone.Zest,
-		warning:           charmtone.Mustard,
-		busy:              charmtone.Citron,
-		info:              charmtone.Malibu,
-		infoMoreSubtle:    charmtone.Sardine,
-		infoMostSubtle:    charmtone.Damson,
-		success:           charmtone.Julep,
-		successMoreSubtle: charmtone.Bok,
-		successMostSubtle: charmtone.Guac,
-	})
+func parseColor(s string) (color.Color, error) {
+	if !hexColor.MatchString(s) {
+		return nil, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	return lipgloss.Color(s), nil
+}
+
+// parseSyntaxStyle parses a style of [ThemeFile.Syntax].
+func parseSyntaxStyle(s string) (ansi.StylePrimitive, error) {
+	var prim ansi.StylePrimitive
+	for field := range strings.FieldsSeq(s) {
+		switch {
+		case field == "bold":
+			prim.Bold = new(true)
+		case field == "italic":
+			prim.Italic = new(true)
+		case field == "underline":
+			prim.Underline = new(true)
+		case strings.HasPrefix(field, "bg:"):
+			c, err := parseColor(strings.TrimPrefix(field, "bg:"))
+			if err != nil {
+				return prim, err
+			}
+			prim.BackgroundColor = hex(c)
+		default:
+			c, err := parseColor(field)
+			if err != nil {
+				return prim, err
+			}
+			prim.Color = hex(c)
+		}
+	}
+	return prim, nil
+}
+
+// paletteRoles are the colors of the palette by their names in theme
+// files.
+var paletteRoles = map[string]func(*quickStyleOpts) *color.Color{
+	"primary":                    func(o *quickStyleOpts) *color.Color { return &o.primary },
+	"secondary":                  func(o *quickStyleOpts) *color.Color { return &o.secondary },
+	"accent":                     func(o *quickStyleOpts) *color.Color { return &o.accent },
+	"keyword":                    func(o *quickStyleOpts) *color.Color { return &o.keyword },
+	"fg_base":                    func(o *quickStyleOpts) *color.Color { return &o.fgBase },
+	"bg_base":                    func(o *quickStyleOpts) *color.Color { return &o.bgBase },
+	"separator":                  func(o *quickStyleOpts) *color.Color { return &o.separator },
+	"fg_subtle":                  func(o *quickStyleOpts) *color.Color { return &o.fgSubtle },
+	"fg_more_subtle":             func(o *quickStyleOpts) *color.Color { return &o.fgMoreSubtle },
+	"fg_most_subtle":             func(o *quickStyleOpts) *color.Color { return &o.fgMostSubtle },
+	"on_primary":                 func(o *quickStyleOpts) *color.Color { return &o.onPrimary },
+	"bg_most_visible":            func(o *quickStyleOpts) *color.Color { return &o.bgMostVisible },
+	"bg_less_visible":            func(o *quickStyleOpts) *color.Color { return &o.bgLessVisible },
+	"bg_least_visible":           func(o *quickStyleOpts) *color.Color { return &o.bgLeastVisible },
+	"destructive":                func(o *quickStyleOpts) *color.Color { return &o.destructive },
+	"error":                      func(o *quickStyleOpts) *color.Color { return &o.error },
+	"warning":                    func(o *quickStyleOpts) *color.Color { return &o.warning },
+	"warning_subtle":             func(o *quickStyleOpts) *color.Color { return &o.warningSubtle },
+	"busy":                       func(o *quickStyleOpts) *color.Color { return &o.busy },
+	"info":                       func(o *quickStyleOpts) *color.Color { return &o.info },
+	"info_more_subtle":           func(o *quickStyleOpts) *color.Color { return &o.infoMoreSubtle },
+	"info_most_subtle":           func(o *quickStyleOpts) *color.Color { return &o.infoMostSubtle },
+	"success":                    func(o *quickStyleOpts) *color.Color { return &o.success },
+	"success_more_subtle":        func(o *quickStyleOpts) *color.Color { return &o.successMoreSubtle },
+	"success_most_subtle":        func(o *quickStyleOpts) *color.Color { return &o.successMostSubtle },
+	"link":                       func(o *quickStyleOpts) *color.Color { return &o.link },
+	"image":                      func(o *quickStyleOpts) *color.Color { return &o.image },
+	"diff_insert":                func(o *quickStyleOpts) *color.Color { return &o.diffInsert },
+	"diff_insert_bg":             func(o *quickStyleOpts) *color.Color { return &o.diffInsertBg },
+	"diff_insert_line_number_bg": func(o *quickStyleOpts) *color.Color { return &o.diffInsertLineNumberBg },
+	"diff_delete":                func(o *quickStyleOpts) *color.Color { return &o.diffDelete },
+	"diff_delete_bg":             func(o *quickStyleOpts) *color.Color { return &o.diffDeleteBg },
+	"diff_delete_line_number_bg": func(o *quickStyleOpts) *color.Color { return &o.diffDeleteLineNumberBg },
+}
+
+// syntaxTokens are the styles of the syntax highlighting by the names of
+// their chroma token types in theme files.
+var syntaxTokens = map[string]func(*ansi.Chroma) *ansi.StylePrimitive{
+	"text":                  func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Text },
+	"error":                 func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Error },
+	"comment":               func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Comment },
+	"comment_preproc":       func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.CommentPreproc },
+	"keyword":               func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Keyword },
+	"keyword_reserved":      func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.KeywordReserved },
+	"keyword_namespace":     func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.KeywordNamespace },
+	"keyword_type":          func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.KeywordType },
+	"operator":              func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Operator },
+	"punctuation":           func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Punctuation },
+	"name":                  func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Name },
+	"name_builtin":          func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameBuiltin },
+	"name_tag":              func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameTag },
+	"name_attribute":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameAttribute },
+	"name_class":            func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameClass },
+	"name_constant":         func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameConstant },
+	"name_decorator":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameDecorator },
+	"name_exception":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameException },
+	"name_function":         func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameFunction },
+	"name_other":            func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.NameOther },
+	"literal":               func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Literal },
+	"literal_number":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.LiteralNumber },
+	"literal_date":          func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.LiteralDate },
+	"literal_string":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.LiteralString },
+	"literal_string_escape": func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.LiteralStringEscape },
+	"generic_deleted":       func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.GenericDeleted },
+	"generic_emph":          func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.GenericEmph },
+	"generic_inserted":      func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.GenericInserted },
+	"generic_strong":        func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.GenericStrong },
+	"generic_subheading":    func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.GenericSubheading },
+	"background":            func(c *ansi.Chroma) *ansi.StylePrimitive { return &c.Background },
+}
+
+// CharmtonePantera returns the Charmtone dark theme.
+func CharmtonePantera() Styles {
+	s, _ := ThemeStyles(ThemeCharmtone)
+	return s
 }
 
 // HypercrushObsidiana returns the Hypercrush dark theme.
 func HypercrushObsidiana() Styles {
-	return quickStyle(quickStyleOpts{
+	s, _ := ThemeStyles(ThemeHypercrush)
+	return s
+}
+
+func charmtonePalette() quickStyleOpts {
+	return quickStyleOpts{
 		primary:   charmtone.Charple,
 		secondary: charmtone.Dolly,
 		accent:    charmtone.Bok,
+		keyword:   charmtone.Blush,
 
 		fgBase:       charmtone.Ash,
 		fgMoreSubtle: charmtone.Squid,
@@ -78,5 +391,39 @@ func HypercrushObsidiana() Styles {
 		success:           charmtone.Julep,
 		successMoreSubtle: charmtone.Bok,
 		successMostSubtle: charmtone.Guac,
-	})
+	}
+}
+
+func hypercrushPalette() quickStyleOpts {
+	o := charmtonePalette()
+	o.keyword = nil
+	return o
+}
+
+// charmtoneLightPalette is the Charmtone palette for light terminals:
+// darker foregrounds on warm, light backgrounds.
+func charmtoneLightPalette() quickStyleOpts {
+	o := isobitLightPalette()
+	o.secondary = charmtone.Urchin
+	o.bgBase = charmtone.Butter
+	o.bgLeastVisible = lipgloss.Color("#f6f0e6")
+	o.bgLessVisible = lipgloss.Color("#ede6da")
+	o.bgMostVisible = lipgloss.Color("#e3dbce")
+	return o
+}
+
+// lightSyntax replaces the syntax highlighting colors that don't come from
+// the palette and are too light for light backgrounds.
+var lightSyntax = map[string]string{
+	"comment_preproc":   charmtone.Paprika.Hex(),
+	"keyword_reserved":  charmtone.Macaron.Hex(),
+	"keyword_namespace": charmtone.Macaron.Hex(),
+	"keyword_type":      charmtone.Ox.Hex(),
+	"operator":          charmtone.Chili.Hex(),
+	"name_builtin":      charmtone.Urchin.Hex(),
+	"name_tag":          charmtone.Prince.Hex(),
+	"name_attribute":    charmtone.Grape.Hex(),
+	"name_class":        charmtone.Pepper.Hex() + " bold underline",
+	"name_decorator":    charmtone.Tang.Hex(),
+	"literal_string":    "#9a6b2f",
 }
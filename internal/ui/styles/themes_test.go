@@ -0,0 +1,106 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTheme(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{ThemeIsobit, ThemeIsobitLight, ThemeCharmtone, ThemeCharmtoneLight, ThemeHypercrush} {
+		require.Contains(t, ThemeNames(), name)
+		_, ok := ThemeStyles(name)
+		require.True(t, ok, name)
+	}
+	_, ok := ThemeStyles("nope")
+	require.False(t, ok)
+}
+
+func TestLoadThemes(t *testing.T) {
+	t.Parallel()
+
+	user := t.TempDir()
+	project := t.TempDir()
+	writeTheme(t, user, "test-load-base.json", `{
+		"colors": {"primary": "#112233", "bg_base": "#fafafa"},
+		"syntax": {"keyword": "#445566 bold"}
+	}`)
+	writeTheme(t, user, "test-load-child.json", `{
+		"extends": "test-load-base",
+		"colors": {"primary": "#aabbcc"}
+	}`)
+	writeTheme(t, user, "test-load-replaced.json", `{"colors": {"primary": "#000000"}}`)
+	writeTheme(t, project, "other.json", `{"name": "test-load-replaced", "colors": {"primary": "#ffffff"}}`)
+	writeTheme(t, project, "notes.txt", `not a theme`)
+
+	require.NoError(t, LoadThemes(user, project, filepath.Join(project, "missing")))
+	require.Contains(t, ThemeNames(), "test-load-child")
+	require.NotContains(t, ThemeNames(), "other")
+
+	base, ok := ThemeStyles("test-load-base")
+	require.True(t, ok)
+	require.Equal(t, "#445566", *base.Markdown.CodeBlock.Chroma.Keyword.Color)
+	require.True(t, *base.Markdown.CodeBlock.Chroma.Keyword.Bold)
+
+	child, ok := ThemeStyles("test-load-child")
+	require.True(t, ok)
+	require.Equal(t, "#aabbcc", *hex(child.WorkingGradFromColor))
+	require.Equal(t, "#fafafa", *hex(child.Background))
+	require.Equal(t, "#445566", *child.Markdown.CodeBlock.Chroma.Keyword.Color)
+
+	replaced, ok := ThemeStyles("test-load-replaced")
+	require.True(t, ok)
+	require.Equal(t, "#ffffff", *hex(replaced.WorkingGradFromColor))
+}
+
+func TestLoadThemesErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTheme(t, dir, "test-errors-ok.json", `{"colors": {"primary": "#123456"}}`)
+	writeTheme(t, dir, "test-errors-json.json", `{`)
+	writeTheme(t, dir, "test-errors-role.json", `{"colors": {"nope": "#123456"}}`)
+	writeTheme(t, dir, "test-errors-color.json", `{"colors": {"primary": "red"}}`)
+	writeTheme(t, dir, "test-errors-token.json", `{"syntax": {"nope": "#123456"}}`)
+	writeTheme(t, dir, "test-errors-style.json", `{"syntax": {"keyword": "#123456 blink"}}`)
+	writeTheme(t, dir, "test-errors-extends.json", `{"extends": "nope"}`)
+	writeTheme(t, dir, "test-errors-cycle-a.json", `{"extends": "test-errors-cycle-b"}`)
+	writeTheme(t, dir, "test-errors-cycle-b.json", `{"extends": "test-errors-cycle-a"}`)
+
+	err := LoadThemes(dir)
+	require.ErrorContains(t, err, "test-errors-json.json")
+	require.ErrorContains(t, err, `unknown color "nope"`)
+	require.ErrorContains(t, err, `invalid color "red"`)
+	require.ErrorContains(t, err, `unknown syntax token "nope"`)
+	require.ErrorContains(t, err, `invalid color "blink"`)
+	require.ErrorContains(t, err, `unknown theme "nope"`)
+	require.ErrorContains(t, err, `unknown theme "test-errors-cycle-a"`)
+
+	names := ThemeNames()
+	require.Contains(t, names, "test-errors-ok")
+	for _, name := range []string{"test-errors-json", "test-errors-role", "test-errors-color", "test-errors-extends", "test-errors-cycle-a"} {
+		require.NotContains(t, names, name)
+	}
+}
+
+func TestParseSyntaxStyle(t *testing.T) {
+	t.Parallel()
+
+	prim, err := parseSyntaxStyle("#112233 bg:#445566 italic underline")
+	require.NoError(t, err)
+	require.Equal(t, "#112233", *prim.Color)
+	require.Equal(t, "#445566", *prim.BackgroundColor)
+	require.True(t, *prim.Italic)
+	require.True(t, *prim.Underline)
+	require.Nil(t, prim.Bold)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent"
 	mcptools "github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
@@ -89,6 +90,14 @@ func (w *AppWorkspace) ListAllUserMessages(ctx context.Context) ([]message.Messa
 	return w.app.Messages.ListAllUserMessages(ctx)
 }
 
+func (w *AppWorkspace) SearchMessages(ctx context.Context, query string, limit int) ([]message.SearchResult, error) {
+	// Flush so messages still streaming in are searched as shown.
+	if err := w.app.Messages.FlushAll(ctx); err != nil {
+		return nil, err
+	}
+	return w.app.Messages.Search(ctx, query, limit)
+}
+
 func (w *AppWorkspace) MessageDelete(ctx context.Context, id string) error {
 	return w.app.Messages.Delete(ctx, id)
 }
@@ -239,6 +248,28 @@ func (w *AppWorkspace) ListSessionHistory(ctx context.Context, sessionID string)
 	return w.app.History.ListBySession(ctx, sessionID)
 }
 
+func (w *AppWorkspace) RewindSession(ctx context.Context, sessionID, target string, opts history.RewindOptions) (history.RewindResult, error) {
+	return w.app.RewindSession(ctx, sessionID, target, opts)
+}
+
+func (w *AppWorkspace) ForkSession(ctx context.Context, sessionID, target string) (session.Session, error) {
+	return w.app.ForkSession(ctx, sessionID, target)
+}
+
+// -- Checkpoints --
+
+func (w *AppWorkspace) ListCheckpoints(ctx context.Context, sessionID string) ([]checkpoint.Checkpoint, error) {
+	return w.app.ListCheckpoints(ctx, sessionID)
+}
+
+func (w *AppWorkspace) CheckpointDiff(ctx context.Context, sessionID string, n int, stat bool) (string, error) {
+	return w.app.CheckpointDiff(ctx, sessionID, n, stat)
+}
+
+func (w *AppWorkspace) RestoreCheckpoint(ctx context.Context, sessionID string, n int) ([]checkpoint.Change, error) {
+	return w.app.RestoreCheckpoint(ctx, sessionID, n)
+}
+
 // -- LSP --
 
 func (w *AppWorkspace) LSPStart(ctx context.Context, path string) {
@@ -354,6 +385,10 @@ func (w *AppWorkspace) MCPRefreshResources(ctx context.Context, name string) {
 	mcptools.RefreshResources(ctx, name)
 }
 
+func (w *AppWorkspace) MCPAnswerElicitation(id string, resp mcptools.ElicitationResponse) error {
+	return mcptools.AnswerElicitation(id, resp)
+}
+
 func (w *AppWorkspace) RefreshMCPTools(ctx context.Context, name string) {
 	mcptools.RefreshTools(ctx, w.store, name)
 }
@@ -413,6 +448,21 @@ func (w *AppWorkspace) Subscribe(program *tea.Program) {
 	w.app.Subscribe(program)
 }
 
+func (w *AppWorkspace) Events(ctx context.Context) <-chan tea.Msg {
+	ch := make(chan tea.Msg)
+	go func() {
+		defer close(ch)
+		for ev := range w.app.Events(ctx) {
+			select {
+			case ch <- ev.Payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 func (w *AppWorkspace) Shutdown() {
 	w.app.Shutdown()
 }
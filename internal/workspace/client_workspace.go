@@ -12,6 +12,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/client"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
@@ -169,6 +170,26 @@ func (w *ClientWorkspace) ListAllUserMessages(ctx context.Context) ([]message.Me
 	return protoToMessages(msgs), nil
 }
 
+func (w *ClientWorkspace) SearchMessages(ctx context.Context, query string, limit int) ([]message.SearchResult, error) {
+	results, err := w.client.SearchMessages(ctx, w.workspaceID(), query, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]message.SearchResult, len(results))
+	for i, r := range results {
+		out[i] = message.SearchResult{
+			MessageID:    r.MessageID,
+			SessionID:    r.SessionID,
+			SessionTitle: r.SessionTitle,
+			Role:         message.MessageRole(r.Role),
+			CreatedAt:    r.CreatedAt,
+			Snippet:      r.Snippet,
+			Matches:      r.Matches,
+		}
+	}
+	return out, nil
+}
+
 // -- Agent --
 
 func (w *ClientWorkspace) AgentRun(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) error {
@@ -377,6 +398,65 @@ func (w *ClientWorkspace) ListSessionHistory(ctx context.Context, sessionID stri
 	return protoToFiles(files), nil
 }
 
+func (w *ClientWorkspace) RewindSession(ctx context.Context, sessionID, target string, opts history.RewindOptions) (history.RewindResult, error) {
+	result, err := w.client.RewindSession(ctx, w.workspaceID(), sessionID, proto.SessionRewindRequest{
+		MessageID: target,
+		Force:     opts.Force,
+		DryRun:    opts.DryRun,
+	})
+	if result == nil {
+		return history.RewindResult{}, err
+	}
+	return protoToRewindResult(*result), err
+}
+
+func (w *ClientWorkspace) ForkSession(ctx context.Context, sessionID, target string) (session.Session, error) {
+	sess, err := w.client.ForkSession(ctx, w.workspaceID(), sessionID, proto.SessionForkRequest{
+		MessageID: target,
+	})
+	if err != nil {
+		return session.Session{}, err
+	}
+	return protoToSession(*sess), nil
+}
+
+// -- Checkpoints --
+
+func (w *ClientWorkspace) ListCheckpoints(ctx context.Context, sessionID string) ([]checkpoint.Checkpoint, error) {
+	checkpoints, err := w.client.ListCheckpoints(ctx, w.workspaceID(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]checkpoint.Checkpoint, len(checkpoints))
+	for i, cp := range checkpoints {
+		out[i] = checkpoint.Checkpoint{
+			Number:    cp.Number,
+			SessionID: cp.SessionID,
+			Commit:    cp.Commit,
+			Prompt:    cp.Prompt,
+			Dir:       cp.Dir,
+			CreatedAt: cp.CreatedAt,
+		}
+	}
+	return out, nil
+}
+
+func (w *ClientWorkspace) CheckpointDiff(ctx context.Context, sessionID string, n int, stat bool) (string, error) {
+	return w.client.CheckpointDiff(ctx, w.workspaceID(), sessionID, n, stat)
+}
+
+func (w *ClientWorkspace) RestoreCheckpoint(ctx context.Context, sessionID string, n int) ([]checkpoint.Change, error) {
+	changes, err := w.client.RestoreCheckpoint(ctx, w.workspaceID(), sessionID, n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]checkpoint.Change, len(changes))
+	for i, c := range changes {
+		out[i] = checkpoint.Change{Path: c.Path, Deleted: c.Deleted}
+	}
+	return out, nil
+}
+
 // -- LSP --
 
 func (w *ClientWorkspace) LSPStart(ctx context.Context, path string) {
@@ -580,6 +660,13 @@ func (w *ClientWorkspace) MCPRefreshResources(ctx context.Context, name string)
 	_ = w.client.MCPRefreshResources(ctx, w.workspaceID(), name)
 }
 
+func (w *ClientWorkspace) MCPAnswerElicitation(id string, resp mcp.ElicitationResponse) error {
+	return w.client.AnswerMCPElicitation(context.Background(), w.workspaceID(), id, proto.MCPElicitationResponse{
+		Action:  resp.Action,
+		Content: resp.Content,
+	})
+}
+
 func (w *ClientWorkspace) RefreshMCPTools(ctx context.Context, name string) {
 	_ = w.client.RefreshMCPTools(ctx, w.workspaceID(), name)
 }
@@ -635,6 +722,31 @@ func (w *ClientWorkspace) Subscribe(program *tea.Program) {
 	}
 }
 
+func (w *ClientWorkspace) Events(ctx context.Context) <-chan tea.Msg {
+	ch := make(chan tea.Msg)
+	evc, err := w.client.SubscribeEvents(ctx, w.workspaceID())
+	if err != nil {
+		slog.Error("Failed to subscribe to events", "error", err)
+		close(ch)
+		return ch
+	}
+	go func() {
+		defer close(ch)
+		for ev := range evc {
+			translated := w.translateEvent(ev)
+			if translated == nil {
+				continue
+			}
+			select {
+			case ch <- translated:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 func (w *ClientWorkspace) Shutdown() {
 	_ = w.client.DeleteWorkspace(context.Background(), w.workspaceID())
 }
@@ -671,6 +783,23 @@ func (w *ClientWorkspace) translateEvent(ev any) tea.Msg {
 				},
 			},
 		}
+	case pubsub.Event[proto.MCPElicitation]:
+		return pubsub.Event[mcp.Elicitation]{
+			Type:    e.Type,
+			Payload: protoToMCPElicitation(e.Payload),
+		}
+	case pubsub.Event[proto.MCPProgress]:
+		return pubsub.Event[mcp.Progress]{
+			Type: e.Type,
+			Payload: mcp.Progress{
+				Name:       e.Payload.Name,
+				SessionID:  e.Payload.SessionID,
+				ToolCallID: e.Payload.ToolCallID,
+				Progress:   e.Payload.Progress,
+				Total:      e.Payload.Total,
+				Message:    e.Payload.Message,
+			},
+		}
 	case pubsub.Event[proto.PermissionRequest]:
 		return pubsub.Event[permission.PermissionRequest]{
 			Type: e.Type,
@@ -683,6 +812,7 @@ func (w *ClientWorkspace) translateEvent(ev any) tea.Msg {
 				Action:      e.Payload.Action,
 				Path:        e.Payload.Path,
 				Params:      e.Payload.Params,
+				Rule:        e.Payload.Rule,
 			},
 		}
 	case pubsub.Event[proto.PermissionNotification]:
@@ -716,6 +846,7 @@ func (w *ClientWorkspace) translateEvent(ev any) tea.Msg {
 				SessionID:    e.Payload.SessionID,
 				SessionTitle: e.Payload.SessionTitle,
 				Type:         notify.Type(e.Payload.Type),
+				Details:      e.Payload.Details,
 			},
 		}
 	case pubsub.Event[proto.SkillsEvent]:
@@ -748,6 +879,31 @@ func protoToMCPEventType(t proto.MCPEventType) mcp.EventType {
 	}
 }
 
+func protoToMCPElicitation(e proto.MCPElicitation) mcp.Elicitation {
+	fields := make([]mcp.ElicitationField, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = mcp.ElicitationField{
+			Name:         f.Name,
+			Title:        f.Title,
+			Description:  f.Description,
+			Type:         f.Type,
+			Format:       f.Format,
+			Required:     f.Required,
+			Options:      f.Options,
+			OptionTitles: f.OptionTitles,
+			Default:      f.Default,
+		}
+	}
+	return mcp.Elicitation{
+		ID:         e.ID,
+		Name:       e.Name,
+		SessionID:  e.SessionID,
+		ToolCallID: e.ToolCallID,
+		Message:    e.Message,
+		Fields:     fields,
+	}
+}
+
 func protoToSession(s proto.Session) session.Session {
 	return session.Session{
 		ID:               s.ID,
@@ -761,6 +917,9 @@ func protoToSession(s proto.Session) session.Session {
 		Todos:            protoToTodos(s.Todos),
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
+
+		ForkedFromSessionID: s.ForkedFromSessionID,
+		ForkedAtMessageID:   s.ForkedAtMessageID,
 	}
 }
 
@@ -855,6 +1014,21 @@ func protoToMessages(msgs []proto.Message) []message.Message {
 	return out
 }
 
+func protoToRewindResult(r proto.SessionRewindResult) history.RewindResult {
+	out := history.RewindResult{
+		Changes: make([]history.RewindChange, len(r.Changes)),
+		Applied: r.Applied,
+	}
+	for i, c := range r.Changes {
+		out.Changes[i] = history.RewindChange{
+			Path:     c.Path,
+			Action:   history.RewindAction(c.Action),
+			Conflict: c.Conflict,
+		}
+	}
+	return out
+}
+
 func protoToFiles(files []proto.File) []history.File {
 	out := make([]history.File, len(files))
 	for i, f := range files {
@@ -876,6 +1050,9 @@ func sessionToProto(s session.Session) proto.Session {
 		Todos:            todosToProto(s.Todos),
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
+
+		ForkedFromSessionID: s.ForkedFromSessionID,
+		ForkedAtMessageID:   s.ForkedAtMessageID,
 	}
 }
 
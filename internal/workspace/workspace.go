@@ -11,6 +11,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
 	mcptools "github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/history"
@@ -74,6 +75,7 @@ type Workspace interface {
 	ListMessages(ctx context.Context, sessionID string) ([]message.Message, error)
 	ListUserMessages(ctx context.Context, sessionID string) ([]message.Message, error)
 	ListAllUserMessages(ctx context.Context) ([]message.Message, error)
+	SearchMessages(ctx context.Context, query string, limit int) ([]message.SearchResult, error)
 	MessageDelete(ctx context.Context, id string) error
 
 	// Agent
@@ -110,6 +112,13 @@ type Workspace interface {
 
 	// History
 	ListSessionHistory(ctx context.Context, sessionID string) ([]history.File, error)
+	RewindSession(ctx context.Context, sessionID, target string, opts history.RewindOptions) (history.RewindResult, error)
+	ForkSession(ctx context.Context, sessionID, target string) (session.Session, error)
+
+	// Checkpoints
+	ListCheckpoints(ctx context.Context, sessionID string) ([]checkpoint.Checkpoint, error)
+	CheckpointDiff(ctx context.Context, sessionID string, n int, stat bool) (string, error)
+	RestoreCheckpoint(ctx context.Context, sessionID string, n int) ([]checkpoint.Change, error)
 
 	// LSP
 	LSPStart(ctx context.Context, path string)
@@ -142,6 +151,7 @@ type Workspace interface {
 	MCPGetStates() map[string]mcptools.ClientInfo
 	MCPRefreshPrompts(ctx context.Context, name string)
 	MCPRefreshResources(ctx context.Context, name string)
+	MCPAnswerElicitation(id string, resp mcptools.ElicitationResponse) error
 	RefreshMCPTools(ctx context.Context, name string)
 	ReadMCPResource(ctx context.Context, name, uri string) ([]MCPResourceContents, error)
 	GetMCPPrompt(clientID, promptID string, args map[string]string) (string, error)
@@ -150,6 +160,9 @@ type Workspace interface {
 
 	// Events
 	Subscribe(program *tea.Program)
+	// Events returns the events Subscribe sends to the TUI, for other
+	// frontends. The channel is closed once ctx is done.
+	Events(ctx context.Context) <-chan tea.Msg
 	Shutdown()
 }
 
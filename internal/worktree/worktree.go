@@ -0,0 +1,278 @@
+// Package worktree gives sessions git worktrees of their own, so sessions
+// that run at the same time don't edit the same files.
+//
+// A session's worktree is checked out on a dedicated branch named after the
+// session, in a directory outside of the repository. The work done in it
+// can be diffed against the branch it started from, merged back into the
+// repository's current branch, or discarded.
+package worktree
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/zeebo/xxh3"
+)
+
+// BranchPrefix is prepended to the name of a session's branch.
+const BranchPrefix = "crush/"
+
+var (
+	// ErrNotRepository is returned by [New] when the working directory is
+	// not inside a git working tree.
+	ErrNotRepository = errors.New("not inside a git working tree")
+	// ErrNotFound is returned when a session has no worktree.
+	ErrNotFound = errors.New("session has no worktree")
+)
+
+// Worktree is the git worktree of a session.
+type Worktree struct {
+	// Path is the directory the worktree is checked out in.
+	Path string
+	// Branch is the branch checked out in the worktree.
+	Branch string
+	// GitDir is the repository's common git directory. Commands run in the
+	// worktree write objects and refs there.
+	GitDir string
+}
+
+// WritableGitPaths returns the parts of the repository's git directory
+// that git commands run in the worktree write to: the worktree's own git
+// directory, and the objects, refs, and reflogs shared with the
+// repository. Its hooks and config are left out, as writing them would
+// run code outside of the worktree.
+func (wt Worktree) WritableGitPaths() []string {
+	var paths []string
+	for _, path := range []string{
+		filepath.Join(wt.GitDir, "worktrees", filepath.Base(wt.Path)),
+		filepath.Join(wt.GitDir, "objects"),
+		filepath.Join(wt.GitDir, "refs"),
+		filepath.Join(wt.GitDir, "logs"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Manager creates and removes the session worktrees of a repository.
+type Manager struct {
+	repo   string
+	gitDir string
+	dir    string
+	mu     sync.Mutex
+}
+
+// New returns a Manager for the repository containing workingDir. Worktrees
+// are created in a directory of their own for the repository under dir.
+func New(ctx context.Context, workingDir, dir string) (*Manager, error) {
+	out, err := git(ctx, workingDir, "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir")
+	if err != nil {
+		return nil, ErrNotRepository
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || lines[0] == "" {
+		return nil, ErrNotRepository
+	}
+	repo := filepath.Clean(lines[0])
+	return &Manager{
+		repo:   repo,
+		gitDir: filepath.Clean(lines[1]),
+		dir:    filepath.Join(dir, fmt.Sprintf("%s-%x", filepath.Base(repo), xxh3.HashString(repo))),
+	}, nil
+}
+
+// Repository returns the root of the working tree sessions merge into.
+func (m *Manager) Repository() string {
+	return m.repo
+}
+
+// Name returns the name of a session's worktree and branch, which is the
+// prefix of the session's hash shown by "crush session list".
+func Name(sessionID string) string {
+	return session.HashID(sessionID)[:12]
+}
+
+// Branch returns the name of a session's branch.
+func Branch(sessionID string) string {
+	return BranchPrefix + Name(sessionID)
+}
+
+func (m *Manager) worktree(sessionID string) Worktree {
+	return Worktree{
+		Path:   filepath.Join(m.dir, Name(sessionID)),
+		Branch: Branch(sessionID),
+		GitDir: m.gitDir,
+	}
+}
+
+// Get returns the worktree of a session, or [ErrNotFound] if it has none.
+func (m *Manager) Get(sessionID string) (Worktree, error) {
+	wt := m.worktree(sessionID)
+	if _, err := os.Stat(filepath.Join(wt.Path, ".git")); err != nil {
+		return Worktree{}, ErrNotFound
+	}
+	return wt, nil
+}
+
+// Ensure returns the worktree of a session, creating it and its branch from
+// the repository's HEAD if needed.
+func (m *Manager) Ensure(ctx context.Context, sessionID string) (Worktree, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if wt, err := m.Get(sessionID); err == nil {
+		return wt, nil
+	}
+
+	wt := m.worktree(sessionID)
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return Worktree{}, fmt.Errorf("failed to create worktrees directory: %w", err)
+	}
+	// Forget worktrees whose directories were deleted by hand, which
+	// would otherwise keep their branches checked out.
+	if _, err := git(ctx, m.repo, "worktree", "prune"); err != nil {
+		return Worktree{}, err
+	}
+	args := []string{"worktree", "add", wt.Path, wt.Branch}
+	if !m.hasBranch(ctx, wt.Branch) {
+		args = []string{"worktree", "add", "-b", wt.Branch, wt.Path, "HEAD"}
+	}
+	if _, err := git(ctx, m.repo, args...); err != nil {
+		return Worktree{}, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	return wt, nil
+}
+
+// Diff returns the changes made in a session's worktree since its branch
+// started from the repository's current branch, including changes that
+// were not committed yet. With stat, a diffstat is returned instead of a
+// patch.
+func (m *Manager) Diff(ctx context.Context, sessionID string, stat bool) (string, error) {
+	wt, err := m.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	base, err := git(ctx, m.repo, "merge-base", "HEAD", wt.Branch)
+	if err != nil {
+		return "", err
+	}
+
+	// Stage everything in a throwaway index, so untracked files show up
+	// without touching the worktree's own index.
+	tmp, err := os.MkdirTemp("", "crush-worktree-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	if _, err := gitEnv(ctx, wt.Path, env, "read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := gitEnv(ctx, wt.Path, env, "add", "--all"); err != nil {
+		return "", err
+	}
+	args := []string{"diff", "--cached"}
+	if stat {
+		args = append(args, "--stat")
+	}
+	return gitEnv(ctx, wt.Path, env, append(args, strings.TrimSpace(base))...)
+}
+
+// Merge commits any pending changes in a session's worktree with message
+// and merges its branch into the repository's current branch. The worktree
+// and its branch are removed once merged; the session gets a new one from
+// the merged HEAD the next time it runs. If the merge fails, it is aborted
+// and the worktree is kept.
+func (m *Manager) Merge(ctx context.Context, sessionID, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wt, err := m.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	status, err := git(ctx, wt.Path, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) != "" {
+		if _, err := git(ctx, wt.Path, "add", "--all"); err != nil {
+			return err
+		}
+		if _, err := git(ctx, wt.Path, "commit", "--no-verify", "--message", message); err != nil {
+			return fmt.Errorf("failed to commit worktree changes: %w", err)
+		}
+	}
+	if _, err := git(ctx, m.repo, "merge", "--no-ff", "--no-edit", wt.Branch); err != nil {
+		_, _ = git(ctx, m.repo, "merge", "--abort")
+		return fmt.Errorf("failed to merge %s: %w", wt.Branch, err)
+	}
+	return m.remove(ctx, wt)
+}
+
+// Discard removes a session's worktree and deletes its branch, along with
+// any work in them that was not merged.
+func (m *Manager) Discard(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wt := m.worktree(sessionID)
+	if _, err := m.Get(sessionID); err != nil && !m.hasBranch(ctx, wt.Branch) {
+		return err
+	}
+	return m.remove(ctx, wt)
+}
+
+func (m *Manager) remove(ctx context.Context, wt Worktree) error {
+	if _, err := os.Stat(wt.Path); err == nil {
+		if _, err := git(ctx, m.repo, "worktree", "remove", "--force", wt.Path); err != nil {
+			return fmt.Errorf("failed to remove worktree: %w", err)
+		}
+	}
+	if _, err := git(ctx, m.repo, "worktree", "prune"); err != nil {
+		return err
+	}
+	if m.hasBranch(ctx, wt.Branch) {
+		if _, err := git(ctx, m.repo, "branch", "-D", wt.Branch); err != nil {
+			return fmt.Errorf("failed to delete branch: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) hasBranch(ctx context.Context, branch string) bool {
+	_, err := git(ctx, m.repo, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitEnv(ctx, dir, nil, args...)
+}
+
+func gitEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(cmp.Or(stderr.String(), stdout.String()))
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,138 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(k+"_NAME", "Crush")
+		t.Setenv(k+"_EMAIL", "crush@charm.land")
+	}
+	repo := t.TempDir()
+	run(t, repo, "init", "--initial-branch=main")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644))
+	run(t, repo, "add", "main.go")
+	run(t, repo, "commit", "--message", "initial")
+	return repo
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(t.Context(), dir, args...)
+	require.NoError(t, err)
+	return out
+}
+
+func TestWorktree(t *testing.T) {
+	repo := newRepo(t)
+	m, err := New(t.Context(), repo, t.TempDir())
+	require.NoError(t, err)
+
+	_, err = m.Get("session")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.Equal(t, Branch("session"), wt.Branch)
+	require.FileExists(t, filepath.Join(wt.Path, "main.go"))
+	require.Equal(t, wt.Branch+"\n", run(t, wt.Path, "branch", "--show-current"))
+
+	again, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.Equal(t, wt, again)
+
+	// Uncommitted and untracked changes show up in the diff, and nothing
+	// leaks into the repository's own working tree.
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "new.go"), []byte("package main\n"), 0o644))
+	diff, err := m.Diff(t.Context(), "session", false)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+func main() {}")
+	require.Contains(t, diff, "new file mode")
+	stat, err := m.Diff(t.Context(), "session", true)
+	require.NoError(t, err)
+	require.Contains(t, stat, "2 files changed")
+	require.Empty(t, run(t, wt.Path, "diff", "--cached", "--name-only"))
+	require.NoFileExists(t, filepath.Join(repo, "new.go"))
+
+	require.NoError(t, m.Merge(t.Context(), "session", "Add main"))
+	require.FileExists(t, filepath.Join(repo, "new.go"))
+	require.NoDirExists(t, wt.Path)
+	require.False(t, m.hasBranch(t.Context(), wt.Branch))
+
+	_, err = m.Get("session")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestWorktreeDiscard(t *testing.T) {
+	repo := newRepo(t)
+	m, err := New(t.Context(), repo, t.TempDir())
+	require.NoError(t, err)
+
+	require.ErrorIs(t, m.Discard(t.Context(), "session"), ErrNotFound)
+
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "new.go"), []byte("package main\n"), 0o644))
+
+	require.NoError(t, m.Discard(t.Context(), "session"))
+	require.NoDirExists(t, wt.Path)
+	require.False(t, m.hasBranch(t.Context(), wt.Branch))
+	require.NoFileExists(t, filepath.Join(repo, "new.go"))
+}
+
+func TestWorktreeMergeConflict(t *testing.T) {
+	repo := newRepo(t)
+	m, err := New(t.Context(), repo, t.TempDir())
+	require.NoError(t, err)
+
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "main.go"), []byte("package session\n"), 0o644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("package repo\n"), 0o644))
+	run(t, repo, "commit", "--all", "--message", "change package")
+
+	require.Error(t, m.Merge(t.Context(), "session", "Change package"))
+	// The merge was aborted, and the session's work is kept.
+	require.Empty(t, run(t, repo, "status", "--porcelain"))
+	_, err = m.Get("session")
+	require.NoError(t, err)
+}
+
+func TestWorktreeWritableGitPaths(t *testing.T) {
+	repo := newRepo(t)
+	m, err := New(t.Context(), repo, t.TempDir())
+	require.NoError(t, err)
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+
+	paths := wt.WritableGitPaths()
+	gitDir := strings.TrimSpace(run(t, wt.Path, "rev-parse", "--path-format=absolute", "--git-dir"))
+	require.Contains(t, paths, gitDir)
+	require.Contains(t, paths, filepath.Join(wt.GitDir, "objects"))
+	require.Contains(t, paths, filepath.Join(wt.GitDir, "refs"))
+	for _, path := range paths {
+		require.NotEqual(t, wt.GitDir, path)
+		require.NotContains(t, []string{"hooks", "config"}, filepath.Base(path))
+	}
+}
+
+func TestNewNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := New(t.Context(), t.TempDir(), t.TempDir())
+	require.ErrorIs(t, err, ErrNotRepository)
+}